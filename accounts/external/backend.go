@@ -0,0 +1,304 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package external implements an account backend delegating all signing to an
+// external signer process, such as clef, over JSON-RPC.
+//
+// The node keeps no keys of its own. It talks to the signer over IPC (or HTTP
+// and websocket) using the following methods of the signer's external API:
+//
+//	account_version                          returns the external API version
+//	account_list                             returns the accounts available
+//	account_signTransaction(tx)              signs a transaction, returns {raw, tx}
+//	account_signHash(address, hash, valid)   signs a raw 32 byte hash
+//
+// The signer is expected to do its own authorization, so passphrases supplied
+// locally are never forwarded.
+package external
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	matrix "github.com/matrix/go-matrix"
+	"github.com/matrix/go-matrix/accounts"
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/common/hexutil"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/event"
+	"github.com/matrix/go-matrix/log"
+	"github.com/matrix/go-matrix/rlp"
+	"github.com/matrix/go-matrix/rpc"
+)
+
+// Scheme is the protocol scheme prefixing the external signer's URL.
+const Scheme = "extapi"
+
+// versionTimeout is the time allowed to the signer to report its version.
+// Other requests may need user approval and are thus not time limited.
+const versionTimeout = 10 * time.Second
+
+var (
+	// errNotSupported is returned for wallet operations that only make sense
+	// for locally managed keys.
+	errNotSupported = errors.New("operation not supported on external signers")
+
+	// errExtraNotSupported is returned for transactions carrying Matrix
+	// specific extras, which the signing protocol can not express.
+	errExtraNotSupported = errors.New("transactions with extra recipients or types not supported by external signers")
+)
+
+// ExternalBackend is an accounts.Backend exposing a single external signer.
+type ExternalBackend struct {
+	signers []accounts.Wallet
+}
+
+// NewExternalBackend connects to the external signer listening on endpoint.
+func NewExternalBackend(endpoint string) (*ExternalBackend, error) {
+	signer, err := NewExternalSigner(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &ExternalBackend{signers: []accounts.Wallet{signer}}, nil
+}
+
+// Wallets implements accounts.Backend, returning the external signer.
+func (eb *ExternalBackend) Wallets() []accounts.Wallet {
+	return eb.signers
+}
+
+// Subscribe implements accounts.Backend. The external signer never arrives or
+// departs, so no events are ever delivered.
+func (eb *ExternalBackend) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
+// ExternalSigner is an accounts.Wallet backed by an external signer process.
+type ExternalSigner struct {
+	client   *rpc.Client
+	endpoint string
+
+	lock     sync.RWMutex
+	accounts []accounts.Account // Accounts last reported by the signer
+}
+
+// NewExternalSigner dials the external signer listening on endpoint and
+// checks that it speaks the signing protocol.
+func NewExternalSigner(endpoint string) (*ExternalSigner, error) {
+	client, err := rpc.Dial(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := newExternalSigner(client, endpoint)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	return signer, nil
+}
+
+// newExternalSigner creates a signer on top of an established connection.
+func newExternalSigner(client *rpc.Client, endpoint string) (*ExternalSigner, error) {
+	signer := &ExternalSigner{client: client, endpoint: endpoint}
+
+	version, err := signer.version()
+	if err != nil {
+		return nil, fmt.Errorf("external signer not responding: %v", err)
+	}
+	log.Info("Connected to external signer", "url", endpoint, "version", version)
+	return signer, nil
+}
+
+// URL implements accounts.Wallet, returning the signer's endpoint.
+func (api *ExternalSigner) URL() accounts.URL {
+	return accounts.URL{Scheme: Scheme, Path: api.endpoint}
+}
+
+// Status implements accounts.Wallet, returning the signer's API version.
+func (api *ExternalSigner) Status() (string, error) {
+	version, err := api.version()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("ok [version=%v]", version), nil
+}
+
+// Open implements accounts.Wallet. The external signer is always open.
+func (api *ExternalSigner) Open(passphrase string) error {
+	return errNotSupported
+}
+
+// Close implements accounts.Wallet, disconnecting from the signer.
+func (api *ExternalSigner) Close() error {
+	api.client.Close()
+	return nil
+}
+
+// Accounts implements accounts.Wallet, returning the accounts reported by the
+// signer. If the signer can't be reached, the last known accounts are returned.
+func (api *ExternalSigner) Accounts() []accounts.Account {
+	var res []struct {
+		Address common.Address `json:"address"`
+	}
+	if err := api.client.Call(&res, "account_list"); err != nil {
+		log.Warn("Failed to list external signer accounts", "url", api.endpoint, "err", err)
+
+		api.lock.RLock()
+		defer api.lock.RUnlock()
+		return api.accounts
+	}
+	accs := make([]accounts.Account, len(res))
+	for i, acc := range res {
+		accs[i] = accounts.Account{Address: acc.Address, URL: api.URL()}
+	}
+	api.lock.Lock()
+	api.accounts = accs
+	api.lock.Unlock()
+
+	return accs
+}
+
+// Contains implements accounts.Wallet, returning whether the signer manages
+// the given account.
+func (api *ExternalSigner) Contains(account accounts.Account) bool {
+	api.lock.RLock()
+	accs := api.accounts
+	api.lock.RUnlock()
+
+	if accs == nil {
+		accs = api.Accounts()
+	}
+	for _, acc := range accs {
+		if acc.Address == account.Address && (account.URL == (accounts.URL{}) || account.URL == api.URL()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Derive implements accounts.Wallet, but is not supported by external signers.
+func (api *ExternalSigner) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	return accounts.Account{}, errNotSupported
+}
+
+// SelfDerive implements accounts.Wallet, but is a noop for external signers.
+func (api *ExternalSigner) SelfDerive(base accounts.DerivationPath, chain matrix.ChainStateReader) {
+}
+
+// SignHash implements accounts.Wallet, requesting the signer to sign the hash.
+func (api *ExternalSigner) SignHash(account accounts.Account, hash []byte) ([]byte, error) {
+	return api.SignHashValidate(account, hash, true)
+}
+
+// SignHashValidate implements accounts.Wallet, requesting the signer to sign
+// the hash, optionally with a Matrix non-validating signature.
+func (api *ExternalSigner) SignHashValidate(account accounts.Account, hash []byte, validate bool) ([]byte, error) {
+	var res hexutil.Bytes
+	if err := api.client.Call(&res, "account_signHash", account.Address, hexutil.Bytes(hash), validate); err != nil {
+		return nil, err
+	}
+	if len(res) != 65 {
+		return nil, fmt.Errorf("invalid signature length %d from external signer", len(res))
+	}
+	return res, nil
+}
+
+// SignTx implements accounts.Wallet, requesting the signer to sign the
+// transaction. The signed transaction is checked to be sent from account on
+// the requested chain, since the signer may let the user amend it.
+func (api *ExternalSigner) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	if len(tx.GetMatrix_EX()) > 0 {
+		return nil, errExtraNotSupported
+	}
+	args := &signTxArgs{
+		From:     account.Address,
+		To:       tx.To(),
+		Gas:      hexutil.Uint64(tx.Gas()),
+		GasPrice: (*hexutil.Big)(tx.GasPrice()),
+		Value:    (*hexutil.Big)(tx.Value()),
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+		Data:     hexutil.Bytes(tx.Data()),
+	}
+	var res struct {
+		Raw hexutil.Bytes `json:"raw"`
+	}
+	if err := api.client.Call(&res, "account_signTransaction", args); err != nil {
+		return nil, err
+	}
+	signed := new(types.Transaction)
+	if err := rlp.DecodeBytes(res.Raw, signed); err != nil {
+		return nil, fmt.Errorf("invalid transaction from external signer: %v", err)
+	}
+	if chainID != nil && signed.ChainId().Cmp(chainID) != 0 {
+		return nil, fmt.Errorf("external signer signed for chain %v, want %v", signed.ChainId(), chainID)
+	}
+	sender, err := types.Sender(types.NewEIP155Signer(signed.ChainId()), signed)
+	if err != nil {
+		return nil, err
+	}
+	if sender != account.Address {
+		return nil, fmt.Errorf("external signer signed as %x, want %x", sender, account.Address)
+	}
+	return signed, nil
+}
+
+// SignHashWithPassphrase implements accounts.Wallet. The passphrase is not
+// forwarded, the signer authorizes requests by its own means.
+func (api *ExternalSigner) SignHashWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
+	return api.SignHash(account, hash)
+}
+
+// SignTxWithPassphrase implements accounts.Wallet. The passphrase is not
+// forwarded, the signer authorizes requests by its own means.
+func (api *ExternalSigner) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return api.SignTx(account, tx, chainID)
+}
+
+// SignHashValidateWithPass implements accounts.Wallet. The passphrase is not
+// forwarded, the signer authorizes requests by its own means.
+func (api *ExternalSigner) SignHashValidateWithPass(account accounts.Account, passphrase string, hash []byte, validate bool) ([]byte, error) {
+	return api.SignHashValidate(account, hash, validate)
+}
+
+// version retrieves the external API version of the signer.
+func (api *ExternalSigner) version() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), versionTimeout)
+	defer cancel()
+
+	var version string
+	if err := api.client.CallContext(ctx, &version, "account_version"); err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
+// signTxArgs is the transaction signing request of account_signTransaction.
+type signTxArgs struct {
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to"`
+	Gas      hexutil.Uint64  `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	Value    *hexutil.Big    `json:"value"`
+	Nonce    hexutil.Uint64  `json:"nonce"`
+	Data     hexutil.Bytes   `json:"data"`
+}
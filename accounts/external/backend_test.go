@@ -0,0 +1,167 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package external
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/matrix/go-matrix/accounts"
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/common/hexutil"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/crypto"
+	"github.com/matrix/go-matrix/rlp"
+	"github.com/matrix/go-matrix/rpc"
+)
+
+// TestSigner is a minimal signer serving the external API from a single key.
+type TestSigner struct {
+	key     *ecdsa.PrivateKey
+	chainID *big.Int
+}
+
+func (s *TestSigner) Version(ctx context.Context) (string, error) {
+	return "2.1.0", nil
+}
+
+func (s *TestSigner) List(ctx context.Context) ([]map[string]interface{}, error) {
+	return []map[string]interface{}{{
+		"type":    "Account",
+		"url":     "keystore:///test",
+		"address": crypto.PubkeyToAddress(s.key.PublicKey),
+	}}, nil
+}
+
+func (s *TestSigner) SignHash(ctx context.Context, addr common.MixedcaseAddress, hash hexutil.Bytes, validate *bool) (hexutil.Bytes, error) {
+	return crypto.SignWithValidate(hash, validate == nil || *validate, s.key)
+}
+
+func (s *TestSigner) SignTransaction(ctx context.Context, args TestTxArgs, methodSelector *string) (map[string]interface{}, error) {
+	tx := types.NewTransaction(uint64(args.Nonce), *args.To, (*big.Int)(&args.Value), uint64(args.Gas), (*big.Int)(&args.GasPrice), args.Data)
+	signed, err := types.SignTx(tx, types.NewEIP155Signer(s.chainID), s.key)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := rlp.EncodeToBytes(signed)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"raw": hexutil.Bytes(raw)}, nil
+}
+
+// TestTxArgs is the signing request as decoded by the test signer.
+type TestTxArgs struct {
+	From     common.MixedcaseAddress `json:"from"`
+	To       *common.Address         `json:"to"`
+	Gas      hexutil.Uint64          `json:"gas"`
+	GasPrice hexutil.Big             `json:"gasPrice"`
+	Value    hexutil.Big             `json:"value"`
+	Nonce    hexutil.Uint64          `json:"nonce"`
+	Data     hexutil.Bytes           `json:"data"`
+}
+
+func newTestExternalSigner(t *testing.T, chainID *big.Int) (*ExternalSigner, *ecdsa.PrivateKey) {
+	key, _ := crypto.GenerateKey()
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", &TestSigner{key: key, chainID: chainID}); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := newExternalSigner(rpc.DialInProc(server), "test.ipc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer, key
+}
+
+func TestExternalSignerAccounts(t *testing.T) {
+	signer, key := newTestExternalSigner(t, big.NewInt(1))
+	defer signer.Close()
+
+	if status, err := signer.Status(); err != nil || status != "ok [version=2.1.0]" {
+		t.Errorf("status mismatch: have %q, %v", status, err)
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	accs := signer.Accounts()
+	if len(accs) != 1 || accs[0].Address != address || accs[0].URL != signer.URL() {
+		t.Fatalf("accounts mismatch: have %v, want %x", accs, address)
+	}
+	if !signer.Contains(accounts.Account{Address: address}) {
+		t.Errorf("signer account not contained")
+	}
+	if signer.Contains(accounts.Account{Address: common.Address{1}}) {
+		t.Errorf("unknown account contained")
+	}
+}
+
+func TestExternalSignerSignHash(t *testing.T) {
+	signer, key := newTestExternalSigner(t, big.NewInt(1))
+	defer signer.Close()
+
+	account := accounts.Account{Address: crypto.PubkeyToAddress(key.PublicKey)}
+	hash := crypto.Keccak256([]byte("hello"))
+
+	sig, err := signer.SignHashWithPassphrase(account, "ignored", hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crypto.PubkeyToAddress(*pub) != account.Address {
+		t.Errorf("signature recovers to %x, want %x", crypto.PubkeyToAddress(*pub), account.Address)
+	}
+	sig, err = signer.SignHashValidateWithPass(account, "ignored", hash, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig[64] < 2 {
+		t.Errorf("non-validating signature has V %d", sig[64])
+	}
+}
+
+func TestExternalSignerSignTx(t *testing.T) {
+	signer, key := newTestExternalSigner(t, big.NewInt(1))
+	defer signer.Close()
+
+	account := accounts.Account{Address: crypto.PubkeyToAddress(key.PublicKey)}
+	tx := types.NewTransaction(3, common.Address{0xaa}, big.NewInt(10), 21000, big.NewInt(1), []byte{1, 2})
+
+	signed, err := signer.SignTx(account, tx, big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signed.Nonce() != 3 || *signed.To() != (common.Address{0xaa}) || signed.Value().Cmp(big.NewInt(10)) != 0 {
+		t.Errorf("signed transaction mismatch: %v", signed)
+	}
+	// A signature for the wrong chain or account must be rejected
+	if _, err := signer.SignTx(account, tx, big.NewInt(2)); err == nil {
+		t.Errorf("signature for wrong chain accepted")
+	}
+	if _, err := signer.SignTx(accounts.Account{Address: common.Address{1}}, tx, big.NewInt(1)); err == nil {
+		t.Errorf("signature by wrong account accepted")
+	}
+	// Matrix extras can't be expressed in the signing protocol
+	extra := types.NewTransactions(3, common.Address{0xaa}, big.NewInt(10), 21000, big.NewInt(1), nil, []*types.ExtraTo_tr{{To_tr: &common.Address{0xbb}}}, 0, 0)
+	if _, err := signer.SignTx(account, extra, big.NewInt(1)); err != errExtraNotSupported {
+		t.Errorf("extras error mismatch: have %v, want %v", err, errExtraNotSupported)
+	}
+}
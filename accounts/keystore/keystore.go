@@ -82,8 +82,14 @@ type unlocked struct {
 
 // NewKeyStore creates a keystore for the given directory.
 func NewKeyStore(keydir string, scryptN, scryptP int) *KeyStore {
+	return NewKeyStoreWithKDF(keydir, ScryptKDF(scryptN, scryptP))
+}
+
+// NewKeyStoreWithKDF creates a keystore for the given directory, encrypting new
+// and updated key files with the given key derivation function.
+func NewKeyStoreWithKDF(keydir string, kdf KDFConfig) *KeyStore {
 	keydir, _ = filepath.Abs(keydir)
-	ks := &KeyStore{storage: &keyStorePassphrase{keydir, scryptN, scryptP}}
+	ks := &KeyStore{storage: &keyStorePassphrase{keydir, kdf}}
 	ks.init(keydir)
 	return ks
 }
@@ -485,13 +491,20 @@ func (ks *KeyStore) Export(a accounts.Account, passphrase, newPassphrase string)
 	if err != nil {
 		return nil, err
 	}
-	var N, P int
+	kdf := ScryptKDF(StandardScryptN, StandardScryptP)
 	if store, ok := ks.storage.(*keyStorePassphrase); ok {
-		N, P = store.scryptN, store.scryptP
-	} else {
-		N, P = StandardScryptN, StandardScryptP
+		kdf = store.kdf
+	}
+	return EncryptKeyWithKDF(key, newPassphrase, kdf)
+}
+
+// ExportECDSA decrypts the key of an account and returns its raw private key.
+func (ks *KeyStore) ExportECDSA(a accounts.Account, passphrase string) (*ecdsa.PrivateKey, error) {
+	_, key, err := ks.getDecryptedKey(a, passphrase)
+	if err != nil {
+		return nil, err
 	}
-	return EncryptKey(key, newPassphrase, N, P)
+	return key.PrivateKey, nil
 }
 
 // Import stores the given encrypted JSON key into the key directory.
@@ -41,6 +41,7 @@ import (
 	"github.com/matrix/go-matrix/crypto"
 	"github.com/matrix/go-matrix/crypto/randentropy"
 	"github.com/pborman/uuid"
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/pbkdf2"
 	"golang.org/x/crypto/scrypt"
 )
@@ -48,6 +49,11 @@ import (
 const (
 	keyHeaderKDF = "scrypt"
 
+	// KDFScrypt and KDFArgon2id name the key derivation functions that can be
+	// used to encrypt new key files.
+	KDFScrypt   = keyHeaderKDF
+	KDFArgon2id = "argon2id"
+
 	// StandardScryptN is the N parameter of Scrypt encryption algorithm, using 256MB
 	// memory and taking approximately 1s CPU time on a modern processor.
 	StandardScryptN = 1 << 18
@@ -66,12 +72,46 @@ const (
 
 	scryptR     = 8
 	scryptDKLen = 32
+
+	// StandardArgon2Time, StandardArgon2Memory and StandardArgon2Threads are the
+	// Argon2id parameters matching the 256MB memory cost of standard scrypt.
+	StandardArgon2Time    = 1
+	StandardArgon2Memory  = 256 * 1024 // KiB
+	StandardArgon2Threads = 4
+
+	// LightArgon2Time, LightArgon2Memory and LightArgon2Threads are the Argon2id
+	// parameters matching the 4MB memory cost of light scrypt.
+	LightArgon2Time    = 1
+	LightArgon2Memory  = 4 * 1024 // KiB
+	LightArgon2Threads = 4
 )
 
+// KDFConfig selects the key derivation function and its parameters used when
+// encrypting key files.
+type KDFConfig struct {
+	Name string // Either KDFScrypt or KDFArgon2id
+
+	ScryptN int
+	ScryptP int
+
+	Argon2Time    uint32
+	Argon2Memory  uint32 // Memory cost in KiB
+	Argon2Threads uint8
+}
+
+// ScryptKDF returns a KDF configuration using scrypt with the given parameters.
+func ScryptKDF(scryptN, scryptP int) KDFConfig {
+	return KDFConfig{Name: KDFScrypt, ScryptN: scryptN, ScryptP: scryptP}
+}
+
+// Argon2idKDF returns a KDF configuration using Argon2id with the given parameters.
+func Argon2idKDF(time, memory uint32, threads uint8) KDFConfig {
+	return KDFConfig{Name: KDFArgon2id, Argon2Time: time, Argon2Memory: memory, Argon2Threads: threads}
+}
+
 type keyStorePassphrase struct {
 	keysDirPath string
-	scryptN     int
-	scryptP     int
+	kdf         KDFConfig
 }
 
 func (ks keyStorePassphrase) GetKey(addr common.Address, filename, auth string) (*Key, error) {
@@ -93,12 +133,18 @@ func (ks keyStorePassphrase) GetKey(addr common.Address, filename, auth string)
 
 // StoreKey generates a key, encrypts with 'auth' and stores in the given directory
 func StoreKey(dir, auth string, scryptN, scryptP int) (common.Address, error) {
-	_, a, err := storeNewKey(&keyStorePassphrase{dir, scryptN, scryptP}, crand.Reader, auth)
+	return StoreKeyWithKDF(dir, auth, ScryptKDF(scryptN, scryptP))
+}
+
+// StoreKeyWithKDF generates a key, encrypts it with 'auth' using the given key
+// derivation function and stores it in the given directory.
+func StoreKeyWithKDF(dir, auth string, kdf KDFConfig) (common.Address, error) {
+	_, a, err := storeNewKey(&keyStorePassphrase{dir, kdf}, crand.Reader, auth)
 	return a.Address, err
 }
 
 func (ks keyStorePassphrase) StoreKey(filename string, key *Key, auth string) error {
-	keyjson, err := EncryptKey(key, auth, ks.scryptN, ks.scryptP)
+	keyjson, err := EncryptKeyWithKDF(key, auth, ks.kdf)
 	if err != nil {
 		return err
 	}
@@ -115,9 +161,14 @@ func (ks keyStorePassphrase) JoinPath(filename string) string {
 // EncryptKey encrypts a key using the specified scrypt parameters into a json
 // blob that can be decrypted later on.
 func EncryptKey(key *Key, auth string, scryptN, scryptP int) ([]byte, error) {
-	authArray := []byte(auth)
+	return EncryptKeyWithKDF(key, auth, ScryptKDF(scryptN, scryptP))
+}
+
+// EncryptKeyWithKDF encrypts a key using the specified key derivation function
+// into a json blob that can be decrypted later on.
+func EncryptKeyWithKDF(key *Key, auth string, kdf KDFConfig) ([]byte, error) {
 	salt := randentropy.GetEntropyCSPRNG(32)
-	derivedKey, err := scrypt.Key(authArray, salt, scryptN, scryptR, scryptP, scryptDKLen)
+	derivedKey, kdfParamsJSON, err := deriveKey(kdf, []byte(auth), salt)
 	if err != nil {
 		return nil, err
 	}
@@ -131,13 +182,6 @@ func EncryptKey(key *Key, auth string, scryptN, scryptP int) ([]byte, error) {
 	}
 	mac := crypto.Keccak256(derivedKey[16:32], cipherText)
 
-	scryptParamsJSON := make(map[string]interface{}, 5)
-	scryptParamsJSON["n"] = scryptN
-	scryptParamsJSON["r"] = scryptR
-	scryptParamsJSON["p"] = scryptP
-	scryptParamsJSON["dklen"] = scryptDKLen
-	scryptParamsJSON["salt"] = hex.EncodeToString(salt)
-
 	cipherParamsJSON := cipherparamsJSON{
 		IV: hex.EncodeToString(iv),
 	}
@@ -146,8 +190,8 @@ func EncryptKey(key *Key, auth string, scryptN, scryptP int) ([]byte, error) {
 		Cipher:       "aes-128-ctr",
 		CipherText:   hex.EncodeToString(cipherText),
 		CipherParams: cipherParamsJSON,
-		KDF:          keyHeaderKDF,
-		KDFParams:    scryptParamsJSON,
+		KDF:          kdf.Name,
+		KDFParams:    kdfParamsJSON,
 		MAC:          hex.EncodeToString(mac),
 	}
 	encryptedKeyJSONV3 := encryptedKeyJSONV3{
@@ -159,6 +203,37 @@ func EncryptKey(key *Key, auth string, scryptN, scryptP int) ([]byte, error) {
 	return json.Marshal(encryptedKeyJSONV3)
 }
 
+// deriveKey derives the encryption key from the passphrase using the configured
+// key derivation function, returning it along with the parameters to store in
+// the key file.
+func deriveKey(kdf KDFConfig, auth, salt []byte) ([]byte, map[string]interface{}, error) {
+	params := make(map[string]interface{}, 5)
+	params["dklen"] = scryptDKLen
+	params["salt"] = hex.EncodeToString(salt)
+
+	switch kdf.Name {
+	case KDFScrypt:
+		derivedKey, err := scrypt.Key(auth, salt, kdf.ScryptN, scryptR, kdf.ScryptP, scryptDKLen)
+		if err != nil {
+			return nil, nil, err
+		}
+		params["n"] = kdf.ScryptN
+		params["r"] = scryptR
+		params["p"] = kdf.ScryptP
+		return derivedKey, params, nil
+
+	case KDFArgon2id:
+		if kdf.Argon2Time < 1 || kdf.Argon2Threads < 1 {
+			return nil, nil, fmt.Errorf("invalid Argon2id parameters: time %d, threads %d", kdf.Argon2Time, kdf.Argon2Threads)
+		}
+		params["t"] = kdf.Argon2Time
+		params["m"] = kdf.Argon2Memory
+		params["p"] = kdf.Argon2Threads
+		return argon2.IDKey(auth, salt, kdf.Argon2Time, kdf.Argon2Memory, kdf.Argon2Threads, scryptDKLen), params, nil
+	}
+	return nil, nil, fmt.Errorf("Unsupported KDF: %s", kdf.Name)
+}
+
 // DecryptKey decrypts a key from a json blob, returning the private key itself.
 func DecryptKey(keyjson []byte, auth string) (*Key, error) {
 	// Parse the json into a simple map to fetch the key version
@@ -287,6 +362,15 @@ func getKDFKey(cryptoJSON cryptoJSON, auth string) ([]byte, error) {
 		p := ensureInt(cryptoJSON.KDFParams["p"])
 		return scrypt.Key(authArray, salt, n, r, p, dkLen)
 
+	} else if cryptoJSON.KDF == KDFArgon2id {
+		t := ensureInt(cryptoJSON.KDFParams["t"])
+		m := ensureInt(cryptoJSON.KDFParams["m"])
+		p := ensureInt(cryptoJSON.KDFParams["p"])
+		if t < 1 || m < 1 || p < 1 || p > 255 {
+			return nil, fmt.Errorf("Invalid Argon2id parameters: t=%d m=%d p=%d", t, m, p)
+		}
+		return argon2.IDKey(authArray, salt, uint32(t), uint32(m), uint8(p), uint32(dkLen)), nil
+
 	} else if cryptoJSON.KDF == "pbkdf2" {
 		c := ensureInt(cryptoJSON.KDFParams["c"])
 		prf := cryptoJSON.KDFParams["prf"].(string)
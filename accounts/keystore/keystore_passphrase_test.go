@@ -17,7 +17,9 @@
 package keystore
 
 import (
+	"encoding/json"
 	"io/ioutil"
+	"os"
 	"testing"
 
 	"github.com/matrix/go-matrix/common"
@@ -58,3 +60,69 @@ func TestKeyEncryptDecrypt(t *testing.T) {
 		}
 	}
 }
+
+// Tests that a key encrypted with Argon2id can be decrypted again.
+func TestKeyEncryptDecryptArgon2id(t *testing.T) {
+	keyjson, err := ioutil.ReadFile("testdata/very-light-scrypt.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := DecryptKey(keyjson, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyjson, err = EncryptKeyWithKDF(key, "argon", Argon2idKDF(1, 64, 1)); err != nil {
+		t.Fatalf("failed to encrypt key with Argon2id: %v", err)
+	}
+	k := new(encryptedKeyJSONV3)
+	if err := json.Unmarshal(keyjson, k); err != nil {
+		t.Fatal(err)
+	}
+	if k.Crypto.KDF != KDFArgon2id {
+		t.Errorf("KDF mismatch: have %s, want %s", k.Crypto.KDF, KDFArgon2id)
+	}
+	if _, err := DecryptKey(keyjson, "bad"); err != ErrDecrypt {
+		t.Errorf("decrypt with bad password: have %v, want %v", err, ErrDecrypt)
+	}
+	dec, err := DecryptKey(keyjson, "argon")
+	if err != nil {
+		t.Fatalf("failed to decrypt Argon2id key: %v", err)
+	}
+	if dec.Address != key.Address {
+		t.Errorf("key address mismatch: have %x, want %x", dec.Address, key.Address)
+	}
+}
+
+// Tests that updating an account re-encrypts its key file with the KDF of the
+// key store.
+func TestKeyStoreUpgradeKDF(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gman-keystore-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ks := NewKeyStore(dir, veryLightScryptN, veryLightScryptP)
+	a, err := ks.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks = NewKeyStoreWithKDF(dir, Argon2idKDF(1, 64, 1))
+	if err := ks.Update(a, "foo", "foo"); err != nil {
+		t.Fatalf("failed to upgrade KDF: %v", err)
+	}
+	keyjson, err := ioutil.ReadFile(a.URL.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := new(encryptedKeyJSONV3)
+	if err := json.Unmarshal(keyjson, k); err != nil {
+		t.Fatal(err)
+	}
+	if k.Crypto.KDF != KDFArgon2id {
+		t.Errorf("KDF mismatch: have %s, want %s", k.Crypto.KDF, KDFArgon2id)
+	}
+	if err := ks.Unlock(a, "foo"); err != nil {
+		t.Errorf("failed to unlock upgraded account: %v", err)
+	}
+}
@@ -28,6 +28,7 @@ import (
 
 	"github.com/matrix/go-matrix/accounts"
 	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/crypto"
 	"github.com/matrix/go-matrix/event"
 )
 
@@ -113,6 +114,27 @@ func TestSignWithPassphrase(t *testing.T) {
 	}
 }
 
+func TestExportECDSA(t *testing.T) {
+	dir, ks := tmpKeyStore(t, true)
+	defer os.RemoveAll(dir)
+
+	pass := "passwd"
+	acc, err := ks.NewAccount(pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ks.ExportECDSA(acc, pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr := crypto.PubkeyToAddress(key.PublicKey); addr != acc.Address {
+		t.Fatalf("exported key address mismatch: have %x, want %x", addr, acc.Address)
+	}
+	if _, err := ks.ExportECDSA(acc, "invalid passwd"); err != ErrDecrypt {
+		t.Fatalf("export with invalid password: have error %v, want %v", err, ErrDecrypt)
+	}
+}
+
 func TestTimedUnlock(t *testing.T) {
 	dir, ks := tmpKeyStore(t, true)
 	defer os.RemoveAll(dir)
@@ -0,0 +1,117 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package accounts
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/matrix/go-matrix/crypto"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+)
+
+// mnemonicSeedIterations is the PBKDF2 round count BIP-39 stretches mnemonics
+// with.
+const mnemonicSeedIterations = 2048
+
+var (
+	errInvalidMnemonic = errors.New("invalid mnemonic")
+	errInvalidChildKey = errors.New("derived child key is invalid")
+)
+
+// masterKeySecret is the HMAC key BIP-32 derives master keys from seeds with.
+var masterKeySecret = []byte("Bitcoin seed")
+
+// NewSeedFromMnemonic derives a BIP-39 seed from a mnemonic sentence and an
+// optional passphrase.
+//
+// Only the number of words is validated: the checksum can't be verified
+// without the wordlist, so callers should confirm the derived address.
+func NewSeedFromMnemonic(mnemonic, passphrase string) ([]byte, error) {
+	words := strings.Fields(norm.NFKD.String(mnemonic))
+	switch len(words) {
+	case 12, 15, 18, 21, 24:
+	default:
+		return nil, fmt.Errorf("%v: have %d words, want 12, 15, 18, 21 or 24", errInvalidMnemonic, len(words))
+	}
+	sentence := strings.Join(words, " ")
+	salt := "mnemonic" + norm.NFKD.String(passphrase)
+	return pbkdf2.Key([]byte(sentence), []byte(salt), mnemonicSeedIterations, 64, sha512.New), nil
+}
+
+// DeriveKey derives the private key at the given BIP-32 derivation path from
+// a seed.
+func DeriveKey(seed []byte, path DerivationPath) (*ecdsa.PrivateKey, error) {
+	mac := hmac.New(sha512.New, masterKeySecret)
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	key, chainCode := sum[:32], sum[32:]
+	if _, err := crypto.ToECDSA(key); err != nil {
+		return nil, errInvalidChildKey
+	}
+	for _, index := range path {
+		var err error
+		if key, chainCode, err = deriveChild(key, chainCode, index); err != nil {
+			return nil, fmt.Errorf("%v at %s", err, path)
+		}
+	}
+	return crypto.ToECDSA(key)
+}
+
+// deriveChild computes the private key and chain code of a child of the given
+// extended private key, as per BIP-32.
+func deriveChild(key, chainCode []byte, index uint32) ([]byte, []byte, error) {
+	data := make([]byte, 0, 37)
+	if index >= 0x80000000 {
+		data = append(append(data, 0x00), key...)
+	} else {
+		parent, err := crypto.ToECDSA(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		data = append(data, crypto.CompressPubkey(&parent.PublicKey)...)
+	}
+	var seq [4]byte
+	binary.BigEndian.PutUint32(seq[:], index)
+	data = append(data, seq[:]...)
+
+	mac := hmac.New(sha512.New, chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	n := crypto.S256().Params().N
+	tweak := new(big.Int).SetBytes(sum[:32])
+	if tweak.Cmp(n) >= 0 {
+		return nil, nil, errInvalidChildKey
+	}
+	child := tweak.Add(tweak, new(big.Int).SetBytes(key))
+	child.Mod(child, n)
+	if child.Sign() == 0 {
+		return nil, nil, errInvalidChildKey
+	}
+	childKey := make([]byte, 32)
+	b := child.Bytes()
+	copy(childKey[32-len(b):], b)
+	return childKey, sum[32:], nil
+}
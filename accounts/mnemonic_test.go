@@ -0,0 +1,72 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package accounts
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/crypto"
+)
+
+// Tests BIP-32 key derivation against the first test vector of the spec.
+func TestDeriveKey(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+
+	tests := []struct {
+		path string
+		key  string
+	}{
+		{"m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{"m/0'/1/2'/2/1000000000", "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8"},
+	}
+	for i, tt := range tests {
+		path, err := ParseDerivationPath(tt.path)
+		if err != nil {
+			t.Fatalf("test %d: failed to parse path: %v", i, err)
+		}
+		key, err := DeriveKey(seed, path)
+		if err != nil {
+			t.Fatalf("test %d: failed to derive key: %v", i, err)
+		}
+		if have := hex.EncodeToString(crypto.FromECDSA(key)); have != tt.key {
+			t.Errorf("test %d: key mismatch: have %s, want %s", i, have, tt.key)
+		}
+	}
+}
+
+// Tests that mnemonics derive the same accounts as other wallets do.
+func TestMnemonicDerivation(t *testing.T) {
+	mnemonic := strings.Repeat("abandon ", 11) + "about"
+
+	seed, err := NewSeedFromMnemonic(mnemonic, "")
+	if err != nil {
+		t.Fatalf("failed to derive seed: %v", err)
+	}
+	key, err := DeriveKey(seed, DefaultBaseDerivationPath)
+	if err != nil {
+		t.Fatalf("failed to derive key: %v", err)
+	}
+	want := common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94")
+	if have := crypto.PubkeyToAddress(key.PublicKey); have != want {
+		t.Errorf("address mismatch: have %x, want %x", have, want)
+	}
+	if _, err := NewSeedFromMnemonic("abandon about", ""); err == nil {
+		t.Errorf("expected error for short mnemonic")
+	}
+}
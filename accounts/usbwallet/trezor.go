@@ -156,6 +156,10 @@ func (w *trezorDriver) SignTx(path accounts.DerivationPath, tx *types.Transactio
 	if w.device == nil {
 		return common.Address{}, nil, accounts.ErrWalletClosed
 	}
+	// The Trezor protocol only carries 32 bit chain IDs
+	if chainID != nil && (chainID.Sign() < 0 || chainID.BitLen() > 32) {
+		return common.Address{}, nil, fmt.Errorf("trezor: chain ID %v exceeds 32 bits", chainID)
+	}
 	return w.trezorSign(path, tx, chainID)
 }
 
@@ -192,8 +196,8 @@ func (w *trezorDriver) trezorSign(derivationPath []uint32, tx *types.Transaction
 	} else {
 		request.DataInitialChunk, data = data, nil
 	}
-	if chainID != nil { // EIP-155 transaction, set chain ID explicitly (only 32 bit is supported!?)
-		id := uint32(chainID.Int64())
+	if chainID != nil { // EIP-155 transaction, set chain ID explicitly (only 32 bit is supported)
+		id := uint32(chainID.Uint64())
 		request.ChainId = &id
 	}
 	// Send the initiation message and stream content until a signature is returned
@@ -19,6 +19,7 @@ package usbwallet
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
@@ -40,6 +41,12 @@ const heartbeatCycle = time.Second
 // requesting accounts like crazy.
 const selfDeriveThrottling = time.Second
 
+// errMatrixExtraUnsupported is returned when a transaction carrying MATRIX
+// extensions (transaction types, lock heights or extra recipients) is sent to a
+// hardware wallet. The device firmware only understands the plain transaction
+// fields, so the produced signature would not cover the extensions.
+var errMatrixExtraUnsupported = errors.New("usbwallet: transactions with MATRIX extensions cannot be signed on hardware wallets")
+
 // driver defines the vendor specific functionality hardware wallets instances
 // must implement to allow using them with the wallet lifecycle management.
 type driver interface {
@@ -521,6 +528,10 @@ func (w *wallet) SignTx(account accounts.Account, tx *types.Transaction, chainID
 	if !ok {
 		return nil, accounts.ErrUnknownAccount
 	}
+	// Make sure the device is able to confirm everything it signs
+	if len(tx.GetMatrix_EX()) > 0 {
+		return nil, errMatrixExtraUnsupported
+	}
 	// All infos gathered and metadata checks out, request signing
 	<-w.commsLock
 	defer func() { w.commsLock <- struct{}{} }()
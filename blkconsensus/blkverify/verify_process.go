@@ -80,6 +80,8 @@ type Process struct {
 	reqCache      *reqCache
 	pm            *ProcessManage
 	txsAcquireSeq int
+	roundStart    time.Time // Start of the request verification, for round timings
+	dposStart     time.Time // Start of the DPOS phase, for round timings
 }
 
 func newProcess(number uint64, pm *ProcessManage) *Process {
@@ -222,6 +224,7 @@ func (p *Process) startReqVerifyCommon() {
 	p.curProcessReq.hash = p.curProcessReq.req.Header.HashNoSignsAndNonce()
 	log.INFO(p.logExtraInfo(), "请求验证阶段", "开始", "高度", p.number, "HeaderHash", p.curProcessReq.hash.TerminalString(), "parent hash", p.curProcessReq.req.Header.ParentHash.TerminalString(), "之前状态", p.state.String())
 	p.state = StateReqVerify
+	p.roundStart = time.Now()
 	p.processReqOnce()
 }
 
@@ -454,6 +457,7 @@ func (p *Process) startDPOSVerify(lvResult uint8) {
 	p.curProcessReq.localVerifyResult = lvResult
 
 	p.state = StateDPOSVerify
+	p.dposStart = time.Now()
 	p.processDPOSOnce()
 }
 
@@ -508,6 +512,9 @@ func (p *Process) finishedProcess() {
 
 	p.votePool().DelVotes(p.curProcessReq.hash)
 	p.state = StateEnd
+
+	roundTimer.UpdateSince(p.roundStart)
+	dposTimer.UpdateSince(p.dposStart)
 }
 
 func (p *Process) checkState(state State) bool {
@@ -0,0 +1,75 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package slashing
+
+import (
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/common/hexutil"
+	"github.com/matrix/go-matrix/core/types"
+)
+
+// PublicSlashingAPI exposes the collected slashing evidence over RPC.
+type PublicSlashingAPI struct {
+	collector *Collector
+}
+
+// NewPublicSlashingAPI creates a new RPC service for the given collector.
+func NewPublicSlashingAPI(collector *Collector) *PublicSlashingAPI {
+	return &PublicSlashingAPI{collector: collector}
+}
+
+// RPCVote is the RPC representation of a consensus vote and the proposal it
+// approves.
+type RPCVote struct {
+	SignHash  common.Hash   `json:"signHash"`
+	Signature hexutil.Bytes `json:"signature"`
+	Header    *types.Header `json:"header"`
+}
+
+// RPCEvidence is the RPC representation of double-signing evidence.
+type RPCEvidence struct {
+	Validator common.Address `json:"validator"`
+	Height    hexutil.Uint64 `json:"height"`
+	Leader    common.Address `json:"leader"`
+	First     RPCVote        `json:"first"`
+	Second    RPCVote        `json:"second"`
+	Time      hexutil.Uint64 `json:"time"`
+	Report    *common.Hash   `json:"report"`
+	Payload   hexutil.Bytes  `json:"payload"`
+}
+
+// GetSlashingEvidence returns the double-signing evidence collected by this
+// node, optionally restricted to a single validator.
+func (api *PublicSlashingAPI) GetSlashingEvidence(validator *common.Address) []*RPCEvidence {
+	list := make([]*RPCEvidence, 0)
+	for _, ev := range api.collector.Evidence(validator) {
+		rpcEv := &RPCEvidence{
+			Validator: ev.Validator,
+			Height:    hexutil.Uint64(ev.Height),
+			Leader:    ev.Leader,
+			First:     RPCVote{SignHash: ev.First.SignHash, Signature: ev.First.Sign.Bytes(), Header: ev.FirstHeader},
+			Second:    RPCVote{SignHash: ev.Second.SignHash, Signature: ev.Second.Sign.Bytes(), Header: ev.SecondHeader},
+			Time:      hexutil.Uint64(ev.Time),
+			Payload:   ev.Payload(),
+		}
+		if ev.Report != (common.Hash{}) {
+			report := ev.Report
+			rpcEv.Report = &report
+		}
+		list = append(list, rpcEv)
+	}
+	return list
+}
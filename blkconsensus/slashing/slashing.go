@@ -0,0 +1,489 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+// Package slashing collects evidence of validators double-signing block
+// consensus votes.
+//
+// A validator approves a block proposal by signing its hash. Proposals are
+// identified by height and leader, since a leader re-election legitimately
+// produces a second proposal at the same height. Two approving votes by the
+// same validator for different proposals of the same height and leader are
+// therefore conflicting, and are persisted as evidence along with both proposal
+// headers, which bind the signed hashes to the height and leader. If a reporter account
+// is configured, the evidence is also submitted in a transaction to a slashing
+// contract so the validator can be penalized on chain.
+package slashing
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/core/state"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/crypto"
+	"github.com/matrix/go-matrix/event"
+	"github.com/matrix/go-matrix/log"
+	"github.com/matrix/go-matrix/mandb"
+	"github.com/matrix/go-matrix/mc"
+	"github.com/matrix/go-matrix/rlp"
+)
+
+const (
+	// retainHeights is the number of heights below the highest proposal seen
+	// for which proposals and votes are kept around to detect conflicts.
+	retainHeights = 64
+
+	// maxUnmatched is the maximum number of votes buffered while waiting for
+	// the proposal they approve to arrive.
+	maxUnmatched = 4096
+
+	// unmatchedTimeout is the time after which a buffered vote is dropped if
+	// its proposal still hasn't been seen.
+	unmatchedTimeout = time.Minute
+)
+
+var (
+	// ErrInvalidEvidence is returned if evidence does not prove two conflicting
+	// approving votes by the same validator.
+	ErrInvalidEvidence = errors.New("invalid slashing evidence")
+
+	evidencePrefix   = []byte("slashing-evidence-") // evidencePrefix + height (uint64 big endian) + leader + validator -> evidence
+	evidenceIndexKey = []byte("slashing-index")     // RLP list of all persisted evidence keys
+)
+
+// Config are the configuration parameters of the slashing evidence collector.
+type Config struct {
+	Reporter common.Address // Account submitting evidence transactions, submission disabled if zero
+	Contract common.Address // Slashing contract evidence transactions are sent to
+	GasLimit uint64         // Gas allowance of a single evidence transaction
+}
+
+// DefaultConfig contains the default settings for the collector.
+var DefaultConfig = Config{
+	GasLimit: 200000,
+}
+
+// Vote is a signed block consensus vote.
+type Vote struct {
+	SignHash common.Hash      // Hash of the proposal voted on
+	Sign     common.Signature // Validator signature over SignHash
+}
+
+// signer recovers the account that signed the vote and whether the vote
+// approves the proposal.
+func (v *Vote) signer() (common.Address, bool, error) {
+	return crypto.VerifySignWithValidate(v.SignHash.Bytes(), v.Sign.Bytes())
+}
+
+// Evidence proves that a validator approved two different proposals of the
+// same height and leader.
+type Evidence struct {
+	Validator    common.Address // Validator that double-signed
+	Height       uint64         // Block height of both proposals
+	Leader       common.Address // Leader of both proposals
+	First        Vote           // Vote seen first
+	FirstHeader  *types.Header  // Proposal approved by the first vote
+	Second       Vote           // Conflicting vote seen later
+	SecondHeader *types.Header  // Proposal approved by the second vote
+	Time         uint64         // Unix time the conflict was detected
+	Report       common.Hash    // Hash of the transaction submitting the evidence, zero if not submitted
+}
+
+// Verify checks that both votes are approving votes signed by the validator
+// over different proposals, and that both proposals hash to the signed hashes
+// and are of the evidence height and leader.
+func (ev *Evidence) Verify() error {
+	if ev.First.SignHash == ev.Second.SignHash {
+		return ErrInvalidEvidence
+	}
+	for i, vote := range []*Vote{&ev.First, &ev.Second} {
+		header := ev.FirstHeader
+		if i == 1 {
+			header = ev.SecondHeader
+		}
+		if header == nil || header.Number == nil || header.HashNoSignsAndNonce() != vote.SignHash {
+			return ErrInvalidEvidence
+		}
+		if !header.Number.IsUint64() || header.Number.Uint64() != ev.Height || header.Leader != ev.Leader {
+			return ErrInvalidEvidence
+		}
+		signer, validate, err := vote.signer()
+		if err != nil || !validate || signer != ev.Validator {
+			return ErrInvalidEvidence
+		}
+	}
+	return nil
+}
+
+// Payload returns the call data of an evidence transaction: the RLP encoding
+// of the validator, height, leader and both votes with their proposals.
+func (ev *Evidence) Payload() []byte {
+	data, _ := rlp.EncodeToBytes([]interface{}{ev.Validator, ev.Height, ev.Leader, ev.First, ev.FirstHeader, ev.Second, ev.SecondHeader})
+	return data
+}
+
+// TxPool is the subset of the transaction pool used to submit evidence.
+type TxPool interface {
+	State() *state.ManagedState
+	GasPrice() *big.Int
+	AddLocal(tx *types.Transaction) error
+}
+
+// SignTxFn signs a transaction with the reporter account.
+type SignTxFn func(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+
+// proposal identifies the slot a proposal competes for.
+type proposal struct {
+	height uint64
+	leader common.Address
+}
+
+// signKey identifies the slot a validator may approve only one proposal for.
+type signKey struct {
+	proposal
+	validator common.Address
+}
+
+// unmatchedVote is a vote waiting for its proposal.
+type unmatchedVote struct {
+	vote      Vote
+	validator common.Address
+	time      time.Time
+}
+
+// Collector watches block consensus proposals and votes for double-signing
+// validators, persisting and optionally reporting the evidence.
+type Collector struct {
+	config  Config
+	db      mandb.Database
+	chainID *big.Int
+	pool    TxPool
+	signTx  SignTxFn
+
+	mu        sync.Mutex
+	proposals map[common.Hash]*types.Header    // Known proposals by the hash validators sign
+	signed    map[signKey]Vote                 // First approving vote of each validator per slot
+	unmatched map[common.Hash][]*unmatchedVote // Votes received before their proposal
+	pending   int                              // Number of buffered unmatched votes
+	head      uint64                           // Highest proposal height seen
+	index     []evidenceKey                    // Keys of all persisted evidence
+	known     map[evidenceKey]struct{}         // Set of persisted evidence keys
+	now       func() time.Time
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// evidenceKey identifies persisted evidence. It includes the leader, as a
+// validator may double-sign again after a leader re-election.
+type evidenceKey struct {
+	Height    uint64
+	Leader    common.Address
+	Validator common.Address
+}
+
+// New creates an evidence collector persisting into db. If signTx is nil, no
+// evidence transactions are submitted.
+func New(config Config, db mandb.Database, chainID *big.Int, pool TxPool, signTx SignTxFn) *Collector {
+	if config.GasLimit == 0 {
+		log.Warn("Sanitizing invalid slashing gas limit", "provided", config.GasLimit, "updated", DefaultConfig.GasLimit)
+		config.GasLimit = DefaultConfig.GasLimit
+	}
+	c := &Collector{
+		config:    config,
+		db:        db,
+		chainID:   chainID,
+		pool:      pool,
+		signTx:    signTx,
+		proposals: make(map[common.Hash]*types.Header),
+		signed:    make(map[signKey]Vote),
+		unmatched: make(map[common.Hash][]*unmatchedVote),
+		known:     make(map[evidenceKey]struct{}),
+		now:       time.Now,
+		quit:      make(chan struct{}),
+	}
+	if blob, err := db.Get(evidenceIndexKey); err == nil {
+		if err := rlp.DecodeBytes(blob, &c.index); err != nil {
+			log.Error("Corrupted slashing evidence index", "err", err)
+			c.index = nil
+		}
+	}
+	for _, key := range c.index {
+		c.known[key] = struct{}{}
+	}
+	return c
+}
+
+// Start subscribes to the block consensus messages of the message center and
+// starts feeding them into the collector.
+func (c *Collector) Start() error {
+	var (
+		reqCh  = make(chan *mc.HD_BlkConsensusReqMsg, 16)
+		voteCh = make(chan *mc.HD_ConsensusVote, 64)
+	)
+	reqSub, err := mc.SubscribeEvent(mc.HD_BlkConsensusReq, reqCh)
+	if err != nil {
+		return err
+	}
+	voteSub, err := mc.SubscribeEvent(mc.HD_BlkConsensusVote, voteCh)
+	if err != nil {
+		reqSub.Unsubscribe()
+		return err
+	}
+	c.wg.Add(1)
+	go c.loop(reqCh, voteCh, reqSub, voteSub)
+	return nil
+}
+
+// Stop terminates the message processing and waits for it to exit.
+func (c *Collector) Stop() {
+	close(c.quit)
+	c.wg.Wait()
+}
+
+// loop processes incoming proposals and votes until stopped.
+func (c *Collector) loop(reqCh chan *mc.HD_BlkConsensusReqMsg, voteCh chan *mc.HD_ConsensusVote, reqSub, voteSub event.Subscription) {
+	defer c.wg.Done()
+	defer reqSub.Unsubscribe()
+	defer voteSub.Unsubscribe()
+
+	for {
+		select {
+		case req := <-reqCh:
+			if req != nil && req.Header != nil {
+				c.AddProposal(req.Header)
+			}
+		case vote := <-voteCh:
+			if vote != nil {
+				c.AddVote(Vote{SignHash: vote.SignHash, Sign: vote.Sign})
+			}
+		case <-c.quit:
+			return
+		}
+	}
+}
+
+// AddProposal records a block proposal, matching any votes that arrived for it
+// before it did.
+func (c *Collector) AddProposal(header *types.Header) []*Evidence {
+	hash := header.HashNoSignsAndNonce()
+	prop := proposal{height: header.Number.Uint64(), leader: header.Leader}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Ignore proposals that already fell out of the retention window
+	if c.head >= retainHeights && prop.height < c.head-retainHeights {
+		return nil
+	}
+	if _, ok := c.proposals[hash]; ok {
+		return nil
+	}
+	c.proposals[hash] = types.CopyHeader(header)
+	if prop.height > c.head {
+		c.head = prop.height
+		c.prune()
+	}
+	var found []*Evidence
+	for _, uv := range c.unmatched[hash] {
+		if ev := c.check(prop, uv.validator, uv.vote); ev != nil {
+			found = append(found, ev)
+		}
+	}
+	c.pending -= len(c.unmatched[hash])
+	delete(c.unmatched, hash)
+
+	return found
+}
+
+// AddVote records a consensus vote, returning the evidence if it conflicts with
+// an earlier vote of the same validator. Rejecting votes are ignored.
+func (c *Collector) AddVote(vote Vote) (*Evidence, error) {
+	validator, validate, err := vote.signer()
+	if err != nil {
+		return nil, err
+	}
+	if !validate {
+		return nil, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	header, ok := c.proposals[vote.SignHash]
+	if !ok {
+		c.buffer(validator, vote)
+		return nil, nil
+	}
+	return c.check(proposal{height: header.Number.Uint64(), leader: header.Leader}, validator, vote), nil
+}
+
+// buffer keeps a vote around until its proposal arrives, dropping the oldest
+// buffered votes if they timed out or too many are waiting.
+func (c *Collector) buffer(validator common.Address, vote Vote) {
+	now := c.now()
+	for hash, votes := range c.unmatched {
+		if now.Sub(votes[0].time) > unmatchedTimeout {
+			c.pending -= len(votes)
+			delete(c.unmatched, hash)
+		}
+	}
+	if c.pending >= maxUnmatched {
+		log.Debug("Dropping unmatched consensus vote", "hash", vote.SignHash, "validator", validator)
+		return
+	}
+	for _, uv := range c.unmatched[vote.SignHash] {
+		if uv.validator == validator {
+			return
+		}
+	}
+	c.unmatched[vote.SignHash] = append(c.unmatched[vote.SignHash], &unmatchedVote{vote: vote, validator: validator, time: now})
+	c.pending++
+}
+
+// check records the first approving vote of a validator for a slot, or creates
+// evidence if it already approved a different proposal for it.
+func (c *Collector) check(prop proposal, validator common.Address, vote Vote) *Evidence {
+	key := signKey{proposal: prop, validator: validator}
+
+	first, ok := c.signed[key]
+	if !ok {
+		c.signed[key] = vote
+		return nil
+	}
+	if first.SignHash == vote.SignHash {
+		return nil
+	}
+	if _, ok := c.known[evidenceKey{prop.height, prop.leader, validator}]; ok {
+		return nil
+	}
+	ev := &Evidence{
+		Validator:    validator,
+		Height:       prop.height,
+		Leader:       prop.leader,
+		First:        first,
+		FirstHeader:  c.proposals[first.SignHash],
+		Second:       vote,
+		SecondHeader: c.proposals[vote.SignHash],
+		Time:         uint64(c.now().Unix()),
+	}
+	log.Warn("Validator double-signed block consensus votes", "validator", validator, "height", prop.height, "leader", prop.leader,
+		"first", first.SignHash, "second", vote.SignHash)
+
+	if c.signTx != nil {
+		if hash, err := c.report(ev); err != nil {
+			log.Error("Failed to submit slashing evidence", "validator", validator, "height", prop.height, "err", err)
+		} else {
+			ev.Report = hash
+		}
+	}
+	if err := c.store(ev); err != nil {
+		log.Error("Failed to store slashing evidence", "validator", validator, "height", prop.height, "err", err)
+	}
+	return ev
+}
+
+// report submits the evidence to the slashing contract from the reporter
+// account.
+func (c *Collector) report(ev *Evidence) (common.Hash, error) {
+	nonce := c.pool.State().GetNonce(c.config.Reporter)
+	tx, err := c.signTx(types.NewTransaction(nonce, c.config.Contract, new(big.Int), c.config.GasLimit, c.pool.GasPrice(), ev.Payload()), c.chainID)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := c.pool.AddLocal(tx); err != nil {
+		return common.Hash{}, err
+	}
+	log.Info("Submitted slashing evidence", "validator", ev.Validator, "height", ev.Height, "tx", tx.Hash())
+	return tx.Hash(), nil
+}
+
+// prune drops proposals and votes too far below the highest height seen.
+func (c *Collector) prune() {
+	if c.head < retainHeights {
+		return
+	}
+	limit := c.head - retainHeights
+	for hash, header := range c.proposals {
+		if header.Number.Uint64() < limit {
+			delete(c.proposals, hash)
+		}
+	}
+	for key := range c.signed {
+		if key.height < limit {
+			delete(c.signed, key)
+		}
+	}
+}
+
+// store persists the evidence and adds it to the evidence index.
+func (c *Collector) store(ev *Evidence) error {
+	blob, err := rlp.EncodeToBytes(ev)
+	if err != nil {
+		return err
+	}
+	key := evidenceKey{Height: ev.Height, Leader: ev.Leader, Validator: ev.Validator}
+	index, err := rlp.EncodeToBytes(append(c.index, key))
+	if err != nil {
+		return err
+	}
+	batch := c.db.NewBatch()
+	batch.Put(evidenceDBKey(key), blob)
+	batch.Put(evidenceIndexKey, index)
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	c.index = append(c.index, key)
+	c.known[key] = struct{}{}
+	return nil
+}
+
+// Evidence returns all persisted evidence in detection order, optionally
+// restricted to a single validator.
+func (c *Collector) Evidence(validator *common.Address) []*Evidence {
+	c.mu.Lock()
+	keys := append([]evidenceKey(nil), c.index...)
+	c.mu.Unlock()
+
+	var list []*Evidence
+	for _, key := range keys {
+		if validator != nil && key.Validator != *validator {
+			continue
+		}
+		blob, err := c.db.Get(evidenceDBKey(key))
+		if err != nil {
+			log.Error("Missing slashing evidence", "validator", key.Validator, "height", key.Height, "err", err)
+			continue
+		}
+		ev := new(Evidence)
+		if err := rlp.DecodeBytes(blob, ev); err != nil {
+			log.Error("Corrupted slashing evidence", "validator", key.Validator, "height", key.Height, "err", err)
+			continue
+		}
+		list = append(list, ev)
+	}
+	return list
+}
+
+// evidenceDBKey = evidencePrefix + height (uint64 big endian) + leader + validator
+func evidenceDBKey(key evidenceKey) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, key.Height)
+
+	return append(append(append(append([]byte{}, evidencePrefix...), enc...), key.Leader.Bytes()...), key.Validator.Bytes()...)
+}
@@ -0,0 +1,263 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package slashing
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/core/state"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/crypto"
+	"github.com/matrix/go-matrix/mandb"
+	"github.com/matrix/go-matrix/rlp"
+)
+
+var (
+	testChainID  = big.NewInt(1)
+	testContract = common.HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314")
+	testLeader   = common.HexToAddress("0x1111111111111111111111111111111111111111")
+)
+
+// testPool is a transaction pool recording the transactions added to it.
+type testPool struct {
+	state *state.ManagedState
+	txs   []*types.Transaction
+}
+
+func newTestPool() *testPool {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(mandb.NewMemDatabase()))
+	return &testPool{state: state.ManageState(statedb)}
+}
+
+func (p *testPool) State() *state.ManagedState { return p.state }
+func (p *testPool) GasPrice() *big.Int         { return big.NewInt(1) }
+
+func (p *testPool) AddLocal(tx *types.Transaction) error {
+	p.txs = append(p.txs, tx)
+	return nil
+}
+
+// newTestProposal creates a block proposal header, made unique by extra.
+func newTestProposal(height uint64, leader common.Address, extra byte) *types.Header {
+	return &types.Header{
+		Number:     new(big.Int).SetUint64(height),
+		Leader:     leader,
+		Difficulty: big.NewInt(1),
+		Extra:      []byte{extra},
+	}
+}
+
+// newTestVote signs a consensus vote on the proposal.
+func newTestVote(t *testing.T, key *ecdsa.PrivateKey, header *types.Header, validate bool) Vote {
+	hash := header.HashNoSignsAndNonce()
+	sign, err := crypto.SignWithValidate(hash.Bytes(), validate, key)
+	if err != nil {
+		t.Fatalf("failed to sign vote: %v", err)
+	}
+	return Vote{SignHash: hash, Sign: common.BytesToSignature(sign)}
+}
+
+// Tests that approving two proposals of the same height and leader yields
+// verifiable evidence which survives a restart.
+func TestDoubleSignDetection(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	validator := crypto.PubkeyToAddress(key.PublicKey)
+
+	db := mandb.NewMemDatabase()
+	c := New(DefaultConfig, db, testChainID, nil, nil)
+
+	first, second := newTestProposal(10, testLeader, 1), newTestProposal(10, testLeader, 2)
+	c.AddProposal(first)
+	c.AddProposal(second)
+
+	if ev, err := c.AddVote(newTestVote(t, key, first, true)); err != nil || ev != nil {
+		t.Fatalf("first vote flagged: %v, %v", ev, err)
+	}
+	// Voting again on the same proposal is not a conflict
+	if ev, _ := c.AddVote(newTestVote(t, key, first, true)); ev != nil {
+		t.Fatalf("repeated vote flagged")
+	}
+	ev, err := c.AddVote(newTestVote(t, key, second, true))
+	if err != nil || ev == nil {
+		t.Fatalf("double sign not detected: %v", err)
+	}
+	if ev.Validator != validator || ev.Height != 10 || ev.Leader != testLeader {
+		t.Fatalf("evidence mismatch: %+v", ev)
+	}
+	if err := ev.Verify(); err != nil {
+		t.Fatalf("evidence failed verification: %v", err)
+	}
+	// The evidence must be reported once and be reloaded from the database
+	if ev, _ := c.AddVote(newTestVote(t, key, newTestProposal(10, testLeader, 1), true)); ev != nil {
+		t.Fatalf("evidence reported twice")
+	}
+	list := New(DefaultConfig, db, testChainID, nil, nil).Evidence(&validator)
+	if len(list) != 1 || list[0].Second.SignHash != second.HashNoSignsAndNonce() {
+		t.Fatalf("persisted evidence mismatch: %v", list)
+	}
+	if err := list[0].Verify(); err != nil {
+		t.Fatalf("persisted evidence failed verification: %v", err)
+	}
+	other := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	if list := c.Evidence(&other); len(list) != 0 {
+		t.Fatalf("evidence returned for unrelated validator: %v", list)
+	}
+}
+
+// Tests that votes which legitimately differ are not considered conflicting.
+func TestNoFalsePositives(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	c := New(DefaultConfig, mandb.NewMemDatabase(), testChainID, nil, nil)
+
+	// A re-elected leader proposing at the same height
+	reelected := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	first, second := newTestProposal(10, testLeader, 1), newTestProposal(10, reelected, 2)
+	c.AddProposal(first)
+	c.AddProposal(second)
+
+	c.AddVote(newTestVote(t, key, first, true))
+	if ev, _ := c.AddVote(newTestVote(t, key, second, true)); ev != nil {
+		t.Fatalf("votes for different leaders flagged")
+	}
+	// A rejecting vote on a competing proposal
+	third := newTestProposal(10, testLeader, 3)
+	c.AddProposal(third)
+	if ev, _ := c.AddVote(newTestVote(t, key, third, false)); ev != nil {
+		t.Fatalf("rejecting vote flagged")
+	}
+	// A forged signature must not be attributed to anybody
+	vote := newTestVote(t, key, third, true)
+	vote.Sign[3] ^= 0xff
+	if ev, _ := c.AddVote(vote); ev != nil && ev.Verify() == nil {
+		t.Fatalf("forged vote produced valid evidence")
+	}
+}
+
+// Tests that votes arriving before their proposal are matched later, and that
+// evidence is submitted to the slashing contract if a reporter is configured.
+func TestUnmatchedVotesAndReporting(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	reporterKey, _ := crypto.GenerateKey()
+	reporter := crypto.PubkeyToAddress(reporterKey.PublicKey)
+
+	pool := newTestPool()
+	signer := types.NewEIP155Signer(testChainID)
+	c := New(Config{Reporter: reporter, Contract: testContract, GasLimit: 100000}, mandb.NewMemDatabase(), testChainID, pool,
+		func(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+			return types.SignTx(tx, signer, reporterKey)
+		})
+
+	first, second := newTestProposal(20, testLeader, 1), newTestProposal(20, testLeader, 2)
+	c.AddVote(newTestVote(t, key, first, true))
+	c.AddVote(newTestVote(t, key, second, true))
+
+	if found := c.AddProposal(first); len(found) != 0 {
+		t.Fatalf("single proposal produced evidence: %v", found)
+	}
+	found := c.AddProposal(second)
+	if len(found) != 1 {
+		t.Fatalf("buffered double sign not detected: have %d evidence, want 1", len(found))
+	}
+	if len(pool.txs) != 1 {
+		t.Fatalf("evidence not submitted: have %d txs, want 1", len(pool.txs))
+	}
+	tx := pool.txs[0]
+	if *tx.To() != testContract || !bytes.Equal(tx.Data(), found[0].Payload()) {
+		t.Fatalf("evidence transaction mismatch: to %x, data %x", tx.To(), tx.Data())
+	}
+	if found[0].Report != tx.Hash() {
+		t.Fatalf("report hash mismatch: have %x, want %x", found[0].Report, tx.Hash())
+	}
+}
+
+// Tests that evidence only verifies if both proposals hash to the signed hashes
+// and match the claimed height and leader.
+func TestEvidenceVerification(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	c := New(DefaultConfig, mandb.NewMemDatabase(), testChainID, nil, nil)
+
+	first, second := newTestProposal(10, testLeader, 1), newTestProposal(10, testLeader, 2)
+	c.AddProposal(first)
+	c.AddProposal(second)
+	c.AddVote(newTestVote(t, key, first, true))
+	ev, _ := c.AddVote(newTestVote(t, key, second, true))
+	if ev == nil {
+		t.Fatalf("double sign not detected")
+	}
+	tests := []struct {
+		name   string
+		tamper func(ev *Evidence)
+	}{
+		{"height", func(ev *Evidence) { ev.Height++ }},
+		{"leader", func(ev *Evidence) { ev.Leader = common.HexToAddress("0x3333333333333333333333333333333333333333") }},
+		{"missing header", func(ev *Evidence) { ev.SecondHeader = nil }},
+		{"swapped header", func(ev *Evidence) { ev.SecondHeader = ev.FirstHeader }},
+		{"other height", func(ev *Evidence) { ev.SecondHeader = newTestProposal(11, testLeader, 2) }},
+		{"header height", func(ev *Evidence) { ev.SecondHeader.Number = big.NewInt(11) }},
+	}
+	for _, tt := range tests {
+		tampered := *ev
+		tampered.SecondHeader = types.CopyHeader(ev.SecondHeader)
+		tt.tamper(&tampered)
+		if err := tampered.Verify(); err != ErrInvalidEvidence {
+			t.Errorf("%s: tampered evidence verification mismatch: have %v, want %v", tt.name, err, ErrInvalidEvidence)
+		}
+	}
+	// Headers must survive the transaction payload
+	var payload struct {
+		Validator    common.Address
+		Height       uint64
+		Leader       common.Address
+		First        Vote
+		FirstHeader  *types.Header
+		Second       Vote
+		SecondHeader *types.Header
+	}
+	if err := rlp.DecodeBytes(ev.Payload(), &payload); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	decoded := &Evidence{Validator: payload.Validator, Height: payload.Height, Leader: payload.Leader,
+		First: payload.First, FirstHeader: payload.FirstHeader, Second: payload.Second, SecondHeader: payload.SecondHeader}
+	if err := decoded.Verify(); err != nil {
+		t.Fatalf("payload evidence failed verification: %v", err)
+	}
+}
+
+// Tests that a validator double-signing again after a leader re-election at the
+// same height is recorded separately.
+func TestDoubleSignAfterReelection(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	validator := crypto.PubkeyToAddress(key.PublicKey)
+	c := New(DefaultConfig, mandb.NewMemDatabase(), testChainID, nil, nil)
+
+	reelected := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	for _, leader := range []common.Address{testLeader, reelected} {
+		first, second := newTestProposal(10, leader, 1), newTestProposal(10, leader, 2)
+		c.AddProposal(first)
+		c.AddProposal(second)
+		c.AddVote(newTestVote(t, key, first, true))
+		if ev, _ := c.AddVote(newTestVote(t, key, second, true)); ev == nil {
+			t.Fatalf("double sign for leader %x not detected", leader)
+		}
+	}
+	if list := c.Evidence(&validator); len(list) != 2 {
+		t.Fatalf("evidence count mismatch: have %d, want 2", len(list))
+	}
+}
@@ -17,6 +17,9 @@
 package main
 
 import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 
@@ -30,6 +33,33 @@ import (
 )
 
 var (
+	accountAddressFlag = cli.StringFlag{
+		Name:  "address",
+		Usage: "Address of the account to export",
+	}
+	accountFormatFlag = cli.StringFlag{
+		Name:  "format",
+		Usage: `Format to export the key in ("json" or "hex")`,
+		Value: "json",
+	}
+	accountOutFlag = cli.StringFlag{
+		Name:  "out",
+		Usage: "File to write the exported key to (default = standard output)",
+	}
+	accountImportRawFlag = cli.BoolFlag{
+		Name:  "import-raw",
+		Usage: "Import the key file as a raw hex private key, even if it parses as JSON",
+	}
+	accountMnemonicFlag = cli.BoolFlag{
+		Name:  "mnemonic",
+		Usage: "Import the key file as a BIP-39 mnemonic, deriving the key along --hdpath",
+	}
+	accountHDPathFlag = cli.StringFlag{
+		Name:  "hdpath",
+		Usage: "HD derivation path of the key imported from a mnemonic",
+		Value: accounts.DefaultBaseDerivationPath.String(),
+	}
+
 	walletCommand = cli.Command{
 		Name:      "wallet",
 		Usage:     "Manage Matrix presale wallets",
@@ -54,6 +84,9 @@ passwordfile as argument containing the wallet password in plaintext.`,
 					utils.KeyStoreDirFlag,
 					utils.PasswordFileFlag,
 					utils.LightKDFFlag,
+					utils.KDFFlag,
+					utils.ScryptNFlag,
+					utils.ScryptPFlag,
 				},
 				Description: `
 	gman wallet [options] /path/to/my/presale.wallet
@@ -82,7 +115,8 @@ safe environments.
 Make sure you remember the password you gave when creating a new account (with
 either new or import). Without it you are not able to unlock your account.
 
-Note that exporting your key in unencrypted format is NOT supported.
+Keys can be exported as standard V3 JSON key files or, explicitly, as raw
+unencrypted hex private keys.
 
 Keys are stored under <DATADIR>/keystore.
 It is safe to transfer the entire directory or the individual keys therein
@@ -110,6 +144,9 @@ Print a short summary of all accounts`,
 					utils.KeyStoreDirFlag,
 					utils.PasswordFileFlag,
 					utils.LightKDFFlag,
+					utils.KDFFlag,
+					utils.ScryptNFlag,
+					utils.ScryptPFlag,
 				},
 				Description: `
     gman account new
@@ -135,6 +172,9 @@ password to file or expose in any other way.
 					utils.DataDirFlag,
 					utils.KeyStoreDirFlag,
 					utils.LightKDFFlag,
+					utils.KDFFlag,
+					utils.ScryptNFlag,
+					utils.ScryptPFlag,
 				},
 				Description: `
     gman account update <address>
@@ -164,15 +204,30 @@ changing your password is only possible interactively.
 					utils.KeyStoreDirFlag,
 					utils.PasswordFileFlag,
 					utils.LightKDFFlag,
+					utils.KDFFlag,
+					utils.ScryptNFlag,
+					utils.ScryptPFlag,
+					accountImportRawFlag,
+					accountMnemonicFlag,
+					accountHDPathFlag,
 				},
 				ArgsUsage: "<keyFile>",
 				Description: `
     gman account import <keyfile>
 
-Imports an unencrypted private key from <keyfile> and creates a new account.
+Imports a private key from <keyfile> and creates a new account.
 Prints the address.
 
-The keyfile is assumed to contain an unencrypted private key in hexadecimal format.
+The keyfile may contain a standard V3 JSON key file, as exported by other
+wallets, or an unencrypted private key in hexadecimal format. JSON key files
+are detected automatically, you are prompted for the passphrase they are
+encrypted with first. Use --import-raw to always treat the keyfile as a hex
+private key.
+
+With --mnemonic the keyfile is read as a BIP-39 mnemonic sentence and the key
+is derived along --hdpath (m/44'/60'/0'/0/0 by default, the first account of
+most wallets). The mnemonic checksum is not verified, so compare the printed
+address with the one your previous wallet shows.
 
 The account is saved in encrypted format, you are prompted for a passphrase.
 
@@ -182,10 +237,71 @@ For non-interactive use the passphrase can be specified with the -password flag:
 
     gman account import [options] <keyfile>
 
+When importing a JSON key file the password file holds its passphrase on the
+first line and the passphrase of the new account on the second.
+
 Note:
 As you can directly copy your encrypted accounts to another matrix instance,
 this import mechanism is not needed when you transfer an account between
 nodes.
+`,
+			},
+			{
+				Name:   "export",
+				Usage:  "Export the private key of an existing account",
+				Action: utils.MigrateFlags(accountExport),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.KeyStoreDirFlag,
+					utils.PasswordFileFlag,
+					utils.LightKDFFlag,
+					utils.KDFFlag,
+					utils.ScryptNFlag,
+					utils.ScryptPFlag,
+					accountAddressFlag,
+					accountFormatFlag,
+					accountOutFlag,
+				},
+				Description: `
+    gman account export --address <address> [--format json|hex] [--out <file>]
+
+Exports the private key of an account, either as a standard V3 JSON key file
+encrypted with a new passphrase, or as an unencrypted hex private key that can
+be imported by other wallets. You are prompted for the passphrase of the
+account, and for the passphrase of the exported JSON key file.
+
+The key is written to the file given with --out, or to standard output.
+Anyone who obtains an unencrypted key controls the account, handle it with care.
+
+For non-interactive use the passphrases can be specified with the --password
+flag, the passphrase of the account on the first line and the passphrase of the
+exported JSON key file on the second.
+`,
+			},
+			{
+				Name:      "upgrade-kdf",
+				Usage:     "Re-encrypt existing accounts with the configured key derivation function",
+				Action:    utils.MigrateFlags(accountUpgradeKDF),
+				ArgsUsage: "[<address> ...]",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.KeyStoreDirFlag,
+					utils.PasswordFileFlag,
+					utils.LightKDFFlag,
+					utils.KDFFlag,
+					utils.ScryptNFlag,
+					utils.ScryptPFlag,
+				},
+				Description: `
+    gman account upgrade-kdf --kdf argon2id [<address> ...]
+
+Re-encrypts the key files of the given accounts, or of all accounts in the
+keystore if none are given, using the key derivation function selected with
+--kdf, --kdf.scryptn, --kdf.scryptp and --lightkdf. The passphrase of each
+account is kept unchanged.
+
+For non-interactive use the passphrases can be specified with the --password
+flag, one per line in the order the accounts are processed.
 `,
 			},
 		},
@@ -299,15 +415,18 @@ func accountCreate(ctx *cli.Context) error {
 		}
 	}
 	utils.SetNodeConfig(ctx, &cfg.Node)
-	scryptN, scryptP, keydir, err := cfg.Node.AccountConfig()
-
+	_, _, keydir, err := cfg.Node.AccountConfig()
+	if err != nil {
+		utils.Fatalf("Failed to read configuration: %v", err)
+	}
+	kdf, err := cfg.Node.KDFConfig()
 	if err != nil {
 		utils.Fatalf("Failed to read configuration: %v", err)
 	}
 
 	password := getPassPhrase("Your new account is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
 
-	address, err := keystore.StoreKey(keydir, password, scryptN, scryptP)
+	address, err := keystore.StoreKeyWithKDF(keydir, password, kdf)
 
 	if err != nil {
 		utils.Fatalf("Failed to create account: %v", err)
@@ -335,6 +454,32 @@ func accountUpdate(ctx *cli.Context) error {
 	return nil
 }
 
+// accountUpgradeKDF re-encrypts existing key files with the key derivation
+// function configured through the CLI flags, keeping their pass-phrases.
+func accountUpgradeKDF(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+
+	addrs := ctx.Args()
+	if len(addrs) == 0 {
+		for _, account := range ks.Accounts() {
+			addrs = append(addrs, account.Address.Hex())
+		}
+	}
+	if len(addrs) == 0 {
+		utils.Fatalf("No accounts to upgrade")
+	}
+	passwords := utils.MakePasswordList(ctx)
+	for i, addr := range addrs {
+		account, password := unlockAccount(ctx, ks, addr, i, passwords)
+		if err := ks.Update(account, password, password); err != nil {
+			utils.Fatalf("Could not upgrade the account: %v", err)
+		}
+		fmt.Printf("Upgraded: {%x} %s\n", account.Address, &account.URL)
+	}
+	return nil
+}
+
 func importWallet(ctx *cli.Context) error {
 	keyfile := ctx.Args().First()
 	if len(keyfile) == 0 {
@@ -357,19 +502,51 @@ func importWallet(ctx *cli.Context) error {
 	return nil
 }
 
+// accountImport creates a new account from a V3 JSON key file, a raw hex
+// private key or a BIP-39 mnemonic.
 func accountImport(ctx *cli.Context) error {
 	keyfile := ctx.Args().First()
 	if len(keyfile) == 0 {
 		utils.Fatalf("keyfile must be given as argument")
 	}
-	key, err := crypto.LoadECDSA(keyfile)
-	if err != nil {
-		utils.Fatalf("Failed to load the private key: %v", err)
-	}
 	stack, _ := makeConfigNode(ctx)
-	passphrase := getPassPhrase("Your new account is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
-
 	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	passwords := utils.MakePasswordList(ctx)
+
+	var (
+		key *ecdsa.PrivateKey
+		err error
+	)
+	switch {
+	case ctx.Bool(accountMnemonicFlag.Name):
+		key, err = loadMnemonicKey(keyfile, ctx.String(accountHDPathFlag.Name))
+		if err != nil {
+			utils.Fatalf("Failed to derive the private key: %v", err)
+		}
+
+	case !ctx.Bool(accountImportRawFlag.Name) && isKeyJSON(keyfile):
+		keyJSON, err := ioutil.ReadFile(keyfile)
+		if err != nil {
+			utils.Fatalf("Could not read key file: %v", err)
+		}
+		passphrase := getPassPhrase("Please give the password the key file is encrypted with.", false, 0, passwords)
+		newPassphrase := getPassPhrase("Your new account is locked with a password. Please give a password. Do not forget this password.", true, 1, passwords)
+
+		acct, err := ks.Import(keyJSON, passphrase, newPassphrase)
+		if err != nil {
+			utils.Fatalf("Could not import the key file: %v", err)
+		}
+		fmt.Printf("Address: {%x}\n", acct.Address)
+		return nil
+
+	default:
+		key, err = crypto.LoadECDSA(keyfile)
+		if err != nil {
+			utils.Fatalf("Failed to load the private key: %v", err)
+		}
+	}
+	passphrase := getPassPhrase("Your new account is locked with a password. Please give a password. Do not forget this password.", true, 0, passwords)
+
 	acct, err := ks.ImportECDSA(key, passphrase)
 	if err != nil {
 		utils.Fatalf("Could not create the account: %v", err)
@@ -377,3 +554,78 @@ func accountImport(ctx *cli.Context) error {
 	fmt.Printf("Address: {%x}\n", acct.Address)
 	return nil
 }
+
+// isKeyJSON reports whether a key file holds a JSON encoded key rather than a
+// raw hex one.
+func isKeyJSON(keyfile string) bool {
+	data, err := ioutil.ReadFile(keyfile)
+	if err != nil {
+		return false
+	}
+	var key map[string]interface{}
+	return json.Unmarshal(data, &key) == nil
+}
+
+// loadMnemonicKey derives the private key at the given HD path from the BIP-39
+// mnemonic stored in a file.
+func loadMnemonicKey(file string, hdpath string) (*ecdsa.PrivateKey, error) {
+	mnemonic, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	path, err := accounts.ParseDerivationPath(hdpath)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := accounts.NewSeedFromMnemonic(string(mnemonic), "")
+	if err != nil {
+		return nil, err
+	}
+	return accounts.DeriveKey(seed, path)
+}
+
+// accountExport writes the key of an account as a V3 JSON key file or as a raw
+// hex private key.
+func accountExport(ctx *cli.Context) error {
+	address := ctx.String(accountAddressFlag.Name)
+	if address == "" {
+		utils.Fatalf("Account to export must be given with --%s", accountAddressFlag.Name)
+	}
+	format := ctx.String(accountFormatFlag.Name)
+	if format != "json" && format != "hex" {
+		utils.Fatalf("Unknown export format %q, want json or hex", format)
+	}
+	stack, _ := makeConfigNode(ctx)
+	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	passwords := utils.MakePasswordList(ctx)
+
+	account, err := utils.MakeAddress(ks, address)
+	if err != nil {
+		utils.Fatalf("Could not find the account: %v", err)
+	}
+	passphrase := getPassPhrase(fmt.Sprintf("Please give the password of account %s.", address), false, 0, passwords)
+
+	var out []byte
+	switch format {
+	case "json":
+		newPassphrase := getPassPhrase("The exported key file is locked with a password. Please give a password. Do not forget this password.", true, 1, passwords)
+		if out, err = ks.Export(account, passphrase, newPassphrase); err != nil {
+			utils.Fatalf("Could not export the account: %v", err)
+		}
+	case "hex":
+		key, err := ks.ExportECDSA(account, passphrase)
+		if err != nil {
+			utils.Fatalf("Could not export the account: %v", err)
+		}
+		out = []byte(hex.EncodeToString(crypto.FromECDSA(key)))
+	}
+	if file := ctx.String(accountOutFlag.Name); file != "" {
+		if err := ioutil.WriteFile(file, append(out, '\n'), 0600); err != nil {
+			utils.Fatalf("Could not write the exported key: %v", err)
+		}
+		fmt.Printf("Exported {%x} to %s\n", account.Address, file)
+		return nil
+	}
+	fmt.Println(string(out))
+	return nil
+}
@@ -171,6 +171,10 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 		utils.RegisterShhService(stack, &cfg.Shh)
 	}
 
+	if endpoint := cfg.Node.GraphQLEndpoint(); endpoint != "" {
+		utils.RegisterGraphQLService(stack, endpoint, cfg.Node.GraphQLCors, cfg.Node.GraphQLVirtualHosts)
+	}
+
 	// Add the Matrix Stats daemon if requested.
 	if cfg.Ethstats.URL != "" {
 		utils.RegisterEthStatsService(stack, cfg.Ethstats.URL)
@@ -0,0 +1,269 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/matrix/go-matrix/cmd/utils"
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/log"
+	"github.com/matrix/go-matrix/mandb"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	dbCommand = cli.Command{
+		Name:      "db",
+		Usage:     "Manage the blockchain databases",
+		ArgsUsage: "",
+		Category:  "BLOCKCHAIN COMMANDS",
+		Description: `
+    gman db migrate --db.engine <engine>
+
+converts the blockchain databases of the data directory to another storage engine.
+
+    gman db shard --datadir.shards <dir1>,<dir2>,...
+
+splits the blockchain databases of the data directory across multiple disks.`,
+		Subcommands: []cli.Command{
+			{
+				Name:      "migrate",
+				Usage:     "Convert the blockchain databases to another storage engine",
+				ArgsUsage: " ",
+				Action:    utils.MigrateFlags(migrateDB),
+				Category:  "BLOCKCHAIN COMMANDS",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.DatabaseEngineFlag,
+					utils.CacheFlag,
+				},
+				Description: `
+    gman db migrate --db.engine <engine>
+
+Copies every entry of the chaindata and lightchaindata databases into a new
+database of the engine selected by --db.engine, then swaps the new database
+into place. The original is kept next to it with a .<engine>.bak suffix and
+can be removed once the node runs fine on the new engine.
+
+The available engines are leveldb and badger. Migrating back to leveldb works
+the same way.
+
+The node must not be running during the migration.`,
+			},
+			{
+				Name:      "shard",
+				Usage:     "Split the blockchain databases across the configured shard directories",
+				ArgsUsage: " ",
+				Action:    utils.MigrateFlags(shardDB),
+				Category:  "BLOCKCHAIN COMMANDS",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.DatabaseEngineFlag,
+					utils.DatabaseShardsFlag,
+					utils.CacheFlag,
+				},
+				Description: `
+    gman db shard --datadir.shards <dir1>,<dir2>,...
+
+Copies every entry of the chaindata and lightchaindata databases of the data
+directory into a new database split across the directories given with
+--datadir.shards, typically one per disk. The original database is renamed
+with a .unsharded.bak suffix and can be removed once the node runs fine with
+the shards. Ancient chain segments are not moved.
+
+The node must be started with the same --datadir.shards list, in the same
+order, from then on. The node must not be running during the conversion.`,
+			},
+		},
+	}
+)
+
+func migrateDB(ctx *cli.Context) error {
+	stack, cfg := makeConfigNode(ctx)
+
+	if !ctx.GlobalIsSet(utils.DatabaseEngineFlag.Name) {
+		utils.Fatalf("No target engine given with --%s", utils.DatabaseEngineFlag.Name)
+	}
+	target := cfg.Node.DatabaseEngine
+	if !mandb.HasEngine(target) {
+		utils.Fatalf("Storage engine %q is not built into this binary, available: %s", target, strings.Join(mandb.Engines(), ", "))
+	}
+	cache := ctx.GlobalInt(utils.CacheFlag.Name) / 2
+
+	for _, name := range []string{"chaindata", "lightchaindata"} {
+		logger := log.New("database", name)
+
+		dbdir := stack.ResolvePath(name)
+		source, err := mandb.DetectEngine(dbdir)
+		if err != nil {
+			utils.Fatalf("Failed to detect database engine: %v", err)
+		}
+		switch source {
+		case "":
+			logger.Info("Database doesn't exist, skipping", "path", dbdir)
+		case target:
+			logger.Info("Database already uses the engine, skipping", "path", dbdir, "engine", target)
+		default:
+			if err := migrateDatabase(logger, dbdir, source, target, cache); err != nil {
+				utils.Fatalf("Failed to migrate database %s: %v", dbdir, err)
+			}
+		}
+	}
+	return nil
+}
+
+// migrateDatabase copies the database at dbdir into a fresh one of the target
+// engine and swaps the two once the copy completed.
+func migrateDatabase(logger log.Logger, dbdir string, source, target string, cache int) error {
+	tmpdir := dbdir + ".migrating"
+	if common.FileExist(tmpdir) {
+		return fmt.Errorf("leftover of an interrupted migration at %s, remove it first", tmpdir)
+	}
+	src, err := mandb.Open(source, dbdir, cache/2, 256)
+	if err != nil {
+		return err
+	}
+	dst, err := mandb.Open(target, tmpdir, cache/2, 256)
+	if err != nil {
+		src.Close()
+		return err
+	}
+	logger.Info("Migrating database", "from", source, "to", target)
+
+	var (
+		start  = time.Now()
+		logged = start
+	)
+	entries, err := mandb.Migrate(dst, src, func(entries int) {
+		if time.Since(logged) > 8*time.Second {
+			logger.Info("Migrating database", "entries", entries, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	})
+	if err == nil {
+		err = dst.Compact()
+	}
+	dst.Close()
+	src.Close()
+
+	if err != nil {
+		os.RemoveAll(tmpdir)
+		return err
+	}
+	backup := dbdir + "." + source + ".bak"
+	if err := os.Rename(dbdir, backup); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpdir, dbdir); err != nil {
+		return err
+	}
+	logger.Info("Database migrated", "engine", target, "entries", entries, "elapsed", common.PrettyDuration(time.Since(start)), "backup", backup)
+	return nil
+}
+
+func shardDB(ctx *cli.Context) error {
+	stack, cfg := makeConfigNode(ctx)
+
+	if len(cfg.Node.DatabaseShards) == 0 {
+		utils.Fatalf("No shard directories given with --%s", utils.DatabaseShardsFlag.Name)
+	}
+	cache := ctx.GlobalInt(utils.CacheFlag.Name) / 2
+
+	for _, name := range []string{"chaindata", "lightchaindata"} {
+		logger := log.New("database", name)
+
+		dbdir := stack.ResolvePath(name)
+		engine, err := mandb.DetectEngine(dbdir)
+		if err != nil {
+			utils.Fatalf("Failed to detect database engine: %v", err)
+		}
+		if engine == "" {
+			logger.Info("Database doesn't exist, skipping", "path", dbdir)
+			continue
+		}
+		if err := shardDatabase(logger, dbdir, engine, cfg.Node.DatabaseEngine, cfg.Node.ShardPaths(name), cache); err != nil {
+			utils.Fatalf("Failed to shard database %s: %v", dbdir, err)
+		}
+	}
+	return nil
+}
+
+// shardDatabase copies the database at dbdir into a fresh sharded one of the
+// target engine and moves the original out of the way once the copy completed.
+func shardDatabase(logger log.Logger, dbdir string, source, target string, shards []string, cache int) error {
+	for _, shard := range shards {
+		if shard == dbdir {
+			return fmt.Errorf("shard %s is the database being sharded", shard)
+		}
+		if common.FileExist(shard) {
+			return fmt.Errorf("shard %s already exists, remove it first", shard)
+		}
+	}
+	src, err := mandb.Open(source, dbdir, cache/2, 256)
+	if err != nil {
+		return err
+	}
+	dst, err := mandb.OpenSharded(target, shards, cache/2, 256)
+	if err != nil {
+		src.Close()
+		return err
+	}
+	logger.Info("Sharding database", "shards", len(shards))
+
+	var (
+		start  = time.Now()
+		logged = start
+	)
+	entries, err := mandb.Migrate(dst, src, func(entries int) {
+		if time.Since(logged) > 8*time.Second {
+			logger.Info("Sharding database", "entries", entries, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	})
+	if err == nil {
+		err = dst.Compact()
+	}
+	dst.Close()
+	src.Close()
+
+	if err != nil {
+		for _, shard := range shards {
+			os.RemoveAll(shard)
+		}
+		return err
+	}
+	// Keep the ancient store where the node looks for it by default
+	backup := dbdir + ".unsharded.bak"
+	if err := os.Rename(dbdir, backup); err != nil {
+		return err
+	}
+	if common.FileExist(filepath.Join(backup, "ancient")) {
+		if err := os.MkdirAll(dbdir, 0755); err != nil {
+			return err
+		}
+		if err := os.Rename(filepath.Join(backup, "ancient"), filepath.Join(dbdir, "ancient")); err != nil {
+			return err
+		}
+	}
+	logger.Info("Database sharded", "shards", len(shards), "entries", entries, "elapsed", common.PrettyDuration(time.Since(start)), "backup", backup)
+	return nil
+}
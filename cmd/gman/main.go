@@ -32,10 +32,10 @@ import (
 	"github.com/matrix/go-matrix/boot"
 	"github.com/matrix/go-matrix/cmd/utils"
 	"github.com/matrix/go-matrix/console"
-	"github.com/matrix/go-matrix/man"
-	"github.com/matrix/go-matrix/manclient"
 	"github.com/matrix/go-matrix/internal/debug"
 	"github.com/matrix/go-matrix/log"
+	"github.com/matrix/go-matrix/man"
+	"github.com/matrix/go-matrix/manclient"
 	"github.com/matrix/go-matrix/metrics"
 	"github.com/matrix/go-matrix/node"
 	"github.com/matrix/go-matrix/params"
@@ -59,8 +59,12 @@ var (
 		utils.BootnodesV4Flag,
 		utils.BootnodesV5Flag,
 		utils.DataDirFlag,
+		utils.AncientFlag,
+		utils.DatabaseShardsFlag,
+		utils.DatabaseEngineFlag,
 		utils.KeyStoreDirFlag,
 		utils.NoUSBFlag,
+		utils.ExternalSignerFlag,
 		utils.DashboardEnabledFlag,
 		utils.DashboardAddrFlag,
 		utils.DashboardPortFlag,
@@ -85,16 +89,22 @@ var (
 		utils.LightModeFlag,
 		utils.SyncModeFlag,
 		utils.GCModeFlag,
+		utils.GCWindowFlag,
 		utils.LightServFlag,
 		utils.LightPeersFlag,
 		utils.LightKDFFlag,
+		utils.KDFFlag,
+		utils.ScryptNFlag,
+		utils.ScryptPFlag,
 		utils.CacheFlag,
 		utils.CacheDatabaseFlag,
+		utils.CacheTrieFlag,
 		utils.CacheGCFlag,
 		utils.TrieCacheGenFlag,
 		utils.ListenPortFlag,
 		utils.MaxPeersFlag,
 		utils.MaxPendingPeersFlag,
+		utils.RekeyIntervalFlag,
 		utils.EtherbaseFlag,
 		utils.GasPriceFlag,
 		utils.MinerThreadsFlag,
@@ -103,6 +113,7 @@ var (
 		utils.NATFlag,
 		utils.NoDiscoverFlag,
 		utils.DiscoveryV5Flag,
+		utils.DiscoveryDNSFlag,
 		utils.NetrestrictFlag,
 		utils.NodeKeyFileFlag,
 		utils.NodeKeyHexFlag,
@@ -116,10 +127,23 @@ var (
 		utils.RPCVirtualHostsFlag,
 		utils.EthStatsURLFlag,
 		utils.MetricsEnabledFlag,
+		utils.MetricsAddrFlag,
 		utils.FakePoWFlag,
 		utils.NoCompactionFlag,
 		utils.GpoBlocksFlag,
 		utils.GpoPercentileFlag,
+		utils.RelaySponsorFlag,
+		utils.RelayContractsFlag,
+		utils.RelayGasLimitFlag,
+		utils.RelayQuotaFlag,
+		utils.RelayQuotaPeriodFlag,
+		utils.SlashingReporterFlag,
+		utils.SlashingContractFlag,
+		utils.SlashingGasLimitFlag,
+		utils.ContractVerifyFlag,
+		utils.ContractVerifyIPFSFlag,
+		utils.ContractVerifySwarmFlag,
+		utils.ContractVerifySolcFlag,
 		utils.ExtraDataFlag,
 		configFileFlag,
 		utils.TestLocalMiningFlag,
@@ -132,11 +156,20 @@ var (
 		utils.RPCListenAddrFlag,
 		utils.RPCPortFlag,
 		utils.RPCApiFlag,
+		utils.RPCRateLimitFlag,
 		utils.WSEnabledFlag,
 		utils.WSListenAddrFlag,
 		utils.WSPortFlag,
 		utils.WSApiFlag,
 		utils.WSAllowedOriginsFlag,
+		utils.GraphQLEnabledFlag,
+		utils.GraphQLListenAddrFlag,
+		utils.GraphQLPortFlag,
+		utils.GraphQLCORSDomainFlag,
+		utils.GraphQLVirtualHostsFlag,
+		utils.GRPCEnabledFlag,
+		utils.GRPCListenAddrFlag,
+		utils.GRPCPortFlag,
 		utils.IPCDisabledFlag,
 		utils.IPCPathFlag,
 	}
@@ -158,16 +191,23 @@ func init() {
 		initCommand,
 		importCommand,
 		exportCommand,
+		exportHistoryCommand,
+		importHistoryCommand,
 		importPreimagesCommand,
 		exportPreimagesCommand,
 		copydbCommand,
 		removedbCommand,
+		dbCommand,
 		dumpCommand,
+		// See replaycmd.go:
+		replayCommand,
 		// See monitorcmd.go:
 		monitorCommand,
 		// See accountcmd.go:
 		accountCommand,
 		walletCommand,
+		// See signtxcmd.go:
+		signTxCommand,
 		// See consolecmd.go:
 		consoleCommand,
 		attachCommand,
@@ -202,6 +242,7 @@ func init() {
 
 		// Start system runtime metrics collection
 		go metrics.CollectProcessMetrics(3 * time.Second)
+		utils.SetupMetrics(ctx)
 
 		utils.SetupNetwork(ctx)
 		return nil
@@ -242,14 +283,16 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 	// Start up the node itself
 	utils.StartNode(stack)
 
-	// Unlock any account specifically requested
-	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	// Unlock any account specifically requested (external signers unlock by themselves)
+	if backends := stack.AccountManager().Backends(keystore.KeyStoreType); len(backends) > 0 {
+		ks := backends[0].(*keystore.KeyStore)
 
-	passwords := utils.MakePasswordList(ctx)
-	unlocks := strings.Split(ctx.GlobalString(utils.UnlockedAccountFlag.Name), ",")
-	for i, account := range unlocks {
-		if trimmed := strings.TrimSpace(account); trimmed != "" {
-			unlockAccount(ctx, ks, trimmed, i, passwords)
+		passwords := utils.MakePasswordList(ctx)
+		unlocks := strings.Split(ctx.GlobalString(utils.UnlockedAccountFlag.Name), ",")
+		for i, account := range unlocks {
+			if trimmed := strings.TrimSpace(account); trimmed != "" {
+				unlockAccount(ctx, ks, trimmed, i, passwords)
+			}
 		}
 	}
 	// Register wallet event handlers to open and auto-derive wallets
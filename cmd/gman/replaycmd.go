@@ -0,0 +1,204 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/matrix/go-matrix/cmd/utils"
+	"github.com/matrix/go-matrix/core"
+	"github.com/matrix/go-matrix/core/rawdb"
+	"github.com/matrix/go-matrix/core/state"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/core/vm"
+	"github.com/matrix/go-matrix/log"
+	"github.com/matrix/go-matrix/mandb"
+	"github.com/matrix/go-matrix/rlp"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	replayFromFlag = cli.Uint64Flag{
+		Name:  "from",
+		Value: 1,
+		Usage: "First block to re-execute",
+	}
+	replayToFlag = cli.Uint64Flag{
+		Name:  "to",
+		Usage: "Last block to re-execute (default = current head)",
+	}
+	replayCommand = cli.Command{
+		Action:    utils.MigrateFlags(replayChain),
+		Name:      "replay",
+		Usage:     "Re-execute a range of local blocks and report the first divergence",
+		ArgsUsage: " ",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.CacheFlag,
+			utils.GCModeFlag,
+			utils.TestnetFlag,
+			utils.RinkebyFlag,
+			replayFromFlag,
+			replayToFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The replay command re-executes the blocks between --from and --to from the local
+database, each one on top of a freshly opened copy of its parent state, and
+compares the resulting receipts, gas usage and state root against the stored
+block. On the first divergence the EVM trace of the offending transaction is
+written to stdout and the command fails.
+
+Replaying requires the parent state of every block in the range to be present,
+so older ranges are only available on nodes running with --gcmode=archive.`,
+	}
+)
+
+// replayDivergence describes the first mismatch found while replaying a block.
+type replayDivergence struct {
+	tx     int // index of the offending transaction, -1 if it cannot be pinned down
+	reason string
+}
+
+func replayChain(ctx *cli.Context) error {
+	stack := makeFullNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer chainDb.Close()
+
+	from, to := ctx.Uint64(replayFromFlag.Name), ctx.Uint64(replayToFlag.Name)
+	if !ctx.IsSet(replayToFlag.Name) {
+		to = chain.CurrentBlock().NumberU64()
+	}
+	if from == 0 {
+		utils.Fatalf("The genesis block cannot be replayed")
+	}
+	if from > to {
+		utils.Fatalf("Invalid block range: from %d > to %d", from, to)
+	}
+	var (
+		start  = time.Now()
+		logged = time.Now()
+	)
+	for number := from; number <= to; number++ {
+		block := chain.GetBlockByNumber(number)
+		if block == nil {
+			utils.Fatalf("Block #%d not found in the local database", number)
+		}
+		div, err := replayBlock(chain, chainDb, block)
+		if err != nil {
+			utils.Fatalf("Failed to replay block #%d: %v", number, err)
+		}
+		if div != nil {
+			fmt.Printf("Block #%d [%x] diverged: %s\n", number, block.Hash(), div.reason)
+			if div.tx >= 0 {
+				tx := block.Transactions()[div.tx]
+				fmt.Printf("Trace of transaction %d [%x]:\n", div.tx, tx.Hash())
+
+				tracer := vm.NewStructLogger(nil)
+				if _, err := replayTransactions(chain, chainDb, block, div.tx, tracer); err != nil {
+					fmt.Printf("error: %v\n", err)
+				}
+				vm.WriteTrace(os.Stdout, tracer.StructLogs())
+			}
+			utils.Fatalf("Replay diverged at block #%d", number)
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Replaying blocks", "number", number, "remaining", to-number, "elapsed", time.Since(start))
+			logged = time.Now()
+		}
+	}
+	fmt.Printf("Replayed %d blocks in %v, no divergence found\n", to-from+1, time.Since(start))
+	return nil
+}
+
+// replayBlock re-executes a single block on top of its parent state, returning
+// the first divergence from the stored block, or nil if the two agree.
+func replayBlock(chain *core.BlockChain, db mandb.Database, block *types.Block) (*replayDivergence, error) {
+	statedb, err := replayParentState(chain, db, block)
+	if err != nil {
+		return nil, err
+	}
+	receipts, _, usedGas, err := chain.Processor().Process(block, statedb, vm.Config{})
+	if err != nil {
+		// Processing aborted, re-apply one by one to find the failing transaction
+		failed, _ := replayTransactions(chain, db, block, len(block.Transactions())-1, nil)
+		return &replayDivergence{tx: failed, reason: fmt.Sprintf("processing failed: %v", err)}, nil
+	}
+	stored := rawdb.ReadReceipts(db, block.Hash(), block.NumberU64())
+	for i, receipt := range receipts {
+		if i >= len(stored) {
+			return &replayDivergence{tx: i, reason: fmt.Sprintf("receipt %d missing from the database", i)}, nil
+		}
+		have, _ := rlp.EncodeToBytes(receipt)
+		want, _ := rlp.EncodeToBytes(stored[i])
+		if !bytes.Equal(have, want) {
+			return &replayDivergence{tx: i, reason: fmt.Sprintf("receipt %d mismatch: have status %d, gas %d, %d logs; want status %d, gas %d, %d logs",
+				i, receipt.Status, receipt.CumulativeGasUsed, len(receipt.Logs), stored[i].Status, stored[i].CumulativeGasUsed, len(stored[i].Logs))}, nil
+		}
+	}
+	if usedGas != block.GasUsed() {
+		return &replayDivergence{tx: -1, reason: fmt.Sprintf("gas used mismatch: have %d, want %d", usedGas, block.GasUsed())}, nil
+	}
+	if root := statedb.IntermediateRoot(chain.Config().IsEIP158(block.Number())); root != block.Root() {
+		return &replayDivergence{tx: -1, reason: fmt.Sprintf("state root mismatch: have %x, want %x", root, block.Root())}, nil
+	}
+	return nil, nil
+}
+
+// replayTransactions applies the transactions of block up to and including the
+// one at index last on top of its parent state, running the final one with the
+// given tracer if any. It returns the index of the first transaction that
+// failed to apply, or -1 if all of them succeeded.
+func replayTransactions(chain *core.BlockChain, db mandb.Database, block *types.Block, last int, tracer vm.Tracer) (int, error) {
+	statedb, err := replayParentState(chain, db, block)
+	if err != nil {
+		return -1, err
+	}
+	var (
+		header  = block.Header()
+		gp      = new(core.GasPool).AddGas(block.GasLimit())
+		usedGas = new(uint64)
+	)
+	for i, tx := range block.Transactions()[:last+1] {
+		cfg := vm.Config{}
+		if i == last && tracer != nil {
+			cfg = vm.Config{Debug: true, Tracer: tracer}
+		}
+		statedb.Prepare(tx.Hash(), block.Hash(), i)
+		if _, _, err := core.ApplyTransaction(chain.Config(), chain, nil, gp, statedb, header, tx, usedGas, cfg); err != nil {
+			return i, err
+		}
+	}
+	return -1, nil
+}
+
+// replayParentState opens a fresh copy of the state a block was built on,
+// bypassing the chain's in-memory caches.
+func replayParentState(chain *core.BlockChain, db mandb.Database, block *types.Block) (*state.StateDB, error) {
+	parent := chain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent block %x missing", block.ParentHash())
+	}
+	statedb, err := state.New(parent.Root(), state.NewDatabase(db))
+	if err != nil {
+		return nil, fmt.Errorf("state of block #%d unavailable (replay needs --gcmode=archive): %v", parent.NumberU64(), err)
+	}
+	return statedb, nil
+}
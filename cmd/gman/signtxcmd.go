@@ -0,0 +1,177 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/matrix/go-matrix/accounts/keystore"
+	"github.com/matrix/go-matrix/cmd/utils"
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/common/hexutil"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/rlp"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	signTxKeyFileFlag = cli.StringFlag{
+		Name:  "keyfile",
+		Usage: "Keystore file holding the signing key",
+	}
+	signTxChainIdFlag = cli.Uint64Flag{
+		Name:  "chainid",
+		Usage: "Chain ID to bind the signature to (EIP-155)",
+	}
+	signTxCommand = cli.Command{
+		Action:    utils.MigrateFlags(signTx),
+		Name:      "signtx",
+		Usage:     "Sign a transaction offline with a keystore file",
+		ArgsUsage: "<txfile>",
+		Flags: []cli.Flag{
+			signTxKeyFileFlag,
+			signTxChainIdFlag,
+			utils.PasswordFileFlag,
+		},
+		Category: "ACCOUNT COMMANDS",
+		Description: `
+    gman signtx --keyfile <keyfile> --chainid <id> [--password <file>] <txfile>
+
+Signs the unsigned transaction stored as JSON in <txfile> with the key held in
+<keyfile> and prints the RLP encoded signed transaction, ready to be submitted
+with man_sendRawTransaction from a connected machine.
+
+The transaction file uses the same fields as man_sendTransaction, except that
+nonce, gas and gasPrice are mandatory since they cannot be filled in without a
+node:
+
+    {
+      "to":       "0x...",
+      "nonce":    "0x0",
+      "gas":      "0x5208",
+      "gasPrice": "0x4a817c800",
+      "value":    "0xde0b6b3a7640000",
+      "input":    "0x"
+    }
+
+If "from" is present it must match the address of the key. Leaving out "to"
+creates a contract.
+
+The command never opens a database or the network, so it is safe to run on an
+air-gapped machine.`,
+	}
+)
+
+// offlineTxArgs is the JSON representation of an unsigned transaction accepted
+// by the signtx command.
+type offlineTxArgs struct {
+	From     *common.Address `json:"from"`
+	To       *common.Address `json:"to"`
+	Gas      *hexutil.Uint64 `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	Value    *hexutil.Big    `json:"value"`
+	Nonce    *hexutil.Uint64 `json:"nonce"`
+	// Both "data" and "input" are accepted, mirroring man_sendTransaction.
+	Data  *hexutil.Bytes `json:"data"`
+	Input *hexutil.Bytes `json:"input"`
+}
+
+// toTransaction validates the arguments and assembles the unsigned transaction.
+func (args *offlineTxArgs) toTransaction() (*types.Transaction, error) {
+	switch {
+	case args.Nonce == nil:
+		return nil, fmt.Errorf("missing nonce")
+	case args.Gas == nil:
+		return nil, fmt.Errorf("missing gas")
+	case args.GasPrice == nil:
+		return nil, fmt.Errorf("missing gasPrice")
+	}
+	if args.Data != nil && args.Input != nil && !bytes.Equal(*args.Data, *args.Input) {
+		return nil, fmt.Errorf(`both "data" and "input" are set and not equal`)
+	}
+	var input []byte
+	if args.Data != nil {
+		input = *args.Data
+	} else if args.Input != nil {
+		input = *args.Input
+	}
+	if args.To == nil && len(input) == 0 {
+		return nil, fmt.Errorf("contract creation without any data provided")
+	}
+	value := new(big.Int)
+	if args.Value != nil {
+		value = args.Value.ToInt()
+	}
+	if args.To == nil {
+		return types.NewContractCreation(uint64(*args.Nonce), value, uint64(*args.Gas), args.GasPrice.ToInt(), input), nil
+	}
+	return types.NewTransaction(uint64(*args.Nonce), *args.To, value, uint64(*args.Gas), args.GasPrice.ToInt(), input), nil
+}
+
+func signTx(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	keyfile := ctx.String(signTxKeyFileFlag.Name)
+	if keyfile == "" {
+		utils.Fatalf("A keystore file must be given with --%s", signTxKeyFileFlag.Name)
+	}
+	if !ctx.IsSet(signTxChainIdFlag.Name) {
+		utils.Fatalf("A chain ID must be given with --%s", signTxChainIdFlag.Name)
+	}
+	chainId := new(big.Int).SetUint64(ctx.Uint64(signTxChainIdFlag.Name))
+
+	// Assemble the unsigned transaction
+	txjson, err := ioutil.ReadFile(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Failed to read the transaction file: %v", err)
+	}
+	var args offlineTxArgs
+	if err := json.Unmarshal(txjson, &args); err != nil {
+		utils.Fatalf("Failed to decode the transaction file: %v", err)
+	}
+	tx, err := args.toTransaction()
+	if err != nil {
+		utils.Fatalf("Invalid transaction: %v", err)
+	}
+	// Decrypt the key and sign
+	keyjson, err := ioutil.ReadFile(keyfile)
+	if err != nil {
+		utils.Fatalf("Failed to read the keystore file: %v", err)
+	}
+	passphrase := getPassPhrase("", false, 0, utils.MakePasswordList(ctx))
+	key, err := keystore.DecryptKey(keyjson, passphrase)
+	if err != nil {
+		utils.Fatalf("Failed to decrypt the keystore file: %v", err)
+	}
+	if args.From != nil && *args.From != key.Address {
+		utils.Fatalf("Transaction sender %s does not match key address %s", args.From.Hex(), key.Address.Hex())
+	}
+	signed, err := types.SignTx(tx, types.NewEIP155Signer(chainId), key.PrivateKey)
+	if err != nil {
+		utils.Fatalf("Failed to sign the transaction: %v", err)
+	}
+	enc, err := rlp.EncodeToBytes(signed)
+	if err != nil {
+		utils.Fatalf("Failed to encode the transaction: %v", err)
+	}
+	fmt.Println(hexutil.Encode(enc))
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package main
+
+import (
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/matrix/go-matrix/accounts/keystore"
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/common/hexutil"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/rlp"
+)
+
+const signTxTestKey = "../../accounts/keystore/testdata/keystore/aaa"
+
+func writeTxFile(t *testing.T, content string) string {
+	file := filepath.Join(tmpdir(t), "tx.json")
+	if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestSignTx(t *testing.T) {
+	txfile := writeTxFile(t, `{
+		"to":       "0x289d485d9771714cce91d3393d764e1311907acc",
+		"nonce":    "0x3",
+		"gas":      "0x5208",
+		"gasPrice": "0x4a817c800",
+		"value":    "0x64"
+	}`)
+	// ECDSA signing is deterministic, so the expected output can be derived locally.
+	keyjson, err := ioutil.ReadFile(signTxTestKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := keystore.DecryptKey(keyjson, "foobar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := types.NewTransaction(3, common.HexToAddress("0x289d485d9771714cce91d3393d764e1311907acc"), big.NewInt(100), 21000, big.NewInt(20000000000), nil)
+	signed, err := types.SignTx(tx, types.NewEIP155Signer(big.NewInt(1)), key.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, _ := rlp.EncodeToBytes(signed)
+
+	gman := runGeth(t, "signtx", "--keyfile", signTxTestKey, "--chainid", "1",
+		"--password", "testdata/passwords.txt", txfile)
+	defer gman.ExpectExit()
+	gman.Expect(hexutil.Encode(enc) + "\n")
+}
+
+func TestSignTxSenderMismatch(t *testing.T) {
+	txfile := writeTxFile(t, `{
+		"from":     "0x7ef5a6135f1fd6a02593eedc869c6d41d934aef8",
+		"to":       "0x289d485d9771714cce91d3393d764e1311907acc",
+		"nonce":    "0x0",
+		"gas":      "0x5208",
+		"gasPrice": "0x1"
+	}`)
+	gman := runGeth(t, "signtx", "--keyfile", signTxTestKey, "--chainid", "1",
+		"--password", "testdata/passwords.txt", txfile)
+	defer gman.ExpectExit()
+	gman.Expect(`
+Fatal: Transaction sender 0x7EF5A6135f1FD6a02593eEdC869c6D41D934aef8 does not match key address 0xf466859eAD1932D743d622CB74FC058882E8648A
+`)
+}
+
+func TestSignTxMissingNonce(t *testing.T) {
+	txfile := writeTxFile(t, `{"to": "0x289d485d9771714cce91d3393d764e1311907acc", "gas": "0x5208", "gasPrice": "0x1"}`)
+	gman := runGeth(t, "signtx", "--keyfile", signTxTestKey, "--chainid", "1",
+		"--password", "testdata/passwords.txt", txfile)
+	defer gman.ExpectExit()
+	gman.Expect(`
+Fatal: Invalid transaction: missing nonce
+`)
+}
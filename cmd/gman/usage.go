@@ -69,18 +69,26 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			configFileFlag,
 			utils.DataDirFlag,
+			utils.AncientFlag,
+			utils.DatabaseShardsFlag,
+			utils.DatabaseEngineFlag,
 			utils.KeyStoreDirFlag,
 			utils.NoUSBFlag,
+			utils.ExternalSignerFlag,
 			utils.NetworkIdFlag,
 			utils.TestnetFlag,
 			utils.RinkebyFlag,
 			utils.SyncModeFlag,
 			utils.GCModeFlag,
+			utils.GCWindowFlag,
 			utils.EthStatsURLFlag,
 			utils.IdentityFlag,
 			utils.LightServFlag,
 			utils.LightPeersFlag,
 			utils.LightKDFFlag,
+			utils.KDFFlag,
+			utils.ScryptNFlag,
+			utils.ScryptPFlag,
 		},
 	},
 	{Name: "DEVELOPER CHAIN",
@@ -130,6 +138,7 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.CacheFlag,
 			utils.CacheDatabaseFlag,
+			utils.CacheTrieFlag,
 			utils.CacheGCFlag,
 			utils.TrieCacheGenFlag,
 		},
@@ -148,11 +157,20 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.RPCListenAddrFlag,
 			utils.RPCPortFlag,
 			utils.RPCApiFlag,
+			utils.RPCRateLimitFlag,
 			utils.WSEnabledFlag,
 			utils.WSListenAddrFlag,
 			utils.WSPortFlag,
 			utils.WSApiFlag,
 			utils.WSAllowedOriginsFlag,
+			utils.GraphQLEnabledFlag,
+			utils.GraphQLListenAddrFlag,
+			utils.GraphQLPortFlag,
+			utils.GraphQLCORSDomainFlag,
+			utils.GraphQLVirtualHostsFlag,
+			utils.GRPCEnabledFlag,
+			utils.GRPCListenAddrFlag,
+			utils.GRPCPortFlag,
 			utils.IPCDisabledFlag,
 			utils.IPCPathFlag,
 			utils.RPCCORSDomainFlag,
@@ -171,9 +189,11 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.ListenPortFlag,
 			utils.MaxPeersFlag,
 			utils.MaxPendingPeersFlag,
+			utils.RekeyIntervalFlag,
 			utils.NATFlag,
 			utils.NoDiscoverFlag,
 			utils.DiscoveryV5Flag,
+			utils.DiscoveryDNSFlag,
 			utils.NetrestrictFlag,
 			utils.NodeKeyFileFlag,
 			utils.NodeKeyHexFlag,
@@ -200,6 +220,33 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.GpoPercentileFlag,
 		},
 	},
+	{
+		Name: "SPONSORED TRANSACTION RELAY",
+		Flags: []cli.Flag{
+			utils.RelaySponsorFlag,
+			utils.RelayContractsFlag,
+			utils.RelayGasLimitFlag,
+			utils.RelayQuotaFlag,
+			utils.RelayQuotaPeriodFlag,
+		},
+	},
+	{
+		Name: "SLASHING EVIDENCE",
+		Flags: []cli.Flag{
+			utils.SlashingReporterFlag,
+			utils.SlashingContractFlag,
+			utils.SlashingGasLimitFlag,
+		},
+	},
+	{
+		Name: "CONTRACT VERIFICATION",
+		Flags: []cli.Flag{
+			utils.ContractVerifyFlag,
+			utils.ContractVerifyIPFSFlag,
+			utils.ContractVerifySwarmFlag,
+			utils.ContractVerifySolcFlag,
+		},
+	},
 	{
 		Name: "VIRTUAL MACHINE",
 		Flags: []cli.Flag{
@@ -210,6 +257,7 @@ var AppHelpFlagGroups = []flagGroup{
 		Name: "LOGGING AND DEBUGGING",
 		Flags: append([]cli.Flag{
 			utils.MetricsEnabledFlag,
+			utils.MetricsAddrFlag,
 			utils.FakePoWFlag,
 			utils.NoCompactionFlag,
 		}, debug.Flags...),
@@ -19,6 +19,7 @@ package utils
 
 import (
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/big"
@@ -30,24 +31,31 @@ import (
 
 	"github.com/matrix/go-matrix/accounts"
 	"github.com/matrix/go-matrix/accounts/keystore"
+	"github.com/matrix/go-matrix/blkconsensus/slashing"
 	"github.com/matrix/go-matrix/common"
 	"github.com/matrix/go-matrix/common/fdlimit"
 	"github.com/matrix/go-matrix/consensus"
-	"github.com/matrix/go-matrix/consensus/clique"
+	_ "github.com/matrix/go-matrix/consensus/clique" // register the clique engine
 	"github.com/matrix/go-matrix/consensus/manash"
+	"github.com/matrix/go-matrix/contracts/verify"
 	"github.com/matrix/go-matrix/core"
+	"github.com/matrix/go-matrix/core/relay"
 	"github.com/matrix/go-matrix/core/state"
 	"github.com/matrix/go-matrix/core/vm"
 	"github.com/matrix/go-matrix/crypto"
 	"github.com/matrix/go-matrix/dashboard"
+	"github.com/matrix/go-matrix/graphql"
+	"github.com/matrix/go-matrix/internal/era"
+	"github.com/matrix/go-matrix/les"
+	"github.com/matrix/go-matrix/log"
 	"github.com/matrix/go-matrix/man"
 	"github.com/matrix/go-matrix/man/downloader"
 	"github.com/matrix/go-matrix/man/gasprice"
 	"github.com/matrix/go-matrix/mandb"
+	_ "github.com/matrix/go-matrix/mandb/badgerdb" // register the badger storage engine
 	"github.com/matrix/go-matrix/manstats"
-	"github.com/matrix/go-matrix/les"
-	"github.com/matrix/go-matrix/log"
 	"github.com/matrix/go-matrix/metrics"
+	"github.com/matrix/go-matrix/metrics/prometheus"
 	"github.com/matrix/go-matrix/node"
 	"github.com/matrix/go-matrix/p2p"
 	"github.com/matrix/go-matrix/p2p/discover"
@@ -55,6 +63,7 @@ import (
 	"github.com/matrix/go-matrix/p2p/nat"
 	"github.com/matrix/go-matrix/p2p/netutil"
 	"github.com/matrix/go-matrix/params"
+	"github.com/matrix/go-matrix/rpc"
 	whisper "github.com/matrix/go-matrix/whisper/whisperv6"
 	"gopkg.in/urfave/cli.v1"
 )
@@ -118,6 +127,19 @@ var (
 		Usage: "Data directory for the databases and keystore",
 		Value: DirectoryString{node.DefaultDataDir()},
 	}
+	DatabaseEngineFlag = cli.StringFlag{
+		Name:  "db.engine",
+		Usage: "Storage engine for the databases (" + strings.Join(mandb.Engines(), ", ") + ")",
+		Value: mandb.DefaultEngine,
+	}
+	AncientFlag = DirectoryFlag{
+		Name:  "datadir.ancient",
+		Usage: "Data directory for ancient chain segments (default = inside chaindata)",
+	}
+	DatabaseShardsFlag = cli.StringFlag{
+		Name:  "datadir.shards",
+		Usage: "Comma separated directories, one per disk, to split the chain database across",
+	}
 	KeyStoreDirFlag = DirectoryFlag{
 		Name:  "keystore",
 		Usage: "Directory for the keystore (default = inside the datadir)",
@@ -126,6 +148,10 @@ var (
 		Name:  "nousb",
 		Usage: "Disables monitoring for and managing USB hardware wallets",
 	}
+	ExternalSignerFlag = cli.StringFlag{
+		Name:  "signer",
+		Usage: "External signer (IPC path or url) to delegate all signing to, replacing the local keystore",
+	}
 	NetworkIdFlag = cli.Uint64Flag{
 		Name:  "networkid",
 		Usage: "Network identifier (integer, 1=Frontier, 2=Morden (disused), 3=Ropsten, 4=Rinkeby)",
@@ -167,14 +193,24 @@ var (
 	defaultSyncMode = man.DefaultConfig.SyncMode
 	SyncModeFlag    = TextMarshalerFlag{
 		Name:  "syncmode",
-		Usage: `Blockchain sync mode ("fast", "full", or "light")`,
+		Usage: `Blockchain sync mode ("fast", "full", "light" or "snap")`,
 		Value: &defaultSyncMode,
 	}
 	GCModeFlag = cli.StringFlag{
 		Name:  "gcmode",
-		Usage: `Blockchain garbage collection mode ("full", "archive")`,
+		Usage: `Blockchain garbage collection mode ("full", "archive", "window")`,
 		Value: "full",
 	}
+	GCWindowFlag = cli.Uint64Flag{
+		Name:  "gcwindow",
+		Usage: fmt.Sprintf("Number of recent blocks to retain state for in window garbage collection mode (minimum %d)", core.MinGCWindow),
+		Value: 128000,
+	}
+	EraSizeFlag = cli.Uint64Flag{
+		Name:  "era-size",
+		Usage: "Number of blocks per era file of exported history",
+		Value: era.DefaultSize,
+	}
 	LightServFlag = cli.IntFlag{
 		Name:  "lightserv",
 		Usage: "Maximum percentage of time allowed for serving LES requests (0-90)",
@@ -189,6 +225,19 @@ var (
 		Name:  "lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
 	}
+	KDFFlag = cli.StringFlag{
+		Name:  "kdf",
+		Usage: `Key derivation function used to encrypt key files ("scrypt" or "argon2id")`,
+		Value: keystore.KDFScrypt,
+	}
+	ScryptNFlag = cli.IntFlag{
+		Name:  "kdf.scryptn",
+		Usage: "Scrypt N parameter used to encrypt key files (0 = default)",
+	}
+	ScryptPFlag = cli.IntFlag{
+		Name:  "kdf.scryptp",
+		Usage: "Scrypt P parameter used to encrypt key files (0 = default)",
+	}
 	// Dashboard settings
 	DashboardEnabledFlag = cli.BoolFlag{
 		Name:  "dashboard",
@@ -298,11 +347,16 @@ var (
 	CacheDatabaseFlag = cli.IntFlag{
 		Name:  "cache.database",
 		Usage: "Percentage of cache memory allowance to use for database io",
-		Value: 75,
+		Value: 50,
+	}
+	CacheTrieFlag = cli.IntFlag{
+		Name:  "cache.trie",
+		Usage: "Percentage of cache memory allowance to use for caching clean trie nodes",
+		Value: 25,
 	}
 	CacheGCFlag = cli.IntFlag{
 		Name:  "cache.gc",
-		Usage: "Percentage of cache memory allowance to use for trie pruning",
+		Usage: "Percentage of cache memory allowance to use for dirty trie nodes before flushing them to disk",
 		Value: 25,
 	}
 	TrieCacheGenFlag = cli.IntFlag{
@@ -376,6 +430,10 @@ var (
 		Name:  metrics.MetricsEnabledFlag,
 		Usage: "Enable metrics collection and reporting",
 	}
+	MetricsAddrFlag = cli.StringFlag{
+		Name:  metrics.MetricsAddrFlag,
+		Usage: "Serve metrics to Prometheus on /metrics at the given listening address (e.g. 127.0.0.1:6061), implies --metrics",
+	}
 	FakePoWFlag = cli.BoolFlag{
 		Name:  "fakepow",
 		Usage: "Disables proof-of-work verification",
@@ -414,6 +472,11 @@ var (
 		Usage: "API's offered over the HTTP-RPC interface",
 		Value: "",
 	}
+	RPCRateLimitFlag = cli.StringFlag{
+		Name:  "rpc.ratelimit",
+		Usage: "Comma separated per client IP quotas of HTTP-RPC and WS-RPC methods as method=rate[:burst] in requests per second ('*' for all other methods)",
+		Value: "",
+	}
 	IPCDisabledFlag = cli.BoolFlag{
 		Name:  "ipcdisable",
 		Usage: "Disable the IPC-RPC server",
@@ -446,6 +509,44 @@ var (
 		Usage: "Origins from which to accept websockets requests",
 		Value: "",
 	}
+	GraphQLEnabledFlag = cli.BoolFlag{
+		Name:  "graphql",
+		Usage: "Enable the GraphQL server",
+	}
+	GraphQLListenAddrFlag = cli.StringFlag{
+		Name:  "graphql.addr",
+		Usage: "GraphQL server listening interface",
+		Value: node.DefaultGraphQLHost,
+	}
+	GraphQLPortFlag = cli.IntFlag{
+		Name:  "graphql.port",
+		Usage: "GraphQL server listening port",
+		Value: node.DefaultGraphQLPort,
+	}
+	GraphQLCORSDomainFlag = cli.StringFlag{
+		Name:  "graphql.corsdomain",
+		Usage: "Comma separated list of domains from which to accept cross origin requests (browser enforced)",
+		Value: "",
+	}
+	GraphQLVirtualHostsFlag = cli.StringFlag{
+		Name:  "graphql.vhosts",
+		Usage: "Comma separated list of virtual hostnames from which to accept requests (server enforced). Accepts '*' wildcard.",
+		Value: strings.Join(node.DefaultConfig.GraphQLVirtualHosts, ","),
+	}
+	GRPCEnabledFlag = cli.BoolFlag{
+		Name:  "grpc",
+		Usage: "Enable the gRPC server",
+	}
+	GRPCListenAddrFlag = cli.StringFlag{
+		Name:  "grpc.addr",
+		Usage: "gRPC server listening interface",
+		Value: node.DefaultGRPCHost,
+	}
+	GRPCPortFlag = cli.IntFlag{
+		Name:  "grpc.port",
+		Usage: "gRPC server listening port",
+		Value: node.DefaultGRPCPort,
+	}
 	ExecFlag = cli.StringFlag{
 		Name:  "exec",
 		Usage: "Execute JavaScript statement",
@@ -466,6 +567,11 @@ var (
 		Usage: "Maximum number of pending connection attempts (defaults used if set to 0)",
 		Value: 0,
 	}
+	RekeyIntervalFlag = cli.DurationFlag{
+		Name:  "rekeyinterval",
+		Usage: "Lifetime of the session keys of dialed peer connections (0 = never rotate)",
+		Value: node.DefaultConfig.P2P.RekeyInterval,
+	}
 	ListenPortFlag = cli.IntFlag{
 		Name:  "port",
 		Usage: "Network listening port",
@@ -507,6 +613,10 @@ var (
 		Name:  "v5disc",
 		Usage: "Enables the experimental RLPx V5 (Topic Discovery) mechanism",
 	}
+	DiscoveryDNSFlag = cli.StringFlag{
+		Name:  "discovery.dns",
+		Usage: "Comma separated enrtree:// URLs of DNS node lists to discover peers from",
+	}
 	NetrestrictFlag = cli.StringFlag{
 		Name:  "netrestrict",
 		Usage: "Restricts network communication to the given IP networks (CIDR masks)",
@@ -530,6 +640,68 @@ var (
 		Usage: "Suggested gas price is the given percentile of a set of recent transaction gas prices",
 		Value: man.DefaultConfig.GPO.Percentile,
 	}
+	// Sponsored transaction relay settings
+	RelaySponsorFlag = cli.StringFlag{
+		Name:  "relay.sponsor",
+		Usage: "Unlocked account paying gas for sponsored transactions (relay disabled if empty)",
+		Value: "",
+	}
+	RelayContractsFlag = cli.StringFlag{
+		Name:  "relay.contracts",
+		Usage: "Comma separated list of contracts the sponsor pays for",
+		Value: "",
+	}
+	RelayGasLimitFlag = cli.Uint64Flag{
+		Name:  "relay.gaslimit",
+		Usage: "Maximum gas allowance of a single sponsored transaction",
+		Value: man.DefaultConfig.Relay.GasLimit,
+	}
+	RelayQuotaFlag = cli.Uint64Flag{
+		Name:  "relay.quota",
+		Usage: "Maximum number of sponsored transactions per sender within the quota period",
+		Value: man.DefaultConfig.Relay.Quota,
+	}
+	RelayQuotaPeriodFlag = cli.DurationFlag{
+		Name:  "relay.quotaperiod",
+		Usage: "Period over which sponsored transaction quotas are accounted",
+		Value: man.DefaultConfig.Relay.QuotaPeriod,
+	}
+	// Slashing evidence settings
+	SlashingReporterFlag = cli.StringFlag{
+		Name:  "slashing.reporter",
+		Usage: "Unlocked account submitting double-signing evidence (submission disabled if empty)",
+		Value: "",
+	}
+	SlashingContractFlag = cli.StringFlag{
+		Name:  "slashing.contract",
+		Usage: "Slashing contract double-signing evidence is submitted to",
+		Value: "",
+	}
+	SlashingGasLimitFlag = cli.Uint64Flag{
+		Name:  "slashing.gaslimit",
+		Usage: "Gas allowance of a single evidence transaction",
+		Value: man.DefaultConfig.Slashing.GasLimit,
+	}
+	// Contract source verification settings
+	ContractVerifyFlag = cli.BoolFlag{
+		Name:  "contractverify",
+		Usage: "Enable contract source verification over RPC (man_verifyContract)",
+	}
+	ContractVerifyIPFSFlag = cli.StringFlag{
+		Name:  "contractverify.ipfs",
+		Usage: "HTTP gateway contract metadata and sources are retrieved through by IPFS hash",
+		Value: man.DefaultConfig.ContractVerify.IPFSGateway,
+	}
+	ContractVerifySwarmFlag = cli.StringFlag{
+		Name:  "contractverify.swarm",
+		Usage: "HTTP gateway contract metadata and sources are retrieved through by Swarm hash",
+		Value: man.DefaultConfig.ContractVerify.SwarmGateway,
+	}
+	ContractVerifySolcFlag = cli.StringFlag{
+		Name:  "contractverify.solc",
+		Usage: "Solidity compiler, or a directory holding solc-v<version> binaries",
+		Value: man.DefaultConfig.ContractVerify.Solc,
+	}
 	WhisperEnabledFlag = cli.BoolFlag{
 		Name:  "shh",
 		Usage: "Enable Whisper",
@@ -654,6 +826,19 @@ func setBootstrapNodesV5(ctx *cli.Context, cfg *p2p.Config) {
 	}
 }
 
+// setDiscoveryDNS sets the DNS node lists to discover peers from.
+func setDiscoveryDNS(ctx *cli.Context, cfg *p2p.Config) {
+	if !ctx.GlobalIsSet(DiscoveryDNSFlag.Name) {
+		return
+	}
+	cfg.DiscoveryDNS = nil
+	for _, url := range strings.Split(ctx.GlobalString(DiscoveryDNSFlag.Name), ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			cfg.DiscoveryDNS = append(cfg.DiscoveryDNS, url)
+		}
+	}
+}
+
 // setListenAddress creates a TCP listening address string from set command
 // line flags.
 func setListenAddress(ctx *cli.Context, cfg *p2p.Config) {
@@ -728,6 +913,53 @@ func setWS(ctx *cli.Context, cfg *node.Config) {
 	}
 }
 
+// setRPCRateLimits configures the method quotas of the remote RPC interfaces
+// from the set command line flags.
+func setRPCRateLimits(ctx *cli.Context, cfg *node.Config) {
+	if ctx.GlobalIsSet(RPCRateLimitFlag.Name) {
+		limits, err := rpc.ParseRateLimits(ctx.GlobalString(RPCRateLimitFlag.Name))
+		if err != nil {
+			Fatalf("Option %q: %v", RPCRateLimitFlag.Name, err)
+		}
+		cfg.RPCRateLimits = limits
+	}
+}
+
+// setGraphQL creates the GraphQL listener interface string from the set
+// command line flags, returning empty if the GraphQL endpoint is disabled.
+func setGraphQL(ctx *cli.Context, cfg *node.Config) {
+	if ctx.GlobalBool(GraphQLEnabledFlag.Name) && cfg.GraphQLHost == "" {
+		cfg.GraphQLHost = "127.0.0.1"
+		if ctx.GlobalIsSet(GraphQLListenAddrFlag.Name) {
+			cfg.GraphQLHost = ctx.GlobalString(GraphQLListenAddrFlag.Name)
+		}
+	}
+
+	if ctx.GlobalIsSet(GraphQLPortFlag.Name) {
+		cfg.GraphQLPort = ctx.GlobalInt(GraphQLPortFlag.Name)
+	}
+	if ctx.GlobalIsSet(GraphQLCORSDomainFlag.Name) {
+		cfg.GraphQLCors = splitAndTrim(ctx.GlobalString(GraphQLCORSDomainFlag.Name))
+	}
+	if ctx.GlobalIsSet(GraphQLVirtualHostsFlag.Name) {
+		cfg.GraphQLVirtualHosts = splitAndTrim(ctx.GlobalString(GraphQLVirtualHostsFlag.Name))
+	}
+}
+
+// setGRPC creates the gRPC listener interface string from the set command line
+// flags, returning empty if the gRPC endpoint is disabled.
+func setGRPC(ctx *cli.Context, cfg *node.Config) {
+	if ctx.GlobalBool(GRPCEnabledFlag.Name) && cfg.GRPCHost == "" {
+		cfg.GRPCHost = "127.0.0.1"
+		if ctx.GlobalIsSet(GRPCListenAddrFlag.Name) {
+			cfg.GRPCHost = ctx.GlobalString(GRPCListenAddrFlag.Name)
+		}
+	}
+	if ctx.GlobalIsSet(GRPCPortFlag.Name) {
+		cfg.GRPCPort = ctx.GlobalInt(GRPCPortFlag.Name)
+	}
+}
+
 // setIPC creates an IPC path configuration from the set command line flags,
 // returning an empty string if IPC was explicitly disabled, or the set path.
 func setIPC(ctx *cli.Context, cfg *node.Config) {
@@ -819,6 +1051,7 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 	setListenAddress(ctx, cfg)
 	setBootstrapNodes(ctx, cfg)
 	setBootstrapNodesV5(ctx, cfg)
+	setDiscoveryDNS(ctx, cfg)
 
 	lightClient := ctx.GlobalBool(LightModeFlag.Name) || ctx.GlobalString(SyncModeFlag.Name) == "light"
 	lightServer := ctx.GlobalInt(LightServFlag.Name) != 0
@@ -849,6 +1082,9 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 	if ctx.GlobalIsSet(MaxPendingPeersFlag.Name) {
 		cfg.MaxPendingPeers = ctx.GlobalInt(MaxPendingPeersFlag.Name)
 	}
+	if ctx.GlobalIsSet(RekeyIntervalFlag.Name) {
+		cfg.RekeyInterval = ctx.GlobalDuration(RekeyIntervalFlag.Name)
+	}
 	if ctx.GlobalIsSet(NoDiscoverFlag.Name) || lightClient {
 		cfg.NoDiscovery = true
 	}
@@ -886,6 +1122,9 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	setIPC(ctx, cfg)
 	setHTTP(ctx, cfg)
 	setWS(ctx, cfg)
+	setGraphQL(ctx, cfg)
+	setGRPC(ctx, cfg)
+	setRPCRateLimits(ctx, cfg)
 	setNodeUserIdent(ctx, cfg)
 
 	switch {
@@ -899,15 +1138,33 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 		cfg.DataDir = filepath.Join(node.DefaultDataDir(), "rinkeby")
 	}
 
+	if ctx.GlobalIsSet(DatabaseEngineFlag.Name) {
+		cfg.DatabaseEngine = ctx.GlobalString(DatabaseEngineFlag.Name)
+	}
+	if ctx.GlobalIsSet(DatabaseShardsFlag.Name) {
+		cfg.DatabaseShards = splitAndTrim(ctx.GlobalString(DatabaseShardsFlag.Name))
+	}
 	if ctx.GlobalIsSet(KeyStoreDirFlag.Name) {
 		cfg.KeyStoreDir = ctx.GlobalString(KeyStoreDirFlag.Name)
 	}
 	if ctx.GlobalIsSet(LightKDFFlag.Name) {
 		cfg.UseLightweightKDF = ctx.GlobalBool(LightKDFFlag.Name)
 	}
+	if ctx.GlobalIsSet(KDFFlag.Name) {
+		cfg.KeyStoreKDF = ctx.GlobalString(KDFFlag.Name)
+	}
+	if ctx.GlobalIsSet(ScryptNFlag.Name) {
+		cfg.ScryptN = ctx.GlobalInt(ScryptNFlag.Name)
+	}
+	if ctx.GlobalIsSet(ScryptPFlag.Name) {
+		cfg.ScryptP = ctx.GlobalInt(ScryptPFlag.Name)
+	}
 	if ctx.GlobalIsSet(NoUSBFlag.Name) {
 		cfg.NoUSB = ctx.GlobalBool(NoUSBFlag.Name)
 	}
+	if ctx.GlobalIsSet(ExternalSignerFlag.Name) {
+		cfg.ExternalSigner = ctx.GlobalString(ExternalSignerFlag.Name)
+	}
 }
 
 func setGPO(ctx *cli.Context, cfg *gasprice.Config) {
@@ -919,6 +1176,78 @@ func setGPO(ctx *cli.Context, cfg *gasprice.Config) {
 	}
 }
 
+// setRelay configures the sponsored transaction relay from the command line
+// flags, resolving the sponsor either as an address or a keystore index.
+func setRelay(ctx *cli.Context, ks *keystore.KeyStore, cfg *relay.Config) {
+	if ctx.GlobalIsSet(RelaySponsorFlag.Name) {
+		account, err := MakeAddress(ks, ctx.GlobalString(RelaySponsorFlag.Name))
+		if err != nil {
+			Fatalf("Option %q: %v", RelaySponsorFlag.Name, err)
+		}
+		cfg.Sponsor = account.Address
+	}
+	if ctx.GlobalIsSet(RelayContractsFlag.Name) {
+		cfg.Contracts = nil
+		for _, contract := range strings.Split(ctx.GlobalString(RelayContractsFlag.Name), ",") {
+			if contract = strings.TrimSpace(contract); contract == "" {
+				continue
+			}
+			if !common.IsHexAddress(contract) {
+				Fatalf("Option %q: invalid contract address %q", RelayContractsFlag.Name, contract)
+			}
+			cfg.Contracts = append(cfg.Contracts, common.HexToAddress(contract))
+		}
+	}
+	if ctx.GlobalIsSet(RelayGasLimitFlag.Name) {
+		cfg.GasLimit = ctx.GlobalUint64(RelayGasLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(RelayQuotaFlag.Name) {
+		cfg.Quota = ctx.GlobalUint64(RelayQuotaFlag.Name)
+	}
+	if ctx.GlobalIsSet(RelayQuotaPeriodFlag.Name) {
+		cfg.QuotaPeriod = ctx.GlobalDuration(RelayQuotaPeriodFlag.Name)
+	}
+}
+
+// setSlashing configures the slashing evidence collector from the command line
+// flags, resolving the reporter either as an address or a keystore index.
+func setSlashing(ctx *cli.Context, ks *keystore.KeyStore, cfg *slashing.Config) {
+	if ctx.GlobalIsSet(SlashingReporterFlag.Name) {
+		account, err := MakeAddress(ks, ctx.GlobalString(SlashingReporterFlag.Name))
+		if err != nil {
+			Fatalf("Option %q: %v", SlashingReporterFlag.Name, err)
+		}
+		cfg.Reporter = account.Address
+	}
+	if ctx.GlobalIsSet(SlashingContractFlag.Name) {
+		contract := ctx.GlobalString(SlashingContractFlag.Name)
+		if !common.IsHexAddress(contract) {
+			Fatalf("Option %q: invalid contract address %q", SlashingContractFlag.Name, contract)
+		}
+		cfg.Contract = common.HexToAddress(contract)
+	}
+	if ctx.GlobalIsSet(SlashingGasLimitFlag.Name) {
+		cfg.GasLimit = ctx.GlobalUint64(SlashingGasLimitFlag.Name)
+	}
+}
+
+// setContractVerify configures the contract source verifier from the command
+// line flags.
+func setContractVerify(ctx *cli.Context, cfg *verify.Config) {
+	if ctx.GlobalIsSet(ContractVerifyFlag.Name) {
+		cfg.Enabled = ctx.GlobalBool(ContractVerifyFlag.Name)
+	}
+	if ctx.GlobalIsSet(ContractVerifyIPFSFlag.Name) {
+		cfg.IPFSGateway = ctx.GlobalString(ContractVerifyIPFSFlag.Name)
+	}
+	if ctx.GlobalIsSet(ContractVerifySwarmFlag.Name) {
+		cfg.SwarmGateway = ctx.GlobalString(ContractVerifySwarmFlag.Name)
+	}
+	if ctx.GlobalIsSet(ContractVerifySolcFlag.Name) {
+		cfg.Solc = ctx.GlobalString(ContractVerifySolcFlag.Name)
+	}
+}
+
 func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolNoLocalsFlag.Name) {
 		cfg.NoLocals = ctx.GlobalBool(TxPoolNoLocalsFlag.Name)
@@ -973,6 +1302,16 @@ func setEthash(ctx *cli.Context, cfg *man.Config) {
 	}
 }
 
+// checkGCWindow returns the state retention window of the window garbage
+// collection mode, refusing one too short to survive a restart.
+func checkGCWindow(ctx *cli.Context) uint64 {
+	window := ctx.GlobalUint64(GCWindowFlag.Name)
+	if window < core.MinGCWindow {
+		Fatalf("--%s must be at least %d blocks", GCWindowFlag.Name, core.MinGCWindow)
+	}
+	return window
+}
+
 // checkExclusive verifies that only a single isntance of the provided flags was
 // set by the user. Each flag might optionally be followed by a string type to
 // specialize it further.
@@ -1033,6 +1372,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *man.Config) {
 	setEtherbase(ctx, ks, cfg)
 	setGPO(ctx, &cfg.GPO)
 	setTxPool(ctx, &cfg.TxPool)
+	setRelay(ctx, ks, &cfg.Relay)
+	setSlashing(ctx, ks, &cfg.Slashing)
+	setContractVerify(ctx, &cfg.ContractVerify)
 	setEthash(ctx, cfg)
 
 	switch {
@@ -1057,12 +1399,21 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *man.Config) {
 		cfg.DatabaseCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheDatabaseFlag.Name) / 100
 	}
 	cfg.DatabaseHandles = makeDatabaseHandles()
+	if ctx.GlobalIsSet(AncientFlag.Name) {
+		cfg.DatabaseFreezer = ctx.GlobalString(AncientFlag.Name)
+	}
 
-	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
-		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
+	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" && gcmode != "window" {
+		Fatalf("--%s must be either 'full', 'archive' or 'window'", GCModeFlag.Name)
 	}
 	cfg.NoPruning = ctx.GlobalString(GCModeFlag.Name) == "archive"
+	if ctx.GlobalString(GCModeFlag.Name) == "window" {
+		cfg.GCWindow = checkGCWindow(ctx)
+	}
 
+	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheTrieFlag.Name) {
+		cfg.TrieCleanCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheTrieFlag.Name) / 100
+	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cfg.TrieCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
 	}
@@ -1170,6 +1521,24 @@ func RegisterShhService(stack *node.Node, cfg *whisper.Config) {
 	}
 }
 
+// RegisterGraphQLService adds a GraphQL server serving the chain data of the
+// full or light client to the stack.
+func RegisterGraphQLService(stack *node.Node, endpoint string, cors, vhosts []string) {
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		var manServ *man.Matrix
+		if err := ctx.Service(&manServ); err == nil {
+			return graphql.New(manServ.APIBackend, endpoint, cors, vhosts)
+		}
+		var lesServ *les.LightMatrix
+		if err := ctx.Service(&lesServ); err == nil {
+			return graphql.New(lesServ.ApiBackend, endpoint, cors, vhosts)
+		}
+		return nil, errors.New("no Matrix service to serve GraphQL queries of")
+	}); err != nil {
+		Fatalf("Failed to register the GraphQL service: %v", err)
+	}
+}
+
 // RegisterEthStatsService configures the Matrix Stats daemon and adds it to
 // th egiven node.
 func RegisterEthStatsService(stack *node.Node, url string) {
@@ -1193,17 +1562,28 @@ func SetupNetwork(ctx *cli.Context) {
 	params.TargetGasLimit = ctx.GlobalUint64(TargetGasLimitFlag.Name)
 }
 
+// SetupMetrics starts the Prometheus metrics server if it was requested.
+func SetupMetrics(ctx *cli.Context) {
+	if addr := ctx.GlobalString(MetricsAddrFlag.Name); addr != "" {
+		prometheus.Setup(addr)
+	}
+}
+
 // MakeChainDatabase open an LevelDB using the flags passed to the client and will hard crash if it fails.
 func MakeChainDatabase(ctx *cli.Context, stack *node.Node) mandb.Database {
 	var (
 		cache   = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheDatabaseFlag.Name) / 100
 		handles = makeDatabaseHandles()
 	)
-	name := "chaindata"
+	var (
+		chainDb mandb.Database
+		err     error
+	)
 	if ctx.GlobalBool(LightModeFlag.Name) {
-		name = "lightchaindata"
+		chainDb, err = stack.OpenDatabase("lightchaindata", cache, handles)
+	} else {
+		chainDb, err = stack.OpenDatabaseWithFreezer("chaindata", cache, handles, ctx.GlobalString(AncientFlag.Name))
 	}
-	chainDb, err := stack.OpenDatabase(name, cache, handles)
 	if err != nil {
 		Fatalf("Could not open database: %v", err)
 	}
@@ -1232,10 +1612,8 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 	if err != nil {
 		Fatalf("%v", err)
 	}
-	var engine consensus.Engine
-	if config.Clique != nil {
-		engine = clique.New(config.Clique, chainDb)
-	} else {
+	engine, err := consensus.New(config, chainDb)
+	if err != nil {
 		engine = manash.NewFaker()
 		if !ctx.GlobalBool(FakePoWFlag.Name) {
 			engine = manash.New(manash.Config{
@@ -1248,13 +1626,20 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 			})
 		}
 	}
-	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
-		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
+	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" && gcmode != "window" {
+		Fatalf("--%s must be either 'full', 'archive' or 'window'", GCModeFlag.Name)
 	}
 	cache := &core.CacheConfig{
-		Disabled:      ctx.GlobalString(GCModeFlag.Name) == "archive",
-		TrieNodeLimit: man.DefaultConfig.TrieCache,
-		TrieTimeLimit: man.DefaultConfig.TrieTimeout,
+		Disabled:       ctx.GlobalString(GCModeFlag.Name) == "archive",
+		TrieCleanLimit: man.DefaultConfig.TrieCleanCache,
+		TrieNodeLimit:  man.DefaultConfig.TrieCache,
+		TrieTimeLimit:  man.DefaultConfig.TrieTimeout,
+	}
+	if ctx.GlobalString(GCModeFlag.Name) == "window" {
+		cache.GCWindow = checkGCWindow(ctx)
+	}
+	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheTrieFlag.Name) {
+		cache.TrieCleanLimit = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheTrieFlag.Name) / 100
 	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cache.TrieNodeLimit = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
@@ -0,0 +1,204 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"syscall"
+
+	"github.com/matrix/go-matrix/core"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/internal/era"
+	"github.com/matrix/go-matrix/log"
+)
+
+// ExportHistory exports the blocks between first and last into era files of
+// size blocks in dir, along with their checksum manifest. Eras are aligned to
+// multiples of size, so the export starts at the era containing first.
+//
+// Full eras already present with a matching checksum are left untouched and
+// the manifest is updated after every era, so an interrupted export can be
+// resumed by rerunning it.
+func ExportHistory(chain *core.BlockChain, dir string, first, last, size uint64) error {
+	if first > last {
+		return fmt.Errorf("export failed: first (%d) is greater than last (%d)", first, last)
+	}
+	if size == 0 {
+		return fmt.Errorf("export failed: era size must be positive")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	sums, err := era.ReadManifest(dir)
+	if os.IsNotExist(err) {
+		sums = make(map[string]string)
+	} else if err != nil {
+		return err
+	}
+	log.Info("Exporting history", "dir", dir, "first", first, "last", last, "size", size)
+
+	for index := first / size; index <= last/size; index++ {
+		start := index * size
+		count := size
+		if start+count-1 > last {
+			count = last - start + 1
+		}
+		name := era.Filename(index)
+		path := filepath.Join(dir, name)
+
+		if sum, ok := sums[name]; ok && count == size {
+			if have, err := era.Checksum(path); err == nil && have == sum {
+				log.Info("Skipping already exported era", "file", name)
+				continue
+			}
+		}
+		if err := exportEra(chain, path, size, start, count); err != nil {
+			return fmt.Errorf("export of %s failed: %v", name, err)
+		}
+		if sums[name], err = era.Checksum(path); err != nil {
+			return err
+		}
+		if err := era.WriteManifest(dir, sums); err != nil {
+			return err
+		}
+		log.Info("Exported era", "file", name, "first", start, "last", start+count-1)
+	}
+	return nil
+}
+
+// exportEra writes a single era file, replacing any existing one only once
+// it is complete.
+func exportEra(chain *core.BlockChain, path string, size, start, count uint64) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	w, err := era.NewWriter(f, size, start, count)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	for number := start; number < start+count; number++ {
+		block := chain.GetBlockByNumber(number)
+		if block == nil {
+			f.Close()
+			return fmt.Errorf("block #%d not found", number)
+		}
+		if err := w.Add(block); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ImportHistory imports the era files listed in the manifest of dir.
+//
+// All era files are first verified in parallel against their checksums and
+// contents. Eras are then imported in order up to the first one missing or
+// failing verification, so a partially downloaded history can be imported
+// and the import resumed once the remaining files are fetched.
+func ImportHistory(chain *core.BlockChain, dir string) error {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	log.Info("Verifying history", "dir", dir)
+	names, errs, err := era.Verify(dir, runtime.NumCPU())
+	if err != nil {
+		return err
+	}
+	for i, name := range names {
+		if errs[i] != nil {
+			log.Warn("Era file failed verification", "file", name, "err", errs[i])
+		}
+	}
+	for i, name := range names {
+		if errs[i] != nil {
+			return fmt.Errorf("stopped at %s, fetch it again and rerun to resume: %v", name, errs[i])
+		}
+		select {
+		case <-interrupt:
+			return fmt.Errorf("interrupted")
+		default:
+		}
+		if err := importEra(chain, filepath.Join(dir, name)); err != nil {
+			return fmt.Errorf("import of %s failed: %v", name, err)
+		}
+		log.Info("Imported era", "file", name)
+	}
+	return nil
+}
+
+// importEra inserts the blocks of a single era file missing from the chain.
+func importEra(chain *core.BlockChain, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r, err := era.NewReader(f)
+	if err != nil {
+		return err
+	}
+	blocks := make(types.Blocks, 0, importBatchSize)
+	for done := false; !done; {
+		blocks = blocks[:0]
+		for len(blocks) < importBatchSize {
+			block, err := r.Next()
+			if err == io.EOF {
+				done = true
+				break
+			}
+			if err != nil {
+				return err
+			}
+			// The genesis block is never imported
+			if block.NumberU64() == 0 {
+				continue
+			}
+			blocks = append(blocks, block)
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+		missing := missingBlocks(chain, blocks)
+		if len(missing) == 0 {
+			continue
+		}
+		if _, err := chain.InsertChain(missing); err != nil {
+			return err
+		}
+	}
+	return nil
+}
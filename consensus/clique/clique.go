@@ -132,6 +132,12 @@ var (
 	errWaitTransactions = errors.New("waiting for transactions")
 )
 
+func init() {
+	consensus.Register(new(params.CliqueConfig).String(), func(config *params.ChainConfig, db mandb.Database) consensus.Engine {
+		return New(config.Clique, db)
+	})
+}
+
 // SignerFn is a signer callback function to request a hash to be signed by a
 // backing account.
 type SignerFn func(accounts.Account, []byte) ([]byte, error)
@@ -244,6 +250,43 @@ func (c *Clique) VerifySignatures(signature []common.Signature) (bool, error) {
 	return true, nil
 }
 
+// VerifyBlock implements consensus.DPOSEngine. Clique blocks carry no validator
+// election data, the signer seal is checked as part of header verification.
+func (c *Clique) VerifyBlock(header *types.Header) error {
+	return nil
+}
+
+// VerifyHash implements consensus.DPOSEngine, accepting the signatures as is.
+func (c *Clique) VerifyHash(signHash common.Hash, signs []common.Signature) ([]common.Signature, error) {
+	return signs, nil
+}
+
+// VerifyHashWithNumber implements consensus.DPOSEngine, accepting the signatures
+// as is.
+func (c *Clique) VerifyHashWithNumber(signHash common.Hash, signs []common.Signature, number uint64) ([]common.Signature, error) {
+	return signs, nil
+}
+
+// VerifyHashWithVerifiedSigns implements consensus.DPOSEngine, accepting the
+// signatures as is.
+func (c *Clique) VerifyHashWithVerifiedSigns(signs []*common.VerifiedSign) ([]common.Signature, error) {
+	return verifiedSignatures(signs), nil
+}
+
+// VerifyHashWithVerifiedSignsAndNumber implements consensus.DPOSEngine, accepting
+// the signatures as is.
+func (c *Clique) VerifyHashWithVerifiedSignsAndNumber(signs []*common.VerifiedSign, number uint64) ([]common.Signature, error) {
+	return verifiedSignatures(signs), nil
+}
+
+func verifiedSignatures(signs []*common.VerifiedSign) []common.Signature {
+	result := make([]common.Signature, 0, len(signs))
+	for _, sign := range signs {
+		result = append(result, sign.Sign)
+	}
+	return result
+}
+
 // VerifyHeaders is similar to VerifyHeader, but verifies a batch of headers. The
 // method returns a quit channel to abort the operations and a results channel to
 // retrieve the async verifications (the order is that of the input slice).
@@ -653,8 +696,10 @@ func (c *Clique) Seal(chain consensus.ChainReader, header *types.Header, stop <-
 	}
 	copy(header.Extra[len(header.Extra)-extraSeal:], sighash)
 
-	//return block.WithSeal(header), nil
-	//todo 在改为加入难度列表，返回header后，clique共识如何处理？
+	select {
+	case foundMsgCh <- &consensus.FoundMsg{Header: header, Difficulty: header.Difficulty}:
+	case <-stop:
+	}
 	return nil
 }
 
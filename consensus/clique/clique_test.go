@@ -0,0 +1,112 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package clique
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/matrix/go-matrix/accounts"
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/consensus"
+	"github.com/matrix/go-matrix/core"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/core/vm"
+	"github.com/matrix/go-matrix/crypto"
+	"github.com/matrix/go-matrix/mandb"
+	"github.com/matrix/go-matrix/params"
+)
+
+// Tests that the chain configuration selects the clique engine through the
+// consensus registry, and that unregistered engines are reported.
+func TestEngineSelection(t *testing.T) {
+	db := mandb.NewMemDatabase()
+
+	engine, err := consensus.New(params.AllCliqueProtocolChanges, db)
+	if err != nil {
+		t.Fatalf("failed to create clique engine: %v", err)
+	}
+	if _, ok := engine.(*Clique); !ok {
+		t.Fatalf("engine type mismatch: have %T, want *Clique", engine)
+	}
+	if _, ok := engine.(consensus.DPOSEngine); !ok {
+		t.Fatalf("clique does not take over block signature checks")
+	}
+	if _, err := consensus.New(params.AllEthashProtocolChanges, db); err != consensus.ErrUnknownEngine {
+		t.Fatalf("manash engine error mismatch: have %v, want %v", err, consensus.ErrUnknownEngine)
+	}
+}
+
+// Tests that an authorized signer seals a prepared header and delivers it on
+// the found channel in a form passing header verification.
+func TestSealDeliversHeader(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+
+	genesis := &core.Genesis{
+		Config:    params.AllCliqueProtocolChanges,
+		ExtraData: make([]byte, extraVanity+common.AddressLength+extraSeal),
+	}
+	copy(genesis.ExtraData[extraVanity:], signer[:])
+
+	db := mandb.NewMemDatabase()
+	genesis.MustCommit(db)
+
+	engine := New(params.AllCliqueProtocolChanges.Clique, db)
+	engine.Authorize(signer, func(account accounts.Account, hash []byte) ([]byte, error) {
+		return crypto.Sign(hash, key)
+	})
+	chain, err := core.NewBlockChain(db, nil, params.AllCliqueProtocolChanges, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	parent := chain.CurrentBlock()
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     big.NewInt(1),
+		GasLimit:   parent.GasLimit(),
+		Time:       big.NewInt(time.Now().Unix()),
+	}
+	if err := engine.Prepare(chain, header); err != nil {
+		t.Fatalf("failed to prepare header: %v", err)
+	}
+	statedb, err := chain.StateAt(parent.Root())
+	if err != nil {
+		t.Fatalf("failed to open parent state: %v", err)
+	}
+	block, err := engine.Finalize(chain, header, statedb, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to finalize block: %v", err)
+	}
+	found := make(chan *consensus.FoundMsg, 1)
+	if err := engine.Seal(chain, block.Header(), make(chan struct{}), found, nil, false); err != nil {
+		t.Fatalf("failed to seal header: %v", err)
+	}
+	select {
+	case msg := <-found:
+		if author, err := engine.Author(msg.Header); err != nil || author != signer {
+			t.Fatalf("author mismatch: have %x (%v), want %x", author, err, signer)
+		}
+		if err := engine.VerifyHeader(chain, msg.Header, true); err != nil {
+			t.Fatalf("sealed header failed verification: %v", err)
+		}
+	default:
+		t.Fatalf("sealed header not delivered")
+	}
+}
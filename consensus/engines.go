@@ -0,0 +1,63 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package consensus
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/matrix/go-matrix/mandb"
+	"github.com/matrix/go-matrix/params"
+)
+
+// ErrUnknownEngine is returned by New if the chain configuration selects a
+// consensus engine which has not been registered.
+var ErrUnknownEngine = errors.New("unknown consensus engine")
+
+// EngineFactory creates a consensus engine instance for the given chain.
+type EngineFactory func(config *params.ChainConfig, db mandb.Database) Engine
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]EngineFactory)
+)
+
+// Register makes a consensus engine available under the given name. The name
+// must match the one reported by params.ChainConfig.EngineName for the chains
+// the engine should run. Register panics if the name is registered twice.
+func Register(name string, factory EngineFactory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if _, dup := factories[name]; dup {
+		panic("consensus: engine " + name + " registered twice")
+	}
+	factories[name] = factory
+}
+
+// New creates the consensus engine selected by the chain configuration. It
+// returns ErrUnknownEngine if no factory was registered for it, leaving the
+// caller to fall back to an engine needing node specific configuration.
+func New(config *params.ChainConfig, db mandb.Database) (Engine, error) {
+	factoriesMu.RLock()
+	factory, ok := factories[config.EngineName()]
+	factoriesMu.RUnlock()
+
+	if !ok {
+		return nil, ErrUnknownEngine
+	}
+	return factory(config, db), nil
+}
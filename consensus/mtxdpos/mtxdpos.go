@@ -53,6 +53,14 @@ var (
 	errBroadcastVerifySign = errors.New("broadcast block's sign is not from broadcast node")
 
 	errBroadcastVerifySignFalse = errors.New("broadcast block's sign is false")
+
+	// ErrLeaderNotValidator is returned by a light DPOS engine if the leader of a
+	// header is not among the validators defined by its parent topology.
+	ErrLeaderNotValidator = errors.New("block leader is not a validator")
+
+	// ErrNoValidators is returned by a light DPOS engine if the headers do not
+	// define any validators, i.e. the chain is not run by MATRIX consensus.
+	ErrNoValidators = errors.New("no validators in header topology")
 )
 
 type dposTarget struct {
@@ -65,7 +73,8 @@ type dposTarget struct {
 }
 
 type MtxDPOS struct {
-	chain consensus.ChainReader
+	chain    consensus.ChainReader
+	topology *headerTopology // Header derived validator topology, nil if read from the CA
 }
 
 func NewMtxDPOS(chain consensus.ChainReader) *MtxDPOS {
@@ -74,7 +83,20 @@ func NewMtxDPOS(chain consensus.ChainReader) *MtxDPOS {
 	}
 }
 
+// NewLightMtxDPOS creates a DPOS engine for light clients. Instead of the topology
+// database maintained by the CA, the validators are reconstructed from the election
+// and topology fields of the headers, which must therefore be available from chain.
+func NewLightMtxDPOS(chain consensus.ChainReader) *MtxDPOS {
+	return &MtxDPOS{
+		chain:    chain,
+		topology: newHeaderTopology(chain),
+	}
+}
+
 func (md *MtxDPOS) VerifyBlock(header *types.Header) error {
+	if md.topology != nil {
+		return md.verifyLightBlock(header)
+	}
 	if common.IsBroadcastNumber(header.Number.Uint64()) {
 		return md.verifyBroadcastBlock(header)
 	}
@@ -91,6 +113,29 @@ func (md *MtxDPOS) VerifyBlock(header *types.Header) error {
 	return err
 }
 
+// verifyLightBlock verifies the signatures of a header against the validators
+// reconstructed from the header chain.
+func (md *MtxDPOS) verifyLightBlock(header *types.Header) error {
+	number := header.Number.Uint64()
+	if number == 0 {
+		return nil
+	}
+	stocks, err := md.topology.validatorStocks(header.ParentHash, number-1)
+	if err != nil {
+		return err
+	}
+	if common.IsBroadcastNumber(number) {
+		return md.verifyBroadcastBlock(header)
+	}
+	// Light clients don't see the leader election messages, at least make sure
+	// the block was proposed by one of the validators
+	if _, ok := stocks[header.Leader]; !ok {
+		return ErrLeaderNotValidator
+	}
+	_, err = md.VerifyHashWithStocks(header.HashNoSignsAndNonce(), header.Signatures, stocks)
+	return err
+}
+
 func (md *MtxDPOS) VerifyHash(signHash common.Hash, signs []common.Signature) ([]common.Signature, error) {
 	return md.VerifyHashWithNumber(signHash, signs, md.chain.CurrentHeader().Number.Uint64())
 }
@@ -305,6 +350,13 @@ func (md *MtxDPOS) getValidatorStocks(number uint64) (map[common.Address]uint16,
 	if parentNumber != 0 {
 		parentNumber--
 	}
+	if md.topology != nil {
+		parent := md.chain.GetHeaderByNumber(parentNumber)
+		if parent == nil {
+			return nil, consensus.ErrUnknownAncestor
+		}
+		return md.topology.validatorStocks(parent.Hash(), parentNumber)
+	}
 
 	graphInfo, err := ca.GetTopologyByNumber(common.RoleType(common.RoleValidator), parentNumber)
 	if err != nil {
@@ -0,0 +1,132 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package mtxdpos
+
+import (
+	"github.com/hashicorp/golang-lru"
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/consensus"
+	"github.com/matrix/go-matrix/core/types"
+)
+
+// inmemoryTopologies is the number of recent topologies to keep in memory.
+const inmemoryTopologies = 128
+
+// topologySnapshot is the network topology in effect after a given block.
+type topologySnapshot struct {
+	nodes  map[uint16]common.Address // Accounts indexed by topology position
+	stocks map[common.Address]uint16 // Stocks of the most recently elected accounts
+}
+
+// apply creates a new snapshot by applying the topology and election changes
+// of the given header on top of the current one.
+func (s *topologySnapshot) apply(header *types.Header) *topologySnapshot {
+	next := &topologySnapshot{
+		nodes:  make(map[uint16]common.Address),
+		stocks: make(map[common.Address]uint16),
+	}
+	if s != nil && header.NetTopology.Type == common.NetTopoTypeChange {
+		for pos, account := range s.nodes {
+			next.nodes[pos] = account
+		}
+	}
+	for _, node := range header.NetTopology.NetTopologyData {
+		next.nodes[node.Position] = node.Account
+	}
+	if s != nil {
+		for account, stock := range s.stocks {
+			next.stocks[account] = stock
+		}
+	}
+	for _, elect := range header.Elect {
+		next.stocks[elect.Account] = elect.Stock
+	}
+	return next
+}
+
+// validators returns the stocks of the validators in the snapshot.
+func (s *topologySnapshot) validators() map[common.Address]uint16 {
+	stocks := make(map[common.Address]uint16)
+	for pos, account := range s.nodes {
+		if common.GetRoleTypeFromPosition(pos) != common.RoleValidator {
+			continue
+		}
+		if _, exist := stocks[account]; !exist {
+			stocks[account] = s.stocks[account]
+		}
+	}
+	return stocks
+}
+
+// headerTopology reconstructs the validator topology purely from the NetTopology
+// and Elect fields of the headers, without needing the local CA database that is
+// only maintained by full nodes.
+type headerTopology struct {
+	chain  consensus.ChainReader
+	recent *lru.ARCCache // Snapshots of recent blocks, indexed by block hash
+}
+
+func newHeaderTopology(chain consensus.ChainReader) *headerTopology {
+	recent, _ := lru.NewARC(inmemoryTopologies)
+	return &headerTopology{
+		chain:  chain,
+		recent: recent,
+	}
+}
+
+// snapshot retrieves the topology in effect after the given block, walking back
+// the chain until a full topology (or a cached one) is found.
+func (t *headerTopology) snapshot(hash common.Hash, number uint64) (*topologySnapshot, error) {
+	var (
+		headers []*types.Header
+		snap    *topologySnapshot
+	)
+	for snap == nil {
+		if cached, ok := t.recent.Get(hash); ok {
+			snap = cached.(*topologySnapshot)
+			break
+		}
+		header := t.chain.GetHeader(hash, number)
+		if header == nil {
+			return nil, consensus.ErrUnknownAncestor
+		}
+		headers = append(headers, header)
+		if number == 0 || header.NetTopology.Type == common.NetTopoTypeAll {
+			break
+		}
+		hash, number = header.ParentHash, number-1
+	}
+	// Replay the collected headers on top of the base snapshot
+	for i := len(headers) - 1; i >= 0; i-- {
+		snap = snap.apply(headers[i])
+		t.recent.Add(headers[i].Hash(), snap)
+	}
+	return snap, nil
+}
+
+// validatorStocks returns the stocks of the validators entitled to sign the
+// block following the given one.
+func (t *headerTopology) validatorStocks(parentHash common.Hash, parentNumber uint64) (map[common.Address]uint16, error) {
+	snap, err := t.snapshot(parentHash, parentNumber)
+	if err != nil {
+		return nil, err
+	}
+	stocks := snap.validators()
+	if len(stocks) == 0 {
+		return nil, ErrNoValidators
+	}
+	return stocks, nil
+}
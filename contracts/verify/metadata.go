@@ -0,0 +1,208 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package verify
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/matrix/go-matrix/common"
+)
+
+var (
+	// ErrNoMetadata is returned if the bytecode carries no Solidity metadata
+	// trailer.
+	ErrNoMetadata = errors.New("no metadata trailer in bytecode")
+
+	errInvalidCBOR = errors.New("invalid CBOR metadata")
+)
+
+// MetadataHash is the reference to the compiler metadata appended by solc to
+// the runtime bytecode of a contract.
+type MetadataHash struct {
+	Storage string // Content addressed storage holding the metadata: "ipfs", "bzzr0" or "bzzr1"
+	Hash    []byte // Raw hash of the metadata file within the storage
+	Solc    string // Compiler version recorded in the trailer, if any
+}
+
+// URI returns the metadata location in the usual "scheme://hash" notation.
+func (m *MetadataHash) URI() string {
+	if m.Storage == "ipfs" {
+		return "ipfs://" + base58Encode(m.Hash)
+	}
+	return m.Storage + "://" + common.Bytes2Hex(m.Hash)
+}
+
+// ParseMetadataHash extracts the metadata reference from the CBOR encoded map
+// solc appends to the runtime bytecode. The last two bytes of the code hold the
+// big endian length of the map.
+func ParseMetadataHash(code []byte) (*MetadataHash, error) {
+	body, _, err := splitMetadata(code)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := decodeCBORMap(body)
+	if err != nil {
+		return nil, err
+	}
+	meta := new(MetadataHash)
+	for _, storage := range []string{"ipfs", "bzzr1", "bzzr0"} {
+		if hash, ok := entries[storage].([]byte); ok {
+			meta.Storage, meta.Hash = storage, hash
+			break
+		}
+	}
+	if meta.Hash == nil {
+		return nil, ErrNoMetadata
+	}
+	switch solc := entries["solc"].(type) {
+	case []byte:
+		if len(solc) == 3 {
+			meta.Solc = fmt.Sprintf("%d.%d.%d", solc[0], solc[1], solc[2])
+		}
+	case string:
+		meta.Solc = solc
+	}
+	return meta, nil
+}
+
+// splitMetadata splits the code into the CBOR metadata map and the code
+// preceding it.
+func splitMetadata(code []byte) (metadata []byte, rest []byte, err error) {
+	if len(code) < 2 {
+		return nil, nil, ErrNoMetadata
+	}
+	size := int(binary.BigEndian.Uint16(code[len(code)-2:]))
+	if size == 0 || size+2 > len(code) {
+		return nil, nil, ErrNoMetadata
+	}
+	start := len(code) - 2 - size
+	if code[start]>>5 != 5 { // must be a CBOR map
+		return nil, nil, ErrNoMetadata
+	}
+	return code[start : len(code)-2], code[:start], nil
+}
+
+// stripMetadata returns the code without its metadata trailer, or the code
+// itself if there is none.
+func stripMetadata(code []byte) []byte {
+	if _, rest, err := splitMetadata(code); err == nil {
+		return rest
+	}
+	return code
+}
+
+// decodeCBORMap decodes the subset of CBOR used by solc metadata trailers: a
+// single map of text keys to byte strings, text strings or booleans.
+func decodeCBORMap(data []byte) (map[string]interface{}, error) {
+	major, count, data, err := cborHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if major != 5 {
+		return nil, errInvalidCBOR
+	}
+	entries := make(map[string]interface{}, count)
+	for i := uint64(0); i < count; i++ {
+		var key, value interface{}
+		if key, data, err = cborItem(data); err != nil {
+			return nil, err
+		}
+		name, ok := key.(string)
+		if !ok {
+			return nil, errInvalidCBOR
+		}
+		if value, data, err = cborItem(data); err != nil {
+			return nil, err
+		}
+		entries[name] = value
+	}
+	if len(data) != 0 {
+		return nil, errInvalidCBOR
+	}
+	return entries, nil
+}
+
+func cborItem(data []byte) (interface{}, []byte, error) {
+	if len(data) > 0 {
+		switch data[0] {
+		case 0xf4:
+			return false, data[1:], nil
+		case 0xf5:
+			return true, data[1:], nil
+		}
+	}
+	major, size, data, err := cborHeader(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if major != 2 && major != 3 {
+		return nil, nil, errInvalidCBOR
+	}
+	if uint64(len(data)) < size {
+		return nil, nil, errInvalidCBOR
+	}
+	if major == 3 {
+		return string(data[:size]), data[size:], nil
+	}
+	return common.CopyBytes(data[:size]), data[size:], nil
+}
+
+// cborHeader decodes the major type and argument of the next CBOR item.
+func cborHeader(data []byte) (byte, uint64, []byte, error) {
+	if len(data) == 0 {
+		return 0, 0, nil, errInvalidCBOR
+	}
+	major, info := data[0]>>5, data[0]&0x1f
+	data = data[1:]
+	switch {
+	case info < 24:
+		return major, uint64(info), data, nil
+	case info == 24 && len(data) >= 1:
+		return major, uint64(data[0]), data[1:], nil
+	case info == 25 && len(data) >= 2:
+		return major, uint64(binary.BigEndian.Uint16(data)), data[2:], nil
+	}
+	return 0, 0, nil, errInvalidCBOR
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58Encode encodes data with the bitcoin base58 alphabet used by IPFS.
+func base58Encode(data []byte) string {
+	var (
+		x    = new(big.Int).SetBytes(data)
+		base = big.NewInt(58)
+		mod  = new(big.Int)
+		out  []byte
+	)
+	for x.Sign() > 0 {
+		x.DivMod(x, base, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
@@ -0,0 +1,436 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+// Package verify implements source verification of deployed Solidity contracts.
+//
+// solc appends a hash of the compilation metadata to the runtime bytecode of
+// every contract. The metadata lists the compiler version, the settings and the
+// keccak256 hashes and storage locations of all sources. Given a contract, the
+// verifier retrieves the metadata and the sources from IPFS or Swarm through an
+// HTTP gateway, recompiles them and compares the result with the deployed code.
+package verify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/common/compiler"
+	"github.com/matrix/go-matrix/crypto"
+	"github.com/matrix/go-matrix/log"
+)
+
+const (
+	// maxFetchSize is the maximum size of a metadata or source file retrieved
+	// from a gateway.
+	maxFetchSize = 4 * 1024 * 1024
+
+	// maxConcurrent is the maximum number of verifications running at once, as
+	// each of them may spawn a compiler process.
+	maxConcurrent = 2
+)
+
+// Status is the outcome of a contract verification.
+type Status string
+
+const (
+	// StatusFull means the recompiled code matches the deployed code including
+	// the metadata hash, i.e. the sources are exactly the ones deployed.
+	StatusFull Status = "full"
+
+	// StatusPartial means the recompiled code only matches the deployed code
+	// with the metadata trailers stripped, e.g. if comments were changed.
+	StatusPartial Status = "partial"
+
+	// StatusMismatch means the sources compile to different code.
+	StatusMismatch Status = "mismatch"
+
+	// StatusUnavailable means the contract could not be verified, because the
+	// metadata, the sources or the compiler could not be obtained.
+	StatusUnavailable Status = "unavailable"
+)
+
+// Config are the configuration parameters of the contract verifier.
+type Config struct {
+	Enabled      bool          // Whether contract verification is offered over RPC
+	IPFSGateway  string        // HTTP gateway metadata and sources are retrieved through by IPFS hash
+	SwarmGateway string        // HTTP gateway metadata and sources are retrieved through by Swarm hash
+	Solc         string        // solc binary, or a directory holding solc-v<version> binaries
+	Timeout      time.Duration // Maximum duration of a single verification
+}
+
+// DefaultConfig contains the default settings for the verifier.
+var DefaultConfig = Config{
+	IPFSGateway:  "https://ipfs.io",
+	SwarmGateway: "https://swarm-gateways.net",
+	Solc:         "solc",
+	Timeout:      time.Minute,
+}
+
+// CodeReader retrieves the code deployed at an address in the latest state.
+type CodeReader func(ctx context.Context, address common.Address) ([]byte, error)
+
+// compileFn compiles a solc standard JSON input with the given compiler version
+// and returns the standard JSON output.
+type compileFn func(ctx context.Context, version string, input []byte) ([]byte, error)
+
+// Result is the outcome of verifying a contract.
+type Result struct {
+	Address      common.Address `json:"address"`
+	CodeHash     common.Hash    `json:"codeHash"`
+	Status       Status         `json:"status"`
+	Metadata     string         `json:"metadata,omitempty"`     // Location of the compilation metadata
+	Compiler     string         `json:"compiler,omitempty"`     // Compiler version the contract was built with
+	ContractName string         `json:"contractName,omitempty"` // Name of the verified contract
+	Sources      []string       `json:"sources,omitempty"`      // Names of the source files
+	Error        string         `json:"error,omitempty"`        // Reason the contract could not be verified
+}
+
+// Verifier verifies deployed contracts against their published sources.
+// Conclusive results are cached by code hash.
+type Verifier struct {
+	config  Config
+	code    CodeReader
+	client  *http.Client
+	compile compileFn
+	sem     chan struct{}
+
+	lock  sync.Mutex
+	cache map[common.Hash]*Result
+}
+
+// New creates a contract verifier reading deployed code through code.
+func New(config Config, code CodeReader) *Verifier {
+	v := &Verifier{
+		config: config,
+		code:   code,
+		client: new(http.Client),
+		sem:    make(chan struct{}, maxConcurrent),
+		cache:  make(map[common.Hash]*Result),
+	}
+	v.compile = v.solc
+	return v
+}
+
+// Verify retrieves the sources of the contract at address, recompiles them and
+// reports whether they match the deployed code.
+func (v *Verifier) Verify(ctx context.Context, address common.Address) (*Result, error) {
+	code, err := v.code(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	if len(code) == 0 {
+		return nil, fmt.Errorf("no contract code at %x", address)
+	}
+	codeHash := crypto.Keccak256Hash(code)
+
+	v.lock.Lock()
+	cached := v.cache[codeHash]
+	v.lock.Unlock()
+	if cached != nil {
+		result := *cached
+		result.Address = address
+		return &result, nil
+	}
+	if v.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.config.Timeout)
+		defer cancel()
+	}
+	select {
+	case v.sem <- struct{}{}:
+		defer func() { <-v.sem }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	result := &Result{Address: address, CodeHash: codeHash, Status: StatusUnavailable}
+	if err := v.verify(ctx, code, result); err != nil {
+		log.Debug("Contract verification failed", "address", address, "err", err)
+		result.Status, result.Error = StatusUnavailable, err.Error()
+		return result, nil
+	}
+	v.lock.Lock()
+	v.cache[codeHash] = result
+	v.lock.Unlock()
+
+	return result, nil
+}
+
+// metadata is the subset of the solc compilation metadata needed to recompile
+// a contract.
+type metadata struct {
+	Compiler struct {
+		Version string `json:"version"`
+	} `json:"compiler"`
+	Language string                     `json:"language"`
+	Settings map[string]json.RawMessage `json:"settings"`
+	Sources  map[string]struct {
+		Keccak256 string   `json:"keccak256"`
+		URLs      []string `json:"urls"`
+		Content   *string  `json:"content"`
+	} `json:"sources"`
+}
+
+// verify runs the verification of code, filling in result. An error is
+// returned if the verification is inconclusive.
+func (v *Verifier) verify(ctx context.Context, code []byte, result *Result) error {
+	hash, err := ParseMetadataHash(code)
+	if err != nil {
+		return err
+	}
+	result.Metadata = hash.URI()
+
+	blob, err := v.fetch(ctx, result.Metadata)
+	if err != nil {
+		return fmt.Errorf("metadata: %v", err)
+	}
+	var meta metadata
+	if err := json.Unmarshal(blob, &meta); err != nil {
+		return fmt.Errorf("metadata: %v", err)
+	}
+	result.Compiler = meta.Compiler.Version
+
+	var target map[string]string
+	if err := json.Unmarshal(meta.Settings["compilationTarget"], &target); err != nil || len(target) != 1 {
+		return errors.New("metadata: missing compilation target")
+	}
+	var file, name string
+	for file, name = range target {
+	}
+	result.ContractName = name
+
+	// Retrieve all sources, checking them against their recorded hashes
+	sources := make(map[string]map[string]string, len(meta.Sources))
+	for path, source := range meta.Sources {
+		content, err := v.source(ctx, source.Content, source.URLs, source.Keccak256)
+		if err != nil {
+			return fmt.Errorf("source %s: %v", path, err)
+		}
+		sources[path] = map[string]string{"content": content}
+		result.Sources = append(result.Sources, path)
+	}
+	sort.Strings(result.Sources)
+
+	input, err := standardInput(&meta, sources, file, name)
+	if err != nil {
+		return err
+	}
+	output, err := v.compile(ctx, meta.Compiler.Version, input)
+	if err != nil {
+		return err
+	}
+	compiled, err := deployedBytecode(output, file, name)
+	if err != nil {
+		return err
+	}
+	switch {
+	case bytes.Equal(compiled, code):
+		result.Status = StatusFull
+	case bytes.Equal(stripMetadata(compiled), stripMetadata(code)):
+		result.Status = StatusPartial
+	default:
+		result.Status = StatusMismatch
+	}
+	return nil
+}
+
+// source returns the content of a source file, either embedded in the metadata
+// or retrieved from one of its urls, after checking it against its hash.
+func (v *Verifier) source(ctx context.Context, content *string, urls []string, keccak string) (string, error) {
+	want := common.HexToHash(keccak)
+	if content != nil {
+		if crypto.Keccak256Hash([]byte(*content)) != want {
+			return "", errors.New("embedded content hash mismatch")
+		}
+		return *content, nil
+	}
+	err := errors.New("no retrievable url")
+	for _, url := range urls {
+		var blob []byte
+		if blob, err = v.fetch(ctx, url); err != nil {
+			continue
+		}
+		if crypto.Keccak256Hash(blob) != want {
+			err = fmt.Errorf("%s: content hash mismatch", url)
+			continue
+		}
+		return string(blob), nil
+	}
+	return "", err
+}
+
+// fetch retrieves a file by its content address through the configured
+// gateways. Supported are ipfs://, dweb:/ipfs/, bzzr0://, bzzr1:// and
+// bzz-raw:// locations.
+func (v *Verifier) fetch(ctx context.Context, location string) ([]byte, error) {
+	var url string
+	switch {
+	case strings.HasPrefix(location, "ipfs://"):
+		url = strings.TrimRight(v.config.IPFSGateway, "/") + "/ipfs/" + strings.TrimPrefix(location, "ipfs://")
+	case strings.HasPrefix(location, "dweb:/ipfs/"):
+		url = strings.TrimRight(v.config.IPFSGateway, "/") + "/ipfs/" + strings.TrimPrefix(location, "dweb:/ipfs/")
+	case strings.HasPrefix(location, "bzzr0://"), strings.HasPrefix(location, "bzzr1://"), strings.HasPrefix(location, "bzz-raw://"):
+		url = strings.TrimRight(v.config.SwarmGateway, "/") + "/bzz-raw:/" + location[strings.Index(location, "://")+3:]
+	default:
+		return nil, fmt.Errorf("unsupported location %q", location)
+	}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := v.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", location, res.Status)
+	}
+	blob, err := ioutil.ReadAll(io.LimitReader(res.Body, maxFetchSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", location, err)
+	}
+	if len(blob) > maxFetchSize {
+		return nil, fmt.Errorf("%s: file exceeds %d bytes", location, maxFetchSize)
+	}
+	return blob, nil
+}
+
+// standardInput assembles the solc standard JSON input recompiling the target
+// contract with the settings recorded in its metadata.
+func standardInput(meta *metadata, sources map[string]map[string]string, file, name string) ([]byte, error) {
+	settings := make(map[string]interface{}, len(meta.Settings))
+	for key, value := range meta.Settings {
+		switch key {
+		case "compilationTarget":
+		case "libraries":
+			// Metadata lists libraries as "file:Name", the input groups them by file
+			var flat map[string]string
+			if err := json.Unmarshal(value, &flat); err != nil {
+				return nil, fmt.Errorf("metadata: invalid libraries: %v", err)
+			}
+			libraries := make(map[string]map[string]string)
+			for lib, addr := range flat {
+				libFile, libName := "", lib
+				if i := strings.LastIndex(lib, ":"); i >= 0 {
+					libFile, libName = lib[:i], lib[i+1:]
+				}
+				if libraries[libFile] == nil {
+					libraries[libFile] = make(map[string]string)
+				}
+				libraries[libFile][libName] = addr
+			}
+			settings[key] = libraries
+		default:
+			settings[key] = value
+		}
+	}
+	settings["outputSelection"] = map[string]map[string][]string{
+		file: {name: {"evm.deployedBytecode.object"}},
+	}
+	language := meta.Language
+	if language == "" {
+		language = "Solidity"
+	}
+	return json.Marshal(map[string]interface{}{
+		"language": language,
+		"sources":  sources,
+		"settings": settings,
+	})
+}
+
+// deployedBytecode extracts the runtime bytecode of a contract from the solc
+// standard JSON output.
+func deployedBytecode(output []byte, file, name string) ([]byte, error) {
+	var out struct {
+		Errors []struct {
+			Severity         string `json:"severity"`
+			FormattedMessage string `json:"formattedMessage"`
+		} `json:"errors"`
+		Contracts map[string]map[string]struct {
+			EVM struct {
+				DeployedBytecode struct {
+					Object string `json:"object"`
+				} `json:"deployedBytecode"`
+			} `json:"evm"`
+		} `json:"contracts"`
+	}
+	if err := json.Unmarshal(output, &out); err != nil {
+		return nil, fmt.Errorf("solc: invalid output: %v", err)
+	}
+	for _, e := range out.Errors {
+		if e.Severity == "error" {
+			return nil, fmt.Errorf("solc: %s", e.FormattedMessage)
+		}
+	}
+	contract, ok := out.Contracts[file][name]
+	if !ok {
+		return nil, fmt.Errorf("solc: contract %s:%s missing from output", file, name)
+	}
+	object := contract.EVM.DeployedBytecode.Object
+	if strings.Contains(object, "__") {
+		return nil, fmt.Errorf("solc: contract %s:%s has unlinked libraries", file, name)
+	}
+	return common.FromHex(object), nil
+}
+
+// solc compiles the standard JSON input with the configured compiler. If the
+// compiler setting is a directory, the binary matching the version is looked
+// up in it, otherwise the configured binary must be of the requested version.
+func (v *Verifier) solc(ctx context.Context, version string, input []byte) ([]byte, error) {
+	path := v.config.Solc
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		found := false
+		for _, name := range []string{"solc-v" + version, "solc-" + version} {
+			if _, err := os.Stat(filepath.Join(path, name)); err == nil {
+				path, found = filepath.Join(path, name), true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("solc %s not found in %s", version, v.config.Solc)
+		}
+	}
+	solc, err := compiler.SolidityVersion(path)
+	if err != nil {
+		return nil, fmt.Errorf("solc: %v", err)
+	}
+	if !strings.Contains(solc.FullVersion, version) {
+		return nil, fmt.Errorf("solc version mismatch: have %s, want %s", solc.Version, version)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, solc.Path, "--standard-json")
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("solc: %v\n%s", err, stderr.Bytes())
+	}
+	return stdout.Bytes(), nil
+}
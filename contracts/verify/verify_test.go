@@ -0,0 +1,165 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/crypto"
+)
+
+func TestBase58Encode(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"", ""},
+		{"61", "2g"},
+		{"626262", "a3gV"},
+		{"73696d706c792061206c6f6e6720737472696e67", "2cFupjhnEsSn59qHXstmK2ffpLv2"},
+		{"00eb15231dfceb60925886b67d065299925915aeb172c06647", "1NS17iag9jJgTHD1VXjvLCEnZuQ3rJDE9L"},
+		{"00000000000000000000", "1111111111"},
+	}
+	for _, tt := range tests {
+		if have := base58Encode(common.Hex2Bytes(tt.input)); have != tt.want {
+			t.Errorf("base58(%s): have %q, want %q", tt.input, have, tt.want)
+		}
+	}
+}
+
+// ipfsTrailer builds the metadata trailer of solc >= 0.5.9 referencing hash.
+func ipfsTrailer(hash []byte) []byte {
+	cbor := append([]byte{0xa2, 0x64}, "ipfs"...)
+	cbor = append(append(cbor, 0x58, byte(len(hash))), hash...)
+	cbor = append(append(cbor, 0x64), "solc"...)
+	cbor = append(cbor, 0x43, 0x00, 0x05, 0x0c)
+	return append(cbor, byte(len(cbor)>>8), byte(len(cbor)))
+}
+
+func TestParseMetadataHash(t *testing.T) {
+	ipfsHash := append([]byte{0x12, 0x20}, crypto.Keccak256([]byte("metadata"))...)
+
+	meta, err := ParseMetadataHash(append([]byte{0x60, 0x80}, ipfsTrailer(ipfsHash)...))
+	if err != nil {
+		t.Fatalf("failed to parse ipfs trailer: %v", err)
+	}
+	if meta.Storage != "ipfs" || meta.Solc != "0.5.12" || meta.URI() != "ipfs://"+base58Encode(ipfsHash) {
+		t.Errorf("ipfs trailer mismatch: %+v", meta)
+	}
+	// Trailer of solc < 0.5.9 referencing swarm
+	bzzHash := crypto.Keccak256([]byte("metadata"))
+	code := append([]byte{0x60, 0x80, 0xa1, 0x65}, "bzzr0"...)
+	code = append(append(code, 0x58, 0x20), bzzHash...)
+	code = append(code, 0x00, 0x29)
+
+	if meta, err = ParseMetadataHash(code); err != nil {
+		t.Fatalf("failed to parse swarm trailer: %v", err)
+	}
+	if meta.Storage != "bzzr0" || meta.Solc != "" || meta.URI() != "bzzr0://"+common.Bytes2Hex(bzzHash) {
+		t.Errorf("swarm trailer mismatch: %+v", meta)
+	}
+	for _, code := range [][]byte{nil, {0x00}, {0x60, 0x80, 0x60, 0x40}, {0x60, 0x00, 0x02}} {
+		if _, err := ParseMetadataHash(code); err != ErrNoMetadata {
+			t.Errorf("code %x: error mismatch: have %v, want %v", code, err, ErrNoMetadata)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	source := "contract Token { uint public supply; }"
+	meta, _ := json.Marshal(map[string]interface{}{
+		"compiler": map[string]string{"version": "0.5.12+commit.7709ece9"},
+		"language": "Solidity",
+		"settings": map[string]interface{}{
+			"compilationTarget": map[string]string{"token.sol": "Token"},
+			"optimizer":         map[string]interface{}{"enabled": true, "runs": 200},
+		},
+		"sources": map[string]interface{}{
+			"token.sol": map[string]interface{}{
+				"keccak256": crypto.Keccak256Hash([]byte(source)).Hex(),
+				"urls":      []string{"bzz-raw://" + common.Bytes2Hex(crypto.Keccak256([]byte(source)))},
+			},
+		},
+	})
+	metaHash := append([]byte{0x12, 0x20}, crypto.Keccak256(meta)...)
+	otherHash := append([]byte{0x12, 0x20}, crypto.Keccak256([]byte("other"))...)
+
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ipfs/" + base58Encode(metaHash):
+			w.Write(meta)
+		case "/bzz-raw:/" + common.Bytes2Hex(crypto.Keccak256([]byte(source))):
+			w.Write([]byte(source))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer gateway.Close()
+
+	runtime := []byte{0x60, 0x80, 0x60, 0x40, 0x52}
+	tests := []struct {
+		deployed []byte
+		compiled []byte
+		status   Status
+	}{
+		{append(runtime, ipfsTrailer(metaHash)...), append(runtime, ipfsTrailer(metaHash)...), StatusFull},
+		{append(runtime, ipfsTrailer(metaHash)...), append(runtime, ipfsTrailer(otherHash)...), StatusPartial},
+		{append(runtime, ipfsTrailer(metaHash)...), append([]byte{0x00}, ipfsTrailer(metaHash)...), StatusMismatch},
+		{append(runtime, ipfsTrailer(otherHash)...), nil, StatusUnavailable},
+		{runtime, nil, StatusUnavailable},
+	}
+	for i, tt := range tests {
+		config := DefaultConfig
+		config.IPFSGateway, config.SwarmGateway = gateway.URL, gateway.URL
+
+		verifier := New(config, func(ctx context.Context, address common.Address) ([]byte, error) {
+			return tt.deployed, nil
+		})
+		verifier.compile = func(ctx context.Context, version string, input []byte) ([]byte, error) {
+			if !strings.Contains(string(input), `"content":"`+source+`"`) {
+				return nil, fmt.Errorf("source missing from input: %s", input)
+			}
+			return json.Marshal(map[string]interface{}{
+				"contracts": map[string]interface{}{
+					"token.sol": map[string]interface{}{
+						"Token": map[string]interface{}{
+							"evm": map[string]interface{}{
+								"deployedBytecode": map[string]string{"object": common.Bytes2Hex(tt.compiled)},
+							},
+						},
+					},
+				},
+			})
+		}
+		result, err := verifier.Verify(context.Background(), common.Address{0x01})
+		if err != nil {
+			t.Fatalf("test %d: verification failed: %v", i, err)
+		}
+		if result.Status != tt.status {
+			t.Errorf("test %d: status mismatch: have %s, want %s (%s)", i, result.Status, tt.status, result.Error)
+		}
+		if tt.status != StatusUnavailable && (result.ContractName != "Token" || len(result.Sources) != 1) {
+			t.Errorf("test %d: result mismatch: %+v", i, result)
+		}
+	}
+}
@@ -66,6 +66,10 @@ const (
 	badBlockLimit       = 10
 	triesInMemory       = 128
 
+	// MinGCWindow is the smallest state retention window accepted, as the states
+	// of the recent blocks held in memory must still be recoverable from disk.
+	MinGCWindow = triesInMemory
+
 	// BlockChainVersion ensures that an incompatible database forces a resync from scratch.
 	BlockChainVersion = 3
 )
@@ -73,9 +77,11 @@ const (
 // CacheConfig contains the configuration values for the trie caching/pruning
 // that's resident in a blockchain.
 type CacheConfig struct {
-	Disabled      bool          // Whether to disable trie write caching (archive node)
-	TrieNodeLimit int           // Memory limit (MB) at which to flush the current in-memory trie to disk
-	TrieTimeLimit time.Duration // Time limit after which to flush the current in-memory trie to disk
+	Disabled       bool          // Whether to disable trie write caching (archive node)
+	TrieCleanLimit int           // Memory allowance (MB) to use for caching trie nodes in memory
+	TrieNodeLimit  int           // Memory limit (MB) at which to start flushing dirty trie nodes to disk
+	TrieTimeLimit  time.Duration // Time limit after which to flush the current in-memory trie to disk
+	GCWindow       uint64        // Number of recent blocks whose state is retained on disk (0 = keep all)
 }
 
 // BlockChain represents the canonical chain given a database with a genesis
@@ -100,11 +106,16 @@ type BlockChain struct {
 	triegc *prque.Prque   // Priority queue mapping block numbers to tries to gc
 	gcproc time.Duration  // Accumulates canonical block processing for trie dumping
 
+	pruning    int32        // Whether a state prune is running, must be accessed atomically
+	lastPrune  uint64       // Block number at which the last state prune was started
+	lastCommit atomic.Value // Root of the most recent state trie flushed to disk
+
 	hc            *HeaderChain
 	rmLogsFeed    event.Feed
 	chainFeed     event.Feed
 	chainSideFeed event.Feed
 	chainHeadFeed event.Feed
+	reorgFeed     event.Feed
 	logsFeed      event.Feed
 	scope         event.SubscriptionScope
 	genesisBlock  *types.Block
@@ -145,10 +156,14 @@ type BlockChain struct {
 func NewBlockChain(db mandb.Database, cacheConfig *CacheConfig, chainConfig *params.ChainConfig, engine consensus.Engine, vmConfig vm.Config) (*BlockChain, error) {
 	if cacheConfig == nil {
 		cacheConfig = &CacheConfig{
-			TrieNodeLimit: 256 * 1024 * 1024,
-			TrieTimeLimit: 5 * time.Minute,
+			TrieCleanLimit: 256,
+			TrieNodeLimit:  256,
+			TrieTimeLimit:  5 * time.Minute,
 		}
 	}
+	if window := cacheConfig.GCWindow; window > 0 && window < MinGCWindow {
+		return nil, fmt.Errorf("state retention window %d below the minimum of %d blocks", window, MinGCWindow)
+	}
 	bodyCache, _ := lru.New(bodyCacheLimit)
 	bodyRLPCache, _ := lru.New(bodyCacheLimit)
 	blockCache, _ := lru.New(blockCacheLimit)
@@ -160,7 +175,7 @@ func NewBlockChain(db mandb.Database, cacheConfig *CacheConfig, chainConfig *par
 		cacheConfig:  cacheConfig,
 		db:           db,
 		triegc:       prque.New(),
-		stateCache:   state.NewDatabase(db),
+		stateCache:   state.NewDatabaseWithCache(db, cacheConfig.TrieCleanLimit),
 		quit:         make(chan struct{}),
 		bodyCache:    bodyCache,
 		bodyRLPCache: bodyRLPCache,
@@ -173,7 +188,13 @@ func NewBlockChain(db mandb.Database, cacheConfig *CacheConfig, chainConfig *par
 	bc.SetValidator(NewBlockValidator(chainConfig, bc, engine))
 	bc.SetProcessor(NewStateProcessor(chainConfig, bc, engine))
 
-	bc.dposEngine = mtxdpos.NewMtxDPOS(bc)
+	// Engines that authorize blocks by their own signer rules (e.g. clique) take
+	// over block signature checks from the validator election based engine.
+	if dposEngine, ok := engine.(consensus.DPOSEngine); ok {
+		bc.dposEngine = dposEngine
+	} else {
+		bc.dposEngine = mtxdpos.NewMtxDPOS(bc)
+	}
 
 	var err error
 	bc.hc, err = NewHeaderChain(db, chainConfig, engine, bc.getProcInterrupt)
@@ -187,6 +208,7 @@ func NewBlockChain(db mandb.Database, cacheConfig *CacheConfig, chainConfig *par
 	if err := bc.loadLastState(); err != nil {
 		return nil, err
 	}
+	bc.lastCommit.Store(bc.CurrentBlock().Root())
 	// Check the current state of the block hashes and make sure that we do not have any of the bad blocks in our chain
 	for hash := range BadHashes {
 		if header := bc.GetHeaderByHash(hash); header != nil {
@@ -285,6 +307,11 @@ func (bc *BlockChain) SetHead(head uint64) error {
 	bc.hc.SetHead(head, delFn)
 	currentHeader := bc.hc.CurrentHeader()
 
+	// Drop the frozen blocks above the new head from the ancient store
+	if err := rawdb.TruncateAncients(bc.db, head+1); err != nil {
+		log.Error("Failed to truncate ancient store", "head", head, "err", err)
+	}
+
 	// Clear out any stale content from the caches
 	bc.bodyCache.Purge()
 	bc.bodyRLPCache.Purge()
@@ -396,6 +423,11 @@ func (bc *BlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
 	return state.New(root, bc.stateCache)
 }
 
+// StateCache returns the caching database underpinning the blockchain instance.
+func (bc *BlockChain) StateCache() state.Database {
+	return bc.stateCache
+}
+
 // Reset purges the entire blockchain, restoring it to its genesis state.
 func (bc *BlockChain) Reset() error {
 	return bc.ResetWithGenesisBlock(bc.genesisBlock)
@@ -691,6 +723,51 @@ func (bc *BlockChain) Stop() {
 	log.Info("Blockchain manager stopped")
 }
 
+// pruneState deletes all the state trie nodes from disk that are not reachable
+// from the genesis state, from any of the canonical blocks within the last
+// GCWindow blocks or from the last state flushed to disk, which a restart would
+// resume from. It is meant to be run on its own goroutine.
+func (bc *BlockChain) pruneState() {
+	defer bc.wg.Done()
+	defer atomic.StoreInt32(&bc.pruning, 0)
+
+	var (
+		start   = time.Now()
+		head    = bc.CurrentBlock().NumberU64()
+		oldest  = uint64(0)
+		triedb  = bc.stateCache.TrieDB()
+		skipped = 0
+	)
+	if head > bc.cacheConfig.GCWindow {
+		oldest = head - bc.cacheConfig.GCWindow
+	}
+	mark := func(keep *trie.MarkSet) error {
+		roots := []common.Hash{bc.genesisBlock.Root(), bc.lastCommit.Load().(common.Hash)}
+		for number := oldest; number <= head; number++ {
+			if header := bc.GetHeaderByNumber(number); header != nil {
+				roots = append(roots, header.Root)
+			}
+		}
+		for _, root := range roots {
+			if err := state.MarkReachable(bc.stateCache, root, keep, bc.quit); err != nil {
+				// States garbage collected from memory were never written, skip them
+				if _, ok := err.(*trie.MissingNodeError); ok {
+					skipped++
+					continue
+				}
+				return err
+			}
+		}
+		return nil
+	}
+	deleted, err := triedb.Prune(mark, bc.quit)
+	if err != nil {
+		log.Warn("Failed to prune state", "window", bc.cacheConfig.GCWindow, "deleted", deleted, "err", err)
+		return
+	}
+	log.Info("Pruned stale state", "from", oldest, "to", head, "missing", skipped, "deleted", deleted, "elapsed", common.PrettyDuration(time.Since(start)))
+}
+
 func (bc *BlockChain) procFutureBlocks() {
 	blocks := make([]*types.Block, 0, bc.futureBlocks.Len())
 	for _, hash := range bc.futureBlocks.Keys() {
@@ -926,33 +1003,30 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 		bc.triegc.Push(root, -float32(block.NumberU64()))
 
 		if current := block.NumberU64(); current > triesInMemory {
-			// Find the next state trie we need to commit
-			header := bc.GetHeaderByNumber(current - triesInMemory)
-			chosen := header.Number.Uint64()
-
-			// Only write to disk if we exceeded our memory allowance *and* also have at
-			// least a given number of tries gapped.
+			// If we exceeded our memory allowance, flush matured singleton nodes to disk
 			var (
 				size  = triedb.Size()
 				limit = common.StorageSize(bc.cacheConfig.TrieNodeLimit) * 1024 * 1024
 			)
-			if size > limit || bc.gcproc > bc.cacheConfig.TrieTimeLimit {
+			if size > limit {
+				triedb.Cap(limit - mandb.IdealBatchSize)
+			}
+			// Find the next state trie we need to commit
+			header := bc.GetHeaderByNumber(current - triesInMemory)
+			chosen := header.Number.Uint64()
+
+			// If we exceeded our time allowance, flush an entire trie to disk
+			if bc.gcproc > bc.cacheConfig.TrieTimeLimit {
 				// If we're exceeding limits but haven't reached a large enough memory gap,
 				// warn the user that the system is becoming unstable.
-				if chosen < lastWrite+triesInMemory {
-					switch {
-					case size >= 2*limit:
-						log.Warn("State memory usage too high, committing", "size", size, "limit", limit, "optimum", float64(chosen-lastWrite)/triesInMemory)
-					case bc.gcproc >= 2*bc.cacheConfig.TrieTimeLimit:
-						log.Info("State in memory for too long, committing", "time", bc.gcproc, "allowance", bc.cacheConfig.TrieTimeLimit, "optimum", float64(chosen-lastWrite)/triesInMemory)
-					}
-				}
-				// If optimum or critical limits reached, write to disk
-				if chosen >= lastWrite+triesInMemory || size >= 2*limit || bc.gcproc >= 2*bc.cacheConfig.TrieTimeLimit {
-					triedb.Commit(header.Root, true)
-					lastWrite = chosen
-					bc.gcproc = 0
+				if chosen < lastWrite+triesInMemory && bc.gcproc >= 2*bc.cacheConfig.TrieTimeLimit {
+					log.Info("State in memory for too long, committing", "time", bc.gcproc, "allowance", bc.cacheConfig.TrieTimeLimit, "optimum", float64(chosen-lastWrite)/triesInMemory)
 				}
+				// Flush an entire trie and restart the counters
+				triedb.Commit(header.Root, true)
+				bc.lastCommit.Store(header.Root)
+				lastWrite = chosen
+				bc.gcproc = 0
 			}
 			// Garbage collect anything below our required write retention
 			for !bc.triegc.Empty() {
@@ -964,6 +1038,21 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 				triedb.Dereference(root.(common.Hash), common.Hash{})
 			}
 		}
+		// If a retention window is configured, periodically drop older state from disk
+		if window := bc.cacheConfig.GCWindow; window > 0 {
+			interval := window / 8
+			if interval < triesInMemory {
+				interval = triesInMemory
+			}
+			if current := block.NumberU64(); current > window && current >= bc.lastPrune+interval {
+				if atomic.CompareAndSwapInt32(&bc.pruning, 0, 1) {
+					bc.lastPrune = current
+
+					bc.wg.Add(1)
+					go bc.pruneState()
+				}
+			}
+		}
 	}
 	rawdb.WriteReceipts(batch, block.Hash(), block.NumberU64(), receipts)
 
@@ -1511,6 +1600,21 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 			}
 		}()
 	}
+	if len(oldChain) > 0 && len(newChain) > 0 {
+		ev := ChainReorgEvent{
+			CommonAncestor: commonBlock.Header(),
+			Dropped:        make([]common.Hash, len(oldChain)),
+			Added:          make([]common.Hash, len(newChain)),
+		}
+		// Both chains were collected head first, flip them to ancestor first
+		for i, block := range oldChain {
+			ev.Dropped[len(oldChain)-1-i] = block.Hash()
+		}
+		for i, block := range newChain {
+			ev.Added[len(newChain)-1-i] = block.Hash()
+		}
+		go bc.reorgFeed.Send(ev)
+	}
 
 	return nil
 }
@@ -1769,6 +1873,11 @@ func (bc *BlockChain) SubscribeChainSideEvent(ch chan<- ChainSideEvent) event.Su
 	return bc.scope.Track(bc.chainSideFeed.Subscribe(ch))
 }
 
+// SubscribeChainReorgEvent registers a subscription of ChainReorgEvent.
+func (bc *BlockChain) SubscribeChainReorgEvent(ch chan<- ChainReorgEvent) event.Subscription {
+	return bc.scope.Track(bc.reorgFeed.Subscribe(ch))
+}
+
 // SubscribeLogsEvent registers a subscription of []*types.Log.
 func (bc *BlockChain) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription {
 	return bc.scope.Track(bc.logsFeed.Subscribe(ch))
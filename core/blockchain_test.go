@@ -1340,6 +1340,30 @@ func TestLargeReorgTrieGC(t *testing.T) {
 	}
 }
 
+// Tests that window state pruning refuses windows shorter than the in-memory
+// tries, as the last state flushed to disk could be pruned away.
+func TestGCWindowLimit(t *testing.T) {
+	engine := manash.NewFaker()
+
+	diskdb := mandb.NewMemDatabase()
+	new(Genesis).MustCommit(diskdb)
+
+	cache := &CacheConfig{TrieCleanLimit: 256, TrieNodeLimit: 256, TrieTimeLimit: 5 * time.Minute, GCWindow: MinGCWindow - 1}
+	if _, err := NewBlockChain(diskdb, cache, params.TestChainConfig, engine, vm.Config{}); err == nil {
+		t.Fatalf("chain created with a window shorter than the in-memory tries")
+	}
+	cache.GCWindow = MinGCWindow
+	chain, err := NewBlockChain(diskdb, cache, params.TestChainConfig, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if root := chain.lastCommit.Load().(common.Hash); root != chain.Genesis().Root() {
+		t.Errorf("last flushed state mismatch: have %x, want %x", root, chain.Genesis().Root())
+	}
+}
+
 // Benchmarks large blocks with value transfers to non-existing accounts
 func benchmarkLargeNumberOfValueToNonexisting(b *testing.B, numTxs, numBlocks int, recipientFn func(uint64) common.Address, dataFn func(uint64) []byte) {
 	var (
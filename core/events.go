@@ -51,3 +51,12 @@ type ChainSideEvent struct {
 }
 
 type ChainHeadEvent struct{ Block *types.Block }
+
+// ChainReorgEvent is posted when the canonical chain is reorganised. Dropped
+// and Added hold the hashes of the blocks removed from and adopted into the
+// canonical chain, both ordered from the common ancestor upwards.
+type ChainReorgEvent struct {
+	CommonAncestor *types.Header
+	Dropped        []common.Hash
+	Added          []common.Hash
+}
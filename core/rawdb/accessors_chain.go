@@ -22,6 +22,7 @@ import (
 	"math/big"
 
 	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/core/rawdb/freezer"
 	"github.com/matrix/go-matrix/core/types"
 	"github.com/matrix/go-matrix/log"
 	"github.com/matrix/go-matrix/rlp"
@@ -30,6 +31,9 @@ import (
 // ReadCanonicalHash retrieves the hash assigned to a canonical block number.
 func ReadCanonicalHash(db DatabaseReader, number uint64) common.Hash {
 	data, _ := db.Get(append(append(headerPrefix, encodeBlockNumber(number)...), headerHashSuffix...))
+	if len(data) == 0 {
+		data = readAncientHash(db, number)
+	}
 	if len(data) == 0 {
 		return common.Hash{}
 	}
@@ -130,6 +134,9 @@ func WriteFastTrieProgress(db DatabaseWriter, count uint64) {
 // ReadHeaderRLP retrieves a block header in its raw RLP database encoding.
 func ReadHeaderRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
 	data, _ := db.Get(append(append(headerPrefix, encodeBlockNumber(number)...), hash.Bytes()...))
+	if len(data) == 0 {
+		data = readAncient(db, freezer.HeaderTable, hash, number)
+	}
 	return data
 }
 
@@ -137,7 +144,7 @@ func ReadHeaderRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawVa
 func HasHeader(db DatabaseReader, hash common.Hash, number uint64) bool {
 	key := append(append(append(headerPrefix, encodeBlockNumber(number)...), hash.Bytes()...))
 	if has, err := db.Has(key); !has || err != nil {
-		return false
+		return hasAncient(db, hash, number)
 	}
 	return true
 }
@@ -193,6 +200,9 @@ func DeleteHeader(db DatabaseDeleter, hash common.Hash, number uint64) {
 // ReadBodyRLP retrieves the block body (transactions and uncles) in RLP encoding.
 func ReadBodyRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
 	data, _ := db.Get(append(append(blockBodyPrefix, encodeBlockNumber(number)...), hash.Bytes()...))
+	if len(data) == 0 {
+		data = readAncient(db, freezer.BodyTable, hash, number)
+	}
 	return data
 }
 
@@ -208,7 +218,7 @@ func WriteBodyRLP(db DatabaseWriter, hash common.Hash, number uint64, rlp rlp.Ra
 func HasBody(db DatabaseReader, hash common.Hash, number uint64) bool {
 	key := append(append(blockBodyPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
 	if has, err := db.Has(key); !has || err != nil {
-		return false
+		return hasAncient(db, hash, number)
 	}
 	return true
 }
@@ -246,6 +256,9 @@ func DeleteBody(db DatabaseDeleter, hash common.Hash, number uint64) {
 // ReadTd retrieves a block's total difficulty corresponding to the hash.
 func ReadTd(db DatabaseReader, hash common.Hash, number uint64) *big.Int {
 	data, _ := db.Get(append(append(append(headerPrefix, encodeBlockNumber(number)...), hash[:]...), headerTDSuffix...))
+	if len(data) == 0 {
+		data = readAncient(db, freezer.DifficultyTable, hash, number)
+	}
 	if len(data) == 0 {
 		return nil
 	}
@@ -280,6 +293,9 @@ func DeleteTd(db DatabaseDeleter, hash common.Hash, number uint64) {
 func ReadReceipts(db DatabaseReader, hash common.Hash, number uint64) types.Receipts {
 	// Retrieve the flattened receipt slice
 	data, _ := db.Get(append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), hash[:]...))
+	if len(data) == 0 {
+		data = readAncient(db, freezer.ReceiptTable, hash, number)
+	}
 	if len(data) == 0 {
 		return nil
 	}
@@ -0,0 +1,171 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rawdb
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/log"
+	"github.com/matrix/go-matrix/mandb"
+	"github.com/matrix/go-matrix/params"
+)
+
+const (
+	// freezerRecheckInterval is the frequency to check the key-value database
+	// for chain segments that can be moved into the ancient store.
+	freezerRecheckInterval = time.Minute
+
+	// freezerBatchLimit is the maximum number of blocks to freeze in one batch
+	// before doing an fsync and deleting them from the key-value store.
+	freezerBatchLimit = 30000
+)
+
+// freezerThreshold is the number of recent blocks kept in the key-value store.
+var freezerThreshold uint64 = params.ImmutabilityThreshold
+
+// freeze is the background loop moving the immutable chain segments from the
+// key-value database into the ancient store. Existing databases are migrated
+// the same way, one batch at a time.
+func (db *freezerdb) freeze() {
+	defer db.wg.Done()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-db.quit:
+			return
+		case <-timer.C:
+		}
+		more, err := db.freezeBatch()
+		if err != nil {
+			log.Error("Failed to freeze chain segment", "err", err)
+		}
+		if more && err == nil {
+			timer.Reset(0)
+		} else {
+			timer.Reset(freezerRecheckInterval)
+		}
+	}
+}
+
+// freezeBatch moves the next batch of immutable blocks into the ancient store
+// and deletes them from the key-value database. It reports whether there are
+// more blocks waiting to be frozen.
+func (db *freezerdb) freezeBatch() (bool, error) {
+	kv := db.KeyValueStore
+
+	head := ReadHeadBlockHash(kv)
+	if head == (common.Hash{}) {
+		return false, nil
+	}
+	number := ReadHeaderNumber(kv, head)
+	if number == nil || *number < freezerThreshold {
+		return false, nil
+	}
+	var (
+		first = db.Ancients()
+		limit = *number - freezerThreshold
+		more  = false
+	)
+	if first > limit {
+		return false, nil
+	}
+	if limit-first >= freezerBatchLimit {
+		limit, more = first+freezerBatchLimit-1, true
+	}
+	start := time.Now()
+
+	hashes := make([]common.Hash, 0, limit-first+1)
+	for n := first; n <= limit; n++ {
+		// Stop freezing if the node is shutting down
+		select {
+		case <-db.quit:
+			return false, db.Sync()
+		default:
+		}
+		hash := ReadCanonicalHash(kv, n)
+		if hash == (common.Hash{}) {
+			return false, fmt.Errorf("canonical hash missing, can't freeze block %d", n)
+		}
+		header, _ := kv.Get(headerKey(n, hash))
+		if len(header) == 0 {
+			return false, fmt.Errorf("block header missing, can't freeze block %d", n)
+		}
+		body, _ := kv.Get(blockBodyKey(n, hash))
+		if len(body) == 0 {
+			return false, fmt.Errorf("block body missing, can't freeze block %d", n)
+		}
+		receipts, _ := kv.Get(blockReceiptsKey(n, hash))
+		if len(receipts) == 0 {
+			return false, fmt.Errorf("block receipts missing, can't freeze block %d", n)
+		}
+		td, _ := kv.Get(headerTDKey(n, hash))
+		if len(td) == 0 {
+			return false, fmt.Errorf("total difficulty missing, can't freeze block %d", n)
+		}
+		if err := db.AppendAncient(n, hash.Bytes(), header, body, receipts, td); err != nil {
+			return false, err
+		}
+		hashes = append(hashes, hash)
+	}
+	// Make sure the blocks are safely on disk before deleting them
+	if err := db.Sync(); err != nil {
+		return false, err
+	}
+	if len(hashes) == 0 {
+		return false, nil
+	}
+	batch := kv.NewBatch()
+	for i, hash := range hashes {
+		n := first + uint64(i)
+		batch.Delete(headerHashKey(n))
+
+		// Delete the canonical block along with any side chain at its height,
+		// keeping only the hash to number mapping of the canonical block
+		it := kv.Iterate(append(append([]byte{}, headerPrefix...), encodeBlockNumber(n)...))
+		for it.Next() {
+			key := it.Key()
+			if len(key) != len(headerPrefix)+8+common.HashLength {
+				continue
+			}
+			side := common.BytesToHash(key[len(key)-common.HashLength:])
+			batch.Delete(headerKey(n, side))
+			batch.Delete(headerTDKey(n, side))
+			batch.Delete(blockBodyKey(n, side))
+			batch.Delete(blockReceiptsKey(n, side))
+			if side != hash {
+				batch.Delete(headerNumberKey(side))
+			}
+		}
+		it.Release()
+
+		if batch.ValueSize() > mandb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return false, err
+			}
+			batch.Reset()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return false, err
+	}
+	log.Info("Deep froze chain segment", "blocks", len(hashes), "number", limit, "hash", hashes[len(hashes)-1], "elapsed", common.PrettyDuration(time.Since(start)))
+	return more, nil
+}
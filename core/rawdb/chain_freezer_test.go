@@ -0,0 +1,119 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rawdb
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/mandb"
+)
+
+// Tests that immutable blocks are moved into the ancient store and can still
+// be read through the regular accessors afterwards.
+func TestFreezeChain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chainfreezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(threshold uint64) { freezerThreshold = threshold }(freezerThreshold)
+	freezerThreshold = 4
+
+	kv, err := mandb.NewLDBDatabase(filepath.Join(dir, "chaindata"), 16, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Write a chain of 10 blocks, along with a side block at height 2
+	var blocks []*types.Block
+	parent := common.Hash{}
+	for i := int64(0); i < 10; i++ {
+		header := &types.Header{Number: big.NewInt(i), ParentHash: parent, Extra: []byte("test")}
+		block := types.NewBlockWithHeader(header)
+		WriteBlock(kv, block)
+		WriteTd(kv, block.Hash(), block.NumberU64(), big.NewInt(i+1))
+		WriteReceipts(kv, block.Hash(), block.NumberU64(), nil)
+		WriteCanonicalHash(kv, block.Hash(), block.NumberU64())
+		blocks, parent = append(blocks, block), block.Hash()
+	}
+	side := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(2), ParentHash: blocks[1].Hash(), Extra: []byte("side")})
+	WriteBlock(kv, side)
+	WriteHeadBlockHash(kv, parent)
+
+	db, err := NewDatabaseWithFreezer(kv, filepath.Join(dir, "ancient"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fdb := db.(*freezerdb)
+	close(fdb.quit) // stop the background loop, freeze manually instead
+	fdb.wg.Wait()
+	fdb.quit = make(chan struct{})
+
+	if _, err := fdb.freezeBatch(); err != nil {
+		t.Fatalf("failed to freeze: %v", err)
+	}
+	if frozen := fdb.Ancients(); frozen != 6 {
+		t.Fatalf("frozen blocks mismatch: have %d, want 6", frozen)
+	}
+	for _, block := range blocks {
+		hash, number := block.Hash(), block.NumberU64()
+		if has, _ := kv.Has(headerKey(number, hash)); has != (number >= 6) {
+			t.Errorf("block %d: header in key-value store: %v", number, has)
+		}
+		if ReadCanonicalHash(db, number) != hash {
+			t.Errorf("block %d: canonical hash mismatch", number)
+		}
+		if b := ReadBlock(db, hash, number); b == nil || b.Hash() != hash {
+			t.Errorf("block %d: not readable", number)
+		}
+		if td := ReadTd(db, hash, number); td == nil || td.Int64() != int64(number)+1 {
+			t.Errorf("block %d: total difficulty mismatch: %v", number, td)
+		}
+		if !HasBody(db, hash, number) || ReadReceipts(db, hash, number) == nil {
+			t.Errorf("block %d: body or receipts missing", number)
+		}
+	}
+	if HasHeader(db, side.Hash(), 2) || ReadHeaderNumber(db, side.Hash()) != nil {
+		t.Errorf("side chain block not deleted")
+	}
+	// Rewinding below the frozen blocks truncates the ancient store
+	if err := TruncateAncients(db, 3); err != nil {
+		t.Fatal(err)
+	}
+	if ReadCanonicalHash(db, 4) != (common.Hash{}) {
+		t.Errorf("truncated block still canonical")
+	}
+	db.Close()
+
+	// Reopening the ancient store with a different chain must fail
+	other, err := mandb.NewLDBDatabase(filepath.Join(dir, "other"), 16, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	WriteCanonicalHash(other, common.Hash{0x01}, 0)
+	WriteHeadHeaderHash(other, common.Hash{0x01})
+	if _, err := NewDatabaseWithFreezer(other, filepath.Join(dir, "ancient")); err == nil {
+		t.Fatal("mismatching ancient store accepted")
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rawdb
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/core/rawdb/freezer"
+	"github.com/matrix/go-matrix/mandb"
+)
+
+// freezerdb is a key-value database whose immutable chain segments are moved
+// into an ancient store by a background freezer.
+type freezerdb struct {
+	mandb.KeyValueStore
+	*freezer.Freezer
+
+	quit      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// NewDatabaseWithFreezer wraps db with the ancient store in the given
+// directory. Blocks older than params.ImmutabilityThreshold are continuously
+// migrated out of db; reads of migrated blocks are served from the ancient
+// store transparently.
+func NewDatabaseWithFreezer(db mandb.KeyValueStore, ancient string) (mandb.KeyValueStore, error) {
+	frdb, err := freezer.New(ancient)
+	if err != nil {
+		return nil, err
+	}
+	// Refuse an ancient store that belongs to a different chain, or one that
+	// outlived the key-value database it was extracted from.
+	if frozen := frdb.Ancients(); frozen > 0 {
+		genesis, err := frdb.Ancient(freezer.HashTable, 0)
+		if err != nil {
+			frdb.Close()
+			return nil, err
+		}
+		if kvgenesis := ReadCanonicalHash(db, 0); kvgenesis != (common.Hash{}) && kvgenesis != common.BytesToHash(genesis) {
+			frdb.Close()
+			return nil, errors.New("genesis mismatch between the database and the ancient store")
+		}
+		if ReadHeadHeaderHash(db) == (common.Hash{}) {
+			frdb.Close()
+			return nil, errors.New("ancient store holds chain segments the database lost track of")
+		}
+	}
+	fdb := &freezerdb{
+		KeyValueStore: db,
+		Freezer:       frdb,
+		quit:          make(chan struct{}),
+	}
+	fdb.wg.Add(1)
+	go fdb.freeze()
+	return fdb, nil
+}
+
+// Close stops the freezer and closes both the ancient store and the key-value
+// database.
+func (db *freezerdb) Close() {
+	db.closeOnce.Do(func() {
+		close(db.quit)
+		db.wg.Wait()
+		db.Freezer.Close()
+		db.KeyValueStore.Close()
+	})
+}
+
+// TruncateAncients discards the frozen blocks from the given number onwards,
+// if db is backed by an ancient store.
+func TruncateAncients(db DatabaseDeleter, items uint64) error {
+	if ancients, ok := db.(AncientWriter); ok {
+		return ancients.TruncateAncients(items)
+	}
+	return nil
+}
+
+// readAncientHash returns the canonical hash of a frozen block, or nil if db
+// has no ancient store or the block isn't frozen.
+func readAncientHash(db DatabaseReader, number uint64) []byte {
+	ancients, ok := db.(AncientReader)
+	if !ok || number >= ancients.Ancients() {
+		return nil
+	}
+	hash, _ := ancients.Ancient(freezer.HashTable, number)
+	return hash
+}
+
+// hasAncient reports whether the given block was moved to the ancient store.
+func hasAncient(db DatabaseReader, hash common.Hash, number uint64) bool {
+	stored := readAncientHash(db, number)
+	return len(stored) > 0 && common.BytesToHash(stored) == hash
+}
+
+// readAncient retrieves an item of a frozen block, or nil if the block isn't
+// in the ancient store.
+func readAncient(db DatabaseReader, kind string, hash common.Hash, number uint64) []byte {
+	if !hasAncient(db, hash, number) {
+		return nil
+	}
+	data, _ := db.(AncientReader).Ancient(kind, number)
+	return data
+}
@@ -0,0 +1,205 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package freezer implements the ancient store: append-only flat files that
+// hold the immutable part of the chain outside of the key-value database.
+package freezer
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/matrix/go-matrix/log"
+	"github.com/matrix/go-matrix/metrics"
+)
+
+// The kinds of data kept in the ancient store, one table each.
+const (
+	HeaderTable     = "headers"  // RLP encoded block headers
+	HashTable       = "hashes"   // Canonical block hashes
+	BodyTable       = "bodies"   // RLP encoded block bodies
+	ReceiptTable    = "receipts" // RLP encoded block receipts in storage form
+	DifficultyTable = "diffs"    // RLP encoded total difficulties
+)
+
+// compressTables lists the tables and whether their items are snappy
+// compressed. Hashes and difficulties are too short to gain from it.
+var compressTables = map[string]bool{
+	HeaderTable:     true,
+	HashTable:       false,
+	BodyTable:       true,
+	ReceiptTable:    true,
+	DifficultyTable: false,
+}
+
+// ErrUnknownTable is returned if an item of an unknown kind is requested.
+var ErrUnknownTable = errors.New("unknown table")
+
+var (
+	appendMeter = metrics.NewRegisteredMeter("man/db/ancient/append", nil)
+	readMeter   = metrics.NewRegisteredMeter("man/db/ancient/read", nil)
+)
+
+// Freezer stores the blocks of the chain in consecutive order, numbered from
+// genesis, in one append-only table per kind of data.
+type Freezer struct {
+	frozen uint64 // Number of blocks stored, accessed atomically
+
+	dir    string
+	tables map[string]*table
+	lock   sync.Mutex // Serialises appends and truncations
+}
+
+// New opens the ancient store in dir, creating it if necessary. Tables left
+// inconsistent by a crash are truncated to the blocks all of them hold.
+func New(dir string) (*Freezer, error) {
+	f := &Freezer{dir: dir, tables: make(map[string]*table)}
+	for name, compress := range compressTables {
+		t, err := newTable(dir, name, compress)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		f.tables[name] = t
+	}
+	if err := f.repair(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	log.Info("Opened ancient database", "dir", dir, "blocks", f.Ancients())
+	return f, nil
+}
+
+// repair truncates all tables to the length of the shortest one.
+func (f *Freezer) repair() error {
+	min := ^uint64(0)
+	for _, t := range f.tables {
+		if items := t.count(); items < min {
+			min = items
+		}
+	}
+	for _, t := range f.tables {
+		if err := t.truncate(min); err != nil {
+			return err
+		}
+	}
+	atomic.StoreUint64(&f.frozen, min)
+	return nil
+}
+
+// Ancients returns the number of blocks in the ancient store.
+func (f *Freezer) Ancients() uint64 {
+	return atomic.LoadUint64(&f.frozen)
+}
+
+// HasAncient reports whether the ancient store holds the given block.
+func (f *Freezer) HasAncient(kind string, number uint64) bool {
+	_, ok := f.tables[kind]
+	return ok && number < f.Ancients()
+}
+
+// Ancient retrieves an item of the given kind of the given block.
+func (f *Freezer) Ancient(kind string, number uint64) ([]byte, error) {
+	t, ok := f.tables[kind]
+	if !ok {
+		return nil, ErrUnknownTable
+	}
+	if number >= f.Ancients() {
+		return nil, ErrOutOfBounds
+	}
+	blob, err := t.retrieve(number)
+	if err == nil {
+		readMeter.Mark(1)
+	}
+	return blob, err
+}
+
+// AppendAncient adds the next block to the ancient store. Blocks must be
+// appended in order; if any table fails, all of them are rolled back.
+func (f *Freezer) AppendAncient(number uint64, hash, header, body, receipts, td []byte) (err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if frozen := f.Ancients(); number != frozen {
+		return fmt.Errorf("%v: have %d, appending %d", ErrOutOrderInsertion, frozen, number)
+	}
+	defer func() {
+		if err != nil {
+			for _, t := range f.tables {
+				if terr := t.truncate(number); terr != nil {
+					log.Error("Failed to roll back ancient table", "table", t.name, "err", terr)
+				}
+			}
+		}
+	}()
+	items := []struct {
+		kind string
+		blob []byte
+	}{
+		{HashTable, hash}, {HeaderTable, header}, {BodyTable, body}, {ReceiptTable, receipts}, {DifficultyTable, td},
+	}
+	for _, item := range items {
+		if aerr := f.tables[item.kind].append(number, item.blob); aerr != nil {
+			return fmt.Errorf("%s: %v", item.kind, aerr)
+		}
+	}
+	atomic.AddUint64(&f.frozen, 1)
+	appendMeter.Mark(1)
+	return nil
+}
+
+// TruncateAncients discards all blocks from the given number onwards.
+func (f *Freezer) TruncateAncients(items uint64) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if items >= f.Ancients() {
+		return nil
+	}
+	for _, t := range f.tables {
+		if err := t.truncate(items); err != nil {
+			return err
+		}
+	}
+	atomic.StoreUint64(&f.frozen, items)
+	return nil
+}
+
+// Sync flushes all tables to disk.
+func (f *Freezer) Sync() error {
+	for _, t := range f.tables {
+		if err := t.sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close releases all tables.
+func (f *Freezer) Close() error {
+	var errs []error
+	for _, t := range f.tables {
+		if err := t.close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package freezer
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func appendBlocks(t *testing.T, f *Freezer, from, to uint64) {
+	for n := from; n < to; n++ {
+		blob := []byte(fmt.Sprintf("block-%d", n))
+		if err := f.AppendAncient(n, blob, blob, bytes.Repeat(blob, 10), blob, blob); err != nil {
+			t.Fatalf("failed to append block %d: %v", n, err)
+		}
+	}
+}
+
+func TestFreezerAppendRetrieve(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendBlocks(t, f, 0, 100)
+	if err := f.AppendAncient(200, nil, nil, nil, nil, nil); err == nil {
+		t.Fatal("out of order append accepted")
+	}
+	f.Close()
+
+	// Reopen and check all data survived
+	if f, err = New(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if frozen := f.Ancients(); frozen != 100 {
+		t.Fatalf("ancients mismatch: have %d, want 100", frozen)
+	}
+	for n := uint64(0); n < 100; n++ {
+		want := []byte(fmt.Sprintf("block-%d", n))
+		if blob, err := f.Ancient(BodyTable, n); err != nil || !bytes.Equal(blob, bytes.Repeat(want, 10)) {
+			t.Fatalf("body %d mismatch: have %q, err %v", n, blob, err)
+		}
+		if blob, err := f.Ancient(HashTable, n); err != nil || !bytes.Equal(blob, want) {
+			t.Fatalf("hash %d mismatch: have %q, err %v", n, blob, err)
+		}
+	}
+	if _, err := f.Ancient(HeaderTable, 100); err != ErrOutOfBounds {
+		t.Fatalf("wrong error beyond the end: %v", err)
+	}
+	if _, err := f.Ancient("nonexistent", 0); err != ErrUnknownTable {
+		t.Fatalf("wrong error for unknown table: %v", err)
+	}
+	// Truncate and continue appending
+	if err := f.TruncateAncients(50); err != nil {
+		t.Fatal(err)
+	}
+	if f.HasAncient(HeaderTable, 50) {
+		t.Fatal("truncated block still present")
+	}
+	appendBlocks(t, f, 50, 60)
+	if frozen := f.Ancients(); frozen != 60 {
+		t.Fatalf("ancients mismatch: have %d, want 60", frozen)
+	}
+}
+
+// Tests that tables left inconsistent by a crash are repaired on open.
+func TestFreezerRepair(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendBlocks(t, f, 0, 10)
+	f.Close()
+
+	// Chop off the tail of the body data and add a torn entry to the hash index
+	if err := os.Truncate(filepath.Join(dir, "bodies.cdat"), 20); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := os.OpenFile(filepath.Join(dir, "hashes.ridx"), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx.Write([]byte{0x01, 0x02, 0x03})
+	idx.Close()
+
+	if f, err = New(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	frozen := f.Ancients()
+	if frozen >= 10 {
+		t.Fatalf("damaged tables not truncated: %d blocks", frozen)
+	}
+	for n := uint64(0); n < frozen; n++ {
+		if _, err := f.Ancient(BodyTable, n); err != nil {
+			t.Fatalf("body %d unreadable after repair: %v", n, err)
+		}
+	}
+	appendBlocks(t, f, frozen, 10)
+}
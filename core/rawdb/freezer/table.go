@@ -0,0 +1,263 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package freezer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/golang/snappy"
+)
+
+const indexEntrySize = 8 // end offset of an item in the data file (uint64 big endian)
+
+var (
+	// ErrOutOfBounds is returned if an item is requested beyond the end of a table.
+	ErrOutOfBounds = errors.New("out of bounds")
+
+	// ErrOutOrderInsertion is returned if items are appended to a table
+	// out of order.
+	ErrOutOrderInsertion = errors.New("the append operation is out-order")
+
+	// errClosed is returned if an operation is attempted on a closed table.
+	errClosed = errors.New("closed")
+)
+
+// table is an append-only flat file store of one kind of item. Items are
+// written back to back into a data file and located through an index file
+// holding the end offset of every item, so item n spans the data between the
+// end offsets of items n-1 and n.
+type table struct {
+	name     string
+	compress bool // whether items are snappy compressed
+
+	index *os.File // file holding the end offset of every item
+	data  *os.File // file holding the item payloads
+	items uint64   // number of items stored
+	size  uint64   // end offset of the last item
+
+	lock sync.RWMutex
+}
+
+// newTable opens or creates the table with the given name in dir, dropping
+// any partially written trailing item left over by a crash.
+func newTable(dir string, name string, compress bool) (*table, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	ext := "r"
+	if compress {
+		ext = "c"
+	}
+	index, err := os.OpenFile(filepath.Join(dir, fmt.Sprintf("%s.%sidx", name, ext)), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.OpenFile(filepath.Join(dir, fmt.Sprintf("%s.%sdat", name, ext)), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		index.Close()
+		return nil, err
+	}
+	t := &table{name: name, compress: compress, index: index, data: data}
+	if err := t.repair(); err != nil {
+		t.close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// repair cross checks the index and data files, truncating both to the last
+// item that was completely written.
+func (t *table) repair() error {
+	stat, err := t.index.Stat()
+	if err != nil {
+		return err
+	}
+	t.items = uint64(stat.Size()) / indexEntrySize
+
+	if stat, err = t.data.Stat(); err != nil {
+		return err
+	}
+	dataSize := uint64(stat.Size())
+	for t.items > 0 {
+		end, err := t.offset(t.items - 1)
+		if err != nil {
+			return err
+		}
+		if end <= dataSize {
+			t.size = end
+			break
+		}
+		t.items--
+	}
+	if t.items == 0 {
+		t.size = 0
+	}
+	if err := t.index.Truncate(int64(t.items * indexEntrySize)); err != nil {
+		return err
+	}
+	return t.data.Truncate(int64(t.size))
+}
+
+// offset returns the end offset of the given item in the data file.
+func (t *table) offset(item uint64) (uint64, error) {
+	var buf [indexEntrySize]byte
+	if _, err := t.index.ReadAt(buf[:], int64(item*indexEntrySize)); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(buf[:]), nil
+}
+
+// append injects a blob at the end of the table. Items must be appended in
+// order, starting from zero.
+func (t *table) append(item uint64, blob []byte) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil {
+		return errClosed
+	}
+	if item != t.items {
+		return ErrOutOrderInsertion
+	}
+	if t.compress {
+		blob = snappy.Encode(nil, blob)
+	}
+	if _, err := t.data.WriteAt(blob, int64(t.size)); err != nil {
+		return err
+	}
+	var buf [indexEntrySize]byte
+	binary.BigEndian.PutUint64(buf[:], t.size+uint64(len(blob)))
+	if _, err := t.index.WriteAt(buf[:], int64(t.items*indexEntrySize)); err != nil {
+		return err
+	}
+	t.items++
+	t.size += uint64(len(blob))
+	return nil
+}
+
+// retrieve looks up the data blob of the given item.
+func (t *table) retrieve(item uint64) ([]byte, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.index == nil {
+		return nil, errClosed
+	}
+	if item >= t.items {
+		return nil, ErrOutOfBounds
+	}
+	var start uint64
+	if item > 0 {
+		offset, err := t.offset(item - 1)
+		if err != nil {
+			return nil, err
+		}
+		start = offset
+	}
+	end, err := t.offset(item)
+	if err != nil {
+		return nil, err
+	}
+	if end < start {
+		return nil, fmt.Errorf("corrupt index of table %s at item %d", t.name, item)
+	}
+	blob := make([]byte, end-start)
+	if _, err := t.data.ReadAt(blob, int64(start)); err != nil {
+		return nil, err
+	}
+	if t.compress {
+		return snappy.Decode(nil, blob)
+	}
+	return blob, nil
+}
+
+// truncate discards all items from the given one onwards.
+func (t *table) truncate(items uint64) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil {
+		return errClosed
+	}
+	if items >= t.items {
+		return nil
+	}
+	var size uint64
+	if items > 0 {
+		offset, err := t.offset(items - 1)
+		if err != nil {
+			return err
+		}
+		size = offset
+	}
+	if err := t.index.Truncate(int64(items * indexEntrySize)); err != nil {
+		return err
+	}
+	if err := t.data.Truncate(int64(size)); err != nil {
+		return err
+	}
+	t.items, t.size = items, size
+	return nil
+}
+
+// count returns the number of items in the table.
+func (t *table) count() uint64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.items
+}
+
+// sync flushes the data file ahead of the index, so that a crash never
+// leaves index entries pointing past the end of the data.
+func (t *table) sync() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil {
+		return errClosed
+	}
+	if err := t.data.Sync(); err != nil {
+		return err
+	}
+	return t.index.Sync()
+}
+
+// close releases the files of the table.
+func (t *table) close() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil {
+		return nil
+	}
+	var errs []error
+	for _, f := range []*os.File{t.data, t.index} {
+		if err := f.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	t.index, t.data = nil, nil
+	if len(errs) > 0 {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
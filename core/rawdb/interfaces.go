@@ -31,3 +31,17 @@ type DatabaseWriter interface {
 type DatabaseDeleter interface {
 	Delete(key []byte) error
 }
+
+// AncientReader wraps the read access to the ancient store, which holds the
+// immutable part of the chain outside of the key-value database.
+type AncientReader interface {
+	Ancients() uint64
+	Ancient(kind string, number uint64) ([]byte, error)
+}
+
+// AncientWriter wraps the write access to the ancient store.
+type AncientWriter interface {
+	AppendAncient(number uint64, hash, header, body, receipts, td []byte) error
+	TruncateAncients(items uint64) error
+	Sync() error
+}
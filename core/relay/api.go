@@ -0,0 +1,66 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package relay
+
+import (
+	"context"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/common/hexutil"
+)
+
+// PublicRelayAPI exposes the sponsored transaction relay over RPC.
+type PublicRelayAPI struct {
+	relay *Relay
+}
+
+// NewPublicRelayAPI creates a new RPC service for the given relay.
+func NewPublicRelayAPI(relay *Relay) *PublicRelayAPI {
+	return &PublicRelayAPI{relay: relay}
+}
+
+// SponsoredTxArgs represents the arguments to submit a sponsored transaction.
+type SponsoredTxArgs struct {
+	From      common.Address `json:"from"`
+	To        common.Address `json:"to"`
+	Data      hexutil.Bytes  `json:"data"`
+	Gas       hexutil.Uint64 `json:"gas"`
+	Expiry    hexutil.Uint64 `json:"expiry"`
+	Signature hexutil.Bytes  `json:"signature"`
+}
+
+// Sponsor returns the account paying for sponsored transactions.
+func (api *PublicRelayAPI) Sponsor() common.Address {
+	return api.relay.Sponsor()
+}
+
+// SendSponsoredTransaction relays a call signed by args.From in a transaction
+// paid for by the sponsor, returning the hash of that transaction.
+func (api *PublicRelayAPI) SendSponsoredTransaction(ctx context.Context, args SponsoredTxArgs) (common.Hash, error) {
+	tx, err := api.relay.Submit(&Intent{
+		From:   args.From,
+		To:     args.To,
+		Data:   args.Data,
+		Gas:    uint64(args.Gas),
+		Expiry: uint64(args.Expiry),
+		Sig:    args.Signature,
+	})
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
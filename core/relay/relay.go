@@ -0,0 +1,270 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package relay implements a sponsored transaction relay, which lets a
+// configured sponsor account pay the gas of calls signed by other senders.
+//
+// A sender signs an Intent describing the call it wants to make. The relay
+// checks the signature, the target contract and the sender's quota, then
+// wraps the call into a transaction from the sponsor. The sender address is
+// appended to the call data, so sponsored contracts must treat the sponsor as
+// a trusted forwarder and read the original sender from the last 20 bytes.
+package relay
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/core/state"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/crypto"
+	"github.com/matrix/go-matrix/log"
+	"github.com/matrix/go-matrix/rlp"
+)
+
+var (
+	// ErrContractNotSponsored is returned if the intent targets a contract the
+	// sponsor does not pay for.
+	ErrContractNotSponsored = errors.New("contract not sponsored")
+
+	// ErrGasLimit is returned if the intent asks for no gas or for more gas
+	// than the relay allows per transaction.
+	ErrGasLimit = errors.New("intent gas outside relay limit")
+
+	// ErrIntentExpired is returned if the intent's expiry has already passed.
+	ErrIntentExpired = errors.New("intent expired")
+
+	// ErrIntentLifetime is returned if the intent's expiry is further in the
+	// future than the relay is willing to remember it for.
+	ErrIntentLifetime = errors.New("intent expiry too far in the future")
+
+	// ErrInvalidSignature is returned if the intent's signature does not
+	// recover to its declared sender.
+	ErrInvalidSignature = errors.New("invalid intent signature")
+
+	// ErrIntentKnown is returned if the intent has already been relayed.
+	ErrIntentKnown = errors.New("intent already relayed")
+
+	// ErrQuotaExceeded is returned if the sender has used up its quota of
+	// sponsored transactions for the current period.
+	ErrQuotaExceeded = errors.New("sender quota exceeded")
+)
+
+// Config are the configuration parameters of the sponsored transaction relay.
+type Config struct {
+	Sponsor     common.Address   // Account paying for relayed transactions, relay disabled if zero
+	Contracts   []common.Address // Contracts the sponsor is willing to pay for
+	GasLimit    uint64           // Maximum gas allowance of a single relayed transaction
+	Quota       uint64           // Maximum number of relayed transactions per sender and period
+	QuotaPeriod time.Duration    // Period over which sender quotas are accounted
+	MaxLifetime time.Duration    // Maximum time until an accepted intent expires
+}
+
+// DefaultConfig contains the default settings for the relay.
+var DefaultConfig = Config{
+	GasLimit:    500000,
+	Quota:       10,
+	QuotaPeriod: time.Hour,
+	MaxLifetime: 10 * time.Minute,
+}
+
+// sanitize checks the provided user configurations and changes anything that's
+// unreasonable or unworkable.
+func (config *Config) sanitize() Config {
+	conf := *config
+	if conf.GasLimit == 0 {
+		log.Warn("Sanitizing invalid relay gas limit", "provided", conf.GasLimit, "updated", DefaultConfig.GasLimit)
+		conf.GasLimit = DefaultConfig.GasLimit
+	}
+	if conf.Quota == 0 {
+		log.Warn("Sanitizing invalid relay quota", "provided", conf.Quota, "updated", DefaultConfig.Quota)
+		conf.Quota = DefaultConfig.Quota
+	}
+	if conf.QuotaPeriod < time.Second {
+		log.Warn("Sanitizing invalid relay quota period", "provided", conf.QuotaPeriod, "updated", DefaultConfig.QuotaPeriod)
+		conf.QuotaPeriod = DefaultConfig.QuotaPeriod
+	}
+	if conf.MaxLifetime < time.Second {
+		log.Warn("Sanitizing invalid relay intent lifetime", "provided", conf.MaxLifetime, "updated", DefaultConfig.MaxLifetime)
+		conf.MaxLifetime = DefaultConfig.MaxLifetime
+	}
+	return conf
+}
+
+// Intent is a contract call signed by a sender asking the sponsor to pay for it.
+type Intent struct {
+	From   common.Address // Sender the call is made on behalf of
+	To     common.Address // Sponsored contract to call
+	Data   []byte         // Call data, without the appended sender
+	Gas    uint64         // Gas allowance of the relayed transaction
+	Expiry uint64         // Unix time after which the intent can no longer be relayed
+	Sig    []byte         // Sender signature over Hash
+}
+
+// Hash returns the digest the sender signs. It commits to the chain id so an
+// intent cannot be replayed on another network.
+func (in *Intent) Hash(chainID *big.Int) common.Hash {
+	enc, _ := rlp.EncodeToBytes([]interface{}{chainID, in.From, in.To, in.Data, in.Gas, in.Expiry})
+	return crypto.Keccak256Hash(enc)
+}
+
+// Sender recovers the address that signed the intent.
+func (in *Intent) Sender(chainID *big.Int) (common.Address, error) {
+	if len(in.Sig) != 65 {
+		return common.Address{}, ErrInvalidSignature
+	}
+	pub, err := crypto.SigToPub(in.Hash(chainID).Bytes(), in.Sig)
+	if err != nil {
+		return common.Address{}, ErrInvalidSignature
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// SignIntent signs the intent with the given key, setting its sender and
+// signature.
+func SignIntent(in *Intent, chainID *big.Int, prv *ecdsa.PrivateKey) error {
+	in.From = crypto.PubkeyToAddress(prv.PublicKey)
+	sig, err := crypto.Sign(in.Hash(chainID).Bytes(), prv)
+	if err != nil {
+		return err
+	}
+	in.Sig = sig
+	return nil
+}
+
+// TxPool is the subset of the transaction pool used by the relay.
+type TxPool interface {
+	State() *state.ManagedState
+	GasPrice() *big.Int
+	AddLocal(tx *types.Transaction) error
+}
+
+// SignTxFn signs a transaction with the sponsor account.
+type SignTxFn func(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+
+// Relay wraps signed intents into transactions paid for by the sponsor.
+type Relay struct {
+	config    Config
+	chainID   *big.Int
+	pool      TxPool
+	signTx    SignTxFn
+	contracts map[common.Address]struct{}
+
+	mu    sync.Mutex
+	usage map[common.Address][]time.Time // Relay times per sender within the quota period
+	seen  map[common.Hash]uint64         // Relayed intent hashes until their expiry
+	now   func() time.Time
+}
+
+// New creates a relay paying for intents with the configured sponsor account.
+func New(config Config, chainID *big.Int, pool TxPool, signTx SignTxFn) *Relay {
+	config = (&config).sanitize()
+
+	contracts := make(map[common.Address]struct{}, len(config.Contracts))
+	for _, addr := range config.Contracts {
+		contracts[addr] = struct{}{}
+	}
+	return &Relay{
+		config:    config,
+		chainID:   chainID,
+		pool:      pool,
+		signTx:    signTx,
+		contracts: contracts,
+		usage:     make(map[common.Address][]time.Time),
+		seen:      make(map[common.Hash]uint64),
+		now:       time.Now,
+	}
+}
+
+// Sponsor returns the account paying for relayed transactions.
+func (r *Relay) Sponsor() common.Address {
+	return r.config.Sponsor
+}
+
+// Submit validates a signed intent and injects the sponsored transaction
+// carrying it into the local transaction pool.
+func (r *Relay) Submit(in *Intent) (*types.Transaction, error) {
+	if _, ok := r.contracts[in.To]; !ok {
+		return nil, ErrContractNotSponsored
+	}
+	if in.Gas == 0 || in.Gas > r.config.GasLimit {
+		return nil, ErrGasLimit
+	}
+	now := r.now()
+	expiry := time.Unix(int64(in.Expiry), 0)
+	if !expiry.After(now) {
+		return nil, ErrIntentExpired
+	}
+	if expiry.Sub(now) > r.config.MaxLifetime {
+		return nil, ErrIntentLifetime
+	}
+	if sender, err := in.Sender(r.chainID); err != nil || sender != in.From {
+		return nil, ErrInvalidSignature
+	}
+	hash := in.Hash(r.chainID)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.expire(now)
+	if _, ok := r.seen[hash]; ok {
+		return nil, ErrIntentKnown
+	}
+	if uint64(len(r.usage[in.From])) >= r.config.Quota {
+		return nil, ErrQuotaExceeded
+	}
+	var (
+		nonce = r.pool.State().GetNonce(r.config.Sponsor)
+		data  = append(common.CopyBytes(in.Data), in.From.Bytes()...)
+	)
+	tx, err := r.signTx(types.NewTransaction(nonce, in.To, new(big.Int), in.Gas, r.pool.GasPrice(), data), r.chainID)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.pool.AddLocal(tx); err != nil {
+		return nil, err
+	}
+	r.seen[hash] = in.Expiry
+	r.usage[in.From] = append(r.usage[in.From], now)
+
+	log.Debug("Relayed sponsored transaction", "hash", tx.Hash(), "sender", in.From, "contract", in.To, "nonce", nonce)
+	return tx, nil
+}
+
+// expire drops quota usage older than the quota period and forgets intents
+// that can no longer be relayed anyway.
+func (r *Relay) expire(now time.Time) {
+	cutoff := now.Add(-r.config.QuotaPeriod)
+	for addr, times := range r.usage {
+		for len(times) > 0 && !times[0].After(cutoff) {
+			times = times[1:]
+		}
+		if len(times) == 0 {
+			delete(r.usage, addr)
+		} else {
+			r.usage[addr] = times
+		}
+	}
+	for hash, expiry := range r.seen {
+		if int64(expiry) <= now.Unix() {
+			delete(r.seen, hash)
+		}
+	}
+}
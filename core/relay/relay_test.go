@@ -0,0 +1,189 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package relay
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/core/state"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/crypto"
+	"github.com/matrix/go-matrix/mandb"
+)
+
+var (
+	testChainID  = big.NewInt(1)
+	testContract = common.HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314")
+)
+
+// testPool is a transaction pool recording the transactions added to it.
+type testPool struct {
+	state *state.ManagedState
+	txs   []*types.Transaction
+}
+
+func newTestPool() *testPool {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(mandb.NewMemDatabase()))
+	return &testPool{state: state.ManageState(statedb)}
+}
+
+func (p *testPool) State() *state.ManagedState { return p.state }
+func (p *testPool) GasPrice() *big.Int         { return big.NewInt(1) }
+
+func (p *testPool) AddLocal(tx *types.Transaction) error {
+	p.txs = append(p.txs, tx)
+	return nil
+}
+
+func newTestRelay(t *testing.T, quota uint64) (*Relay, *testPool, *ecdsa.PrivateKey) {
+	sponsorKey, _ := crypto.GenerateKey()
+	sponsor := crypto.PubkeyToAddress(sponsorKey.PublicKey)
+
+	pool := newTestPool()
+	signer := types.NewEIP155Signer(testChainID)
+	relay := New(Config{
+		Sponsor:     sponsor,
+		Contracts:   []common.Address{testContract},
+		GasLimit:    100000,
+		Quota:       quota,
+		QuotaPeriod: time.Hour,
+		MaxLifetime: 10 * time.Minute,
+	}, testChainID, pool, func(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+		signed, err := types.SignTx(tx, signer, sponsorKey)
+		if err == nil {
+			pool.state.SetNonce(sponsor, tx.Nonce()+1)
+		}
+		return signed, err
+	})
+	return relay, pool, sponsorKey
+}
+
+func newTestIntent(t *testing.T, key *ecdsa.PrivateKey, data []byte, expiry time.Time) *Intent {
+	in := &Intent{To: testContract, Data: data, Gas: 50000, Expiry: uint64(expiry.Unix())}
+	if err := SignIntent(in, testChainID, key); err != nil {
+		t.Fatalf("failed to sign intent: %v", err)
+	}
+	return in
+}
+
+// Tests that a valid intent is wrapped into a sponsor transaction carrying the
+// call data with the sender appended.
+func TestSubmit(t *testing.T) {
+	relay, pool, sponsorKey := newTestRelay(t, 10)
+	key, _ := crypto.GenerateKey()
+
+	in := newTestIntent(t, key, []byte{0xde, 0xad}, time.Now().Add(time.Minute))
+	tx, err := relay.Submit(in)
+	if err != nil {
+		t.Fatalf("failed to submit intent: %v", err)
+	}
+	if len(pool.txs) != 1 || pool.txs[0] != tx {
+		t.Fatalf("pool transactions mismatch: have %d, want 1", len(pool.txs))
+	}
+	from, err := types.Sender(types.NewEIP155Signer(testChainID), tx)
+	if err != nil || from != crypto.PubkeyToAddress(sponsorKey.PublicKey) {
+		t.Errorf("transaction sender mismatch: have %x (err %v), want sponsor", from, err)
+	}
+	if *tx.To() != testContract || tx.Gas() != in.Gas || tx.Value().Sign() != 0 {
+		t.Errorf("transaction fields mismatch: to %x, gas %d, value %v", tx.To(), tx.Gas(), tx.Value())
+	}
+	if want := append([]byte{0xde, 0xad}, in.From.Bytes()...); !bytes.Equal(tx.Data(), want) {
+		t.Errorf("call data mismatch: have %x, want %x", tx.Data(), want)
+	}
+	// A second intent must use the next sponsor nonce
+	first := tx.Nonce()
+	tx, err = relay.Submit(newTestIntent(t, key, []byte{0xbe, 0xef}, time.Now().Add(time.Minute)))
+	if err != nil {
+		t.Fatalf("failed to submit second intent: %v", err)
+	}
+	if tx.Nonce() != first+1 {
+		t.Errorf("second nonce mismatch: have %d, want %d", tx.Nonce(), first+1)
+	}
+}
+
+// Tests that invalid intents are rejected before reaching the pool.
+func TestSubmitRejects(t *testing.T) {
+	relay, pool, _ := newTestRelay(t, 10)
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+
+	valid := func() *Intent { return newTestIntent(t, key, nil, time.Now().Add(time.Minute)) }
+
+	tests := []struct {
+		intent *Intent
+		err    error
+	}{
+		{func() *Intent { in := valid(); in.To = common.Address{1}; return in }(), ErrContractNotSponsored},
+		{func() *Intent { in := valid(); in.Gas = 0; return in }(), ErrGasLimit},
+		{func() *Intent { in := valid(); in.Gas = 100001; return in }(), ErrGasLimit},
+		{newTestIntent(t, key, nil, time.Now().Add(-time.Second)), ErrIntentExpired},
+		{newTestIntent(t, key, nil, time.Now().Add(time.Hour)), ErrIntentLifetime},
+		{func() *Intent { in := valid(); in.Data = []byte{1}; return in }(), ErrInvalidSignature},
+		{func() *Intent { in := valid(); in.From = crypto.PubkeyToAddress(other.PublicKey); return in }(), ErrInvalidSignature},
+		{func() *Intent { in := valid(); in.Sig = in.Sig[:64]; return in }(), ErrInvalidSignature},
+	}
+	for i, tt := range tests {
+		if _, err := relay.Submit(tt.intent); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+	if len(pool.txs) != 0 {
+		t.Errorf("rejected intents reached the pool: %d", len(pool.txs))
+	}
+	// Relaying the same intent twice must fail
+	in := valid()
+	if _, err := relay.Submit(in); err != nil {
+		t.Fatalf("failed to submit intent: %v", err)
+	}
+	if _, err := relay.Submit(in); err != ErrIntentKnown {
+		t.Errorf("replay error mismatch: have %v, want %v", err, ErrIntentKnown)
+	}
+}
+
+// Tests that sender quotas are enforced and replenished once the quota period
+// has passed.
+func TestSubmitQuota(t *testing.T) {
+	relay, _, _ := newTestRelay(t, 2)
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+
+	now := time.Now()
+	relay.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if _, err := relay.Submit(newTestIntent(t, key, []byte{byte(i)}, now.Add(time.Minute))); err != nil {
+			t.Fatalf("intent %d: failed to submit: %v", i, err)
+		}
+	}
+	if _, err := relay.Submit(newTestIntent(t, key, []byte{2}, now.Add(time.Minute))); err != ErrQuotaExceeded {
+		t.Errorf("quota error mismatch: have %v, want %v", err, ErrQuotaExceeded)
+	}
+	// Other senders have their own quota
+	if _, err := relay.Submit(newTestIntent(t, other, []byte{2}, now.Add(time.Minute))); err != nil {
+		t.Errorf("failed to submit intent of other sender: %v", err)
+	}
+	// Once the period passes, the sender may relay again
+	now = now.Add(time.Hour + time.Second)
+	if _, err := relay.Submit(newTestIntent(t, key, []byte{3}, now.Add(time.Minute))); err != nil {
+		t.Errorf("failed to submit intent after quota period: %v", err)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 
 	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/common/hexutil"
 	"github.com/matrix/go-matrix/rlp"
 	"github.com/matrix/go-matrix/trie"
 )
@@ -39,6 +40,14 @@ type Dump struct {
 	Accounts map[string]DumpAccount `json:"accounts"`
 }
 
+// IteratorDump is a single page of a state dump. Next holds the hashed trie key
+// to resume the enumeration from, it is empty once the trie is exhausted.
+type IteratorDump struct {
+	Root     string                 `json:"root"`
+	Accounts map[string]DumpAccount `json:"accounts"`
+	Next     hexutil.Bytes          `json:"next,omitempty"`
+}
+
 func (self *StateDB) RawDump() Dump {
 	dump := Dump{
 		Root:     fmt.Sprintf("%x", self.trie.Hash()),
@@ -52,23 +61,59 @@ func (self *StateDB) RawDump() Dump {
 		if err := rlp.DecodeBytes(it.Value, &data); err != nil {
 			panic(err)
 		}
+		dump.Accounts[common.Bytes2Hex(addr)] = self.dumpAccount(common.BytesToAddress(addr), data, false, false)
+	}
+	return dump
+}
 
-		obj := newObject(nil, common.BytesToAddress(addr), data)
-		account := DumpAccount{
-			Balance:  data.Balance.String(),
-			Nonce:    data.Nonce,
-			Root:     common.Bytes2Hex(data.Root[:]),
-			CodeHash: common.Bytes2Hex(data.CodeHash),
-			Code:     common.Bytes2Hex(obj.Code(self.db)),
-			Storage:  make(map[string]string),
+// DumpRange dumps at most maxResults accounts, walking the state trie from the
+// given hashed key onwards. Accounts whose address preimage is unknown are keyed
+// by their hashed trie key instead. Code and storage can be left out to keep the
+// pages small.
+func (self *StateDB) DumpRange(start []byte, maxResults int, excludeCode, excludeStorage bool) (IteratorDump, error) {
+	dump := IteratorDump{
+		Root:     fmt.Sprintf("%x", self.trie.Hash()),
+		Accounts: make(map[string]DumpAccount),
+	}
+	it := trie.NewIterator(self.trie.NodeIterator(start))
+	for it.Next() {
+		if len(dump.Accounts) >= maxResults {
+			dump.Next = common.CopyBytes(it.Key)
+			break
 		}
+		var data Account
+		if err := rlp.DecodeBytes(it.Value, &data); err != nil {
+			return IteratorDump{}, err
+		}
+		addr := self.trie.GetKey(it.Key)
+		key := common.Bytes2Hex(addr)
+		if addr == nil {
+			key = common.Bytes2Hex(it.Key)
+		}
+		dump.Accounts[key] = self.dumpAccount(common.BytesToAddress(addr), data, excludeCode, excludeStorage)
+	}
+	return dump, it.Err
+}
+
+func (self *StateDB) dumpAccount(addr common.Address, data Account, excludeCode, excludeStorage bool) DumpAccount {
+	obj := newObject(nil, addr, data)
+	account := DumpAccount{
+		Balance:  data.Balance.String(),
+		Nonce:    data.Nonce,
+		Root:     common.Bytes2Hex(data.Root[:]),
+		CodeHash: common.Bytes2Hex(data.CodeHash),
+		Storage:  make(map[string]string),
+	}
+	if !excludeCode {
+		account.Code = common.Bytes2Hex(obj.Code(self.db))
+	}
+	if !excludeStorage {
 		storageIt := trie.NewIterator(obj.getTrie(self.db).NodeIterator(nil))
 		for storageIt.Next() {
 			account.Storage[common.Bytes2Hex(self.trie.GetKey(storageIt.Key))] = common.Bytes2Hex(storageIt.Value)
 		}
-		dump.Accounts[common.Bytes2Hex(addr)] = account
 	}
-	return dump
+	return account
 }
 
 func (self *StateDB) Dump() []byte {
@@ -0,0 +1,90 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package state
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/rlp"
+	"github.com/matrix/go-matrix/trie"
+)
+
+// errMarkAborted is returned by MarkReachable if it was interrupted.
+var errMarkAborted = errors.New("mark aborted")
+
+// MarkReachable adds the hashes of all the trie nodes and contract codes that
+// are reachable from the given state root to the marked set. Subtries already
+// in the set are assumed to be fully marked and are not visited again, so that
+// consecutive roots sharing most of their state are cheap to walk.
+func MarkReachable(db Database, root common.Hash, marked *trie.MarkSet, abort <-chan struct{}) error {
+	tr, err := db.OpenTrie(root)
+	if err != nil {
+		return err
+	}
+	return markTrie(tr.NodeIterator(nil), marked, abort, func(leaf []byte) error {
+		var account Account
+		if err := rlp.Decode(bytes.NewReader(leaf), &account); err != nil {
+			return err
+		}
+		if account.Root != emptyState {
+			storage, err := db.OpenStorageTrie(common.Hash{}, account.Root)
+			if err != nil {
+				return err
+			}
+			if err := markTrie(storage.NodeIterator(nil), marked, abort, nil); err != nil {
+				return err
+			}
+		}
+		if code := common.BytesToHash(account.CodeHash); code != emptyCode {
+			return marked.Add(code)
+		}
+		return nil
+	})
+}
+
+// markTrie walks a trie, skipping subtries whose root was already marked, and
+// invokes onLeaf for every leaf reached.
+func markTrie(it trie.NodeIterator, marked *trie.MarkSet, abort <-chan struct{}, onLeaf func(leaf []byte) error) error {
+	for descend := true; it.Next(descend); {
+		select {
+		case <-abort:
+			return errMarkAborted
+		default:
+		}
+		descend = true
+		if hash := it.Hash(); hash != (common.Hash{}) {
+			seen, err := marked.Contains(hash)
+			if err != nil {
+				return err
+			}
+			if seen {
+				descend = false
+				continue
+			}
+			if err := marked.Add(hash); err != nil {
+				return err
+			}
+		}
+		if it.Leaf() && onLeaf != nil {
+			if err := onLeaf(it.LeafBlob()); err != nil {
+				return err
+			}
+		}
+	}
+	return it.Error()
+}
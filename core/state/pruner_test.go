@@ -0,0 +1,112 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package state
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/core/rawdb"
+	"github.com/matrix/go-matrix/mandb"
+	"github.com/matrix/go-matrix/trie"
+)
+
+// makePrunableState creates a state with accounts, storage and code derived from
+// seed and flushes it to disk, returning its root hash.
+func makePrunableState(t *testing.T, db Database, seed byte) common.Hash {
+	state, _ := New(common.Hash{}, db)
+	for i := byte(0); i < 32; i++ {
+		addr := common.BytesToAddress([]byte{seed, i})
+		state.SetBalance(addr, big.NewInt(int64(seed)*1000+int64(i)))
+		state.SetState(addr, common.BytesToHash([]byte{i}), common.BytesToHash([]byte{seed, i}))
+		if i%4 == 0 {
+			state.SetCode(addr, []byte{seed, i, i, i})
+		}
+	}
+	root, err := state.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := db.TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to flush state: %v", err)
+	}
+	return root
+}
+
+// Tests that state pruning works on a chain database wrapped by the ancient
+// freezer, which is what every full node runs on.
+func TestPruneWithFreezer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state-prune")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	kvdb, err := mandb.NewLDBDatabase(filepath.Join(dir, "chaindata"), 16, 16)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	diskdb, err := rawdb.NewDatabaseWithFreezer(kvdb, filepath.Join(dir, "ancient"))
+	if err != nil {
+		t.Fatalf("failed to open ancient store: %v", err)
+	}
+	defer diskdb.Close()
+
+	testPrune(t, diskdb)
+}
+
+// Tests that state pruning works on a chain database split across shards.
+func TestPruneSharded(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state-prune")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	diskdb, err := mandb.OpenSharded(mandb.DefaultEngine, []string{filepath.Join(dir, "a"), filepath.Join(dir, "b")}, 16, 16)
+	if err != nil {
+		t.Fatalf("failed to open sharded database: %v", err)
+	}
+	defer diskdb.Close()
+
+	testPrune(t, diskdb)
+}
+
+func testPrune(t *testing.T, diskdb mandb.Database) {
+	db := NewDatabase(diskdb)
+	stale := makePrunableState(t, db, 1)
+	live := makePrunableState(t, db, 2)
+
+	deleted, err := db.TrieDB().Prune(func(keep *trie.MarkSet) error {
+		return MarkReachable(db, live, keep, nil)
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to prune: %v", err)
+	}
+	if deleted == 0 {
+		t.Fatalf("no nodes pruned")
+	}
+	if ok, _ := diskdb.Has(stale[:]); ok {
+		t.Errorf("stale state root still present after prune")
+	}
+	if err := checkStateConsistency(diskdb, live); err != nil {
+		t.Errorf("live state damaged by prune: %v", err)
+	}
+}
@@ -86,6 +86,44 @@ func (s *StateSuite) TestDump(c *checker.C) {
 	}
 }
 
+func (s *StateSuite) TestDumpRange(c *checker.C) {
+	for i := byte(1); i <= 5; i++ {
+		obj := s.state.GetOrNewStateObject(toAddr([]byte{i}))
+		obj.AddBalance(big.NewInt(int64(i)))
+		obj.SetCode(crypto.Keccak256Hash([]byte{i}), []byte{i})
+	}
+	s.state.Commit(false)
+
+	// page through the trie two accounts at a time
+	var (
+		accounts = make(map[string]DumpAccount)
+		start    []byte
+		pages    int
+	)
+	for {
+		page, err := s.state.DumpRange(start, 2, true, false)
+		c.Assert(err, checker.IsNil)
+		c.Assert(len(page.Accounts) <= 2, checker.Equals, true)
+		for key, account := range page.Accounts {
+			c.Assert(account.Code, checker.Equals, "")
+			accounts[key] = account
+		}
+		pages++
+		if len(page.Next) == 0 {
+			break
+		}
+		start = page.Next
+	}
+	c.Assert(pages, checker.Equals, 3)
+
+	full := s.state.RawDump()
+	c.Assert(len(accounts), checker.Equals, len(full.Accounts))
+	for key, account := range full.Accounts {
+		account.Code = ""
+		c.Assert(accounts[key], checker.DeepEquals, account)
+	}
+}
+
 func (s *StateSuite) SetUpTest(c *checker.C) {
 	s.db = mandb.NewMemDatabase()
 	s.state, _ = New(common.Hash{}, NewDatabase(s.db))
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/matrix/go-matrix/common"
 	"github.com/matrix/go-matrix/core/types"
@@ -95,7 +96,11 @@ func (journal *txJournal) load(add func([]*types.Transaction) []error) error {
 		// Parse the next transaction and terminate on error
 		tx := new(types.Transaction)
 		if err = stream.Decode(tx); err != nil {
-			if err != io.EOF {
+			// A crash may leave a partially written transaction at the end of
+			// the journal. Keep everything before it, the next rotation drops it.
+			if err == io.ErrUnexpectedEOF {
+				log.Warn("Discarding truncated transaction journal entry", "path", journal.path)
+			} else if err != io.EOF {
 				failure = err
 			}
 			if batch.Len() > 0 {
@@ -138,6 +143,9 @@ func (journal *txJournal) rotate(all map[common.Address]types.Transactions) erro
 		journal.writer = nil
 	}
 	// Generate a new journal with the contents of the current pool
+	if err := os.MkdirAll(filepath.Dir(journal.path), 0755); err != nil {
+		return err
+	}
 	replacement, err := os.OpenFile(journal.path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
 	if err != nil {
 		return err
@@ -152,6 +160,12 @@ func (journal *txJournal) rotate(all map[common.Address]types.Transactions) erro
 		}
 		journaled += len(txs)
 	}
+	// Make sure the replacement hits the disk before swapping it in, otherwise a
+	// crash right after the rename could leave an empty journal behind.
+	if err = replacement.Sync(); err != nil {
+		replacement.Close()
+		return err
+	}
 	replacement.Close()
 
 	// Replace the live journal with the newly generated one
@@ -0,0 +1,94 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/crypto"
+)
+
+// Tests that a journal cut short by a crash in the middle of a write is still
+// replayed up to the last complete transaction, and that rotation drops the
+// partial entry.
+func TestTxJournalTruncatedReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "txjournal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "transactions.rlp")
+
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	txs := types.Transactions{transaction(0, 100000, key), transaction(1, 100000, key)}
+
+	journal := newTxJournal(path)
+	if err := journal.rotate(map[common.Address]types.Transactions{from: txs}); err != nil {
+		t.Fatalf("failed to rotate journal: %v", err)
+	}
+	if err := journal.insert(transaction(2, 100000, key)); err != nil {
+		t.Fatalf("failed to insert transaction: %v", err)
+	}
+	journal.close()
+
+	// Chop off the tail of the last transaction to simulate a crash
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, blob[:len(blob)-10], 0644); err != nil {
+		t.Fatal(err)
+	}
+	var loaded types.Transactions
+	journal = newTxJournal(path)
+	err = journal.load(func(batch []*types.Transaction) []error {
+		loaded = append(loaded, batch...)
+		return make([]error, len(batch))
+	})
+	if err != nil {
+		t.Fatalf("failed to load truncated journal: %v", err)
+	}
+	if len(loaded) != len(txs) {
+		t.Fatalf("loaded transaction count mismatch: have %d, want %d", len(loaded), len(txs))
+	}
+	for i, tx := range loaded {
+		if tx.Hash() != txs[i].Hash() {
+			t.Errorf("transaction %d: hash mismatch: have %x, want %x", i, tx.Hash(), txs[i].Hash())
+		}
+	}
+	// Rotating the journal should leave only the complete transactions behind
+	if err := journal.rotate(map[common.Address]types.Transactions{from: loaded}); err != nil {
+		t.Fatalf("failed to rotate journal: %v", err)
+	}
+	journal.close()
+
+	loaded = loaded[:0]
+	if err := journal.load(func(batch []*types.Transaction) []error {
+		loaded = append(loaded, batch...)
+		return make([]error, len(batch))
+	}); err != nil {
+		t.Fatalf("failed to load rotated journal: %v", err)
+	}
+	if len(loaded) != len(txs) {
+		t.Fatalf("rotated transaction count mismatch: have %d, want %d", len(loaded), len(txs))
+	}
+}
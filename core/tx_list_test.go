@@ -17,6 +17,7 @@
 package core
 
 import (
+	"math/big"
 	"math/rand"
 	"testing"
 
@@ -37,7 +38,7 @@ func TestStrictTxListAdd(t *testing.T) {
 	// Insert the transactions in a random order
 	list := newTxList(true)
 	for _, v := range rand.Perm(len(txs)) {
-		list.Add(txs[v], DefaultTxPoolConfig.PriceBump)
+		list.Add(txs[v], &GasPricer{PriceBump: DefaultTxPoolConfig.PriceBump})
 	}
 	// Verify internal state
 	if len(list.txs.items) != len(txs) {
@@ -49,3 +50,58 @@ func TestStrictTxListAdd(t *testing.T) {
 		}
 	}
 }
+
+// gasLimitPricer is a test pricer valuing transactions by their gas limit,
+// allowing replacements by any transaction with a larger one.
+type gasLimitPricer struct{}
+
+func (gasLimitPricer) Cmp(a, b *types.Transaction) int {
+	switch {
+	case a.Gas() < b.Gas():
+		return -1
+	case a.Gas() > b.Gas():
+		return 1
+	}
+	return 0
+}
+
+func (gasLimitPricer) Replaces(old, tx *types.Transaction) bool {
+	return tx.Gas() > old.Gas()
+}
+
+// Tests that transaction lists and the priced list consult the configured
+// pricer for replacements, underpricing and eviction order.
+func TestCustomTxPricer(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	pricer := gasLimitPricer{}
+
+	// Replacements are decided by the pricer, regardless of the gas price
+	list := newTxList(true)
+	list.Add(pricedTransaction(0, 100000, big.NewInt(10), key), pricer)
+	if ok, _ := list.Add(pricedTransaction(0, 100000, big.NewInt(1000), key), pricer); ok {
+		t.Errorf("replacement with equal gas limit accepted")
+	}
+	if ok, old := list.Add(pricedTransaction(0, 200000, big.NewInt(1), key), pricer); !ok || old == nil {
+		t.Errorf("replacement with higher gas limit rejected")
+	}
+	// Eviction order and underpricing follow the pricer too
+	all := newTxLookup()
+	priced := newTxPricedList(all, pricer)
+	locals := newAccountSet(types.NewEIP155Signer(big.NewInt(1)))
+
+	for i, gas := range []uint64{300000, 100000, 200000} {
+		tx := pricedTransaction(uint64(i), gas, big.NewInt(int64(1000-i)), key)
+		all.Add(tx)
+		priced.Put(tx)
+	}
+	if !priced.Underpriced(pricedTransaction(3, 100000, big.NewInt(100000), key), locals) {
+		t.Errorf("transaction with lowest gas limit not underpriced")
+	}
+	if priced.Underpriced(pricedTransaction(3, 150000, big.NewInt(1), key), locals) {
+		t.Errorf("transaction with higher gas limit underpriced")
+	}
+	drop := priced.Discard(2, locals)
+	if len(drop) != 2 || drop[0].Gas() != 100000 || drop[1].Gas() != 200000 {
+		t.Errorf("discarded transactions mismatch: have %v", drop)
+	}
+}
@@ -125,6 +125,10 @@ var (
 	// General tx metrics
 	invalidTxCounter     = metrics.NewRegisteredCounter("txpool/invalid", nil)
 	underpricedTxCounter = metrics.NewRegisteredCounter("txpool/underpriced", nil)
+
+	// Pool size metrics, refreshed on every stats report
+	pendingGauge = metrics.NewRegisteredGauge("txpool/pending", nil)
+	queuedGauge  = metrics.NewRegisteredGauge("txpool/queued", nil)
 )
 
 // TxStatus is the current status of a transaction as seen by the pool.
@@ -230,6 +234,10 @@ type TxPoolConfig struct {
 	PriceLimit uint64 // Minimum gas price to enforce for acceptance into the pool
 	PriceBump  uint64 // Minimum price bump percentage to replace an already existing transaction (nonce)
 
+	// Pricer values transactions for ordering, replacement and eviction. If
+	// nil, transactions are valued by gas price, bumped by PriceBump.
+	Pricer TxPricer `toml:"-"`
+
 	AccountSlots uint64 // Minimum number of executable transaction slots guaranteed per account
 	GlobalSlots  uint64 // Maximum number of executable transaction slots for all accounts
 	AccountQueue uint64 // Maximum number of non-executable transaction slots permitted per account
@@ -271,6 +279,9 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool price bump", "provided", conf.PriceBump, "updated", DefaultTxPoolConfig.PriceBump)
 		conf.PriceBump = DefaultTxPoolConfig.PriceBump
 	}
+	if conf.Pricer == nil {
+		conf.Pricer = &GasPricer{PriceBump: conf.PriceBump}
+	}
 	return conf
 }
 
@@ -344,22 +355,16 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		gasPrice:    new(big.Int).SetUint64(config.PriceLimit),
 	}
 	pool.locals = newAccountSet(pool.signer)
-	pool.priced = newTxPricedList(pool.all)
+	pool.priced = newTxPricedList(pool.all, pool.config.Pricer)
 	pool.reset(nil, chain.CurrentBlock().Header())
 
 	//go pool.testList() //for test
 
-	// If local transactions and journaling is enabled, load from disk
-	//if !config.NoLocals && config.Journal != "" {
-	//	pool.journal = newTxJournal(config.Journal)
-	//
-	//	if err := pool.journal.load(pool.AddLocals); err != nil {
-	//		log.Warn("Failed to load transaction journal", "err", err)
-	//	}
-	//	if err := pool.journal.rotate(pool.local()); err != nil {
-	//		log.Warn("Failed to rotate transaction journal", "err", err)
-	//	}
-	//}
+	// If local transactions and journaling is enabled, create the journal. It is
+	// replayed once the event loops are running, see below.
+	if !config.NoLocals && config.Journal != "" {
+		pool.journal = newTxJournal(config.Journal)
+	}
 	// Subscribe events from blockchain
 	pool.chainHeadSub = pool.chain.SubscribeChainHeadEvent(pool.chainHeadCh)
 
@@ -379,6 +384,19 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 	go pool.checkList() //hezi
 	go pool.listenudp()
 
+	// Replay the local transactions journaled before the last shutdown or crash.
+	// This must happen after the loops are started, as transactions injected by
+	// miners and validators are handed over to the broadcast loop.
+	if pool.journal != nil {
+		if err := pool.journal.load(pool.AddLocals); err != nil {
+			log.Warn("Failed to load transaction journal", "err", err)
+		}
+		pool.mu.Lock()
+		if err := pool.journal.rotate(pool.local()); err != nil {
+			log.Warn("Failed to rotate transaction journal", "err", err)
+		}
+		pool.mu.Unlock()
+	}
 	return pool
 }
 
@@ -503,6 +521,9 @@ func (pool *TxPool) loop() {
 			stales := pool.priced.stales
 			pool.mu.RUnlock()
 
+			pendingGauge.Update(int64(pending))
+			queuedGauge.Update(int64(queued))
+
 			if pending != prevPending || queued != prevQueued || stales != prevStales {
 				log.Debug("Transaction pool status report", "executable", pending, "queued", queued, "stales", stales)
 				prevPending, prevQueued, prevStales = pending, queued, stales
@@ -1048,6 +1069,22 @@ func (pool *TxPool) Content() (map[common.Address]types.Transactions, map[common
 	return pending, queued
 }
 
+// ContentFrom retrieves the data content of the transaction pool for a single
+// account, returning its pending as well as queued transactions, sorted by nonce.
+func (pool *TxPool) ContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	var pending, queued types.Transactions
+	if list, ok := pool.pending[addr]; ok {
+		pending = list.Flatten()
+	}
+	if list, ok := pool.queue[addr]; ok {
+		queued = list.Flatten()
+	}
+	return pending, queued
+}
+
 // Pending retrieves all currently processable transactions, groupped by origin
 // account and sorted by nonce. The returned transaction set is a copy and can be
 // freely modified by calling code.
@@ -1689,7 +1726,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 	from, _ := types.Sender(pool.signer, tx) // already validated
 	if list := pool.pending[from]; list != nil && list.Overlaps(tx) {
 		// Nonce already pending, check if required price bump is met
-		inserted, old := list.Add(tx, pool.config.PriceBump)
+		inserted, old := list.Add(tx, pool.config.Pricer)
 		if !inserted {
 			pendingDiscardCounter.Inc(1)
 			return false, ErrReplaceUnderpriced
@@ -1753,7 +1790,7 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 	if pool.queue[from] == nil {
 		pool.queue[from] = newTxList(false)
 	}
-	inserted, old := pool.queue[from].Add(tx, pool.config.PriceBump)
+	inserted, old := pool.queue[from].Add(tx, pool.config.Pricer)
 	if !inserted {
 		// An older transaction was better, discard this
 		queuedDiscardCounter.Inc(1)
@@ -1795,7 +1832,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	}
 	list := pool.pending[addr]
 
-	inserted, old := list.Add(tx, pool.config.PriceBump)
+	inserted, old := list.Add(tx, pool.config.Pricer)
 	if !inserted {
 		// An older transaction was better, discard this
 		pool.all.Remove(hash)
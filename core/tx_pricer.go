@@ -0,0 +1,59 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package core
+
+import (
+	"math/big"
+
+	"github.com/matrix/go-matrix/core/types"
+)
+
+// TxPricer values transactions for the transaction pool. The pool consults it
+// to order its transactions for eviction when full, to decide whether a new
+// transaction is worth more than the cheapest one already pooled, and whether
+// a transaction may replace a pooled one with the same sender and nonce.
+//
+// Cmp must be a consistent total order that doesn't change while transactions
+// are pooled, as the pool keeps them in a heap sorted by it.
+type TxPricer interface {
+	// Cmp returns -1 if a is worth less than b, +1 if it is worth more and 0 if
+	// both are worth the same. The least worth transactions are evicted first.
+	Cmp(a, b *types.Transaction) int
+
+	// Replaces reports whether tx may replace old, a pooled transaction of the
+	// same sender with the same nonce.
+	Replaces(old, tx *types.Transaction) bool
+}
+
+// GasPricer is the default TxPricer, valuing transactions by their gas price.
+// A replacement has to pay at least PriceBump percent more than the original.
+type GasPricer struct {
+	PriceBump uint64 // Minimum price bump percentage to replace a transaction
+}
+
+// Cmp implements TxPricer, comparing the gas prices of the transactions.
+func (p *GasPricer) Cmp(a, b *types.Transaction) int {
+	return a.GasPrice().Cmp(b.GasPrice())
+}
+
+// Replaces implements TxPricer, requiring the gas price to be bumped.
+func (p *GasPricer) Replaces(old, tx *types.Transaction) bool {
+	threshold := new(big.Int).Div(new(big.Int).Mul(old.GasPrice(), big.NewInt(100+int64(p.PriceBump))), big.NewInt(100))
+	// Have to ensure that the new gas price is higher than the old gas
+	// price as well as checking the percentage threshold to ensure that
+	// this is accurate for low (Wei-level) gas price replacements
+	return old.GasPrice().Cmp(tx.GasPrice()) < 0 && threshold.Cmp(tx.GasPrice()) <= 0
+}
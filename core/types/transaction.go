@@ -346,6 +346,16 @@ func (tx *Transaction) SetNonce(nc uint64) {
 	tx.data.AccountNonce = nc
 }
 
+// WithGasPrice returns an unsigned copy of the transaction with the gas price
+// replaced, used to re-sign a transaction when bumping its price in the pool.
+func (tx *Transaction) WithGasPrice(price *big.Int) *Transaction {
+	cpy := &Transaction{data: tx.data}
+	cpy.data.Price = new(big.Int).Set(price)
+	cpy.data.V, cpy.data.R, cpy.data.S = new(big.Int), new(big.Int), new(big.Int)
+	cpy.data.Hash = nil
+	return cpy
+}
+
 //YY
 func GetFloodData(tx *Transaction) *Floodtxdata {
 
@@ -264,3 +264,21 @@ func TestTransactionJSON(t *testing.T) {
 		}
 	}
 }
+
+// Tests that re-pricing a transaction keeps its content, but drops the signature.
+func TestTransactionWithGasPrice(t *testing.T) {
+	tx := rightvrsTx.WithGasPrice(big.NewInt(2))
+
+	if tx.GasPrice().Cmp(big.NewInt(2)) != 0 {
+		t.Errorf("gas price mismatch: have %v, want %v", tx.GasPrice(), 2)
+	}
+	if rightvrsTx.GasPrice().Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("original gas price modified: have %v, want %v", rightvrsTx.GasPrice(), 1)
+	}
+	if tx.Nonce() != rightvrsTx.Nonce() || tx.Gas() != rightvrsTx.Gas() || *tx.To() != *rightvrsTx.To() || !bytes.Equal(tx.Data(), rightvrsTx.Data()) {
+		t.Errorf("transaction content changed by re-pricing")
+	}
+	if v, r, s := tx.RawSignatureValues(); v.Sign() != 0 || r.Sign() != 0 || s.Sign() != 0 {
+		t.Errorf("signature retained after re-pricing: v %v, r %v, s %v", v, r, s)
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package vm
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/matrix/go-matrix/common"
+)
+
+// ContractStatsTracer is a Tracer aggregating the execution statistics of a
+// single contract over any number of transactions traced with it.
+//
+// Gas is attributed to the frame executing an operation. The cost of a call
+// or create includes the gas handed to the callee, so that part is left to
+// the callee's own frame and only the remainder is counted for the caller.
+type ContractStatsTracer struct {
+	address common.Address
+	frames  []statsFrame
+	slots   map[common.Hash]struct{}
+
+	Calls         uint64 // Number of times the contract's code was entered
+	Gas           uint64 // Gas consumed by the contract's own code, excluding its sub-calls
+	StorageWrites uint64 // Number of storage writes executed by the contract
+}
+
+// statsFrame tracks the last operation of a call frame until its actual
+// cost is known, which for calls is only the case once the callee returned.
+type statsFrame struct {
+	target   bool   // Whether the frame executes the traced contract
+	pending  bool   // Whether the last operation still has to be accounted
+	op       OpCode // Last operation executed in the frame
+	gas      uint64 // Gas available before the last operation
+	cost     uint64 // Cost of the last operation
+	childGas uint64 // Gas available to the frame entered by the last operation
+}
+
+// NewContractStatsTracer creates a tracer collecting statistics of the
+// contract at address.
+func NewContractStatsTracer(address common.Address) *ContractStatsTracer {
+	return &ContractStatsTracer{
+		address: address,
+		slots:   make(map[common.Hash]struct{}),
+	}
+}
+
+// SlotsWritten returns the number of distinct storage slots written.
+func (t *ContractStatsTracer) SlotsWritten() int {
+	return len(t.slots)
+}
+
+func (t *ContractStatsTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.frames = t.frames[:0]
+	return nil
+}
+
+func (t *ContractStatsTracer) CaptureState(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
+	// Frames deeper than the current one have returned
+	for len(t.frames) > depth {
+		t.leave()
+	}
+	if len(t.frames) < depth {
+		// The last operation of the parent entered a new frame
+		if n := len(t.frames); n > 0 {
+			t.frames[n-1].childGas = gas
+		}
+		target := contract.Address() == t.address
+		if target {
+			t.Calls++
+		}
+		t.frames = append(t.frames, statsFrame{target: target})
+	} else {
+		t.settle(&t.frames[len(t.frames)-1], gas)
+	}
+	f := &t.frames[len(t.frames)-1]
+	f.pending, f.op, f.gas, f.cost, f.childGas = true, op, gas, cost, 0
+
+	if err != nil {
+		// Failing operations consume all the remaining gas
+		f.cost = gas
+	}
+	if f.target && op == SSTORE && err == nil {
+		t.StorageWrites++
+		t.slots[common.BigToHash(stack.Back(0))] = struct{}{}
+	}
+	return nil
+}
+
+func (t *ContractStatsTracer) CaptureFault(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
+	if n := len(t.frames); n > 0 {
+		t.frames[n-1].cost = gas
+	}
+	return nil
+}
+
+func (t *ContractStatsTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	for len(t.frames) > 0 {
+		t.leave()
+	}
+	return nil
+}
+
+// settle accounts the last operation of a frame which continues executing
+// with the given amount of gas.
+func (t *ContractStatsTracer) settle(f *statsFrame, gas uint64) {
+	if !f.pending {
+		return
+	}
+	f.pending = false
+	if !f.target {
+		return
+	}
+	used := f.cost
+	switch f.op {
+	case CALL, CALLCODE, DELEGATECALL, STATICCALL, CREATE:
+		switch {
+		case f.childGas > 0:
+			// The callee ran code, its frame accounts for the gas it received
+			used = 0
+			if f.cost > f.childGas {
+				used = f.cost - f.childGas
+			}
+		case f.gas > gas:
+			// No code ran in the callee, everything not refunded was consumed
+			used = f.gas - gas
+		default:
+			used = 0
+		}
+	}
+	t.Gas += used
+}
+
+// leave accounts the last operation of the innermost frame and drops it.
+func (t *ContractStatsTracer) leave() {
+	f := &t.frames[len(t.frames)-1]
+	if f.pending && f.target {
+		t.Gas += f.cost
+	}
+	t.frames = t.frames[:len(t.frames)-1]
+}
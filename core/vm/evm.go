@@ -48,6 +48,9 @@ func run(evm *EVM, contract *Contract, input []byte) ([]byte, error) {
 			return RunPrecompiledContract(p, input, contract, evm)
 		}
 	}
+	if isWASM(evm, contract.Code) {
+		return runWASM(evm, contract, input)
+	}
 	return evm.interpreter.Run(contract, input)
 }
 
@@ -356,7 +359,11 @@ func (evm *EVM) Create(caller ContractRef, code []byte, gas uint64, value *big.I
 	}
 	start := time.Now()
 
-	ret, err = run(evm, contract, nil)
+	if isWASM(evm, code) {
+		ret, err = deployWASM(evm, contract)
+	} else {
+		ret, err = run(evm, contract, nil)
+	}
 
 	// check whether the max code size has been exceeded
 	maxCodeSizeExceeded := evm.ChainConfig().IsEIP158(evm.BlockNumber) && len(ret) > params.MaxCodeSize
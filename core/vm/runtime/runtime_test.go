@@ -17,6 +17,7 @@
 package runtime
 
 import (
+	"bytes"
 	"math/big"
 	"strings"
 	"testing"
@@ -26,6 +27,7 @@ import (
 	"github.com/matrix/go-matrix/core/state"
 	"github.com/matrix/go-matrix/core/vm"
 	"github.com/matrix/go-matrix/mandb"
+	"github.com/matrix/go-matrix/params"
 )
 
 func TestDefaults(t *testing.T) {
@@ -116,6 +118,108 @@ func TestCall(t *testing.T) {
 	}
 }
 
+// wasmStore is a WASM contract which stores its deployer in slot zero and
+// on every call stores the first word of the call data there, returning it.
+//
+//	(module
+//	  (import "env" "sstore" (func $sstore (param i32 i32)))
+//	  (import "env" "caller" (func $caller (param i32)))
+//	  (import "env" "calldatacopy" (func $calldatacopy (param i32 i32 i32)))
+//	  (import "env" "finish" (func $finish (param i32 i32)))
+//	  (memory 1)
+//	  (func (export "deploy")
+//	    (call $caller (i32.const 76))
+//	    (call $sstore (i32.const 128) (i32.const 64)))
+//	  (func (export "main")
+//	    (call $calldatacopy (i32.const 0) (i32.const 0) (i32.const 32))
+//	    (call $sstore (i32.const 128) (i32.const 0))
+//	    (call $finish (i32.const 0) (i32.const 32))))
+var wasmStore = common.Hex2Bytes("0061736d0100000001130460027f7f0060017f0060037f7f7f00600000023b0403656e76067373746f7265000003656e760663616c6c6572000103656e760c63616c6c64617461636f7079000203656e760666696e697368000003030203030503010001071102066465706c6f790004046d61696e00050a29020f0041cc00100141800141c00010000b17004100410041201002418001410010004100412010030b")
+
+func TestWASM(t *testing.T) {
+	state, _ := state.New(common.Hash{}, state.NewDatabase(mandb.NewMemDatabase()))
+	cfg := &Config{
+		ChainConfig: &params.ChainConfig{ChainId: big.NewInt(1), EIP158Block: new(big.Int), WASMBlock: new(big.Int)},
+		Origin:      common.HexToAddress("0x1234"),
+		State:       state,
+		GasLimit:    1000000,
+	}
+	code, address, _, err := Create(wasmStore, cfg)
+	if err != nil {
+		t.Fatal("failed to deploy contract:", err)
+	}
+	if !bytes.Equal(code, wasmStore) || !bytes.Equal(state.GetCode(address), wasmStore) {
+		t.Errorf("deployed code mismatch")
+	}
+	if owner := state.GetState(address, common.Hash{}); owner != cfg.Origin.Hash() {
+		t.Errorf("deploy function not run: slot zero %x", owner)
+	}
+
+	input := common.HexToHash("0xff").Bytes()
+	ret, _, err := Call(address, input, cfg)
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	if !bytes.Equal(ret, input) {
+		t.Errorf("return data mismatch: have %x, want %x", ret, input)
+	}
+	if val := state.GetState(address, common.Hash{}); val != common.BytesToHash(input) {
+		t.Errorf("storage mismatch: have %x, want %x", val, input)
+	}
+	// Storage writes must fail in a static context
+	vmenv := NewEnv(cfg)
+	if _, _, err := vmenv.StaticCall(vm.AccountRef(cfg.Origin), address, input, cfg.GasLimit); err == nil {
+		t.Errorf("storage write succeeded in static call")
+	}
+	// Without the runtime activated the module is treated as EVM init code
+	cfg.ChainConfig = &params.ChainConfig{ChainId: big.NewInt(1), EIP158Block: new(big.Int)}
+	if code, _, _, err := Create(wasmStore, cfg); err != nil || len(code) != 0 {
+		t.Errorf("module deployed before activation: code %x, err %v", code, err)
+	}
+}
+
+func TestContractStatsTracer(t *testing.T) {
+	var (
+		outer  = common.HexToAddress("0xc0ffee01")
+		inner  = common.HexToAddress("0xc0ffee02")
+		origin = common.HexToAddress("0x1234")
+	)
+	state, _ := state.New(common.Hash{}, state.NewDatabase(mandb.NewMemDatabase()))
+	// sstore(0, 1); sstore(1, 2); sstore(0, 3); call(0xffff, inner, 0, 0, 0, 0, 0)
+	state.SetCode(outer, append(append(common.Hex2Bytes("60016000556002600155600360005560006000600060006000"+"73"),
+		inner.Bytes()...), common.Hex2Bytes("61fffff15000")...))
+	// sstore(0, 1)
+	state.SetCode(inner, common.Hex2Bytes("600160005500"))
+
+	outerStats, innerStats := vm.NewContractStatsTracer(outer), vm.NewContractStatsTracer(inner)
+	for _, tracer := range []*vm.ContractStatsTracer{outerStats, innerStats} {
+		cfg := &Config{
+			Origin:    origin,
+			State:     state.Copy(),
+			GasLimit:  1000000,
+			EVMConfig: vm.Config{Debug: true, Tracer: tracer},
+		}
+		_, left, err := Call(outer, nil, cfg)
+		if err != nil {
+			t.Fatal("didn't expect error", err)
+		}
+		if tracer == innerStats {
+			if used := cfg.GasLimit - left; outerStats.Gas+innerStats.Gas != used {
+				t.Errorf("gas mismatch: outer %d + inner %d, want %d", outerStats.Gas, innerStats.Gas, used)
+			}
+		}
+	}
+	if outerStats.Calls != 1 || outerStats.StorageWrites != 3 || outerStats.SlotsWritten() != 2 {
+		t.Errorf("outer stats mismatch: calls %d, writes %d, slots %d", outerStats.Calls, outerStats.StorageWrites, outerStats.SlotsWritten())
+	}
+	if innerStats.Calls != 1 || innerStats.StorageWrites != 1 || innerStats.SlotsWritten() != 1 {
+		t.Errorf("inner stats mismatch: calls %d, writes %d, slots %d", innerStats.Calls, innerStats.StorageWrites, innerStats.SlotsWritten())
+	}
+	if innerStats.Gas != 20006 {
+		t.Errorf("inner gas mismatch: have %d, want %d", innerStats.Gas, 20006)
+	}
+}
+
 func BenchmarkCall(b *testing.B) {
 	var definition = `[{"constant":true,"inputs":[],"name":"seller","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"abort","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"value","outputs":[{"name":"","type":"uint256"}],"type":"function"},{"constant":false,"inputs":[],"name":"refund","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"buyer","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmReceived","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"state","outputs":[{"name":"","type":"uint8"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmPurchase","outputs":[],"type":"function"},{"inputs":[],"type":"constructor"},{"anonymous":false,"inputs":[],"name":"Aborted","type":"event"},{"anonymous":false,"inputs":[],"name":"PurchaseConfirmed","type":"event"},{"anonymous":false,"inputs":[],"name":"ItemReceived","type":"event"},{"anonymous":false,"inputs":[],"name":"Refunded","type":"event"}]`
 
@@ -0,0 +1,447 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package vm
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/hashicorp/golang-lru"
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/common/math"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/core/vm/wasm"
+	"github.com/matrix/go-matrix/crypto"
+	"github.com/matrix/go-matrix/params"
+)
+
+// Exports a WASM contract is entered through. The main function is invoked
+// for every call, while the optional deploy function runs once on creation
+// and plays the role of the EVM init code.
+const (
+	wasmMainExport   = "main"
+	wasmDeployExport = "deploy"
+)
+
+var (
+	errWASMFinish    = errors.New("wasm: finish")
+	errWASMNoMain    = errors.New("wasm: contract does not export a main function")
+	errWASMEntryType = errors.New("wasm: entry points must take no arguments and return no results")
+	errWASMTopics    = errors.New("wasm: too many log topics")
+)
+
+// wasmModules caches decoded and metered modules by code hash. Decoded modules
+// are immutable so they can be shared by concurrent executions.
+var wasmModules, _ = lru.New(256)
+
+// isWASM reports whether code has to be executed by the WASM runtime instead
+// of the EVM interpreter.
+//
+// Once the WASM activation block is reached, code starting with the WASM
+// magic number is no longer run by the EVM. As the magic starts with a STOP
+// opcode, no EVM contract could have done anything useful with it.
+func isWASM(evm *EVM, code []byte) bool {
+	return evm.chainRules.IsWASM && wasm.IsModule(code)
+}
+
+// loadWASMModule returns the decoded module of the given code, whose hash is
+// used as the cache key unless it is unknown.
+func loadWASMModule(hash common.Hash, code []byte) (*wasm.Module, error) {
+	if module, ok := wasmModules.Get(hash); ok {
+		return module.(*wasm.Module), nil
+	}
+	module, err := wasm.Decode(code)
+	if err != nil {
+		return nil, err
+	}
+	if typ, ok := module.Export(wasmMainExport); !ok {
+		return nil, errWASMNoMain
+	} else if len(typ.Params) != 0 || len(typ.Results) != 0 {
+		return nil, errWASMEntryType
+	}
+	if typ, ok := module.Export(wasmDeployExport); ok && (len(typ.Params) != 0 || len(typ.Results) != 0) {
+		return nil, errWASMEntryType
+	}
+	if hash != (common.Hash{}) {
+		wasmModules.Add(hash, module)
+	}
+	return module, nil
+}
+
+// runWASM executes the main function of a WASM contract.
+func runWASM(evm *EVM, contract *Contract, input []byte) ([]byte, error) {
+	return invokeWASM(evm, contract, input, wasmMainExport)
+}
+
+// deployWASM validates the module being created and runs its deploy function
+// if it has one. Unlike the EVM, the creation payload is the contract code
+// itself, which is returned for storage on success.
+func deployWASM(evm *EVM, contract *Contract) ([]byte, error) {
+	module, err := loadWASMModule(contract.CodeHash, contract.Code)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := module.Export(wasmDeployExport); ok {
+		if ret, err := invokeWASM(evm, contract, nil, wasmDeployExport); err != nil {
+			return ret, err
+		}
+	}
+	return contract.Code, nil
+}
+
+func invokeWASM(evm *EVM, contract *Contract, input []byte, entry string) ([]byte, error) {
+	// Increment the call depth which is restricted to 1024
+	evm.depth++
+	defer func() { evm.depth-- }()
+
+	module, err := loadWASMModule(contract.CodeHash, contract.Code)
+	if err != nil {
+		return nil, err
+	}
+	rt := &wasmRuntime{evm: evm, contract: contract, input: input}
+
+	instance, err := wasm.Instantiate(module, rt.hostFunctions(), contract.Gas)
+	if err != nil {
+		return nil, err
+	}
+	_, err = instance.Invoke(entry)
+	contract.Gas = instance.Gas
+
+	switch err {
+	case nil, errWASMFinish:
+		return rt.ret, nil
+	case errExecutionReverted:
+		return rt.ret, err
+	default:
+		return nil, err
+	}
+}
+
+// wasmRuntime implements the host functions a WASM contract imports from the
+// "env" namespace. They mirror the EVM opcodes of the same name and charge
+// the same gas, with 256 bit words and addresses passed through linear memory.
+type wasmRuntime struct {
+	evm        *EVM
+	contract   *Contract
+	input      []byte
+	ret        []byte // Data passed to finish or revert
+	returnData []byte // Return data of the last call
+}
+
+type hostFunc func(vm *wasm.VM, args []uint64) ([]uint64, error)
+
+func (rt *wasmRuntime) hostFunctions() map[string]wasm.HostFunction {
+	var (
+		i32 = wasm.I32
+		i64 = wasm.I64
+	)
+	fn := func(f hostFunc, params []wasm.ValueType, results ...wasm.ValueType) wasm.HostFunction {
+		return wasm.HostFunction{Type: wasm.FuncType{Params: params, Results: results}, Func: f}
+	}
+	return map[string]wasm.HostFunction{
+		"address":        fn(rt.address, []wasm.ValueType{i32}),
+		"caller":         fn(rt.caller, []wasm.ValueType{i32}),
+		"callvalue":      fn(rt.callValue, []wasm.ValueType{i32}),
+		"calldatasize":   fn(rt.callDataSize, nil, i32),
+		"calldatacopy":   fn(rt.callDataCopy, []wasm.ValueType{i32, i32, i32}),
+		"balance":        fn(rt.balance, []wasm.ValueType{i32, i32}),
+		"blocknumber":    fn(rt.blockNumber, nil, i64),
+		"timestamp":      fn(rt.timestamp, nil, i64),
+		"gasleft":        fn(rt.gasLeft, nil, i64),
+		"sload":          fn(rt.sload, []wasm.ValueType{i32, i32}),
+		"sstore":         fn(rt.sstore, []wasm.ValueType{i32, i32}),
+		"keccak256":      fn(rt.keccak256, []wasm.ValueType{i32, i32, i32}),
+		"log":            fn(rt.log, []wasm.ValueType{i32, i32, i32, i32, i32, i32, i32}),
+		"call":           fn(rt.call, []wasm.ValueType{i64, i32, i32, i32, i32}, i32),
+		"returndatasize": fn(rt.returnDataSize, nil, i32),
+		"returndatacopy": fn(rt.returnDataCopy, []wasm.ValueType{i32, i32, i32}),
+		"finish":         fn(rt.finish, []wasm.ValueType{i32, i32}),
+		"revert":         fn(rt.revert, []wasm.ValueType{i32, i32}),
+	}
+}
+
+// useGas charges the given amount, aborting the execution if it is not available.
+func useGas(vm *wasm.VM, gas uint64) error {
+	if !vm.UseGas(gas) {
+		return ErrOutOfGas
+	}
+	return nil
+}
+
+// copyGas returns the cost of copying size bytes on top of the base cost.
+func copyGas(base uint64, size uint64) uint64 {
+	return base + toWordSize(size)*params.CopyGas
+}
+
+func (rt *wasmRuntime) address(vm *wasm.VM, args []uint64) ([]uint64, error) {
+	if err := useGas(vm, GasQuickStep); err != nil {
+		return nil, err
+	}
+	return nil, vm.WriteMemory(uint32(args[0]), rt.contract.Address().Bytes())
+}
+
+func (rt *wasmRuntime) caller(vm *wasm.VM, args []uint64) ([]uint64, error) {
+	if err := useGas(vm, GasQuickStep); err != nil {
+		return nil, err
+	}
+	return nil, vm.WriteMemory(uint32(args[0]), rt.contract.Caller().Bytes())
+}
+
+func (rt *wasmRuntime) callValue(vm *wasm.VM, args []uint64) ([]uint64, error) {
+	if err := useGas(vm, GasQuickStep); err != nil {
+		return nil, err
+	}
+	return nil, vm.WriteMemory(uint32(args[0]), math.PaddedBigBytes(rt.contract.Value(), 32))
+}
+
+func (rt *wasmRuntime) callDataSize(vm *wasm.VM, args []uint64) ([]uint64, error) {
+	if err := useGas(vm, GasQuickStep); err != nil {
+		return nil, err
+	}
+	return []uint64{uint64(len(rt.input))}, nil
+}
+
+func (rt *wasmRuntime) callDataCopy(vm *wasm.VM, args []uint64) ([]uint64, error) {
+	if err := useGas(vm, copyGas(GasFastestStep, args[2])); err != nil {
+		return nil, err
+	}
+	return nil, vm.WriteMemory(uint32(args[0]), getData(rt.input, args[1], args[2]))
+}
+
+func (rt *wasmRuntime) balance(vm *wasm.VM, args []uint64) ([]uint64, error) {
+	if err := useGas(vm, rt.evm.ChainConfig().GasTable(rt.evm.BlockNumber).Balance); err != nil {
+		return nil, err
+	}
+	addr, err := vm.ReadMemory(uint32(args[0]), common.AddressLength)
+	if err != nil {
+		return nil, err
+	}
+	balance := rt.evm.StateDB.GetBalance(common.BytesToAddress(addr))
+	return nil, vm.WriteMemory(uint32(args[1]), math.PaddedBigBytes(balance, 32))
+}
+
+func (rt *wasmRuntime) blockNumber(vm *wasm.VM, args []uint64) ([]uint64, error) {
+	if err := useGas(vm, GasQuickStep); err != nil {
+		return nil, err
+	}
+	return []uint64{rt.evm.BlockNumber.Uint64()}, nil
+}
+
+func (rt *wasmRuntime) timestamp(vm *wasm.VM, args []uint64) ([]uint64, error) {
+	if err := useGas(vm, GasQuickStep); err != nil {
+		return nil, err
+	}
+	return []uint64{rt.evm.Time.Uint64()}, nil
+}
+
+func (rt *wasmRuntime) gasLeft(vm *wasm.VM, args []uint64) ([]uint64, error) {
+	if err := useGas(vm, GasQuickStep); err != nil {
+		return nil, err
+	}
+	return []uint64{vm.Gas}, nil
+}
+
+func (rt *wasmRuntime) sload(vm *wasm.VM, args []uint64) ([]uint64, error) {
+	if err := useGas(vm, rt.evm.ChainConfig().GasTable(rt.evm.BlockNumber).SLoad); err != nil {
+		return nil, err
+	}
+	key, err := vm.ReadMemory(uint32(args[0]), common.HashLength)
+	if err != nil {
+		return nil, err
+	}
+	val := rt.evm.StateDB.GetState(rt.contract.Address(), common.BytesToHash(key))
+	return nil, vm.WriteMemory(uint32(args[1]), val.Bytes())
+}
+
+func (rt *wasmRuntime) sstore(vm *wasm.VM, args []uint64) ([]uint64, error) {
+	if rt.evm.interpreter.readOnly {
+		return nil, errWriteProtection
+	}
+	key, err := vm.ReadMemory(uint32(args[0]), common.HashLength)
+	if err != nil {
+		return nil, err
+	}
+	val, err := vm.ReadMemory(uint32(args[1]), common.HashLength)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		loc     = common.BytesToHash(key)
+		value   = common.BytesToHash(val)
+		current = rt.evm.StateDB.GetState(rt.contract.Address(), loc)
+		gas     = params.SstoreResetGas
+	)
+	// Same pricing as the SSTORE opcode, see gasSStore
+	if common.EmptyHash(current) && !common.EmptyHash(value) {
+		gas = params.SstoreSetGas
+	} else if !common.EmptyHash(current) && common.EmptyHash(value) {
+		gas = params.SstoreClearGas
+	}
+	if err := useGas(vm, gas); err != nil {
+		return nil, err
+	}
+	if gas == params.SstoreClearGas {
+		rt.evm.StateDB.AddRefund(params.SstoreRefundGas)
+	}
+	rt.evm.StateDB.SetState(rt.contract.Address(), loc, value)
+	return nil, nil
+}
+
+func (rt *wasmRuntime) keccak256(vm *wasm.VM, args []uint64) ([]uint64, error) {
+	if err := useGas(vm, params.Sha3Gas+toWordSize(args[1])*params.Sha3WordGas); err != nil {
+		return nil, err
+	}
+	data, err := vm.ReadMemory(uint32(args[0]), uint32(args[1]))
+	if err != nil {
+		return nil, err
+	}
+	return nil, vm.WriteMemory(uint32(args[2]), crypto.Keccak256(data))
+}
+
+// log emits a log with up to four topics, each read from the memory location
+// given by the trailing arguments.
+func (rt *wasmRuntime) log(vm *wasm.VM, args []uint64) ([]uint64, error) {
+	if rt.evm.interpreter.readOnly {
+		return nil, errWriteProtection
+	}
+	n := args[2]
+	if n > 4 {
+		return nil, errWASMTopics
+	}
+	if err := useGas(vm, params.LogGas+n*params.LogTopicGas+args[1]*params.LogDataGas); err != nil {
+		return nil, err
+	}
+	data, err := vm.ReadMemory(uint32(args[0]), uint32(args[1]))
+	if err != nil {
+		return nil, err
+	}
+	topics := make([]common.Hash, n)
+	for i := range topics {
+		topic, err := vm.ReadMemory(uint32(args[3+i]), common.HashLength)
+		if err != nil {
+			return nil, err
+		}
+		topics[i] = common.BytesToHash(topic)
+	}
+	rt.evm.StateDB.AddLog(&types.Log{
+		Address: rt.contract.Address(),
+		Topics:  topics,
+		Data:    data,
+		// This is a non-consensus field, but assigned here because
+		// core/state doesn't know the current block number.
+		BlockNumber: rt.evm.BlockNumber.Uint64(),
+	})
+	return nil, nil
+}
+
+// call invokes another contract, returning 0 on success, 1 on failure and 2
+// if the callee reverted.
+func (rt *wasmRuntime) call(vm *wasm.VM, args []uint64) ([]uint64, error) {
+	addr, err := vm.ReadMemory(uint32(args[1]), common.AddressLength)
+	if err != nil {
+		return nil, err
+	}
+	val, err := vm.ReadMemory(uint32(args[2]), 32)
+	if err != nil {
+		return nil, err
+	}
+	input, err := vm.ReadMemory(uint32(args[3]), uint32(args[4]))
+	if err != nil {
+		return nil, err
+	}
+	var (
+		evm   = rt.evm
+		to    = common.BytesToAddress(addr)
+		value = new(big.Int).SetBytes(val)
+		gt    = evm.ChainConfig().GasTable(evm.BlockNumber)
+		cost  = gt.Calls
+	)
+	if value.Sign() != 0 && evm.interpreter.readOnly {
+		return nil, errWriteProtection
+	}
+	// Same pricing as the CALL opcode, see gasCall
+	if evm.ChainConfig().IsEIP158(evm.BlockNumber) {
+		if value.Sign() != 0 && evm.StateDB.Empty(to) {
+			cost += params.CallNewAccountGas
+		}
+	} else if !evm.StateDB.Exist(to) {
+		cost += params.CallNewAccountGas
+	}
+	if value.Sign() != 0 {
+		cost += params.CallValueTransferGas
+	}
+	if err := useGas(vm, cost); err != nil {
+		return nil, err
+	}
+	gas, err := callGas(gt, vm.Gas, 0, new(big.Int).SetUint64(args[0]))
+	if err != nil {
+		return nil, err
+	}
+	if err := useGas(vm, gas); err != nil {
+		return nil, err
+	}
+	if value.Sign() != 0 {
+		gas += params.CallStipend
+	}
+	ret, returnGas, err := evm.Call(rt.contract, to, input, gas, value)
+	vm.Gas += returnGas
+	rt.returnData = ret
+
+	switch err {
+	case nil:
+		return []uint64{0}, nil
+	case errExecutionReverted:
+		return []uint64{2}, nil
+	default:
+		return []uint64{1}, nil
+	}
+}
+
+func (rt *wasmRuntime) returnDataSize(vm *wasm.VM, args []uint64) ([]uint64, error) {
+	if err := useGas(vm, GasQuickStep); err != nil {
+		return nil, err
+	}
+	return []uint64{uint64(len(rt.returnData))}, nil
+}
+
+func (rt *wasmRuntime) returnDataCopy(vm *wasm.VM, args []uint64) ([]uint64, error) {
+	if err := useGas(vm, copyGas(GasFastestStep, args[2])); err != nil {
+		return nil, err
+	}
+	if args[1]+args[2] > uint64(len(rt.returnData)) {
+		return nil, errReturnDataOutOfBounds
+	}
+	return nil, vm.WriteMemory(uint32(args[0]), rt.returnData[args[1]:args[1]+args[2]])
+}
+
+func (rt *wasmRuntime) finish(vm *wasm.VM, args []uint64) ([]uint64, error) {
+	data, err := vm.ReadMemory(uint32(args[0]), uint32(args[1]))
+	if err != nil {
+		return nil, err
+	}
+	rt.ret = data
+	return nil, errWASMFinish
+}
+
+func (rt *wasmRuntime) revert(vm *wasm.VM, args []uint64) ([]uint64, error) {
+	data, err := vm.ReadMemory(uint32(args[0]), uint32(args[1]))
+	if err != nil {
+		return nil, err
+	}
+	rt.ret = data
+	return nil, errExecutionReverted
+}
@@ -0,0 +1,679 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wasm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// Traps aborting the execution of a module.
+var (
+	ErrOutOfGas             = errors.New("wasm: out of gas")
+	ErrUnreachable          = errors.New("wasm: unreachable executed")
+	ErrMemoryOutOfBounds    = errors.New("wasm: out of bounds memory access")
+	ErrDivideByZero         = errors.New("wasm: integer divide by zero")
+	ErrIntegerOverflow      = errors.New("wasm: integer overflow")
+	ErrStackOverflow        = errors.New("wasm: value stack exhausted")
+	ErrStackUnderflow       = errors.New("wasm: value stack underflow")
+	ErrCallStackExhausted   = errors.New("wasm: call stack exhausted")
+	ErrUndefinedElement     = errors.New("wasm: undefined table element")
+	ErrIndirectCallMismatch = errors.New("wasm: indirect call signature mismatch")
+)
+
+// noElement marks an uninitialised table slot.
+const noElement = math.MaxUint32
+
+// HostFunction is a function provided by the embedder which modules can
+// import from the "env" namespace.
+type HostFunction struct {
+	Type FuncType
+	Func func(vm *VM, args []uint64) ([]uint64, error)
+}
+
+// trap is used to unwind the interpreter on a runtime error.
+type trap struct{ err error }
+
+// label is the runtime state of an entered block, loop or if.
+type label struct {
+	cont   int // Position to continue at when branching to the label
+	height int // Value stack height on entry
+	arity  int // Number of values carried over when branching to the label
+}
+
+// VM is an instance of a module with its own linear memory and globals.
+// A VM is not safe for concurrent use.
+type VM struct {
+	Gas uint64 // Gas still available for execution
+
+	module  *Module
+	host    []HostFunction
+	memory  []byte
+	maxMem  uint32
+	globals []uint64
+	table   []uint32
+	stack   []uint64
+	floor   int // Stack height below which the running function may not pop
+	depth   int
+}
+
+// Instantiate creates an instance of the module, resolving its imports from
+// env and charging the initial linear memory against the given gas.
+func Instantiate(m *Module, env map[string]HostFunction, gas uint64) (*VM, error) {
+	vm := &VM{Gas: gas, module: m}
+	for _, imp := range m.imports {
+		fn, ok := env[imp.name]
+		if imp.module != "env" || !ok {
+			return nil, fmt.Errorf("wasm: unknown import %s.%s", imp.module, imp.name)
+		}
+		if !fn.Type.Equal(m.types[imp.typ]) {
+			return nil, fmt.Errorf("wasm: import %s.%s: signature mismatch", imp.module, imp.name)
+		}
+		vm.host = append(vm.host, fn)
+	}
+	if m.memory != nil {
+		if !vm.UseGas(uint64(m.memory.min) * GasMemoryPage) {
+			return nil, ErrOutOfGas
+		}
+		vm.memory = make([]byte, int(m.memory.min)*PageSize)
+		vm.maxMem = MaxPages
+		if m.memory.hasMax && m.memory.max < MaxPages {
+			vm.maxMem = m.memory.max
+		}
+	}
+	for _, seg := range m.data {
+		if uint64(seg.offset)+uint64(len(seg.data)) > uint64(len(vm.memory)) {
+			return nil, errors.New("wasm: data segment does not fit in memory")
+		}
+		copy(vm.memory[seg.offset:], seg.data)
+	}
+	vm.globals = make([]uint64, len(m.globals))
+	for i, g := range m.globals {
+		vm.globals[i] = g.init
+	}
+	if m.table != nil {
+		vm.table = make([]uint32, m.table.min)
+		for i := range vm.table {
+			vm.table[i] = noElement
+		}
+	}
+	for _, seg := range m.elements {
+		if uint64(seg.offset)+uint64(len(seg.funcs)) > uint64(len(vm.table)) {
+			return nil, errors.New("wasm: element segment does not fit in table")
+		}
+		copy(vm.table[seg.offset:], seg.funcs)
+	}
+	return vm, nil
+}
+
+// Invoke calls the exported function called name with the given arguments.
+// Traps as well as errors returned by host functions abort the execution and
+// are returned unchanged.
+func (vm *VM) Invoke(name string, args ...uint64) (results []uint64, err error) {
+	idx, ok := vm.module.exports[name]
+	if !ok {
+		return nil, fmt.Errorf("wasm: unknown export %q", name)
+	}
+	typ := vm.module.funcType(idx)
+	if len(args) != len(typ.Params) {
+		return nil, fmt.Errorf("wasm: %s expects %d arguments, have %d", name, len(typ.Params), len(args))
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			t, ok := r.(trap)
+			if !ok {
+				panic(r)
+			}
+			vm.stack, vm.floor, vm.depth = vm.stack[:0], 0, 0
+			results, err = nil, t.err
+		}
+	}()
+	for i, arg := range args {
+		vm.push(normalize(typ.Params[i], arg))
+	}
+	vm.call(idx)
+
+	results = make([]uint64, len(typ.Results))
+	copy(results, vm.stack[len(vm.stack)-len(results):])
+	vm.stack = vm.stack[:0]
+	return results, nil
+}
+
+// UseGas attempts to consume gas and reports whether enough was available.
+func (vm *VM) UseGas(gas uint64) bool {
+	if vm.Gas < gas {
+		return false
+	}
+	vm.Gas -= gas
+	return true
+}
+
+// ReadMemory returns a copy of size bytes of linear memory at offset.
+func (vm *VM) ReadMemory(offset, size uint32) ([]byte, error) {
+	if uint64(offset)+uint64(size) > uint64(len(vm.memory)) {
+		return nil, ErrMemoryOutOfBounds
+	}
+	return append([]byte{}, vm.memory[offset:offset+size]...), nil
+}
+
+// WriteMemory copies data into linear memory at offset.
+func (vm *VM) WriteMemory(offset uint32, data []byte) error {
+	if uint64(offset)+uint64(len(data)) > uint64(len(vm.memory)) {
+		return ErrMemoryOutOfBounds
+	}
+	copy(vm.memory[offset:], data)
+	return nil
+}
+
+// normalize clears the upper half of 32 bit values, which is the canonical
+// representation of an i32 on the value stack.
+func normalize(typ ValueType, v uint64) uint64 {
+	if typ == I32 {
+		return uint64(uint32(v))
+	}
+	return v
+}
+
+func (vm *VM) push(v uint64) {
+	if len(vm.stack) >= MaxStackHeight {
+		panic(trap{ErrStackOverflow})
+	}
+	vm.stack = append(vm.stack, v)
+}
+
+func (vm *VM) pop() uint64 {
+	if len(vm.stack) <= vm.floor {
+		panic(trap{ErrStackUnderflow})
+	}
+	v := vm.stack[len(vm.stack)-1]
+	vm.stack = vm.stack[:len(vm.stack)-1]
+	return v
+}
+
+// call invokes the function at idx of the function index space with its
+// arguments on top of the value stack, replacing them with its results.
+func (vm *VM) call(idx uint32) {
+	typ := vm.module.funcType(idx)
+	if len(vm.stack)-vm.floor < len(typ.Params) {
+		panic(trap{ErrStackUnderflow})
+	}
+	base := len(vm.stack) - len(typ.Params)
+
+	if idx < uint32(len(vm.host)) {
+		args := append([]uint64{}, vm.stack[base:]...)
+		vm.stack = vm.stack[:base]
+
+		results, err := vm.host[idx].Func(vm, args)
+		if err != nil {
+			panic(trap{err})
+		}
+		if len(results) != len(typ.Results) {
+			panic(trap{fmt.Errorf("wasm: host function returned %d results, want %d", len(results), len(typ.Results))})
+		}
+		for i, v := range results {
+			vm.push(normalize(typ.Results[i], v))
+		}
+		return
+	}
+	if vm.depth >= MaxCallDepth {
+		panic(trap{ErrCallStackExhausted})
+	}
+	vm.depth++
+	defer func() { vm.depth-- }()
+
+	fn := &vm.module.functions[idx-uint32(len(vm.host))]
+	if !vm.UseGas(uint64(fn.locals) * GasLocal) {
+		panic(trap{ErrOutOfGas})
+	}
+	for i := 0; i < fn.locals; i++ {
+		vm.push(0)
+	}
+	vm.execute(fn, base, len(typ.Results))
+}
+
+// execute runs the body of fn, whose locals start at base of the value stack.
+// Locals are addressed through the stack itself as pushing may move it.
+func (vm *VM) execute(fn *function, base int, results int) {
+	var (
+		code   = fn.code
+		labels = []label{{cont: len(code), height: len(vm.stack), arity: results}}
+		floor  = vm.floor
+	)
+	vm.floor = len(vm.stack)
+
+	// branch unwinds to the label at the given depth, keeping its arity
+	// values on top of the stack, and returns the position to continue at.
+	branch := func(depth int) int {
+		l := labels[len(labels)-1-depth]
+		if len(vm.stack) < l.height+l.arity {
+			panic(trap{ErrStackUnderflow})
+		}
+		copy(vm.stack[l.height:], vm.stack[len(vm.stack)-l.arity:])
+		vm.stack = vm.stack[:l.height+l.arity]
+		labels = labels[:len(labels)-1-depth]
+		return l.cont
+	}
+	for pc := 0; pc < len(code); {
+		ins := &code[pc]
+		switch op := ins.op; {
+		case op == opMeter:
+			if !vm.UseGas(ins.imm) {
+				panic(trap{ErrOutOfGas})
+			}
+		case op == opUnreachable:
+			panic(trap{ErrUnreachable})
+		case op == opNop:
+
+		case op == opBlock:
+			labels = append(labels, label{cont: int(ins.end) + 1, height: len(vm.stack), arity: int(ins.arity)})
+		case op == opLoop:
+			labels = append(labels, label{cont: pc, height: len(vm.stack)})
+		case op == opIf:
+			cond := vm.pop()
+			labels = append(labels, label{cont: int(ins.end) + 1, height: len(vm.stack), arity: int(ins.arity)})
+			if uint32(cond) == 0 {
+				if ins.els != ins.end {
+					pc = int(ins.els) + 1 // continue with the else branch
+				} else {
+					pc = int(ins.end) // let end pop the label
+				}
+				continue
+			}
+		case op == opElse:
+			// reached the end of the taken then branch
+			pc = branch(0)
+			continue
+		case op == opEnd:
+			branch(0)
+		case op == opBr:
+			pc = branch(int(ins.imm))
+			continue
+		case op == opBrIf:
+			if uint32(vm.pop()) != 0 {
+				pc = branch(int(ins.imm))
+				continue
+			}
+		case op == opBrTable:
+			i := uint32(vm.pop())
+			if i >= uint32(len(ins.table)-1) {
+				i = uint32(len(ins.table) - 1)
+			}
+			pc = branch(int(ins.table[i]))
+			continue
+		case op == opReturn:
+			pc = branch(len(labels) - 1)
+			continue
+
+		case op == opCall:
+			vm.call(uint32(ins.imm))
+		case op == opCallIndirect:
+			i := uint32(vm.pop())
+			if i >= uint32(len(vm.table)) || vm.table[i] == noElement {
+				panic(trap{ErrUndefinedElement})
+			}
+			if !vm.module.funcType(vm.table[i]).Equal(vm.module.types[ins.imm]) {
+				panic(trap{ErrIndirectCallMismatch})
+			}
+			vm.call(vm.table[i])
+
+		case op == opDrop:
+			vm.pop()
+		case op == opSelect:
+			cond, b, a := uint32(vm.pop()), vm.pop(), vm.pop()
+			if cond != 0 {
+				vm.push(a)
+			} else {
+				vm.push(b)
+			}
+
+		case op == opLocalGet:
+			vm.push(vm.stack[base+int(ins.imm)])
+		case op == opLocalSet:
+			v := vm.pop()
+			vm.stack[base+int(ins.imm)] = v
+		case op == opLocalTee:
+			v := vm.pop()
+			vm.stack[base+int(ins.imm)] = v
+			vm.push(v)
+		case op == opGlobalGet:
+			vm.push(vm.globals[ins.imm])
+		case op == opGlobalSet:
+			vm.globals[ins.imm] = normalize(vm.module.globals[ins.imm].typ, vm.pop())
+
+		case op >= opI32Load && op <= opI64Load32U:
+			vm.load(op, ins.imm)
+		case op >= opI32Store && op <= opI64Store32:
+			vm.store(op, ins.imm)
+		case op == opMemorySize:
+			vm.push(uint64(len(vm.memory) / PageSize))
+		case op == opMemoryGrow:
+			vm.push(vm.grow(uint32(vm.pop())))
+
+		case op == opI32Const || op == opI64Const:
+			vm.push(ins.imm)
+
+		default:
+			vm.numeric(op)
+		}
+		pc++
+	}
+	// Move the results down to where the arguments of the call started
+	if len(vm.stack) < vm.floor+results {
+		panic(trap{ErrStackUnderflow})
+	}
+	vm.floor = floor
+	copy(vm.stack[base:], vm.stack[len(vm.stack)-results:])
+	vm.stack = vm.stack[:base+results]
+}
+
+// address computes the effective address of a memory access of the given
+// size, trapping if it is out of bounds.
+func (vm *VM) address(offset uint64, size uint64) uint64 {
+	addr := uint64(uint32(vm.pop())) + offset
+	if addr+size > uint64(len(vm.memory)) {
+		panic(trap{ErrMemoryOutOfBounds})
+	}
+	return addr
+}
+
+func (vm *VM) load(op byte, offset uint64) {
+	var size uint64
+	switch op {
+	case opI32Load8S, opI32Load8U, opI64Load8S, opI64Load8U:
+		size = 1
+	case opI32Load16S, opI32Load16U, opI64Load16S, opI64Load16U:
+		size = 2
+	case opI32Load, opI64Load32S, opI64Load32U:
+		size = 4
+	default:
+		size = 8
+	}
+	addr := vm.address(offset, size)
+
+	var v uint64
+	for i := size; i > 0; i-- {
+		v = v<<8 | uint64(vm.memory[addr+i-1])
+	}
+	switch op {
+	case opI32Load8S:
+		v = uint64(uint32(int8(v)))
+	case opI32Load16S:
+		v = uint64(uint32(int16(v)))
+	case opI64Load8S:
+		v = uint64(int8(v))
+	case opI64Load16S:
+		v = uint64(int16(v))
+	case opI64Load32S:
+		v = uint64(int32(v))
+	}
+	vm.push(v)
+}
+
+func (vm *VM) store(op byte, offset uint64) {
+	var size uint64
+	switch op {
+	case opI32Store8, opI64Store8:
+		size = 1
+	case opI32Store16, opI64Store16:
+		size = 2
+	case opI32Store, opI64Store32:
+		size = 4
+	default:
+		size = 8
+	}
+	v := vm.pop()
+	addr := vm.address(offset, size)
+	for i := uint64(0); i < size; i++ {
+		vm.memory[addr+i] = byte(v >> (8 * i))
+	}
+}
+
+// grow extends the linear memory by delta pages, returning the previous size
+// in pages or -1 if the memory cannot grow that far.
+func (vm *VM) grow(delta uint32) uint64 {
+	pages := uint32(len(vm.memory) / PageSize)
+	if uint64(pages)+uint64(delta) > uint64(vm.maxMem) {
+		return uint64(math.MaxUint32)
+	}
+	if !vm.UseGas(uint64(delta) * GasMemoryPage) {
+		panic(trap{ErrOutOfGas})
+	}
+	vm.memory = append(vm.memory, make([]byte, int(delta)*PageSize)...)
+	return uint64(pages)
+}
+
+func b2u(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// numeric executes the comparison, arithmetic and conversion instructions.
+func (vm *VM) numeric(op byte) {
+	switch {
+	case op == opI32Eqz:
+		vm.push(b2u(uint32(vm.pop()) == 0))
+	case op == opI64Eqz:
+		vm.push(b2u(vm.pop() == 0))
+	case op >= opI32Eq && op <= opI32GeU:
+		b, a := uint32(vm.pop()), uint32(vm.pop())
+		vm.push(b2u(compare32(op, a, b)))
+	case op >= opI64Eq && op <= opI64GeU:
+		b, a := vm.pop(), vm.pop()
+		vm.push(b2u(compare64(op, a, b)))
+
+	case op == opI32Clz:
+		vm.push(uint64(bits.LeadingZeros32(uint32(vm.pop()))))
+	case op == opI32Ctz:
+		vm.push(uint64(bits.TrailingZeros32(uint32(vm.pop()))))
+	case op == opI32Popcnt:
+		vm.push(uint64(bits.OnesCount32(uint32(vm.pop()))))
+	case op >= opI32Add && op <= opI32Rotr:
+		b, a := uint32(vm.pop()), uint32(vm.pop())
+		vm.push(uint64(binary32(op, a, b)))
+
+	case op == opI64Clz:
+		vm.push(uint64(bits.LeadingZeros64(vm.pop())))
+	case op == opI64Ctz:
+		vm.push(uint64(bits.TrailingZeros64(vm.pop())))
+	case op == opI64Popcnt:
+		vm.push(uint64(bits.OnesCount64(vm.pop())))
+	case op >= opI64Add && op <= opI64Rotr:
+		b, a := vm.pop(), vm.pop()
+		vm.push(binary64(op, a, b))
+
+	case op == opI32WrapI64:
+		vm.push(uint64(uint32(vm.pop())))
+	case op == opI64ExtendI32S:
+		vm.push(uint64(int32(vm.pop())))
+	case op == opI64ExtendI32U:
+		vm.push(uint64(uint32(vm.pop())))
+	case op == opI32Extend8S:
+		vm.push(uint64(uint32(int8(vm.pop()))))
+	case op == opI32Extend16S:
+		vm.push(uint64(uint32(int16(vm.pop()))))
+	case op == opI64Extend8S:
+		vm.push(uint64(int8(vm.pop())))
+	case op == opI64Extend16S:
+		vm.push(uint64(int16(vm.pop())))
+	case op == opI64Extend32S:
+		vm.push(uint64(int32(vm.pop())))
+
+	default:
+		// The decoder only lets supported opcodes through
+		panic(fmt.Sprintf("wasm: unhandled opcode 0x%x", op))
+	}
+}
+
+func compare32(op byte, a, b uint32) bool {
+	switch op {
+	case opI32Eq:
+		return a == b
+	case opI32Ne:
+		return a != b
+	case opI32LtS:
+		return int32(a) < int32(b)
+	case opI32LtU:
+		return a < b
+	case opI32GtS:
+		return int32(a) > int32(b)
+	case opI32GtU:
+		return a > b
+	case opI32LeS:
+		return int32(a) <= int32(b)
+	case opI32LeU:
+		return a <= b
+	case opI32GeS:
+		return int32(a) >= int32(b)
+	default:
+		return a >= b
+	}
+}
+
+func compare64(op byte, a, b uint64) bool {
+	switch op {
+	case opI64Eq:
+		return a == b
+	case opI64Ne:
+		return a != b
+	case opI64LtS:
+		return int64(a) < int64(b)
+	case opI64LtU:
+		return a < b
+	case opI64GtS:
+		return int64(a) > int64(b)
+	case opI64GtU:
+		return a > b
+	case opI64LeS:
+		return int64(a) <= int64(b)
+	case opI64LeU:
+		return a <= b
+	case opI64GeS:
+		return int64(a) >= int64(b)
+	default:
+		return a >= b
+	}
+}
+
+func binary32(op byte, a, b uint32) uint32 {
+	switch op {
+	case opI32Add:
+		return a + b
+	case opI32Sub:
+		return a - b
+	case opI32Mul:
+		return a * b
+	case opI32DivS:
+		if b == 0 {
+			panic(trap{ErrDivideByZero})
+		}
+		if int32(a) == math.MinInt32 && int32(b) == -1 {
+			panic(trap{ErrIntegerOverflow})
+		}
+		return uint32(int32(a) / int32(b))
+	case opI32DivU:
+		if b == 0 {
+			panic(trap{ErrDivideByZero})
+		}
+		return a / b
+	case opI32RemS:
+		if b == 0 {
+			panic(trap{ErrDivideByZero})
+		}
+		if int32(b) == -1 {
+			return 0
+		}
+		return uint32(int32(a) % int32(b))
+	case opI32RemU:
+		if b == 0 {
+			panic(trap{ErrDivideByZero})
+		}
+		return a % b
+	case opI32And:
+		return a & b
+	case opI32Or:
+		return a | b
+	case opI32Xor:
+		return a ^ b
+	case opI32Shl:
+		return a << (b % 32)
+	case opI32ShrS:
+		return uint32(int32(a) >> (b % 32))
+	case opI32ShrU:
+		return a >> (b % 32)
+	case opI32Rotl:
+		return bits.RotateLeft32(a, int(b%32))
+	default:
+		return bits.RotateLeft32(a, -int(b%32))
+	}
+}
+
+func binary64(op byte, a, b uint64) uint64 {
+	switch op {
+	case opI64Add:
+		return a + b
+	case opI64Sub:
+		return a - b
+	case opI64Mul:
+		return a * b
+	case opI64DivS:
+		if b == 0 {
+			panic(trap{ErrDivideByZero})
+		}
+		if int64(a) == math.MinInt64 && int64(b) == -1 {
+			panic(trap{ErrIntegerOverflow})
+		}
+		return uint64(int64(a) / int64(b))
+	case opI64DivU:
+		if b == 0 {
+			panic(trap{ErrDivideByZero})
+		}
+		return a / b
+	case opI64RemS:
+		if b == 0 {
+			panic(trap{ErrDivideByZero})
+		}
+		if int64(b) == -1 {
+			return 0
+		}
+		return uint64(int64(a) % int64(b))
+	case opI64RemU:
+		if b == 0 {
+			panic(trap{ErrDivideByZero})
+		}
+		return a % b
+	case opI64And:
+		return a & b
+	case opI64Or:
+		return a | b
+	case opI64Xor:
+		return a ^ b
+	case opI64Shl:
+		return a << (b % 64)
+	case opI64ShrS:
+		return uint64(int64(a) >> (b % 64))
+	case opI64ShrU:
+		return a >> (b % 64)
+	case opI64Rotl:
+		return bits.RotateLeft64(a, int(b%64))
+	default:
+		return bits.RotateLeft64(a, -int(b%64))
+	}
+}
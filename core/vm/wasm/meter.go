@@ -0,0 +1,79 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wasm
+
+// Gas costs charged for executing WASM code, denominated in EVM gas.
+const (
+	GasInstruction  uint64 = 1    // Cost of a plain stack, local or arithmetic instruction
+	GasMemoryAccess uint64 = 3    // Cost of a linear memory load or store
+	GasDivision     uint64 = 5    // Cost of an integer division or remainder
+	GasCall         uint64 = 10   // Cost of a direct or indirect function call
+	GasLocal        uint64 = 1    // Cost of every local allocated on function entry
+	GasMemoryPage   uint64 = 6144 // Cost of a linear memory page, priced as 2048 EVM memory words
+)
+
+// instrCost returns the static cost of an instruction.
+func instrCost(op byte) uint64 {
+	switch {
+	case op == opNop || op == opBlock || op == opLoop || op == opElse || op == opEnd:
+		return 0
+	case op == opCall || op == opCallIndirect:
+		return GasCall
+	case op >= opI32Load && op <= opI64Store32:
+		return GasMemoryAccess
+	case op == opI32DivS || op == opI32DivU || op == opI32RemS || op == opI32RemU,
+		op == opI64DivS || op == opI64DivU || op == opI64RemS || op == opI64RemU:
+		return GasDivision
+	default:
+		return GasInstruction
+	}
+}
+
+// injectMetering splits the code into straight-line segments and prefixes
+// each of them with a meter instruction charging the summed static cost of
+// the whole segment. A segment ends at every instruction which may transfer
+// control, so a branch target always starts with a fresh charge and each
+// loop iteration pays for its body again.
+//
+// Costs that depend on runtime values, such as memory growth, function locals
+// and host functions, are charged when they are incurred instead.
+func injectMetering(code []instr) []instr {
+	metered := make([]instr, 0, len(code)+len(code)/4+1)
+
+	meter := -1
+	for _, ins := range code {
+		if meter < 0 {
+			metered = append(metered, instr{op: opMeter})
+			meter = len(metered) - 1
+		}
+		metered[meter].imm += instrCost(ins.op)
+		metered = append(metered, ins)
+
+		switch ins.op {
+		case opBlock, opLoop, opIf, opElse, opEnd, opBr, opBrIf, opBrTable, opReturn, opUnreachable:
+			meter = -1
+		}
+	}
+	// Drop the meters of segments consisting of free instructions only
+	out := metered[:0]
+	for _, ins := range metered {
+		if ins.op != opMeter || ins.imm != 0 {
+			out = append(out, ins)
+		}
+	}
+	return out
+}
@@ -0,0 +1,682 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package wasm implements a small, deterministic WebAssembly interpreter for
+// the experimental WASM contract runtime.
+//
+// Modules are read with the binary decoder of core/wasm. Only the integer
+// subset of the MVP instruction set is supported. Floating point instructions,
+// imported memories, tables and globals as well as start functions are
+// rejected when decoding, which keeps execution identical on every platform.
+// Every decoded function is rewritten by the metering pass (see meter.go)
+// before it can be executed.
+package wasm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	wasmbin "github.com/matrix/go-matrix/core/wasm"
+	"github.com/matrix/go-matrix/core/wasm/leb128"
+	"github.com/matrix/go-matrix/core/wasm/operators"
+)
+
+// Module limits, chosen to keep the cost of decoding and instantiating a
+// contract proportional to its size.
+const (
+	PageSize       = 65536 // Size of a linear memory page
+	MaxPages       = 256   // Maximum number of linear memory pages (16MB)
+	MaxTableSize   = 65536 // Maximum number of indirect call table entries
+	MaxLocals      = 4096  // Maximum number of locals (including parameters) per function
+	MaxStackHeight = 65536 // Maximum number of values on the shared value stack
+	MaxCallDepth   = 512   // Maximum depth of nested function calls
+)
+
+var magic = []byte{0x00, 0x61, 0x73, 0x6d}
+
+var (
+	ErrInvalidMagic   = wasmbin.ErrInvalidMagic
+	ErrInvalidVersion = errors.New("wasm: unsupported binary version")
+)
+
+// ValueType is the type of a WebAssembly value.
+type ValueType byte
+
+const (
+	I32 ValueType = 0x7f
+	I64 ValueType = 0x7e
+)
+
+// FuncType is the signature of a function.
+type FuncType struct {
+	Params  []ValueType
+	Results []ValueType
+}
+
+// Equal returns whether the two signatures are identical.
+func (t FuncType) Equal(other FuncType) bool {
+	return bytes.Equal(valueTypeBytes(t.Params), valueTypeBytes(other.Params)) &&
+		bytes.Equal(valueTypeBytes(t.Results), valueTypeBytes(other.Results))
+}
+
+func valueTypeBytes(types []ValueType) []byte {
+	b := make([]byte, len(types))
+	for i, t := range types {
+		b[i] = byte(t)
+	}
+	return b
+}
+
+// instr is a decoded instruction. Branch targets of structured control
+// instructions are resolved after metering has been injected.
+type instr struct {
+	op    byte
+	arity byte     // Number of results of a block, loop or if
+	imm   uint64   // Constant, index, memory offset or gas charge
+	end   uint32   // Position of the matching end of a block, loop, if or else
+	els   uint32   // Position of the matching else of an if (end if absent)
+	table []uint32 // Branch depths of a br_table, the last one being the default
+}
+
+type function struct {
+	typ    uint32
+	locals int
+	code   []instr
+}
+
+type global struct {
+	typ     ValueType
+	mutable bool
+	init    uint64
+}
+
+type segment struct {
+	offset uint32
+	funcs  []uint32
+	data   []byte
+}
+
+type limits struct {
+	min, max uint32
+	hasMax   bool
+}
+
+type funcImport struct {
+	module, name string
+	typ          uint32
+}
+
+// Module is a decoded and metered WebAssembly module. A module is immutable
+// once decoded and may be shared between any number of instances.
+type Module struct {
+	types     []FuncType
+	imports   []funcImport
+	decl      []uint32 // Type indices of the declared functions
+	functions []function
+	globals   []global
+	table     *limits
+	memory    *limits
+	exports   map[string]uint32 // Exported function indices by name
+	elements  []segment
+	data      []segment
+}
+
+// IsModule reports whether code starts with the WebAssembly magic number.
+func IsModule(code []byte) bool {
+	return len(code) >= len(magic) && bytes.Equal(code[:len(magic)], magic)
+}
+
+// Export returns the signature of the exported function called name.
+func (m *Module) Export(name string) (FuncType, bool) {
+	idx, ok := m.exports[name]
+	if !ok {
+		return FuncType{}, false
+	}
+	return m.funcType(idx), true
+}
+
+// funcType returns the signature of the function at the given index of the
+// function index space, which starts with the imported functions.
+func (m *Module) funcType(idx uint32) FuncType {
+	if idx < uint32(len(m.imports)) {
+		return m.types[m.imports[idx].typ]
+	}
+	return m.types[m.functions[idx-uint32(len(m.imports))].typ]
+}
+
+func (m *Module) numFuncs() uint32 {
+	return uint32(len(m.imports) + len(m.decl))
+}
+
+// Decode parses a binary WebAssembly module, validates that it only uses the
+// supported feature set and injects gas metering into every function.
+func Decode(code []byte) (*Module, error) {
+	if !IsModule(code) {
+		return nil, ErrInvalidMagic
+	}
+	bin, err := wasmbin.DecodeModule(bytes.NewReader(code))
+	if err != nil {
+		return nil, err
+	}
+	if bin.Version != wasmbin.Version {
+		return nil, ErrInvalidVersion
+	}
+	var last wasmbin.SectionID
+	for _, sec := range bin.Sections {
+		id := sec.SectionID()
+		if id == wasmbin.SectionIDCustom {
+			continue
+		}
+		if id <= last {
+			return nil, fmt.Errorf("wasm: section %s out of order", id)
+		}
+		last = id
+	}
+	if bin.Start != nil {
+		return nil, errors.New("wasm: start functions are not supported")
+	}
+	m := &Module{exports: make(map[string]uint32)}
+	for _, decode := range []func(*wasmbin.Module) error{
+		m.decodeTypes,
+		m.decodeImports,
+		m.decodeFunctions,
+		m.decodeTable,
+		m.decodeMemory,
+		m.decodeGlobals,
+		m.decodeExports,
+		m.decodeElements,
+		m.decodeCode,
+		m.decodeData,
+	} {
+		if err := decode(bin); err != nil {
+			return nil, err
+		}
+	}
+	if len(m.decl) != len(m.functions) {
+		return nil, errors.New("wasm: function and code section counts differ")
+	}
+	return m, nil
+}
+
+func (m *Module) decodeTypes(bin *wasmbin.Module) error {
+	if bin.Types == nil {
+		return nil
+	}
+	m.types = make([]FuncType, len(bin.Types.Entries))
+	for i, sig := range bin.Types.Entries {
+		if int(sig.Form) != wasmbin.TypeFunc {
+			return fmt.Errorf("wasm: invalid function type form %d", sig.Form)
+		}
+		if len(sig.ReturnTypes) > 1 {
+			return errors.New("wasm: multiple return values are not supported")
+		}
+		var err error
+		if m.types[i].Params, err = valueTypes(sig.ParamTypes); err != nil {
+			return err
+		}
+		if m.types[i].Results, err = valueTypes(sig.ReturnTypes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Module) decodeImports(bin *wasmbin.Module) error {
+	if bin.Import == nil {
+		return nil
+	}
+	for _, entry := range bin.Import.Entries {
+		imp, ok := entry.Type.(wasmbin.FuncImport)
+		if !ok {
+			return fmt.Errorf("wasm: import %s.%s: only functions can be imported", entry.ModuleName, entry.FieldName)
+		}
+		if imp.Type >= uint32(len(m.types)) {
+			return fmt.Errorf("wasm: import %s.%s: unknown type %d", entry.ModuleName, entry.FieldName, imp.Type)
+		}
+		m.imports = append(m.imports, funcImport{module: entry.ModuleName, name: entry.FieldName, typ: imp.Type})
+	}
+	return nil
+}
+
+func (m *Module) decodeFunctions(bin *wasmbin.Module) error {
+	if bin.Function == nil {
+		return nil
+	}
+	for i, typ := range bin.Function.Types {
+		if typ >= uint32(len(m.types)) {
+			return fmt.Errorf("wasm: function %d: unknown type %d", i, typ)
+		}
+	}
+	m.decl = bin.Function.Types
+	return nil
+}
+
+func (m *Module) decodeTable(bin *wasmbin.Module) error {
+	if bin.Table == nil {
+		return nil
+	}
+	if n := len(bin.Table.Entries); n != 1 {
+		return fmt.Errorf("wasm: expected exactly one table, have %d", n)
+	}
+	table := bin.Table.Entries[0]
+	if table.ElementType != wasmbin.ElemTypeAnyFunc {
+		return fmt.Errorf("wasm: unsupported table element type %d", table.ElementType)
+	}
+	var err error
+	m.table, err = decodeLimits(table.Limits, MaxTableSize)
+	return err
+}
+
+func (m *Module) decodeMemory(bin *wasmbin.Module) error {
+	if bin.Memory == nil {
+		return nil
+	}
+	if n := len(bin.Memory.Entries); n != 1 {
+		return fmt.Errorf("wasm: expected exactly one memory, have %d", n)
+	}
+	var err error
+	m.memory, err = decodeLimits(bin.Memory.Entries[0].Limits, MaxPages)
+	return err
+}
+
+func (m *Module) decodeGlobals(bin *wasmbin.Module) error {
+	if bin.Global == nil {
+		return nil
+	}
+	m.globals = make([]global, len(bin.Global.Globals))
+	for i, entry := range bin.Global.Globals {
+		typ, err := valueType(entry.Type.Type)
+		if err != nil {
+			return err
+		}
+		init, err := constExpr(entry.Init, typ)
+		if err != nil {
+			return fmt.Errorf("wasm: global %d: %v", i, err)
+		}
+		m.globals[i] = global{typ: typ, mutable: entry.Type.Mutable, init: init}
+	}
+	return nil
+}
+
+func (m *Module) decodeExports(bin *wasmbin.Module) error {
+	if bin.Export == nil {
+		return nil
+	}
+	// Check the exports in a fixed order so every node reports the same error
+	names := make([]string, 0, len(bin.Export.Entries))
+	for name := range bin.Export.Entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		entry := bin.Export.Entries[name]
+		if entry.Kind != wasmbin.ExternalFunction {
+			continue // only exported functions can be invoked
+		}
+		if entry.Index >= m.numFuncs() {
+			return fmt.Errorf("wasm: export %q: unknown function %d", name, entry.Index)
+		}
+		m.exports[name] = entry.Index
+	}
+	return nil
+}
+
+func (m *Module) decodeElements(bin *wasmbin.Module) error {
+	if bin.Elements == nil {
+		return nil
+	}
+	if m.table == nil {
+		return errors.New("wasm: element segments without a table")
+	}
+	m.elements = make([]segment, len(bin.Elements.Entries))
+	for i, entry := range bin.Elements.Entries {
+		if entry.Index != 0 {
+			return fmt.Errorf("wasm: element segment %d: unknown table %d", i, entry.Index)
+		}
+		offset, err := constExpr(entry.Offset, I32)
+		if err != nil {
+			return fmt.Errorf("wasm: element segment %d: %v", i, err)
+		}
+		for _, fn := range entry.Elems {
+			if fn >= m.numFuncs() {
+				return fmt.Errorf("wasm: element segment %d: unknown function %d", i, fn)
+			}
+		}
+		m.elements[i] = segment{offset: uint32(offset), funcs: entry.Elems}
+	}
+	return nil
+}
+
+func (m *Module) decodeCode(bin *wasmbin.Module) error {
+	if bin.Code == nil {
+		return nil
+	}
+	if len(bin.Code.Bodies) != len(m.decl) {
+		return errors.New("wasm: function and code section counts differ")
+	}
+	for i, typ := range m.decl {
+		fn, err := m.decodeBody(&bin.Code.Bodies[i], typ)
+		if err != nil {
+			return fmt.Errorf("wasm: function %d: %v", i, err)
+		}
+		m.functions = append(m.functions, fn)
+	}
+	return nil
+}
+
+func (m *Module) decodeData(bin *wasmbin.Module) error {
+	if bin.Data == nil {
+		return nil
+	}
+	if m.memory == nil {
+		return errors.New("wasm: data segments without a memory")
+	}
+	m.data = make([]segment, len(bin.Data.Entries))
+	for i, entry := range bin.Data.Entries {
+		if entry.Index != 0 {
+			return fmt.Errorf("wasm: data segment %d: unknown memory %d", i, entry.Index)
+		}
+		offset, err := constExpr(entry.Offset, I32)
+		if err != nil {
+			return fmt.Errorf("wasm: data segment %d: %v", i, err)
+		}
+		m.data[i] = segment{offset: uint32(offset), data: entry.Data}
+	}
+	return nil
+}
+
+// decodeLimits validates the limits of a table or memory.
+func decodeLimits(lim wasmbin.ResizableLimits, max uint32) (*limits, error) {
+	if lim.Flags > 1 {
+		return nil, fmt.Errorf("wasm: invalid limits flags %d", lim.Flags)
+	}
+	l := &limits{min: lim.Initial, max: lim.Maximum, hasMax: lim.Flags == 1}
+	if l.hasMax && l.max < l.min {
+		return nil, errors.New("wasm: maximum size below minimum")
+	}
+	if l.min > max {
+		return nil, fmt.Errorf("wasm: initial size %d above limit %d", l.min, max)
+	}
+	return l, nil
+}
+
+func valueType(t wasmbin.ValueType) (ValueType, error) {
+	switch t {
+	case wasmbin.ValueTypeI32:
+		return I32, nil
+	case wasmbin.ValueTypeI64:
+		return I64, nil
+	default:
+		return 0, fmt.Errorf("wasm: unsupported value type %s", t)
+	}
+}
+
+func valueTypes(types []wasmbin.ValueType) ([]ValueType, error) {
+	out := make([]ValueType, len(types))
+	for i, t := range types {
+		var err error
+		if out[i], err = valueType(t); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// constExpr evaluates an initializer expression, which must be a single
+// constant of the given type.
+func constExpr(expr []byte, typ ValueType) (uint64, error) {
+	r := bytes.NewReader(expr)
+
+	op, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	switch {
+	case op == opI32Const && typ == I32:
+		x, err := leb128.ReadVarint32(r)
+		if err != nil {
+			return 0, err
+		}
+		v = uint64(uint32(x))
+	case op == opI64Const && typ == I64:
+		x, err := leb128.ReadVarint64(r)
+		if err != nil {
+			return 0, err
+		}
+		v = uint64(x)
+	default:
+		return 0, fmt.Errorf("unsupported initializer opcode 0x%x", op)
+	}
+	if end, err := r.ReadByte(); err != nil || end != opEnd || r.Len() != 0 {
+		return 0, errors.New("initializer is not a single constant")
+	}
+	return v, nil
+}
+
+// decodeBody decodes a function body into its instruction sequence, checking
+// every immediate against the module, and injects the gas metering.
+func (m *Module) decodeBody(body *wasmbin.FunctionBody, typ uint32) (function, error) {
+	var (
+		params = len(m.types[typ].Params)
+		locals = uint64(params)
+	)
+	for _, entry := range body.Locals {
+		if locals += uint64(entry.Count); locals > MaxLocals {
+			return function{}, fmt.Errorf("too many locals, limit %d", MaxLocals)
+		}
+		if _, err := valueType(entry.Type); err != nil {
+			return function{}, err
+		}
+	}
+	// The decoder strips the end of the function, which is appended back once
+	// all the nested blocks are closed.
+	var (
+		r     = bytes.NewReader(body.Code)
+		code  []instr
+		depth = uint32(1)
+	)
+	for r.Len() > 0 {
+		op, _ := r.ReadByte()
+		if !supported[op] {
+			if o, err := operators.New(op); err == nil {
+				return function{}, fmt.Errorf("unsupported operator %s", o.Name)
+			}
+			return function{}, fmt.Errorf("unsupported opcode 0x%x", op)
+		}
+		ins := instr{op: op}
+		switch {
+		case op == opBlock || op == opLoop || op == opIf:
+			bt, err := r.ReadByte()
+			if err != nil {
+				return function{}, err
+			}
+			switch bt {
+			case 0x40:
+			case byte(I32), byte(I64):
+				ins.arity = 1
+			default:
+				return function{}, fmt.Errorf("unsupported block type 0x%x", bt)
+			}
+			depth++
+
+		case op == opEnd:
+			if depth--; depth == 0 {
+				return function{}, errors.New("trailing bytes after function end")
+			}
+
+		case op == opBr || op == opBrIf:
+			d, err := leb128.ReadVarUint32(r)
+			if err != nil {
+				return function{}, err
+			}
+			if d >= depth {
+				return function{}, fmt.Errorf("invalid branch depth %d", d)
+			}
+			ins.imm = uint64(d)
+
+		case op == opBrTable:
+			n, err := leb128.ReadVarUint32(r)
+			if err != nil {
+				return function{}, err
+			}
+			if uint64(n) >= uint64(r.Len()) {
+				return function{}, wasmbin.ErrLengthTooLarge
+			}
+			ins.table = make([]uint32, n+1)
+			for i := range ins.table {
+				if ins.table[i], err = leb128.ReadVarUint32(r); err != nil {
+					return function{}, err
+				}
+				if ins.table[i] >= depth {
+					return function{}, fmt.Errorf("invalid branch depth %d", ins.table[i])
+				}
+			}
+
+		case op == opCall:
+			idx, err := leb128.ReadVarUint32(r)
+			if err != nil {
+				return function{}, err
+			}
+			if idx >= m.numFuncs() {
+				return function{}, fmt.Errorf("call to unknown function %d", idx)
+			}
+			ins.imm = uint64(idx)
+
+		case op == opCallIndirect:
+			idx, err := leb128.ReadVarUint32(r)
+			if err != nil {
+				return function{}, err
+			}
+			if idx >= uint32(len(m.types)) {
+				return function{}, fmt.Errorf("indirect call with unknown type %d", idx)
+			}
+			if m.table == nil {
+				return function{}, errors.New("indirect call without a table")
+			}
+			if b, err := r.ReadByte(); err != nil || b != 0 {
+				return function{}, errors.New("invalid indirect call table index")
+			}
+			ins.imm = uint64(idx)
+
+		case op >= opLocalGet && op <= opLocalTee:
+			idx, err := leb128.ReadVarUint32(r)
+			if err != nil {
+				return function{}, err
+			}
+			if uint64(idx) >= locals {
+				return function{}, fmt.Errorf("unknown local %d", idx)
+			}
+			ins.imm = uint64(idx)
+
+		case op == opGlobalGet || op == opGlobalSet:
+			idx, err := leb128.ReadVarUint32(r)
+			if err != nil {
+				return function{}, err
+			}
+			if idx >= uint32(len(m.globals)) {
+				return function{}, fmt.Errorf("unknown global %d", idx)
+			}
+			if op == opGlobalSet && !m.globals[idx].mutable {
+				return function{}, fmt.Errorf("assignment to immutable global %d", idx)
+			}
+			ins.imm = uint64(idx)
+
+		case op >= opI32Load && op <= opMemoryGrow:
+			if m.memory == nil {
+				return function{}, errors.New("memory access without a memory")
+			}
+			if op == opMemorySize || op == opMemoryGrow {
+				if b, err := r.ReadByte(); err != nil || b != 0 {
+					return function{}, errors.New("invalid memory index")
+				}
+				break
+			}
+			if _, err := leb128.ReadVarUint32(r); err != nil { // alignment hint
+				return function{}, err
+			}
+			offset, err := leb128.ReadVarUint32(r)
+			if err != nil {
+				return function{}, err
+			}
+			ins.imm = uint64(offset)
+
+		case op == opI32Const:
+			v, err := leb128.ReadVarint32(r)
+			if err != nil {
+				return function{}, err
+			}
+			ins.imm = uint64(uint32(v))
+
+		case op == opI64Const:
+			v, err := leb128.ReadVarint64(r)
+			if err != nil {
+				return function{}, err
+			}
+			ins.imm = uint64(v)
+		}
+		code = append(code, ins)
+	}
+	if depth != 1 {
+		return function{}, errors.New("unterminated block")
+	}
+	code, err := resolve(injectMetering(append(code, instr{op: opEnd})))
+	if err != nil {
+		return function{}, err
+	}
+	return function{typ: typ, locals: int(locals) - params, code: code}, nil
+}
+
+// resolve links every block, loop, if and else instruction to its matching
+// end, and every if to its matching else.
+func resolve(code []instr) ([]instr, error) {
+	var open []int
+	for pc := range code {
+		switch code[pc].op {
+		case opBlock, opLoop, opIf:
+			open = append(open, pc)
+
+		case opElse:
+			if len(open) == 0 || code[open[len(open)-1]].op != opIf || code[open[len(open)-1]].els != 0 {
+				return nil, errors.New("else without matching if")
+			}
+			code[open[len(open)-1]].els = uint32(pc)
+
+		case opEnd:
+			if len(open) == 0 {
+				if pc != len(code)-1 {
+					return nil, errors.New("unbalanced end")
+				}
+				continue
+			}
+			start := &code[open[len(open)-1]]
+			open = open[:len(open)-1]
+
+			start.end = uint32(pc)
+			if start.op == opIf {
+				if start.els == 0 {
+					start.els = uint32(pc)
+				} else {
+					code[start.els].end = uint32(pc)
+				}
+			}
+		}
+	}
+	return code, nil
+}
@@ -0,0 +1,312 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wasm
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Helpers assembling binary modules by hand.
+
+func uleb(v uint64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		if v >>= 7; v != 0 {
+			out = append(out, b|0x80)
+			continue
+		}
+		return append(out, b)
+	}
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func vec(items ...[]byte) []byte {
+	return concat(uleb(uint64(len(items))), concat(items...))
+}
+
+func section(id byte, items ...[]byte) []byte {
+	payload := vec(items...)
+	return concat([]byte{id}, uleb(uint64(len(payload))), payload)
+}
+
+func str(s string) []byte {
+	return concat(uleb(uint64(len(s))), []byte(s))
+}
+
+func sig(params, results []byte) []byte {
+	return concat([]byte{0x60}, uleb(uint64(len(params))), params, uleb(uint64(len(results))), results)
+}
+
+// body encodes a function body declaring the given number of i32 locals.
+func body(locals int, code ...byte) []byte {
+	var decl []byte
+	if locals > 0 {
+		decl = concat([]byte{1}, uleb(uint64(locals)), []byte{byte(I32)})
+	} else {
+		decl = []byte{0}
+	}
+	b := concat(decl, code)
+	return concat(uleb(uint64(len(b))), b)
+}
+
+func export(name string, idx uint32) []byte {
+	return concat(str(name), []byte{0}, uleb(uint64(idx)))
+}
+
+func module(sections ...[]byte) []byte {
+	return concat(magic, []byte{1, 0, 0, 0}, concat(sections...))
+}
+
+var (
+	i32 = []byte{byte(I32)}
+	i64 = []byte{byte(I64)}
+)
+
+func instantiate(t *testing.T, code []byte, env map[string]HostFunction, gas uint64) *VM {
+	m, err := Decode(code)
+	if err != nil {
+		t.Fatalf("failed to decode module: %v", err)
+	}
+	vm, err := Instantiate(m, env, gas)
+	if err != nil {
+		t.Fatalf("failed to instantiate module: %v", err)
+	}
+	return vm
+}
+
+// Tests recursion, if/else with results and i64 arithmetic.
+func TestFactorial(t *testing.T) {
+	code := module(
+		section(1, sig(i64, i64)),
+		section(3, []byte{0}),
+		section(7, export("fac", 0)),
+		section(10, body(0,
+			opLocalGet, 0, opI64Eqz, opIf, byte(I64),
+			opI64Const, 1,
+			opElse,
+			opLocalGet, 0, opLocalGet, 0, opI64Const, 1, opI64Sub, opCall, 0, opI64Mul,
+			opEnd, opEnd,
+		)),
+	)
+	vm := instantiate(t, code, nil, 1000000)
+	res, err := vm.Invoke("fac", 20)
+	if err != nil {
+		t.Fatalf("invocation failed: %v", err)
+	}
+	if res[0] != 2432902008176640000 {
+		t.Errorf("factorial mismatch: have %d, want %d", res[0], uint64(2432902008176640000))
+	}
+}
+
+// sumModule sums the integers 1..n in a loop.
+var sumModule = module(
+	section(1, sig(i32, i32)),
+	section(3, []byte{0}),
+	section(7, export("sum", 0)),
+	section(10, body(1,
+		opBlock, 0x40,
+		opLoop, 0x40,
+		opLocalGet, 0, opI32Eqz, opBrIf, 1,
+		opLocalGet, 1, opLocalGet, 0, opI32Add, opLocalSet, 1,
+		opLocalGet, 0, opI32Const, 1, opI32Sub, opLocalSet, 0,
+		opBr, 0,
+		opEnd,
+		opEnd,
+		opLocalGet, 1,
+		opEnd,
+	)),
+)
+
+// Tests that loops are metered on every iteration.
+func TestLoopMetering(t *testing.T) {
+	var used []uint64
+	for _, n := range []uint64{10, 20} {
+		vm := instantiate(t, sumModule, nil, 1000000)
+		res, err := vm.Invoke("sum", n)
+		if err != nil {
+			t.Fatalf("invocation failed: %v", err)
+		}
+		if res[0] != n*(n+1)/2 {
+			t.Errorf("sum(%d) mismatch: have %d, want %d", n, res[0], n*(n+1)/2)
+		}
+		used = append(used, 1000000-vm.Gas)
+	}
+	// Each iteration executes 12 instructions of unit cost
+	if used[1]-used[0] != 10*12 {
+		t.Errorf("gas per 10 iterations mismatch: have %d, want %d", used[1]-used[0], 10*12)
+	}
+	vm := instantiate(t, sumModule, nil, 1000)
+	if _, err := vm.Invoke("sum", 1000000); err != ErrOutOfGas {
+		t.Errorf("unbounded loop error mismatch: have %v, want %v", err, ErrOutOfGas)
+	}
+}
+
+// Tests linear memory, data segments, memory growth and bounds checks.
+func TestMemory(t *testing.T) {
+	code := module(
+		section(1, sig(nil, i32), sig(nil, nil)),
+		section(3, []byte{0}, []byte{0}, []byte{1}),
+		section(5, []byte{0, 1}),
+		section(7, export("load", 0), export("grow", 1), export("oob", 2)),
+		section(10,
+			body(0, opI32Const, 4, opI32Load8S, 0, 4, opI32Const, 8, opI32Load, 2, 0, opI32Add, opEnd),
+			body(0, opI32Const, 2, opMemoryGrow, 0, opDrop, opMemorySize, 0, opEnd),
+			body(0, opI32Const, 0x80, 0x80, 0x04, opI32Load, 2, 0, opDrop, opEnd),
+		),
+		section(11,
+			concat([]byte{0, opI32Const, 8, opEnd}, str("\x2a\x01\x00\x00")),
+			concat([]byte{0, opI32Const, 8, opEnd}, str("\xff")),
+		),
+	)
+	vm := instantiate(t, code, nil, 1000000)
+	if res, err := vm.Invoke("load"); err != nil || res[0] != 0x100+0xff-1 {
+		t.Errorf("load mismatch: have %v (%v), want %d", res, err, 0x100+0xff-1)
+	}
+	gas := vm.Gas
+	if res, err := vm.Invoke("grow"); err != nil || res[0] != 3 {
+		t.Errorf("grow mismatch: have %v (%v), want 3", res, err)
+	}
+	if gas-vm.Gas < 2*GasMemoryPage {
+		t.Errorf("memory growth undercharged: %d", gas-vm.Gas)
+	}
+	if _, err := vm.Invoke("oob"); err != nil {
+		t.Errorf("in bounds access after growth failed: %v", err)
+	}
+	vm = instantiate(t, code, nil, 1000000)
+	if _, err := vm.Invoke("oob"); err != ErrMemoryOutOfBounds {
+		t.Errorf("out of bounds error mismatch: have %v, want %v", err, ErrMemoryOutOfBounds)
+	}
+}
+
+// Tests imported host functions and indirect calls through a table.
+func TestCalls(t *testing.T) {
+	code := module(
+		section(1, sig(i32, i32), sig(nil, i32)),
+		section(2, concat(str("env"), str("double"), []byte{0, 0})),
+		section(3, []byte{1}, []byte{1}, []byte{0}),
+		section(4, []byte{0x70, 0, 3}),
+		section(7, export("pick", 3)),
+		section(9, concat([]byte{0, opI32Const, 0, opEnd}, vec([]byte{1}, []byte{2}))),
+		section(10,
+			body(0, opI32Const, 21, opCall, 0, opEnd),
+			body(0, opI32Const, 7, opEnd),
+			body(0, opLocalGet, 0, opCallIndirect, 1, 0, opEnd),
+		),
+	)
+	env := map[string]HostFunction{
+		"double": {
+			Type: FuncType{Params: []ValueType{I32}, Results: []ValueType{I32}},
+			Func: func(vm *VM, args []uint64) ([]uint64, error) { return []uint64{args[0] * 2}, nil },
+		},
+	}
+	vm := instantiate(t, code, env, 1000000)
+	for i, want := range []uint64{42, 7} {
+		if res, err := vm.Invoke("pick", uint64(i)); err != nil || res[0] != want {
+			t.Errorf("pick(%d) mismatch: have %v (%v), want %d", i, res, err, want)
+		}
+	}
+	if _, err := vm.Invoke("pick", 2); err != ErrUndefinedElement {
+		t.Errorf("uninitialised element error mismatch: have %v, want %v", err, ErrUndefinedElement)
+	}
+	if _, err := vm.Invoke("pick", 3); err != ErrUndefinedElement {
+		t.Errorf("out of table error mismatch: have %v, want %v", err, ErrUndefinedElement)
+	}
+	m, _ := Decode(code)
+	if _, err := Instantiate(m, nil, 1000000); err == nil {
+		t.Errorf("instantiated module with unresolved import")
+	}
+}
+
+// Tests runtime traps.
+func TestTraps(t *testing.T) {
+	code := module(
+		section(1, sig(nil, nil)),
+		section(3, []byte{0}, []byte{0}, []byte{0}, []byte{0}),
+		section(7, export("div", 0), export("overflow", 1), export("unreachable", 2), export("underflow", 3)),
+		section(10,
+			body(0, opI32Const, 1, opI32Const, 0, opI32DivU, opDrop, opEnd),
+			body(0, opI32Const, 0x80, 0x80, 0x80, 0x80, 0x78, opI32Const, 0x7f, opI32DivS, opDrop, opEnd),
+			body(0, opUnreachable, opEnd),
+			body(0, opDrop, opEnd),
+		),
+	)
+	tests := map[string]error{
+		"div":         ErrDivideByZero,
+		"overflow":    ErrIntegerOverflow,
+		"unreachable": ErrUnreachable,
+		"underflow":   ErrStackUnderflow,
+	}
+	vm := instantiate(t, code, nil, 1000000)
+	for name, want := range tests {
+		if _, err := vm.Invoke(name); err != want {
+			t.Errorf("%s: error mismatch: have %v, want %v", name, err, want)
+		}
+	}
+}
+
+// Tests that modules outside of the supported subset are rejected.
+func TestDecodeUnsupported(t *testing.T) {
+	tests := map[string][]byte{
+		"magic":   []byte("\x00asx\x01\x00\x00\x00"),
+		"version": []byte("\x00asm\x02\x00\x00\x00"),
+		"float": module(
+			section(1, sig(nil, nil)),
+			section(3, []byte{0}),
+			section(10, body(0, 0x43, 0, 0, 0, 0, opDrop, opEnd)),
+		),
+		"start": module(
+			section(1, sig(nil, nil)),
+			section(3, []byte{0}),
+			concat([]byte{8, 1, 0}),
+			section(10, body(0, opEnd)),
+		),
+		"meter": module(
+			section(1, sig(nil, nil)),
+			section(3, []byte{0}),
+			section(10, body(0, opMeter, opEnd)),
+		),
+		"branch": module(
+			section(1, sig(nil, nil)),
+			section(3, []byte{0}),
+			section(10, body(0, opBr, 1, opEnd)),
+		),
+		"truncated": sumModule[:len(sumModule)-1],
+		"order": module(
+			section(3, []byte{0}),
+			section(1, sig(nil, nil)),
+			section(10, body(0, opEnd)),
+		),
+		"length": module(
+			[]byte{1, 0xff, 0xff, 0xff, 0xff, 0x0f},
+		),
+		"empty body": module(
+			section(1, sig(nil, nil)),
+			section(3, []byte{0}),
+			section(10, []byte{1, 0}),
+		),
+	}
+	for name, code := range tests {
+		if _, err := Decode(code); err == nil {
+			t.Errorf("%s: decoded unsupported module", name)
+		}
+	}
+}
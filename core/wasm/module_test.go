@@ -45,3 +45,23 @@ func TestReadModule(t *testing.T) {
 		}
 	}
 }
+
+func TestDecodeModuleMalformed(t *testing.T) {
+	header := []byte("\x00asm\x01\x00\x00\x00")
+	for i, test := range []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"section length", []byte{1, 0xff, 0xff, 0xff, 0xff, 0x0f}, wasm.ErrLengthTooLarge},
+		{"vector length", []byte{1, 5, 0xff, 0xff, 0xff, 0xff, 0x0f}, wasm.ErrLengthTooLarge},
+		{"body length", []byte{3, 2, 1, 0, 10, 6, 1, 0xff, 0xff, 0xff, 0xff, 0x0f}, wasm.ErrLengthTooLarge},
+		{"empty body", []byte{1, 4, 1, 0x60, 0, 0, 3, 2, 1, 0, 10, 3, 1, 1, 0}, wasm.ErrFunctionNoEnd},
+		{"duplicate export", []byte{7, 9, 2, 1, 'a', 0, 0, 1, 'a', 0, 0}, wasm.DuplicateExportError("a")},
+	} {
+		_, err := wasm.DecodeModule(bytes.NewReader(append(header, test.data...)))
+		if err != test.err {
+			t.Errorf("test %d (%s): error mismatch: have %v, want %v", i, test.name, err, test.err)
+		}
+	}
+}
@@ -6,11 +6,46 @@ package wasm
 
 import (
 	"encoding/binary"
+	"errors"
 	"io"
 
+	"github.com/matrix/go-matrix/core/wasm/internal/readpos"
 	"github.com/matrix/go-matrix/core/wasm/leb128"
 )
 
+// ErrLengthTooLarge is returned when the length of a vector or a byte string
+// exceeds the remaining input.
+var ErrLengthTooLarge = errors.New("wasm: length exceeds the remaining input")
+
+// remaining returns the number of bytes left in r, if known.
+func remaining(r io.Reader) (int64, bool) {
+	switch r := r.(type) {
+	case *io.LimitedReader:
+		return r.N, true
+	case *readpos.ReadPos:
+		return remaining(r.R)
+	case interface {
+		Len() int
+	}:
+		return int64(r.Len()), true
+	}
+	return 0, false
+}
+
+// readLen reads the length of a vector or a byte string. As every element
+// takes at least one byte, lengths beyond the remaining input are rejected
+// before anything is allocated for them.
+func readLen(r io.Reader) (uint32, error) {
+	n, err := leb128.ReadVarUint32(r)
+	if err != nil {
+		return 0, err
+	}
+	if left, ok := remaining(r); ok && int64(n) > left {
+		return 0, ErrLengthTooLarge
+	}
+	return n, nil
+}
+
 func readBytes(r io.Reader, n int) ([]byte, error) {
 	bytes := make([]byte, n)
 	_, err := io.ReadFull(r, bytes)
@@ -22,7 +57,7 @@ func readBytes(r io.Reader, n int) ([]byte, error) {
 }
 
 func readBytesUint(r io.Reader) ([]byte, error) {
-	n, err := leb128.ReadVarUint32(r)
+	n, err := readLen(r)
 	if err != nil {
 		return nil, err
 	}
@@ -38,7 +73,7 @@ func readString(r io.Reader, n int) (string, error) {
 }
 
 func readStringUint(r io.Reader) (string, error) {
-	n, err := leb128.ReadVarUint32(r)
+	n, err := readLen(r)
 	if err != nil {
 		return "", err
 	}
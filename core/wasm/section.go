@@ -122,7 +122,7 @@ func (m *Module) readSection(r *readpos.ReadPos) (bool, error) {
 
 	logger.Println("Reading payload length")
 
-	payloadDataLen, err := leb128.ReadVarUint32(r)
+	payloadDataLen, err := readLen(r)
 	if err != nil {
 		return false, err
 	}
@@ -194,6 +194,9 @@ func (m *Module) readSection(r *readpos.ReadPos) (bool, error) {
 		logger.Println(err)
 		return false, err
 	}
+	if sectionReader.(*io.LimitedReader).N != 0 {
+		return false, fmt.Errorf("wasm: section %s size mismatch", s.ID)
+	}
 	s.End = r.CurPos
 	s.Bytes = sectionBytes.Bytes()
 	*sec.GetRawSection() = s
@@ -264,7 +267,7 @@ func (*SectionTypes) SectionID() SectionID {
 }
 
 func (s *SectionTypes) ReadPayload(r io.Reader) error {
-	count, err := leb128.ReadVarUint32(r)
+	count, err := readLen(r)
 	if err != nil {
 		return err
 	}
@@ -303,7 +306,7 @@ func (*SectionImports) SectionID() SectionID {
 }
 
 func (s *SectionImports) ReadPayload(r io.Reader) error {
-	count, err := leb128.ReadVarUint32(r)
+	count, err := readLen(r)
 	if err != nil {
 		return err
 	}
@@ -409,7 +412,7 @@ func (*SectionFunctions) SectionID() SectionID {
 }
 
 func (s *SectionFunctions) ReadPayload(r io.Reader) error {
-	count, err := leb128.ReadVarUint32(r)
+	count, err := readLen(r)
 	if err != nil {
 		return err
 	}
@@ -447,7 +450,7 @@ func (*SectionTables) SectionID() SectionID {
 }
 
 func (s *SectionTables) ReadPayload(r io.Reader) error {
-	count, err := leb128.ReadVarUint32(r)
+	count, err := readLen(r)
 	if err != nil {
 		return err
 	}
@@ -484,7 +487,7 @@ func (*SectionMemories) SectionID() SectionID {
 }
 
 func (s *SectionMemories) ReadPayload(r io.Reader) error {
-	count, err := leb128.ReadVarUint32(r)
+	count, err := readLen(r)
 	if err != nil {
 		return err
 	}
@@ -521,7 +524,7 @@ func (*SectionGlobals) SectionID() SectionID {
 }
 
 func (s *SectionGlobals) ReadPayload(r io.Reader) error {
-	count, err := leb128.ReadVarUint32(r)
+	count, err := readLen(r)
 	if err != nil {
 		return err
 	}
@@ -584,7 +587,7 @@ func (*SectionExports) SectionID() SectionID {
 }
 
 func (s *SectionExports) ReadPayload(r io.Reader) error {
-	count, err := leb128.ReadVarUint32(r)
+	count, err := readLen(r)
 	if err != nil {
 		return err
 	}
@@ -626,7 +629,7 @@ func (s *SectionExports) WritePayload(w io.Writer) error {
 type DuplicateExportError string
 
 func (e DuplicateExportError) Error() string {
-	return fmt.Sprintf("Duplicate export entry: %s", e)
+	return fmt.Sprintf("Duplicate export entry: %s", string(e))
 }
 
 // ExportEntry represents an exported entry by the module
@@ -697,7 +700,7 @@ func (*SectionElements) SectionID() SectionID {
 }
 
 func (s *SectionElements) ReadPayload(r io.Reader) error {
-	count, err := leb128.ReadVarUint32(r)
+	count, err := readLen(r)
 	if err != nil {
 		return err
 	}
@@ -740,7 +743,7 @@ func (s *ElementSegment) UnmarshalWASM(r io.Reader) error {
 		return err
 	}
 
-	numElems, err := leb128.ReadVarUint32(r)
+	numElems, err := readLen(r)
 	if err != nil {
 		return err
 	}
@@ -787,7 +790,7 @@ func (*SectionCode) SectionID() SectionID {
 }
 
 func (s *SectionCode) ReadPayload(r io.Reader) error {
-	count, err := leb128.ReadVarUint32(r)
+	count, err := readLen(r)
 	if err != nil {
 		return err
 	}
@@ -825,7 +828,7 @@ type FunctionBody struct {
 
 func (f *FunctionBody) UnmarshalWASM(r io.Reader) error {
 
-	bodySize, err := leb128.ReadVarUint32(r)
+	bodySize, err := readLen(r)
 	if err != nil {
 		return err
 	}
@@ -838,7 +841,7 @@ func (f *FunctionBody) UnmarshalWASM(r io.Reader) error {
 
 	bytesReader := bytes.NewBuffer(body)
 
-	localCount, err := leb128.ReadVarUint32(bytesReader)
+	localCount, err := readLen(bytesReader)
 	if err != nil {
 		return err
 	}
@@ -855,7 +858,7 @@ func (f *FunctionBody) UnmarshalWASM(r io.Reader) error {
 	code := bytesReader.Bytes()
 	logger.Printf("Read %d bytes for function body", len(code))
 
-	if code[len(code)-1] != end {
+	if len(code) == 0 || code[len(code)-1] != end {
 		return ErrFunctionNoEnd
 	}
 
@@ -923,7 +926,7 @@ func (*SectionData) SectionID() SectionID {
 }
 
 func (s *SectionData) ReadPayload(r io.Reader) error {
-	count, err := leb128.ReadVarUint32(r)
+	count, err := readLen(r)
 	if err != nil {
 		return err
 	}
@@ -130,7 +130,7 @@ func (f *FunctionSig) UnmarshalWASM(r io.Reader) error {
 	}
 	f.Form = int8(form)
 
-	paramCount, err := leb128.ReadVarUint32(r)
+	paramCount, err := readLen(r)
 	if err != nil {
 		return err
 	}
@@ -143,7 +143,7 @@ func (f *FunctionSig) UnmarshalWASM(r io.Reader) error {
 		}
 	}
 
-	returnCount, err := leb128.ReadVarUint32(r)
+	returnCount, err := readLen(r)
 	if err != nil {
 		return err
 	}
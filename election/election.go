@@ -82,18 +82,31 @@ type ElectMVSub struct {
 }
 
 func NewEle() *Elector {
-	var ele Elector
-
-	ele.MaxSample = 1000
-	ele.J = 0
-	ele.M = 11
-	ele.P = 5
-	ele.N = 21
+	ele := newElector()
 	ele.EleServer()
 	ele.EleMMRs = make(chan *mc.MasterMinerReElectionRsp, 10)
 	ele.EleMVRs = make(chan *mc.MasterValidatorReElectionRsq, 10)
 
-	return &ele
+	return ele
+}
+
+// NewStandaloneEle creates an elector that is not attached to the message
+// center. It runs elections synchronously via ElectMiners and ElectValidators
+// and is meant for read-only simulations.
+func NewStandaloneEle() *Elector {
+	ele := newElector()
+	ele.ChoiceEngine(1)
+	return ele
+}
+
+func newElector() *Elector {
+	return &Elector{
+		MaxSample: 1000,
+		J:         0,
+		M:         11,
+		P:         5,
+		N:         21,
+	}
 }
 
 /////////////////////////////
@@ -186,7 +199,6 @@ type Strallyint struct {
 
 func Normalize(probVal []Stf) []pnormalized {
 
-	fmt.Println(probVal)
 	var total float64
 	//	var mlen int
 	for _, item := range probVal {
@@ -412,7 +424,7 @@ func (Ele *Elector) ValNodesSelected(probVal []Stf, seed int64, M int, P int, J
 	Ele.J = J
 	//归一化价值函数 成为 采样概率
 	probnormalized := Normalize(probVal)
-	fmt.Println(probnormalized)
+	log.Trace("Elector ValNodesSelected", "probabilities", probnormalized)
 	// 选出M+P-J个节点 或者 进行1000次采样
 	PricipalValNodes, BakValNodes, RemainingProbNormalizedNodes := Ele.SampleMPlusPNodes(probnormalized, seed) //SampleMPlusPNodes(probnormalized=probnormalized,seed=seed,M=M,J=J,P=5,MaxSample=MaxSample)
 	// 计算所有剩余节点的股权RemainingProbNormalizedNodes
@@ -435,7 +447,7 @@ func (Ele *Elector) ValNodesSelected(probVal []Stf, seed int64, M int, P int, J
 func (Ele *Elector) MinerNodesSelected(probVal []Stf, seed int64, Ms int) ([]Strallyint, []Strallyint) {
 	probnormalized := Normalize(probVal)
 
-	fmt.Println(probnormalized)
+	log.Trace("Elector MinerNodesSelected", "probabilities", probnormalized)
 	PricipalMinerNodes, BakMinerNodes := Ele.SampleMinerNodes(probnormalized, seed, Ms)
 
 	//计算所有剩余节点的股权
@@ -575,126 +587,126 @@ func (Ele *Elector) Listen() {
 	for {
 		select {
 		case mmrerm := <-Ele.EleMMSub.MasterMinerReElectionReqMsgCH:
-			MinerElectMap := make(map[string]vm.DepositDetail)
-			for i, item := range mmrerm.MinerList {
-				//				MinerElectMap[string(item.Account[:])] = item
-				MinerElectMap[string(item.NodeID[:])] = item
-				if item.Deposit == nil {
-					mmrerm.MinerList[i].Deposit = big.NewInt(50000)
-				}
-				if item.WithdrawH == nil {
-					mmrerm.MinerList[i].WithdrawH = big.NewInt(0)
-				}
-				if item.OnlineTime == nil {
-					mmrerm.MinerList[i].OnlineTime = big.NewInt(300)
-				}
-			}
-
-			value := CalcAllValueFunction(mmrerm.MinerList)
-
-			a, b := Ele.MinerNodesSelected(value, mmrerm.RandSeed.Int64(), 21) //Ele.Engine(value, mmrerm.RandSeed.Int64()) //0x12217)
-			for index, item := range a {
-				fmt.Println(index, item)
-			}
-			for index, item := range b {
-				fmt.Println(index, item)
-			}
-			MinerEleRs := new(mc.MasterMinerReElectionRsp)
-			MinerEleRs.SeqNum = mmrerm.SeqNum
-
-			for index, item := range a {
-				fmt.Println(item.Nodeid, []byte(item.Nodeid))
-				tmp := MinerElectMap[item.Nodeid]
-				var ToG mc.TopologyNodeInfo
-				ToG.Account = tmp.Address
-				ToG.Position = uint16(index)
-				ToG.Type = common.RoleMiner
-				ToG.Stock = uint16(item.Value)
-				MinerEleRs.MasterMiner = append(MinerEleRs.MasterMiner, ToG)
-			}
-
-			for index, item := range b {
-				tmp := MinerElectMap[item.Nodeid]
-				var ToG mc.TopologyNodeInfo
-				ToG.Account = tmp.Address
-				//				ToG.OnlineState = true
-				ToG.Position = uint16(index)
-				ToG.Type = common.RoleMiner
-				ToG.Stock = uint16(item.Value)
-				MinerEleRs.BackUpMiner = append(MinerEleRs.BackUpMiner, ToG)
-			}
-
-			Ele.EleMMRs <- MinerEleRs
-			//			Ele.EleMVRs <- fmt.Println(value)
-			//fmt.Println("收到数据", mmrerm)
+			Ele.EleMMRs <- Ele.ElectMiners(mmrerm)
 
 		case mvrerm := <-Ele.EleMVSub.MasterValidatorReElectionReqMsgCH:
 			log.Info("Elector Listen", "ReElec_MasterValidatorElectionReq", mvrerm)
-			ValidatorElectMap := make(map[string]vm.DepositDetail)
-			for i, item := range mvrerm.ValidatorList {
-				ValidatorElectMap[string(item.NodeID[:])] = item
-				//todo: panic
-				if item.Deposit == nil {
-					mvrerm.ValidatorList[i].Deposit = big.NewInt(50000)
-				}
-				if item.WithdrawH == nil {
-					mvrerm.ValidatorList[i].WithdrawH = big.NewInt(0)
-				}
-				if item.OnlineTime == nil {
-					mvrerm.ValidatorList[i].OnlineTime = big.NewInt(300)
-				}
-			}
+			Ele.EleMVRs <- Ele.ElectValidators(mvrerm)
+		}
+	}
+}
 
-			ValidatorEleRs := new(mc.MasterValidatorReElectionRsq)
-			ValidatorEleRs.SeqNum = mvrerm.SeqNum
-
-			var a, b, c []Strallyint
-			var value []Stf
-			if len(mvrerm.FoundationValidatoeList) == 0 {
-				value = CalcAllValueFunction(mvrerm.ValidatorList)
-				a, b, c = Ele.Engine(value, mvrerm.RandSeed.Int64(), 11, 5, 0) //mvrerm.RandSeed.Int64(), 11, 5, 0) //0x12217)
-			} else {
-				value = CalcAllValueFunction(mvrerm.ValidatorList)
-				valuefound := CalcAllValueFunction(mvrerm.FoundationValidatoeList)
-				a, b, c = Ele.Engine(value, mvrerm.RandSeed.Int64(), 11, 5, len(mvrerm.FoundationValidatoeList)) //0x12217)
-				a = Ele.CommbineFundNodesAndPricipal(value, valuefound, a, 0.25, 4.0)
-			}
+// ElectMiners runs the miner election for the given request.
+func (Ele *Elector) ElectMiners(mmrerm *mc.MasterMinerReElectionReqMsg) *mc.MasterMinerReElectionRsp {
+	MinerElectMap := make(map[string]vm.DepositDetail)
+	for i, item := range mmrerm.MinerList {
+		//				MinerElectMap[string(item.Account[:])] = item
+		MinerElectMap[string(item.NodeID[:])] = item
+		if item.Deposit == nil {
+			mmrerm.MinerList[i].Deposit = big.NewInt(50000)
+		}
+		if item.WithdrawH == nil {
+			mmrerm.MinerList[i].WithdrawH = big.NewInt(0)
+		}
+		if item.OnlineTime == nil {
+			mmrerm.MinerList[i].OnlineTime = big.NewInt(300)
+		}
+	}
 
-			for index, item := range a {
-				tmp := ValidatorElectMap[item.Nodeid]
-				var ToG mc.TopologyNodeInfo
-				ToG.Account = tmp.Address
-				ToG.Position = uint16(index)
-				ToG.Type = common.RoleValidator
-				ToG.Stock = uint16(item.Value)
-				ValidatorEleRs.MasterValidator = append(ValidatorEleRs.MasterValidator, ToG)
-			}
+	value := CalcAllValueFunction(mmrerm.MinerList)
 
-			for index, item := range b {
-				tmp := ValidatorElectMap[item.Nodeid]
-				var ToG mc.TopologyNodeInfo
-				ToG.Account = tmp.Address
-				ToG.Position = uint16(index)
-				ToG.Type = common.RoleValidator
-				ToG.Stock = uint16(item.Value)
-				ValidatorEleRs.BackUpValidator = append(ValidatorEleRs.BackUpValidator, ToG)
-			}
+	a, b := Ele.MinerNodesSelected(value, mmrerm.RandSeed.Int64(), 21) //Ele.Engine(value, mmrerm.RandSeed.Int64()) //0x12217)
+	log.Trace("Elector ElectMiners", "master", a, "backup", b)
+	MinerEleRs := new(mc.MasterMinerReElectionRsp)
+	MinerEleRs.SeqNum = mmrerm.SeqNum
 
-			for index, item := range c {
-				tmp := ValidatorElectMap[item.Nodeid]
-				var ToG mc.TopologyNodeInfo
-				ToG.Account = tmp.Address
+	for index, item := range a {
+		tmp := MinerElectMap[item.Nodeid]
+		var ToG mc.TopologyNodeInfo
+		ToG.Account = tmp.Address
+		ToG.Position = uint16(index)
+		ToG.Type = common.RoleMiner
+		ToG.Stock = uint16(item.Value)
+		MinerEleRs.MasterMiner = append(MinerEleRs.MasterMiner, ToG)
+	}
 
-				ToG.Position = uint16(index)
-				ToG.Type = common.RoleValidator
-				ToG.Stock = uint16(item.Value)
-				ValidatorEleRs.CandidateValidator = append(ValidatorEleRs.CandidateValidator, ToG)
-			}
-			Ele.EleMVRs <- ValidatorEleRs
-			//			Ele.EleMVRs <- fmt.Println(value)
-			//	fmt.Println("受到数据", mvrerm)
+	for index, item := range b {
+		tmp := MinerElectMap[item.Nodeid]
+		var ToG mc.TopologyNodeInfo
+		ToG.Account = tmp.Address
+		//				ToG.OnlineState = true
+		ToG.Position = uint16(index)
+		ToG.Type = common.RoleMiner
+		ToG.Stock = uint16(item.Value)
+		MinerEleRs.BackUpMiner = append(MinerEleRs.BackUpMiner, ToG)
+	}
+
+	return MinerEleRs
+}
+
+// ElectValidators runs the validator election for the given request.
+func (Ele *Elector) ElectValidators(mvrerm *mc.MasterValidatorReElectionReqMsg) *mc.MasterValidatorReElectionRsq {
+	ValidatorElectMap := make(map[string]vm.DepositDetail)
+	for i, item := range mvrerm.ValidatorList {
+		ValidatorElectMap[string(item.NodeID[:])] = item
+		//todo: panic
+		if item.Deposit == nil {
+			mvrerm.ValidatorList[i].Deposit = big.NewInt(50000)
+		}
+		if item.WithdrawH == nil {
+			mvrerm.ValidatorList[i].WithdrawH = big.NewInt(0)
+		}
+		if item.OnlineTime == nil {
+			mvrerm.ValidatorList[i].OnlineTime = big.NewInt(300)
 		}
 	}
+
+	ValidatorEleRs := new(mc.MasterValidatorReElectionRsq)
+	ValidatorEleRs.SeqNum = mvrerm.SeqNum
+
+	var a, b, c []Strallyint
+	var value []Stf
+	if len(mvrerm.FoundationValidatoeList) == 0 {
+		value = CalcAllValueFunction(mvrerm.ValidatorList)
+		a, b, c = Ele.Engine(value, mvrerm.RandSeed.Int64(), 11, 5, 0) //mvrerm.RandSeed.Int64(), 11, 5, 0) //0x12217)
+	} else {
+		value = CalcAllValueFunction(mvrerm.ValidatorList)
+		valuefound := CalcAllValueFunction(mvrerm.FoundationValidatoeList)
+		a, b, c = Ele.Engine(value, mvrerm.RandSeed.Int64(), 11, 5, len(mvrerm.FoundationValidatoeList)) //0x12217)
+		a = Ele.CommbineFundNodesAndPricipal(value, valuefound, a, 0.25, 4.0)
+	}
+
+	for index, item := range a {
+		tmp := ValidatorElectMap[item.Nodeid]
+		var ToG mc.TopologyNodeInfo
+		ToG.Account = tmp.Address
+		ToG.Position = uint16(index)
+		ToG.Type = common.RoleValidator
+		ToG.Stock = uint16(item.Value)
+		ValidatorEleRs.MasterValidator = append(ValidatorEleRs.MasterValidator, ToG)
+	}
+
+	for index, item := range b {
+		tmp := ValidatorElectMap[item.Nodeid]
+		var ToG mc.TopologyNodeInfo
+		ToG.Account = tmp.Address
+		ToG.Position = uint16(index)
+		ToG.Type = common.RoleValidator
+		ToG.Stock = uint16(item.Value)
+		ValidatorEleRs.BackUpValidator = append(ValidatorEleRs.BackUpValidator, ToG)
+	}
+
+	for index, item := range c {
+		tmp := ValidatorElectMap[item.Nodeid]
+		var ToG mc.TopologyNodeInfo
+		ToG.Account = tmp.Address
+
+		ToG.Position = uint16(index)
+		ToG.Type = common.RoleValidator
+		ToG.Stock = uint16(item.Value)
+		ValidatorEleRs.CandidateValidator = append(ValidatorEleRs.CandidateValidator, ToG)
+	}
+	return ValidatorEleRs
 }
 
 func (Ele *Elector) ToPoUpdate(Q0, Q1, Q2 []mc.TopologyNodeInfo, nettopo mc.TopologyGraph, offline []common.Address) []mc.Alternative {
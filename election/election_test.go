@@ -0,0 +1,70 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package election
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/core/vm"
+	"github.com/matrix/go-matrix/mc"
+)
+
+func testDeposits(n int) []vm.DepositDetail {
+	deposits := make([]vm.DepositDetail, n)
+	for i := range deposits {
+		deposits[i].Address = common.BigToAddress(big.NewInt(int64(i + 1)))
+		deposits[i].NodeID[0] = byte(i + 1)
+		deposits[i].Deposit = big.NewInt(int64(10000 + 2000*i))
+		deposits[i].OnlineTime = big.NewInt(300)
+	}
+	return deposits
+}
+
+func TestElectValidators(t *testing.T) {
+	req := func() *mc.MasterValidatorReElectionReqMsg {
+		return &mc.MasterValidatorReElectionReqMsg{SeqNum: 1, RandSeed: big.NewInt(12345), ValidatorList: testDeposits(20)}
+	}
+	rsp := NewStandaloneEle().ElectValidators(req())
+
+	if len(rsp.MasterValidator) != 11 || len(rsp.BackUpValidator) != 5 || len(rsp.CandidateValidator) != 4 {
+		t.Fatalf("validator set sizes mismatch: have %d/%d/%d, want 11/5/4",
+			len(rsp.MasterValidator), len(rsp.BackUpValidator), len(rsp.CandidateValidator))
+	}
+	// Every depositor must show up exactly once across all sets
+	seen := make(map[common.Address]bool)
+	for _, set := range [][]mc.TopologyNodeInfo{rsp.MasterValidator, rsp.BackUpValidator, rsp.CandidateValidator} {
+		for _, node := range set {
+			if seen[node.Account] {
+				t.Errorf("validator %x elected twice", node.Account)
+			}
+			seen[node.Account] = true
+		}
+	}
+	// A separate elector given the same inputs must elect the same sets
+	if again := NewStandaloneEle().ElectValidators(req()); !reflect.DeepEqual(rsp, again) {
+		t.Errorf("election not deterministic:\nfirst:  %v\nsecond: %v", rsp, again)
+	}
+}
+
+func TestElectMinersBelowLimit(t *testing.T) {
+	rsp := NewStandaloneEle().ElectMiners(&mc.MasterMinerReElectionReqMsg{SeqNum: 1, RandSeed: big.NewInt(1), MinerList: testDeposits(5)})
+	if len(rsp.MasterMiner) != 5 || len(rsp.BackUpMiner) != 0 {
+		t.Fatalf("miner set sizes mismatch: have %d/%d, want 5/0", len(rsp.MasterMiner), len(rsp.BackUpMiner))
+	}
+}
@@ -0,0 +1,374 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// maxQueryDepth bounds the nesting of the fields of a query, as every level
+// of e.g. block { parent { parent ... } } costs a database lookup.
+const maxQueryDepth = 16
+
+// object is a value of a GraphQL object type, whose fields are resolved on
+// demand.
+type object interface {
+	// typeName returns the name of the GraphQL type of the object.
+	typeName() string
+
+	// resolve returns the value of a field with the given arguments, which
+	// is nil, an object, a list of objects or a JSON encodable scalar.
+	resolve(ctx context.Context, field string, args arguments) (interface{}, error)
+}
+
+// request is a GraphQL request as sent over HTTP.
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// response is the result of a GraphQL request.
+type response struct {
+	Data   interface{}  `json:"data,omitempty"`
+	Errors []*execError `json:"errors,omitempty"`
+}
+
+// execError is an error of the request, or of resolving the field at Path.
+type execError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// unknownFieldError is returned by resolvers for fields their type lacks.
+type unknownFieldError struct {
+	typ, field string
+}
+
+func (e *unknownFieldError) Error() string {
+	return fmt.Sprintf("cannot query field %q on type %q", e.field, e.typ)
+}
+
+// orderedMap is a JSON object whose keys are encoded in the order of the
+// fields of the query.
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (m *orderedMap) set(key string, value interface{}) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// executor runs an operation of a document against a root object.
+type executor struct {
+	doc    *document
+	vars   map[string]interface{}
+	errors []*execError
+}
+
+// execute runs the request against the root query object.
+func execute(ctx context.Context, root object, req *request) *response {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return &response{Errors: []*execError{{Message: err.Error()}}}
+	}
+	op, err := doc.operation(req.OperationName)
+	if err != nil {
+		return &response{Errors: []*execError{{Message: err.Error()}}}
+	}
+	if op.kind != "query" {
+		return &response{Errors: []*execError{{Message: fmt.Sprintf("%s operations are not supported", op.kind)}}}
+	}
+	if doc.depth(op.selections, make(map[string]bool)) > maxQueryDepth {
+		return &response{Errors: []*execError{{Message: fmt.Sprintf("query exceeds the maximum depth of %d", maxQueryDepth)}}}
+	}
+	vars, err := op.coerceVariables(req.Variables)
+	if err != nil {
+		return &response{Errors: []*execError{{Message: err.Error()}}}
+	}
+	e := &executor{doc: doc, vars: vars}
+	data := e.selectionSet(ctx, root, op.selections, nil)
+	return &response{Data: data, Errors: e.errors}
+}
+
+// operation selects the operation to run, which has to be named if the
+// document contains several.
+func (doc *document) operation(name string) (*operation, error) {
+	if name == "" {
+		if len(doc.operations) > 1 {
+			return nil, errors.New("operation name required for documents with several operations")
+		}
+		return doc.operations[0], nil
+	}
+	for _, op := range doc.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
+
+// depth returns the nesting depth of the fields of a selection set. Spreads
+// of fragments being expanded are not followed again.
+func (doc *document) depth(sels []selection, expanding map[string]bool) int {
+	max := 0
+	for _, sel := range sels {
+		d := 0
+		switch sel := sel.(type) {
+		case *field:
+			d = 1 + doc.depth(sel.selections, expanding)
+		case *inlineFragment:
+			d = doc.depth(sel.selections, expanding)
+		case *fragmentSpread:
+			if frag, ok := doc.fragments[sel.name]; ok && !expanding[sel.name] {
+				expanding[sel.name] = true
+				d = doc.depth(frag.selections, expanding)
+				delete(expanding, sel.name)
+			}
+		}
+		if d > max {
+			max = d
+		}
+	}
+	return max
+}
+
+// coerceVariables merges the given variables with the defaults of the
+// operation, rejecting unknown and missing ones.
+func (op *operation) coerceVariables(given map[string]interface{}) (map[string]interface{}, error) {
+	vars := make(map[string]interface{})
+	declared := make(map[string]bool)
+	for _, def := range op.variables {
+		declared[def.name] = true
+		if v, ok := given[def.name]; ok {
+			vars[def.name] = v
+		} else if def.defValue != nil {
+			vars[def.name] = def.defValue
+		}
+		if vars[def.name] == nil && def.nonNull {
+			return nil, fmt.Errorf("variable $%s of non-null type not provided", def.name)
+		}
+	}
+	for name := range given {
+		if !declared[name] {
+			return nil, fmt.Errorf("variable $%s not declared by the operation", name)
+		}
+	}
+	return vars, nil
+}
+
+// fieldGroup are the fields of a selection set reported under the same
+// response key, which are merged.
+type fieldGroup struct {
+	key    string
+	fields []*field
+}
+
+// collectFields flattens the fragments of a selection set for an object
+// of the given type and groups its fields by response key.
+func (e *executor) collectFields(typ string, sels []selection, groups []*fieldGroup, visited map[string]bool) ([]*fieldGroup, error) {
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *field:
+			include, err := e.included(sel.directives)
+			if err != nil {
+				return nil, err
+			}
+			if !include {
+				continue
+			}
+			var group *fieldGroup
+			for _, g := range groups {
+				if g.key == sel.key() {
+					group = g
+				}
+			}
+			if group == nil {
+				group = &fieldGroup{key: sel.key()}
+				groups = append(groups, group)
+			} else if group.fields[0].name != sel.name {
+				return nil, fmt.Errorf("fields %q and %q conflict as both are named %q", group.fields[0].name, sel.name, sel.key())
+			}
+			group.fields = append(group.fields, sel)
+
+		case *fragmentSpread:
+			include, err := e.included(sel.directives)
+			if err != nil {
+				return nil, err
+			}
+			if !include || visited[sel.name] {
+				continue
+			}
+			frag, ok := e.doc.fragments[sel.name]
+			if !ok {
+				return nil, fmt.Errorf("unknown fragment %q", sel.name)
+			}
+			if frag.typeCondition != typ {
+				continue
+			}
+			visited[sel.name] = true
+			if groups, err = e.collectFields(typ, frag.selections, groups, visited); err != nil {
+				return nil, err
+			}
+
+		case *inlineFragment:
+			include, err := e.included(sel.directives)
+			if err != nil {
+				return nil, err
+			}
+			if !include || (sel.typeCondition != "" && sel.typeCondition != typ) {
+				continue
+			}
+			if groups, err = e.collectFields(typ, sel.selections, groups, visited); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return groups, nil
+}
+
+// included evaluates the @skip and @include directives of a selection.
+func (e *executor) included(dirs []*directive) (bool, error) {
+	for _, dir := range dirs {
+		if dir.name != "skip" && dir.name != "include" {
+			return false, fmt.Errorf("unknown directive @%s", dir.name)
+		}
+		cond, ok := e.value(dir.arguments["if"]).(bool)
+		if !ok {
+			return false, fmt.Errorf("directive @%s requires a boolean argument \"if\"", dir.name)
+		}
+		if cond == (dir.name == "skip") {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// value substitutes the variables of an argument value.
+func (e *executor) value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case variable:
+		return e.vars[string(v)]
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, elem := range v {
+			list[i] = e.value(elem)
+		}
+		return list
+	case map[string]interface{}:
+		obj := make(map[string]interface{}, len(v))
+		for k, elem := range v {
+			obj[k] = e.value(elem)
+		}
+		return obj
+	}
+	return v
+}
+
+// selectionSet resolves the selected fields of an object. Fields failing
+// to resolve are reported as errors and set to null.
+func (e *executor) selectionSet(ctx context.Context, obj object, sels []selection, path []interface{}) interface{} {
+	groups, err := e.collectFields(obj.typeName(), sels, nil, make(map[string]bool))
+	if err != nil {
+		e.fail(path, err)
+		return nil
+	}
+	out := &orderedMap{values: make(map[string]interface{})}
+	for _, group := range groups {
+		f := group.fields[0]
+		fieldPath := append(append([]interface{}{}, path...), group.key)
+		if f.name == "__typename" {
+			out.set(group.key, obj.typeName())
+			continue
+		}
+		args := make(arguments, len(f.arguments))
+		for k, v := range f.arguments {
+			args[k] = e.value(v)
+		}
+		value, err := obj.resolve(ctx, f.name, args)
+		if err != nil {
+			e.fail(fieldPath, err)
+			out.set(group.key, nil)
+			continue
+		}
+		var subsels []selection
+		for _, f := range group.fields {
+			subsels = append(subsels, f.selections...)
+		}
+		out.set(group.key, e.complete(ctx, f.name, value, subsels, fieldPath))
+	}
+	return out
+}
+
+// complete converts the resolved value of a field into its result, resolving
+// the selections of objects.
+func (e *executor) complete(ctx context.Context, name string, value interface{}, sels []selection, path []interface{}) interface{} {
+	switch value := value.(type) {
+	case nil:
+		return nil
+
+	case object:
+		if len(sels) == 0 {
+			e.fail(path, fmt.Errorf("field %q of type %q must have a selection of subfields", name, value.typeName()))
+			return nil
+		}
+		return e.selectionSet(ctx, value, sels, path)
+
+	case []object:
+		list := make([]interface{}, len(value))
+		for i, elem := range value {
+			list[i] = e.complete(ctx, name, elem, sels, append(append([]interface{}{}, path...), i))
+		}
+		return list
+	}
+	if len(sels) > 0 {
+		e.fail(path, fmt.Errorf("field %q is a scalar and must not have a selection", name))
+		return nil
+	}
+	return value
+}
+
+func (e *executor) fail(path []interface{}, err error) {
+	e.errors = append(e.errors, &execError{Message: err.Error(), Path: path})
+}
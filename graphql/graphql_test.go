@@ -0,0 +1,253 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/core/state"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/crypto"
+	"github.com/matrix/go-matrix/mandb"
+	"github.com/matrix/go-matrix/rpc"
+)
+
+// testBackend serves a short chain whose second block holds a transaction
+// emitting one log.
+type testBackend struct {
+	db       mandb.Database
+	blocks   []*types.Block
+	receipts map[common.Hash]types.Receipts
+	state    *state.StateDB
+}
+
+func newTestBackend(t *testing.T) (*testBackend, *types.Transaction) {
+	key, _ := crypto.GenerateKey()
+	signer := types.NewEIP155Signer(big.NewInt(1))
+	tx, err := types.SignTx(types.NewTransaction(0, common.HexToAddress("0x0101"), big.NewInt(10), 21000, big.NewInt(1), nil), signer, key)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	contract := common.HexToAddress("0x0202")
+	receipt := &types.Receipt{
+		Status:            types.ReceiptStatusSuccessful,
+		CumulativeGasUsed: 21000,
+		GasUsed:           21000,
+		TxHash:            tx.Hash(),
+		Logs: []*types.Log{{
+			Address: contract,
+			Topics:  []common.Hash{common.HexToHash("0xaa"), common.HexToHash("0xbb")},
+			Data:    []byte{1, 2},
+			TxHash:  tx.Hash(),
+		}},
+	}
+	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+
+	db := mandb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	statedb.AddBalance(contract, big.NewInt(1000))
+	statedb.SetNonce(contract, 3)
+
+	b := &testBackend{db: db, receipts: make(map[common.Hash]types.Receipts), state: statedb}
+	var parent common.Hash
+	for i := 0; i < 3; i++ {
+		header := &types.Header{ParentHash: parent, Number: big.NewInt(int64(i)), Difficulty: big.NewInt(1), Time: big.NewInt(int64(100 + i)), GasLimit: 8000000}
+		var block *types.Block
+		if i == 1 {
+			block = types.NewBlock(header, []*types.Transaction{tx}, nil, []*types.Receipt{receipt})
+			b.receipts[block.Hash()] = types.Receipts{receipt}
+		} else {
+			block = types.NewBlock(header, nil, nil, nil)
+		}
+		b.blocks = append(b.blocks, block)
+		parent = block.Hash()
+	}
+	return b, tx
+}
+
+func (b *testBackend) ChainDb() mandb.Database { return b.db }
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	block, err := b.BlockByNumber(ctx, number)
+	if block == nil {
+		return nil, err
+	}
+	return block.Header(), nil
+}
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	if number < 0 {
+		return b.blocks[len(b.blocks)-1], nil
+	}
+	if int(number) >= len(b.blocks) {
+		return nil, nil
+	}
+	return b.blocks[number], nil
+}
+
+func (b *testBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	header, err := b.HeaderByNumber(ctx, number)
+	return b.state, header, err
+}
+
+func (b *testBackend) GetBlock(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	for _, block := range b.blocks {
+		if block.Hash() == hash {
+			return block, nil
+		}
+	}
+	return nil, nil
+}
+
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return b.receipts[hash], nil
+}
+
+func (b *testBackend) GetPoolTransaction(hash common.Hash) *types.Transaction { return nil }
+
+// post sends a GraphQL request to the handler and decodes the response.
+func post(t *testing.T, h http.Handler, req request) (map[string]interface{}, []*execError) {
+	body, _ := json.Marshal(req)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)))
+
+	var resp struct {
+		Data   map[string]interface{} `json:"data"`
+		Errors []*execError           `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response %q: %v", rec.Body.String(), err)
+	}
+	return resp.Data, resp.Errors
+}
+
+func TestNestedQuery(t *testing.T) {
+	backend, tx := newTestBackend(t)
+	h := NewHandler(backend)
+
+	data, errs := post(t, h, request{
+		Query: `query Block($number: Long!) {
+			block(number: $number) {
+				number
+				parentNumber: parent { number }
+				transactions {
+					hash
+					...receipt
+				}
+			}
+			latest: block { number __typename }
+		}
+		fragment receipt on Transaction {
+			status
+			logs { topics account { address balance transactionCount } }
+		}`,
+		Variables: map[string]interface{}{"number": 1},
+	})
+	if len(errs) > 0 {
+		t.Fatalf("query failed: %s", errs[0].Message)
+	}
+	have, _ := json.Marshal(data)
+	want := `{"block":{"number":1,"parentNumber":{"number":0},"transactions":[{"hash":"` + tx.Hash().Hex() + `","logs":[{"account":{"address":"0x0000000000000000000000000000000000000202","balance":"0x3e8","transactionCount":` + fmt.Sprint(backend.state.GetNonce(common.HexToAddress("0x0202"))) + `},"topics":["` + common.HexToHash("0xaa").Hex() + `","` + common.HexToHash("0xbb").Hex() + `"]}],"status":1}]},"latest":{"__typename":"Block","number":2}}`
+	if string(have) != want {
+		t.Errorf("result mismatch:\nhave %s\nwant %s", have, want)
+	}
+}
+
+func TestLogsQuery(t *testing.T) {
+	backend, tx := newTestBackend(t)
+	h := NewHandler(backend)
+
+	for _, tt := range []struct {
+		filter string
+		logs   int
+	}{
+		{`{fromBlock: 0}`, 1},
+		{`{fromBlock: 0, addresses: ["0x0000000000000000000000000000000000000202"]}`, 1},
+		{`{fromBlock: 0, addresses: ["0x0000000000000000000000000000000000000303"]}`, 0},
+		{`{fromBlock: 0, topics: [[], ["` + common.HexToHash("0xbb").Hex() + `"]]}`, 1},
+		{`{fromBlock: 0, topics: [["` + common.HexToHash("0xbb").Hex() + `"]]}`, 0},
+		{`{fromBlock: 2}`, 0},
+	} {
+		data, errs := post(t, h, request{Query: `{ logs(filter: ` + tt.filter + `) { data transaction { hash } } }`})
+		if len(errs) > 0 {
+			t.Fatalf("filter %s: query failed: %s", tt.filter, errs[0].Message)
+		}
+		logs := data["logs"].([]interface{})
+		if len(logs) != tt.logs {
+			t.Errorf("filter %s: log count mismatch: have %d, want %d", tt.filter, len(logs), tt.logs)
+			continue
+		}
+		if tt.logs > 0 {
+			have, _ := json.Marshal(logs[0])
+			if want := `{"data":"0x0102","transaction":{"hash":"` + tx.Hash().Hex() + `"}}`; string(have) != want {
+				t.Errorf("filter %s: log mismatch: have %s, want %s", tt.filter, have, want)
+			}
+		}
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	backend, _ := newTestBackend(t)
+	h := NewHandler(backend)
+
+	tests := []struct {
+		query string
+		err   string
+	}{
+		{`{ block { number`, "unexpected end of document"},
+		{`{ block { size } }`, `cannot query field "size" on type "Block"`},
+		{`{ block }`, "must have a selection of subfields"},
+		{`{ block { number { value } } }`, "must not have a selection"},
+		{`{ block(number: 1, hash: "0x00") { number } }`, "invalid argument"},
+		{`{ blocks(from: 0, to: 5000) { number } }`, "block range exceeds"},
+		{`mutation { block { number } }`, "mutation operations are not supported"},
+		{`{ block { ...missing } }`, `unknown fragment "missing"`},
+	}
+	for _, tt := range tests {
+		_, errs := post(t, h, request{Query: tt.query})
+		if len(errs) == 0 || !strings.Contains(errs[0].Message, tt.err) {
+			t.Errorf("query %s: error mismatch: have %v, want %q", tt.query, errs, tt.err)
+		}
+	}
+	// Failing fields are null, the rest of the query is still answered
+	data, errs := post(t, h, request{Query: `{ a: block { number } b: block { size } }`})
+	if len(errs) != 1 || data["a"] == nil || data["b"].(map[string]interface{})["size"] != nil {
+		t.Errorf("partial result mismatch: have %v, errors %v", data, errs)
+	}
+}
+
+func TestQueryDepthLimit(t *testing.T) {
+	backend, _ := newTestBackend(t)
+	h := NewHandler(backend)
+
+	q := "number"
+	for i := 0; i < maxQueryDepth+1; i++ {
+		q = "parent { " + q + " }"
+	}
+	_, errs := post(t, h, request{Query: "{ block { " + q + " } }"})
+	if len(errs) == 0 || !strings.Contains(errs[0].Message, "maximum depth") {
+		t.Errorf("deep query not rejected: %v", errs)
+	}
+}
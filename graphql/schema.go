@@ -0,0 +1,845 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/common/hexutil"
+	"github.com/matrix/go-matrix/core/rawdb"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/rpc"
+)
+
+// Limits of the block ranges a single query can scan.
+const (
+	maxBlockRange = 1000
+	maxLogRange   = 10000
+)
+
+// Schema describes the types and fields served, in the GraphQL schema
+// language. Long values are JSON numbers, BigInt values are hex encoded
+// quantities and Bytes, Bytes32 and Address values hex encoded data.
+const Schema = `
+scalar Long
+scalar BigInt
+scalar Bytes
+scalar Bytes32
+scalar Address
+
+type Query {
+    # The block with the given number or hash, the latest block by default.
+    block(number: Long, hash: Bytes32): Block
+    # The blocks from one number up to another one, inclusive, or up to the latest block.
+    blocks(from: Long!, to: Long): [Block!]!
+    # A transaction of the chain or the transaction pool.
+    transaction(hash: Bytes32!): Transaction
+    # The logs matching a filter, in the latest block by default.
+    logs(filter: FilterCriteria!): [Log!]!
+    # An account at a block, the latest block by default.
+    account(address: Address!, blockNumber: Long): Account!
+}
+
+input FilterCriteria {
+    fromBlock: Long
+    toBlock: Long
+    addresses: [Address!]
+    # Per position, the topics a log may have there. Empty lists match any topic.
+    topics: [[Bytes32!]!]
+}
+
+input BlockFilterCriteria {
+    addresses: [Address!]
+    topics: [[Bytes32!]!]
+}
+
+type Block {
+    number: Long!
+    hash: Bytes32!
+    parent: Block
+    nonce: Long!
+    transactionsRoot: Bytes32!
+    stateRoot: Bytes32!
+    receiptsRoot: Bytes32!
+    miner: Account!
+    extraData: Bytes!
+    gasLimit: Long!
+    gasUsed: Long!
+    timestamp: BigInt!
+    logsBloom: Bytes!
+    difficulty: BigInt!
+    transactionCount: Int!
+    transactions: [Transaction!]!
+    transactionAt(index: Int!): Transaction
+    logs(filter: BlockFilterCriteria!): [Log!]!
+    account(address: Address!): Account!
+}
+
+type Transaction {
+    hash: Bytes32!
+    nonce: Long!
+    # The index in the block, null for pending transactions.
+    index: Int
+    from: Account!
+    # The recipient, null for contract creations.
+    to: Account
+    value: BigInt!
+    gasPrice: BigInt!
+    gas: Long!
+    inputData: Bytes!
+    # The block including the transaction, null for pending transactions.
+    block: Block
+    # Receipt fields, null for pending transactions.
+    status: Long
+    gasUsed: Long
+    cumulativeGasUsed: Long
+    createdContract: Account
+    logs: [Log!]
+}
+
+type Log {
+    index: Int!
+    account: Account!
+    topics: [Bytes32!]!
+    data: Bytes!
+    transaction: Transaction!
+}
+
+type Account {
+    address: Address!
+    balance: BigInt!
+    transactionCount: Long!
+    code: Bytes!
+    storage(slot: Bytes32!): Bytes32!
+}
+`
+
+// arguments are the arguments of a field, with variables substituted.
+type arguments map[string]interface{}
+
+// long returns a Long argument, which may also be given as a decimal or
+// hex encoded string.
+func (a arguments) long(name string) (uint64, bool, error) {
+	switch v := a[name].(type) {
+	case nil:
+		return 0, false, nil
+	case int64:
+		if v >= 0 {
+			return uint64(v), true, nil
+		}
+	case float64:
+		if v >= 0 && v <= math.MaxUint64 && v == math.Trunc(v) {
+			return uint64(v), true, nil
+		}
+	case string:
+		if strings.HasPrefix(v, "0x") {
+			n, err := hexutil.DecodeUint64(v)
+			return n, err == nil, argError(name, err)
+		}
+		n, err := strconv.ParseUint(v, 10, 64)
+		return n, err == nil, argError(name, err)
+	}
+	return 0, false, fmt.Errorf("argument %q must be a non-negative integer", name)
+}
+
+// int returns an Int argument.
+func (a arguments) int(name string) (int, bool, error) {
+	n, ok, err := a.long(name)
+	if err == nil && n > math.MaxInt32 {
+		err = fmt.Errorf("argument %q out of range", name)
+	}
+	return int(n), ok, err
+}
+
+// hash returns a Bytes32 argument.
+func (a arguments) hash(name string) (common.Hash, bool, error) {
+	var h common.Hash
+	switch v := a[name].(type) {
+	case nil:
+		return h, false, nil
+	case string:
+		err := h.UnmarshalText([]byte(v))
+		return h, err == nil, argError(name, err)
+	}
+	return h, false, fmt.Errorf("argument %q must be a hex string", name)
+}
+
+// address returns an Address argument.
+func (a arguments) address(name string) (common.Address, bool, error) {
+	var addr common.Address
+	switch v := a[name].(type) {
+	case nil:
+		return addr, false, nil
+	case string:
+		err := addr.UnmarshalText([]byte(v))
+		return addr, err == nil, argError(name, err)
+	}
+	return addr, false, fmt.Errorf("argument %q must be a hex string", name)
+}
+
+// object returns an input object argument.
+func (a arguments) object(name string) (arguments, bool, error) {
+	switch v := a[name].(type) {
+	case nil:
+		return nil, false, nil
+	case map[string]interface{}:
+		return arguments(v), true, nil
+	}
+	return nil, false, fmt.Errorf("argument %q must be an object", name)
+}
+
+// addresses returns an [Address!] argument.
+func (a arguments) addresses(name string) ([]common.Address, error) {
+	list, ok := a[name].([]interface{})
+	if !ok {
+		if a[name] == nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("argument %q must be a list", name)
+	}
+	addrs := make([]common.Address, len(list))
+	for i, elem := range list {
+		var err error
+		if addrs[i], _, err = (arguments{name: elem}).address(name); err != nil {
+			return nil, err
+		}
+	}
+	return addrs, nil
+}
+
+// topics returns a [[Bytes32!]!] argument.
+func (a arguments) topics(name string) ([][]common.Hash, error) {
+	list, ok := a[name].([]interface{})
+	if !ok {
+		if a[name] == nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("argument %q must be a list of lists", name)
+	}
+	topics := make([][]common.Hash, len(list))
+	for i, elem := range list {
+		alternatives, ok := elem.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("argument %q must be a list of lists", name)
+		}
+		for _, alt := range alternatives {
+			h, _, err := (arguments{name: alt}).hash(name)
+			if err != nil {
+				return nil, err
+			}
+			topics[i] = append(topics[i], h)
+		}
+	}
+	return topics, nil
+}
+
+func argError(name string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("invalid argument %q: %v", name, err)
+}
+
+// query is the root object of queries.
+type query struct {
+	backend Backend
+}
+
+func (q *query) typeName() string { return "Query" }
+
+func (q *query) resolve(ctx context.Context, field string, args arguments) (interface{}, error) {
+	switch field {
+	case "block":
+		number, byNumber, err := args.long("number")
+		if err != nil {
+			return nil, err
+		}
+		hash, byHash, err := args.hash("hash")
+		if err != nil {
+			return nil, err
+		}
+		var block *types.Block
+		switch {
+		case byNumber && byHash:
+			return nil, errors.New("only one of number and hash may be given")
+		case byHash:
+			block, err = q.backend.GetBlock(ctx, hash)
+		case byNumber:
+			block, err = q.backend.BlockByNumber(ctx, rpc.BlockNumber(number))
+		default:
+			block, err = q.backend.BlockByNumber(ctx, rpc.LatestBlockNumber)
+		}
+		return newBlock(q.backend, block), err
+
+	case "blocks":
+		from, ok, err := args.long("from")
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.New("argument \"from\" required")
+		}
+		to, ok, err := args.long("to")
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			to = q.latest(ctx)
+		}
+		if to >= from && to-from >= maxBlockRange {
+			return nil, fmt.Errorf("block range exceeds %d blocks", maxBlockRange)
+		}
+		blocks := []object{}
+		for n := from; n <= to; n++ {
+			block, err := q.backend.BlockByNumber(ctx, rpc.BlockNumber(n))
+			if err != nil {
+				return nil, err
+			}
+			if block == nil {
+				break
+			}
+			blocks = append(blocks, newBlock(q.backend, block))
+		}
+		return blocks, nil
+
+	case "transaction":
+		hash, ok, err := args.hash("hash")
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.New("argument \"hash\" required")
+		}
+		if tx, blockHash, number, index := rawdb.ReadTransaction(q.backend.ChainDb(), hash); tx != nil {
+			return &transaction{backend: q.backend, tx: tx, blockHash: blockHash, blockNumber: number, index: index}, nil
+		}
+		if tx := q.backend.GetPoolTransaction(hash); tx != nil {
+			return &transaction{backend: q.backend, tx: tx, pending: true}, nil
+		}
+		return nil, nil
+
+	case "logs":
+		filter, ok, err := args.object("filter")
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.New("argument \"filter\" required")
+		}
+		latest := q.latest(ctx)
+		from, ok, err := filter.long("fromBlock")
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			from = latest
+		}
+		to, ok, err := filter.long("toBlock")
+		if err != nil {
+			return nil, err
+		}
+		if !ok || to > latest {
+			to = latest
+		}
+		if to >= from && to-from >= maxLogRange {
+			return nil, fmt.Errorf("block range exceeds %d blocks", maxLogRange)
+		}
+		crit, err := newLogCriteria(filter)
+		if err != nil {
+			return nil, err
+		}
+		logs := []object{}
+		for n := from; n <= to; n++ {
+			b, err := q.backend.BlockByNumber(ctx, rpc.BlockNumber(n))
+			if err != nil {
+				return nil, err
+			}
+			if b == nil {
+				break
+			}
+			matched, err := (&block{backend: q.backend, block: b}).logs(ctx, crit)
+			if err != nil {
+				return nil, err
+			}
+			logs = append(logs, matched...)
+		}
+		return logs, nil
+
+	case "account":
+		addr, ok, err := args.address("address")
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.New("argument \"address\" required")
+		}
+		number := rpc.LatestBlockNumber
+		if n, ok, err := args.long("blockNumber"); err != nil {
+			return nil, err
+		} else if ok {
+			number = rpc.BlockNumber(n)
+		}
+		return &account{backend: q.backend, address: addr, number: number}, nil
+	}
+	return nil, &unknownFieldError{q.typeName(), field}
+}
+
+// latest returns the number of the latest block.
+func (q *query) latest(ctx context.Context) uint64 {
+	header, err := q.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil || header == nil {
+		return 0
+	}
+	return header.Number.Uint64()
+}
+
+// block is a block of the chain.
+type block struct {
+	backend  Backend
+	block    *types.Block
+	receipts types.Receipts
+}
+
+// newBlock returns the object of a block, or nil if the block is unknown.
+func newBlock(backend Backend, b *types.Block) object {
+	if b == nil {
+		return nil
+	}
+	return &block{backend: backend, block: b}
+}
+
+func (b *block) typeName() string { return "Block" }
+
+func (b *block) resolve(ctx context.Context, field string, args arguments) (interface{}, error) {
+	switch field {
+	case "number":
+		return b.block.NumberU64(), nil
+	case "hash":
+		return b.block.Hash(), nil
+	case "parent":
+		if b.block.NumberU64() == 0 {
+			return nil, nil
+		}
+		parent, err := b.backend.GetBlock(ctx, b.block.ParentHash())
+		return newBlock(b.backend, parent), err
+	case "nonce":
+		return b.block.Nonce(), nil
+	case "transactionsRoot":
+		return b.block.TxHash(), nil
+	case "stateRoot":
+		return b.block.Root(), nil
+	case "receiptsRoot":
+		return b.block.ReceiptHash(), nil
+	case "miner":
+		return &account{backend: b.backend, address: b.block.Coinbase(), number: rpc.BlockNumber(b.block.NumberU64())}, nil
+	case "extraData":
+		return hexutil.Bytes(b.block.Extra()), nil
+	case "gasLimit":
+		return b.block.GasLimit(), nil
+	case "gasUsed":
+		return b.block.GasUsed(), nil
+	case "timestamp":
+		return (*hexutil.Big)(b.block.Time()), nil
+	case "logsBloom":
+		bloom := b.block.Bloom()
+		return hexutil.Bytes(bloom.Bytes()), nil
+	case "difficulty":
+		return (*hexutil.Big)(b.block.Difficulty()), nil
+	case "transactionCount":
+		return len(b.block.Transactions()), nil
+	case "transactions":
+		txs := make([]object, len(b.block.Transactions()))
+		for i := range txs {
+			txs[i] = b.transaction(i)
+		}
+		return txs, nil
+	case "transactionAt":
+		index, ok, err := args.int("index")
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.New("argument \"index\" required")
+		}
+		if index >= len(b.block.Transactions()) {
+			return nil, nil
+		}
+		return b.transaction(index), nil
+	case "logs":
+		filter, ok, err := args.object("filter")
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.New("argument \"filter\" required")
+		}
+		crit, err := newLogCriteria(filter)
+		if err != nil {
+			return nil, err
+		}
+		return b.logs(ctx, crit)
+	case "account":
+		addr, ok, err := args.address("address")
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.New("argument \"address\" required")
+		}
+		return &account{backend: b.backend, address: addr, number: rpc.BlockNumber(b.block.NumberU64())}, nil
+	}
+	return nil, &unknownFieldError{b.typeName(), field}
+}
+
+func (b *block) transaction(index int) *transaction {
+	return &transaction{
+		backend:     b.backend,
+		tx:          b.block.Transactions()[index],
+		blockHash:   b.block.Hash(),
+		blockNumber: b.block.NumberU64(),
+		index:       uint64(index),
+		block:       b,
+	}
+}
+
+// getReceipts returns the receipts of the block, loading them once.
+func (b *block) getReceipts(ctx context.Context) (types.Receipts, error) {
+	if b.receipts == nil {
+		receipts, err := b.backend.GetReceipts(ctx, b.block.Hash())
+		if err != nil {
+			return nil, err
+		}
+		b.receipts = receipts
+	}
+	return b.receipts, nil
+}
+
+// logs returns the logs of the block matching the criteria.
+func (b *block) logs(ctx context.Context, crit *logCriteria) ([]object, error) {
+	logs := []object{}
+	if !crit.mayMatch(b.block.Bloom()) {
+		return logs, nil
+	}
+	receipts, err := b.getReceipts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, receipt := range receipts {
+		if i >= len(b.block.Transactions()) {
+			break
+		}
+		for _, l := range receipt.Logs {
+			if crit.matches(l) {
+				logs = append(logs, &txLog{tx: b.transaction(i), log: l})
+			}
+		}
+	}
+	return logs, nil
+}
+
+// logCriteria selects logs by emitting contract and topics.
+type logCriteria struct {
+	addresses []common.Address
+	topics    [][]common.Hash
+}
+
+func newLogCriteria(filter arguments) (*logCriteria, error) {
+	addrs, err := filter.addresses("addresses")
+	if err != nil {
+		return nil, err
+	}
+	topics, err := filter.topics("topics")
+	if err != nil {
+		return nil, err
+	}
+	return &logCriteria{addresses: addrs, topics: topics}, nil
+}
+
+// mayMatch reports whether a block with the given bloom may contain
+// matching logs.
+func (c *logCriteria) mayMatch(bloom types.Bloom) bool {
+	if len(c.addresses) > 0 {
+		found := false
+		for _, addr := range c.addresses {
+			if types.BloomLookup(bloom, addr) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, alternatives := range c.topics {
+		found := len(alternatives) == 0
+		for _, topic := range alternatives {
+			if types.BloomLookup(bloom, topic) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// matches reports whether a log matches the criteria.
+func (c *logCriteria) matches(l *types.Log) bool {
+	if len(c.addresses) > 0 {
+		found := false
+		for _, addr := range c.addresses {
+			if l.Address == addr {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(c.topics) > len(l.Topics) {
+		return false
+	}
+	for i, alternatives := range c.topics {
+		found := len(alternatives) == 0
+		for _, topic := range alternatives {
+			if l.Topics[i] == topic {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// transaction is a transaction of a block or of the transaction pool.
+type transaction struct {
+	backend     Backend
+	tx          *types.Transaction
+	blockHash   common.Hash
+	blockNumber uint64
+	index       uint64
+	pending     bool
+
+	block *block // the including block, loaded on demand
+}
+
+func (t *transaction) typeName() string { return "Transaction" }
+
+func (t *transaction) resolve(ctx context.Context, field string, args arguments) (interface{}, error) {
+	switch field {
+	case "hash":
+		return t.tx.Hash(), nil
+	case "nonce":
+		return t.tx.Nonce(), nil
+	case "index":
+		if t.pending {
+			return nil, nil
+		}
+		return t.index, nil
+	case "from":
+		var signer types.Signer = types.FrontierSigner{}
+		if t.tx.Protected() {
+			signer = types.NewEIP155Signer(t.tx.ChainId())
+		}
+		from, err := types.Sender(signer, t.tx)
+		if err != nil {
+			return nil, err
+		}
+		return t.account(from), nil
+	case "to":
+		if t.tx.To() == nil {
+			return nil, nil
+		}
+		return t.account(*t.tx.To()), nil
+	case "value":
+		return (*hexutil.Big)(t.tx.Value()), nil
+	case "gasPrice":
+		return (*hexutil.Big)(t.tx.GasPrice()), nil
+	case "gas":
+		return t.tx.Gas(), nil
+	case "inputData":
+		return hexutil.Bytes(t.tx.Data()), nil
+	case "block":
+		if t.pending {
+			return nil, nil
+		}
+		b, err := t.getBlock(ctx)
+		if b == nil {
+			return nil, err
+		}
+		return b, err
+	}
+
+	// The remaining fields are those of the receipt
+	switch field {
+	case "status", "gasUsed", "cumulativeGasUsed", "createdContract", "logs":
+	default:
+		return nil, &unknownFieldError{t.typeName(), field}
+	}
+	receipt, err := t.receipt(ctx)
+	if receipt == nil {
+		return nil, err
+	}
+	switch field {
+	case "status":
+		return receipt.Status, nil
+	case "gasUsed":
+		return receipt.GasUsed, nil
+	case "cumulativeGasUsed":
+		return receipt.CumulativeGasUsed, nil
+	case "createdContract":
+		if t.tx.To() != nil || receipt.ContractAddress == (common.Address{}) {
+			return nil, nil
+		}
+		return t.account(receipt.ContractAddress), nil
+	default:
+		logs := make([]object, len(receipt.Logs))
+		for i, l := range receipt.Logs {
+			logs[i] = &txLog{tx: t, log: l}
+		}
+		return logs, nil
+	}
+}
+
+// account returns an account as of the block including the transaction, or
+// the latest block for pending transactions.
+func (t *transaction) account(addr common.Address) *account {
+	number := rpc.LatestBlockNumber
+	if !t.pending {
+		number = rpc.BlockNumber(t.blockNumber)
+	}
+	return &account{backend: t.backend, address: addr, number: number}
+}
+
+func (t *transaction) getBlock(ctx context.Context) (*block, error) {
+	if t.block == nil {
+		b, err := t.backend.GetBlock(ctx, t.blockHash)
+		if b == nil {
+			return nil, err
+		}
+		t.block = &block{backend: t.backend, block: b}
+	}
+	return t.block, nil
+}
+
+// receipt returns the receipt of a mined transaction, or nil.
+func (t *transaction) receipt(ctx context.Context) (*types.Receipt, error) {
+	if t.pending {
+		return nil, nil
+	}
+	b, err := t.getBlock(ctx)
+	if b == nil {
+		return nil, err
+	}
+	receipts, err := b.getReceipts(ctx)
+	if err != nil || uint64(len(receipts)) <= t.index {
+		return nil, err
+	}
+	return receipts[t.index], nil
+}
+
+// txLog is a log emitted by a transaction.
+type txLog struct {
+	tx  *transaction
+	log *types.Log
+}
+
+func (l *txLog) typeName() string { return "Log" }
+
+func (l *txLog) resolve(ctx context.Context, field string, args arguments) (interface{}, error) {
+	switch field {
+	case "index":
+		return l.log.Index, nil
+	case "account":
+		return l.tx.account(l.log.Address), nil
+	case "topics":
+		topics := l.log.Topics
+		if topics == nil {
+			topics = []common.Hash{}
+		}
+		return topics, nil
+	case "data":
+		return hexutil.Bytes(l.log.Data), nil
+	case "transaction":
+		return l.tx, nil
+	}
+	return nil, &unknownFieldError{l.typeName(), field}
+}
+
+// account is an account at a block.
+type account struct {
+	backend Backend
+	address common.Address
+	number  rpc.BlockNumber
+}
+
+func (a *account) typeName() string { return "Account" }
+
+func (a *account) resolve(ctx context.Context, field string, args arguments) (interface{}, error) {
+	if field == "address" {
+		return a.address, nil
+	}
+	var slot common.Hash
+	switch field {
+	case "balance", "transactionCount", "code":
+	case "storage":
+		var (
+			ok  bool
+			err error
+		)
+		if slot, ok, err = args.hash("slot"); err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, errors.New("argument \"slot\" required")
+		}
+	default:
+		return nil, &unknownFieldError{a.typeName(), field}
+	}
+	state, _, err := a.backend.StateAndHeaderByNumber(ctx, a.number)
+	if state == nil || err != nil {
+		if err == nil {
+			err = fmt.Errorf("state of block %d not available", a.number)
+		}
+		return nil, err
+	}
+	switch field {
+	case "balance":
+		balance := state.GetBalance(a.address)
+		if balance == nil {
+			balance = new(big.Int)
+		}
+		return (*hexutil.Big)(balance), nil
+	case "transactionCount":
+		return state.GetNonce(a.address), nil
+	case "code":
+		return hexutil.Bytes(state.GetCode(a.address)), nil
+	default:
+		return state.GetState(a.address, slot), nil
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package graphql serves the chain data of a Matrix node over GraphQL, so that
+// blocks, transactions, logs and account state can be fetched together in one
+// nested query.
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/core/state"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/log"
+	"github.com/matrix/go-matrix/mandb"
+	"github.com/matrix/go-matrix/p2p"
+	"github.com/matrix/go-matrix/rpc"
+)
+
+// maxRequestContentLength is the maximum size of a request accepted.
+const maxRequestContentLength = 1024 * 128
+
+// Backend is the part of the node API the GraphQL service reads the chain
+// through. It is implemented by the API backends of the full and light
+// clients.
+type Backend interface {
+	ChainDb() mandb.Database
+	HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error)
+	BlockByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Block, error)
+	StateAndHeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDB, *types.Header, error)
+	GetBlock(ctx context.Context, blockHash common.Hash) (*types.Block, error)
+	GetReceipts(ctx context.Context, blockHash common.Hash) (types.Receipts, error)
+	GetPoolTransaction(txHash common.Hash) *types.Transaction
+}
+
+// Service is a node service serving GraphQL queries over HTTP.
+type Service struct {
+	endpoint string       // interface and port to listen at
+	cors     []string     // allowed CORS domains
+	vhosts   []string     // recognised virtual hosts
+	handler  http.Handler // handler serving the queries
+	listener net.Listener // listener of the HTTP server, nil if stopped
+}
+
+// New constructs a GraphQL service for the backend, listening at endpoint once
+// started.
+func New(backend Backend, endpoint string, cors, vhosts []string) (*Service, error) {
+	return &Service{
+		endpoint: endpoint,
+		cors:     cors,
+		vhosts:   vhosts,
+		handler:  NewHandler(backend),
+	}, nil
+}
+
+// Protocols implements node.Service, returning no p2p protocols.
+func (s *Service) Protocols() []p2p.Protocol { return nil }
+
+// APIs implements node.Service, returning no RPC APIs.
+func (s *Service) APIs() []rpc.API { return nil }
+
+// Start implements node.Service, starting the HTTP server.
+func (s *Service) Start(server *p2p.Server) error {
+	listener, err := net.Listen("tcp", s.endpoint)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+	go (&http.Server{Handler: rpc.NewHTTPHandlerStack(s.handler, s.cors, s.vhosts)}).Serve(listener)
+	log.Info("GraphQL endpoint opened", "url", fmt.Sprintf("http://%s", s.endpoint), "cors", strings.Join(s.cors, ","), "vhosts", strings.Join(s.vhosts, ","))
+	return nil
+}
+
+// Stop implements node.Service, stopping the HTTP server.
+func (s *Service) Stop() error {
+	if s.listener != nil {
+		s.listener.Close()
+		s.listener = nil
+		log.Info("GraphQL endpoint closed", "url", fmt.Sprintf("http://%s", s.endpoint))
+	}
+	return nil
+}
+
+// handler serves GraphQL requests sent as JSON in the body of POST requests,
+// or in the query, operationName and variables parameters of GET requests.
+type handler struct {
+	root object
+}
+
+// NewHandler returns an HTTP handler serving GraphQL queries of the chain
+// data of the backend.
+func NewHandler(backend Backend) http.Handler {
+	return &handler{root: &query{backend: backend}}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	switch r.Method {
+	case http.MethodGet:
+		params := r.URL.Query()
+		req.Query = params.Get("query")
+		req.OperationName = params.Get("operationName")
+		if vars := params.Get("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				http.Error(w, "invalid variables: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+	case http.MethodPost:
+		if r.ContentLength > maxRequestContentLength {
+			http.Error(w, fmt.Sprintf("content length too large (%d>%d)", r.ContentLength, maxRequestContentLength), http.StatusRequestEntityTooLarge)
+			return
+		}
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestContentLength))
+		dec.UseNumber()
+		if err := dec.Decode(&req); err != nil {
+			http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.Variables = normalizeNumbers(req.Variables).(map[string]interface{})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if req.Query == "" {
+		http.Error(w, "query required", http.StatusBadRequest)
+		return
+	}
+	resp := execute(r.Context(), h.root, &req)
+
+	w.Header().Set("Content-Type", "application/json")
+	if resp.Data == nil {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// normalizeNumbers converts the numbers of decoded JSON variables to the
+// int64 and float64 values of literals in queries.
+func normalizeNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = normalizeNumbers(elem)
+		}
+	case map[string]interface{}:
+		for k, elem := range v {
+			v[k] = normalizeNumbers(elem)
+		}
+	}
+	return v
+}
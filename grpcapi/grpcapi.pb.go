@@ -0,0 +1,1746 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: grpcapi.proto
+
+/*
+Package grpcapi is a generated protocol buffer package.
+
+It is generated from these files:
+
+	grpcapi.proto
+
+It has these top-level messages:
+
+	Empty
+	Uint64Value
+	BoolValue
+	StringValue
+	BytesValue
+	BigInt
+	Hash
+	BlockNumber
+	AccountRequest
+	StorageRequest
+	BlockRequest
+	HashRequest
+	CallMsg
+	CallRequest
+	Header
+	Block
+	Transaction
+	Log
+	Receipt
+	Topics
+	LogFilter
+*/
+package grpcapi
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+type BlockNumber_Tag int32
+
+const (
+	BlockNumber_LATEST   BlockNumber_Tag = 0
+	BlockNumber_PENDING  BlockNumber_Tag = 1
+	BlockNumber_EARLIEST BlockNumber_Tag = 2
+)
+
+var BlockNumber_Tag_name = map[int32]string{
+	0: "LATEST",
+	1: "PENDING",
+	2: "EARLIEST",
+}
+var BlockNumber_Tag_value = map[string]int32{
+	"LATEST":   0,
+	"PENDING":  1,
+	"EARLIEST": 2,
+}
+
+func (x BlockNumber_Tag) String() string {
+	return proto.EnumName(BlockNumber_Tag_name, int32(x))
+}
+func (BlockNumber_Tag) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{7, 0} }
+
+type Empty struct {
+}
+
+func (m *Empty) Reset()                    { *m = Empty{} }
+func (m *Empty) String() string            { return proto.CompactTextString(m) }
+func (*Empty) ProtoMessage()               {}
+func (*Empty) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
+type Uint64Value struct {
+	Value uint64 `protobuf:"varint,1,opt,name=value" json:"value,omitempty"`
+}
+
+func (m *Uint64Value) Reset()                    { *m = Uint64Value{} }
+func (m *Uint64Value) String() string            { return proto.CompactTextString(m) }
+func (*Uint64Value) ProtoMessage()               {}
+func (*Uint64Value) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+func (m *Uint64Value) GetValue() uint64 {
+	if m != nil {
+		return m.Value
+	}
+	return 0
+}
+
+type BoolValue struct {
+	Value bool `protobuf:"varint,1,opt,name=value" json:"value,omitempty"`
+}
+
+func (m *BoolValue) Reset()                    { *m = BoolValue{} }
+func (m *BoolValue) String() string            { return proto.CompactTextString(m) }
+func (*BoolValue) ProtoMessage()               {}
+func (*BoolValue) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+func (m *BoolValue) GetValue() bool {
+	if m != nil {
+		return m.Value
+	}
+	return false
+}
+
+type StringValue struct {
+	Value string `protobuf:"bytes,1,opt,name=value" json:"value,omitempty"`
+}
+
+func (m *StringValue) Reset()                    { *m = StringValue{} }
+func (m *StringValue) String() string            { return proto.CompactTextString(m) }
+func (*StringValue) ProtoMessage()               {}
+func (*StringValue) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+func (m *StringValue) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+type BytesValue struct {
+	Value []byte `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *BytesValue) Reset()                    { *m = BytesValue{} }
+func (m *BytesValue) String() string            { return proto.CompactTextString(m) }
+func (*BytesValue) ProtoMessage()               {}
+func (*BytesValue) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *BytesValue) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+type BigInt struct {
+	Value []byte `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *BigInt) Reset()                    { *m = BigInt{} }
+func (m *BigInt) String() string            { return proto.CompactTextString(m) }
+func (*BigInt) ProtoMessage()               {}
+func (*BigInt) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *BigInt) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+type Hash struct {
+	Value []byte `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *Hash) Reset()                    { *m = Hash{} }
+func (m *Hash) String() string            { return proto.CompactTextString(m) }
+func (*Hash) ProtoMessage()               {}
+func (*Hash) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+func (m *Hash) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+type BlockNumber struct {
+	// Types that are valid to be assigned to Block:
+	//	*BlockNumber_Number
+	//	*BlockNumber_Tag_
+	Block isBlockNumber_Block `protobuf_oneof:"block"`
+}
+
+func (m *BlockNumber) Reset()                    { *m = BlockNumber{} }
+func (m *BlockNumber) String() string            { return proto.CompactTextString(m) }
+func (*BlockNumber) ProtoMessage()               {}
+func (*BlockNumber) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+type isBlockNumber_Block interface{ isBlockNumber_Block() }
+
+type BlockNumber_Number struct {
+	Number uint64 `protobuf:"varint,1,opt,name=number,oneof"`
+}
+type BlockNumber_Tag_ struct {
+	Tag BlockNumber_Tag `protobuf:"varint,2,opt,name=tag,enum=grpcapi.BlockNumber_Tag,oneof"`
+}
+
+func (*BlockNumber_Number) isBlockNumber_Block() {}
+func (*BlockNumber_Tag_) isBlockNumber_Block()   {}
+
+func (m *BlockNumber) GetBlock() isBlockNumber_Block {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+func (m *BlockNumber) GetNumber() uint64 {
+	if x, ok := m.GetBlock().(*BlockNumber_Number); ok {
+		return x.Number
+	}
+	return 0
+}
+
+func (m *BlockNumber) GetTag() BlockNumber_Tag {
+	if x, ok := m.GetBlock().(*BlockNumber_Tag_); ok {
+		return x.Tag
+	}
+	return BlockNumber_LATEST
+}
+
+// XXX_OneofFuncs is for the internal use of the proto package.
+func (*BlockNumber) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
+	return _BlockNumber_OneofMarshaler, _BlockNumber_OneofUnmarshaler, _BlockNumber_OneofSizer, []interface{}{
+		(*BlockNumber_Number)(nil),
+		(*BlockNumber_Tag_)(nil),
+	}
+}
+
+func _BlockNumber_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
+	m := msg.(*BlockNumber)
+	// block
+	switch x := m.Block.(type) {
+	case *BlockNumber_Number:
+		b.EncodeVarint(1<<3 | proto.WireVarint)
+		b.EncodeVarint(uint64(x.Number))
+	case *BlockNumber_Tag_:
+		b.EncodeVarint(2<<3 | proto.WireVarint)
+		b.EncodeVarint(uint64(x.Tag))
+	case nil:
+	default:
+		return fmt.Errorf("BlockNumber.Block has unexpected type %T", x)
+	}
+	return nil
+}
+
+func _BlockNumber_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
+	m := msg.(*BlockNumber)
+	switch tag {
+	case 1: // block.number
+		if wire != proto.WireVarint {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeVarint()
+		m.Block = &BlockNumber_Number{x}
+		return true, err
+	case 2: // block.tag
+		if wire != proto.WireVarint {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeVarint()
+		m.Block = &BlockNumber_Tag_{BlockNumber_Tag(x)}
+		return true, err
+	default:
+		return false, nil
+	}
+}
+
+func _BlockNumber_OneofSizer(msg proto.Message) (n int) {
+	m := msg.(*BlockNumber)
+	// block
+	switch x := m.Block.(type) {
+	case *BlockNumber_Number:
+		n += proto.SizeVarint(1<<3 | proto.WireVarint)
+		n += proto.SizeVarint(uint64(x.Number))
+	case *BlockNumber_Tag_:
+		n += proto.SizeVarint(2<<3 | proto.WireVarint)
+		n += proto.SizeVarint(uint64(x.Tag))
+	case nil:
+	default:
+		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+	}
+	return n
+}
+
+type AccountRequest struct {
+	Address []byte       `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Block   *BlockNumber `protobuf:"bytes,2,opt,name=block" json:"block,omitempty"`
+}
+
+func (m *AccountRequest) Reset()                    { *m = AccountRequest{} }
+func (m *AccountRequest) String() string            { return proto.CompactTextString(m) }
+func (*AccountRequest) ProtoMessage()               {}
+func (*AccountRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+func (m *AccountRequest) GetAddress() []byte {
+	if m != nil {
+		return m.Address
+	}
+	return nil
+}
+
+func (m *AccountRequest) GetBlock() *BlockNumber {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+type StorageRequest struct {
+	Address []byte       `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Key     []byte       `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Block   *BlockNumber `protobuf:"bytes,3,opt,name=block" json:"block,omitempty"`
+}
+
+func (m *StorageRequest) Reset()                    { *m = StorageRequest{} }
+func (m *StorageRequest) String() string            { return proto.CompactTextString(m) }
+func (*StorageRequest) ProtoMessage()               {}
+func (*StorageRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+func (m *StorageRequest) GetAddress() []byte {
+	if m != nil {
+		return m.Address
+	}
+	return nil
+}
+
+func (m *StorageRequest) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *StorageRequest) GetBlock() *BlockNumber {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+type BlockRequest struct {
+	Block *BlockNumber `protobuf:"bytes,1,opt,name=block" json:"block,omitempty"`
+}
+
+func (m *BlockRequest) Reset()                    { *m = BlockRequest{} }
+func (m *BlockRequest) String() string            { return proto.CompactTextString(m) }
+func (*BlockRequest) ProtoMessage()               {}
+func (*BlockRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
+func (m *BlockRequest) GetBlock() *BlockNumber {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+type HashRequest struct {
+	Hash []byte `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+}
+
+func (m *HashRequest) Reset()                    { *m = HashRequest{} }
+func (m *HashRequest) String() string            { return proto.CompactTextString(m) }
+func (*HashRequest) ProtoMessage()               {}
+func (*HashRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+func (m *HashRequest) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+type CallMsg struct {
+	From     []byte `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To       []byte `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	Gas      uint64 `protobuf:"varint,3,opt,name=gas" json:"gas,omitempty"`
+	GasPrice []byte `protobuf:"bytes,4,opt,name=gas_price,json=gasPrice,proto3" json:"gas_price,omitempty"`
+	Value    []byte `protobuf:"bytes,5,opt,name=value,proto3" json:"value,omitempty"`
+	Data     []byte `protobuf:"bytes,6,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (m *CallMsg) Reset()                    { *m = CallMsg{} }
+func (m *CallMsg) String() string            { return proto.CompactTextString(m) }
+func (*CallMsg) ProtoMessage()               {}
+func (*CallMsg) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+func (m *CallMsg) GetFrom() []byte {
+	if m != nil {
+		return m.From
+	}
+	return nil
+}
+
+func (m *CallMsg) GetTo() []byte {
+	if m != nil {
+		return m.To
+	}
+	return nil
+}
+
+func (m *CallMsg) GetGas() uint64 {
+	if m != nil {
+		return m.Gas
+	}
+	return 0
+}
+
+func (m *CallMsg) GetGasPrice() []byte {
+	if m != nil {
+		return m.GasPrice
+	}
+	return nil
+}
+
+func (m *CallMsg) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *CallMsg) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+type CallRequest struct {
+	Msg   *CallMsg     `protobuf:"bytes,1,opt,name=msg" json:"msg,omitempty"`
+	Block *BlockNumber `protobuf:"bytes,2,opt,name=block" json:"block,omitempty"`
+}
+
+func (m *CallRequest) Reset()                    { *m = CallRequest{} }
+func (m *CallRequest) String() string            { return proto.CompactTextString(m) }
+func (*CallRequest) ProtoMessage()               {}
+func (*CallRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+func (m *CallRequest) GetMsg() *CallMsg {
+	if m != nil {
+		return m.Msg
+	}
+	return nil
+}
+
+func (m *CallRequest) GetBlock() *BlockNumber {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+type Header struct {
+	Hash             []byte `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	ParentHash       []byte `protobuf:"bytes,2,opt,name=parent_hash,json=parentHash,proto3" json:"parent_hash,omitempty"`
+	Number           uint64 `protobuf:"varint,3,opt,name=number" json:"number,omitempty"`
+	Timestamp        uint64 `protobuf:"varint,4,opt,name=timestamp" json:"timestamp,omitempty"`
+	Miner            []byte `protobuf:"bytes,5,opt,name=miner,proto3" json:"miner,omitempty"`
+	Leader           []byte `protobuf:"bytes,6,opt,name=leader,proto3" json:"leader,omitempty"`
+	StateRoot        []byte `protobuf:"bytes,7,opt,name=state_root,json=stateRoot,proto3" json:"state_root,omitempty"`
+	TransactionsRoot []byte `protobuf:"bytes,8,opt,name=transactions_root,json=transactionsRoot,proto3" json:"transactions_root,omitempty"`
+	ReceiptsRoot     []byte `protobuf:"bytes,9,opt,name=receipts_root,json=receiptsRoot,proto3" json:"receipts_root,omitempty"`
+	Difficulty       []byte `protobuf:"bytes,10,opt,name=difficulty,proto3" json:"difficulty,omitempty"`
+	GasLimit         uint64 `protobuf:"varint,11,opt,name=gas_limit,json=gasLimit" json:"gas_limit,omitempty"`
+	GasUsed          uint64 `protobuf:"varint,12,opt,name=gas_used,json=gasUsed" json:"gas_used,omitempty"`
+	ExtraData        []byte `protobuf:"bytes,13,opt,name=extra_data,json=extraData,proto3" json:"extra_data,omitempty"`
+}
+
+func (m *Header) Reset()                    { *m = Header{} }
+func (m *Header) String() string            { return proto.CompactTextString(m) }
+func (*Header) ProtoMessage()               {}
+func (*Header) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+func (m *Header) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+func (m *Header) GetParentHash() []byte {
+	if m != nil {
+		return m.ParentHash
+	}
+	return nil
+}
+
+func (m *Header) GetNumber() uint64 {
+	if m != nil {
+		return m.Number
+	}
+	return 0
+}
+
+func (m *Header) GetTimestamp() uint64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *Header) GetMiner() []byte {
+	if m != nil {
+		return m.Miner
+	}
+	return nil
+}
+
+func (m *Header) GetLeader() []byte {
+	if m != nil {
+		return m.Leader
+	}
+	return nil
+}
+
+func (m *Header) GetStateRoot() []byte {
+	if m != nil {
+		return m.StateRoot
+	}
+	return nil
+}
+
+func (m *Header) GetTransactionsRoot() []byte {
+	if m != nil {
+		return m.TransactionsRoot
+	}
+	return nil
+}
+
+func (m *Header) GetReceiptsRoot() []byte {
+	if m != nil {
+		return m.ReceiptsRoot
+	}
+	return nil
+}
+
+func (m *Header) GetDifficulty() []byte {
+	if m != nil {
+		return m.Difficulty
+	}
+	return nil
+}
+
+func (m *Header) GetGasLimit() uint64 {
+	if m != nil {
+		return m.GasLimit
+	}
+	return 0
+}
+
+func (m *Header) GetGasUsed() uint64 {
+	if m != nil {
+		return m.GasUsed
+	}
+	return 0
+}
+
+func (m *Header) GetExtraData() []byte {
+	if m != nil {
+		return m.ExtraData
+	}
+	return nil
+}
+
+type Block struct {
+	Header       *Header  `protobuf:"bytes,1,opt,name=header" json:"header,omitempty"`
+	Transactions [][]byte `protobuf:"bytes,2,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	Size         uint64   `protobuf:"varint,3,opt,name=size" json:"size,omitempty"`
+}
+
+func (m *Block) Reset()                    { *m = Block{} }
+func (m *Block) String() string            { return proto.CompactTextString(m) }
+func (*Block) ProtoMessage()               {}
+func (*Block) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+func (m *Block) GetHeader() *Header {
+	if m != nil {
+		return m.Header
+	}
+	return nil
+}
+
+func (m *Block) GetTransactions() [][]byte {
+	if m != nil {
+		return m.Transactions
+	}
+	return nil
+}
+
+func (m *Block) GetSize() uint64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+type Transaction struct {
+	Hash             []byte `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	BlockHash        []byte `protobuf:"bytes,2,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	BlockNumber      uint64 `protobuf:"varint,3,opt,name=block_number,json=blockNumber" json:"block_number,omitempty"`
+	TransactionIndex uint64 `protobuf:"varint,4,opt,name=transaction_index,json=transactionIndex" json:"transaction_index,omitempty"`
+	From             []byte `protobuf:"bytes,5,opt,name=from,proto3" json:"from,omitempty"`
+	To               []byte `protobuf:"bytes,6,opt,name=to,proto3" json:"to,omitempty"`
+	Nonce            uint64 `protobuf:"varint,7,opt,name=nonce" json:"nonce,omitempty"`
+	Gas              uint64 `protobuf:"varint,8,opt,name=gas" json:"gas,omitempty"`
+	GasPrice         []byte `protobuf:"bytes,9,opt,name=gas_price,json=gasPrice,proto3" json:"gas_price,omitempty"`
+	Value            []byte `protobuf:"bytes,10,opt,name=value,proto3" json:"value,omitempty"`
+	Input            []byte `protobuf:"bytes,11,opt,name=input,proto3" json:"input,omitempty"`
+}
+
+func (m *Transaction) Reset()                    { *m = Transaction{} }
+func (m *Transaction) String() string            { return proto.CompactTextString(m) }
+func (*Transaction) ProtoMessage()               {}
+func (*Transaction) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+func (m *Transaction) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+func (m *Transaction) GetBlockHash() []byte {
+	if m != nil {
+		return m.BlockHash
+	}
+	return nil
+}
+
+func (m *Transaction) GetBlockNumber() uint64 {
+	if m != nil {
+		return m.BlockNumber
+	}
+	return 0
+}
+
+func (m *Transaction) GetTransactionIndex() uint64 {
+	if m != nil {
+		return m.TransactionIndex
+	}
+	return 0
+}
+
+func (m *Transaction) GetFrom() []byte {
+	if m != nil {
+		return m.From
+	}
+	return nil
+}
+
+func (m *Transaction) GetTo() []byte {
+	if m != nil {
+		return m.To
+	}
+	return nil
+}
+
+func (m *Transaction) GetNonce() uint64 {
+	if m != nil {
+		return m.Nonce
+	}
+	return 0
+}
+
+func (m *Transaction) GetGas() uint64 {
+	if m != nil {
+		return m.Gas
+	}
+	return 0
+}
+
+func (m *Transaction) GetGasPrice() []byte {
+	if m != nil {
+		return m.GasPrice
+	}
+	return nil
+}
+
+func (m *Transaction) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *Transaction) GetInput() []byte {
+	if m != nil {
+		return m.Input
+	}
+	return nil
+}
+
+type Log struct {
+	Address          []byte   `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Topics           [][]byte `protobuf:"bytes,2,rep,name=topics,proto3" json:"topics,omitempty"`
+	Data             []byte   `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	BlockNumber      uint64   `protobuf:"varint,4,opt,name=block_number,json=blockNumber" json:"block_number,omitempty"`
+	BlockHash        []byte   `protobuf:"bytes,5,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	TransactionHash  []byte   `protobuf:"bytes,6,opt,name=transaction_hash,json=transactionHash,proto3" json:"transaction_hash,omitempty"`
+	TransactionIndex uint64   `protobuf:"varint,7,opt,name=transaction_index,json=transactionIndex" json:"transaction_index,omitempty"`
+	LogIndex         uint64   `protobuf:"varint,8,opt,name=log_index,json=logIndex" json:"log_index,omitempty"`
+	Removed          bool     `protobuf:"varint,9,opt,name=removed" json:"removed,omitempty"`
+}
+
+func (m *Log) Reset()                    { *m = Log{} }
+func (m *Log) String() string            { return proto.CompactTextString(m) }
+func (*Log) ProtoMessage()               {}
+func (*Log) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+func (m *Log) GetAddress() []byte {
+	if m != nil {
+		return m.Address
+	}
+	return nil
+}
+
+func (m *Log) GetTopics() [][]byte {
+	if m != nil {
+		return m.Topics
+	}
+	return nil
+}
+
+func (m *Log) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *Log) GetBlockNumber() uint64 {
+	if m != nil {
+		return m.BlockNumber
+	}
+	return 0
+}
+
+func (m *Log) GetBlockHash() []byte {
+	if m != nil {
+		return m.BlockHash
+	}
+	return nil
+}
+
+func (m *Log) GetTransactionHash() []byte {
+	if m != nil {
+		return m.TransactionHash
+	}
+	return nil
+}
+
+func (m *Log) GetTransactionIndex() uint64 {
+	if m != nil {
+		return m.TransactionIndex
+	}
+	return 0
+}
+
+func (m *Log) GetLogIndex() uint64 {
+	if m != nil {
+		return m.LogIndex
+	}
+	return 0
+}
+
+func (m *Log) GetRemoved() bool {
+	if m != nil {
+		return m.Removed
+	}
+	return false
+}
+
+type Receipt struct {
+	TransactionHash   []byte `protobuf:"bytes,1,opt,name=transaction_hash,json=transactionHash,proto3" json:"transaction_hash,omitempty"`
+	BlockHash         []byte `protobuf:"bytes,2,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	BlockNumber       uint64 `protobuf:"varint,3,opt,name=block_number,json=blockNumber" json:"block_number,omitempty"`
+	TransactionIndex  uint64 `protobuf:"varint,4,opt,name=transaction_index,json=transactionIndex" json:"transaction_index,omitempty"`
+	From              []byte `protobuf:"bytes,5,opt,name=from,proto3" json:"from,omitempty"`
+	To                []byte `protobuf:"bytes,6,opt,name=to,proto3" json:"to,omitempty"`
+	ContractAddress   []byte `protobuf:"bytes,7,opt,name=contract_address,json=contractAddress,proto3" json:"contract_address,omitempty"`
+	GasUsed           uint64 `protobuf:"varint,8,opt,name=gas_used,json=gasUsed" json:"gas_used,omitempty"`
+	CumulativeGasUsed uint64 `protobuf:"varint,9,opt,name=cumulative_gas_used,json=cumulativeGasUsed" json:"cumulative_gas_used,omitempty"`
+	Status            uint64 `protobuf:"varint,10,opt,name=status" json:"status,omitempty"`
+	Logs              []*Log `protobuf:"bytes,11,rep,name=logs" json:"logs,omitempty"`
+}
+
+func (m *Receipt) Reset()                    { *m = Receipt{} }
+func (m *Receipt) String() string            { return proto.CompactTextString(m) }
+func (*Receipt) ProtoMessage()               {}
+func (*Receipt) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *Receipt) GetTransactionHash() []byte {
+	if m != nil {
+		return m.TransactionHash
+	}
+	return nil
+}
+
+func (m *Receipt) GetBlockHash() []byte {
+	if m != nil {
+		return m.BlockHash
+	}
+	return nil
+}
+
+func (m *Receipt) GetBlockNumber() uint64 {
+	if m != nil {
+		return m.BlockNumber
+	}
+	return 0
+}
+
+func (m *Receipt) GetTransactionIndex() uint64 {
+	if m != nil {
+		return m.TransactionIndex
+	}
+	return 0
+}
+
+func (m *Receipt) GetFrom() []byte {
+	if m != nil {
+		return m.From
+	}
+	return nil
+}
+
+func (m *Receipt) GetTo() []byte {
+	if m != nil {
+		return m.To
+	}
+	return nil
+}
+
+func (m *Receipt) GetContractAddress() []byte {
+	if m != nil {
+		return m.ContractAddress
+	}
+	return nil
+}
+
+func (m *Receipt) GetGasUsed() uint64 {
+	if m != nil {
+		return m.GasUsed
+	}
+	return 0
+}
+
+func (m *Receipt) GetCumulativeGasUsed() uint64 {
+	if m != nil {
+		return m.CumulativeGasUsed
+	}
+	return 0
+}
+
+func (m *Receipt) GetStatus() uint64 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *Receipt) GetLogs() []*Log {
+	if m != nil {
+		return m.Logs
+	}
+	return nil
+}
+
+type Topics struct {
+	Topics [][]byte `protobuf:"bytes,1,rep,name=topics,proto3" json:"topics,omitempty"`
+}
+
+func (m *Topics) Reset()                    { *m = Topics{} }
+func (m *Topics) String() string            { return proto.CompactTextString(m) }
+func (*Topics) ProtoMessage()               {}
+func (*Topics) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+func (m *Topics) GetTopics() [][]byte {
+	if m != nil {
+		return m.Topics
+	}
+	return nil
+}
+
+type LogFilter struct {
+	Addresses [][]byte  `protobuf:"bytes,1,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	Topics    []*Topics `protobuf:"bytes,2,rep,name=topics" json:"topics,omitempty"`
+}
+
+func (m *LogFilter) Reset()                    { *m = LogFilter{} }
+func (m *LogFilter) String() string            { return proto.CompactTextString(m) }
+func (*LogFilter) ProtoMessage()               {}
+func (*LogFilter) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+
+func (m *LogFilter) GetAddresses() [][]byte {
+	if m != nil {
+		return m.Addresses
+	}
+	return nil
+}
+
+func (m *LogFilter) GetTopics() []*Topics {
+	if m != nil {
+		return m.Topics
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*Empty)(nil), "grpcapi.Empty")
+	proto.RegisterType((*Uint64Value)(nil), "grpcapi.Uint64Value")
+	proto.RegisterType((*BoolValue)(nil), "grpcapi.BoolValue")
+	proto.RegisterType((*StringValue)(nil), "grpcapi.StringValue")
+	proto.RegisterType((*BytesValue)(nil), "grpcapi.BytesValue")
+	proto.RegisterType((*BigInt)(nil), "grpcapi.BigInt")
+	proto.RegisterType((*Hash)(nil), "grpcapi.Hash")
+	proto.RegisterType((*BlockNumber)(nil), "grpcapi.BlockNumber")
+	proto.RegisterType((*AccountRequest)(nil), "grpcapi.AccountRequest")
+	proto.RegisterType((*StorageRequest)(nil), "grpcapi.StorageRequest")
+	proto.RegisterType((*BlockRequest)(nil), "grpcapi.BlockRequest")
+	proto.RegisterType((*HashRequest)(nil), "grpcapi.HashRequest")
+	proto.RegisterType((*CallMsg)(nil), "grpcapi.CallMsg")
+	proto.RegisterType((*CallRequest)(nil), "grpcapi.CallRequest")
+	proto.RegisterType((*Header)(nil), "grpcapi.Header")
+	proto.RegisterType((*Block)(nil), "grpcapi.Block")
+	proto.RegisterType((*Transaction)(nil), "grpcapi.Transaction")
+	proto.RegisterType((*Log)(nil), "grpcapi.Log")
+	proto.RegisterType((*Receipt)(nil), "grpcapi.Receipt")
+	proto.RegisterType((*Topics)(nil), "grpcapi.Topics")
+	proto.RegisterType((*LogFilter)(nil), "grpcapi.LogFilter")
+	proto.RegisterEnum("grpcapi.BlockNumber_Tag", BlockNumber_Tag_name, BlockNumber_Tag_value)
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for Man service
+
+type ManClient interface {
+	BlockNumber(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Uint64Value, error)
+	GasPrice(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*BigInt, error)
+	GetBalance(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*BigInt, error)
+	GetTransactionCount(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*Uint64Value, error)
+	GetCode(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*BytesValue, error)
+	GetStorageAt(ctx context.Context, in *StorageRequest, opts ...grpc.CallOption) (*BytesValue, error)
+	GetBlockByNumber(ctx context.Context, in *BlockRequest, opts ...grpc.CallOption) (*Block, error)
+	GetBlockByHash(ctx context.Context, in *HashRequest, opts ...grpc.CallOption) (*Block, error)
+	GetTransactionByHash(ctx context.Context, in *HashRequest, opts ...grpc.CallOption) (*Transaction, error)
+	GetTransactionReceipt(ctx context.Context, in *HashRequest, opts ...grpc.CallOption) (*Receipt, error)
+	Call(ctx context.Context, in *CallRequest, opts ...grpc.CallOption) (*BytesValue, error)
+	EstimateGas(ctx context.Context, in *CallMsg, opts ...grpc.CallOption) (*Uint64Value, error)
+	SendRawTransaction(ctx context.Context, in *BytesValue, opts ...grpc.CallOption) (*Hash, error)
+	SubscribeNewHeads(ctx context.Context, in *Empty, opts ...grpc.CallOption) (Man_SubscribeNewHeadsClient, error)
+	SubscribeLogs(ctx context.Context, in *LogFilter, opts ...grpc.CallOption) (Man_SubscribeLogsClient, error)
+}
+
+type manClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewManClient(cc *grpc.ClientConn) ManClient {
+	return &manClient{cc}
+}
+
+func (c *manClient) BlockNumber(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Uint64Value, error) {
+	out := new(Uint64Value)
+	err := grpc.Invoke(ctx, "/grpcapi.Man/BlockNumber", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *manClient) GasPrice(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*BigInt, error) {
+	out := new(BigInt)
+	err := grpc.Invoke(ctx, "/grpcapi.Man/GasPrice", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *manClient) GetBalance(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*BigInt, error) {
+	out := new(BigInt)
+	err := grpc.Invoke(ctx, "/grpcapi.Man/GetBalance", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *manClient) GetTransactionCount(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*Uint64Value, error) {
+	out := new(Uint64Value)
+	err := grpc.Invoke(ctx, "/grpcapi.Man/GetTransactionCount", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *manClient) GetCode(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*BytesValue, error) {
+	out := new(BytesValue)
+	err := grpc.Invoke(ctx, "/grpcapi.Man/GetCode", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *manClient) GetStorageAt(ctx context.Context, in *StorageRequest, opts ...grpc.CallOption) (*BytesValue, error) {
+	out := new(BytesValue)
+	err := grpc.Invoke(ctx, "/grpcapi.Man/GetStorageAt", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *manClient) GetBlockByNumber(ctx context.Context, in *BlockRequest, opts ...grpc.CallOption) (*Block, error) {
+	out := new(Block)
+	err := grpc.Invoke(ctx, "/grpcapi.Man/GetBlockByNumber", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *manClient) GetBlockByHash(ctx context.Context, in *HashRequest, opts ...grpc.CallOption) (*Block, error) {
+	out := new(Block)
+	err := grpc.Invoke(ctx, "/grpcapi.Man/GetBlockByHash", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *manClient) GetTransactionByHash(ctx context.Context, in *HashRequest, opts ...grpc.CallOption) (*Transaction, error) {
+	out := new(Transaction)
+	err := grpc.Invoke(ctx, "/grpcapi.Man/GetTransactionByHash", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *manClient) GetTransactionReceipt(ctx context.Context, in *HashRequest, opts ...grpc.CallOption) (*Receipt, error) {
+	out := new(Receipt)
+	err := grpc.Invoke(ctx, "/grpcapi.Man/GetTransactionReceipt", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *manClient) Call(ctx context.Context, in *CallRequest, opts ...grpc.CallOption) (*BytesValue, error) {
+	out := new(BytesValue)
+	err := grpc.Invoke(ctx, "/grpcapi.Man/Call", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *manClient) EstimateGas(ctx context.Context, in *CallMsg, opts ...grpc.CallOption) (*Uint64Value, error) {
+	out := new(Uint64Value)
+	err := grpc.Invoke(ctx, "/grpcapi.Man/EstimateGas", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *manClient) SendRawTransaction(ctx context.Context, in *BytesValue, opts ...grpc.CallOption) (*Hash, error) {
+	out := new(Hash)
+	err := grpc.Invoke(ctx, "/grpcapi.Man/SendRawTransaction", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *manClient) SubscribeNewHeads(ctx context.Context, in *Empty, opts ...grpc.CallOption) (Man_SubscribeNewHeadsClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Man_serviceDesc.Streams[0], c.cc, "/grpcapi.Man/SubscribeNewHeads", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &manSubscribeNewHeadsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Man_SubscribeNewHeadsClient interface {
+	Recv() (*Header, error)
+	grpc.ClientStream
+}
+
+type manSubscribeNewHeadsClient struct {
+	grpc.ClientStream
+}
+
+func (x *manSubscribeNewHeadsClient) Recv() (*Header, error) {
+	m := new(Header)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *manClient) SubscribeLogs(ctx context.Context, in *LogFilter, opts ...grpc.CallOption) (Man_SubscribeLogsClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Man_serviceDesc.Streams[1], c.cc, "/grpcapi.Man/SubscribeLogs", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &manSubscribeLogsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Man_SubscribeLogsClient interface {
+	Recv() (*Log, error)
+	grpc.ClientStream
+}
+
+type manSubscribeLogsClient struct {
+	grpc.ClientStream
+}
+
+func (x *manSubscribeLogsClient) Recv() (*Log, error) {
+	m := new(Log)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Server API for Man service
+
+type ManServer interface {
+	BlockNumber(context.Context, *Empty) (*Uint64Value, error)
+	GasPrice(context.Context, *Empty) (*BigInt, error)
+	GetBalance(context.Context, *AccountRequest) (*BigInt, error)
+	GetTransactionCount(context.Context, *AccountRequest) (*Uint64Value, error)
+	GetCode(context.Context, *AccountRequest) (*BytesValue, error)
+	GetStorageAt(context.Context, *StorageRequest) (*BytesValue, error)
+	GetBlockByNumber(context.Context, *BlockRequest) (*Block, error)
+	GetBlockByHash(context.Context, *HashRequest) (*Block, error)
+	GetTransactionByHash(context.Context, *HashRequest) (*Transaction, error)
+	GetTransactionReceipt(context.Context, *HashRequest) (*Receipt, error)
+	Call(context.Context, *CallRequest) (*BytesValue, error)
+	EstimateGas(context.Context, *CallMsg) (*Uint64Value, error)
+	SendRawTransaction(context.Context, *BytesValue) (*Hash, error)
+	SubscribeNewHeads(*Empty, Man_SubscribeNewHeadsServer) error
+	SubscribeLogs(*LogFilter, Man_SubscribeLogsServer) error
+}
+
+func RegisterManServer(s *grpc.Server, srv ManServer) {
+	s.RegisterService(&_Man_serviceDesc, srv)
+}
+
+func _Man_BlockNumber_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManServer).BlockNumber(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Man/BlockNumber",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManServer).BlockNumber(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Man_GasPrice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManServer).GasPrice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Man/GasPrice",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManServer).GasPrice(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Man_GetBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManServer).GetBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Man/GetBalance",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManServer).GetBalance(ctx, req.(*AccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Man_GetTransactionCount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManServer).GetTransactionCount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Man/GetTransactionCount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManServer).GetTransactionCount(ctx, req.(*AccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Man_GetCode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManServer).GetCode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Man/GetCode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManServer).GetCode(ctx, req.(*AccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Man_GetStorageAt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StorageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManServer).GetStorageAt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Man/GetStorageAt",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManServer).GetStorageAt(ctx, req.(*StorageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Man_GetBlockByNumber_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManServer).GetBlockByNumber(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Man/GetBlockByNumber",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManServer).GetBlockByNumber(ctx, req.(*BlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Man_GetBlockByHash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManServer).GetBlockByHash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Man/GetBlockByHash",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManServer).GetBlockByHash(ctx, req.(*HashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Man_GetTransactionByHash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManServer).GetTransactionByHash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Man/GetTransactionByHash",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManServer).GetTransactionByHash(ctx, req.(*HashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Man_GetTransactionReceipt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManServer).GetTransactionReceipt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Man/GetTransactionReceipt",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManServer).GetTransactionReceipt(ctx, req.(*HashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Man_Call_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CallRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManServer).Call(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Man/Call",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManServer).Call(ctx, req.(*CallRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Man_EstimateGas_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CallMsg)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManServer).EstimateGas(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Man/EstimateGas",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManServer).EstimateGas(ctx, req.(*CallMsg))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Man_SendRawTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BytesValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManServer).SendRawTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Man/SendRawTransaction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManServer).SendRawTransaction(ctx, req.(*BytesValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Man_SubscribeNewHeads_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ManServer).SubscribeNewHeads(m, &manSubscribeNewHeadsServer{stream})
+}
+
+type Man_SubscribeNewHeadsServer interface {
+	Send(*Header) error
+	grpc.ServerStream
+}
+
+type manSubscribeNewHeadsServer struct {
+	grpc.ServerStream
+}
+
+func (x *manSubscribeNewHeadsServer) Send(m *Header) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Man_SubscribeLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(LogFilter)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ManServer).SubscribeLogs(m, &manSubscribeLogsServer{stream})
+}
+
+type Man_SubscribeLogsServer interface {
+	Send(*Log) error
+	grpc.ServerStream
+}
+
+type manSubscribeLogsServer struct {
+	grpc.ServerStream
+}
+
+func (x *manSubscribeLogsServer) Send(m *Log) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _Man_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "grpcapi.Man",
+	HandlerType: (*ManServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "BlockNumber",
+			Handler:    _Man_BlockNumber_Handler,
+		},
+		{
+			MethodName: "GasPrice",
+			Handler:    _Man_GasPrice_Handler,
+		},
+		{
+			MethodName: "GetBalance",
+			Handler:    _Man_GetBalance_Handler,
+		},
+		{
+			MethodName: "GetTransactionCount",
+			Handler:    _Man_GetTransactionCount_Handler,
+		},
+		{
+			MethodName: "GetCode",
+			Handler:    _Man_GetCode_Handler,
+		},
+		{
+			MethodName: "GetStorageAt",
+			Handler:    _Man_GetStorageAt_Handler,
+		},
+		{
+			MethodName: "GetBlockByNumber",
+			Handler:    _Man_GetBlockByNumber_Handler,
+		},
+		{
+			MethodName: "GetBlockByHash",
+			Handler:    _Man_GetBlockByHash_Handler,
+		},
+		{
+			MethodName: "GetTransactionByHash",
+			Handler:    _Man_GetTransactionByHash_Handler,
+		},
+		{
+			MethodName: "GetTransactionReceipt",
+			Handler:    _Man_GetTransactionReceipt_Handler,
+		},
+		{
+			MethodName: "Call",
+			Handler:    _Man_Call_Handler,
+		},
+		{
+			MethodName: "EstimateGas",
+			Handler:    _Man_EstimateGas_Handler,
+		},
+		{
+			MethodName: "SendRawTransaction",
+			Handler:    _Man_SendRawTransaction_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeNewHeads",
+			Handler:       _Man_SubscribeNewHeads_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "SubscribeLogs",
+			Handler:       _Man_SubscribeLogs_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "grpcapi.proto",
+}
+
+// Client API for Net service
+
+type NetClient interface {
+	Version(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*StringValue, error)
+	PeerCount(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Uint64Value, error)
+	Listening(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*BoolValue, error)
+}
+
+type netClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewNetClient(cc *grpc.ClientConn) NetClient {
+	return &netClient{cc}
+}
+
+func (c *netClient) Version(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*StringValue, error) {
+	out := new(StringValue)
+	err := grpc.Invoke(ctx, "/grpcapi.Net/Version", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *netClient) PeerCount(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Uint64Value, error) {
+	out := new(Uint64Value)
+	err := grpc.Invoke(ctx, "/grpcapi.Net/PeerCount", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *netClient) Listening(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*BoolValue, error) {
+	out := new(BoolValue)
+	err := grpc.Invoke(ctx, "/grpcapi.Net/Listening", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Net service
+
+type NetServer interface {
+	Version(context.Context, *Empty) (*StringValue, error)
+	PeerCount(context.Context, *Empty) (*Uint64Value, error)
+	Listening(context.Context, *Empty) (*BoolValue, error)
+}
+
+func RegisterNetServer(s *grpc.Server, srv NetServer) {
+	s.RegisterService(&_Net_serviceDesc, srv)
+}
+
+func _Net_Version_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NetServer).Version(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Net/Version",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NetServer).Version(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Net_PeerCount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NetServer).PeerCount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Net/PeerCount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NetServer).PeerCount(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Net_Listening_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NetServer).Listening(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Net/Listening",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NetServer).Listening(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Net_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "grpcapi.Net",
+	HandlerType: (*NetServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Version",
+			Handler:    _Net_Version_Handler,
+		},
+		{
+			MethodName: "PeerCount",
+			Handler:    _Net_PeerCount_Handler,
+		},
+		{
+			MethodName: "Listening",
+			Handler:    _Net_Listening_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "grpcapi.proto",
+}
+
+func init() { proto.RegisterFile("grpcapi.proto", fileDescriptor0) }
+
+var fileDescriptor0 = []byte{
+	// 1274 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x57, 0x5b, 0x6f, 0xd4, 0xc6,
+	0x17, 0x67, 0x6f, 0xf6, 0xfa, 0x78, 0xb3, 0x2c, 0x43, 0xe0, 0xef, 0x3f, 0xb7, 0x06, 0xf3, 0x50,
+	0x28, 0x28, 0x40, 0x68, 0xd3, 0x8b, 0x2a, 0x55, 0x59, 0x48, 0x37, 0x91, 0x96, 0x08, 0x39, 0x81,
+	0xb7, 0x6a, 0x35, 0xf1, 0x0e, 0x5e, 0x0b, 0xaf, 0x67, 0xeb, 0x99, 0x05, 0xd2, 0x0f, 0x50, 0xa9,
+	0x52, 0x3f, 0x41, 0xd5, 0xd7, 0x7e, 0xb4, 0x7e, 0x86, 0xbe, 0x56, 0x73, 0xb1, 0x3d, 0x66, 0xbd,
+	0xa1, 0x7d, 0xeb, 0xdb, 0x9c, 0x73, 0x7e, 0x67, 0xce, 0x7d, 0x7c, 0x0c, 0x1b, 0x51, 0xb6, 0x08,
+	0xf1, 0x22, 0xde, 0x5e, 0x64, 0x94, 0x53, 0x64, 0x6b, 0xd2, 0xb7, 0xa1, 0xb3, 0x3f, 0x5f, 0xf0,
+	0x33, 0xff, 0x0e, 0xb8, 0x2f, 0xe3, 0x94, 0xef, 0x7e, 0xfe, 0x0a, 0x27, 0x4b, 0x82, 0x36, 0xa1,
+	0xf3, 0x56, 0x1c, 0xbc, 0xc6, 0x56, 0xe3, 0x6e, 0x3b, 0x50, 0x84, 0x7f, 0x1b, 0x9c, 0x21, 0xa5,
+	0x49, 0x0d, 0xa4, 0x9b, 0x43, 0xee, 0x80, 0x7b, 0xcc, 0xb3, 0x38, 0x8d, 0x6a, 0x40, 0x4e, 0x0e,
+	0xf2, 0x01, 0x86, 0x67, 0x9c, 0xb0, 0x1a, 0x4c, 0x2f, 0xc7, 0xdc, 0x02, 0x6b, 0x18, 0x47, 0x87,
+	0x29, 0x5f, 0x23, 0xbf, 0x01, 0xed, 0x03, 0xcc, 0x66, 0x6b, 0xa4, 0xbf, 0x36, 0xc0, 0x1d, 0x26,
+	0x34, 0x7c, 0x73, 0xb4, 0x9c, 0x9f, 0x92, 0x0c, 0x79, 0x60, 0xa5, 0xf2, 0xa4, 0x02, 0x3a, 0xb8,
+	0x10, 0x68, 0x1a, 0x3d, 0x80, 0x16, 0xc7, 0x91, 0xd7, 0xdc, 0x6a, 0xdc, 0xed, 0xef, 0x78, 0xdb,
+	0x79, 0x9e, 0x0c, 0xe5, 0xed, 0x13, 0x1c, 0x1d, 0x5c, 0x08, 0x04, 0xcc, 0x7f, 0x00, 0xad, 0x13,
+	0x1c, 0x21, 0x00, 0x6b, 0xbc, 0x77, 0xb2, 0x7f, 0x7c, 0x32, 0xb8, 0x80, 0x5c, 0xb0, 0x5f, 0xec,
+	0x1f, 0x3d, 0x3b, 0x3c, 0x1a, 0x0d, 0x1a, 0xa8, 0x07, 0xdd, 0xfd, 0xbd, 0x60, 0x7c, 0x28, 0x44,
+	0xcd, 0xa1, 0x0d, 0x9d, 0x53, 0x71, 0x8f, 0xff, 0x0a, 0xfa, 0x7b, 0x61, 0x48, 0x97, 0x29, 0x0f,
+	0xc8, 0x8f, 0x4b, 0xc2, 0x38, 0xf2, 0xc0, 0xc6, 0xd3, 0x69, 0x46, 0x18, 0xd3, 0x8e, 0xe7, 0x24,
+	0xfa, 0x4c, 0x2b, 0x49, 0x97, 0xdc, 0x9d, 0xcd, 0x3a, 0x97, 0x02, 0x7d, 0xef, 0x0c, 0xfa, 0xc7,
+	0x9c, 0x66, 0x38, 0x22, 0x1f, 0xbf, 0x77, 0x00, 0xad, 0x37, 0xe4, 0x4c, 0xde, 0xda, 0x0b, 0xc4,
+	0xb1, 0xb4, 0xd4, 0xfa, 0xb8, 0xa5, 0x6f, 0xa0, 0x27, 0xb9, 0xb9, 0x9d, 0x42, 0xb7, 0xf1, 0x71,
+	0xdd, 0xdb, 0xe0, 0x8a, 0x52, 0xe5, 0xaa, 0x08, 0xda, 0x33, 0xcc, 0x66, 0xda, 0x3f, 0x79, 0xf6,
+	0x7f, 0x6e, 0x80, 0xfd, 0x14, 0x27, 0xc9, 0x73, 0x16, 0x09, 0xf9, 0xeb, 0x8c, 0xce, 0x73, 0xb9,
+	0x38, 0xa3, 0x3e, 0x34, 0x39, 0xd5, 0xbe, 0x37, 0x39, 0x15, 0xc1, 0x44, 0x98, 0x49, 0xc7, 0xdb,
+	0x81, 0x38, 0xa2, 0xeb, 0xe0, 0x44, 0x98, 0x4d, 0x16, 0x59, 0x1c, 0x12, 0xaf, 0x2d, 0x81, 0xdd,
+	0x08, 0xb3, 0x17, 0x82, 0x2e, 0x9b, 0xa4, 0x63, 0x34, 0x89, 0x30, 0x34, 0xc5, 0x1c, 0x7b, 0x96,
+	0x32, 0x24, 0xce, 0xfe, 0x0f, 0xe0, 0x0a, 0x3f, 0x72, 0x5f, 0x7d, 0x68, 0xcd, 0x59, 0xa4, 0x83,
+	0x1c, 0x14, 0x41, 0x6a, 0x57, 0x03, 0x21, 0xfc, 0x57, 0x05, 0xfb, 0xa5, 0x05, 0xd6, 0x01, 0xc1,
+	0x53, 0x92, 0xd5, 0xa5, 0x01, 0x7d, 0x02, 0xee, 0x02, 0x67, 0x24, 0xe5, 0x13, 0x29, 0x52, 0xf1,
+	0x82, 0x62, 0xc9, 0x6e, 0xbf, 0x5a, 0xf4, 0xb1, 0x0a, 0x5d, 0x53, 0xe8, 0x06, 0x38, 0x3c, 0x9e,
+	0x13, 0xc6, 0xf1, 0x7c, 0x21, 0xa3, 0x6f, 0x07, 0x25, 0x43, 0x84, 0x3f, 0x8f, 0x53, 0x92, 0xe5,
+	0xe1, 0x4b, 0x42, 0xdc, 0x95, 0x48, 0x57, 0x74, 0x02, 0x34, 0x85, 0x6e, 0x02, 0x30, 0x8e, 0x39,
+	0x99, 0x64, 0x94, 0x72, 0xcf, 0x96, 0x32, 0x47, 0x72, 0x02, 0x4a, 0x39, 0xba, 0x0f, 0x97, 0x78,
+	0x86, 0x53, 0x86, 0x43, 0x1e, 0xd3, 0x94, 0x29, 0x54, 0x57, 0xa2, 0x06, 0xa6, 0x40, 0x82, 0xef,
+	0xc0, 0x46, 0x46, 0x42, 0x12, 0x2f, 0xb8, 0x06, 0x3a, 0x12, 0xd8, 0xcb, 0x99, 0x12, 0x74, 0x0b,
+	0x60, 0x1a, 0xbf, 0x7e, 0x1d, 0x87, 0xcb, 0x84, 0x9f, 0x79, 0xa0, 0x82, 0x2e, 0x39, 0x79, 0x69,
+	0x93, 0x78, 0x1e, 0x73, 0xcf, 0x95, 0xc1, 0x89, 0xd2, 0x8e, 0x05, 0x8d, 0xfe, 0x0f, 0xe2, 0x3c,
+	0x59, 0x32, 0x32, 0xf5, 0x7a, 0x52, 0x66, 0x47, 0x98, 0xbd, 0x64, 0x64, 0x2a, 0x02, 0x21, 0xef,
+	0x79, 0x86, 0x27, 0xb2, 0xca, 0x1b, 0x2a, 0x10, 0xc9, 0x79, 0x26, 0x4a, 0x3d, 0x83, 0x8e, 0xac,
+	0x10, 0xfa, 0x14, 0xac, 0x99, 0x4a, 0x84, 0xaa, 0xf3, 0xc5, 0xa2, 0x82, 0xaa, 0x54, 0x81, 0x16,
+	0x23, 0x1f, 0x7a, 0x66, 0x84, 0x5e, 0x73, 0xab, 0x25, 0x82, 0x31, 0x79, 0xa2, 0xac, 0x2c, 0xfe,
+	0x89, 0xe8, 0xfa, 0xc8, 0xb3, 0xff, 0x47, 0x13, 0xdc, 0x93, 0x12, 0x54, 0x5b, 0xfa, 0x9b, 0x00,
+	0xb2, 0x45, 0xcc, 0xca, 0x3b, 0x92, 0x23, 0x0b, 0x7f, 0x1b, 0x7a, 0x4a, 0x5c, 0x29, 0xbf, 0x7b,
+	0x6a, 0xbc, 0x71, 0xd5, 0xc2, 0x4c, 0xe2, 0x74, 0x4a, 0xde, 0xeb, 0x5e, 0x30, 0x0b, 0x73, 0x28,
+	0xf8, 0xc5, 0x90, 0x75, 0x56, 0x86, 0xcc, 0x2a, 0x86, 0x6c, 0x13, 0x3a, 0x29, 0x4d, 0x43, 0x22,
+	0x7b, 0xa0, 0x1d, 0x28, 0x22, 0x1f, 0xbd, 0xee, 0x9a, 0xd1, 0x73, 0xd6, 0x8d, 0x1e, 0x98, 0xa3,
+	0xb7, 0x09, 0x9d, 0x38, 0x5d, 0x2c, 0x55, 0x39, 0x7b, 0x81, 0x22, 0xfc, 0xdf, 0x9b, 0xd0, 0x1a,
+	0xd3, 0xe8, 0x9c, 0x47, 0xec, 0x2a, 0x58, 0x9c, 0x2e, 0xe2, 0x30, 0xcf, 0xbd, 0xa6, 0x8a, 0x51,
+	0x6e, 0x95, 0xa3, 0xbc, 0x92, 0xb2, 0xf6, 0x6a, 0xca, 0xaa, 0x49, 0xef, 0x7c, 0x98, 0xf4, 0x7b,
+	0x60, 0x26, 0x4e, 0x81, 0x54, 0x7a, 0x2e, 0x1a, 0x7c, 0x09, 0xad, 0x4d, 0xbe, 0xbd, 0x26, 0xf9,
+	0xd7, 0xc1, 0x49, 0x68, 0xa4, 0x41, 0x2a, 0x91, 0xdd, 0x84, 0x46, 0x4a, 0xe8, 0x81, 0x9d, 0x91,
+	0x39, 0x7d, 0x4b, 0xa6, 0x32, 0x97, 0xdd, 0x20, 0x27, 0xfd, 0xbf, 0x9a, 0x60, 0x07, 0x6a, 0x70,
+	0x6a, 0x5d, 0x6b, 0xd4, 0xbb, 0xf6, 0x1f, 0xec, 0xac, 0x7b, 0x30, 0x08, 0x69, 0xca, 0x33, 0x1c,
+	0xf2, 0x49, 0x5e, 0x69, 0xf5, 0xd0, 0x5c, 0xcc, 0xf9, 0x7b, 0xba, 0xe2, 0xe6, 0x7c, 0x77, 0xab,
+	0xf3, 0xbd, 0x0d, 0x97, 0xc3, 0xe5, 0x7c, 0x99, 0x60, 0x1e, 0xbf, 0x25, 0x93, 0x02, 0xe5, 0x48,
+	0xd4, 0xa5, 0x52, 0x34, 0xd2, 0xf8, 0xab, 0x60, 0x89, 0x67, 0x6c, 0xc9, 0x64, 0x2f, 0xb6, 0x03,
+	0x4d, 0xa1, 0x2d, 0x68, 0x27, 0x34, 0x62, 0x9e, 0xbb, 0xd5, 0xba, 0xeb, 0xee, 0xf4, 0x8a, 0xe9,
+	0x1f, 0xd3, 0x28, 0x90, 0x12, 0x7f, 0x0b, 0xac, 0x13, 0xd5, 0x68, 0x65, 0x03, 0x36, 0xcc, 0x06,
+	0xf4, 0x03, 0x70, 0xc6, 0x34, 0xfa, 0x3e, 0x4e, 0xb8, 0x7a, 0x8d, 0x75, 0x54, 0x24, 0xc7, 0x95,
+	0x0c, 0xf1, 0xdc, 0x18, 0x3d, 0x6c, 0x3e, 0x37, 0xca, 0x46, 0x7e, 0xe7, 0xce, 0x9f, 0x16, 0xb4,
+	0x9e, 0xe3, 0x14, 0x3d, 0xa9, 0xee, 0x32, 0xfd, 0x02, 0x2f, 0x57, 0xb7, 0x6b, 0xe5, 0x07, 0xc7,
+	0xdc, 0xe0, 0xee, 0x43, 0x77, 0x94, 0xcf, 0xe0, 0x87, 0x1a, 0xa5, 0x45, 0xbd, 0x62, 0xed, 0x02,
+	0x8c, 0x08, 0x1f, 0xe2, 0x04, 0x8b, 0x09, 0xff, 0x5f, 0x21, 0xae, 0x2e, 0x2d, 0xab, 0x7a, 0xcf,
+	0xe0, 0xf2, 0x88, 0x70, 0xe3, 0x69, 0x7b, 0x2a, 0xe0, 0xeb, 0x2f, 0xa8, 0x77, 0xf5, 0x4b, 0xb0,
+	0x47, 0x84, 0x3f, 0xa5, 0xd3, 0x73, 0x4c, 0x5f, 0x2e, 0x4d, 0x97, 0x9b, 0xe3, 0xb7, 0xd0, 0x1b,
+	0x11, 0xae, 0x37, 0xa0, 0x3d, 0xd3, 0x6e, 0x75, 0x2b, 0xaa, 0xd7, 0xfe, 0x1a, 0x06, 0x22, 0x68,
+	0x91, 0xd9, 0xe1, 0x99, 0xce, 0xed, 0x95, 0xea, 0xc7, 0x3b, 0xd7, 0xef, 0x57, 0xd9, 0x68, 0x17,
+	0xfa, 0xa5, 0xaa, 0x5a, 0x43, 0xcb, 0x6f, 0x46, 0xb9, 0xea, 0xac, 0xe8, 0x0d, 0x61, 0xb3, 0x9a,
+	0xaf, 0x73, 0xb5, 0x4b, 0xae, 0xa1, 0x81, 0xbe, 0x83, 0x2b, 0xd5, 0x3b, 0xf2, 0x27, 0xa1, 0xfe,
+	0x92, 0x72, 0x69, 0xc9, 0x71, 0x8f, 0xa1, 0x2d, 0xf6, 0x17, 0x03, 0x6f, 0x6c, 0x3c, 0xf5, 0xa9,
+	0xfa, 0x02, 0xdc, 0x7d, 0xc6, 0xe3, 0x39, 0xe6, 0x62, 0x98, 0xd0, 0xca, 0x22, 0xb4, 0xa6, 0xb0,
+	0x5f, 0x01, 0x3a, 0x26, 0xe9, 0x34, 0xc0, 0xef, 0xcc, 0x00, 0xea, 0x2c, 0x5c, 0xdb, 0xa8, 0x38,
+	0x8f, 0x76, 0xe1, 0xd2, 0xf1, 0xf2, 0x94, 0x85, 0x59, 0x7c, 0x4a, 0x8e, 0xc8, 0x3b, 0xf1, 0x1d,
+	0x66, 0xe7, 0xb4, 0xb1, 0xfa, 0x4e, 0x3f, 0x6a, 0xa0, 0x27, 0xb0, 0x51, 0xe8, 0x8d, 0x69, 0xc4,
+	0x10, 0x32, 0xa7, 0x59, 0x8d, 0xe7, 0xb5, 0xca, 0x84, 0x3f, 0x6a, 0xec, 0xfc, 0xd6, 0x80, 0xd6,
+	0x11, 0xe1, 0xe8, 0x21, 0xd8, 0xaf, 0x48, 0xc6, 0x84, 0x8f, 0xeb, 0x67, 0xcc, 0xfc, 0xbb, 0x79,
+	0x0c, 0xce, 0x0b, 0x42, 0x32, 0xd5, 0xf4, 0xff, 0x6c, 0x2c, 0x1f, 0x82, 0x33, 0x8e, 0x19, 0x27,
+	0x69, 0x9c, 0x46, 0x2b, 0x2a, 0xa5, 0xb3, 0xc5, 0x6f, 0xd6, 0xa9, 0x25, 0xff, 0xd8, 0x9e, 0xfc,
+	0x3d, 0x00, 0x76, 0xdb, 0x25, 0xdd, 0xc2, 0x0d, 0x00, 0x00,
+}
@@ -0,0 +1,567 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package grpcapi serves the man and net RPC namespaces of a node over gRPC.
+package grpcapi
+
+//go:generate protoc --go_out=plugins=grpc:. grpcapi.proto
+
+import (
+	"math/big"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/common/hexutil"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/rpc"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// subscriptionBuffer is the number of notifications buffered per subscription
+// before the stream to the gRPC client applies back pressure.
+const subscriptionBuffer = 64
+
+// Server implements the gRPC services by forwarding the requests to the
+// JSON-RPC APIs registered in an RPC server, typically the in-process handler
+// of a node. Only the methods mirrored by the services are reachable.
+type Server struct {
+	client *rpc.Client
+}
+
+// NewServer creates a gRPC API server on top of the given RPC handler.
+func NewServer(handler *rpc.Server) *Server {
+	return &Server{client: rpc.DialInProc(handler)}
+}
+
+// Register registers the Man and Net services with the gRPC server.
+func (s *Server) Register(server *grpc.Server) {
+	RegisterManServer(server, &manServer{s.client})
+	RegisterNetServer(server, &netServer{s.client})
+}
+
+// Close releases the connection to the RPC handler.
+func (s *Server) Close() {
+	s.client.Close()
+}
+
+// manServer implements the Man service.
+type manServer struct {
+	client *rpc.Client
+}
+
+func (s *manServer) BlockNumber(ctx context.Context, req *Empty) (*Uint64Value, error) {
+	var number hexutil.Big
+	if err := s.client.CallContext(ctx, &number, "man_blockNumber"); err != nil {
+		return nil, rpcError(err)
+	}
+	return &Uint64Value{Value: number.ToInt().Uint64()}, nil
+}
+
+func (s *manServer) GasPrice(ctx context.Context, req *Empty) (*BigInt, error) {
+	var price hexutil.Big
+	if err := s.client.CallContext(ctx, &price, "man_gasPrice"); err != nil {
+		return nil, rpcError(err)
+	}
+	return &BigInt{Value: bigBytes(price.ToInt())}, nil
+}
+
+func (s *manServer) GetBalance(ctx context.Context, req *AccountRequest) (*BigInt, error) {
+	addr, err := toAddress("address", req.Address)
+	if err != nil {
+		return nil, err
+	}
+	var balance hexutil.Big
+	if err := s.client.CallContext(ctx, &balance, "man_getBalance", addr, blockArg(req.Block)); err != nil {
+		return nil, rpcError(err)
+	}
+	return &BigInt{Value: bigBytes(balance.ToInt())}, nil
+}
+
+func (s *manServer) GetTransactionCount(ctx context.Context, req *AccountRequest) (*Uint64Value, error) {
+	addr, err := toAddress("address", req.Address)
+	if err != nil {
+		return nil, err
+	}
+	var nonce hexutil.Uint64
+	if err := s.client.CallContext(ctx, &nonce, "man_getTransactionCount", addr, blockArg(req.Block)); err != nil {
+		return nil, rpcError(err)
+	}
+	return &Uint64Value{Value: uint64(nonce)}, nil
+}
+
+func (s *manServer) GetCode(ctx context.Context, req *AccountRequest) (*BytesValue, error) {
+	addr, err := toAddress("address", req.Address)
+	if err != nil {
+		return nil, err
+	}
+	var code hexutil.Bytes
+	if err := s.client.CallContext(ctx, &code, "man_getCode", addr, blockArg(req.Block)); err != nil {
+		return nil, rpcError(err)
+	}
+	return &BytesValue{Value: code}, nil
+}
+
+func (s *manServer) GetStorageAt(ctx context.Context, req *StorageRequest) (*BytesValue, error) {
+	addr, err := toAddress("address", req.Address)
+	if err != nil {
+		return nil, err
+	}
+	key, err := toHash("key", req.Key)
+	if err != nil {
+		return nil, err
+	}
+	var value hexutil.Bytes
+	if err := s.client.CallContext(ctx, &value, "man_getStorageAt", addr, key.Hex(), blockArg(req.Block)); err != nil {
+		return nil, rpcError(err)
+	}
+	return &BytesValue{Value: value}, nil
+}
+
+func (s *manServer) GetBlockByNumber(ctx context.Context, req *BlockRequest) (*Block, error) {
+	var block *rpcBlock
+	if err := s.client.CallContext(ctx, &block, "man_getBlockByNumber", blockArg(req.Block), false); err != nil {
+		return nil, rpcError(err)
+	}
+	if block == nil {
+		return nil, status.Error(codes.NotFound, "block not found")
+	}
+	return block.proto(), nil
+}
+
+func (s *manServer) GetBlockByHash(ctx context.Context, req *HashRequest) (*Block, error) {
+	hash, err := toHash("hash", req.Hash)
+	if err != nil {
+		return nil, err
+	}
+	var block *rpcBlock
+	if err := s.client.CallContext(ctx, &block, "man_getBlockByHash", hash, false); err != nil {
+		return nil, rpcError(err)
+	}
+	if block == nil {
+		return nil, status.Error(codes.NotFound, "block not found")
+	}
+	return block.proto(), nil
+}
+
+func (s *manServer) GetTransactionByHash(ctx context.Context, req *HashRequest) (*Transaction, error) {
+	hash, err := toHash("hash", req.Hash)
+	if err != nil {
+		return nil, err
+	}
+	var tx *rpcTransaction
+	if err := s.client.CallContext(ctx, &tx, "man_getTransactionByHash", hash); err != nil {
+		return nil, rpcError(err)
+	}
+	if tx == nil {
+		return nil, status.Error(codes.NotFound, "transaction not found")
+	}
+	return tx.proto(), nil
+}
+
+func (s *manServer) GetTransactionReceipt(ctx context.Context, req *HashRequest) (*Receipt, error) {
+	hash, err := toHash("hash", req.Hash)
+	if err != nil {
+		return nil, err
+	}
+	var receipt *rpcReceipt
+	if err := s.client.CallContext(ctx, &receipt, "man_getTransactionReceipt", hash); err != nil {
+		return nil, rpcError(err)
+	}
+	if receipt == nil {
+		return nil, status.Error(codes.NotFound, "receipt not found")
+	}
+	return receipt.proto(), nil
+}
+
+func (s *manServer) Call(ctx context.Context, req *CallRequest) (*BytesValue, error) {
+	args, err := callArgs(req.Msg)
+	if err != nil {
+		return nil, err
+	}
+	var result hexutil.Bytes
+	if err := s.client.CallContext(ctx, &result, "man_call", args, blockArg(req.Block)); err != nil {
+		return nil, rpcError(err)
+	}
+	return &BytesValue{Value: result}, nil
+}
+
+func (s *manServer) EstimateGas(ctx context.Context, req *CallMsg) (*Uint64Value, error) {
+	args, err := callArgs(req)
+	if err != nil {
+		return nil, err
+	}
+	var gas hexutil.Uint64
+	if err := s.client.CallContext(ctx, &gas, "man_estimateGas", args); err != nil {
+		return nil, rpcError(err)
+	}
+	return &Uint64Value{Value: uint64(gas)}, nil
+}
+
+func (s *manServer) SendRawTransaction(ctx context.Context, req *BytesValue) (*Hash, error) {
+	var hash common.Hash
+	if err := s.client.CallContext(ctx, &hash, "man_sendRawTransaction", hexutil.Bytes(req.Value)); err != nil {
+		return nil, rpcError(err)
+	}
+	return &Hash{Value: hash.Bytes()}, nil
+}
+
+func (s *manServer) SubscribeNewHeads(req *Empty, stream Man_SubscribeNewHeadsServer) error {
+	ch := make(chan *rpcHeader, subscriptionBuffer)
+	sub, err := s.client.Subscribe(stream.Context(), "man", ch, "newHeads")
+	if err != nil {
+		return rpcError(err)
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case head := <-ch:
+			if err := stream.Send(head.proto()); err != nil {
+				return err
+			}
+		case err := <-sub.Err():
+			return rpcError(err)
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+func (s *manServer) SubscribeLogs(req *LogFilter, stream Man_SubscribeLogsServer) error {
+	crit, err := filterCriteria(req)
+	if err != nil {
+		return err
+	}
+	ch := make(chan *types.Log, subscriptionBuffer)
+	sub, err := s.client.Subscribe(stream.Context(), "man", ch, "logs", crit)
+	if err != nil {
+		return rpcError(err)
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case log := <-ch:
+			if err := stream.Send(logProto(log)); err != nil {
+				return err
+			}
+		case err := <-sub.Err():
+			return rpcError(err)
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// netServer implements the Net service.
+type netServer struct {
+	client *rpc.Client
+}
+
+func (s *netServer) Version(ctx context.Context, req *Empty) (*StringValue, error) {
+	var version string
+	if err := s.client.CallContext(ctx, &version, "net_version"); err != nil {
+		return nil, rpcError(err)
+	}
+	return &StringValue{Value: version}, nil
+}
+
+func (s *netServer) PeerCount(ctx context.Context, req *Empty) (*Uint64Value, error) {
+	var count hexutil.Uint
+	if err := s.client.CallContext(ctx, &count, "net_peerCount"); err != nil {
+		return nil, rpcError(err)
+	}
+	return &Uint64Value{Value: uint64(count)}, nil
+}
+
+func (s *netServer) Listening(ctx context.Context, req *Empty) (*BoolValue, error) {
+	var listening bool
+	if err := s.client.CallContext(ctx, &listening, "net_listening"); err != nil {
+		return nil, rpcError(err)
+	}
+	return &BoolValue{Value: listening}, nil
+}
+
+// rpcError converts an error of the RPC client into a gRPC status error.
+func rpcError(err error) error {
+	switch err {
+	case nil:
+		return nil
+	case context.Canceled:
+		return status.Error(codes.Canceled, err.Error())
+	case context.DeadlineExceeded:
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
+
+// blockArg converts a block selector into its JSON-RPC representation.
+func blockArg(block *BlockNumber) string {
+	if block == nil {
+		return "latest"
+	}
+	switch b := block.Block.(type) {
+	case *BlockNumber_Number:
+		return hexutil.EncodeUint64(b.Number)
+	case *BlockNumber_Tag_:
+		switch b.Tag {
+		case BlockNumber_PENDING:
+			return "pending"
+		case BlockNumber_EARLIEST:
+			return "earliest"
+		}
+	}
+	return "latest"
+}
+
+// toAddress converts a request field into an address, rejecting invalid lengths.
+func toAddress(field string, b []byte) (common.Address, error) {
+	if len(b) != common.AddressLength {
+		return common.Address{}, status.Errorf(codes.InvalidArgument, "invalid %s length %d, want %d", field, len(b), common.AddressLength)
+	}
+	return common.BytesToAddress(b), nil
+}
+
+// toHash converts a request field into a hash, rejecting invalid lengths.
+func toHash(field string, b []byte) (common.Hash, error) {
+	if len(b) != common.HashLength {
+		return common.Hash{}, status.Errorf(codes.InvalidArgument, "invalid %s length %d, want %d", field, len(b), common.HashLength)
+	}
+	return common.BytesToHash(b), nil
+}
+
+// bigBytes returns the big-endian representation of a quantity.
+func bigBytes(n *big.Int) []byte {
+	if n == nil {
+		return nil
+	}
+	return n.Bytes()
+}
+
+// hexBig converts a JSON-RPC quantity into its big-endian representation.
+func hexBig(n *hexutil.Big) []byte {
+	return bigBytes((*big.Int)(n))
+}
+
+// hexUint64 converts a JSON-RPC quantity into a uint64, treating a missing
+// field as zero.
+func hexUint64(n *hexutil.Big) uint64 {
+	if n == nil {
+		return 0
+	}
+	return (*big.Int)(n).Uint64()
+}
+
+// callArgs converts a call message into the arguments of man_call.
+func callArgs(msg *CallMsg) (map[string]interface{}, error) {
+	if msg == nil {
+		return nil, status.Error(codes.InvalidArgument, "missing call message")
+	}
+	args := map[string]interface{}{
+		"gas":      hexutil.Uint64(msg.Gas),
+		"gasPrice": (*hexutil.Big)(new(big.Int).SetBytes(msg.GasPrice)),
+		"value":    (*hexutil.Big)(new(big.Int).SetBytes(msg.Value)),
+		"data":     hexutil.Bytes(msg.Data),
+	}
+	if len(msg.From) > 0 {
+		from, err := toAddress("from", msg.From)
+		if err != nil {
+			return nil, err
+		}
+		args["from"] = from
+	}
+	if len(msg.To) > 0 {
+		to, err := toAddress("to", msg.To)
+		if err != nil {
+			return nil, err
+		}
+		args["to"] = to
+	}
+	return args, nil
+}
+
+// filterCriteria converts a log filter into the criteria of the logs subscription.
+func filterCriteria(filter *LogFilter) (map[string]interface{}, error) {
+	addrs := make([]common.Address, len(filter.Addresses))
+	for i, b := range filter.Addresses {
+		addr, err := toAddress("address", b)
+		if err != nil {
+			return nil, err
+		}
+		addrs[i] = addr
+	}
+	topics := make([][]common.Hash, len(filter.Topics))
+	for i, alternatives := range filter.Topics {
+		if len(alternatives.Topics) == 0 {
+			continue // wildcard
+		}
+		for _, b := range alternatives.Topics {
+			topic, err := toHash("topic", b)
+			if err != nil {
+				return nil, err
+			}
+			topics[i] = append(topics[i], topic)
+		}
+	}
+	return map[string]interface{}{"address": addrs, "topics": topics}, nil
+}
+
+// rpcHeader is the JSON-RPC representation of a header, as returned by the
+// block retrieval methods and the newHeads subscription.
+type rpcHeader struct {
+	Hash        common.Hash    `json:"hash"`
+	ParentHash  common.Hash    `json:"parentHash"`
+	Number      *hexutil.Big   `json:"number"`
+	Time        *hexutil.Big   `json:"timestamp"`
+	Miner       common.Address `json:"miner"`
+	Leader      common.Address `json:"leader"`
+	Root        common.Hash    `json:"stateRoot"`
+	TxHash      common.Hash    `json:"transactionsRoot"`
+	ReceiptHash common.Hash    `json:"receiptsRoot"`
+	Difficulty  *hexutil.Big   `json:"difficulty"`
+	GasLimit    hexutil.Uint64 `json:"gasLimit"`
+	GasUsed     hexutil.Uint64 `json:"gasUsed"`
+	Extra       hexutil.Bytes  `json:"extraData"`
+}
+
+func (h *rpcHeader) proto() *Header {
+	return &Header{
+		Hash:             h.Hash.Bytes(),
+		ParentHash:       h.ParentHash.Bytes(),
+		Number:           hexUint64(h.Number),
+		Timestamp:        hexUint64(h.Time),
+		Miner:            h.Miner.Bytes(),
+		Leader:           h.Leader.Bytes(),
+		StateRoot:        h.Root.Bytes(),
+		TransactionsRoot: h.TxHash.Bytes(),
+		ReceiptsRoot:     h.ReceiptHash.Bytes(),
+		Difficulty:       hexBig(h.Difficulty),
+		GasLimit:         uint64(h.GasLimit),
+		GasUsed:          uint64(h.GasUsed),
+		ExtraData:        h.Extra,
+	}
+}
+
+// rpcBlock is the JSON-RPC representation of a block with transaction hashes.
+type rpcBlock struct {
+	rpcHeader
+	Transactions []common.Hash  `json:"transactions"`
+	Size         hexutil.Uint64 `json:"size"`
+}
+
+func (b *rpcBlock) proto() *Block {
+	txs := make([][]byte, len(b.Transactions))
+	for i, hash := range b.Transactions {
+		txs[i] = hash.Bytes()
+	}
+	return &Block{Header: b.rpcHeader.proto(), Transactions: txs, Size: uint64(b.Size)}
+}
+
+// rpcTransaction is the JSON-RPC representation of a transaction.
+type rpcTransaction struct {
+	Hash             common.Hash     `json:"hash"`
+	BlockHash        common.Hash     `json:"blockHash"`
+	BlockNumber      *hexutil.Big    `json:"blockNumber"`
+	TransactionIndex hexutil.Uint    `json:"transactionIndex"`
+	From             common.Address  `json:"from"`
+	To               *common.Address `json:"to"`
+	Nonce            hexutil.Uint64  `json:"nonce"`
+	Gas              hexutil.Uint64  `json:"gas"`
+	GasPrice         *hexutil.Big    `json:"gasPrice"`
+	Value            *hexutil.Big    `json:"value"`
+	Input            hexutil.Bytes   `json:"input"`
+}
+
+func (tx *rpcTransaction) proto() *Transaction {
+	pb := &Transaction{
+		Hash:             tx.Hash.Bytes(),
+		TransactionIndex: uint64(tx.TransactionIndex),
+		From:             tx.From.Bytes(),
+		Nonce:            uint64(tx.Nonce),
+		Gas:              uint64(tx.Gas),
+		GasPrice:         hexBig(tx.GasPrice),
+		Value:            hexBig(tx.Value),
+		Input:            tx.Input,
+	}
+	if tx.BlockNumber != nil {
+		pb.BlockHash = tx.BlockHash.Bytes()
+		pb.BlockNumber = (*big.Int)(tx.BlockNumber).Uint64()
+	}
+	if tx.To != nil {
+		pb.To = tx.To.Bytes()
+	}
+	return pb
+}
+
+// rpcReceipt is the JSON-RPC representation of a transaction receipt.
+type rpcReceipt struct {
+	TransactionHash   common.Hash     `json:"transactionHash"`
+	BlockHash         common.Hash     `json:"blockHash"`
+	BlockNumber       hexutil.Uint64  `json:"blockNumber"`
+	TransactionIndex  hexutil.Uint64  `json:"transactionIndex"`
+	From              common.Address  `json:"from"`
+	To                *common.Address `json:"to"`
+	ContractAddress   *common.Address `json:"contractAddress"`
+	GasUsed           hexutil.Uint64  `json:"gasUsed"`
+	CumulativeGasUsed hexutil.Uint64  `json:"cumulativeGasUsed"`
+	Status            hexutil.Uint    `json:"status"`
+	Logs              []*types.Log    `json:"logs"`
+}
+
+func (r *rpcReceipt) proto() *Receipt {
+	pb := &Receipt{
+		TransactionHash:   r.TransactionHash.Bytes(),
+		BlockHash:         r.BlockHash.Bytes(),
+		BlockNumber:       uint64(r.BlockNumber),
+		TransactionIndex:  uint64(r.TransactionIndex),
+		From:              r.From.Bytes(),
+		GasUsed:           uint64(r.GasUsed),
+		CumulativeGasUsed: uint64(r.CumulativeGasUsed),
+		Status:            uint64(r.Status),
+		Logs:              make([]*Log, len(r.Logs)),
+	}
+	if r.To != nil {
+		pb.To = r.To.Bytes()
+	}
+	if r.ContractAddress != nil {
+		pb.ContractAddress = r.ContractAddress.Bytes()
+	}
+	for i, log := range r.Logs {
+		pb.Logs[i] = logProto(log)
+	}
+	return pb
+}
+
+// logProto converts a log into its protobuf representation.
+func logProto(log *types.Log) *Log {
+	topics := make([][]byte, len(log.Topics))
+	for i, topic := range log.Topics {
+		topics[i] = topic.Bytes()
+	}
+	return &Log{
+		Address:          log.Address.Bytes(),
+		Topics:           topics,
+		Data:             log.Data,
+		BlockNumber:      log.BlockNumber,
+		BlockHash:        log.BlockHash.Bytes(),
+		TransactionHash:  log.TxHash.Bytes(),
+		TransactionIndex: uint64(log.TxIndex),
+		LogIndex:         uint64(log.Index),
+		Removed:          log.Removed,
+	}
+}
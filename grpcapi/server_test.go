@@ -0,0 +1,207 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package grpcapi
+
+import (
+	"context"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/common/hexutil"
+	"github.com/matrix/go-matrix/rpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var testAddr = common.HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314")
+
+// TestManAPI is a stand-in for the man JSON-RPC namespace.
+type TestManAPI struct{}
+
+func (api *TestManAPI) BlockNumber() *big.Int { return big.NewInt(42) }
+
+func (api *TestManAPI) GetBalance(addr common.Address, block rpc.BlockNumber) (*big.Int, error) {
+	if addr != testAddr {
+		return new(big.Int), nil
+	}
+	if block == rpc.PendingBlockNumber {
+		return big.NewInt(2000), nil
+	}
+	return big.NewInt(1000), nil
+}
+
+func (api *TestManAPI) GetBlockByNumber(block rpc.BlockNumber, fullTx bool) (map[string]interface{}, error) {
+	if block != 7 {
+		return nil, nil
+	}
+	return map[string]interface{}{
+		"number":       (*hexutil.Big)(big.NewInt(7)),
+		"hash":         common.HexToHash("0x07"),
+		"timestamp":    (*hexutil.Big)(big.NewInt(1500000000)),
+		"gasLimit":     hexutil.Uint64(8000000),
+		"transactions": []common.Hash{common.HexToHash("0xaa")},
+	}, nil
+}
+
+func (api *TestManAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, _ := rpc.NotifierFromContext(ctx)
+	sub := notifier.CreateSubscription()
+	go func() {
+		// Notifications are dropped until the subscription is activated, keep
+		// sending until the client goes away.
+		for i := int64(1); ; i++ {
+			head := map[string]interface{}{"number": (*hexutil.Big)(big.NewInt(i))}
+			notifier.Notify(sub.ID, head)
+			select {
+			case <-sub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}()
+	return sub, nil
+}
+
+// TestNetAPI is a stand-in for the net JSON-RPC namespace.
+type TestNetAPI struct{}
+
+func (api *TestNetAPI) Version() string         { return "1" }
+func (api *TestNetAPI) PeerCount() hexutil.Uint { return 3 }
+func (api *TestNetAPI) Listening() bool         { return true }
+
+func newTestClients(t *testing.T) (ManClient, NetClient, func()) {
+	handler := rpc.NewServer()
+	if err := handler.RegisterName("man", new(TestManAPI)); err != nil {
+		t.Fatal(err)
+	}
+	if err := handler.RegisterName("net", new(TestNetAPI)); err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := NewServer(handler)
+	server := grpc.NewServer()
+	api.Register(server)
+	go server.Serve(listener)
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewManClient(conn), NewNetClient(conn), func() {
+		conn.Close()
+		server.Stop()
+		api.Close()
+		handler.Stop()
+	}
+}
+
+func TestUnaryCalls(t *testing.T) {
+	man, netc, closer := newTestClients(t)
+	defer closer()
+	ctx := context.Background()
+
+	number, err := man.BlockNumber(ctx, &Empty{})
+	if err != nil || number.Value != 42 {
+		t.Errorf("block number mismatch: have %v (err %v), want 42", number, err)
+	}
+	balance, err := man.GetBalance(ctx, &AccountRequest{Address: testAddr.Bytes()})
+	if err != nil || new(big.Int).SetBytes(balance.Value).Int64() != 1000 {
+		t.Errorf("latest balance mismatch: have %v (err %v), want 1000", balance, err)
+	}
+	pending := &BlockNumber{Block: &BlockNumber_Tag_{Tag: BlockNumber_PENDING}}
+	balance, err = man.GetBalance(ctx, &AccountRequest{Address: testAddr.Bytes(), Block: pending})
+	if err != nil || new(big.Int).SetBytes(balance.Value).Int64() != 2000 {
+		t.Errorf("pending balance mismatch: have %v (err %v), want 2000", balance, err)
+	}
+	peers, err := netc.PeerCount(ctx, &Empty{})
+	if err != nil || peers.Value != 3 {
+		t.Errorf("peer count mismatch: have %v (err %v), want 3", peers, err)
+	}
+}
+
+func TestGetBlock(t *testing.T) {
+	man, _, closer := newTestClients(t)
+	defer closer()
+	ctx := context.Background()
+
+	block, err := man.GetBlockByNumber(ctx, &BlockRequest{Block: &BlockNumber{Block: &BlockNumber_Number{Number: 7}}})
+	if err != nil {
+		t.Fatalf("failed to retrieve block: %v", err)
+	}
+	if block.Header.Number != 7 || block.Header.Timestamp != 1500000000 || block.Header.GasLimit != 8000000 {
+		t.Errorf("header mismatch: %v", block.Header)
+	}
+	if len(block.Transactions) != 1 || common.BytesToHash(block.Transactions[0]) != common.HexToHash("0xaa") {
+		t.Errorf("transactions mismatch: %x", block.Transactions)
+	}
+	_, err = man.GetBlockByNumber(ctx, &BlockRequest{Block: &BlockNumber{Block: &BlockNumber_Number{Number: 8}}})
+	if errorCode(err) != codes.NotFound {
+		t.Errorf("missing block error mismatch: have %v, want %v", err, codes.NotFound)
+	}
+}
+
+func TestInvalidArguments(t *testing.T) {
+	man, _, closer := newTestClients(t)
+	defer closer()
+
+	_, err := man.GetBalance(context.Background(), &AccountRequest{Address: []byte{1, 2, 3}})
+	if errorCode(err) != codes.InvalidArgument {
+		t.Errorf("short address error mismatch: have %v, want %v", err, codes.InvalidArgument)
+	}
+}
+
+func TestSubscribeNewHeads(t *testing.T) {
+	man, _, closer := newTestClients(t)
+	defer closer()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := man.SubscribeNewHeads(ctx, &Empty{})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	var last uint64
+	for i := 0; i < 3; i++ {
+		head, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("failed to receive head %d: %v", i, err)
+		}
+		if head.Number <= last {
+			t.Errorf("head %d: number not increasing: have %d, previous %d", i, head.Number, last)
+		}
+		last = head.Number
+	}
+}
+
+// errorCode extracts the gRPC status code from an error returned by a client.
+func errorCode(err error) codes.Code {
+	if s, ok := status.FromError(err); ok {
+		return s.Code()
+	}
+	return codes.Unknown
+}
@@ -22,6 +22,7 @@ package debug
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"os/user"
@@ -48,6 +49,7 @@ type HandlerT struct {
 	cpuFile   string
 	traceW    io.WriteCloser
 	traceFile string
+	vmodule   string // Currently active vmodule ruleset
 }
 
 // Verbosity sets the log verbosity ceiling. The verbosity of individual packages
@@ -58,8 +60,47 @@ func (*HandlerT) Verbosity(level int) {
 
 // Vmodule sets the log verbosity pattern. See package log for details on the
 // pattern syntax.
-func (*HandlerT) Vmodule(pattern string) error {
-	return glogger.Vmodule(pattern)
+func (h *HandlerT) Vmodule(pattern string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := glogger.Vmodule(pattern); err != nil {
+		return err
+	}
+	h.vmodule = pattern
+	return nil
+}
+
+// SetLogLevel adjusts the log verbosity at runtime. An empty module or "*" sets
+// the global verbosity, otherwise the module pattern (see Vmodule) is given its
+// own level, taking precedence over any earlier rule for the same module.
+func (h *HandlerT) SetLogLevel(module string, level int) error {
+	if level < int(log.LvlCrit) || level > int(log.LvlTrace) {
+		return fmt.Errorf("invalid log level %d", level)
+	}
+	module = strings.TrimSpace(module)
+	if module == "" || module == "*" {
+		glogger.Verbosity(log.Lvl(level))
+		return nil
+	}
+	if strings.ContainsAny(module, ",=") {
+		return fmt.Errorf("invalid module pattern %q", module)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	rules := []string{fmt.Sprintf("%s=%d", module, level)}
+	for _, rule := range strings.Split(h.vmodule, ",") {
+		if name := strings.TrimSpace(strings.SplitN(rule, "=", 2)[0]); name != "" && name != module {
+			rules = append(rules, rule)
+		}
+	}
+	ruleset := strings.Join(rules, ",")
+	if err := glogger.Vmodule(ruleset); err != nil {
+		return err
+	}
+	h.vmodule = ruleset
+	return nil
 }
 
 // BacktraceAt sets the log backtrace location. See package log for details on
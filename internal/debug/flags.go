@@ -24,11 +24,11 @@ import (
 	"os"
 	"runtime"
 
+	"github.com/fjl/memsize/memsizeui"
 	"github.com/matrix/go-matrix/log"
 	"github.com/matrix/go-matrix/log/term"
 	"github.com/matrix/go-matrix/metrics"
 	"github.com/matrix/go-matrix/metrics/exp"
-	"github.com/fjl/memsize/memsizeui"
 	colorable "github.com/mattn/go-colorable"
 	"gopkg.in/urfave/cli.v1"
 )
@@ -43,9 +43,19 @@ var (
 	}
 	vmoduleFlag = cli.StringFlag{
 		Name:  "vmodule",
-		Usage: "Per-module verbosity: comma-separated list of <pattern>=<level> (e.g. man/*=5,p2p=4)",
+		Usage: "Per-module verbosity (deprecated, use --log.vmodule)",
 		Value: "",
 	}
+	logVmoduleFlag = cli.StringFlag{
+		Name:  "log.vmodule",
+		Usage: "Per-module verbosity overriding --verbosity: comma-separated list of <pattern>=<level> (e.g. p2p=5,core=3)",
+		Value: "",
+	}
+	logFormatFlag = cli.StringFlag{
+		Name:  "log.format",
+		Usage: "Log output format (terminal, logfmt or json)",
+		Value: "terminal",
+	}
 	backtraceAtFlag = cli.StringFlag{
 		Name:  "backtrace",
 		Usage: "Request a stack trace at a specific logging statement (e.g. \"block.go:271\")",
@@ -90,7 +100,7 @@ var (
 
 // Flags holds all command-line flags required for debugging.
 var Flags = []cli.Flag{
-	verbosityFlag, vmoduleFlag, backtraceAtFlag, debugFlag,
+	verbosityFlag, logVmoduleFlag, logFormatFlag, vmoduleFlag, backtraceAtFlag, debugFlag,
 	pprofFlag, pprofAddrFlag, pprofPortFlag,
 	memprofilerateFlag, blockprofilerateFlag, cpuprofileFlag, traceFlag,
 }
@@ -103,13 +113,30 @@ var (
 func init() {
 	usecolor := term.IsTty(os.Stderr.Fd()) && os.Getenv("TERM") != "dumb"
 
-	output := io.Writer(os.Stderr)
+	ostream = log.StreamHandler(logOutput(usecolor), log.TerminalFormat(usecolor))
+	glogger = log.NewGlogHandler(ostream)
+}
 
+// logOutput returns the writer console logs are emitted to.
+func logOutput(usecolor bool) io.Writer {
 	if usecolor {
-		output = colorable.NewColorableStderr()
+		return colorable.NewColorableStderr()
 	}
-	ostream = log.StreamHandler(output, log.TerminalFormat(usecolor))
-	glogger = log.NewGlogHandler(ostream)
+	return os.Stderr
+}
+
+// logFormat resolves the name of a log output format into the record formatter
+// to use. Colors are only ever applied to the terminal format.
+func logFormat(name string, usecolor bool) (log.Format, error) {
+	switch name {
+	case "", "terminal":
+		return log.TerminalFormat(usecolor), nil
+	case "logfmt":
+		return log.LogfmtFormat(), nil
+	case "json":
+		return log.JSONFormat(), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q, want terminal, logfmt or json", name)
 }
 
 // Setup initializes profiling and logging based on the CLI flags.
@@ -117,12 +144,23 @@ func init() {
 func Setup(ctx *cli.Context, logdir string) error {
 	// logging
 	log.PrintOrigins(ctx.GlobalBool(debugFlag.Name))
+
+	name := ctx.GlobalString(logFormatFlag.Name)
+	usecolor := (name == "" || name == "terminal") && term.IsTty(os.Stderr.Fd()) && os.Getenv("TERM") != "dumb"
+
+	format, err := logFormat(name, usecolor)
+	if err != nil {
+		return err
+	}
+	ostream = log.StreamHandler(logOutput(usecolor), format)
+	glogger.SetHandler(ostream)
+
 	if logdir != "" {
+		fileFormat, _ := logFormat(name, false)
 		rfh, err := log.RotatingFileHandler(
 			logdir,
 			1024*1024*1024*2, //262144,
-			/*log.JSONFormatOrderedEx(false, true),*/
-			log.TerminalFormat(false),
+			fileFormat,
 		)
 		if err != nil {
 			return err
@@ -130,7 +168,14 @@ func Setup(ctx *cli.Context, logdir string) error {
 		glogger.SetHandler(log.MultiHandler(ostream, rfh))
 	}
 	glogger.Verbosity(log.Lvl(ctx.GlobalInt(verbosityFlag.Name)))
-	glogger.Vmodule(ctx.GlobalString(vmoduleFlag.Name))
+
+	vmodule := ctx.GlobalString(logVmoduleFlag.Name)
+	if vmodule == "" {
+		vmodule = ctx.GlobalString(vmoduleFlag.Name)
+	}
+	if err := Handler.Vmodule(vmodule); err != nil {
+		return fmt.Errorf("invalid --%s: %v", logVmoduleFlag.Name, err)
+	}
 	glogger.BacktraceAt(ctx.GlobalString(backtraceAtFlag.Name))
 	log.Root().SetHandler(glogger)
 
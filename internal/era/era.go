@@ -0,0 +1,334 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package era implements the chunked chain history format used by
+// gman export-history and import-history.
+//
+// History is split into eras of a fixed number of blocks, each stored in its
+// own gzip compressed file holding an RLP header followed by the RLP encoded
+// blocks of the era. A manifest in sha256sum format lists the checksum of
+// every era file, so that a directory of eras can be mirrored over HTTP or a
+// CDN, verified with standard tools and downloads resumed file by file.
+package era
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/rlp"
+)
+
+const (
+	// DefaultSize is the default number of blocks stored in an era file.
+	DefaultSize = 8192
+
+	// Version is the version of the era file format.
+	Version = 1
+
+	// ManifestName is the file name of the checksum manifest.
+	ManifestName = "checksums.txt"
+)
+
+var (
+	errUnknownVersion = errors.New("unknown era file version")
+	errShortEra       = errors.New("era file ends before its last block")
+	errLongEra        = errors.New("era file has data after its last block")
+)
+
+// nameRegexp matches the file names of era files.
+var nameRegexp = regexp.MustCompile(`^era-(\d+)\.rlp\.gz$`)
+
+// Filename returns the file name of the era with the given index.
+func Filename(index uint64) string {
+	return fmt.Sprintf("era-%05d.rlp.gz", index)
+}
+
+// Index returns the era index of an era file name.
+func Index(name string) (uint64, bool) {
+	match := nameRegexp.FindStringSubmatch(name)
+	if match == nil {
+		return 0, false
+	}
+	index, err := strconv.ParseUint(match[1], 10, 64)
+	return index, err == nil
+}
+
+// Header is the first item of every era file, describing its contents.
+type Header struct {
+	Version uint
+	Size    uint64 // Number of blocks in a full era
+	Start   uint64 // Number of the first block in the era
+	Count   uint64 // Number of blocks in the era, less than Size only for the last one
+}
+
+// Writer encodes an era file.
+type Writer struct {
+	gz      *gzip.Writer
+	header  Header
+	written uint64
+}
+
+// NewWriter starts an era file of count blocks starting at start, in a chain
+// history split into eras of size blocks.
+func NewWriter(w io.Writer, size, start, count uint64) (*Writer, error) {
+	if size == 0 || start%size != 0 || count > size {
+		return nil, fmt.Errorf("invalid era of %d blocks from #%d with size %d", count, start, size)
+	}
+	header := Header{Version: Version, Size: size, Start: start, Count: count}
+
+	gz := gzip.NewWriter(w)
+	if err := rlp.Encode(gz, &header); err != nil {
+		return nil, err
+	}
+	return &Writer{gz: gz, header: header}, nil
+}
+
+// Add appends the next block of the era.
+func (w *Writer) Add(block *types.Block) error {
+	if want := w.header.Start + w.written; block.NumberU64() != want || w.written == w.header.Count {
+		return fmt.Errorf("unexpected block #%d in era starting at #%d with %d blocks", block.NumberU64(), w.header.Start, w.header.Count)
+	}
+	if err := block.EncodeRLP(w.gz); err != nil {
+		return err
+	}
+	w.written++
+	return nil
+}
+
+// Close finishes the era file, failing if not all blocks were added.
+func (w *Writer) Close() error {
+	if w.written != w.header.Count {
+		return fmt.Errorf("era starting at #%d has %d blocks, want %d", w.header.Start, w.written, w.header.Count)
+	}
+	return w.gz.Close()
+}
+
+// Reader decodes an era file.
+type Reader struct {
+	gz     *gzip.Reader
+	stream *rlp.Stream
+	header Header
+	read   uint64
+}
+
+// NewReader opens an era file, decoding its header.
+func NewReader(r io.Reader) (*Reader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	stream := rlp.NewStream(gz, 0)
+
+	var header Header
+	if err := stream.Decode(&header); err != nil {
+		return nil, err
+	}
+	if header.Version != Version {
+		return nil, errUnknownVersion
+	}
+	if header.Size == 0 || header.Start%header.Size != 0 || header.Count > header.Size {
+		return nil, fmt.Errorf("invalid era of %d blocks from #%d with size %d", header.Count, header.Start, header.Size)
+	}
+	return &Reader{gz: gz, stream: stream, header: header}, nil
+}
+
+// Header returns the header of the era file.
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+// Next decodes the next block of the era, returning io.EOF after the last one.
+func (r *Reader) Next() (*types.Block, error) {
+	if r.read == r.header.Count {
+		if _, _, err := r.stream.Kind(); err != io.EOF {
+			return nil, errLongEra
+		}
+		return nil, io.EOF
+	}
+	block := new(types.Block)
+	if err := r.stream.Decode(block); err != nil {
+		if err == io.EOF {
+			err = errShortEra
+		}
+		return nil, err
+	}
+	if want := r.header.Start + r.read; block.NumberU64() != want {
+		return nil, fmt.Errorf("era has block #%d at position of #%d", block.NumberU64(), want)
+	}
+	r.read++
+	return block, nil
+}
+
+// Checksum returns the hex encoded SHA-256 checksum of a file.
+func Checksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// ReadManifest reads the checksums of the era files listed in the manifest
+// of a directory, indexed by file name.
+func ReadManifest(dir string) (map[string]string, error) {
+	f, err := os.Open(filepath.Join(dir, ManifestName))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: malformed checksum line", ManifestName, line)
+		}
+		// sha256sum prefixes the file name with '*' in binary mode
+		sums[strings.TrimPrefix(fields[1], "*")] = fields[0]
+	}
+	return sums, scanner.Err()
+}
+
+// WriteManifest replaces the manifest of a directory with the given
+// checksums, indexed by file name.
+func WriteManifest(dir string, sums map[string]string) error {
+	names := make([]string, 0, len(sums))
+	for name := range sums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tmp := filepath.Join(dir, ManifestName+".tmp")
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if _, err := fmt.Fprintf(f, "%s  %s\n", sums[name], name); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, ManifestName))
+}
+
+// Verify checks the era files of a directory against their manifest checksums
+// and decodes them to ensure they hold a contiguous run of blocks, using the
+// given number of parallel workers. It returns the names of the listed era
+// files in order and, for each of them, nil or the reason it failed.
+func Verify(dir string, workers int) ([]string, []error, error) {
+	sums, err := ReadManifest(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	var names []string
+	for name := range sums {
+		if _, ok := Index(name); !ok {
+			return nil, nil, fmt.Errorf("unexpected file %q in manifest", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if workers < 1 {
+		workers = 1
+	}
+	var (
+		errs  = make([]error, len(names))
+		tasks = make(chan int)
+		wg    sync.WaitGroup
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range tasks {
+				errs[i] = verifyFile(filepath.Join(dir, names[i]), sums[names[i]])
+			}
+		}()
+	}
+	for i := range names {
+		tasks <- i
+	}
+	close(tasks)
+	wg.Wait()
+
+	return names, errs, nil
+}
+
+// verifyFile checks a single era file against its checksum and contents.
+func verifyFile(path string, sum string) error {
+	have, err := Checksum(path)
+	if err != nil {
+		return err
+	}
+	if have != sum {
+		return fmt.Errorf("checksum mismatch: have %s, want %s", have, sum)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r, err := NewReader(f)
+	if err != nil {
+		return err
+	}
+	if index, _ := Index(filepath.Base(path)); index != r.Header().Start/r.Header().Size {
+		return fmt.Errorf("file holds era %d", r.Header().Start/r.Header().Size)
+	}
+	var parent *types.Block
+	for {
+		block, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if parent != nil && block.ParentHash() != parent.Hash() {
+			return fmt.Errorf("block #%d does not extend #%d", block.NumberU64(), parent.NumberU64())
+		}
+		parent = block
+	}
+}
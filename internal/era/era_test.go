@@ -0,0 +1,196 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package era
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/core/types"
+)
+
+// makeChain creates n linked blocks starting with the genesis block.
+func makeChain(n int) []*types.Block {
+	blocks := make([]*types.Block, n)
+	parent := common.Hash{}
+	for i := range blocks {
+		blocks[i] = types.NewBlockWithHeader(&types.Header{
+			ParentHash: parent,
+			Number:     big.NewInt(int64(i)),
+			Time:       big.NewInt(int64(i)),
+			Difficulty: big.NewInt(1),
+		})
+		parent = blocks[i].Hash()
+	}
+	return blocks
+}
+
+// writeEras exports blocks into eras of size blocks along with a manifest.
+func writeEras(t *testing.T, dir string, blocks []*types.Block, size uint64) {
+	sums := make(map[string]string)
+	for start := uint64(0); start < uint64(len(blocks)); start += size {
+		count := size
+		if start+count > uint64(len(blocks)) {
+			count = uint64(len(blocks)) - start
+		}
+		name := Filename(start / size)
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := NewWriter(f, size, start, count)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, block := range blocks[start : start+count] {
+			if err := w.Add(block); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+
+		if sums[name], err = Checksum(filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := WriteManifest(dir, sums); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriterReader(t *testing.T) {
+	blocks := makeChain(10)
+
+	buf := new(bytes.Buffer)
+	w, err := NewWriter(buf, 16, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Add(blocks[1]); err == nil {
+		t.Errorf("out of order block accepted")
+	}
+	for _, block := range blocks[:9] {
+		if err := w.Add(block); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err == nil {
+		t.Errorf("incomplete era closed")
+	}
+	w.Add(blocks[9])
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewReader(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := r.Header(); h.Version != Version || h.Size != 16 || h.Start != 0 || h.Count != 10 {
+		t.Errorf("header mismatch: %+v", h)
+	}
+	for i := 0; ; i++ {
+		block, err := r.Next()
+		if err == io.EOF {
+			if i != 10 {
+				t.Errorf("read %d blocks, want 10", i)
+			}
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if block.Hash() != blocks[i].Hash() {
+			t.Errorf("block %d: hash mismatch", i)
+		}
+	}
+	if _, err := NewWriter(new(bytes.Buffer), 16, 8, 10); err == nil {
+		t.Errorf("unaligned era accepted")
+	}
+}
+
+func TestFilenameIndex(t *testing.T) {
+	if name := Filename(42); name != "era-00042.rlp.gz" {
+		t.Errorf("filename mismatch: have %s", name)
+	}
+	if index, ok := Index(Filename(123456)); !ok || index != 123456 {
+		t.Errorf("index mismatch: have %d, %v", index, ok)
+	}
+	if _, ok := Index(ManifestName); ok {
+		t.Errorf("manifest parsed as era file")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "era-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeEras(t, dir, makeChain(20), 8)
+
+	names, errs, err := Verify(dir, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 3 || names[0] != Filename(0) || names[2] != Filename(2) {
+		t.Fatalf("names mismatch: %v", names)
+	}
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("era %d: %v", i, err)
+		}
+	}
+	// Corrupt the middle era and drop the last one, as in an interrupted download
+	path := filepath.Join(dir, Filename(1))
+	data, _ := ioutil.ReadFile(path)
+	data[len(data)/2] ^= 0xff
+	ioutil.WriteFile(path, data, 0644)
+	os.Remove(filepath.Join(dir, Filename(2)))
+
+	if _, errs, err = Verify(dir, 4); err != nil {
+		t.Fatal(err)
+	}
+	if errs[0] != nil || errs[1] == nil || errs[2] == nil {
+		t.Errorf("verification mismatch: %v", errs)
+	}
+}
+
+func TestVerifyBrokenLink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "era-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	blocks := makeChain(8)
+	blocks[5] = types.NewBlockWithHeader(&types.Header{Number: big.NewInt(5), Time: new(big.Int), Difficulty: big.NewInt(1)})
+	writeEras(t, dir, blocks, 8)
+
+	if _, errs, err := Verify(dir, 1); err != nil || errs[0] == nil {
+		t.Errorf("broken chain verified: %v, %v", errs, err)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 	"strings"
 	"time"
 
@@ -38,6 +39,7 @@ import (
 	"github.com/matrix/go-matrix/consensus/manash"
 	"github.com/matrix/go-matrix/core"
 	"github.com/matrix/go-matrix/core/rawdb"
+	"github.com/matrix/go-matrix/core/state"
 	"github.com/matrix/go-matrix/core/types"
 	"github.com/matrix/go-matrix/core/vm"
 	"github.com/matrix/go-matrix/crypto"
@@ -108,29 +110,113 @@ func NewPublicTxPoolAPI(b Backend) *PublicTxPoolAPI {
 	return &PublicTxPoolAPI{b}
 }
 
-// Content returns the transactions contained within the transaction pool.
-func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransaction {
+// TxPoolFilter narrows down the transactions returned by txpool_content. All
+// set criteria must match. Matching transactions are ordered by status (pending
+// before queued), sender and nonce, and paginated with Offset and Limit.
+type TxPoolFilter struct {
+	From        *common.Address `json:"from"`
+	To          *common.Address `json:"to"`
+	MinGasPrice *hexutil.Big    `json:"minGasPrice"`
+	MaxGasPrice *hexutil.Big    `json:"maxGasPrice"`
+	Offset      hexutil.Uint    `json:"offset"`
+	Limit       *hexutil.Uint   `json:"limit"`
+}
+
+// matches checks whether a transaction passes the recipient and gas price
+// criteria of the filter. The sender is checked once per account instead.
+func (f *TxPoolFilter) matches(tx *types.Transaction) bool {
+	if f == nil {
+		return true
+	}
+	if f.To != nil {
+		if to := tx.To(); to == nil || *to != *f.To {
+			return false
+		}
+	}
+	if f.MinGasPrice != nil && tx.GasPrice().Cmp((*big.Int)(f.MinGasPrice)) < 0 {
+		return false
+	}
+	if f.MaxGasPrice != nil && tx.GasPrice().Cmp((*big.Int)(f.MaxGasPrice)) > 0 {
+		return false
+	}
+	return true
+}
+
+// Content returns the transactions contained within the transaction pool. An
+// optional filter restricts the result to the matching transactions, allowing
+// large pools to be paged through instead of dumped in one go.
+func (s *PublicTxPoolAPI) Content(filter *TxPoolFilter) map[string]map[string]map[string]*RPCTransaction {
 	content := map[string]map[string]map[string]*RPCTransaction{
 		"pending": make(map[string]map[string]*RPCTransaction),
 		"queued":  make(map[string]map[string]*RPCTransaction),
 	}
-	pending, queue := s.b.TxPoolContent()
-
-	// Flatten the pending transactions
-	for account, txs := range pending {
-		dump := make(map[string]*RPCTransaction)
-		for _, tx := range txs {
-			dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx)
+	var pending, queue map[common.Address]types.Transactions
+	if filter != nil && filter.From != nil {
+		// Avoid copying the entire pool if only a single sender is requested
+		txs, queued := s.b.TxPoolContentFrom(*filter.From)
+		pending = map[common.Address]types.Transactions{*filter.From: txs}
+		queue = map[common.Address]types.Transactions{*filter.From: queued}
+	} else {
+		pending, queue = s.b.TxPoolContent()
+	}
+	skip, left := 0, -1
+	if filter != nil {
+		skip = int(filter.Offset)
+		if filter.Limit != nil {
+			left = int(*filter.Limit)
 		}
-		content["pending"][account.Hex()] = dump
 	}
-	// Flatten the queued transactions
-	for account, txs := range queue {
-		dump := make(map[string]*RPCTransaction)
+	for _, section := range []struct {
+		name string
+		txs  map[common.Address]types.Transactions
+	}{{"pending", pending}, {"queued", queue}} {
+		accounts := make([]common.Address, 0, len(section.txs))
+		for account := range section.txs {
+			accounts = append(accounts, account)
+		}
+		sort.Slice(accounts, func(i, j int) bool {
+			return bytes.Compare(accounts[i][:], accounts[j][:]) < 0
+		})
+		// Flatten the transactions of each account, in a deterministic order
+		for _, account := range accounts {
+			var dump map[string]*RPCTransaction
+			for _, tx := range section.txs[account] {
+				if !filter.matches(tx) {
+					continue
+				}
+				if skip > 0 {
+					skip--
+					continue
+				}
+				if left == 0 {
+					return content
+				}
+				if dump == nil {
+					dump = make(map[string]*RPCTransaction)
+					content[section.name][account.Hex()] = dump
+				}
+				dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx)
+				if left > 0 {
+					left--
+				}
+			}
+		}
+	}
+	return content
+}
+
+// ContentFrom returns the pending and queued transactions of a single sender,
+// keyed by nonce.
+func (s *PublicTxPoolAPI) ContentFrom(addr common.Address) map[string]map[string]*RPCTransaction {
+	pending, queue := s.b.TxPoolContentFrom(addr)
+
+	content := make(map[string]map[string]*RPCTransaction, 2)
+	for name, txs := range map[string]types.Transactions{"pending": pending, "queued": queue} {
+		dump := make(map[string]*RPCTransaction, len(txs))
 		for _, tx := range txs {
 			dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx)
 		}
-		content["queued"][account.Hex()] = dump
+		content[name] = dump
 	}
 	return content
 }
@@ -179,6 +265,87 @@ func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
 	return content
 }
 
+// ReplaceTransaction re-signs the pooled transaction of the given sender and
+// nonce with a higher gas price and swaps it in place of the original. If no
+// gas price is given, the minimum accepted by the pool (or the suggested price,
+// if higher) is used.
+func (s *PublicTxPoolAPI) ReplaceTransaction(ctx context.Context, from common.Address, nonce hexutil.Uint64, gasPrice *hexutil.Big) (common.Hash, error) {
+	tx, err := s.poolTransaction(from, uint64(nonce))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	price, err := s.replacementPrice(ctx, tx, gasPrice)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	signed, err := signTransaction(s.b, from, tx.WithGasPrice(price))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return submitTransaction(ctx, s.b, signed)
+}
+
+// CancelTransaction replaces the pooled transaction of the given sender and
+// nonce with a zero value transfer to the sender itself, paying a higher gas
+// price so that the pool accepts the swap.
+func (s *PublicTxPoolAPI) CancelTransaction(ctx context.Context, from common.Address, nonce hexutil.Uint64, gasPrice *hexutil.Big) (common.Hash, error) {
+	tx, err := s.poolTransaction(from, uint64(nonce))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	price, err := s.replacementPrice(ctx, tx, gasPrice)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	cancel := types.NewTransaction(tx.Nonce(), from, new(big.Int), params.TxGas, price, nil)
+
+	signed, err := signTransaction(s.b, from, cancel)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return submitTransaction(ctx, s.b, signed)
+}
+
+// poolTransaction retrieves the pending or queued transaction of the given
+// sender with the given nonce.
+func (s *PublicTxPoolAPI) poolTransaction(from common.Address, nonce uint64) (*types.Transaction, error) {
+	pending, queue := s.b.TxPoolContent()
+	for _, txs := range []types.Transactions{pending[from], queue[from]} {
+		for _, tx := range txs {
+			if tx.Nonce() == nonce {
+				return tx, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no pooled transaction from %s with nonce %d", from.Hex(), nonce)
+}
+
+// replacementPrice calculates the gas price to replace tx with. An explicitly
+// requested price is validated against the pool's price bump, otherwise the
+// cheapest acceptable one is picked.
+func (s *PublicTxPoolAPI) replacementPrice(ctx context.Context, tx *types.Transaction, gasPrice *hexutil.Big) (*big.Int, error) {
+	// Mirror the threshold enforced by the pool when replacing a transaction
+	minimum := new(big.Int).Mul(tx.GasPrice(), big.NewInt(100+int64(core.DefaultTxPoolConfig.PriceBump)))
+	minimum.Div(minimum, big.NewInt(100))
+	if minimum.Cmp(tx.GasPrice()) <= 0 {
+		minimum.Add(tx.GasPrice(), common.Big1)
+	}
+	if gasPrice != nil {
+		if price := (*big.Int)(gasPrice); price.Cmp(minimum) < 0 {
+			return nil, fmt.Errorf("gas price %v too low to replace transaction, need at least %v", price, minimum)
+		}
+		return (*big.Int)(gasPrice), nil
+	}
+	suggested, err := s.b.SuggestPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if suggested.Cmp(minimum) > 0 {
+		return suggested, nil
+	}
+	return minimum, nil
+}
+
 // PublicAccountAPI provides an API to access accounts managed by this node.
 // It offers only methods that can retrieve accounts.
 type PublicAccountAPI struct {
@@ -205,17 +372,17 @@ func (s *PublicAccountAPI) Accounts() []common.Address {
 // It offers methods to create, (un)lock en list accounts. Some methods accept
 // passwords and are therefore considered private by default.
 type PrivateAccountAPI struct {
-	am        *accounts.Manager
-	nonceLock *AddrLocker
-	b         Backend
+	am     *accounts.Manager
+	nonces *NonceManager
+	b      Backend
 }
 
 // NewPrivateAccountAPI create a new PrivateAccountAPI.
-func NewPrivateAccountAPI(b Backend, nonceLock *AddrLocker) *PrivateAccountAPI {
+func NewPrivateAccountAPI(b Backend, nonces *NonceManager) *PrivateAccountAPI {
 	return &PrivateAccountAPI{
-		am:        b.AccountManager(),
-		nonceLock: nonceLock,
-		b:         b,
+		am:     b.AccountManager(),
+		nonces: nonces,
+		b:      b,
 	}
 }
 
@@ -339,7 +506,7 @@ func (s *PrivateAccountAPI) LockAccount(addr common.Address) bool {
 }
 
 // signTransactions sets defaults and signs the given transaction
-// NOTE: the caller needs to ensure that the nonceLock is held, if applicable,
+// NOTE: the caller needs to ensure that the nonce lock is held, if applicable,
 // and release it after the transaction has been submitted to the tx pool
 func (s *PrivateAccountAPI) signTransaction(ctx context.Context, args SendTxArgs, passwd string) (*types.Transaction, error) {
 	// Look up the wallet containing the requested signer
@@ -365,12 +532,23 @@ func (s *PrivateAccountAPI) signTransaction(ctx context.Context, args SendTxArgs
 // SendTransaction will create a transaction from the given arguments and
 // tries to sign it with the key associated with args.To. If the given passwd isn't
 // able to decrypt the key it fails.
-func (s *PrivateAccountAPI) SendTransaction(ctx context.Context, args SendTxArgs, passwd string) (common.Hash, error) {
+func (s *PrivateAccountAPI) SendTransaction(ctx context.Context, args SendTxArgs, passwd string) (hash common.Hash, err error) {
 	if args.Nonce == nil {
 		// Hold the addresse's mutex around signing to prevent concurrent assignment of
 		// the same nonce to multiple accounts.
-		s.nonceLock.LockAddr(args.From)
-		defer s.nonceLock.UnlockAddr(args.From)
+		s.nonces.LockAddr(args.From)
+		defer s.nonces.UnlockAddr(args.From)
+
+		nonce, nerr := s.nonces.Next(ctx, args.From)
+		if nerr != nil {
+			return common.Hash{}, nerr
+		}
+		defer func() {
+			if err != nil {
+				s.nonces.Release(args.From, nonce)
+			}
+		}()
+		args.Nonce = (*hexutil.Uint64)(&nonce)
 	}
 	signed, err := s.signTransaction(ctx, args, passwd)
 	if err != nil {
@@ -410,7 +588,8 @@ func (s *PrivateAccountAPI) SignTransaction(ctx context.Context, args SendTxArgs
 // safely used to calculate a signature from.
 //
 // The hash is calulcated as
-//   keccak256("\x19Matrix Signed Message:\n"${message length}${message}).
+//
+//	keccak256("\x19Matrix Signed Message:\n"${message length}${message}).
 //
 // This gives context to the signed message and prevents signing of transactions.
 func signHash(data []byte) []byte {
@@ -616,13 +795,54 @@ type CallArgs struct {
 	Data     hexutil.Bytes   `json:"data"`
 }
 
-func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, vmCfg vm.Config, timeout time.Duration) ([]byte, uint64, bool, error) {
-	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
+// OverrideAccount specifies the fields of an account to replace in the state
+// before executing a call. Fields left empty keep their current values.
+type OverrideAccount struct {
+	Nonce     *hexutil.Uint64             `json:"nonce"`
+	Code      *hexutil.Bytes              `json:"code"`
+	Balance   *hexutil.Big                `json:"balance"`
+	StateDiff map[common.Hash]common.Hash `json:"stateDiff"`
+}
+
+// StateOverride is the set of accounts to override in the state before executing
+// a call, keyed by address.
+type StateOverride map[common.Address]OverrideAccount
 
+// Apply overrides the fields of the specified accounts in the given state.
+func (diff *StateOverride) Apply(statedb *state.StateDB) {
+	if diff == nil {
+		return
+	}
+	for addr, account := range *diff {
+		if account.Nonce != nil {
+			statedb.SetNonce(addr, uint64(*account.Nonce))
+		}
+		if account.Code != nil {
+			statedb.SetCode(addr, *account.Code)
+		}
+		if account.Balance != nil {
+			statedb.SetBalance(addr, account.Balance.ToInt())
+		}
+		for key, value := range account.StateDiff {
+			statedb.SetState(addr, key, value)
+		}
+	}
+}
+
+func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, overrides *StateOverride, vmCfg vm.Config, timeout time.Duration) ([]byte, uint64, bool, error) {
 	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
 	if state == nil || err != nil {
 		return nil, 0, false, err
 	}
+	overrides.Apply(state)
+	return s.applyCall(ctx, args, state, header, vmCfg, timeout)
+}
+
+// applyCall executes a call on top of the given state, which is modified by the
+// execution and should not be reused afterwards.
+func (s *PublicBlockChainAPI) applyCall(ctx context.Context, args CallArgs, statedb *state.StateDB, header *types.Header, vmCfg vm.Config, timeout time.Duration) ([]byte, uint64, bool, error) {
+	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
+
 	// Set sender address or use a default if none specified
 	addr := args.From
 	if addr == (common.Address{}) {
@@ -657,7 +877,7 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 	defer cancel()
 
 	// Get a new instance of the EVM.
-	evm, vmError, err := s.b.GetEVM(ctx, msg, state, header, vmCfg)
+	evm, vmError, err := s.b.GetEVM(ctx, msg, statedb, header, vmCfg)
 	if err != nil {
 		return nil, 0, false, err
 	}
@@ -680,11 +900,52 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 
 // Call executes the given transaction on the state for the given block number.
 // It doesn't make and changes in the state/blockchain and is useful to execute and retrieve values.
-func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber) (hexutil.Bytes, error) {
-	result, _, _, err := s.doCall(ctx, args, blockNr, vm.Config{}, 5*time.Second)
+//
+// The optional overrides replace the balance, nonce, code or storage slots of
+// accounts before the call is executed, allowing calls to be simulated against
+// hypothetical states.
+func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, overrides *StateOverride) (hexutil.Bytes, error) {
+	result, _, _, err := s.doCall(ctx, args, blockNr, overrides, vm.Config{}, 5*time.Second)
 	return (hexutil.Bytes)(result), err
 }
 
+// maxCallManyBatch is the maximum number of calls accepted by CallMany.
+const maxCallManyBatch = 1024
+
+// CallResult is the outcome of a single call executed by CallMany.
+type CallResult struct {
+	Result  hexutil.Bytes  `json:"result"`
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+	Failed  bool           `json:"failed"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// CallMany executes a batch of calls on the state of the given block number. All
+// calls see the same state: changes made by one call are not visible to the next.
+// A call that fails is reported in its own result, without aborting the batch.
+func (s *PublicBlockChainAPI) CallMany(ctx context.Context, calls []CallArgs, blockNr rpc.BlockNumber) ([]*CallResult, error) {
+	if len(calls) > maxCallManyBatch {
+		return nil, fmt.Errorf("too many calls in batch: have %d, max %d", len(calls), maxCallManyBatch)
+	}
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	results := make([]*CallResult, len(calls))
+	for i, args := range calls {
+		res, gas, failed, err := s.applyCall(ctx, args, state.Copy(), header, vm.Config{}, 5*time.Second)
+		results[i] = &CallResult{Result: res, GasUsed: hexutil.Uint64(gas), Failed: failed}
+		if err != nil {
+			results[i].Failed, results[i].Error = true, err.Error()
+		}
+		// Abort the remaining calls if the request itself was cancelled
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
 // EstimateGas returns an estimate of the amount of gas needed to execute the
 // given transaction against the current pending block.
 func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (hexutil.Uint64, error) {
@@ -710,7 +971,7 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (h
 	executable := func(gas uint64) bool {
 		args.Gas = hexutil.Uint64(gas)
 
-		_, _, failed, err := s.doCall(ctx, args, rpc.PendingBlockNumber, vm.Config{}, 0)
+		_, _, failed, err := s.doCall(ctx, args, rpc.PendingBlockNumber, nil, vm.Config{}, 0)
 		if err != nil || failed {
 			return false
 		}
@@ -983,13 +1244,13 @@ func newRPCTransactionFromBlockHash(b *types.Block, hash common.Hash) *RPCTransa
 
 // PublicTransactionPoolAPI exposes methods for the RPC interface
 type PublicTransactionPoolAPI struct {
-	b         Backend
-	nonceLock *AddrLocker
+	b      Backend
+	nonces *NonceManager
 }
 
 // NewPublicTransactionPoolAPI creates a new RPC service with methods specific for the transaction pool.
-func NewPublicTransactionPoolAPI(b Backend, nonceLock *AddrLocker) *PublicTransactionPoolAPI {
-	return &PublicTransactionPoolAPI{b, nonceLock}
+func NewPublicTransactionPoolAPI(b Backend, nonces *NonceManager) *PublicTransactionPoolAPI {
+	return &PublicTransactionPoolAPI{b, nonces}
 }
 
 // GetBlockTransactionCountByNumber returns the number of transactions in the block with the given block number.
@@ -1042,6 +1303,12 @@ func (s *PublicTransactionPoolAPI) GetRawTransactionByBlockHashAndIndex(ctx cont
 	return nil
 }
 
+// NonceStatus reports the confirmed, pooled and locally in-flight nonces of the
+// given address, along with the nonce gaps keeping its queued transactions stuck.
+func (s *PublicTransactionPoolAPI) NonceStatus(ctx context.Context, address common.Address) (*NonceStatus, error) {
+	return s.nonces.Status(ctx, address)
+}
+
 // GetTransactionCount returns the number of transactions the given address has sent for the given block number
 func (s *PublicTransactionPoolAPI) GetTransactionCount(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (*hexutil.Uint64, error) {
 	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
@@ -1134,22 +1401,28 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 
 // sign is a helper function that signs a transaction with the private key of the given address.
 func (s *PublicTransactionPoolAPI) sign(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
+	return signTransaction(s.b, addr, tx)
+}
+
+// signTransaction signs a transaction with the private key of the given address,
+// using the wallet managed by the backend's account manager.
+func signTransaction(b Backend, addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
 	// Look up the wallet containing the requested signer
 	account := accounts.Account{Address: addr}
 
-	wallet, err := s.b.AccountManager().Find(account)
+	wallet, err := b.AccountManager().Find(account)
 	if err != nil {
 		return nil, err
 	}
 	// Request the wallet to sign the transaction
 	var chainID *big.Int
-	if config := s.b.ChainConfig(); config.IsEIP155(s.b.CurrentBlock().Number()) {
+	if config := b.ChainConfig(); config.IsEIP155(b.CurrentBlock().Number()) {
 		chainID = config.ChainId
 	}
 	return wallet.SignTx(account, tx, chainID)
 }
 
-//YY
+// YY
 type ExtraTo_Mx struct {
 	To2    *common.Address `json:"to"`
 	Value2 *hexutil.Big    `json:"value"`
@@ -1275,7 +1548,7 @@ func submitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (c
 
 // SendTransaction creates a transaction for the given argument, sign it and submit it to the
 // transaction pool.
-func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args SendTxArgs) (common.Hash, error) {
+func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args SendTxArgs) (hash common.Hash, err error) {
 
 	// Look up the wallet containing the requested signer
 	account := accounts.Account{Address: args.From}
@@ -1288,8 +1561,19 @@ func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args Sen
 	if args.Nonce == nil {
 		// Hold the addresse's mutex around signing to prevent concurrent assignment of
 		// the same nonce to multiple accounts.
-		s.nonceLock.LockAddr(args.From)
-		defer s.nonceLock.UnlockAddr(args.From)
+		s.nonces.LockAddr(args.From)
+		defer s.nonces.UnlockAddr(args.From)
+
+		nonce, nerr := s.nonces.Next(ctx, args.From)
+		if nerr != nil {
+			return common.Hash{}, nerr
+		}
+		defer func() {
+			if err != nil {
+				s.nonces.Release(args.From, nonce)
+			}
+		}()
+		args.Nonce = (*hexutil.Uint64)(&nonce)
 	} else { //YY add else
 		nc1 := params.NonceAddOne
 		nc := uint64(*args.Nonce)
@@ -0,0 +1,211 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package manapi
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/common/hexutil"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/rpc"
+)
+
+// inflightNonceTimeout is the time after which a nonce handed out to a local
+// transaction, but never seen in the transaction pool, is considered lost and
+// may be assigned again.
+const inflightNonceTimeout = time.Minute
+
+// NonceManager assigns nonces to transactions signed by the node. Besides the
+// pool contents it tracks the nonces handed out but not yet visible in the pool,
+// and fills gaps left by transactions which were dropped or never submitted, so
+// a sender's queued transactions are not stuck behind a missing nonce.
+type NonceManager struct {
+	AddrLocker
+
+	b        Backend
+	mu       sync.Mutex
+	inflight map[common.Address]map[uint64]time.Time
+}
+
+// NewNonceManager creates a nonce manager on top of the given backend.
+func NewNonceManager(b Backend) *NonceManager {
+	return &NonceManager{
+		b:        b,
+		inflight: make(map[common.Address]map[uint64]time.Time),
+	}
+}
+
+// NonceGap is a range of missing nonces, both ends inclusive.
+type NonceGap struct {
+	From hexutil.Uint64 `json:"from"`
+	To   hexutil.Uint64 `json:"to"`
+}
+
+// NonceStatus reports the nonce state of a sender as seen by the node.
+type NonceStatus struct {
+	Confirmed hexutil.Uint64   `json:"confirmed"` // Next nonce according to the latest block
+	Pending   []hexutil.Uint64 `json:"pending"`   // Nonces of executable pool transactions
+	Queued    []hexutil.Uint64 `json:"queued"`    // Nonces of non-executable pool transactions
+	Inflight  []hexutil.Uint64 `json:"inflight"`  // Nonces handed out locally but not yet in the pool
+	Gaps      []NonceGap       `json:"gaps"`      // Missing nonces blocking the queued transactions
+	Next      hexutil.Uint64   `json:"next"`      // Nonce the next local transaction would get
+	Stuck     bool             `json:"stuck"`     // Whether queued transactions wait on a gap
+}
+
+// Next returns the nonce to use for the next transaction of addr and marks it
+// in-flight. The lowest nonce from the latest block onwards that is neither in
+// the pool nor in-flight is chosen. The caller should hold the address lock and
+// Release the nonce if the transaction could not be submitted.
+func (m *NonceManager) Next(ctx context.Context, addr common.Address) (uint64, error) {
+	confirmed, pending, queued, err := m.known(ctx, addr)
+	if err != nil {
+		return 0, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	inflight := m.inflightOf(addr, confirmed)
+	nonce := nextFreeNonce(confirmed, poolNonces(pending, queued), inflight)
+	inflight[nonce] = time.Now()
+	return nonce, nil
+}
+
+// Release drops an in-flight nonce whose transaction failed to be submitted.
+func (m *NonceManager) Release(addr common.Address, nonce uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if inflight, ok := m.inflight[addr]; ok {
+		delete(inflight, nonce)
+		if len(inflight) == 0 {
+			delete(m.inflight, addr)
+		}
+	}
+}
+
+// Status reports the confirmed, pooled and in-flight nonces of addr together
+// with the gaps preventing its queued transactions from executing.
+func (m *NonceManager) Status(ctx context.Context, addr common.Address) (*NonceStatus, error) {
+	confirmed, pending, queued, err := m.known(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	used := poolNonces(pending, queued)
+
+	status := &NonceStatus{
+		Confirmed: hexutil.Uint64(confirmed),
+		Pending:   txNonces(pending),
+		Queued:    txNonces(queued),
+		Inflight:  []hexutil.Uint64{},
+		Gaps:      []NonceGap{},
+	}
+	m.mu.Lock()
+	inflight := m.inflightOf(addr, confirmed)
+	for nonce := range inflight {
+		if _, ok := used[nonce]; !ok {
+			status.Inflight = append(status.Inflight, hexutil.Uint64(nonce))
+		}
+	}
+	status.Next = hexutil.Uint64(nextFreeNonce(confirmed, used, inflight))
+	m.mu.Unlock()
+	sortNonces(status.Inflight)
+
+	// Walk the pooled nonces upwards, every hole blocks the transactions above it
+	all := append(append([]hexutil.Uint64{}, status.Pending...), status.Queued...)
+	sortNonces(all)
+
+	next := confirmed
+	for _, nonce := range all {
+		if uint64(nonce) > next {
+			status.Gaps = append(status.Gaps, NonceGap{From: hexutil.Uint64(next), To: nonce - 1})
+		}
+		next = uint64(nonce) + 1
+	}
+	status.Stuck = len(status.Gaps) > 0
+	return status, nil
+}
+
+// known returns the next confirmed nonce of addr according to the latest block,
+// along with its pending and queued transactions in the pool.
+func (m *NonceManager) known(ctx context.Context, addr common.Address) (uint64, types.Transactions, types.Transactions, error) {
+	state, _, err := m.b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if state == nil || err != nil {
+		return 0, nil, nil, err
+	}
+	pending, queued := m.b.TxPoolContentFrom(addr)
+	return state.GetNonce(addr), pending, queued, nil
+}
+
+// inflightOf returns the in-flight nonces of addr, dropping the ones already
+// confirmed or timed out. The caller must hold m.mu.
+func (m *NonceManager) inflightOf(addr common.Address, confirmed uint64) map[uint64]time.Time {
+	inflight, ok := m.inflight[addr]
+	if !ok {
+		inflight = make(map[uint64]time.Time)
+		m.inflight[addr] = inflight
+	}
+	for nonce, handed := range inflight {
+		if nonce < confirmed || time.Since(handed) > inflightNonceTimeout {
+			delete(inflight, nonce)
+		}
+	}
+	return inflight
+}
+
+// nextFreeNonce returns the lowest nonce from confirmed onwards that is neither
+// used by a pooled transaction nor in-flight.
+func nextFreeNonce(confirmed uint64, used map[uint64]struct{}, inflight map[uint64]time.Time) uint64 {
+	nonce := confirmed
+	for {
+		if _, ok := used[nonce]; ok {
+			nonce++
+			continue
+		}
+		if _, ok := inflight[nonce]; ok {
+			nonce++
+			continue
+		}
+		return nonce
+	}
+}
+
+func poolNonces(pending, queued types.Transactions) map[uint64]struct{} {
+	used := make(map[uint64]struct{}, len(pending)+len(queued))
+	for _, tx := range pending {
+		used[tx.Nonce()] = struct{}{}
+	}
+	for _, tx := range queued {
+		used[tx.Nonce()] = struct{}{}
+	}
+	return used
+}
+
+func txNonces(txs types.Transactions) []hexutil.Uint64 {
+	nonces := make([]hexutil.Uint64, 0, len(txs))
+	for _, tx := range txs {
+		nonces = append(nonces, hexutil.Uint64(tx.Nonce()))
+	}
+	sortNonces(nonces)
+	return nonces
+}
+
+func sortNonces(nonces []hexutil.Uint64) {
+	sort.Slice(nonces, func(i, j int) bool { return nonces[i] < nonces[j] })
+}
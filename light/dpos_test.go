@@ -0,0 +1,146 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package light
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/consensus/mtxdpos"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/crypto"
+	"github.com/matrix/go-matrix/params"
+)
+
+// dposHeaderChain is a consensus.ChainReader backed by a list of headers.
+type dposHeaderChain struct {
+	headers []*types.Header
+}
+
+func (c *dposHeaderChain) Config() *params.ChainConfig  { return params.TestChainConfig }
+func (c *dposHeaderChain) CurrentHeader() *types.Header { return c.headers[len(c.headers)-1] }
+func (c *dposHeaderChain) GetHeaderByNumber(number uint64) *types.Header {
+	if number < uint64(len(c.headers)) {
+		return c.headers[number]
+	}
+	return nil
+}
+func (c *dposHeaderChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header := c.GetHeaderByNumber(number); header != nil && header.Hash() == hash {
+		return header
+	}
+	return nil
+}
+func (c *dposHeaderChain) GetHeaderByHash(hash common.Hash) *types.Header {
+	for _, header := range c.headers {
+		if header.Hash() == hash {
+			return header
+		}
+	}
+	return nil
+}
+func (c *dposHeaderChain) GetBlock(hash common.Hash, number uint64) *types.Block { return nil }
+
+// extend appends a new header to the chain, signed by the given validators.
+func (c *dposHeaderChain) extend(leader common.Address, topology common.NetTopology, signers []*ecdsa.PrivateKey) *types.Header {
+	parent := c.CurrentHeader()
+	header := &types.Header{
+		ParentHash:  parent.Hash(),
+		Leader:      leader,
+		Number:      new(big.Int).Add(parent.Number, common.Big1),
+		Difficulty:  common.Big1,
+		Time:        big.NewInt(int64(len(c.headers))),
+		NetTopology: topology,
+	}
+	hash := header.HashNoSignsAndNonce()
+	for _, key := range signers {
+		sign, _ := crypto.SignWithValidate(hash.Bytes(), true, key)
+		header.Signatures = append(header.Signatures, common.BytesToSignature(sign))
+	}
+	c.headers = append(c.headers, header)
+	return header
+}
+
+func newDPOSHeaderChain(count int) (*dposHeaderChain, []common.Address, []*ecdsa.PrivateKey) {
+	var (
+		accounts []common.Address
+		keys     []*ecdsa.PrivateKey
+		genesis  = &types.Header{Number: new(big.Int), Difficulty: common.Big1, Time: new(big.Int)}
+	)
+	for i := 0; i < count; i++ {
+		key, _ := crypto.GenerateKey()
+		account := crypto.PubkeyToAddress(key.PublicKey)
+
+		accounts, keys = append(accounts, account), append(keys, key)
+		genesis.Elect = append(genesis.Elect, common.Elect{Account: account, Stock: 1, Type: common.ElectRoleValidator})
+		genesis.NetTopology.NetTopologyData = append(genesis.NetTopology.NetTopologyData, common.NetTopologyData{
+			Account:  account,
+			Position: common.GeneratePosition(uint16(i), common.ElectRoleValidator),
+		})
+	}
+	genesis.NetTopology.Type = common.NetTopoTypeAll
+	return &dposHeaderChain{headers: []*types.Header{genesis}}, accounts, keys
+}
+
+// Tests that light clients verify headers against the validators defined by the
+// topology of the header chain.
+func TestLightVerifyBlock(t *testing.T) {
+	chain, accounts, keys := newDPOSHeaderChain(3)
+	dpos := mtxdpos.NewLightMtxDPOS(chain)
+
+	if err := dpos.VerifyBlock(chain.extend(accounts[0], common.NetTopology{Type: common.NetTopoTypeChange}, keys)); err != nil {
+		t.Fatalf("failed to verify fully signed header: %v", err)
+	}
+	if err := dpos.VerifyBlock(chain.extend(common.Address{0x01}, common.NetTopology{Type: common.NetTopoTypeChange}, keys)); err != mtxdpos.ErrLeaderNotValidator {
+		t.Errorf("foreign leader error mismatch: have %v, want %v", err, mtxdpos.ErrLeaderNotValidator)
+	}
+	if err := dpos.VerifyBlock(chain.extend(accounts[0], common.NetTopology{Type: common.NetTopoTypeChange}, keys[:2])); err == nil {
+		t.Errorf("header missing a validator signature verified")
+	}
+	// Swap a validator and ensure only the new set is accepted afterwards
+	key, _ := crypto.GenerateKey()
+	account := crypto.PubkeyToAddress(key.PublicKey)
+
+	change := common.NetTopology{
+		Type: common.NetTopoTypeChange,
+		NetTopologyData: []common.NetTopologyData{{
+			Account:  account,
+			Position: common.GeneratePosition(2, common.ElectRoleValidator),
+		}},
+	}
+	chain.extend(accounts[0], change, keys)
+
+	if err := dpos.VerifyBlock(chain.extend(accounts[0], common.NetTopology{Type: common.NetTopoTypeChange}, []*ecdsa.PrivateKey{keys[0], keys[1], key})); err != nil {
+		t.Errorf("failed to verify header signed by new validator set: %v", err)
+	}
+	if err := dpos.VerifyBlock(chain.extend(accounts[0], common.NetTopology{Type: common.NetTopoTypeChange}, keys)); err == nil {
+		t.Errorf("header signed by a replaced validator verified")
+	}
+	// Ensure a cold engine rebuilds the same topology from the headers
+	if err := mtxdpos.NewLightMtxDPOS(chain).VerifyBlock(chain.headers[5]); err != nil {
+		t.Errorf("failed to verify header with uncached topology: %v", err)
+	}
+}
+
+// Tests that headers of chains without validators are reported as such.
+func TestLightVerifyBlockNoValidators(t *testing.T) {
+	chain, _, _ := newDPOSHeaderChain(0)
+	if err := mtxdpos.NewLightMtxDPOS(chain).VerifyBlock(chain.extend(common.Address{}, common.NetTopology{}, nil)); err != mtxdpos.ErrNoValidators {
+		t.Errorf("error mismatch: have %v, want %v", err, mtxdpos.ErrNoValidators)
+	}
+}
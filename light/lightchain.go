@@ -26,6 +26,7 @@ import (
 
 	"github.com/matrix/go-matrix/common"
 	"github.com/matrix/go-matrix/consensus"
+	"github.com/matrix/go-matrix/consensus/mtxdpos"
 	"github.com/matrix/go-matrix/core"
 	"github.com/matrix/go-matrix/core/rawdb"
 	"github.com/matrix/go-matrix/core/state"
@@ -69,7 +70,8 @@ type LightChain struct {
 	procInterrupt int32 // interrupt signaler for block processing
 	wg            sync.WaitGroup
 
-	engine consensus.Engine
+	engine     consensus.Engine
+	dposEngine consensus.DPOSEngine
 }
 
 // NewLightChain returns a fully initialised light chain using information
@@ -94,6 +96,12 @@ func NewLightChain(odr OdrBackend, config *params.ChainConfig, engine consensus.
 	if err != nil {
 		return nil, err
 	}
+	if dposEngine, ok := engine.(consensus.DPOSEngine); ok {
+		bc.dposEngine = dposEngine
+	} else {
+		bc.dposEngine = mtxdpos.NewLightMtxDPOS(bc.hc)
+	}
+
 	bc.genesisBlock, _ = bc.GetBlockByNumber(NoOdr, 0)
 	if bc.genesisBlock == nil {
 		return nil, core.ErrNoGenesis
@@ -373,6 +381,15 @@ func (self *LightChain) InsertHeaderChain(chain []*types.Header, checkFreq int)
 		self.mu.Lock()
 		defer self.mu.Unlock()
 
+		// Verify the validator signatures on top of the seal. Headers below a trusted
+		// checkpoint may lack the ancestry to rebuild the topology, accept those, as
+		// well as headers of chains not defining any validators.
+		if err := self.dposEngine.VerifyBlock(header); err != nil {
+			if err != consensus.ErrUnknownAncestor && err != mtxdpos.ErrNoValidators {
+				return err
+			}
+			log.Debug("Skipped DPOS verification of header", "number", header.Number, "hash", header.Hash(), "reason", err)
+		}
 		status, err := self.hc.WriteHeader(header)
 
 		switch status {
@@ -451,6 +468,9 @@ func (self *LightChain) GetHeaderByNumberOdr(ctx context.Context, number uint64)
 // Config retrieves the header chain's chain configuration.
 func (self *LightChain) Config() *params.ChainConfig { return self.hc.Config() }
 
+// DPOSEngine retrieves the light chain's validator signature verifier.
+func (self *LightChain) DPOSEngine() consensus.DPOSEngine { return self.dposEngine }
+
 func (self *LightChain) SyncCht(ctx context.Context) bool {
 	if self.odr.ChtIndexer() == nil {
 		return false
@@ -509,3 +529,9 @@ func (self *LightChain) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscri
 func (self *LightChain) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
 	return self.scope.Track(new(event.Feed).Subscribe(ch))
 }
+
+// SubscribeChainReorgEvent implements the interface of filters.Backend
+// LightChain does not send core.ChainReorgEvent, so return an empty subscription.
+func (self *LightChain) SubscribeChainReorgEvent(ch chan<- core.ChainReorgEvent) event.Subscription {
+	return self.scope.Track(new(event.Feed).Subscribe(ch))
+}
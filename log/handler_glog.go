@@ -82,14 +82,17 @@ func (h *GlogHandler) Verbosity(level Lvl) {
 //
 // For instance:
 //
-//  pattern="gopher.go=3"
-//   sets the V level to 3 in all Go files named "gopher.go"
+//	pattern="gopher.go=3"
+//	 sets the V level to 3 in all Go files named "gopher.go"
 //
-//  pattern="foo=3"
-//   sets V to 3 in all files of any packages whose import path ends in "foo"
+//	pattern="foo=3"
+//	 sets V to 3 in all files of any packages whose import path ends in "foo"
 //
-//  pattern="foo/*=3"
-//   sets V to 3 in all files of any packages whose import path contains "foo"
+//	pattern="foo/*=3"
+//	 sets V to 3 in all files of any packages whose import path contains "foo"
+//
+// The first rule matching a callsite replaces the global verbosity ceiling for
+// it, so modules can be made both more and less verbose than the rest.
 func (h *GlogHandler) Vmodule(ruleset string) error {
 	var filter []pattern
 	for _, rule := range strings.Split(ruleset, ",") {
@@ -112,8 +115,8 @@ func (h *GlogHandler) Vmodule(ruleset string) error {
 		if err != nil {
 			return errVmoduleSyntax
 		}
-		if level <= 0 {
-			continue // Ignore. It's harmless but no point in paying the overhead.
+		if level < 0 {
+			return errVmoduleSyntax
 		}
 		// Compile the rule pattern into a regular expression
 		matcher := ".*"
@@ -178,8 +181,12 @@ func (h *GlogHandler) BacktraceAt(location string) error {
 
 var gGLogCounter uint = 0
 
-// Log implements Handler.Log, filtering a log record through the global, local
-// and backtrace filters, finally emitting it if either allow it through.
+// lvlUnset marks a cached callsite that no vmodule rule matched.
+const lvlUnset Lvl = -1
+
+// Log implements Handler.Log, filtering a log record through the backtrace,
+// local and global filters, finally emitting it if allowed through. A local
+// (vmodule) rule matching the callsite overrides the global level.
 func (h *GlogHandler) Log(r *Record) error {
 	// If backtracing is requested, check whether this is the callsite
 	if atomic.LoadUint32(&h.backtrace) > 0 {
@@ -199,14 +206,11 @@ func (h *GlogHandler) Log(r *Record) error {
 			r.Msg += "\n\n" + string(buf)
 		}
 	}
-	// If the global log level allows, fast track logging
-	if atomic.LoadUint32(&h.level) >= uint32(r.Lvl) {
-		r.Cnt = gGLogCounter
-		gGLogCounter++
-		return h.origin.Log(r)
-	}
-	// If no local overrides are present, fast track skipping
+	// If no local overrides are present, fast track on the global log level
 	if atomic.LoadUint32(&h.override) == 0 {
+		if atomic.LoadUint32(&h.level) >= uint32(r.Lvl) {
+			return h.emit(r)
+		}
 		return nil
 	}
 	// Check callsite cache for previously calculated log levels
@@ -217,20 +221,30 @@ func (h *GlogHandler) Log(r *Record) error {
 	// If we didn't cache the callsite yet, calculate it
 	if !ok {
 		h.lock.Lock()
+		lvl = lvlUnset
 		for _, rule := range h.patterns {
 			if rule.pattern.MatchString(fmt.Sprintf("%+s", r.Call)) {
-				h.siteCache[r.Call.PC()], lvl, ok = rule.level, rule.level, true
+				lvl = rule.level
 				break
 			}
 		}
-		// If no rule matched, remember to drop log the next time
-		if !ok {
-			h.siteCache[r.Call.PC()] = 0
-		}
+		h.siteCache[r.Call.PC()] = lvl
 		h.lock.Unlock()
 	}
+	// A matching module rule takes precedence over the global level, allowing
+	// noisy modules to be lowered as well as quiet ones raised
+	if lvl == lvlUnset {
+		lvl = Lvl(atomic.LoadUint32(&h.level))
+	}
 	if lvl >= r.Lvl {
-		return h.origin.Log(r)
+		return h.emit(r)
 	}
 	return nil
 }
+
+// emit numbers a record that passed the filters and hands it to the origin.
+func (h *GlogHandler) emit(r *Record) error {
+	r.Cnt = gGLogCounter
+	gGLogCounter++
+	return h.origin.Log(r)
+}
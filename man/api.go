@@ -36,6 +36,7 @@ import (
 	"github.com/matrix/go-matrix/core/state"
 	"github.com/matrix/go-matrix/core/types"
 	"github.com/matrix/go-matrix/log"
+	"github.com/matrix/go-matrix/man/propagation"
 	"github.com/matrix/go-matrix/mc"
 
 	"github.com/matrix/go-matrix/miner"
@@ -71,6 +72,61 @@ func (api *PublicMatrixAPI) Hashrate() hexutil.Uint64 {
 	return hexutil.Uint64(api.e.Miner().HashRate())
 }
 
+// RPCElectedNode is a single node of a simulated election topology.
+type RPCElectedNode struct {
+	Address common.Address `json:"address"`
+	Stock   hexutil.Uint64 `json:"stock"`
+}
+
+// RPCElection is the result of a simulated election.
+type RPCElection struct {
+	Number              hexutil.Uint64   `json:"number"`
+	NextElection        hexutil.Uint64   `json:"nextElection"`
+	Seed                *hexutil.Big     `json:"seed"`
+	MasterMiners        []RPCElectedNode `json:"masterMiners"`
+	BackupMiners        []RPCElectedNode `json:"backupMiners"`
+	MasterValidators    []RPCElectedNode `json:"masterValidators"`
+	BackupValidators    []RPCElectedNode `json:"backupValidators"`
+	CandidateValidators []RPCElectedNode `json:"candidateValidators"`
+	Broadcast           []common.Address `json:"broadcast"`
+}
+
+func newRPCElectedNodes(nodes []mc.TopologyNodeInfo) []RPCElectedNode {
+	result := make([]RPCElectedNode, len(nodes))
+	for i, node := range nodes {
+		result[i] = RPCElectedNode{Address: node.Account, Stock: hexutil.Uint64(node.Stock)}
+	}
+	return result
+}
+
+// SimulateElection runs the miner and validator elections read-only against the
+// deposits at the given block, returning the node sets that would be elected.
+// It lets operators check whether their deposit qualifies ahead of an election.
+func (api *PublicMatrixAPI) SimulateElection(blockNr rpc.BlockNumber) (*RPCElection, error) {
+	var number uint64
+	switch blockNr {
+	case rpc.LatestBlockNumber, rpc.PendingBlockNumber:
+		number = api.e.BlockChain().CurrentBlock().NumberU64()
+	default:
+		number = uint64(blockNr)
+	}
+	result, err := api.e.ReElection().SimulateElection(number)
+	if err != nil {
+		return nil, err
+	}
+	return &RPCElection{
+		Number:              hexutil.Uint64(result.Number),
+		NextElection:        hexutil.Uint64(common.GetNextReElectionNumber(result.Number)),
+		Seed:                (*hexutil.Big)(result.Seed),
+		MasterMiners:        newRPCElectedNodes(result.MasterMiner),
+		BackupMiners:        newRPCElectedNodes(result.BackUpMiner),
+		MasterValidators:    newRPCElectedNodes(result.MasterValidator),
+		BackupValidators:    newRPCElectedNodes(result.BackUpValidator),
+		CandidateValidators: newRPCElectedNodes(result.CandidateValidator),
+		Broadcast:           result.BroadCast,
+	}, nil
+}
+
 // PublicMinerAPI provides an API to control the miner.
 // It offers only methods that operate on data that pose no security risk when it is publicly accessible.
 type PublicMinerAPI struct {
@@ -448,12 +504,42 @@ func NewPublicDebugAPI(man *Matrix) *PublicDebugAPI {
 
 // DumpBlock retrieves the entire state of the database at a given block.
 func (api *PublicDebugAPI) DumpBlock(blockNr rpc.BlockNumber) (state.Dump, error) {
+	stateDb, err := api.stateAt(blockNr)
+	if err != nil {
+		return state.Dump{}, err
+	}
+	return stateDb.RawDump(), nil
+}
+
+// AccountRangeMaxResults is the maximum number of accounts returned by a single
+// debug_accountRange call.
+const AccountRangeMaxResults = 256
+
+// AccountRange enumerates the accounts of the state at the given block, starting
+// from the hashed trie key start. The returned next key resumes the enumeration
+// in a subsequent call, allowing the whole state to be paged through.
+func (api *PublicDebugAPI) AccountRange(blockNr rpc.BlockNumber, start hexutil.Bytes, maxResults int, nocode, nostorage bool) (state.IteratorDump, error) {
+	stateDb, err := api.stateAt(blockNr)
+	if err != nil {
+		return state.IteratorDump{}, err
+	}
+	if maxResults <= 0 || maxResults > AccountRangeMaxResults {
+		maxResults = AccountRangeMaxResults
+	}
+	return stateDb.DumpRange(start, maxResults, nocode, nostorage)
+}
+
+// stateAt returns the state database at the given block number.
+func (api *PublicDebugAPI) stateAt(blockNr rpc.BlockNumber) (*state.StateDB, error) {
 	if blockNr == rpc.PendingBlockNumber {
 		// If we're dumping the pending state, we need to request
 		// both the pending block as well as the pending state from
 		// the miner and operate on those
 		_, stateDb := api.man.miner.Pending()
-		return stateDb.RawDump(), nil
+		if stateDb == nil {
+			return nil, errors.New("pending state not available")
+		}
+		return stateDb, nil
 	}
 	var block *types.Block
 	if blockNr == rpc.LatestBlockNumber {
@@ -462,13 +548,9 @@ func (api *PublicDebugAPI) DumpBlock(blockNr rpc.BlockNumber) (state.Dump, error
 		block = api.man.blockchain.GetBlockByNumber(uint64(blockNr))
 	}
 	if block == nil {
-		return state.Dump{}, fmt.Errorf("block #%d not found", blockNr)
-	}
-	stateDb, err := api.man.BlockChain().StateAt(block.Root())
-	if err != nil {
-		return state.Dump{}, err
+		return nil, fmt.Errorf("block #%d not found", blockNr)
 	}
-	return stateDb.RawDump(), nil
+	return api.man.BlockChain().StateAt(block.Root())
 }
 
 // PrivateDebugAPI is the collection of Matrix full node APIs exposed over
@@ -484,6 +566,13 @@ func NewPrivateDebugAPI(config *params.ChainConfig, man *Matrix) *PrivateDebugAP
 	return &PrivateDebugAPI{config: config, man: man}
 }
 
+// PropagationStats returns when recent blocks and transactions were first seen
+// relative to each peer announcing and delivering them, and the latency from
+// block announcements to the full blocks arriving.
+func (api *PrivateDebugAPI) PropagationStats() *propagation.Stats {
+	return api.man.protocolManager.propagation.Stats()
+}
+
 // Preimage is a debug API function that returns the preimage for a sha3 hash, if known.
 func (api *PrivateDebugAPI) Preimage(ctx context.Context, hash common.Hash) (hexutil.Bytes, error) {
 	if preimage := rawdb.ReadPreimage(api.man.ChainDb(), hash); preimage != nil {
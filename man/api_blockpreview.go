@@ -0,0 +1,125 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package man
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/matrix/go-matrix/ca"
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/common/hexutil"
+	"github.com/matrix/go-matrix/core"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/matrixwork"
+)
+
+// BlockPreviewTransaction is a transaction selected into a block preview.
+type BlockPreviewTransaction struct {
+	Hash     common.Hash     `json:"hash"`
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to"`
+	Nonce    hexutil.Uint64  `json:"nonce"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	GasUsed  hexutil.Uint64  `json:"gasUsed"`
+	Fee      *hexutil.Big    `json:"fee"` // GasUsed * GasPrice
+	Status   hexutil.Uint64  `json:"status"`
+}
+
+// BlockPreview is the candidate block the local node would produce next.
+type BlockPreview struct {
+	Number       hexutil.Uint64             `json:"number"`
+	ParentHash   common.Hash                `json:"parentHash"`
+	Leader       common.Address             `json:"leader"`
+	Timestamp    hexutil.Uint64             `json:"timestamp"`
+	GasLimit     hexutil.Uint64             `json:"gasLimit"`
+	GasUsed      hexutil.Uint64             `json:"gasUsed"`
+	Broadcast    bool                       `json:"broadcast"` // Broadcast blocks carry no pool transactions
+	TotalFees    *hexutil.Big               `json:"totalFees"`
+	Transactions []*BlockPreviewTransaction `json:"transactions"`
+}
+
+// BuildBlockPreview assembles the block the node would produce on top of the
+// current head, selecting transactions from the pool the same way the block
+// generator does. The block is neither sealed nor broadcast and the preview
+// leaves the pool and the chain untouched.
+func (api *PublicMatrixAPI) BuildBlockPreview(ctx context.Context) (*BlockPreview, error) {
+	bc := api.e.BlockChain()
+	parent := bc.CurrentBlock()
+	if parent == nil {
+		return nil, errors.New("current block unavailable")
+	}
+	tstamp := time.Now().Unix()
+	if parent.Time().Cmp(big.NewInt(tstamp)) >= 0 {
+		tstamp = parent.Time().Int64() + 1
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Leader:     ca.GetAddress(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   core.CalcGasLimit(parent),
+		Extra:      make([]byte, 0),
+		Time:       big.NewInt(tstamp),
+		Version:    parent.Header().Version,
+	}
+	if err := api.e.Engine().Prepare(bc, header); err != nil {
+		return nil, err
+	}
+	preview := &BlockPreview{
+		Number:       hexutil.Uint64(header.Number.Uint64()),
+		ParentHash:   header.ParentHash,
+		Leader:       header.Leader,
+		Timestamp:    hexutil.Uint64(tstamp),
+		GasLimit:     hexutil.Uint64(header.GasLimit),
+		Broadcast:    common.IsBroadcastNumber(header.Number.Uint64()),
+		TotalFees:    new(hexutil.Big),
+		Transactions: []*BlockPreviewTransaction{},
+	}
+	if preview.Broadcast {
+		return preview, nil
+	}
+	work, err := matrixwork.NewWork(bc.Config(), bc, nil, header)
+	if err != nil {
+		return nil, err
+	}
+	_, txs := work.ProcessTransactions(nil, api.e.TxPool(), bc)
+
+	signer := types.NewEIP155Signer(bc.Config().ChainId)
+	total := new(big.Int)
+	for i, tx := range txs {
+		receipt := work.Receipts[i]
+		from, _ := types.Sender(signer, tx)
+		fee := new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), tx.GasPrice())
+		total.Add(total, fee)
+
+		preview.Transactions = append(preview.Transactions, &BlockPreviewTransaction{
+			Hash:     tx.Hash(),
+			From:     from,
+			To:       tx.To(),
+			Nonce:    hexutil.Uint64(tx.Nonce()),
+			GasPrice: (*hexutil.Big)(tx.GasPrice()),
+			GasUsed:  hexutil.Uint64(receipt.GasUsed),
+			Fee:      (*hexutil.Big)(fee),
+			Status:   hexutil.Uint64(receipt.Status),
+		})
+	}
+	preview.GasUsed = hexutil.Uint64(header.GasUsed)
+	preview.TotalFees = (*hexutil.Big)(total)
+	return preview, nil
+}
@@ -0,0 +1,137 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package man
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/common/hexutil"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/core/vm"
+	"github.com/matrix/go-matrix/rpc"
+)
+
+// maxContractStatsBlocks is the maximum number of blocks a single contract
+// statistics request is allowed to replay.
+const maxContractStatsBlocks = 4096
+
+// ContractStats is the aggregated usage of a contract over a block range.
+type ContractStats struct {
+	Address       common.Address `json:"address"`
+	FromBlock     hexutil.Uint64 `json:"fromBlock"`
+	ToBlock       hexutil.Uint64 `json:"toBlock"`
+	Transactions  hexutil.Uint64 `json:"transactions"`  // Transactions sent directly to the contract
+	TxGasUsed     hexutil.Uint64 `json:"txGasUsed"`     // Gas used by the transactions sent directly to the contract
+	Calls         hexutil.Uint64 `json:"calls"`         // Number of times the contract's code was executed
+	Gas           hexutil.Uint64 `json:"gas"`           // Gas consumed by the contract's own code
+	StorageWrites hexutil.Uint64 `json:"storageWrites"` // Storage writes executed by the contract
+	SlotsWritten  hexutil.Uint64 `json:"slotsWritten"`  // Distinct storage slots written by the contract
+}
+
+// ContractStats replays the blocks between fromBlock and toBlock (inclusive)
+// and aggregates the gas consumed, the storage written and the number of
+// calls made into the contract at address.
+func (api *PrivateDebugAPI) ContractStats(ctx context.Context, address common.Address, fromBlock, toBlock rpc.BlockNumber) (*ContractStats, error) {
+	var from, to *types.Block
+
+	switch fromBlock {
+	case rpc.PendingBlockNumber:
+		from = api.man.miner.PendingBlock()
+	case rpc.LatestBlockNumber:
+		from = api.man.blockchain.CurrentBlock()
+	default:
+		from = api.man.blockchain.GetBlockByNumber(uint64(fromBlock))
+	}
+	switch toBlock {
+	case rpc.PendingBlockNumber:
+		to = api.man.miner.PendingBlock()
+	case rpc.LatestBlockNumber:
+		to = api.man.blockchain.CurrentBlock()
+	default:
+		to = api.man.blockchain.GetBlockByNumber(uint64(toBlock))
+	}
+	if from == nil {
+		return nil, fmt.Errorf("starting block #%d not found", fromBlock)
+	}
+	if to == nil {
+		return nil, fmt.Errorf("end block #%d not found", toBlock)
+	}
+	if from.NumberU64() == 0 {
+		return nil, fmt.Errorf("genesis is not traceable")
+	}
+	if from.NumberU64() > to.NumberU64() {
+		return nil, fmt.Errorf("end block #%d before starting block #%d", to.NumberU64(), from.NumberU64())
+	}
+	if n := to.NumberU64() - from.NumberU64() + 1; n > maxContractStatsBlocks {
+		return nil, fmt.Errorf("block range too large: %d blocks, maximum %d", n, maxContractStatsBlocks)
+	}
+	// Create the parent state database to replay the range on top of
+	parent := api.man.blockchain.GetBlock(from.ParentHash(), from.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent %x not found", from.ParentHash())
+	}
+	statedb, err := api.computeStateDB(parent, defaultTraceReexec)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		tracer = vm.NewContractStatsTracer(address)
+		stats  = &ContractStats{
+			Address:   address,
+			FromBlock: hexutil.Uint64(from.NumberU64()),
+			ToBlock:   hexutil.Uint64(to.NumberU64()),
+		}
+	)
+	for number := from.NumberU64(); number <= to.NumberU64(); number++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		block := from
+		if number != from.NumberU64() {
+			if number == to.NumberU64() {
+				block = to
+			} else if block = api.man.blockchain.GetBlockByNumber(number); block == nil {
+				return nil, fmt.Errorf("block #%d not found", number)
+			}
+		}
+		receipts, _, _, err := api.man.blockchain.Processor().Process(block, statedb, vm.Config{Debug: true, Tracer: tracer})
+		if err != nil {
+			return nil, fmt.Errorf("processing block #%d failed: %v", number, err)
+		}
+		for i, tx := range block.Transactions() {
+			if recipient := tx.To(); recipient != nil && *recipient == address {
+				stats.Transactions++
+				stats.TxGasUsed += hexutil.Uint64(receipts[i].GasUsed)
+			}
+		}
+		// Finalize the state so any modifications are written to the trie
+		root, err := statedb.Commit(true)
+		if err != nil {
+			return nil, err
+		}
+		if err := statedb.Reset(root); err != nil {
+			return nil, err
+		}
+	}
+	stats.Calls = hexutil.Uint64(tracer.Calls)
+	stats.Gas = hexutil.Uint64(tracer.Gas)
+	stats.StorageWrites = hexutil.Uint64(tracer.StorageWrites)
+	stats.SlotsWritten = hexutil.Uint64(tracer.SlotsWritten())
+	return stats, nil
+}
@@ -18,6 +18,7 @@
 package man
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math/big"
@@ -35,6 +36,7 @@ import (
 	"github.com/matrix/go-matrix/accounts"
 	"github.com/matrix/go-matrix/accounts/signhelper"
 	"github.com/matrix/go-matrix/blkconsensus/blkverify"
+	"github.com/matrix/go-matrix/blkconsensus/slashing"
 	"github.com/matrix/go-matrix/blockgenor"
 	"github.com/matrix/go-matrix/broadcastTx"
 	"github.com/matrix/go-matrix/common"
@@ -42,15 +44,18 @@ import (
 	"github.com/matrix/go-matrix/consensus"
 	"github.com/matrix/go-matrix/consensus/clique"
 	"github.com/matrix/go-matrix/consensus/manash"
+	"github.com/matrix/go-matrix/contracts/verify"
 	"github.com/matrix/go-matrix/core"
 	"github.com/matrix/go-matrix/core/bloombits"
 	"github.com/matrix/go-matrix/core/rawdb"
+	"github.com/matrix/go-matrix/core/relay"
 	"github.com/matrix/go-matrix/core/types"
 	"github.com/matrix/go-matrix/core/vm"
 	"github.com/matrix/go-matrix/depoistInfo"
 	"github.com/matrix/go-matrix/man/downloader"
 	"github.com/matrix/go-matrix/man/filters"
 	"github.com/matrix/go-matrix/man/gasprice"
+	"github.com/matrix/go-matrix/man/tokens"
 	"github.com/matrix/go-matrix/mandb"
 	"github.com/matrix/go-matrix/event"
 	"github.com/matrix/go-matrix/hd"
@@ -87,6 +92,9 @@ type Matrix struct {
 
 	// Handlers
 	txPool          *core.TxPool
+	relay           *relay.Relay        // Sponsored transaction relay, nil if disabled
+	slashing        *slashing.Collector // Double-signing evidence collector
+	verifier        *verify.Verifier    // Contract source verifier, nil if disabled
 	blockchain      *core.BlockChain
 	protocolManager *ProtocolManager
 	lesServer       LesServer
@@ -104,6 +112,7 @@ type Matrix struct {
 	APIBackend *EthAPIBackend
 
 	miner     *miner.Miner
+	sealer    *miner.Sealer // Signer based block production, nil unless the engine is clique
 	gasPrice  *big.Int
 	manbase common.Address
 
@@ -182,7 +191,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Matrix, error) {
 	}
 	var (
 		vmConfig    = vm.Config{EnablePreimageRecording: config.EnablePreimageRecording}
-		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieNodeLimit: config.TrieCache, TrieTimeLimit: config.TrieTimeout}
+		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieCleanLimit: config.TrieCleanCache, TrieNodeLimit: config.TrieCache, TrieTimeLimit: config.TrieTimeout, GCWindow: config.GCWindow}
 	)
 	man.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, man.chainConfig, man.engine, vmConfig)
 	if err != nil {
@@ -201,6 +210,45 @@ func New(ctx *node.ServiceContext, config *Config) (*Matrix, error) {
 	}
 	man.txPool = core.NewTxPool(config.TxPool, man.chainConfig, man.blockchain, ctx.GetConfig().DataDir)
 
+	if sponsor := config.Relay.Sponsor; sponsor != (common.Address{}) {
+		account := accounts.Account{Address: sponsor}
+		wallet, err := man.accountManager.Find(account)
+		if err != nil {
+			return nil, fmt.Errorf("relay sponsor %x: %v", sponsor, err)
+		}
+		man.relay = relay.New(config.Relay, man.chainConfig.ChainId, man.txPool, func(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+			return wallet.SignTx(account, tx, chainID)
+		})
+		log.Info("Sponsored transaction relay enabled", "sponsor", sponsor, "contracts", len(config.Relay.Contracts))
+	}
+	var reportTx slashing.SignTxFn
+	if reporter := config.Slashing.Reporter; reporter != (common.Address{}) {
+		if config.Slashing.Contract == (common.Address{}) {
+			return nil, fmt.Errorf("slashing reporter %x configured without a slashing contract", reporter)
+		}
+		account := accounts.Account{Address: reporter}
+		wallet, err := man.accountManager.Find(account)
+		if err != nil {
+			return nil, fmt.Errorf("slashing reporter %x: %v", reporter, err)
+		}
+		reportTx = func(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+			return wallet.SignTx(account, tx, chainID)
+		}
+		log.Info("Slashing evidence reporting enabled", "reporter", reporter, "contract", config.Slashing.Contract)
+	}
+	man.slashing = slashing.New(config.Slashing, chainDb, man.chainConfig.ChainId, man.txPool, reportTx)
+
+	if config.ContractVerify.Enabled {
+		man.verifier = verify.New(config.ContractVerify, func(ctx context.Context, address common.Address) ([]byte, error) {
+			statedb, err := man.blockchain.State()
+			if err != nil {
+				return nil, err
+			}
+			return statedb.GetCode(address), nil
+		})
+		log.Info("Contract source verification enabled", "ipfs", config.ContractVerify.IPFSGateway, "swarm", config.ContractVerify.SwarmGateway)
+	}
+
 	if man.protocolManager, err = NewProtocolManager(man.chainConfig, config.SyncMode, config.NetworkId, man.eventMux, man.txPool, man.engine, man.blockchain, chainDb, ctx.MsgCenter); err != nil {
 		return nil, err
 	}
@@ -211,6 +259,10 @@ func New(ctx *node.ServiceContext, config *Config) (*Matrix, error) {
 		return nil, err
 	}
 	man.miner.SetExtra(makeExtraData(config.ExtraData))
+	if chainConfig.Clique != nil {
+		man.sealer = miner.NewSealer(man.blockchain, man.txPool, man.EventMux(), chainConfig.Clique.Period)
+		man.sealer.SetExtra(makeExtraData(config.ExtraData))
+	}
 
 	//algorithm
 	dbDir := ctx.GetConfig().DataDir
@@ -231,6 +283,12 @@ func New(ctx *node.ServiceContext, config *Config) (*Matrix, error) {
 	man.APIBackend.gpo = gasprice.NewOracle(man.APIBackend, gpoParams)
 	depoistInfo.NewDepositInfo(man.APIBackend)
 	man.broadTx = broadcastTx.NewBroadCast(man.APIBackend) //YY
+
+	// Proof-of-authority chains are sealed by their signers directly, skip the
+	// validator election and block generation machinery.
+	if man.sealer != nil {
+		return man, nil
+	}
 	man.leaderServer, err = verifier.NewLeaderIdentityService(man, "leader服务")
 
 	man.topNode = topnode.NewTopNodeService(man.blockchain.DPOSEngine())
@@ -276,11 +334,11 @@ func makeExtraData(extra []byte) []byte {
 
 // CreateDB creates the chain database.
 func CreateDB(ctx *node.ServiceContext, config *Config, name string) (mandb.Database, error) {
-	db, err := ctx.OpenDatabase(name, config.DatabaseCache, config.DatabaseHandles)
+	db, err := ctx.OpenDatabaseWithFreezer(name, config.DatabaseCache, config.DatabaseHandles, config.DatabaseFreezer)
 	if err != nil {
 		return nil, err
 	}
-	if db, ok := db.(*mandb.LDBDatabase); ok {
+	if db, ok := db.(mandb.KeyValueStore); ok {
 		db.Meter("man/db/chaindata/")
 	}
 	return db, nil
@@ -288,9 +346,9 @@ func CreateDB(ctx *node.ServiceContext, config *Config, name string) (mandb.Data
 
 // CreateConsensusEngine creates the required type of consensus engine instance for an Matrix service
 func CreateConsensusEngine(ctx *node.ServiceContext, config *manash.Config, chainConfig *params.ChainConfig, db mandb.Database) consensus.Engine {
-	// If proof-of-authority is requested, set it up
-	if chainConfig.Clique != nil {
-		return clique.New(chainConfig.Clique, db)
+	// If the chain config selects a registered engine (e.g. clique), set it up
+	if engine, err := consensus.New(chainConfig, db); err == nil {
+		return engine
 	}
 	// Otherwise assume proof-of-work
 	switch config.PowMode {
@@ -325,8 +383,8 @@ func (s *Matrix) APIs() []rpc.API {
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
 
-	// Append all the local APIs and return
-	return append(apis, []rpc.API{
+	// Append all the local APIs
+	apis = append(apis, []rpc.API{
 		{
 			Namespace: "man",
 			Version:   "1.0",
@@ -372,6 +430,21 @@ func (s *Matrix) APIs() []rpc.API {
 			Version:   "1.0",
 			Service:   filters.NewPublicFilterAPI(s.APIBackend, false),
 			Public:    true,
+		}, {
+			Namespace: "man",
+			Version:   "1.0",
+			Service:   tokens.NewPublicTokenAPI(s.APIBackend),
+			Public:    true,
+		}, {
+			Namespace: "man",
+			Version:   "1.0",
+			Service:   gasprice.NewPublicEstimatorAPI(gasprice.NewEstimator(s.APIBackend)),
+			Public:    true,
+		}, {
+			Namespace: "man",
+			Version:   "1.0",
+			Service:   gasprice.NewPublicGasHistoryAPI(s.APIBackend),
+			Public:    true,
 		}, {
 			Namespace: "admin",
 			Version:   "1.0",
@@ -392,6 +465,32 @@ func (s *Matrix) APIs() []rpc.API {
 			Public:    true,
 		},
 	}...)
+
+	apis = append(apis, rpc.API{
+		Namespace: "man",
+		Version:   "1.0",
+		Service:   slashing.NewPublicSlashingAPI(s.slashing),
+		Public:    true,
+	})
+	// Expose contract source verification if enabled
+	if s.verifier != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "man",
+			Version:   "1.0",
+			Service:   verify.NewPublicVerifyAPI(s.verifier),
+			Public:    true,
+		})
+	}
+	// Expose the sponsored transaction relay if a sponsor is configured
+	if s.relay != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "man",
+			Version:   "1.0",
+			Service:   relay.NewPublicRelayAPI(s.relay),
+			Public:    true,
+		})
+	}
+	return apis
 }
 
 func (s *Matrix) ResetWithGenesisBlock(gb *types.Block) {
@@ -451,12 +550,29 @@ func (s *Matrix) StartMining(local bool) error {
 		// will ensure that private networks work in single miner mode too.
 		atomic.StoreUint32(&s.protocolManager.acceptTxs, 1)
 	}
+	if s.sealer != nil {
+		s.sealer.Start()
+		return nil
+	}
 	go s.miner.Start(eb)
 	return nil
 }
 
-func (s *Matrix) StopMining()         { s.miner.Stop() }
-func (s *Matrix) IsMining() bool      { return s.miner.Mining() }
+func (s *Matrix) StopMining() {
+	if s.sealer != nil {
+		s.sealer.Stop()
+		return
+	}
+	s.miner.Stop()
+}
+
+func (s *Matrix) IsMining() bool {
+	if s.sealer != nil {
+		return s.sealer.Mining()
+	}
+	return s.miner.Mining()
+}
+
 func (s *Matrix) Miner() *miner.Miner { return s.miner }
 
 func (s *Matrix) AccountManager() *accounts.Manager  { return s.accountManager }
@@ -508,6 +624,9 @@ func (s *Matrix) Start(srvr *p2p.Server) error {
 	if s.lesServer != nil {
 		s.lesServer.Start(srvr)
 	}
+	if err := s.slashing.Start(); err != nil {
+		return err
+	}
 	//s.broadTx.Start()//YY
 	return nil
 }
@@ -532,8 +651,12 @@ func (s *Matrix) Stop() error {
 	if s.lesServer != nil {
 		s.lesServer.Stop()
 	}
+	s.slashing.Stop()
 	s.txPool.Stop()
 	s.miner.Stop()
+	if s.sealer != nil {
+		s.sealer.Stop()
+	}
 	s.eventMux.Stop()
 
 	s.chainDb.Close()
@@ -24,10 +24,13 @@ import (
 	"runtime"
 	"time"
 
+	"github.com/matrix/go-matrix/blkconsensus/slashing"
 	"github.com/matrix/go-matrix/common"
 	"github.com/matrix/go-matrix/common/hexutil"
 	"github.com/matrix/go-matrix/consensus/manash"
+	"github.com/matrix/go-matrix/contracts/verify"
 	"github.com/matrix/go-matrix/core"
+	"github.com/matrix/go-matrix/core/relay"
 	"github.com/matrix/go-matrix/man/downloader"
 	"github.com/matrix/go-matrix/man/gasprice"
 	"github.com/matrix/go-matrix/params"
@@ -43,18 +46,22 @@ var DefaultConfig = Config{
 		DatasetsInMem:  1,
 		DatasetsOnDisk: 2,
 	},
-	NetworkId:     1,
-	LightPeers:    100,
-	DatabaseCache: 768,
-	TrieCache:     256,
-	TrieTimeout:   5 * time.Minute,
-	GasPrice:      big.NewInt(18 * params.Shannon),
+	NetworkId:      1,
+	LightPeers:     100,
+	DatabaseCache:  512,
+	TrieCleanCache: 256,
+	TrieCache:      256,
+	TrieTimeout:    5 * time.Minute,
+	GasPrice:       big.NewInt(18 * params.Shannon),
 
 	TxPool: core.DefaultTxPoolConfig,
 	GPO: gasprice.Config{
 		Blocks:     20,
 		Percentile: 60,
 	},
+	Relay:          relay.DefaultConfig,
+	Slashing:       slashing.DefaultConfig,
+	ContractVerify: verify.DefaultConfig,
 }
 
 func init() {
@@ -82,6 +89,7 @@ type Config struct {
 	NetworkId uint64 // Network ID to use for selecting peers to connect to
 	SyncMode  downloader.SyncMode
 	NoPruning bool
+	GCWindow  uint64 `toml:",omitempty"` // Number of recent blocks to retain state for (0 = no window pruning)
 
 	// Light client options
 	LightServ  int `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
@@ -91,6 +99,8 @@ type Config struct {
 	SkipBcVersionCheck bool `toml:"-"`
 	DatabaseHandles    int  `toml:"-"`
 	DatabaseCache      int
+	DatabaseFreezer    string `toml:",omitempty"` // Directory of the ancient store (default = inside the chain database)
+	TrieCleanCache     int
 	TrieCache          int
 	TrieTimeout        time.Duration
 
@@ -109,6 +119,15 @@ type Config struct {
 	// Gas Price Oracle options
 	GPO gasprice.Config
 
+	// Sponsored transaction relay options
+	Relay relay.Config
+
+	// Slashing evidence collection options
+	Slashing slashing.Config
+
+	// Contract source verification options
+	ContractVerify verify.Config
+
 	// Enables tracking of SHA3 preimages in the VM
 	EnablePreimageRecording bool
 
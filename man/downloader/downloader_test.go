@@ -32,6 +32,7 @@ import (
 	"github.com/matrix/go-matrix/crypto"
 	"github.com/matrix/go-matrix/mandb"
 	"github.com/matrix/go-matrix/event"
+	"github.com/matrix/go-matrix/log"
 	"github.com/matrix/go-matrix/params"
 	"github.com/matrix/go-matrix/trie"
 )
@@ -1753,3 +1754,82 @@ func testDeliverHeadersHang(t *testing.T, protocol int, mode SyncMode) {
 		tester.downloader.peers.peers["peer"].peer.(*floodingTestPeer).pend.Wait()
 	}
 }
+
+// Tests that the first throughput measurements of a peer are weighted heavily
+// enough for its estimate to converge quickly, and that a failed delivery
+// restarts the convergence.
+func TestThroughputAdaptation(t *testing.T) {
+	p := newPeerConnection("peer", 63, nil, log.New())
+
+	deliver := func(items int, elapsed time.Duration) {
+		atomic.StoreInt32(&p.blockIdle, 1)
+		p.blockStarted = time.Now().Add(-elapsed)
+		p.SetBodiesIdle(items)
+	}
+	// Five deliveries of 100 bodies/sec should land well above the halfway mark
+	for i := 0; i < 5; i++ {
+		deliver(100, time.Second)
+	}
+	if p.blockThroughput < 75 {
+		t.Fatalf("throughput converged too slowly: have %f, want >= 75", p.blockThroughput)
+	}
+	if atomic.LoadInt32(&p.blockIdle) != 0 {
+		t.Fatalf("peer not idled after delivery")
+	}
+	// An empty delivery drops the estimate and the sample history
+	deliver(0, time.Second)
+	if p.blockThroughput != 0 || p.blockSamples != 0 {
+		t.Fatalf("empty delivery not reset: throughput %f, samples %d", p.blockThroughput, p.blockSamples)
+	}
+	// Once past the warmup, single outliers only have the steady state impact
+	for i := 0; i < 20; i++ {
+		deliver(100, time.Second)
+	}
+	before := p.blockThroughput
+	deliver(1000, time.Second)
+	if jump := p.blockThroughput - before; jump > measurementImpact*1000 {
+		t.Fatalf("steady state outlier moved estimate by %f", jump)
+	}
+}
+
+// Tests that free result slots are shared proportionally between idle peers
+// when they can't satisfy every peer's capacity.
+func TestShareCapacity(t *testing.T) {
+	tests := []struct {
+		capacity, free, wanted, share int
+	}{
+		{128, 1000, 256, 128}, // enough slots, no scaling
+		{128, 128, 256, 64},   // half the wanted slots
+		{96, 64, 128, 48},     // proportional to the peer's capacity
+		{2, 10, 1000, 1},      // never drop a peer below a single item
+		{5, 0, 0, 5},          // nothing wanted, nothing to scale
+	}
+	for i, tt := range tests {
+		if share := shareCapacity(tt.capacity, tt.free, tt.wanted); share != tt.share {
+			t.Errorf("test %d: share mismatch: have %d, want %d", i, share, tt.share)
+		}
+	}
+}
+
+// Benchmarks a fast sync against peers of uneven latency, where the result
+// cache can't hold the capacity of every idle peer at once.
+func BenchmarkFastSyncUnevenPeers(b *testing.B) {
+	delays := []time.Duration{time.Millisecond, 5 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond}
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		tester := newTester()
+		targetBlocks := 4 * blockCacheItems
+		hashes, headers, blocks, receipts := tester.makeChain(targetBlocks, 0, tester.genesis, nil, false)
+		for j, delay := range delays {
+			tester.newSlowPeer(fmt.Sprintf("peer #%d", j), 63, hashes, headers, blocks, receipts, delay)
+		}
+		b.StartTimer()
+
+		if err := tester.sync("peer #0", nil, FastSync); err != nil {
+			b.Fatalf("failed to synchronise blocks: %v", err)
+		}
+		b.StopTimer()
+		tester.terminate()
+	}
+}
@@ -59,6 +59,11 @@ type peerConnection struct {
 	receiptThroughput float64 // Number of receipts measured to be retrievable per second
 	stateThroughput   float64 // Number of node data pieces measured to be retrievable per second
 
+	headerSamples  int // Number of header throughput measurements since the last reset
+	blockSamples   int // Number of block (body) throughput measurements since the last reset
+	receiptSamples int // Number of receipt throughput measurements since the last reset
+	stateSamples   int // Number of node data throughput measurements since the last reset
+
 	rtt time.Duration // Request round trip time to track responsiveness (QoS)
 
 	headerStarted  time.Time // Time instance when the last header fetch was started
@@ -140,6 +145,8 @@ func (p *peerConnection) Reset() {
 	p.receiptThroughput = 0
 	p.stateThroughput = 0
 
+	p.headerSamples, p.blockSamples, p.receiptSamples, p.stateSamples = 0, 0, 0, 0
+
 	p.lacking = make(map[common.Hash]struct{})
 }
 
@@ -226,40 +233,45 @@ func (p *peerConnection) FetchNodeData(hashes []common.Hash) error {
 // requests. Its estimated header retrieval throughput is updated with that measured
 // just now.
 func (p *peerConnection) SetHeadersIdle(delivered int) {
-	p.setIdle(p.headerStarted, delivered, &p.headerThroughput, &p.headerIdle)
+	p.setIdle(p.headerStarted, delivered, &p.headerThroughput, &p.headerSamples, &p.headerIdle)
 }
 
 // SetBlocksIdle sets the peer to idle, allowing it to execute new block retrieval
 // requests. Its estimated block retrieval throughput is updated with that measured
 // just now.
 func (p *peerConnection) SetBlocksIdle(delivered int) {
-	p.setIdle(p.blockStarted, delivered, &p.blockThroughput, &p.blockIdle)
+	p.setIdle(p.blockStarted, delivered, &p.blockThroughput, &p.blockSamples, &p.blockIdle)
 }
 
 // SetBodiesIdle sets the peer to idle, allowing it to execute block body retrieval
 // requests. Its estimated body retrieval throughput is updated with that measured
 // just now.
 func (p *peerConnection) SetBodiesIdle(delivered int) {
-	p.setIdle(p.blockStarted, delivered, &p.blockThroughput, &p.blockIdle)
+	p.setIdle(p.blockStarted, delivered, &p.blockThroughput, &p.blockSamples, &p.blockIdle)
 }
 
 // SetReceiptsIdle sets the peer to idle, allowing it to execute new receipt
 // retrieval requests. Its estimated receipt retrieval throughput is updated
 // with that measured just now.
 func (p *peerConnection) SetReceiptsIdle(delivered int) {
-	p.setIdle(p.receiptStarted, delivered, &p.receiptThroughput, &p.receiptIdle)
+	p.setIdle(p.receiptStarted, delivered, &p.receiptThroughput, &p.receiptSamples, &p.receiptIdle)
 }
 
 // SetNodeDataIdle sets the peer to idle, allowing it to execute new state trie
 // data retrieval requests. Its estimated state retrieval throughput is updated
 // with that measured just now.
 func (p *peerConnection) SetNodeDataIdle(delivered int) {
-	p.setIdle(p.stateStarted, delivered, &p.stateThroughput, &p.stateIdle)
+	p.setIdle(p.stateStarted, delivered, &p.stateThroughput, &p.stateSamples, &p.stateIdle)
 }
 
 // setIdle sets the peer to idle, allowing it to execute new retrieval requests.
 // Its estimated retrieval throughput is updated with that measured just now.
-func (p *peerConnection) setIdle(started time.Time, delivered int, throughput *float64, idle *int32) {
+//
+// The first few measurements after a reset are weighted more heavily than the
+// steady state measurementImpact, so that a freshly connected (or recovering)
+// peer converges onto its real throughput within a handful of requests instead
+// of dozens.
+func (p *peerConnection) setIdle(started time.Time, delivered int, throughput *float64, samples *int, idle *int32) {
 	// Irrelevant of the scaling, make sure the peer ends up idle
 	defer atomic.StoreInt32(idle, 0)
 
@@ -268,14 +280,17 @@ func (p *peerConnection) setIdle(started time.Time, delivered int, throughput *f
 
 	// If nothing was delivered (hard timeout / unavailable data), reduce throughput to minimum
 	if delivered == 0 {
-		*throughput = 0
+		*throughput, *samples = 0, 0
 		return
 	}
 	// Otherwise update the throughput with a new measurement
 	elapsed := time.Since(started) + 1 // +1 (ns) to ensure non-zero divisor
 	measured := float64(delivered) / (float64(elapsed) / float64(time.Second))
 
-	*throughput = (1-measurementImpact)*(*throughput) + measurementImpact*measured
+	*samples++
+	impact := math.Max(measurementImpact, 1/float64(*samples+1))
+
+	*throughput = (1-impact)*(*throughput) + impact*measured
 	p.rtt = time.Duration((1-measurementImpact)*float64(p.rtt) + measurementImpact*float64(elapsed))
 
 	p.log.Trace("Peer throughput measurements updated",
@@ -538,13 +553,9 @@ func (ps *peerSet) idlePeers(minProtocol, maxProtocol int, idleCheck func(*peerC
 			total++
 		}
 	}
-	for i := 0; i < len(idle); i++ {
-		for j := i + 1; j < len(idle); j++ {
-			if throughput(idle[i]) < throughput(idle[j]) {
-				idle[i], idle[j] = idle[j], idle[i]
-			}
-		}
-	}
+	sort.SliceStable(idle, func(i, j int) bool {
+		return throughput(idle[i]) > throughput(idle[j])
+	})
 	return idle, total
 }
 
@@ -215,22 +215,22 @@ func (q *queue) Idle() bool {
 	return (queued + pending + cached) == 0
 }
 
-// ShouldThrottleBlocks checks if the download should be throttled (active block (body)
-// fetches exceed block cache).
-func (q *queue) ShouldThrottleBlocks() bool {
+// BlockSlots retrieves the number of result slots still available for block
+// (body) fetches. A non-positive value means the download should be throttled.
+func (q *queue) BlockSlots() int {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
-	return q.resultSlots(q.blockPendPool, q.blockDonePool) <= 0
+	return q.resultSlots(q.blockPendPool, q.blockDonePool)
 }
 
-// ShouldThrottleReceipts checks if the download should be throttled (active receipt
-// fetches exceed block cache).
-func (q *queue) ShouldThrottleReceipts() bool {
+// ReceiptSlots retrieves the number of result slots still available for receipt
+// fetches. A non-positive value means the download should be throttled.
+func (q *queue) ReceiptSlots() int {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
-	return q.resultSlots(q.receiptPendPool, q.receiptDonePool) <= 0
+	return q.resultSlots(q.receiptPendPool, q.receiptDonePool)
 }
 
 // resultSlots calculates the number of results slots available for requests
@@ -336,7 +336,7 @@ func (q *queue) Schedule(headers []*types.Header, from uint64) []*types.Header {
 		q.blockTaskPool[hash] = header
 		q.blockTaskQueue.Push(header, -float32(header.Number.Uint64()))
 
-		if q.mode == FastSync {
+		if q.mode.isFast() {
 			q.receiptTaskPool[hash] = header
 			q.receiptTaskQueue.Push(header, -float32(header.Number.Uint64()))
 		}
@@ -514,7 +514,7 @@ func (q *queue) reserveHeaders(p *peerConnection, count int, taskPool map[common
 		}
 		if q.resultCache[index] == nil {
 			components := 1
-			if q.mode == FastSync {
+			if q.mode.isFast() {
 				components = 2
 			}
 			q.resultCache[index] = &fetchResult{
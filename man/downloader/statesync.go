@@ -28,6 +28,7 @@ import (
 	"github.com/matrix/go-matrix/crypto/sha3"
 	"github.com/matrix/go-matrix/mandb"
 	"github.com/matrix/go-matrix/log"
+	"github.com/matrix/go-matrix/man/snap"
 	"github.com/matrix/go-matrix/trie"
 )
 
@@ -59,6 +60,9 @@ type stateSyncStats struct {
 
 // syncState starts downloading state with the given root hash.
 func (d *Downloader) syncState(root common.Hash) *stateSync {
+	if d.mode == SnapSync {
+		return d.syncSnapState(root)
+	}
 	s := newStateSync(d, root)
 	select {
 	case d.stateSyncStart <- s:
@@ -69,6 +73,25 @@ func (d *Downloader) syncState(root common.Hash) *stateSync {
 	return s
 }
 
+// syncSnapState starts downloading the state with the given root hash over the
+// snap protocol. The returned sync only supports cancellation and waiting.
+func (d *Downloader) syncSnapState(root common.Hash) *stateSync {
+	s := &stateSync{
+		d:      d,
+		cancel: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(s.done)
+
+		s.err = d.SnapSyncer.Sync(root, s.cancel)
+		if s.err == snap.ErrCancelled {
+			s.err = errCancelStateFetch
+		}
+	}()
+	return s
+}
+
 // stateFetcher manages the active state sync and accepts requests
 // on its behalf.
 func (d *Downloader) stateFetcher() {
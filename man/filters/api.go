@@ -28,9 +28,10 @@ import (
 	matrix "github.com/matrix/go-matrix"
 	"github.com/matrix/go-matrix/common"
 	"github.com/matrix/go-matrix/common/hexutil"
+	"github.com/matrix/go-matrix/core"
 	"github.com/matrix/go-matrix/core/types"
-	"github.com/matrix/go-matrix/mandb"
 	"github.com/matrix/go-matrix/event"
+	"github.com/matrix/go-matrix/mandb"
 	"github.com/matrix/go-matrix/rpc"
 )
 
@@ -59,6 +60,7 @@ type PublicFilterAPI struct {
 	events    *EventSystem
 	filtersMu sync.Mutex
 	filters   map[rpc.ID]*filter
+	persistMu sync.Mutex // Serializes access to the persistent filters in chainDb
 }
 
 // NewPublicFilterAPI returns a new PublicFilterAPI instance.
@@ -75,7 +77,8 @@ func NewPublicFilterAPI(backend Backend, lightMode bool) *PublicFilterAPI {
 	return api
 }
 
-// timeoutLoop runs every 5 minutes and deletes filters that have not been recently used.
+// timeoutLoop runs every 5 minutes and deletes filters that have not been recently used,
+// including persistent filters that outlived their TTL.
 // Tt is started when the api is created.
 func (api *PublicFilterAPI) timeoutLoop() {
 	ticker := time.NewTicker(5 * time.Minute)
@@ -92,6 +95,8 @@ func (api *PublicFilterAPI) timeoutLoop() {
 			}
 		}
 		api.filtersMu.Unlock()
+
+		api.prunePersistentFilters()
 	}
 }
 
@@ -168,6 +173,144 @@ func (api *PublicFilterAPI) NewPendingTransactions(ctx context.Context) (*rpc.Su
 	return rpcSub, nil
 }
 
+// PendingTransactionsCriteria restricts a fullPendingTransactions subscription
+// to transactions sent to one of the given addresses. Matrix transactions match
+// when either the primary or one of the extra recipients is listed.
+type PendingTransactionsCriteria struct {
+	To []common.Address `json:"to"`
+}
+
+// matches reports whether tx satisfies the criteria. Empty criteria match
+// every transaction.
+func (crit *PendingTransactionsCriteria) matches(tx *types.Transaction) bool {
+	if crit == nil || len(crit.To) == 0 {
+		return true
+	}
+	included := func(addr *common.Address) bool {
+		if addr == nil {
+			return false
+		}
+		for _, to := range crit.To {
+			if to == *addr {
+				return true
+			}
+		}
+		return false
+	}
+	if included(tx.To()) {
+		return true
+	}
+	for _, ext := range tx.GetMatrix_EX() {
+		for _, e := range ext.ExtraTo {
+			if included(e.Recipient) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RPCPendingExtraTo is the RPC representation of an additional recipient of a
+// Matrix transaction.
+type RPCPendingExtraTo struct {
+	To    *common.Address `json:"to"`
+	Value *hexutil.Big    `json:"value"`
+	Input hexutil.Bytes   `json:"input"`
+}
+
+// RPCPendingTransaction is the RPC representation of a transaction in the
+// pending state, as streamed by fullPendingTransactions subscriptions. It
+// serializes like the transaction objects returned by man_getTransactionByHash.
+type RPCPendingTransaction struct {
+	BlockHash        common.Hash          `json:"blockHash"`
+	BlockNumber      *hexutil.Big         `json:"blockNumber"`
+	From             common.Address       `json:"from"`
+	Gas              hexutil.Uint64       `json:"gas"`
+	GasPrice         *hexutil.Big         `json:"gasPrice"`
+	Hash             common.Hash          `json:"hash"`
+	Input            hexutil.Bytes        `json:"input"`
+	Nonce            hexutil.Uint64       `json:"nonce"`
+	To               *common.Address      `json:"to"`
+	TransactionIndex hexutil.Uint         `json:"transactionIndex"`
+	Value            *hexutil.Big         `json:"value"`
+	V                *hexutil.Big         `json:"v"`
+	R                *hexutil.Big         `json:"r"`
+	S                *hexutil.Big         `json:"s"`
+	ExtraTo          []*RPCPendingExtraTo `json:"extra_to"`
+}
+
+// newRPCPendingTransaction returns a pending transaction that will serialize
+// to the RPC representation.
+func newRPCPendingTransaction(tx *types.Transaction) *RPCPendingTransaction {
+	var signer types.Signer = types.FrontierSigner{}
+	if tx.Protected() {
+		signer = types.NewEIP155Signer(tx.ChainId())
+	}
+	from, _ := types.Sender(signer, tx)
+	v, r, s := tx.RawSignatureValues()
+
+	result := &RPCPendingTransaction{
+		From:     from,
+		Gas:      hexutil.Uint64(tx.Gas()),
+		GasPrice: (*hexutil.Big)(tx.GasPrice()),
+		Hash:     tx.Hash(),
+		Input:    hexutil.Bytes(tx.Data()),
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+		To:       tx.To(),
+		Value:    (*hexutil.Big)(tx.Value()),
+		V:        (*hexutil.Big)(v),
+		R:        (*hexutil.Big)(r),
+		S:        (*hexutil.Big)(s),
+	}
+	for _, ext := range tx.GetMatrix_EX() {
+		for _, e := range ext.ExtraTo {
+			result.ExtraTo = append(result.ExtraTo, &RPCPendingExtraTo{
+				To:    e.Recipient,
+				Value: (*hexutil.Big)(e.Amount),
+				Input: hexutil.Bytes(e.Payload),
+			})
+		}
+	}
+	return result
+}
+
+// FullPendingTransactions creates a subscription that is triggered each time a
+// transaction enters the transaction pool. Unlike NewPendingTransactions it
+// delivers the complete transaction, optionally restricted to the recipients
+// listed in crit.
+func (api *PublicFilterAPI) FullPendingTransactions(ctx context.Context, crit *PendingTransactionsCriteria) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		pendingTxs := make(chan []*types.Transaction, 128)
+		pendingTxSub := api.events.SubscribeFullPendingTxs(pendingTxs)
+
+		for {
+			select {
+			case txs := <-pendingTxs:
+				for _, tx := range txs {
+					if crit.matches(tx) {
+						notifier.Notify(rpcSub.ID, newRPCPendingTransaction(tx))
+					}
+				}
+			case <-rpcSub.Err():
+				pendingTxSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				pendingTxSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // NewBlockFilter creates a filter that fetches blocks that are imported into the chain.
 // It is part of the filter package since polling goes with man_getFilterChanges.
 //
@@ -233,6 +376,55 @@ func (api *PublicFilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, er
 	return rpcSub, nil
 }
 
+// ChainReorg sends a notification each time the canonical chain is reorganised,
+// carrying the common ancestor together with the dropped and adopted blocks.
+func (api *PublicFilterAPI) ChainReorg(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		reorgs := make(chan core.ChainReorgEvent)
+		reorgsSub := api.events.SubscribeChainReorg(reorgs)
+
+		for {
+			select {
+			case ev := <-reorgs:
+				notifier.Notify(rpcSub.ID, newRPCChainReorg(ev))
+			case <-rpcSub.Err():
+				reorgsSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				reorgsSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// RPCChainReorg is the notification sent to chainReorg subscribers. Dropped and
+// Added are ordered from the common ancestor upwards.
+type RPCChainReorg struct {
+	CommonAncestor       common.Hash    `json:"commonAncestor"`
+	CommonAncestorNumber hexutil.Uint64 `json:"commonAncestorNumber"`
+	Dropped              []common.Hash  `json:"dropped"`
+	Added                []common.Hash  `json:"added"`
+}
+
+func newRPCChainReorg(ev core.ChainReorgEvent) *RPCChainReorg {
+	return &RPCChainReorg{
+		CommonAncestor:       ev.CommonAncestor.Hash(),
+		CommonAncestorNumber: hexutil.Uint64(ev.CommonAncestor.Number.Uint64()),
+		Dropped:              ev.Dropped,
+		Added:                ev.Added,
+	}
+}
+
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
 func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
@@ -341,7 +533,8 @@ func (api *PublicFilterAPI) GetLogs(ctx context.Context, crit FilterCriteria) ([
 	return returnLogs(logs), err
 }
 
-// UninstallFilter removes the filter with the given filter id.
+// UninstallFilter removes the filter with the given filter id, which may also
+// be a persistent filter.
 //
 // https://github.com/matrix/wiki/wiki/JSON-RPC#man_uninstallfilter
 func (api *PublicFilterAPI) UninstallFilter(id rpc.ID) bool {
@@ -353,9 +546,9 @@ func (api *PublicFilterAPI) UninstallFilter(id rpc.ID) bool {
 	api.filtersMu.Unlock()
 	if found {
 		f.s.Unsubscribe()
+		return true
 	}
-
-	return found
+	return api.uninstallPersistentFilter(id)
 }
 
 // GetFilterLogs returns the logs for the filter with the given id.
@@ -367,8 +560,15 @@ func (api *PublicFilterAPI) GetFilterLogs(ctx context.Context, id rpc.ID) ([]*ty
 	f, found := api.filters[id]
 	api.filtersMu.Unlock()
 
-	if !found || f.typ != LogsSubscription {
-		return nil, fmt.Errorf("filter not found")
+	if !found {
+		logs, err := api.persistentFilterLogs(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return returnLogs(logs), nil
+	}
+	if f.typ != LogsSubscription {
+		return nil, errFilterNotFound
 	}
 
 	begin := rpc.LatestBlockNumber.Int64()
@@ -393,14 +593,14 @@ func (api *PublicFilterAPI) GetFilterLogs(ctx context.Context, id rpc.ID) ([]*ty
 // last time it was called. This can be used for polling.
 //
 // For pending transaction and block filters the result is []common.Hash.
-// (pending)Log filters return []Log.
+// (pending)Log filters and persistent filters return []Log.
 //
 // https://github.com/matrix/wiki/wiki/JSON-RPC#man_getfilterchanges
 func (api *PublicFilterAPI) GetFilterChanges(id rpc.ID) (interface{}, error) {
 	api.filtersMu.Lock()
-	defer api.filtersMu.Unlock()
-
 	if f, found := api.filters[id]; found {
+		defer api.filtersMu.Unlock()
+
 		if !f.deadline.Stop() {
 			// timer expired but filter is not yet removed in timeout loop
 			// receive timer value and reset timer
@@ -418,9 +618,15 @@ func (api *PublicFilterAPI) GetFilterChanges(id rpc.ID) (interface{}, error) {
 			f.logs = nil
 			return returnLogs(logs), nil
 		}
+		return []interface{}{}, errFilterNotFound
 	}
+	api.filtersMu.Unlock()
 
-	return []interface{}{}, fmt.Errorf("filter not found")
+	logs, err := api.persistentFilterChanges(context.Background(), id)
+	if err != nil {
+		return []interface{}{}, err
+	}
+	return returnLogs(logs), nil
 }
 
 // returnHashes is a helper that will return an empty hash array case the given hash array is nil,
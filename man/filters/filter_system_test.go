@@ -46,6 +46,7 @@ type testBackend struct {
 	rmLogsFeed *event.Feed
 	logsFeed   *event.Feed
 	chainFeed  *event.Feed
+	reorgFeed  *event.Feed
 }
 
 func (b *testBackend) ChainDb() mandb.Database {
@@ -112,6 +113,10 @@ func (b *testBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subsc
 	return b.chainFeed.Subscribe(ch)
 }
 
+func (b *testBackend) SubscribeChainReorgEvent(ch chan<- core.ChainReorgEvent) event.Subscription {
+	return b.reorgFeed.Subscribe(ch)
+}
+
 func (b *testBackend) BloomStatus() (uint64, uint64) {
 	return params.BloomBitsBlocks, b.sections
 }
@@ -158,7 +163,7 @@ func TestBlockSubscription(t *testing.T) {
 		rmLogsFeed  = new(event.Feed)
 		logsFeed    = new(event.Feed)
 		chainFeed   = new(event.Feed)
-		backend     = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend     = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
 		api         = NewPublicFilterAPI(backend, false)
 		genesis     = new(core.Genesis).MustCommit(db)
 		chain, _    = core.GenerateChain(params.TestChainConfig, genesis, manash.NewFaker(), db, 10, func(i int, gen *core.BlockGen) {})
@@ -204,6 +209,46 @@ func TestBlockSubscription(t *testing.T) {
 	<-sub1.Err()
 }
 
+// TestChainReorgSubscription tests if a reorg subscription relays the common
+// ancestor, dropped and adopted block hashes of posted reorg events.
+func TestChainReorgSubscription(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mux       = new(event.TypeMux)
+		db        = mandb.NewMemDatabase()
+		reorgFeed = new(event.Feed)
+		backend   = &testBackend{mux, db, 0, new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed), reorgFeed}
+		api       = NewPublicFilterAPI(backend, false)
+		genesis   = new(core.Genesis).MustCommit(db)
+		chain, _  = core.GenerateChain(params.TestChainConfig, genesis, manash.NewFaker(), db, 4, func(i int, gen *core.BlockGen) {})
+		reorg     = core.ChainReorgEvent{
+			CommonAncestor: genesis.Header(),
+			Dropped:        []common.Hash{common.HexToHash("0x01"), common.HexToHash("0x02")},
+			Added:          []common.Hash{chain[0].Hash(), chain[1].Hash(), chain[2].Hash()},
+		}
+	)
+
+	reorgs := make(chan core.ChainReorgEvent)
+	sub := api.events.SubscribeChainReorg(reorgs)
+	defer sub.Unsubscribe()
+
+	go reorgFeed.Send(reorg)
+
+	select {
+	case ev := <-reorgs:
+		if !reflect.DeepEqual(ev, reorg) {
+			t.Errorf("reorg event mismatch: have %+v, want %+v", ev, reorg)
+		}
+		notification := newRPCChainReorg(ev)
+		if notification.CommonAncestor != genesis.Hash() || notification.CommonAncestorNumber != 0 {
+			t.Errorf("common ancestor mismatch: have %x/%d, want %x/0", notification.CommonAncestor, notification.CommonAncestorNumber, genesis.Hash())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for reorg event")
+	}
+}
+
 // TestPendingTxFilter tests whether pending tx filters retrieve all pending transactions that are posted to the event mux.
 func TestPendingTxFilter(t *testing.T) {
 	t.Parallel()
@@ -215,7 +260,7 @@ func TestPendingTxFilter(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
 		api        = NewPublicFilterAPI(backend, false)
 
 		transactions = []*types.Transaction{
@@ -265,6 +310,72 @@ func TestPendingTxFilter(t *testing.T) {
 	}
 }
 
+// TestFullPendingTxSubscription tests whether pending transactions are delivered
+// in full and filtered by recipient.
+func TestFullPendingTxSubscription(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mux        = new(event.TypeMux)
+		db         = mandb.NewMemDatabase()
+		txFeed     = new(event.Feed)
+		rmLogsFeed = new(event.Feed)
+		logsFeed   = new(event.Feed)
+		chainFeed  = new(event.Feed)
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
+		api        = NewPublicFilterAPI(backend, false)
+
+		watched = common.HexToAddress("0xb794f5ea0ba39494ce83a213fffba74279579268")
+		other   = common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+
+		transactions = []*types.Transaction{
+			types.NewTransaction(0, watched, big.NewInt(1), 0, new(big.Int), nil),
+			types.NewTransaction(1, other, big.NewInt(2), 0, new(big.Int), nil),
+			types.NewContractCreation(2, new(big.Int), 0, new(big.Int), nil),
+			types.NewTransaction(3, watched, big.NewInt(4), 0, new(big.Int), []byte{0x01}),
+		}
+	)
+
+	txs := make(chan []*types.Transaction)
+	sub := api.events.SubscribeFullPendingTxs(txs)
+	defer sub.Unsubscribe()
+
+	go txFeed.Send(core.NewTxsEvent{Txs: transactions})
+
+	select {
+	case got := <-txs:
+		if len(got) != len(transactions) {
+			t.Fatalf("invalid number of transactions, want %d, got %d", len(transactions), len(got))
+		}
+		for i := range got {
+			if got[i].Hash() != transactions[i].Hash() {
+				t.Errorf("txs[%d] invalid, want %x, got %x", i, transactions[i].Hash(), got[i].Hash())
+			}
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for pending transactions")
+	}
+
+	crit := &PendingTransactionsCriteria{To: []common.Address{watched}}
+	for i, tx := range transactions {
+		want := i == 0 || i == 3
+		if have := crit.matches(tx); have != want {
+			t.Errorf("tx %d: match mismatch, want %t, have %t", i, want, have)
+		}
+		if !(*PendingTransactionsCriteria)(nil).matches(tx) {
+			t.Errorf("tx %d: not matched by empty criteria", i)
+		}
+	}
+
+	rpcTx := newRPCPendingTransaction(transactions[3])
+	if rpcTx.Hash != transactions[3].Hash() || rpcTx.To == nil || *rpcTx.To != watched {
+		t.Errorf("invalid rpc transaction: %+v", rpcTx)
+	}
+	if rpcTx.Value.ToInt().Cmp(big.NewInt(4)) != 0 || len(rpcTx.Input) != 1 {
+		t.Errorf("invalid rpc transaction value or input: %+v", rpcTx)
+	}
+}
+
 // TestLogFilterCreation test whether a given filter criteria makes sense.
 // If not it must return an error.
 func TestLogFilterCreation(t *testing.T) {
@@ -275,7 +386,7 @@ func TestLogFilterCreation(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
 		api        = NewPublicFilterAPI(backend, false)
 
 		testCases = []struct {
@@ -324,7 +435,7 @@ func TestInvalidLogFilterCreation(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
 		api        = NewPublicFilterAPI(backend, false)
 	)
 
@@ -354,7 +465,7 @@ func TestLogFilter(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
 		api        = NewPublicFilterAPI(backend, false)
 
 		firstAddr      = common.HexToAddress("0x1111111111111111111111111111111111111111")
@@ -473,7 +584,7 @@ func TestPendingLogsSubscription(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
 		api        = NewPublicFilterAPI(backend, false)
 
 		firstAddr      = common.HexToAddress("0x1111111111111111111111111111111111111111")
@@ -0,0 +1,416 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package filters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/common/hexutil"
+	"github.com/matrix/go-matrix/core/rawdb"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/log"
+	"github.com/matrix/go-matrix/rpc"
+)
+
+const (
+	// persistentFilterTTL is the default time a persistent filter is kept
+	// without being polled.
+	persistentFilterTTL = 24 * time.Hour
+
+	// maxPersistentFilterTTL is the longest time a client may ask a persistent
+	// filter to be kept without being polled.
+	maxPersistentFilterTTL = 7 * 24 * time.Hour
+
+	// maxPersistentFilters caps the number of persistent filters stored in the
+	// database.
+	maxPersistentFilters = 1024
+
+	// maxPersistentFilterRange caps the number of blocks a single poll of a
+	// persistent filter catches up on, the rest is delivered by later polls.
+	maxPersistentFilterRange = 10000
+
+	// maxPersistentFilterIDLength caps the length of client chosen filter ids.
+	maxPersistentFilterIDLength = 64
+)
+
+var (
+	persistentFilterPrefix   = []byte("pfilter-") // persistentFilterPrefix + id -> persistent filter
+	persistentFilterIndexKey = []byte("pfilters") // persistentFilterIndexKey -> ids of all persistent filters
+)
+
+var (
+	errFilterNotFound       = errors.New("filter not found")
+	errInvalidFilterID      = errors.New("invalid filter id")
+	errFilterExists         = errors.New("filter already exists with different criteria")
+	errTooManyFilters       = errors.New("too many persistent filters")
+	errPendingPersistentLog = errors.New("persistent filters don't support pending logs")
+)
+
+// persistentCriteria is the part of a persistent filter chosen by the client.
+type persistentCriteria struct {
+	FromBlock int64            `json:"fromBlock"`
+	ToBlock   int64            `json:"toBlock"`
+	Addresses []common.Address `json:"addresses"`
+	Topics    [][]common.Hash  `json:"topics"`
+	TTL       uint64           `json:"ttl"` // Seconds the filter is kept without being polled
+}
+
+// persistentFilter is a log filter stored in the node database. Instead of
+// buffering matched logs it remembers the last block it delivered, so that
+// changes missed while the node was down are recomputed from the chain.
+type persistentFilter struct {
+	persistentCriteria
+
+	Expires int64       `json:"expires"` // Unix time after which the filter is dropped
+	Number  uint64      `json:"number"`  // Last block delivered to the client
+	Hash    common.Hash `json:"hash"`    // Hash of the last block delivered to the client
+}
+
+// expired reports whether the filter has not been polled within its TTL.
+func (f *persistentFilter) expired(now time.Time) bool {
+	return now.Unix() > f.Expires
+}
+
+// touch extends the lifetime of the filter by its TTL.
+func (f *persistentFilter) touch(now time.Time) {
+	f.Expires = now.Add(time.Duration(f.TTL) * time.Second).Unix()
+}
+
+// validPersistentFilterID reports whether id may be chosen for a persistent
+// filter.
+func validPersistentFilterID(id rpc.ID) bool {
+	if len(id) == 0 || len(id) > maxPersistentFilterIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= '0' && c <= '9', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '-', c == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// NewPersistentFilter creates a log filter under the client chosen id that is
+// stored in the node database. Unlike filters created by man_newFilter it
+// survives node restarts, and is only dropped once it hasn't been polled for
+// ttl seconds (24 hours by default, at most a week). Changes are retrieved
+// with man_getFilterChanges as usual; logs of blocks imported while the node
+// was down are delivered by the next poll.
+//
+// Creating a filter under an existing id with the same criteria extends its
+// lifetime and keeps its position, so clients may safely call it after every
+// reconnect.
+func (api *PublicFilterAPI) NewPersistentFilter(id rpc.ID, crit FilterCriteria, ttl *hexutil.Uint64) (rpc.ID, error) {
+	if !validPersistentFilterID(id) {
+		return "", fmt.Errorf("%v: must be 1-%d letters, digits, '-' or '_'", errInvalidFilterID, maxPersistentFilterIDLength)
+	}
+	criteria := persistentCriteria{
+		FromBlock: rpc.LatestBlockNumber.Int64(),
+		ToBlock:   rpc.LatestBlockNumber.Int64(),
+		Addresses: crit.Addresses,
+		Topics:    crit.Topics,
+		TTL:       uint64(persistentFilterTTL / time.Second),
+	}
+	if crit.FromBlock != nil {
+		criteria.FromBlock = crit.FromBlock.Int64()
+	}
+	if crit.ToBlock != nil {
+		criteria.ToBlock = crit.ToBlock.Int64()
+	}
+	if criteria.FromBlock == rpc.PendingBlockNumber.Int64() || criteria.ToBlock == rpc.PendingBlockNumber.Int64() {
+		return "", errPendingPersistentLog
+	}
+	if ttl != nil {
+		if *ttl == 0 || time.Duration(*ttl)*time.Second > maxPersistentFilterTTL {
+			return "", fmt.Errorf("invalid ttl %d, want 1-%d seconds", *ttl, uint64(maxPersistentFilterTTL/time.Second))
+		}
+		criteria.TTL = uint64(*ttl)
+	}
+	api.filtersMu.Lock()
+	_, live := api.filters[id]
+	api.filtersMu.Unlock()
+	if live {
+		return "", errFilterExists
+	}
+
+	api.persistMu.Lock()
+	defer api.persistMu.Unlock()
+
+	now := time.Now()
+	if f := api.readPersistentFilter(id); f != nil && !f.expired(now) {
+		have, _ := json.Marshal(f.persistentCriteria)
+		want, _ := json.Marshal(criteria)
+		if !bytes.Equal(have, want) {
+			return "", errFilterExists
+		}
+		f.touch(now)
+		return id, api.writePersistentFilter(id, f)
+	}
+	ids := api.readPersistentFilterIndex()
+	if !containsID(ids, id) {
+		if len(ids) >= maxPersistentFilters {
+			return "", errTooManyFilters
+		}
+		ids = append(ids, id)
+	}
+	head, err := api.backend.HeaderByNumber(context.Background(), rpc.LatestBlockNumber)
+	if err != nil {
+		return "", err
+	}
+	if head == nil {
+		return "", errors.New("no chain head")
+	}
+	f := &persistentFilter{persistentCriteria: criteria, Number: head.Number.Uint64(), Hash: head.Hash()}
+	f.touch(now)
+
+	if err := api.writePersistentFilter(id, f); err != nil {
+		return "", err
+	}
+	if err := api.writePersistentFilterIndex(ids); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// persistentFilterChanges returns the logs matched by a persistent filter
+// since it was last polled. Logs of blocks reorged out since are returned
+// first, with their removed flag set.
+func (api *PublicFilterAPI) persistentFilterChanges(ctx context.Context, id rpc.ID) ([]*types.Log, error) {
+	api.persistMu.Lock()
+	defer api.persistMu.Unlock()
+
+	f, err := api.livePersistentFilter(id)
+	if err != nil {
+		return nil, err
+	}
+	removed, err := api.unwindPersistentFilter(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	head, err := api.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	var logs []*types.Log
+	if head != nil {
+		begin, end := f.Number+1, head.Number.Uint64()
+		if f.FromBlock >= 0 && uint64(f.FromBlock) > begin {
+			begin = uint64(f.FromBlock)
+		}
+		if f.ToBlock >= 0 && uint64(f.ToBlock) < end {
+			end = uint64(f.ToBlock)
+		}
+		if end >= f.Number+maxPersistentFilterRange {
+			end = f.Number + maxPersistentFilterRange
+		}
+		if begin <= end {
+			if logs, err = New(api.backend, int64(begin), int64(end), f.Addresses, f.Topics).Logs(ctx); err != nil {
+				return nil, err
+			}
+		}
+		if end > f.Number {
+			last, err := api.backend.HeaderByNumber(ctx, rpc.BlockNumber(end))
+			if err != nil {
+				return nil, err
+			}
+			if last != nil {
+				f.Number, f.Hash = end, last.Hash()
+			}
+		}
+	}
+	f.touch(time.Now())
+	if err := api.writePersistentFilter(id, f); err != nil {
+		return nil, err
+	}
+	return append(removed, logs...), nil
+}
+
+// unwindPersistentFilter moves the position of a persistent filter back to the
+// canonical chain if the blocks it delivered were reorged out, returning the
+// matching logs of the dropped blocks marked as removed.
+func (api *PublicFilterAPI) unwindPersistentFilter(ctx context.Context, f *persistentFilter) ([]*types.Log, error) {
+	var removed []*types.Log
+	for i := 0; i < maxPersistentFilterRange && f.Number > 0; i++ {
+		canon, err := api.backend.HeaderByNumber(ctx, rpc.BlockNumber(f.Number))
+		if err != nil {
+			return nil, err
+		}
+		if canon != nil && canon.Hash() == f.Hash {
+			break
+		}
+		stale := rawdb.ReadHeader(api.chainDb, f.Hash, f.Number)
+		if stale == nil {
+			// The dropped block is unknown, the removed logs can't be recovered
+			if canon != nil {
+				f.Hash = canon.Hash()
+				break
+			}
+			f.Number--
+			continue
+		}
+		blockLogs, err := api.backend.GetLogs(ctx, f.Hash)
+		if err != nil {
+			return nil, err
+		}
+		var unfiltered []*types.Log
+		for _, logs := range blockLogs {
+			unfiltered = append(unfiltered, logs...)
+		}
+		for _, l := range filterLogs(unfiltered, nil, nil, f.Addresses, f.Topics) {
+			cpy := *l
+			cpy.Removed = true
+			removed = append(removed, &cpy)
+		}
+		f.Number, f.Hash = f.Number-1, stale.ParentHash
+	}
+	return removed, nil
+}
+
+// persistentFilterLogs returns all logs matching the criteria of a persistent
+// filter.
+func (api *PublicFilterAPI) persistentFilterLogs(ctx context.Context, id rpc.ID) ([]*types.Log, error) {
+	api.persistMu.Lock()
+	f, err := api.livePersistentFilter(id)
+	if err == nil {
+		f.touch(time.Now())
+		err = api.writePersistentFilter(id, f)
+	}
+	api.persistMu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+	return New(api.backend, f.FromBlock, f.ToBlock, f.Addresses, f.Topics).Logs(ctx)
+}
+
+// uninstallPersistentFilter deletes a persistent filter, reporting whether it
+// existed.
+func (api *PublicFilterAPI) uninstallPersistentFilter(id rpc.ID) bool {
+	api.persistMu.Lock()
+	defer api.persistMu.Unlock()
+
+	if _, err := api.livePersistentFilter(id); err != nil {
+		return false
+	}
+	api.deletePersistentFilter(id)
+	return true
+}
+
+// prunePersistentFilters deletes the persistent filters that have not been
+// polled within their TTL.
+func (api *PublicFilterAPI) prunePersistentFilters() {
+	api.persistMu.Lock()
+	defer api.persistMu.Unlock()
+
+	now := time.Now()
+	for _, id := range api.readPersistentFilterIndex() {
+		if f := api.readPersistentFilter(id); f == nil || f.expired(now) {
+			log.Debug("Dropping expired persistent filter", "id", id)
+			api.deletePersistentFilter(id)
+		}
+	}
+}
+
+// livePersistentFilter loads a persistent filter, deleting it instead if it
+// has expired. The caller must hold persistMu.
+func (api *PublicFilterAPI) livePersistentFilter(id rpc.ID) (*persistentFilter, error) {
+	f := api.readPersistentFilter(id)
+	if f == nil {
+		return nil, errFilterNotFound
+	}
+	if f.expired(time.Now()) {
+		api.deletePersistentFilter(id)
+		return nil, errFilterNotFound
+	}
+	return f, nil
+}
+
+func (api *PublicFilterAPI) readPersistentFilter(id rpc.ID) *persistentFilter {
+	data, _ := api.chainDb.Get(append(persistentFilterPrefix, id...))
+	if len(data) == 0 {
+		return nil
+	}
+	f := new(persistentFilter)
+	if err := json.Unmarshal(data, f); err != nil {
+		log.Error("Invalid persistent filter", "id", id, "err", err)
+		return nil
+	}
+	return f
+}
+
+func (api *PublicFilterAPI) writePersistentFilter(id rpc.ID, f *persistentFilter) error {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return api.chainDb.Put(append(persistentFilterPrefix, id...), data)
+}
+
+// deletePersistentFilter removes a persistent filter and its index entry. The
+// caller must hold persistMu.
+func (api *PublicFilterAPI) deletePersistentFilter(id rpc.ID) {
+	if err := api.chainDb.Delete(append(persistentFilterPrefix, id...)); err != nil {
+		log.Error("Failed to delete persistent filter", "id", id, "err", err)
+	}
+	ids := api.readPersistentFilterIndex()
+	for i, have := range ids {
+		if have == id {
+			ids = append(ids[:i], ids[i+1:]...)
+			break
+		}
+	}
+	if err := api.writePersistentFilterIndex(ids); err != nil {
+		log.Error("Failed to update persistent filter index", "err", err)
+	}
+}
+
+func (api *PublicFilterAPI) readPersistentFilterIndex() []rpc.ID {
+	data, _ := api.chainDb.Get(persistentFilterIndexKey)
+	if len(data) == 0 {
+		return nil
+	}
+	var ids []rpc.ID
+	if err := json.Unmarshal(data, &ids); err != nil {
+		log.Error("Invalid persistent filter index", "err", err)
+		return nil
+	}
+	return ids
+}
+
+func (api *PublicFilterAPI) writePersistentFilterIndex(ids []rpc.ID) error {
+	data, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	return api.chainDb.Put(persistentFilterIndexKey, data)
+}
+
+func containsID(ids []rpc.ID, id rpc.ID) bool {
+	for _, have := range ids {
+		if have == id {
+			return true
+		}
+	}
+	return false
+}
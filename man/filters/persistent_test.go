@@ -0,0 +1,169 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package filters
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/common/hexutil"
+	"github.com/matrix/go-matrix/consensus/manash"
+	"github.com/matrix/go-matrix/core"
+	"github.com/matrix/go-matrix/core/rawdb"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/event"
+	"github.com/matrix/go-matrix/mandb"
+	"github.com/matrix/go-matrix/params"
+	"github.com/matrix/go-matrix/rpc"
+)
+
+// generateLogChain creates n blocks on top of parent, each holding a single
+// log with the given topic emitted by addr.
+func generateLogChain(db mandb.Database, parent *types.Block, n int, addr common.Address, topic common.Hash) []*types.Block {
+	blocks, receipts := core.GenerateChain(params.TestChainConfig, parent, manash.NewFaker(), db, n, func(i int, gen *core.BlockGen) {
+		receipt := types.NewReceipt(nil, false, 0)
+		receipt.Logs = []*types.Log{{Address: addr, Topics: []common.Hash{topic}}}
+		gen.AddUncheckedReceipt(receipt)
+	})
+	for i, block := range blocks {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	return blocks
+}
+
+// setCanonical makes the given blocks the canonical chain head.
+func setCanonical(db mandb.Database, blocks []*types.Block) {
+	for _, block := range blocks {
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+	}
+}
+
+func checkPersistentLogs(t *testing.T, changes interface{}, err error, wantTopics []common.Hash, wantRemoved []bool) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("failed to get filter changes: %v", err)
+	}
+	logs := changes.([]*types.Log)
+	if len(logs) != len(wantTopics) {
+		t.Fatalf("log count mismatch: have %d, want %d", len(logs), len(wantTopics))
+	}
+	for i, log := range logs {
+		if log.Topics[0] != wantTopics[i] || log.Removed != wantRemoved[i] {
+			t.Errorf("log %d: have topic %x removed %v, want topic %x removed %v", i, log.Topics[0], log.Removed, wantTopics[i], wantRemoved[i])
+		}
+	}
+}
+
+func TestPersistentFilter(t *testing.T) {
+	var (
+		db      = mandb.NewMemDatabase()
+		backend = &testBackend{new(event.TypeMux), db, 0, new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed)}
+		addr    = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		other   = common.HexToAddress("0x2222222222222222222222222222222222222222")
+		topic1  = common.BytesToHash([]byte("topic1"))
+		topic2  = common.BytesToHash([]byte("topic2"))
+		genesis = new(core.Genesis).MustCommit(db)
+	)
+	setCanonical(db, []*types.Block{genesis})
+
+	chain := generateLogChain(db, genesis, 2, addr, topic1)
+	setCanonical(db, chain)
+
+	api := NewPublicFilterAPI(backend, false)
+	crit := FilterCriteria{Addresses: []common.Address{addr}}
+	if _, err := api.NewPersistentFilter("my-filter", crit, nil); err != nil {
+		t.Fatalf("failed to create filter: %v", err)
+	}
+	// Blocks imported while the node is down are delivered after a restart
+	more := generateLogChain(db, chain[len(chain)-1], 3, addr, topic1)
+	setCanonical(db, more)
+	generateLogChain(db, more[len(more)-1], 1, other, topic1)
+
+	api = NewPublicFilterAPI(backend, false)
+	changes, err := api.GetFilterChanges("my-filter")
+	checkPersistentLogs(t, changes, err, []common.Hash{topic1, topic1, topic1}, []bool{false, false, false})
+
+	changes, err = api.GetFilterChanges("my-filter")
+	checkPersistentLogs(t, changes, err, nil, nil)
+
+	// Re-creating the filter with the same criteria keeps its position,
+	// different criteria are rejected
+	if _, err := api.NewPersistentFilter("my-filter", crit, nil); err != nil {
+		t.Fatalf("failed to re-create filter: %v", err)
+	}
+	if _, err := api.NewPersistentFilter("my-filter", FilterCriteria{Addresses: []common.Address{other}}, nil); err != errFilterExists {
+		t.Fatalf("re-creating filter with different criteria: have error %v, want %v", err, errFilterExists)
+	}
+	// Logs of reorged blocks are returned as removed before the new ones
+	fork := generateLogChain(db, more[0], 3, addr, topic2)
+	setCanonical(db, fork)
+
+	changes, err = api.GetFilterChanges("my-filter")
+	checkPersistentLogs(t, changes, err, []common.Hash{topic1, topic1, topic2, topic2, topic2}, []bool{true, true, false, false, false})
+
+	// Uninstalled filters are gone
+	if !api.UninstallFilter("my-filter") {
+		t.Fatalf("failed to uninstall filter")
+	}
+	if _, err := api.GetFilterChanges("my-filter"); err != errFilterNotFound {
+		t.Fatalf("polling uninstalled filter: have error %v, want %v", err, errFilterNotFound)
+	}
+	if ids := api.readPersistentFilterIndex(); len(ids) != 0 {
+		t.Fatalf("uninstalled filter still indexed: %v", ids)
+	}
+}
+
+func TestPersistentFilterExpiry(t *testing.T) {
+	var (
+		db      = mandb.NewMemDatabase()
+		backend = &testBackend{new(event.TypeMux), db, 0, new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed)}
+		genesis = new(core.Genesis).MustCommit(db)
+		api     = NewPublicFilterAPI(backend, false)
+		ttl     = hexutil.Uint64(60)
+	)
+	setCanonical(db, []*types.Block{genesis})
+
+	for _, id := range []rpc.ID{"", "white space", "0123456789012345678901234567890123456789012345678901234567890123456789"} {
+		if _, err := api.NewPersistentFilter(id, FilterCriteria{}, nil); err == nil {
+			t.Errorf("filter id %q: expected error", id)
+		}
+	}
+	if _, err := api.NewPersistentFilter("expiring", FilterCriteria{ToBlock: big.NewInt(rpc.PendingBlockNumber.Int64())}, nil); err != errPendingPersistentLog {
+		t.Errorf("pending filter: have error %v, want %v", err, errPendingPersistentLog)
+	}
+	if _, err := api.NewPersistentFilter("expiring", FilterCriteria{}, &ttl); err != nil {
+		t.Fatalf("failed to create filter: %v", err)
+	}
+	f := api.readPersistentFilter("expiring")
+	if f.TTL != 60 {
+		t.Fatalf("ttl mismatch: have %d, want 60", f.TTL)
+	}
+	// Filters not polled within their TTL are pruned
+	f.Expires = time.Now().Add(-time.Second).Unix()
+	api.writePersistentFilter("expiring", f)
+	api.prunePersistentFilters()
+
+	if f := api.readPersistentFilter("expiring"); f != nil {
+		t.Fatalf("expired filter not pruned: %+v", f)
+	}
+	if _, err := api.GetFilterChanges("expiring"); err != errFilterNotFound {
+		t.Fatalf("polling expired filter: have error %v, want %v", err, errFilterNotFound)
+	}
+}
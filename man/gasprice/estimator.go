@@ -0,0 +1,258 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package gasprice
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/common/hexutil"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/params"
+	"github.com/matrix/go-matrix/rpc"
+)
+
+const (
+	// estimateBlocks is the number of recent blocks the inclusion model is
+	// built from.
+	estimateBlocks = 60
+
+	// fullBlockRatio is the gas usage, in percent of the gas limit, above which
+	// a block is considered full and thus selective about the prices included.
+	fullBlockRatio = 90
+)
+
+// estimatePercentiles are the confirmation probabilities estimates are
+// reported for.
+var estimatePercentiles = []int{50, 75, 90, 95}
+
+var errNoBlocks = errors.New("no blocks to estimate from")
+
+// EstimatorBackend is the chain and pool access needed to estimate
+// confirmation times.
+type EstimatorBackend interface {
+	HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error)
+	BlockByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Block, error)
+	GetPoolTransactions() (types.Transactions, error)
+	ChainConfig() *params.ChainConfig
+}
+
+// blockSample is the part of a recent block the inclusion model uses.
+type blockSample struct {
+	time     uint64
+	gasLimit uint64
+	full     bool     // Whether the block was filled up to fullBlockRatio
+	minPrice *big.Int // Lowest gas price included, nil for empty blocks
+}
+
+// includes reports whether a transaction paying price would have made it
+// into the sampled block.
+func (s *blockSample) includes(price *big.Int) bool {
+	return !s.full || s.minPrice == nil || price.Cmp(s.minPrice) >= 0
+}
+
+// Estimator predicts how many blocks a transaction paying a given gas price
+// waits for inclusion, based on the fullness and the included prices of
+// recent blocks and on the transactions outbidding it in the pool.
+type Estimator struct {
+	backend EstimatorBackend
+
+	lock     sync.Mutex
+	lastHead common.Hash
+	samples  []*blockSample // Samples of the recent blocks, newest first
+}
+
+// NewEstimator returns a new confirmation time estimator.
+func NewEstimator(backend EstimatorBackend) *Estimator {
+	return &Estimator{backend: backend}
+}
+
+// ConfirmationPercentile is the number of blocks within which a transaction is
+// included with a given probability. Blocks and Seconds are nil if recent
+// blocks suggest the transaction is not going to be included at all.
+type ConfirmationPercentile struct {
+	Percentile int             `json:"percentile"`
+	Blocks     *hexutil.Uint64 `json:"blocks"`
+	Seconds    *float64        `json:"seconds"`
+}
+
+// ConfirmationEstimate is the expected inclusion time of a transaction.
+type ConfirmationEstimate struct {
+	GasPrice    *hexutil.Big             `json:"gasPrice"`
+	Blocks      hexutil.Uint64           `json:"blocks"`      // Number of recent blocks sampled
+	BlockTime   float64                  `json:"blockTime"`   // Average block interval in seconds
+	Probability float64                  `json:"probability"` // Chance of inclusion in any single block
+	PendingGas  hexutil.Uint64           `json:"pendingGas"`  // Gas of the pending transactions paying more
+	Percentiles []ConfirmationPercentile `json:"percentiles"`
+}
+
+// Estimate returns the expected inclusion time of a transaction paying price.
+//
+// Every sampled block in which a transaction paying price would have been
+// included counts towards the per-block inclusion probability. Before that,
+// the pending transactions paying more need to be mined, which takes their
+// total gas divided by the average block gas limit blocks.
+func (e *Estimator) Estimate(ctx context.Context, price *big.Int) (*ConfirmationEstimate, error) {
+	samples, err := e.recentSamples(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := e.backend.GetPoolTransactions()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		included int
+		gasLimit uint64
+		ahead    uint64
+	)
+	for _, sample := range samples {
+		if sample.includes(price) {
+			included++
+		}
+		gasLimit += sample.gasLimit
+	}
+	gasLimit /= uint64(len(samples))
+
+	for _, tx := range pool {
+		if tx.GasPrice().Cmp(price) > 0 {
+			ahead += tx.Gas()
+		}
+	}
+	estimate := &ConfirmationEstimate{
+		GasPrice:    (*hexutil.Big)(new(big.Int).Set(price)),
+		Blocks:      hexutil.Uint64(len(samples)),
+		Probability: float64(included) / float64(len(samples)),
+		PendingGas:  hexutil.Uint64(ahead),
+	}
+	if n := len(samples); n > 1 && samples[0].time > samples[n-1].time {
+		estimate.BlockTime = float64(samples[0].time-samples[n-1].time) / float64(n-1)
+	}
+	var queued uint64
+	if gasLimit > 0 {
+		queued = ahead / gasLimit
+	}
+	for _, percentile := range estimatePercentiles {
+		result := ConfirmationPercentile{Percentile: percentile}
+		if blocks, ok := inclusionBlocks(estimate.Probability, percentile); ok {
+			blocks += queued
+			seconds := float64(blocks) * estimate.BlockTime
+
+			result.Blocks = (*hexutil.Uint64)(&blocks)
+			result.Seconds = &seconds
+		}
+		estimate.Percentiles = append(estimate.Percentiles, result)
+	}
+	return estimate, nil
+}
+
+// inclusionBlocks returns the number of blocks after which a transaction with
+// a per-block inclusion probability p is included with the given percentile
+// probability, that is the smallest k with 1-(1-p)^k >= percentile/100.
+func inclusionBlocks(p float64, percentile int) (uint64, bool) {
+	switch {
+	case p <= 0:
+		return 0, false
+	case p >= 1:
+		return 1, true
+	}
+	k := math.Ceil(math.Log(1-float64(percentile)/100) / math.Log(1-p))
+	if k < 1 {
+		k = 1
+	}
+	return uint64(k), true
+}
+
+// recentSamples returns the samples of the most recent blocks, regenerating
+// them if the chain head changed since the last call.
+func (e *Estimator) recentSamples(ctx context.Context) ([]*blockSample, error) {
+	head, err := e.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if head == nil {
+		if err == nil {
+			err = errNoBlocks
+		}
+		return nil, err
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if head.Hash() == e.lastHead {
+		return e.samples, nil
+	}
+	var (
+		signer  = types.MakeSigner(e.backend.ChainConfig(), head.Number)
+		samples []*blockSample
+	)
+	for number := head.Number.Uint64(); number > 0 && len(samples) < estimateBlocks; number-- {
+		block, err := e.backend.BlockByNumber(ctx, rpc.BlockNumber(number))
+		if block == nil {
+			if err == nil {
+				break
+			}
+			return nil, err
+		}
+		samples = append(samples, sampleBlock(signer, block))
+	}
+	if len(samples) == 0 {
+		return nil, errNoBlocks
+	}
+	e.lastHead, e.samples = head.Hash(), samples
+	return samples, nil
+}
+
+// sampleBlock extracts the fullness and the lowest gas price paid by someone
+// other than the miner from a block.
+func sampleBlock(signer types.Signer, block *types.Block) *blockSample {
+	sample := &blockSample{
+		time:     block.Time().Uint64(),
+		gasLimit: block.GasLimit(),
+		full:     block.GasUsed()*100 >= block.GasLimit()*fullBlockRatio,
+	}
+	txs := make([]*types.Transaction, len(block.Transactions()))
+	copy(txs, block.Transactions())
+	sort.Sort(transactionsByGasPrice(txs))
+
+	for _, tx := range txs {
+		if sender, err := types.Sender(signer, tx); err == nil && sender != block.Coinbase() {
+			sample.minPrice = tx.GasPrice()
+			break
+		}
+	}
+	return sample
+}
+
+// PublicEstimatorAPI offers confirmation time estimates to wallets.
+type PublicEstimatorAPI struct {
+	e *Estimator
+}
+
+// NewPublicEstimatorAPI creates a new confirmation time estimation API.
+func NewPublicEstimatorAPI(e *Estimator) *PublicEstimatorAPI {
+	return &PublicEstimatorAPI{e: e}
+}
+
+// EstimateConfirmationTime returns the number of blocks, and the corresponding
+// time, within which a transaction paying gasPrice is expected to be included
+// with 50, 75, 90 and 95 percent probability.
+func (api *PublicEstimatorAPI) EstimateConfirmationTime(ctx context.Context, gasPrice hexutil.Big) (*ConfirmationEstimate, error) {
+	return api.e.Estimate(ctx, (*big.Int)(&gasPrice))
+}
@@ -0,0 +1,190 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package gasprice
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/crypto"
+	"github.com/matrix/go-matrix/params"
+	"github.com/matrix/go-matrix/rpc"
+)
+
+var testConfig = &params.ChainConfig{ChainId: big.NewInt(1), EIP155Block: new(big.Int)}
+
+type testEstimatorBackend struct {
+	blocks  []*types.Block
+	pool    types.Transactions
+	fetched int
+}
+
+func (b *testEstimatorBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	if number == rpc.LatestBlockNumber {
+		number = rpc.BlockNumber(len(b.blocks) - 1)
+	}
+	return b.blocks[number].Header(), nil
+}
+
+func (b *testEstimatorBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	b.fetched++
+	if int(number) >= len(b.blocks) {
+		return nil, nil
+	}
+	return b.blocks[number], nil
+}
+
+func (b *testEstimatorBackend) GetPoolTransactions() (types.Transactions, error) {
+	return b.pool, nil
+}
+
+func (b *testEstimatorBackend) ChainConfig() *params.ChainConfig {
+	return testConfig
+}
+
+// newTestEstimatorBackend creates a chain of ten blocks, ten seconds apart,
+// where every odd block is full with a lowest gas price of minPrice and every
+// even block has spare capacity.
+func newTestEstimatorBackend(t *testing.T, minPrice int64) *testEstimatorBackend {
+	key, _ := crypto.GenerateKey()
+	signer := types.NewEIP155Signer(testConfig.ChainId)
+
+	b := &testEstimatorBackend{blocks: []*types.Block{types.NewBlockWithHeader(&types.Header{Number: new(big.Int), Time: new(big.Int)})}}
+	for i := int64(1); i <= 10; i++ {
+		header := &types.Header{
+			Number:   big.NewInt(i),
+			Time:     big.NewInt(i * 10),
+			GasLimit: 1000000,
+			GasUsed:  500000,
+		}
+		price := big.NewInt(params.Shannon)
+		if i%2 == 1 {
+			header.GasUsed = 950000
+			price = big.NewInt(minPrice * params.Shannon)
+		}
+		tx, err := types.SignTx(types.NewTransaction(uint64(i), common.Address{}, new(big.Int), params.TxGas, price, nil), signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.blocks = append(b.blocks, types.NewBlockWithHeader(header).WithBody([]*types.Transaction{tx}, nil))
+	}
+	return b
+}
+
+func checkBlocks(t *testing.T, estimate *ConfirmationEstimate, want []uint64) {
+	t.Helper()
+	for i, p := range estimate.Percentiles {
+		if want == nil {
+			if p.Blocks != nil || p.Seconds != nil {
+				t.Errorf("percentile %d: have %v blocks, want none", p.Percentile, p.Blocks)
+			}
+			continue
+		}
+		if p.Blocks == nil || uint64(*p.Blocks) != want[i] {
+			t.Errorf("percentile %d: have %v blocks, want %d", p.Percentile, p.Blocks, want[i])
+			continue
+		}
+		if *p.Seconds != float64(want[i])*estimate.BlockTime {
+			t.Errorf("percentile %d: have %v seconds, want %v", p.Percentile, *p.Seconds, float64(want[i])*estimate.BlockTime)
+		}
+	}
+}
+
+func TestEstimateConfirmationTime(t *testing.T) {
+	backend := newTestEstimatorBackend(t, 10)
+	estimator := NewEstimator(backend)
+
+	// Outbidding the full blocks gets included right away
+	estimate, err := estimator.Estimate(context.Background(), big.NewInt(20*params.Shannon))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate.Blocks != 10 || estimate.BlockTime != 10 || estimate.Probability != 1 {
+		t.Errorf("estimate mismatch: blocks %d, block time %v, probability %v", estimate.Blocks, estimate.BlockTime, estimate.Probability)
+	}
+	checkBlocks(t, estimate, []uint64{1, 1, 1, 1})
+
+	// Underbidding only gets into the blocks with spare capacity
+	estimate, err = estimator.Estimate(context.Background(), big.NewInt(5*params.Shannon))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate.Probability != 0.5 {
+		t.Errorf("probability mismatch: have %v, want 0.5", estimate.Probability)
+	}
+	checkBlocks(t, estimate, []uint64{1, 2, 4, 5})
+
+	// Samples are only gathered once per head
+	if backend.fetched != 10 {
+		t.Errorf("blocks fetched: have %d, want 10", backend.fetched)
+	}
+	// Pending transactions paying more delay inclusion
+	for i := 0; i < 5; i++ {
+		backend.pool = append(backend.pool, types.NewTransaction(uint64(i), common.Address{}, new(big.Int), 500000, big.NewInt(50*params.Shannon), nil))
+	}
+	estimate, err = estimator.Estimate(context.Background(), big.NewInt(20*params.Shannon))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate.PendingGas != 2500000 {
+		t.Errorf("pending gas mismatch: have %d, want 2500000", estimate.PendingGas)
+	}
+	checkBlocks(t, estimate, []uint64{3, 3, 3, 3})
+}
+
+func TestEstimateNeverIncluded(t *testing.T) {
+	backend := newTestEstimatorBackend(t, 10)
+	for i, block := range backend.blocks[1:] {
+		header := block.Header()
+		header.GasUsed = header.GasLimit
+		backend.blocks[i+1] = types.NewBlockWithHeader(header).WithBody(block.Transactions(), nil)
+	}
+	// With every block full, nothing below the lowest price gets included
+	estimate, err := NewEstimator(backend).Estimate(context.Background(), big.NewInt(params.Shannon/2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate.Probability != 0 {
+		t.Errorf("probability mismatch: have %v, want 0", estimate.Probability)
+	}
+	checkBlocks(t, estimate, nil)
+}
+
+func TestInclusionBlocks(t *testing.T) {
+	tests := []struct {
+		p          float64
+		percentile int
+		blocks     uint64
+		ok         bool
+	}{
+		{0, 50, 0, false},
+		{1, 95, 1, true},
+		{0.5, 50, 1, true},
+		{0.5, 90, 4, true},
+		{0.1, 50, 7, true},
+		{0.1, 95, 29, true},
+	}
+	for i, tt := range tests {
+		blocks, ok := inclusionBlocks(tt.p, tt.percentile)
+		if blocks != tt.blocks || ok != tt.ok {
+			t.Errorf("test %d: have (%d, %v), want (%d, %v)", i, blocks, ok, tt.blocks, tt.ok)
+		}
+	}
+}
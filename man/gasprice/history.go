@@ -0,0 +1,231 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package gasprice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/common/hexutil"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/rpc"
+)
+
+const (
+	// maxHistoryBlocks caps the number of blocks a single gas history request
+	// may walk back over.
+	maxHistoryBlocks = 1024
+
+	// maxHistoryPercentiles caps the number of gas price percentiles a single
+	// gas history request may ask for.
+	maxHistoryPercentiles = 100
+)
+
+var errInvalidPercentile = errors.New("invalid gas price percentile")
+
+// HistoryBackend is the chain access needed to collect gas statistics of
+// recent blocks.
+type HistoryBackend interface {
+	HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error)
+	BlockByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Block, error)
+	GetReceipts(ctx context.Context, blockHash common.Hash) (types.Receipts, error)
+}
+
+// GasHistory is the gas usage and the gas prices paid in a range of recent
+// blocks, ordered from the oldest block to the newest one.
+type GasHistory struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+	GasPrice     [][]*hexutil.Big `json:"gasPrice,omitempty"`
+}
+
+// txGasAndPrice is the gas used and the price paid by a single transaction.
+type txGasAndPrice struct {
+	gasUsed uint64
+	price   *big.Int
+}
+
+// CollectGasHistory returns the gas used ratio of the last blocks blocks up to
+// and including the current head, along with the requested gas price
+// percentiles of each of them. Percentiles are weighted by the gas used by the
+// transactions, so that the 50th percentile is the price below which half of
+// the block's gas was bought.
+func CollectGasHistory(ctx context.Context, backend HistoryBackend, blocks int, percentiles []float64) (*GasHistory, error) {
+	if blocks < 1 {
+		return &GasHistory{OldestBlock: (*hexutil.Big)(new(big.Int)), GasUsedRatio: []float64{}}, nil
+	}
+	if blocks > maxHistoryBlocks {
+		blocks = maxHistoryBlocks
+	}
+	if len(percentiles) > maxHistoryPercentiles {
+		return nil, fmt.Errorf("%v: too many percentiles, have %d, max %d", errInvalidPercentile, len(percentiles), maxHistoryPercentiles)
+	}
+	for i, p := range percentiles {
+		if p < 0 || p > 100 {
+			return nil, fmt.Errorf("%v: %f", errInvalidPercentile, p)
+		}
+		if i > 0 && p < percentiles[i-1] {
+			return nil, fmt.Errorf("%v: #%d:%f > #%d:%f", errInvalidPercentile, i-1, percentiles[i-1], i, p)
+		}
+	}
+	head, err := backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if head == nil {
+		return nil, errNoBlocks
+	}
+	last := head.Number.Uint64()
+	if uint64(blocks) > last+1 {
+		blocks = int(last + 1)
+	}
+	oldest := last + 1 - uint64(blocks)
+
+	history := &GasHistory{
+		OldestBlock:  (*hexutil.Big)(new(big.Int).SetUint64(oldest)),
+		GasUsedRatio: make([]float64, blocks),
+	}
+	if len(percentiles) > 0 {
+		history.GasPrice = make([][]*hexutil.Big, blocks)
+	}
+	for i := 0; i < blocks; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		number := rpc.BlockNumber(oldest + uint64(i))
+		if len(percentiles) == 0 {
+			header, err := backend.HeaderByNumber(ctx, number)
+			if err != nil {
+				return nil, err
+			}
+			if header == nil {
+				return nil, fmt.Errorf("missing block #%d", number)
+			}
+			history.GasUsedRatio[i] = gasUsedRatio(header)
+			continue
+		}
+		block, err := backend.BlockByNumber(ctx, number)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return nil, fmt.Errorf("missing block #%d", number)
+		}
+		history.GasUsedRatio[i] = gasUsedRatio(block.Header())
+
+		txs, err := blockGasPrices(ctx, backend, block)
+		if err != nil {
+			return nil, err
+		}
+		history.GasPrice[i] = gasPricePercentiles(txs, percentiles)
+	}
+	return history, nil
+}
+
+// gasUsedRatio returns the fraction of the gas limit used by a block.
+func gasUsedRatio(header *types.Header) float64 {
+	if header.GasLimit == 0 {
+		return 0
+	}
+	return float64(header.GasUsed) / float64(header.GasLimit)
+}
+
+// blockGasPrices returns the gas used and the price paid by each transaction
+// of a block, ordered by increasing gas price. The gas used is taken from the
+// receipts; should they be unavailable, the gas limit of the transaction is
+// used as an approximation instead.
+func blockGasPrices(ctx context.Context, backend HistoryBackend, block *types.Block) ([]txGasAndPrice, error) {
+	txs := block.Transactions()
+	if len(txs) == 0 {
+		return nil, nil
+	}
+	receipts, err := backend.GetReceipts(ctx, block.Hash())
+	if err != nil {
+		return nil, err
+	}
+	if len(receipts) != len(txs) {
+		receipts = nil
+	}
+	sorted := make([]txGasAndPrice, len(txs))
+	for i, tx := range txs {
+		sorted[i] = txGasAndPrice{gasUsed: tx.Gas(), price: tx.GasPrice()}
+		if receipts != nil {
+			sorted[i].gasUsed = receipts[i].CumulativeGasUsed
+			if i > 0 {
+				sorted[i].gasUsed -= receipts[i-1].CumulativeGasUsed
+			}
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].price.Cmp(sorted[j].price) < 0
+	})
+	return sorted, nil
+}
+
+// gasPricePercentiles picks the gas price at each of the percentiles of the
+// gas used by the given transactions, which are sorted by increasing gas
+// price. Empty blocks report zero for every percentile.
+func gasPricePercentiles(txs []txGasAndPrice, percentiles []float64) []*hexutil.Big {
+	prices := make([]*hexutil.Big, len(percentiles))
+	if len(txs) == 0 {
+		for i := range prices {
+			prices[i] = (*hexutil.Big)(new(big.Int))
+		}
+		return prices
+	}
+	var total uint64
+	for _, tx := range txs {
+		total += tx.gasUsed
+	}
+	var (
+		index  int
+		sumGas = txs[0].gasUsed
+	)
+	for i, p := range percentiles {
+		threshold := uint64(float64(total) * p / 100)
+		for sumGas < threshold && index < len(txs)-1 {
+			index++
+			sumGas += txs[index].gasUsed
+		}
+		prices[i] = (*hexutil.Big)(new(big.Int).Set(txs[index].price))
+	}
+	return prices
+}
+
+// PublicGasHistoryAPI offers gas usage and gas price statistics of recent
+// blocks to wallets.
+type PublicGasHistoryAPI struct {
+	b HistoryBackend
+}
+
+// NewPublicGasHistoryAPI creates a new gas statistics API.
+func NewPublicGasHistoryAPI(b HistoryBackend) *PublicGasHistoryAPI {
+	return &PublicGasHistoryAPI{b: b}
+}
+
+// GasHistory returns the gas used ratio and the requested gas price
+// percentiles of the last blockCount blocks, so that fee suggestions can be
+// made without fetching whole blocks.
+func (api *PublicGasHistoryAPI) GasHistory(ctx context.Context, blockCount hexutil.Uint64, percentiles []float64) (*GasHistory, error) {
+	if blockCount > maxHistoryBlocks {
+		blockCount = maxHistoryBlocks
+	}
+	return CollectGasHistory(ctx, api.b, int(blockCount), percentiles)
+}
@@ -0,0 +1,120 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package gasprice
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/crypto"
+	"github.com/matrix/go-matrix/params"
+)
+
+// testHistoryBackend extends the estimator's test chain with receipts.
+type testHistoryBackend struct {
+	*testEstimatorBackend
+	receipts map[common.Hash]types.Receipts
+}
+
+func (b *testHistoryBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return b.receipts[hash], nil
+}
+
+func TestGasHistory(t *testing.T) {
+	backend := &testHistoryBackend{testEstimatorBackend: newTestEstimatorBackend(t, 10)}
+
+	history, err := CollectGasHistory(context.Background(), backend, 4, []float64{0, 50, 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history.OldestBlock.ToInt().Uint64() != 7 {
+		t.Errorf("oldest block mismatch: have %v, want 7", history.OldestBlock.ToInt())
+	}
+	wantRatios := []float64{0.95, 0.5, 0.95, 0.5}
+	wantPrices := []int64{10, 1, 10, 1}
+	if len(history.GasUsedRatio) != len(wantRatios) || len(history.GasPrice) != len(wantPrices) {
+		t.Fatalf("history length mismatch: have %d ratios and %d prices, want %d", len(history.GasUsedRatio), len(history.GasPrice), len(wantRatios))
+	}
+	for i := range wantRatios {
+		if history.GasUsedRatio[i] != wantRatios[i] {
+			t.Errorf("block %d: gas used ratio mismatch: have %v, want %v", i, history.GasUsedRatio[i], wantRatios[i])
+		}
+		for j, price := range history.GasPrice[i] {
+			if want := big.NewInt(wantPrices[i] * params.Shannon); price.ToInt().Cmp(want) != 0 {
+				t.Errorf("block %d, percentile %d: price mismatch: have %v, want %v", i, j, price.ToInt(), want)
+			}
+		}
+	}
+	// Requests reaching back beyond genesis are clipped
+	if history, err = CollectGasHistory(context.Background(), backend, 100, nil); err != nil {
+		t.Fatal(err)
+	}
+	if history.OldestBlock.ToInt().Sign() != 0 || len(history.GasUsedRatio) != 11 || history.GasPrice != nil {
+		t.Errorf("clipped history mismatch: oldest %v, %d ratios, prices %v", history.OldestBlock.ToInt(), len(history.GasUsedRatio), history.GasPrice)
+	}
+	// Decreasing or out of range percentiles are rejected
+	for _, percentiles := range [][]float64{{50, 10}, {-1}, {101}} {
+		if _, err := CollectGasHistory(context.Background(), backend, 1, percentiles); err == nil {
+			t.Errorf("percentiles %v: expected error", percentiles)
+		}
+	}
+}
+
+func TestGasHistoryPercentileWeighting(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := types.NewEIP155Signer(testConfig.ChainId)
+
+	// A single block with a cheap transaction burning most of the gas and two
+	// pricier ones burning little of it
+	var (
+		txs      types.Transactions
+		receipts types.Receipts
+		gasUsed  = []uint64{80000, 10000, 10000}
+		prices   = []int64{1, 5, 9}
+		total    uint64
+	)
+	for i := range gasUsed {
+		tx, err := types.SignTx(types.NewTransaction(uint64(i), common.Address{}, new(big.Int), 100000, big.NewInt(prices[i]), nil), signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += gasUsed[i]
+		txs = append(txs, tx)
+		receipts = append(receipts, &types.Receipt{CumulativeGasUsed: total})
+	}
+	genesis := types.NewBlockWithHeader(&types.Header{Number: new(big.Int), Time: new(big.Int)})
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), Time: big.NewInt(10), GasLimit: 200000, GasUsed: total}).WithBody(txs, nil)
+
+	backend := &testHistoryBackend{
+		testEstimatorBackend: &testEstimatorBackend{blocks: []*types.Block{genesis, block}},
+		receipts:             map[common.Hash]types.Receipts{block.Hash(): receipts},
+	}
+	history, err := CollectGasHistory(context.Background(), backend, 1, []float64{50, 85, 95})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []int64{1, 5, 9} {
+		if have := history.GasPrice[0][i].ToInt().Int64(); have != want {
+			t.Errorf("percentile %d: price mismatch: have %d, want %d", i, have, want)
+		}
+	}
+	if history.GasUsedRatio[0] != 0.5 {
+		t.Errorf("gas used ratio mismatch: have %v, want 0.5", history.GasUsedRatio[0])
+	}
+}
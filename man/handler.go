@@ -34,6 +34,8 @@ import (
 	"github.com/matrix/go-matrix/core/types"
 	"github.com/matrix/go-matrix/man/downloader"
 	"github.com/matrix/go-matrix/man/fetcher"
+	"github.com/matrix/go-matrix/man/propagation"
+	"github.com/matrix/go-matrix/man/snap"
 	"github.com/matrix/go-matrix/mandb"
 	"github.com/matrix/go-matrix/event"
 	"github.com/matrix/go-matrix/hd"
@@ -74,6 +76,7 @@ type ProtocolManager struct {
 	networkId uint64
 
 	fastSync  uint32 // Flag whether fast sync is enabled (gets disabled if we already have blocks)
+	snapSync  uint32 // Flag whether fast sync should retrieve the pivot state over snap
 	acceptTxs uint32 // Flag whether we're considered synchronised (enables transaction processing)
 
 	txpool      txPool
@@ -81,8 +84,9 @@ type ProtocolManager struct {
 	chainconfig *params.ChainConfig
 	maxPeers    int
 
-	downloader *downloader.Downloader
-	fetcher    *fetcher.Fetcher
+	downloader  *downloader.Downloader
+	fetcher     *fetcher.Fetcher
+	propagation *propagation.Tracker
 	//	peers      *peerSet
 	Peers        *peerSet
 	SubProtocols []p2p.Protocol
@@ -121,20 +125,24 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		txsyncCh:    make(chan *txsync),
 		quitSync:    make(chan struct{}),
 		Msgcenter:   MsgCenter,
+		propagation: propagation.NewTracker(),
 	}
 	// Figure out whether to allow fast sync or not
-	if mode == downloader.FastSync && blockchain.CurrentBlock().NumberU64() > 0 {
+	if (mode == downloader.FastSync || mode == downloader.SnapSync) && blockchain.CurrentBlock().NumberU64() > 0 {
 		log.Warn("Blockchain not empty, fast sync disabled")
 		mode = downloader.FullSync
 	}
-	if mode == downloader.FastSync {
+	if mode == downloader.FastSync || mode == downloader.SnapSync {
 		manager.fastSync = uint32(1)
 	}
+	if mode == downloader.SnapSync {
+		manager.snapSync = uint32(1)
+	}
 	// Initiate a sub-protocol for every implemented version we can handle
 	manager.SubProtocols = make([]p2p.Protocol, 0, len(ProtocolVersions))
 	for i, version := range ProtocolVersions {
 		// Skip protocol version if incompatible with the mode of operation
-		if mode == downloader.FastSync && version < man63 {
+		if (mode == downloader.FastSync || mode == downloader.SnapSync) && version < man63 {
 			continue
 		}
 		// Compatible; initialise the sub-protocol
@@ -170,7 +178,10 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		return nil, errIncompatibleConfig
 	}
 	// Construct the different synchronisation mechanisms
-	manager.downloader = downloader.New(mode, chaindb, manager.eventMux, blockchain, nil, manager.removePeer)
+	manager.downloader = downloader.New(mode, chaindb, manager.eventMux, blockchain, nil, manager.dropPeer)
+
+	// Serve the chain state over snap and feed the syncer with the responses
+	manager.SubProtocols = append(manager.SubProtocols, snap.MakeProtocols(blockchain, manager.downloader.SnapSyncer)...)
 
 	validator := func(header *types.Header) error {
 		if header.IsBroadcastHeader() || header.IsReElectionHeader() {
@@ -189,13 +200,25 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 			return 0, nil
 		}
 		atomic.StoreUint32(&manager.acceptTxs, 1) // Mark initial sync done on any fetcher import
+		for _, block := range blocks {
+			manager.propagation.BlockImported(block.Hash(), time.Now())
+		}
 		return manager.blockchain.InsertChain(blocks)
 	}
-	manager.fetcher = fetcher.New(blockchain.GetBlockByHash, validator, manager.BroadcastBlock, heighter, inserter, manager.removePeer)
+	manager.fetcher = fetcher.New(blockchain.GetBlockByHash, validator, manager.BroadcastBlock, heighter, inserter, manager.dropPeer)
 
 	return manager, nil
 }
 
+// dropPeer lowers the reputation of a peer the synchronisation mechanisms gave
+// up on for serving bad or stalling data, and disconnects it.
+func (pm *ProtocolManager) dropPeer(id string) {
+	if peer := pm.Peers.Peer(id); peer != nil {
+		peer.Peer.ReportUseless()
+	}
+	pm.removePeer(id)
+}
+
 func (pm *ProtocolManager) removePeer(id string) {
 	// Short circuit if the peer was already removed
 	//	peer := pm.peers.Peer(id)
@@ -207,6 +230,7 @@ func (pm *ProtocolManager) removePeer(id string) {
 
 	// Unregister the peer from the downloader and Matrix peer set
 	pm.downloader.UnregisterPeer(id)
+	pm.propagation.RemovePeer(id)
 	//	if err := pm.peers.Unregister(id); err != nil {
 	if err := pm.Peers.Unregister(id); err != nil {
 		log.Error("Peer removal failed", "peer", id, "err", err)
@@ -345,6 +369,7 @@ func (pm *ProtocolManager) handle(p *peer) error {
 		// Start a timer to disconnect if the peer doesn't reply in time
 		p.forkDrop = time.AfterFunc(daoChallengeTimeout, func() {
 			p.Log().Debug("Timed out DAO fork-check, dropping")
+			p.Peer.ReportTimeout()
 			pm.removePeer(p.id)
 		})
 		// Make sure it's cleaned up if the peer dies off
@@ -359,6 +384,7 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	for {
 		if err := pm.handleMsg(p); err != nil {
 			p.Log().Debug("Matrix message handling failed", "err", err)
+			p.Peer.ReportInvalid()
 			return err
 		}
 	}
@@ -663,6 +689,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		// Mark the hashes as present at the remote node
 		for _, block := range announces {
 			p.MarkBlock(block.Hash)
+			pm.propagation.BlockAnnounced(p.id, block.Hash, msg.ReceivedAt)
 		}
 		// Schedule all the unknown hashes for retrieval
 		unknown := make(newBlockHashesData, 0, len(announces))
@@ -684,8 +711,13 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		request.Block.ReceivedAt = msg.ReceivedAt
 		request.Block.ReceivedFrom = p
 
+		// Track the propagation latency of blocks extending our head
+		if request.Block.NumberU64() == pm.blockchain.CurrentBlock().NumberU64()+1 {
+			p.Peer.ReportLatency(time.Since(time.Unix(request.Block.Time().Int64(), 0)))
+		}
 		// Mark the peer as owning the block and schedule it for import
 		p.MarkBlock(request.Block.Hash())
+		pm.propagation.BlockDelivered(p.id, request.Block.Hash(), msg.ReceivedAt)
 		pm.fetcher.Enqueue(p.id, request.Block)
 
 		// Assuming the block is importable by the peer, but possibly not yet done so,
@@ -719,12 +751,14 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		if err := msg.Decode(&txs); err != nil {
 			return errResp(ErrDecode, "msg %v: %v", msg, err)
 		}
+		hashes := make([]common.Hash, 0, len(txs))
 		for i, tx := range txs {
 			// Validate and mark the remote transaction
 			if tx == nil {
 				return errResp(ErrDecode, "transaction %d is nil", i)
 			}
 			p.MarkTransaction(tx.Hash())
+			hashes = append(hashes, tx.Hash())
 			//YY ====begin======
 			if nc := tx.Nonce(); nc < params.NonceAddOne {
 				nc = nc | params.NonceAddOne
@@ -732,6 +766,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			}
 			//=========end======
 		}
+		pm.propagation.TxsDelivered(p.id, hashes, msg.ReceivedAt)
 		pm.txpool.AddRemotes(txs)
 	case msg.Code == common.NetworkMsg:
 		var m []*core.MsgStruct
@@ -0,0 +1,342 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package propagation records how timely peers announce and deliver blocks and
+// transactions, backing the debug_propagationStats telemetry.
+package propagation
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru"
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/metrics"
+)
+
+const (
+	// blockRecords is the number of recent blocks whose first-seen
+	// time and announcements are remembered.
+	blockRecords = 1024
+
+	// txRecords is the number of recent transactions whose
+	// first-seen time is remembered.
+	txRecords = 32768
+
+	// latencySamples is the number of recent latency samples percentiles
+	// are computed over.
+	latencySamples = 256
+)
+
+var (
+	propBlockAnnounceDelayTimer = metrics.NewRegisteredTimer("man/propagation/blocks/announce/delay", nil)
+	propBlockDeliveryDelayTimer = metrics.NewRegisteredTimer("man/propagation/blocks/delivery/delay", nil)
+	propBlockAnnounceToDelivery = metrics.NewRegisteredTimer("man/propagation/blocks/announce/delivery", nil)
+	propTxDeliveryDelayTimer    = metrics.NewRegisteredTimer("man/propagation/txns/delivery/delay", nil)
+)
+
+// LatencyStats summarises recent latency samples, in milliseconds.
+type LatencyStats struct {
+	Samples uint64  `json:"samples"` // Number of samples taken overall
+	Mean    float64 `json:"mean"`    // Mean of all samples
+	P50     float64 `json:"p50"`     // Percentiles of the recent samples
+	P90     float64 `json:"p90"`
+	P99     float64 `json:"p99"`
+	Max     float64 `json:"max"` // Maximum of the recent samples
+}
+
+// latencyRecorder accumulates latency samples, keeping the recent ones for
+// percentiles.
+type latencyRecorder struct {
+	count  uint64
+	sum    time.Duration
+	recent []time.Duration // Ring buffer of the last latencySamples samples
+}
+
+func (r *latencyRecorder) add(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	if len(r.recent) < latencySamples {
+		r.recent = append(r.recent, d)
+	} else {
+		r.recent[r.count%latencySamples] = d
+	}
+	r.count++
+	r.sum += d
+}
+
+// stats summarises the recorded samples, or returns nil if there are none.
+func (r *latencyRecorder) stats() *LatencyStats {
+	if r == nil || r.count == 0 {
+		return nil
+	}
+	sorted := make([]time.Duration, len(r.recent))
+	copy(sorted, r.recent)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	percentile := func(p int) float64 { return ms(sorted[(len(sorted)-1)*p/100]) }
+
+	return &LatencyStats{
+		Samples: r.count,
+		Mean:    ms(r.sum / time.Duration(r.count)),
+		P50:     percentile(50),
+		P90:     percentile(90),
+		P99:     percentile(99),
+		Max:     ms(sorted[len(sorted)-1]),
+	}
+}
+
+// PeerStats is how timely a single peer propagates blocks or
+// transactions to us. Delays are measured from the moment any peer first told
+// us about the block or transaction.
+type PeerStats struct {
+	Announces         uint64        `json:"announces,omitempty"`         // Block hashes announced
+	Deliveries        uint64        `json:"deliveries"`                  // Full blocks or transactions delivered
+	First             uint64        `json:"first"`                       // Times the peer was the first to tell us
+	AnnounceDelay     *LatencyStats `json:"announceDelay,omitempty"`     // Announcement behind first seen
+	DeliveryDelay     *LatencyStats `json:"deliveryDelay,omitempty"`     // Delivery behind first seen
+	AnnounceToDeliver *LatencyStats `json:"announceToDeliver,omitempty"` // Delivery behind the peer's own announcement
+}
+
+// KindStats is the propagation telemetry of either blocks or
+// transactions.
+type KindStats struct {
+	Tracked           int                   `json:"tracked"`                     // Recent hashes with a known first-seen time
+	AnnounceToDeliver *LatencyStats         `json:"announceToDeliver,omitempty"` // First announcement to first full block
+	Peers             map[string]*PeerStats `json:"peers"`
+}
+
+// Stats is the block and transaction gossip telemetry returned by
+// debug_propagationStats.
+type Stats struct {
+	Blocks       KindStats `json:"blocks"`
+	Transactions KindStats `json:"transactions"`
+}
+
+// peerPropagation accumulates the propagation telemetry of a single peer.
+type peerPropagation struct {
+	announces, deliveries, first uint64
+	announceDelay, deliveryDelay *latencyRecorder
+	announceToDeliver            *latencyRecorder
+}
+
+func newPeerPropagation() *peerPropagation {
+	return &peerPropagation{
+		announceDelay:     new(latencyRecorder),
+		deliveryDelay:     new(latencyRecorder),
+		announceToDeliver: new(latencyRecorder),
+	}
+}
+
+func (p *peerPropagation) stats() *PeerStats {
+	return &PeerStats{
+		Announces:         p.announces,
+		Deliveries:        p.deliveries,
+		First:             p.first,
+		AnnounceDelay:     p.announceDelay.stats(),
+		DeliveryDelay:     p.deliveryDelay.stats(),
+		AnnounceToDeliver: p.announceToDeliver.stats(),
+	}
+}
+
+// blockSighting is what is known about when a recent block reached us.
+type blockSighting struct {
+	firstSeen     time.Time
+	firstAnnounce time.Time            // Zero if the block was never announced
+	delivered     bool                 // Whether the full block arrived already
+	announces     map[string]time.Time // Announcement time by peer
+}
+
+// Tracker records when blocks and transactions are first seen and
+// how far behind each peer announces and delivers them, so that gossip
+// parameters can be tuned on live networks.
+type Tracker struct {
+	blocks *lru.Cache // Block hash -> *blockSighting
+	txs    *lru.Cache // Transaction hash -> first-seen time.Time
+
+	blockPeers        map[string]*peerPropagation
+	txPeers           map[string]*peerPropagation
+	announceToDeliver *latencyRecorder
+
+	lock sync.Mutex
+}
+
+func NewTracker() *Tracker {
+	blocks, _ := lru.New(blockRecords)
+	txs, _ := lru.New(txRecords)
+	return &Tracker{
+		blocks:            blocks,
+		txs:               txs,
+		blockPeers:        make(map[string]*peerPropagation),
+		txPeers:           make(map[string]*peerPropagation),
+		announceToDeliver: new(latencyRecorder),
+	}
+}
+
+// sighting returns the record of a block, creating it if the block is seen for
+// the first time.
+func (t *Tracker) sighting(hash common.Hash, now time.Time) (*blockSighting, bool) {
+	if s, ok := t.blocks.Get(hash); ok {
+		return s.(*blockSighting), false
+	}
+	s := &blockSighting{firstSeen: now, announces: make(map[string]time.Time)}
+	t.blocks.Add(hash, s)
+	return s, true
+}
+
+func (t *Tracker) blockPeer(peer string) *peerPropagation {
+	p, ok := t.blockPeers[peer]
+	if !ok {
+		p = newPeerPropagation()
+		t.blockPeers[peer] = p
+	}
+	return p
+}
+
+func (t *Tracker) txPeer(peer string) *peerPropagation {
+	p, ok := t.txPeers[peer]
+	if !ok {
+		p = newPeerPropagation()
+		t.txPeers[peer] = p
+	}
+	return p
+}
+
+// BlockAnnounced records a peer announcing the hash of a block.
+func (t *Tracker) BlockAnnounced(peer string, hash common.Hash, now time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	s, first := t.sighting(hash, now)
+	if _, ok := s.announces[peer]; ok {
+		return
+	}
+	s.announces[peer] = now
+	if s.firstAnnounce.IsZero() {
+		s.firstAnnounce = now
+	}
+	p := t.blockPeer(peer)
+	p.announces++
+	if first {
+		p.first++
+	}
+	delay := now.Sub(s.firstSeen)
+	p.announceDelay.add(delay)
+	propBlockAnnounceDelayTimer.Update(delay)
+}
+
+// BlockDelivered records a peer propagating a full block.
+func (t *Tracker) BlockDelivered(peer string, hash common.Hash, now time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	s, first := t.sighting(hash, now)
+	p := t.blockPeer(peer)
+	p.deliveries++
+	if first {
+		p.first++
+	}
+	delay := now.Sub(s.firstSeen)
+	p.deliveryDelay.add(delay)
+	propBlockDeliveryDelayTimer.Update(delay)
+
+	if announced, ok := s.announces[peer]; ok {
+		p.announceToDeliver.add(now.Sub(announced))
+	}
+	t.markDelivered(s, now)
+}
+
+// BlockImported records a block becoming available through the fetcher, which
+// completes announcements whose full block was retrieved on request.
+func (t *Tracker) BlockImported(hash common.Hash, now time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if s, ok := t.blocks.Get(hash); ok {
+		t.markDelivered(s.(*blockSighting), now)
+	}
+}
+
+// markDelivered records the first full block of an announced hash arriving.
+func (t *Tracker) markDelivered(s *blockSighting, now time.Time) {
+	if s.delivered {
+		return
+	}
+	s.delivered = true
+	if !s.firstAnnounce.IsZero() {
+		delay := now.Sub(s.firstAnnounce)
+		t.announceToDeliver.add(delay)
+		propBlockAnnounceToDelivery.Update(delay)
+	}
+}
+
+// TxsDelivered records a peer propagating a batch of transactions.
+func (t *Tracker) TxsDelivered(peer string, hashes []common.Hash, now time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	p := t.txPeer(peer)
+	for _, hash := range hashes {
+		p.deliveries++
+
+		seen, ok := t.txs.Get(hash)
+		if !ok {
+			t.txs.Add(hash, now)
+			p.first++
+			seen = now
+		}
+		delay := now.Sub(seen.(time.Time))
+		p.deliveryDelay.add(delay)
+		propTxDeliveryDelayTimer.Update(delay)
+	}
+}
+
+// RemovePeer drops the telemetry of a disconnected peer.
+func (t *Tracker) RemovePeer(peer string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	delete(t.blockPeers, peer)
+	delete(t.txPeers, peer)
+}
+
+// Stats returns a snapshot of the recorded telemetry.
+func (t *Tracker) Stats() *Stats {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	stats := &Stats{
+		Blocks: KindStats{
+			Tracked:           t.blocks.Len(),
+			AnnounceToDeliver: t.announceToDeliver.stats(),
+			Peers:             make(map[string]*PeerStats),
+		},
+		Transactions: KindStats{
+			Tracked: t.txs.Len(),
+			Peers:   make(map[string]*PeerStats),
+		},
+	}
+	for id, p := range t.blockPeers {
+		stats.Blocks.Peers[id] = p.stats()
+	}
+	for id, p := range t.txPeers {
+		stats.Transactions.Peers[id] = p.stats()
+	}
+	return stats
+}
@@ -0,0 +1,74 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package propagation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matrix/go-matrix/common"
+)
+
+func TestPropagationTracker(t *testing.T) {
+	var (
+		tracker = NewTracker()
+		start   = time.Now()
+		block   = common.HexToHash("0x01")
+		fetched = common.HexToHash("0x02")
+	)
+	// Peer a announces and later delivers a block, peer b follows behind
+	tracker.BlockAnnounced("a", block, start)
+	tracker.BlockAnnounced("b", block, start.Add(100*time.Millisecond))
+	tracker.BlockAnnounced("b", block, start.Add(200*time.Millisecond)) // duplicate, ignored
+	tracker.BlockDelivered("a", block, start.Add(300*time.Millisecond))
+	tracker.BlockDelivered("b", block, start.Add(500*time.Millisecond))
+
+	// An announced block retrieved by the fetcher completes on import
+	tracker.BlockAnnounced("b", fetched, start)
+	tracker.BlockImported(fetched, start.Add(50*time.Millisecond))
+
+	stats := tracker.Stats()
+	a, b := stats.Blocks.Peers["a"], stats.Blocks.Peers["b"]
+	if a.Announces != 1 || a.Deliveries != 1 || a.First != 1 {
+		t.Errorf("peer a counters mismatch: %+v", a)
+	}
+	if b.Announces != 2 || b.Deliveries != 1 || b.First != 1 {
+		t.Errorf("peer b counters mismatch: %+v", b)
+	}
+	if b.AnnounceDelay.Max != 100 || b.DeliveryDelay.Mean != 500 || b.AnnounceToDeliver.P50 != 400 {
+		t.Errorf("peer b latencies mismatch: announce %+v, delivery %+v, announce to deliver %+v", b.AnnounceDelay, b.DeliveryDelay, b.AnnounceToDeliver)
+	}
+	if got := stats.Blocks.AnnounceToDeliver; got.Samples != 2 || got.P50 != 50 || got.Max != 300 {
+		t.Errorf("announce to deliver mismatch: %+v", got)
+	}
+	// Transactions are timed against the first peer delivering them
+	tx := common.HexToHash("0x03")
+	tracker.TxsDelivered("a", []common.Hash{tx}, start)
+	tracker.TxsDelivered("b", []common.Hash{tx}, start.Add(20*time.Millisecond))
+
+	stats = tracker.Stats()
+	if a := stats.Transactions.Peers["a"]; a.First != 1 || a.DeliveryDelay.Max != 0 {
+		t.Errorf("peer a transaction stats mismatch: %+v", a)
+	}
+	if b := stats.Transactions.Peers["b"]; b.First != 0 || b.DeliveryDelay.Max != 20 {
+		t.Errorf("peer b transaction stats mismatch: %+v", b)
+	}
+	// Disconnected peers are dropped
+	tracker.RemovePeer("a")
+	if stats = tracker.Stats(); stats.Blocks.Peers["a"] != nil || stats.Transactions.Peers["a"] != nil {
+		t.Errorf("removed peer still reported")
+	}
+}
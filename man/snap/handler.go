@@ -0,0 +1,304 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package snap
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/core/state"
+	"github.com/matrix/go-matrix/mandb"
+	"github.com/matrix/go-matrix/p2p"
+	"github.com/matrix/go-matrix/rlp"
+	"github.com/matrix/go-matrix/trie"
+)
+
+const (
+	// softResponseLimit is the target maximum size of replies to data retrievals.
+	softResponseLimit = 2 * 1024 * 1024
+
+	// maxCodeLookups is the maximum number of bytecodes to serve. This number is
+	// there to limit the number of disk lookups.
+	maxCodeLookups = 1024
+)
+
+// Backend provides the state served over the snap protocol.
+type Backend interface {
+	// StateCache returns the caching database underpinning the chain state.
+	StateCache() state.Database
+}
+
+// MakeProtocols constructs the P2P protocol definitions for `snap`. Requests of
+// remote peers are served from the backend's state, responses are fed into the
+// syncer, which may be nil if the node never syncs over snap.
+func MakeProtocols(backend Backend, syncer *Syncer) []p2p.Protocol {
+	protocols := make([]p2p.Protocol, len(ProtocolVersions))
+	for i, version := range ProtocolVersions {
+		version := version // Closure for the run
+
+		protocols[i] = p2p.Protocol{
+			Name:    ProtocolName,
+			Version: version,
+			Length:  ProtocolLengths[i],
+			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+				peer := NewPeer(version, p, rw)
+				if syncer != nil {
+					if err := syncer.Register(peer); err != nil {
+						return err
+					}
+					defer syncer.Unregister(peer.id)
+				}
+				return Handle(backend, syncer, peer)
+			},
+		}
+	}
+	return protocols
+}
+
+// Handle is the callback invoked to manage the life cycle of a `snap` peer.
+// When this function terminates, the peer is disconnected.
+func Handle(backend Backend, syncer *Syncer, peer *Peer) error {
+	for {
+		if err := handleMessage(backend, syncer, peer); err != nil {
+			peer.logger.Debug("Message handling failed in `snap`", "err", err)
+			return err
+		}
+	}
+}
+
+// handleMessage is invoked whenever an inbound message is received from a
+// remote peer on the `snap` protocol. The remote connection is torn down upon
+// returning any error.
+func handleMessage(backend Backend, syncer *Syncer, peer *Peer) error {
+	// Read the next message from the remote peer, and ensure it's fully consumed
+	msg, err := peer.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Size > ProtocolMaxMsgSize {
+		return fmt.Errorf("%v: %v > %v", errMsgTooLarge, msg.Size, ProtocolMaxMsgSize)
+	}
+	defer msg.Discard()
+
+	// Handle the message depending on its contents
+	switch msg.Code {
+	case GetAccountRangeMsg:
+		var req GetAccountRangePacket
+		if err := msg.Decode(&req); err != nil {
+			return fmt.Errorf("%v: message %v: %v", errDecode, msg, err)
+		}
+		return p2p.Send(peer.rw, AccountRangeMsg, serveAccountRange(backend.StateCache(), &req))
+
+	case AccountRangeMsg:
+		res := new(AccountRangePacket)
+		if err := msg.Decode(res); err != nil {
+			return fmt.Errorf("%v: message %v: %v", errDecode, msg, err)
+		}
+		if syncer != nil {
+			syncer.deliver(&response{peer: peer, id: res.ID, accounts: res})
+		}
+		return nil
+
+	case GetStorageRangesMsg:
+		var req GetStorageRangesPacket
+		if err := msg.Decode(&req); err != nil {
+			return fmt.Errorf("%v: message %v: %v", errDecode, msg, err)
+		}
+		return p2p.Send(peer.rw, StorageRangesMsg, serveStorageRanges(backend.StateCache(), &req))
+
+	case StorageRangesMsg:
+		res := new(StorageRangesPacket)
+		if err := msg.Decode(res); err != nil {
+			return fmt.Errorf("%v: message %v: %v", errDecode, msg, err)
+		}
+		if syncer != nil {
+			syncer.deliver(&response{peer: peer, id: res.ID, storage: res})
+		}
+		return nil
+
+	case GetByteCodesMsg:
+		var req GetByteCodesPacket
+		if err := msg.Decode(&req); err != nil {
+			return fmt.Errorf("%v: message %v: %v", errDecode, msg, err)
+		}
+		return p2p.Send(peer.rw, ByteCodesMsg, serveByteCodes(backend.StateCache(), &req))
+
+	case ByteCodesMsg:
+		res := new(ByteCodesPacket)
+		if err := msg.Decode(res); err != nil {
+			return fmt.Errorf("%v: message %v: %v", errDecode, msg, err)
+		}
+		if syncer != nil {
+			syncer.deliver(&response{peer: peer, id: res.ID, codes: res})
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("%v: %v", errInvalidMsgCode, msg.Code)
+	}
+}
+
+// responseLimit caps the byte budget requested by a remote peer.
+func responseLimit(requested uint64) uint64 {
+	if requested > softResponseLimit {
+		return softResponseLimit
+	}
+	return requested
+}
+
+// serveAccountRange assembles the response to an account range query. An empty
+// response without proof is returned if the requested state is not available.
+func serveAccountRange(db state.Database, req *GetAccountRangePacket) *AccountRangePacket {
+	tr, err := trie.New(req.Root, db.TrieDB())
+	if err != nil {
+		return &AccountRangePacket{ID: req.ID}
+	}
+	var (
+		res   = &AccountRangePacket{ID: req.ID}
+		limit = responseLimit(req.Bytes)
+		size  uint64
+	)
+	it := trie.NewIterator(tr.NodeIterator(req.Origin[:]))
+	for it.Next() {
+		hash := common.BytesToHash(it.Key)
+		res.Accounts = append(res.Accounts, &AccountData{Hash: hash, Body: common.CopyBytes(it.Value)})
+
+		size += uint64(common.HashLength + len(it.Value))
+		if bytes.Compare(hash[:], req.Limit[:]) >= 0 || size >= limit {
+			break
+		}
+	}
+	if it.Err != nil {
+		return &AccountRangePacket{ID: req.ID}
+	}
+	// Prove the origin and the last account of the range, so the whole range
+	// can be verified against the root
+	keys := [][]byte{req.Origin[:]}
+	if len(res.Accounts) > 0 {
+		keys = append(keys, res.Accounts[len(res.Accounts)-1].Hash[:])
+	}
+	proof, err := prove(tr, keys...)
+	if err != nil {
+		return &AccountRangePacket{ID: req.ID}
+	}
+	res.Proof = proof
+	return res
+}
+
+// serveStorageRanges assembles the response to a storage ranges query. Serving
+// stops at the first account whose storage is not available.
+func serveStorageRanges(db state.Database, req *GetStorageRangesPacket) *StorageRangesPacket {
+	res := &StorageRangesPacket{ID: req.ID}
+
+	accTrie, err := trie.New(req.Root, db.TrieDB())
+	if err != nil {
+		return res
+	}
+	var (
+		limit = responseLimit(req.Bytes)
+		size  uint64
+	)
+	for i, account := range req.Accounts {
+		if size >= limit {
+			break
+		}
+		blob, err := accTrie.TryGet(account[:])
+		if err != nil || blob == nil {
+			break
+		}
+		var acc state.Account
+		if err := rlp.DecodeBytes(blob, &acc); err != nil {
+			break
+		}
+		stTrie, err := trie.New(acc.Root, db.TrieDB())
+		if err != nil {
+			break
+		}
+		var origin common.Hash
+		if i == 0 {
+			origin = req.Origin
+		}
+		var (
+			slots     []*StorageData
+			truncated bool
+		)
+		it := trie.NewIterator(stTrie.NodeIterator(origin[:]))
+		for it.Next() {
+			slots = append(slots, &StorageData{Hash: common.BytesToHash(it.Key), Body: common.CopyBytes(it.Value)})
+
+			size += uint64(common.HashLength + len(it.Value))
+			if size >= limit {
+				truncated = true
+				break
+			}
+		}
+		if it.Err != nil {
+			break
+		}
+		res.Slots = append(res.Slots, slots)
+
+		// If the range doesn't cover the whole storage of the account, prove its
+		// edges. Only the last range of a response can be proven.
+		if origin != (common.Hash{}) || truncated {
+			keys := [][]byte{origin[:]}
+			if len(slots) > 0 {
+				keys = append(keys, slots[len(slots)-1].Hash[:])
+			}
+			if res.Proof, err = prove(stTrie, keys...); err != nil {
+				res.Slots = res.Slots[:len(res.Slots)-1]
+			}
+			break
+		}
+	}
+	return res
+}
+
+// serveByteCodes assembles the response to a bytecode query.
+func serveByteCodes(db state.Database, req *GetByteCodesPacket) *ByteCodesPacket {
+	var (
+		res   = &ByteCodesPacket{ID: req.ID}
+		limit = responseLimit(req.Bytes)
+		size  uint64
+	)
+	for i, hash := range req.Hashes {
+		if i >= maxCodeLookups || size >= limit {
+			break
+		}
+		if code, err := db.ContractCode(common.Hash{}, hash); err == nil && len(code) > 0 {
+			res.Codes = append(res.Codes, code)
+			size += uint64(len(code))
+		}
+	}
+	return res
+}
+
+// prove collects the Merkle proofs of the given keys in the trie into a single
+// list of trie nodes.
+func prove(tr *trie.Trie, keys ...[]byte) ([][]byte, error) {
+	proofDb := mandb.NewMemDatabase()
+	for _, key := range keys {
+		if err := tr.Prove(key, 0, proofDb); err != nil {
+			return nil, err
+		}
+	}
+	var proof [][]byte
+	for _, key := range proofDb.Keys() {
+		node, _ := proofDb.Get(key)
+		proof = append(proof, node)
+	}
+	return proof, nil
+}
@@ -0,0 +1,654 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package snap
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/core/state"
+	"github.com/matrix/go-matrix/crypto"
+	"github.com/matrix/go-matrix/log"
+	"github.com/matrix/go-matrix/mandb"
+	"github.com/matrix/go-matrix/p2p"
+	"github.com/matrix/go-matrix/rlp"
+	"github.com/matrix/go-matrix/trie"
+)
+
+var (
+	// emptyRoot is the known root hash of an empty trie.
+	emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+	// emptyCode is the known hash of the empty EVM bytecode.
+	emptyCode = crypto.Keccak256Hash(nil)
+)
+
+const (
+	// accountConcurrency is the number of chunks to split the account trie into
+	// to allow concurrent retrievals.
+	accountConcurrency = 16
+
+	// maxRequestSize is the maximum number of bytes to request from a remote peer.
+	maxRequestSize = 512 * 1024
+
+	// maxCodeRequestCount is the maximum number of bytecode blobs to request in a
+	// single query.
+	maxCodeRequestCount = 128
+
+	// requestTimeout is the maximum time a peer is allowed to spend on serving a
+	// single network request.
+	requestTimeout = 10 * time.Second
+
+	// maxUncommitted is the number of account trie updates after which the trie
+	// is flushed to disk.
+	maxUncommitted = 16384
+)
+
+var (
+	errAlreadyRegistered = errors.New("peer is already registered")
+	errNotRegistered     = errors.New("peer is not registered")
+	errSyncRunning       = errors.New("snap sync already running")
+
+	// ErrCancelled is returned from snap syncing if the operation was prematurely
+	// terminated.
+	ErrCancelled = errors.New("sync cancelled")
+)
+
+// accountTask represents a chunk of the account hash space to retrieve.
+type accountTask struct {
+	next common.Hash // Next account hash to retrieve
+	last common.Hash // Last account hash belonging to this chunk
+	req  *request    // Pending request filling this chunk, if any
+	done bool        // Whether the chunk was fully retrieved
+}
+
+// storageTask represents the (remaining) storage of an account to retrieve.
+type storageTask struct {
+	account common.Hash // Hash of the account owning the storage
+	root    common.Hash // Storage root the retrieved slots need to add up to
+	next    common.Hash // Next slot hash to retrieve
+	trie    *trie.Trie  // Storage trie being assembled from the retrieved slots
+}
+
+// request tracks a single pending network request.
+type request struct {
+	id    uint64
+	peer  *Peer
+	timer *time.Timer
+
+	account *accountTask  // Account chunk requested, if an account range request
+	storage *storageTask  // Storage requested, if a storage ranges request
+	codes   []common.Hash // Code hashes requested, if a bytecode request
+}
+
+// response is an inbound reply of a remote peer to one of our requests.
+type response struct {
+	peer *Peer
+	id   uint64
+
+	accounts *AccountRangePacket
+	storage  *StorageRangesPacket
+	codes    *ByteCodesPacket
+}
+
+// Syncer downloads the state of a block over the snap protocol: the account
+// trie as contiguous ranges of accounts, then the storage tries of the accounts
+// and their contract codes. Every range of accounts or storage slots is verified
+// against the state root or the storage root of its account as it arrives, so
+// peers serving forged or incomplete ranges are dropped right away. The tries
+// are rebuilt locally from the verified leaves.
+type Syncer struct {
+	db     mandb.Database // Database to store the retrieved state into
+	triedb *trie.Database // Trie database assembling the retrieved tries
+
+	peers map[string]*Peer // Peers that can serve snap requests
+	lock  sync.RWMutex     // Lock protecting the peer set and the delivery channels
+
+	update   chan struct{}  // Notification channel for newly joined peers
+	respCh   chan *response // Channel of the running sync receiving responses
+	cancelCh chan struct{}  // Cancellation channel of the running sync
+
+	// State of the running sync, only ever touched by its own goroutine
+	root      common.Hash
+	accTrie   *trie.Trie
+	accTasks  []*accountTask
+	storages  []*storageTask
+	codes     map[common.Hash]struct{}
+	pending   map[uint64]*request
+	stateless map[string]struct{} // Peers that don't serve the requested state
+	nextID    uint64
+	dirty     int
+
+	// Statistics, protected by the stats lock
+	accountSynced  uint64
+	slotSynced     uint64
+	bytecodeSynced uint64
+	pendingItems   uint64
+	statsLock      sync.RWMutex
+}
+
+// NewSyncer creates a new snapshot syncer storing the retrieved state into the
+// given database.
+func NewSyncer(db mandb.Database) *Syncer {
+	return &Syncer{
+		db:     db,
+		triedb: trie.NewDatabase(db),
+		peers:  make(map[string]*Peer),
+		update: make(chan struct{}, 1),
+	}
+}
+
+// Register injects a new data source into the syncer's peerset.
+func (s *Syncer) Register(peer *Peer) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.peers[peer.id]; ok {
+		return errAlreadyRegistered
+	}
+	s.peers[peer.id] = peer
+
+	select {
+	case s.update <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Unregister removes a data source from the syncer's peerset. Requests pending
+// on the peer will time out and be retried from someone else.
+func (s *Syncer) Unregister(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.peers[id]; !ok {
+		return errNotRegistered
+	}
+	delete(s.peers, id)
+	return nil
+}
+
+// Progress returns the number of state entries (accounts, storage slots and
+// codes) retrieved by the running or last sync, and the number of entries
+// known to be still missing.
+func (s *Syncer) Progress() (synced uint64, pending uint64) {
+	s.statsLock.RLock()
+	defer s.statsLock.RUnlock()
+
+	return s.accountSynced + s.slotSynced + s.bytecodeSynced, s.pendingItems
+}
+
+// deliver hands a response of a remote peer over to the running sync, if any.
+func (s *Syncer) deliver(res *response) {
+	s.lock.RLock()
+	respCh, cancelCh := s.respCh, s.cancelCh
+	s.lock.RUnlock()
+
+	if respCh == nil {
+		return
+	}
+	select {
+	case respCh <- res:
+	case <-cancelCh:
+	}
+}
+
+// Sync retrieves the state with the given root, blocking until it's complete,
+// failed or cancelled via the cancel channel.
+func (s *Syncer) Sync(root common.Hash, cancel chan struct{}) error {
+	s.lock.Lock()
+	if s.respCh != nil {
+		s.lock.Unlock()
+		return errSyncRunning
+	}
+	s.respCh, s.cancelCh = make(chan *response), cancel
+	s.lock.Unlock()
+
+	defer func() {
+		s.lock.Lock()
+		s.respCh, s.cancelCh = nil, nil
+		s.lock.Unlock()
+	}()
+	log.Debug("Starting snapshot sync cycle", "root", root)
+
+	s.reset(root)
+	timeouts := make(chan *request)
+	defer func() {
+		for _, req := range s.pending {
+			req.timer.Stop()
+		}
+	}()
+	for !s.complete() {
+		if err := s.assignTasks(timeouts, cancel); err != nil {
+			return err
+		}
+		s.reportProgress()
+
+		select {
+		case <-s.update:
+			// New peer arrived, try to assign it download tasks
+
+		case <-cancel:
+			return ErrCancelled
+
+		case req := <-timeouts:
+			if s.pending[req.id] == req {
+				req.peer.logger.Debug("Snap request timed out", "reqid", req.id)
+				s.revert(req)
+			}
+
+		case res := <-s.respCh:
+			if err := s.process(res); err != nil {
+				return err
+			}
+		}
+	}
+	s.reportProgress()
+
+	// All the state was retrieved, make sure it adds up to the requested root
+	hash, err := s.commit()
+	if err != nil {
+		return err
+	}
+	if hash != root {
+		return fmt.Errorf("state root mismatch: have %x, want %x", hash, root)
+	}
+	log.Info("Snapshot sync complete", "root", root, "accounts", s.accountSynced, "slots", s.slotSynced, "codes", s.bytecodeSynced)
+	return nil
+}
+
+// reset initialises the sync state to retrieve the state with the given root,
+// splitting the account hash space into evenly sized chunks.
+func (s *Syncer) reset(root common.Hash) {
+	s.root = root
+	s.accTrie, _ = trie.New(common.Hash{}, s.triedb)
+	s.accTasks = make([]*accountTask, 0, accountConcurrency)
+	s.storages = nil
+	s.codes = make(map[common.Hash]struct{})
+	s.pending = make(map[uint64]*request)
+	s.stateless = make(map[string]struct{})
+	s.dirty = 0
+
+	step := new(big.Int).Div(new(big.Int).Lsh(common.Big1, 256), big.NewInt(accountConcurrency))
+	for i := 0; i < accountConcurrency; i++ {
+		next := new(big.Int).Mul(step, big.NewInt(int64(i)))
+		last := common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+		if i < accountConcurrency-1 {
+			last = common.BigToHash(new(big.Int).Sub(new(big.Int).Add(next, step), common.Big1))
+		}
+		s.accTasks = append(s.accTasks, &accountTask{next: common.BigToHash(next), last: last})
+	}
+	s.statsLock.Lock()
+	s.accountSynced, s.slotSynced, s.bytecodeSynced, s.pendingItems = 0, 0, 0, 0
+	s.statsLock.Unlock()
+}
+
+// complete reports whether all the state was retrieved.
+func (s *Syncer) complete() bool {
+	for _, task := range s.accTasks {
+		if !task.done {
+			return false
+		}
+	}
+	return len(s.storages) == 0 && len(s.codes) == 0 && len(s.pending) == 0
+}
+
+// assignTasks sends a request to every idle peer that has something to do.
+func (s *Syncer) assignTasks(timeouts chan *request, cancel chan struct{}) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	busy := make(map[string]bool)
+	for _, req := range s.pending {
+		busy[req.peer.id] = true
+	}
+	for id, peer := range s.peers {
+		if _, ok := s.stateless[id]; ok || busy[id] {
+			continue
+		}
+		req := s.nextRequest(peer)
+		if req == nil {
+			return nil
+		}
+		var err error
+		switch {
+		case req.account != nil:
+			err = peer.RequestAccountRange(req.id, s.root, req.account.next, req.account.last, maxRequestSize)
+		case req.storage != nil:
+			err = peer.RequestStorageRanges(req.id, s.root, []common.Hash{req.storage.account}, req.storage.next, maxRequestSize)
+		default:
+			err = peer.RequestByteCodes(req.id, req.codes, maxRequestSize)
+		}
+		if err != nil {
+			peer.logger.Debug("Failed to send snap request", "err", err)
+			s.revert(req)
+			continue
+		}
+		req.timer = time.AfterFunc(requestTimeout, func() {
+			select {
+			case timeouts <- req:
+			case <-cancel:
+			}
+		})
+	}
+	return nil
+}
+
+// nextRequest picks the next piece of work to request from the given peer and
+// tracks it as pending, returning nil if there's nothing left to request.
+func (s *Syncer) nextRequest(peer *Peer) *request {
+	req := &request{id: s.nextID, peer: peer}
+	switch {
+	case len(s.codes) > 0:
+		for hash := range s.codes {
+			req.codes = append(req.codes, hash)
+			delete(s.codes, hash)
+			if len(req.codes) >= maxCodeRequestCount {
+				break
+			}
+		}
+	case len(s.storages) > 0:
+		req.storage, s.storages = s.storages[0], s.storages[1:]
+
+	default:
+		for _, task := range s.accTasks {
+			if !task.done && task.req == nil {
+				req.account, task.req = task, req
+				break
+			}
+		}
+		if req.account == nil {
+			return nil
+		}
+	}
+	s.nextID++
+	s.pending[req.id] = req
+	return req
+}
+
+// revert returns the work of a request that failed or timed out to the task
+// queues, so it gets requested again.
+func (s *Syncer) revert(req *request) {
+	if req.timer != nil {
+		req.timer.Stop()
+	}
+	delete(s.pending, req.id)
+
+	switch {
+	case req.account != nil:
+		req.account.req = nil
+	case req.storage != nil:
+		s.storages = append(s.storages, req.storage)
+	default:
+		for _, hash := range req.codes {
+			s.codes[hash] = struct{}{}
+		}
+	}
+}
+
+// dropPeer reverts a request answered with invalid data and disconnects the
+// peer which served it.
+func (s *Syncer) dropPeer(req *request, err error) {
+	req.peer.logger.Warn("Invalid snap response, dropping peer", "reqid", req.id, "err", err)
+	s.stateless[req.peer.id] = struct{}{}
+	s.revert(req)
+	req.peer.Disconnect(p2p.DiscUselessPeer)
+}
+
+// markStateless reverts a request the peer couldn't serve and stops asking the
+// peer for more of this state.
+func (s *Syncer) markStateless(req *request) {
+	req.peer.logger.Debug("Peer doesn't serve requested state", "root", s.root)
+	s.stateless[req.peer.id] = struct{}{}
+	s.revert(req)
+}
+
+// process handles a response of a remote peer, ignoring it if it doesn't match
+// a pending request.
+func (s *Syncer) process(res *response) error {
+	req := s.pending[res.id]
+	if req == nil || req.peer.id != res.peer.id {
+		return nil
+	}
+	switch {
+	case req.account != nil && res.accounts != nil:
+		return s.processAccounts(req, res.accounts)
+	case req.storage != nil && res.storage != nil:
+		return s.processStorage(req, res.storage)
+	case req.codes != nil && res.codes != nil:
+		s.processCodes(req, res.codes)
+		return nil
+	}
+	s.dropPeer(req, errors.New("unexpected response type"))
+	return nil
+}
+
+// processAccounts verifies a range of accounts against the state root, inserts
+// it into the account trie and schedules the retrieval of their storage and
+// codes.
+func (s *Syncer) processAccounts(req *request, res *AccountRangePacket) error {
+	task := req.account
+	if len(res.Accounts) == 0 && len(res.Proof) == 0 {
+		s.markStateless(req)
+		return nil
+	}
+	// Ensure the accounts are exactly the ones of the state between the origin
+	// and the last returned account
+	keys, values := make([][]byte, len(res.Accounts)), make([][]byte, len(res.Accounts))
+	for i, account := range res.Accounts {
+		keys[i], values[i] = account.Hash[:], account.Body
+	}
+	last := task.next
+	if len(res.Accounts) > 0 {
+		last = res.Accounts[len(res.Accounts)-1].Hash
+	}
+	more, err := verifyRangeProof(s.root, task.next, last, keys, values, res.Proof)
+	if err != nil {
+		s.dropPeer(req, err)
+		return nil
+	}
+	accounts := make([]state.Account, 0, len(res.Accounts))
+	for _, account := range res.Accounts {
+		var acc state.Account
+		if err := rlp.DecodeBytes(account.Body, &acc); err != nil {
+			s.dropPeer(req, err)
+			return nil
+		}
+		accounts = append(accounts, acc)
+	}
+	req.timer.Stop()
+	delete(s.pending, req.id)
+	task.req = nil
+
+	// Insert the accounts belonging to the chunk and schedule their contents
+	var synced uint64
+	for i, account := range res.Accounts {
+		if bytes.Compare(account.Hash[:], task.last[:]) > 0 {
+			task.done = true
+			break
+		}
+		if err := s.accTrie.TryUpdate(account.Hash[:], account.Body); err != nil {
+			return err
+		}
+		if acc := accounts[i]; acc.Root != emptyRoot {
+			tr, _ := trie.New(common.Hash{}, s.triedb)
+			s.storages = append(s.storages, &storageTask{account: account.Hash, root: acc.Root, trie: tr})
+		}
+		if hash := common.BytesToHash(accounts[i].CodeHash); hash != emptyCode {
+			if ok, _ := s.db.Has(hash[:]); !ok {
+				s.codes[hash] = struct{}{}
+			}
+		}
+		if account.Hash == task.last {
+			task.done = true
+		}
+		synced++
+	}
+	if !more {
+		task.done = true
+	}
+	if !task.done {
+		task.next = common.BigToHash(new(big.Int).Add(last.Big(), common.Big1))
+	}
+	s.statsLock.Lock()
+	s.accountSynced += synced
+	s.statsLock.Unlock()
+
+	s.dirty += int(synced)
+	if s.dirty >= maxUncommitted {
+		if _, err := s.commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// processStorage verifies a range of storage slots against the storage root of
+// an account and inserts it into the account's storage trie, flushing the trie
+// once the whole storage was retrieved.
+func (s *Syncer) processStorage(req *request, res *StorageRangesPacket) error {
+	task := req.storage
+	if len(res.Slots) == 0 {
+		s.markStateless(req)
+		return nil
+	}
+	// Ensure the slots are exactly the ones of the storage between the origin
+	// and the last returned slot
+	slots := res.Slots[0]
+	keys, values := make([][]byte, len(slots)), make([][]byte, len(slots))
+	for i, slot := range slots {
+		keys[i], values[i] = slot.Hash[:], slot.Body
+	}
+	last := task.next
+	if len(slots) > 0 {
+		last = slots[len(slots)-1].Hash
+	}
+	more, err := verifyRangeProof(task.root, task.next, last, keys, values, res.Proof)
+	if err != nil {
+		s.dropPeer(req, err)
+		return nil
+	}
+	req.timer.Stop()
+	delete(s.pending, req.id)
+
+	for _, slot := range slots {
+		if err := task.trie.TryUpdate(slot.Hash[:], slot.Body); err != nil {
+			return err
+		}
+	}
+	s.statsLock.Lock()
+	s.slotSynced += uint64(len(slots))
+	s.statsLock.Unlock()
+
+	if more {
+		task.next = common.BigToHash(new(big.Int).Add(last.Big(), common.Big1))
+		s.storages = append(s.storages, task)
+		return nil
+	}
+	// Storage complete, the verified ranges must add up to the root
+	root, err := task.trie.Commit(nil)
+	if err != nil {
+		return err
+	}
+	if root != task.root {
+		return fmt.Errorf("storage root mismatch for account %x: have %x, want %x", task.account, root, task.root)
+	}
+	return s.triedb.Commit(root, false)
+}
+
+// processCodes stores the delivered contract codes, rescheduling the ones the
+// peer didn't deliver.
+func (s *Syncer) processCodes(req *request, res *ByteCodesPacket) {
+	if len(res.Codes) == 0 {
+		s.markStateless(req)
+		return
+	}
+	req.timer.Stop()
+	delete(s.pending, req.id)
+
+	requested := make(map[common.Hash]struct{}, len(req.codes))
+	for _, hash := range req.codes {
+		requested[hash] = struct{}{}
+	}
+	batch := s.db.NewBatch()
+	for _, code := range res.Codes {
+		hash := crypto.Keccak256Hash(code)
+		if _, ok := requested[hash]; !ok {
+			continue
+		}
+		batch.Put(hash[:], code)
+		delete(requested, hash)
+	}
+	if err := batch.Write(); err != nil {
+		log.Error("Failed to store contract codes", "err", err)
+		for _, hash := range req.codes {
+			requested[hash] = struct{}{}
+		}
+	}
+	for hash := range requested {
+		s.codes[hash] = struct{}{}
+	}
+	s.statsLock.Lock()
+	s.bytecodeSynced += uint64(len(req.codes) - len(requested))
+	s.statsLock.Unlock()
+}
+
+// commit flushes the account trie assembled so far to disk.
+func (s *Syncer) commit() (common.Hash, error) {
+	root, err := s.accTrie.Commit(nil)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	s.dirty = 0
+	if root == emptyRoot {
+		return root, nil
+	}
+	return root, s.triedb.Commit(root, false)
+}
+
+// reportProgress updates the number of known missing state entries.
+func (s *Syncer) reportProgress() {
+	pending := uint64(len(s.storages) + len(s.codes) + len(s.pending))
+	for _, task := range s.accTasks {
+		if !task.done {
+			pending++
+		}
+	}
+	s.statsLock.Lock()
+	s.pendingItems = pending
+	s.statsLock.Unlock()
+}
+
+// verifyRangeProof checks that the given leaves are exactly the entries of the
+// trie with the given root between origin and last, returning whether the trie
+// holds more entries after them. Without proof nodes, the leaves need to make
+// up the whole trie.
+func verifyRangeProof(root common.Hash, origin, last common.Hash, keys, values [][]byte, proof [][]byte) (bool, error) {
+	var proofDb trie.DatabaseReader
+	if len(proof) > 0 {
+		db := mandb.NewMemDatabase()
+		for _, node := range proof {
+			db.Put(crypto.Keccak256(node), node)
+		}
+		proofDb = db
+	}
+	return trie.VerifyRangeProof(root, origin[:], last[:], keys, values, proofDb)
+}
@@ -0,0 +1,267 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package snap
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/core/state"
+	"github.com/matrix/go-matrix/crypto"
+	"github.com/matrix/go-matrix/mandb"
+	"github.com/matrix/go-matrix/p2p"
+	"github.com/matrix/go-matrix/p2p/discover"
+	"github.com/matrix/go-matrix/rlp"
+	"github.com/matrix/go-matrix/trie"
+)
+
+// testBackend serves the state of a database over snap.
+type testBackend struct {
+	db state.Database
+}
+
+func (b *testBackend) StateCache() state.Database { return b.db }
+
+// makeTestState creates a state with plain accounts, contracts with code and
+// a contract with a large storage, returning the backend and the state root.
+func makeTestState(t *testing.T) (*testBackend, common.Hash) {
+	db := state.NewDatabase(mandb.NewMemDatabase())
+	statedb, _ := state.New(common.Hash{}, db)
+	for i := 0; i < 500; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i + 1)))
+		statedb.AddBalance(addr, big.NewInt(int64(i+1)))
+		if i%50 == 0 {
+			statedb.SetCode(addr, []byte{0x60, byte(i), 0x00})
+			for j := 0; j < 10; j++ {
+				statedb.SetState(addr, common.BigToHash(big.NewInt(int64(j))), common.BigToHash(big.NewInt(int64(i*j+1))))
+			}
+		}
+	}
+	contract := common.BigToAddress(big.NewInt(1000))
+	for j := 0; j < 5000; j++ {
+		statedb.SetState(contract, common.BigToHash(big.NewInt(int64(j))), common.BigToHash(big.NewInt(int64(j+1))))
+	}
+	root, err := statedb.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := db.TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to flush state: %v", err)
+	}
+	return &testBackend{db}, root
+}
+
+// connectPeer links the syncer to a peer serving the given backend.
+func connectPeer(t *testing.T, syncer *Syncer, backend Backend, id byte) {
+	app, net := p2p.MsgPipe()
+
+	server := NewPeer(snap1, p2p.NewPeer(discover.NodeID{id}, "server", nil), app)
+	client := NewPeer(snap1, p2p.NewPeer(discover.NodeID{id}, "client", nil), net)
+
+	go Handle(backend, nil, server)
+	go Handle(&testBackend{state.NewDatabase(mandb.NewMemDatabase())}, syncer, client)
+
+	if err := syncer.Register(client); err != nil {
+		t.Fatalf("failed to register peer: %v", err)
+	}
+}
+
+// Tests that a state can be retrieved over snap from multiple peers and is
+// complete afterwards.
+func TestSnapSync(t *testing.T) {
+	source, root := makeTestState(t)
+
+	diskdb := mandb.NewMemDatabase()
+	syncer := NewSyncer(diskdb)
+	connectPeer(t, syncer, source, 1)
+	connectPeer(t, syncer, source, 2)
+
+	done := make(chan error)
+	go func() { done <- syncer.Sync(root, make(chan struct{})) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("sync failed: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("sync timed out")
+	}
+	// Ensure every trie node and code of the state is available locally
+	synced := state.NewDatabase(diskdb)
+	statedb, err := state.New(root, synced)
+	if err != nil {
+		t.Fatalf("failed to open synced state: %v", err)
+	}
+	it := state.NewNodeIterator(statedb)
+	for it.Next() {
+	}
+	if it.Error != nil {
+		t.Fatalf("synced state incomplete: %v", it.Error)
+	}
+	want, _ := state.New(root, source.db)
+	addr := common.BigToAddress(big.NewInt(1))
+	if have := statedb.GetCode(addr); !bytes.Equal(have, want.GetCode(addr)) {
+		t.Errorf("code mismatch: have %x, want %x", have, want.GetCode(addr))
+	}
+	if synced, pending := syncer.Progress(); pending != 0 || synced == 0 {
+		t.Errorf("progress mismatch: synced %d, pending %d", synced, pending)
+	}
+}
+
+// Tests that a sync reports an error if no peer serves the requested state and
+// that it can be cancelled.
+func TestSnapSyncCancel(t *testing.T) {
+	source, _ := makeTestState(t)
+
+	syncer := NewSyncer(mandb.NewMemDatabase())
+	connectPeer(t, syncer, source, 1)
+
+	cancel := make(chan struct{})
+	done := make(chan error)
+	go func() { done <- syncer.Sync(common.Hash{0x01}, cancel) }()
+
+	time.Sleep(100 * time.Millisecond)
+	close(cancel)
+	select {
+	case err := <-done:
+		if err != ErrCancelled {
+			t.Fatalf("error mismatch: have %v, want %v", err, ErrCancelled)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("sync not cancelled")
+	}
+}
+
+// Tests that storage ranges exceeding the byte budget are truncated and the
+// last slot of the truncated range is proven.
+func TestServeStorageRangesTruncated(t *testing.T) {
+	source, root := makeTestState(t)
+
+	tr, _ := trie.New(root, source.db.TrieDB())
+	it := trie.NewIterator(tr.NodeIterator(nil))
+
+	var accounts []common.Hash
+	for it.Next() {
+		accounts = append(accounts, common.BytesToHash(it.Key))
+	}
+	res := serveStorageRanges(source.db, &GetStorageRangesPacket{Root: root, Accounts: accounts, Bytes: 4096})
+	if len(res.Slots) == 0 || len(res.Proof) == 0 {
+		t.Fatalf("storage range not truncated: %d accounts, %d proof nodes", len(res.Slots), len(res.Proof))
+	}
+	if len(res.Slots) == len(accounts) {
+		t.Fatalf("all accounts served despite truncation")
+	}
+}
+
+// Tests that account and storage ranges are verified as they arrive, dropping
+// peers that serve forged or incomplete ranges.
+func TestForgedRangesDropped(t *testing.T) {
+	source, root := makeTestState(t)
+
+	syncer := NewSyncer(mandb.NewMemDatabase())
+	syncer.reset(root)
+	peer := NewPeer(snap1, p2p.NewPeer(discover.NodeID{1}, "forger", nil), nil)
+
+	// deliver feeds a response to a fresh request, reporting whether the peer
+	// was dropped for it
+	deliver := func(req *request, res *response) bool {
+		req.id, req.peer, req.timer = syncer.nextID, peer, time.NewTimer(time.Hour)
+		syncer.nextID++
+		syncer.pending[req.id] = req
+		delete(syncer.stateless, peer.id)
+
+		res.id, res.peer = req.id, peer
+		if err := syncer.process(res); err != nil {
+			t.Fatalf("failed to process response: %v", err)
+		}
+		_, dropped := syncer.stateless[peer.id]
+		return dropped
+	}
+	task := syncer.accTasks[0]
+	accountTests := []struct {
+		name  string
+		forge func(res *AccountRangePacket)
+	}{
+		{"missing account", func(res *AccountRangePacket) { res.Accounts = append(res.Accounts[:1], res.Accounts[2:]...) }},
+		{"modified account", func(res *AccountRangePacket) { res.Accounts[1].Body = res.Accounts[2].Body }},
+		{"missing proof node", func(res *AccountRangePacket) { res.Proof = res.Proof[1:] }},
+		{"empty range", func(res *AccountRangePacket) { res.Accounts = nil }},
+	}
+	for _, tt := range accountTests {
+		res := serveAccountRange(source.db, &GetAccountRangePacket{Root: root, Origin: task.next, Limit: task.last, Bytes: 1024})
+		tt.forge(res)
+		if !deliver(&request{account: task}, &response{accounts: res}) {
+			t.Errorf("%s: peer not dropped", tt.name)
+		}
+		if task.done || syncer.accTrie.Hash() != emptyRoot {
+			t.Fatalf("%s: forged accounts accepted", tt.name)
+		}
+	}
+	res := serveAccountRange(source.db, &GetAccountRangePacket{Root: root, Origin: task.next, Limit: task.last, Bytes: 1024})
+	if deliver(&request{account: task}, &response{accounts: res}) {
+		t.Fatalf("honest peer dropped")
+	}
+	if task.next != common.BigToHash(new(big.Int).Add(res.Accounts[len(res.Accounts)-1].Hash.Big(), common.Big1)) {
+		t.Fatalf("account task not advanced")
+	}
+	// Forge the storage of the contract with the large storage
+	accTrie, _ := trie.New(root, source.db.TrieDB())
+	account := crypto.Keccak256Hash(common.BigToAddress(big.NewInt(1000)).Bytes())
+	var acc state.Account
+	blob, _ := accTrie.TryGet(account[:])
+	if err := rlp.DecodeBytes(blob, &acc); err != nil {
+		t.Fatalf("failed to decode contract: %v", err)
+	}
+	newTask := func() *storageTask {
+		tr, _ := trie.New(common.Hash{}, syncer.triedb)
+		return &storageTask{account: account, root: acc.Root, trie: tr}
+	}
+	serve := func(origin common.Hash) *StorageRangesPacket {
+		return serveStorageRanges(source.db, &GetStorageRangesPacket{Root: root, Accounts: []common.Hash{account}, Origin: origin, Bytes: 4096})
+	}
+	storageTests := []struct {
+		name  string
+		forge func(res *StorageRangesPacket)
+	}{
+		{"missing slot", func(res *StorageRangesPacket) { res.Slots[0] = append(res.Slots[0][:1], res.Slots[0][2:]...) }},
+		{"modified slot", func(res *StorageRangesPacket) { res.Slots[0][1].Body = res.Slots[0][2].Body }},
+		{"truncation hidden", func(res *StorageRangesPacket) { res.Proof = nil }},
+	}
+	for _, tt := range storageTests {
+		task := newTask()
+		res := serve(common.Hash{})
+		tt.forge(res)
+		if !deliver(&request{storage: task}, &response{storage: res}) {
+			t.Errorf("%s: peer not dropped", tt.name)
+		}
+		if task.trie.Hash() != emptyRoot {
+			t.Fatalf("%s: forged slots accepted", tt.name)
+		}
+	}
+	// A continuation range must be proven from its origin
+	stTask := newTask()
+	if deliver(&request{storage: stTask}, &response{storage: serve(common.Hash{})}) {
+		t.Fatalf("honest peer dropped")
+	}
+	cont := serve(stTask.next)
+	cont.Slots[0] = cont.Slots[0][1:]
+	if !deliver(&request{storage: stTask}, &response{storage: cont}) {
+		t.Errorf("continuation with missing first slot: peer not dropped")
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+// Package tokens implements RPC helpers for querying MRC-20 token contracts
+// directly from the node.
+package tokens
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/hashicorp/golang-lru"
+	"github.com/matrix/go-matrix/accounts/abi"
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/common/hexutil"
+	"github.com/matrix/go-matrix/core"
+	"github.com/matrix/go-matrix/core/state"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/core/vm"
+	"github.com/matrix/go-matrix/rpc"
+)
+
+const (
+	// maxTokens is the maximum number of token contracts that can be queried
+	// in a single request.
+	maxTokens = 256
+
+	// callGas is the gas allowance of every individual static call.
+	callGas = 1000000
+
+	// metadataCacheSize is the number of token contracts whose metadata is
+	// kept in memory.
+	metadataCacheSize = 1024
+)
+
+// mrc20ABI is the subset of the MRC-20 interface needed to report balances.
+const mrc20ABI = `[
+	{"constant":true,"inputs":[{"name":"owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"type":"function"},
+	{"constant":true,"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"type":"function"},
+	{"constant":true,"inputs":[],"name":"symbol","outputs":[{"name":"","type":"string"}],"type":"function"}
+]`
+
+var (
+	errTooManyTokens = fmt.Errorf("too many token contracts, at most %d allowed", maxTokens)
+	errNoContract    = errors.New("no contract code at address")
+)
+
+// Backend is the chain access needed to run static calls against token contracts.
+type Backend interface {
+	StateAndHeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDB, *types.Header, error)
+	GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg vm.Config) (*vm.EVM, func() error, error)
+}
+
+// tokenMetadata is the immutable part of a token contract, cached across calls.
+type tokenMetadata struct {
+	symbol   string
+	decimals uint8
+}
+
+// PublicTokenAPI provides read access to MRC-20 token contracts.
+type PublicTokenAPI struct {
+	b     Backend
+	abi   abi.ABI
+	cache *lru.Cache // Token metadata indexed by contract address
+}
+
+// NewPublicTokenAPI creates a new token API served by the given backend.
+func NewPublicTokenAPI(b Backend) *PublicTokenAPI {
+	parsed, err := abi.JSON(strings.NewReader(mrc20ABI))
+	if err != nil {
+		panic(err)
+	}
+	cache, _ := lru.New(metadataCacheSize)
+	return &PublicTokenAPI{b: b, abi: parsed, cache: cache}
+}
+
+// TokenBalance is the balance of a single token contract held by an account.
+// If the contract could not be queried, Error is set and the rest is empty.
+type TokenBalance struct {
+	Contract common.Address `json:"contract"`
+	Symbol   string         `json:"symbol,omitempty"`
+	Decimals hexutil.Uint64 `json:"decimals"`
+	Balance  *hexutil.Big   `json:"balance,omitempty"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// TokenBalances returns the balance, symbol and decimals of every given token
+// contract for address, evaluated against the latest block. Failures of single
+// contracts are reported inline rather than failing the whole request.
+func (api *PublicTokenAPI) TokenBalances(ctx context.Context, address common.Address, tokens []common.Address) ([]TokenBalance, error) {
+	if len(tokens) > maxTokens {
+		return nil, errTooManyTokens
+	}
+	statedb, header, err := api.b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	balances := make([]TokenBalance, len(tokens))
+	for i, token := range tokens {
+		balances[i].Contract = token
+		meta, balance, err := api.tokenBalance(ctx, statedb, header, address, token)
+		if err != nil {
+			balances[i].Error = err.Error()
+			continue
+		}
+		balances[i].Symbol = meta.symbol
+		balances[i].Decimals = hexutil.Uint64(meta.decimals)
+		balances[i].Balance = (*hexutil.Big)(balance)
+	}
+	return balances, nil
+}
+
+// tokenBalance queries the balance of owner in a single token contract, along
+// with the contract's metadata.
+func (api *PublicTokenAPI) tokenBalance(ctx context.Context, statedb *state.StateDB, header *types.Header, owner, token common.Address) (*tokenMetadata, *big.Int, error) {
+	if statedb.GetCodeSize(token) == 0 {
+		return nil, nil, errNoContract
+	}
+	meta, err := api.metadata(ctx, statedb, header, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	balance := new(big.Int)
+	if err := api.call(ctx, statedb, header, token, &balance, "balanceOf", owner); err != nil {
+		return nil, nil, err
+	}
+	return meta, balance, nil
+}
+
+// metadata returns the symbol and decimals of a token contract, from the cache
+// if they were queried before.
+func (api *PublicTokenAPI) metadata(ctx context.Context, statedb *state.StateDB, header *types.Header, token common.Address) (*tokenMetadata, error) {
+	if meta, ok := api.cache.Get(token); ok {
+		return meta.(*tokenMetadata), nil
+	}
+	meta := new(tokenMetadata)
+	if err := api.call(ctx, statedb, header, token, &meta.decimals, "decimals"); err != nil {
+		return nil, err
+	}
+	// Some early tokens declare the symbol as bytes32 instead of string
+	if err := api.call(ctx, statedb, header, token, &meta.symbol, "symbol"); err != nil {
+		raw, rawErr := api.rawCall(ctx, statedb, header, token, "symbol")
+		if rawErr != nil || len(raw) != 32 {
+			return nil, err
+		}
+		meta.symbol = string(bytes.TrimRight(raw, "\x00"))
+	}
+	api.cache.Add(token, meta)
+	return meta, nil
+}
+
+// call executes a read-only contract method and unpacks its result into out.
+func (api *PublicTokenAPI) call(ctx context.Context, statedb *state.StateDB, header *types.Header, token common.Address, out interface{}, method string, args ...interface{}) error {
+	ret, err := api.rawCall(ctx, statedb, header, token, method, args...)
+	if err != nil {
+		return err
+	}
+	if err := api.abi.Unpack(out, method, ret); err != nil {
+		return fmt.Errorf("%s: %v", method, err)
+	}
+	return nil
+}
+
+// rawCall executes a read-only contract method and returns its raw output.
+func (api *PublicTokenAPI) rawCall(ctx context.Context, statedb *state.StateDB, header *types.Header, token common.Address, method string, args ...interface{}) ([]byte, error) {
+	input, err := api.abi.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	msg := types.NewMessage(common.Address{}, &token, 0, new(big.Int), callGas, new(big.Int), input, false)
+	evm, _, err := api.b.GetEVM(ctx, msg, statedb, header, vm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	ret, _, err := evm.StaticCall(vm.AccountRef(msg.From()), token, input, callGas)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", method, err)
+	}
+	return ret, nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package tokens
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/core"
+	"github.com/matrix/go-matrix/core/state"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/core/vm"
+	"github.com/matrix/go-matrix/mandb"
+	"github.com/matrix/go-matrix/params"
+	"github.com/matrix/go-matrix/rpc"
+)
+
+// tokenCode is a minimal MRC-20 contract answering balanceOf from the storage
+// slot keyed by the owner address, decimals with 18 and symbol with "TST".
+var tokenCode = common.FromHex(strings.Join([]string{
+	"6000357c0100000000000000000000000000000000000000000000000000000000900480",     // selector
+	"6370a08231146045578063313ce56714605257806395d89b4114605d57600080fd", // dispatch
+	"5b6004355460005260206000f3", // 0x45: balanceOf
+	"5b601260005260206000f3",     // 0x52: decimals
+	"5b602060005260036020527f" + "545354" + strings.Repeat("00", 29) + "60405260606000f3", // 0x5d: symbol
+}, ""))
+
+type testBackend struct {
+	statedb *state.StateDB
+	calls   int
+}
+
+func (b *testBackend) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	return b.statedb.Copy(), &types.Header{Number: big.NewInt(1), Time: big.NewInt(0), Difficulty: big.NewInt(0)}, nil
+}
+
+func (b *testBackend) GetEVM(ctx context.Context, msg core.Message, statedb *state.StateDB, header *types.Header, vmCfg vm.Config) (*vm.EVM, func() error, error) {
+	b.calls++
+	context := vm.Context{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		GetHash:     func(uint64) common.Hash { return common.Hash{} },
+		Origin:      msg.From(),
+		GasPrice:    new(big.Int),
+		BlockNumber: header.Number,
+		Time:        header.Time,
+		Difficulty:  header.Difficulty,
+	}
+	return vm.NewEVM(context, statedb, params.TestChainConfig, vmCfg), func() error { return nil }, nil
+}
+
+func newTestBackend(t *testing.T) (*testBackend, common.Address, common.Address) {
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(mandb.NewMemDatabase()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	owner := common.HexToAddress("0x2000000000000000000000000000000000000002")
+
+	statedb.SetCode(token, tokenCode)
+	statedb.SetState(token, common.BytesToHash(owner.Bytes()), common.BigToHash(big.NewInt(12345)))
+	return &testBackend{statedb: statedb}, token, owner
+}
+
+func TestTokenBalances(t *testing.T) {
+	backend, token, owner := newTestBackend(t)
+	api := NewPublicTokenAPI(backend)
+
+	missing := common.HexToAddress("0x3000000000000000000000000000000000000003")
+	balances, err := api.TokenBalances(context.Background(), owner, []common.Address{token, missing})
+	if err != nil {
+		t.Fatalf("failed to query balances: %v", err)
+	}
+	if len(balances) != 2 {
+		t.Fatalf("balance count mismatch: have %d, want 2", len(balances))
+	}
+	if b := balances[0]; b.Error != "" || b.Symbol != "TST" || b.Decimals != 18 || b.Balance.ToInt().Int64() != 12345 {
+		t.Errorf("token balance mismatch: have %+v", b)
+	}
+	if b := balances[1]; b.Error != errNoContract.Error() || b.Balance != nil {
+		t.Errorf("missing contract not reported: have %+v", b)
+	}
+	// Metadata must be served from the cache on subsequent queries
+	calls := backend.calls
+	if _, err := api.TokenBalances(context.Background(), common.Address{}, []common.Address{token}); err != nil {
+		t.Fatalf("failed to query balances: %v", err)
+	}
+	if have := backend.calls - calls; have != 1 {
+		t.Errorf("call count mismatch with cached metadata: have %d, want 1", have)
+	}
+}
+
+func TestTokenBalancesLimit(t *testing.T) {
+	backend, _, owner := newTestBackend(t)
+	api := NewPublicTokenAPI(backend)
+
+	if _, err := api.TokenBalances(context.Background(), owner, make([]common.Address, maxTokens+1)); err != errTooManyTokens {
+		t.Fatalf("error mismatch: have %v, want %v", err, errTooManyTokens)
+	}
+}
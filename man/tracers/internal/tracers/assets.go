@@ -21,6 +21,7 @@
 // noop_tracer.js
 // opcount_tracer.js
 // prestate_tracer.js
+// transfer_tracer.js
 
 package tracers
 
@@ -207,6 +208,26 @@ func prestate_tracerJs() (*asset, error) {
 	return a, nil
 }
 
+var _transfer_tracerJs = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xad\x58\x6b\x73\xda\x48\x16\xfd\x6c\xff\x8a\x1e\x3e\xcc\x42\xad\x42\x78\x3f\xbc\x9b\x6c\x11\x90\x63\xd5\x60\x70\x81\x9c\x4c\x2a\x95\x0f\x2d\xa9\x05\xda\x08\x49\xab\x47\x6c\x26\xc3\x7f\xdf\x73\xbb\x25\x81\x6c\x3c\x95\xd9\x9d\x94\x2b\xa0\x56\xdf\xd7\xb9\xe7\xde\xbe\xcd\xeb\xd7\x6c\x1a\x46\xfb\xd8\xdb\x6c\x53\xd6\x69\xb5\x47\xcc\xdc\x0a\x76\x3b\x31\x57\xc6\xaf\x6c\x92\xa5\xdb\x30\x4e\x18\x4f\xd8\x83\xf0\x7d\xfa\xac\x6c\xee\xbd\xc2\x7f\x43\x29\xb1\x09\x5f\x89\x74\x2b\x62\x91\xed\x0a\xb1\xcb\xd7\xaf\xf1\xca\x4b\x98\xeb\xf9\x82\xe1\xd3\x0e\x83\xc4\x4b\x52\xe1\xb0\xd0\x65\xe9\xd1\x8c\xef\x59\x31\x8f\xf7\x8c\x07\x0e\x8b\x78\x9c\x16\xaf\x4f\x75\xe6\x7b\x9a\x50\xaa\xf4\x16\xd2\xcf\xb6\x90\x25\x37\x16\x82\x25\xa1\x9b\x3e\xf0\x58\x5c\xb1\x7d\x98\x31\x9b\x07\x2c\x16\x0e\xec\xc7\x9e\x95\xa5\x70\x28\x25\x83\xaf\xc3\x98\xed\x42\xc7\x73\xf7\xb4\x90\x05\x8e\x88\xa5\xed\x54\xc4\xbb\xa4\xf4\xd3\x30\xd9\xdc\xb3\x45\x90\x88\xc2\x81\x3b\xbc\xf7\x92\xc4\x0b\x03\xb2\x47\x1e\x58\x7b\xb6\x89\x79\x80\xf0\x34\x65\x1f\xc2\xf6\x96\xc7\x1b\xa1\xb1\x34\x84\xad\x3d\x8b\x44\x9c\x40\x20\xb4\x52\xee\x05\x5e\xb0\x61\x1c\x98\x44\x7b\x65\x06\x6a\x0a\x8f\x25\x12\x3c\x49\x42\xdb\xe3\x04\x97\x13\xda\xd9\x4e\x04\x29\x4f\xc9\x1e\xc1\x99\xb0\x3a\x39\x56\x5b\xe7\x12\xb5\x86\x46\x5e\xc1\x8e\x23\xb8\xcf\xbc\x40\xfa\x5d\xbc\x65\x0f\x1e\x32\x92\xa5\x00\x80\xc2\xb7\x49\x8d\x86\x4d\xb6\x9f\x39\xe4\x46\xf1\xda\xf7\x76\x5e\x6e\x84\xc4\x65\x9e\x13\x52\x9a\x25\x08\x82\x5c\xd5\x72\xb0\xf0\x29\x64\x64\x51\x66\xf9\x5e\xb2\xd5\xd8\x11\x59\x8d\x25\xb4\x28\xe1\xd2\x18\xdc\xca\x61\x4e\x88\x42\x50\xe2\x89\x12\xd8\xc2\x41\x4d\x46\x0c\x43\x11\xc1\x9a\xe6\x40\x49\xd3\x0f\xdb\x70\x57\x0d\x86\xf2\x9b\xc5\x01\xac\x0a\x29\xe3\x84\x00\x4e\x1a\xfd\xb7\xb0\x53\xac\xd0\x6e\x37\xf4\xfd\xf0\x81\x82\x03\xeb\x1c\x8f\x62\x4a\xae\x54\xee\x88\x3b\xdc\x0a\xbf\x09\x19\x91\x22\x73\x10\xa6\x70\x58\x79\x41\x99\x88\x8e\xe9\xcd\x5f\x25\x5b\x0e\xf7\x2d\x91\xc3\x06\xd3\x00\x99\x9f\x44\x14\x93\x07\x49\x0a\x06\x78\xc8\x40\x14\xc6\xd2\xe6\xd3\x48\x73\xfe\x98\x37\x3a\x5b\x2f\xaf\xcd\x8f\x93\x95\xce\x8c\x35\xbb\x5b\x2d\x3f\x18\x33\x7d\xc6\x6a\x93\x35\x9e\x6b\x1a\xfb\x68\x98\x37\xcb\x7b\x93\x61\xc7\x6a\xb2\x30\x3f\xb1\xe5\x35\x9b\x2c\x3e\xb1\x5f\x8c\xc5\x4c\x63\xfa\xaf\x77\x2b\x7d\xbd\x66\xcb\x15\x33\x6e\xef\xe6\x86\x8e\x35\x63\x31\x9d\xdf\xcf\x8c\xc5\x7b\xf6\x0e\x72\x8b\x25\x38\x6b\x80\xb9\x50\x6a\x2e\x19\x19\xcc\x55\x19\xfa\x9a\x94\xdd\xea\xab\xe9\x0d\x1e\x27\xef\x8c\xb9\x61\x7e\x22\xfe\x5c\x1b\xe6\x82\xb4\x5e\x43\xed\x84\xdd\x4d\x56\xa6\x31\xbd\x9f\x4f\x56\xec\xee\x7e\x75\xb7\x5c\xeb\x70\x60\x06\xc5\x0b\x63\x71\xbd\x82\x1d\xfd\x56\x5f\x98\x4d\xd8\xc5\x1a\xd3\x3f\xe0\x81\xad\x6f\x26\xf3\xb9\x34\x36\xb9\x87\xff\x2b\xe9\xe1\x74\x79\xf7\x69\x65\xbc\xbf\x31\xd9\xcd\x72\x3e\xd3\xb1\xf8\x4e\x87\x6f\x93\x77\x73\x5d\x99\x42\x58\xd3\xf9\xc4\xb8\xd5\xd8\x6c\x72\x3b\x79\xaf\x4b\xa9\x25\xb4\xac\xe4\x36\xe5\x1f\x31\xe9\xe3\x8d\x2e\x57\x61\x72\x82\xbf\xa9\x69\x2c\x17\x14\xcb\x74\xb9\x30\x57\x78\xd4\x10\xea\xca\x2c\xa5\x3f\x1a\x6b\x5d\x63\x93\x95\xb1\x86\xb7\xd7\xab\x25\x0c\x10\xa4\x10\x58\x4a\x1d\x10\x5b\xe8\x4a\x09\xc1\xcd\x4e\xb3\x02\x6b\xd8\x44\x2b\xf7\x08\xbc\xf4\x67\xa6\x4f\xe6\x50\xb6\x26\xf1\xd3\xed\x4d\x59\x7e\xa8\xff\xc4\x15\xb1\x19\x73\x1b\x7d\x24\x16\xc4\x82\x44\xe6\xff\x1b\xf7\x33\xc5\xaf\xdb\xd5\x14\x6d\x13\x34\xfd\x2a\x82\x52\x02\x3d\x56\x7d\xe7\xb2\x38\x49\x19\x28\xe8\x86\x68\x42\x60\xf6\x57\x2f\x8a\x88\xca\xa8\xd2\x04\x65\x09\x6a\x83\x7e\x16\xb7\xbf\x32\x34\x1d\x97\x7b\x3e\xbd\xf4\x82\x00\x36\x6d\x50\x32\x69\xb2\x05\xaa\x18\x04\x2f\xd5\x93\x42\xaa\x1d\xe5\x12\xa4\xa1\x5a\xf5\xb9\xa3\x51\x34\x40\x94\xa8\xab\xe5\xbe\xda\x3c\x8e\xf7\xb2\x80\x48\xa5\x74\xdd\x8e\x85\xec\x0e\x4a\x1d\x16\x68\x3f\x3a\x0e\x2a\x3f\xb3\xd3\x44\x7b\x16\x14\x59\x11\xdf\x04\x9a\xb2\x40\x59\x93\x5d\x33\x7f\x47\xcb\x41\x5a\x74\x53\xfd\x91\xef\x22\x5f\x50\x81\x32\xc6\xde\x42\xa5\x95\x6d\x9a\x29\xc1\x68\x1e\x1d\xac\xd7\x5a\x8f\x9d\x76\x4f\xf4\xc7\x43\xd1\xed\x3b\xbc\x35\xea\x0e\xc6\x1d\xb7\xdf\x1d\x0d\xda\xbd\xb6\x18\x8c\xdd\xde\x50\x8c\x87\x5d\xab\x63\xf7\x07\x62\xc8\x47\x2d\x7c\x6f\x0b\xec\x73\x9d\x61\x7f\xd8\x16\x63\x47\xa0\xb2\xbe\x4b\xbd\xf1\x15\xab\x55\xf3\x55\x3b\x34\x94\xfd\xcf\xea\x83\xb1\xef\xc5\x17\xc6\xd2\x7d\x84\x83\xa4\x36\x05\xbd\x6b\xda\x71\xd9\x8d\xc3\x1d\x96\x5b\x8f\x5d\x6b\x34\xec\xf2\x71\x7b\xcc\x79\xab\xdf\xee\x38\x7d\xde\x72\x5b\x63\x31\x70\x6c\x5b\x70\xde\xe3\x83\x61\x67\xe8\x72\x57\x9c\x0a\xa7\xa1\x14\x6d\xb5\x3a\x3d\x77\xd0\x1f\xf1\xde\xc0\xb5\xac\xd1\xd8\x19\x71\xbb\xdd\xea\x8b\xf1\xc8\x19\x72\x7b\x68\x5b\x16\x77\x3b\x43\xbb\x7f\x2a\x2a\x53\x24\xa5\x1d\xd1\xb2\x06\x56\x97\x0f\x07\xbd\x16\xfe\xd5\x8a\x3d\x07\xed\xe5\x28\x40\xc1\x4e\xab\xea\x09\xf2\xf6\x3f\x3b\xf3\x57\x80\xd0\xb1\x6d\xdb\xed\x8b\x16\x92\xd9\x1b\x77\xed\x0e\xf2\xdb\xb6\xfb\x82\x5c\x69\xb9\xc3\xa1\x33\x1e\x8b\x71\xbb\x3b\x1e\x9c\x07\x61\xd0\x3b\xc6\xad\xbe\x7c\xb9\xfc\x7e\x79\x81\x6f\x6e\xcc\x77\xe8\xce\x9e\xaa\x42\xb4\x67\x14\x0d\x5a\x72\x24\x02\xa7\xe0\x76\xbe\x47\x63\x82\xdb\x5b\xb4\x73\x54\x17\xe8\x26\xcb\xed\xb4\x82\x48\x9b\x13\x06\xea\x30\xf5\x02\x35\x2e\xa4\x9e\x2f\xb7\x49\x3d\x58\xb1\x84\x1f\x06\x1b\x79\x70\x25\x19\xa2\x16\x8e\x3c\x19\xa8\x46\x93\xe6\xe5\x85\xb2\x74\xc5\x3e\x7f\x2f\xf5\xe2\xe1\xcb\xe1\x8b\x76\x29\xf5\x97\x84\xc4\x99\x62\x17\x4e\xcb\x4a\x61\x89\xb7\x09\x78\x9a\xc5\x72\xae\x28\xaa\xa8\xce\x1d\x07\x67\x7a\xa2\x15\x9f\x99\x17\xa4\x9d\xfe\xa0\x01\x5b\x15\x5d\x40\xc9\x71\xdc\x4e\xbf\xc3\x9d\xb6\x25\x3a\xf6\x68\x6c\x0d\xc6\x76\xc7\x6a\x0d\x46\xae\xdd\x1d\x8e\x1c\xce\xc7\xfd\x8e\xc5\x87\x6e\x7b\xd0\xb5\x7b\xbc\xdd\x1e\x74\x46\x6e\xbf\xcf\x7b\x8e\xdb\xef\x74\xad\xae\x70\x6b\xb9\x8f\xb1\xb0\xc3\x18\xe3\x49\x44\x18\x96\x7d\x8b\x4a\x1a\x41\x4b\x2c\xb2\x38\x86\xc7\x3e\x8a\xfe\x51\xd8\x59\x5a\xc5\x19\x9e\x29\x0d\x57\x38\xc5\x03\x55\xd7\x85\x86\x06\xb8\x7a\x71\x41\x07\x70\x53\x21\xf5\xf9\xe4\x7b\xd3\x17\xc1\x26\xdd\xb2\x57\xac\xfd\xa5\x59\xc2\xd7\x8c\xb2\x64\x7b\x54\xf0\x8f\xcb\x8b\x43\xe9\x67\x12\xfa\xe8\x81\x89\x48\x53\x1a\x98\xc8\x83\x32\x55\x39\x2d\xd2\x2d\xa7\xa1\x08\xb0\x06\xd4\x51\x05\xfa\x95\x38\x8d\x41\x2a\x0a\x23\x3b\x74\x84\x9a\x78\x9e\xd1\x82\xb2\x21\x33\x9d\x24\x6e\xe6\x33\xf0\x03\x64\x0b\x14\x12\x5e\x4c\xe3\x2c\xd4\x24\x32\x68\xe9\xce\x49\xd4\x7e\xb8\x51\x01\x7f\xe3\x31\x3a\x5e\x84\xd8\xde\x30\x2c\x36\x37\x22\x9d\xd1\x63\x9d\xc2\xb9\x78\xd8\xd2\xf8\x5c\x3f\x83\xc4\x5b\xd6\x66\x3f\xff\xcc\x7e\x04\x2f\xa5\xfe\xed\x1b\x65\x47\x99\x95\x76\xe5\x5e\xd8\x3d\x15\x8c\xc2\x88\x4c\xd3\x96\x7c\xd4\x96\x98\x21\x82\xcc\x4f\x89\x95\x61\x80\xe4\xe6\x43\xa2\x2a\x29\xcf\xc5\x0d\xe1\x6f\x00\x4f\x9e\x4a\xf9\xf8\x49\x52\x04\x98\x54\x5b\x18\x04\xda\x30\xa7\xa8\x90\x07\x9d\x7b\xc5\xfe\x25\xa3\x97\x0a\x9b\x91\x10\x5f\xeb\xad\x06\xbb\x62\x96\xb7\x31\x70\x52\xfc\x26\xe2\x90\xe0\xb8\x80\xa9\x3a\x94\x34\xc5\x7f\x32\xee\x27\xd8\x93\x47\x73\x81\x41\x0f\x5c\xcb\x84\xdc\x75\x28\xb6\x2a\x4b\xf2\xe4\x12\xec\x27\xd8\xa2\x19\xdf\xf5\x90\xef\x42\xee\x74\x47\x13\x99\x03\x18\xe1\x8d\x78\xac\xa7\xe1\x44\x55\x95\x34\x97\x86\x6b\xcc\xb7\xc1\xa6\xde\x1e\x34\x1a\x8d\xa3\x0d\x8a\x49\x65\xb9\x8a\xe2\x4b\xa9\x90\x92\x4a\xe0\xc8\x62\x88\x3e\x5d\x6a\x22\x1c\x9b\xa7\xb9\xff\xe5\xb2\xb4\x7c\x38\xb2\x1c\xd7\xa9\x88\x52\xe2\x05\xdf\xd0\xc3\x9d\x93\x13\x57\xd1\x56\x51\x9c\xb2\xf1\xe1\x36\xaf\x47\x41\x74\x24\xb9\x2a\x17\x31\xc2\x5b\x0a\x12\xa8\x9d\xf2\x48\xf6\x1a\xba\xb5\x88\x38\xa6\x4b\xa0\xb7\xdb\xe1\xfa\x04\x8c\xfc\x7d\xce\x59\xf9\xe2\xc8\x59\x9d\x1e\x15\x67\x09\x78\xf5\xf6\x1c\xe2\x0a\x19\x0e\x32\x95\x76\x25\x2a\xaa\x10\xf3\x00\xd5\xae\xbc\x6a\x64\xad\x48\x46\x92\xdd\x30\xca\x8d\x86\xd1\x31\x2d\x52\x45\x82\xde\x8c\x1e\x5e\x0f\x23\x65\xca\xe6\x98\x97\xd4\xa1\x7d\xc5\x8e\x0f\xd3\xe5\x4c\x2f\x17\x66\xfa\x5c\x7f\x3f\x31\xf5\xca\xae\xb5\x39\xc1\xec\xab\x96\x9e\x96\xca\x77\xc9\xd6\xab\x27\xa5\xaa\xb1\x6a\x4b\x2f\xb9\x4a\xde\xbe\xc9\x9d\x28\x28\x47\xea\xd4\x94\xf5\xe6\x29\xe7\x3b\x0a\x0b\x29\xfa\x93\xdc\xf3\x9c\xe9\x17\x4f\x48\xa1\x9a\x60\xfe\xee\x42\x1d\xf2\x40\x49\xcb\x17\xd4\xf9\x9c\xb3\x9a\xcc\x51\xa5\x60\xe4\x49\xc9\xfd\x82\xe3\x8d\x46\xb1\x9d\x4e\x64\xc6\x9e\x15\xc1\x13\x3f\xdb\x8d\x27\x25\x51\x88\x1f\x4f\xe5\x1a\xfb\xbb\x8a\xb2\xb2\x53\x6d\x3b\xe4\x61\x1e\xca\x42\xaa\x74\x20\x8a\x47\x7e\x57\xdb\x2c\x14\xe7\x57\x99\xff\x3c\x89\x2b\x1d\x19\xfb\xbf\x52\xf3\x07\x29\x68\x35\xca\xe4\xbd\x94\x81\x4a\x57\x79\x53\xa4\xe5\x29\xf2\x7f\x0e\xf8\x1f\x02\x4e\x39\x7f\x9e\x00\xa7\x4e\x9d\x74\xa8\x3f\x03\xec\x5a\x9f\x5f\xcf\xf4\xb5\xb9\xba\x9f\x9a\x47\x78\x2d\xee\xf3\xc0\xa6\x40\x1d\x8b\x5c\x7f\xa7\x9e\xff\x20\xa4\x23\x80\xb9\xec\x73\x08\xf3\xfa\xa6\x51\xa0\xfe\x97\xe0\xf7\x63\xbc\x6d\xbd\xc0\xdb\x2a\xfa\x85\xd7\xcf\xf1\x3f\x01\xf6\x29\x78\xf3\xe5\xfb\xae\x02\x8d\x22\x7f\x56\xd7\x15\x65\x68\x8c\xea\xb4\xa8\x8e\x7c\xbf\xff\xfe\x94\x8c\xa8\x33\xe9\xda\xd2\xad\x37\xd8\x3f\x59\xb7\x53\xe0\x97\x5b\xaf\x64\xb9\x02\x67\x8e\x66\x39\xed\x2b\x8c\xe4\xa0\xff\x63\x88\x56\xe0\x7f\x11\xd0\xee\x79\x40\x7f\x2c\x1b\xbd\xf3\xc2\xd5\x64\x90\xcc\x4e\xec\xc2\x78\x4f\x5e\xde\x63\xbc\x3a\x93\xd4\x12\xa4\xc6\xb3\xa4\x1d\x2a\x5c\xaf\x1c\x9f\xf2\x14\x3a\x3d\x3f\x1f\xb6\x42\x0d\x2f\xc0\x04\x84\x2d\x46\x58\xfa\xd5\xce\xc5\x69\x58\x9c\xaa\x6a\xa0\x97\xb3\x51\x7e\xff\x3e\xde\x36\x48\x9d\x1a\x3a\x1d\xba\x9f\xe3\x56\x2d\x27\x21\x11\x6b\xd0\xee\xe1\x78\x4a\x04\x8d\xa3\x8c\x26\x9a\xd3\xc9\x8a\xee\x07\xe4\xce\x4b\xc7\xf2\x99\x03\xf1\x74\xd8\x3d\x17\x48\x31\xf3\x2a\xaf\x13\xb6\xe5\x18\x88\x2d\x21\xe8\xf6\x22\x62\xf9\xdb\x62\x88\x61\x41\xde\xe5\xd5\xe9\xab\xee\x39\xf2\x17\x34\x2f\xe0\xe5\xd0\x97\xff\x8a\x45\x54\x41\xac\x6a\x9c\xad\xba\x6a\xa7\x8f\x47\x57\x89\xff\x58\x68\xbe\x3c\x03\x28\x6b\xe8\xc6\xc5\x71\x4f\x7d\xe6\x74\x1e\x52\x6f\x64\x0b\x21\x4d\xe7\xfb\xf0\xd9\x13\x30\xe7\x3d\x49\xd1\xd7\x33\x5c\xa6\x57\xb4\x70\x8e\xaa\x52\x2c\x3c\xcb\xc3\xa3\x1f\xe7\x19\x76\x50\xb3\xd3\xb5\xba\x5b\x24\xb8\x1b\xfa\xe5\x45\x53\x5d\x0c\xe9\x8a\xa4\x7e\x4e\x91\x63\x59\x40\x93\x5a\x79\xff\x00\x7c\x31\x86\x05\xe0\xbc\xbb\x2c\x01\x7a\x36\x0f\x9e\x8e\x98\xad\x2f\xd5\xc1\xf0\x70\x79\xb8\xfc\x2f\x85\x9a\x5c\x6e\xd1\x17\x00\x00")
+
+func transfer_tracerJsBytes() ([]byte, error) {
+	return bindataRead(
+		_transfer_tracerJs,
+		"transfer_tracer.js",
+	)
+}
+
+func transfer_tracerJs() (*asset, error) {
+	bytes, err := transfer_tracerJsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "transfer_tracer.js", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -270,6 +291,8 @@ var _bindata = map[string]func() (*asset, error){
 	"opcount_tracer.js": opcount_tracerJs,
 
 	"prestate_tracer.js": prestate_tracerJs,
+
+	"transfer_tracer.js": transfer_tracerJs,
 }
 
 // AssetDir returns the file names below a certain
@@ -319,6 +342,7 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"noop_tracer.js":     {noop_tracerJs, map[string]*bintree{}},
 	"opcount_tracer.js":  {opcount_tracerJs, map[string]*bintree{}},
 	"prestate_tracer.js": {prestate_tracerJs, map[string]*bintree{}},
+	"transfer_tracer.js": {transfer_tracerJs, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory
@@ -0,0 +1,164 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+// transferTracer reports the value and MRC-20 token transfers a transaction
+// performs, skipping those rolled back by failing inner calls. Native transfers
+// are reported for the transaction itself, value carrying calls and creations
+// and self destructs, token transfers for every emitted Transfer event.
+//
+// Example:
+//   > debug.traceTransaction("0x214e597e35da083692f5386141e69f47e973b2c56e7a8073b1ea08fd7571e9de", {tracer: "transferTracer"})
+//   [
+//     {
+//       type: "CALL",
+//       from: "0x3b873a919aa0512d5a0f09e6dcceaa4a6727fafe",
+//       to: "0x0024f658a46fbb89d8ac105e98d7ac7cbbaf27c5",
+//       value: "0xde0b6b3a7640000"
+//     },
+//     {
+//       type: "MRC20",
+//       token: "0x0024f658a46fbb89d8ac105e98d7ac7cbbaf27c5",
+//       from: "0x3b873a919aa0512d5a0f09e6dcceaa4a6727fafe",
+//       to: "0x2cccf5e0538493c235d1c5ef6580f77d99e91396",
+//       value: "0x64"
+//     }
+//   ]
+{
+	// frames is the stack of pending call frames, each collecting the transfers
+	// done within it until the call it belongs to succeeds or fails.
+	frames: [{transfers: []}],
+
+	// transferTopic is the event signature of Transfer(address,address,uint256).
+	transferTopic: "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+
+	// record appends a transfer to the currently executing call frame.
+	record: function(transfer) {
+		this.frames[this.frames.length - 1].transfers.push(transfer);
+	},
+
+	// resolve settles all the call frames that returned before the current
+	// opcode, merging the transfers of successful ones into their parents.
+	resolve: function(log) {
+		var depth = log.getDepth();
+		while (this.frames.length > 1 && this.frames[this.frames.length - 1].depth >= depth) {
+			var frame = this.frames.pop();
+
+			// The call result is only on the stack if we're back in the calling frame
+			var ret = frame.depth == depth ? log.stack.peek(0) : bigInt.zero;
+			if (ret.equals(0)) {
+				continue;
+			}
+			if (frame.create !== undefined) {
+				frame.create.to = toHex(toAddress(ret.toString(16)));
+			}
+			var parent = this.frames[this.frames.length - 1];
+			parent.transfers = parent.transfers.concat(frame.transfers);
+		}
+	},
+
+	// step is invoked for every opcode that the VM executes.
+	step: function(log, db) {
+		// Capture any errors immediately
+		var error = log.getError();
+		if (error !== undefined) {
+			this.fault(log, db);
+			return;
+		}
+		this.resolve(log);
+
+		var op = log.op.toString();
+		switch (op) {
+		case "CALL": case "CALLCODE": case "DELEGATECALL": case "STATICCALL":
+			var frame = {depth: log.getDepth(), transfers: []};
+			if (op == "CALL") {
+				var value = log.stack.peek(2);
+				if (!value.equals(0)) {
+					frame.transfers.push({
+						type:  op,
+						from:  toHex(log.contract.getAddress()),
+						to:    toHex(toAddress(log.stack.peek(1).toString(16))),
+						value: "0x" + value.toString(16)
+					});
+				}
+			}
+			this.frames.push(frame);
+			break;
+
+		case "CREATE":
+			var frame = {depth: log.getDepth(), transfers: []};
+			var value = log.stack.peek(0);
+			if (!value.equals(0)) {
+				frame.create = {
+					type:  op,
+					from:  toHex(log.contract.getAddress()),
+					value: "0x" + value.toString(16)
+				};
+				frame.transfers.push(frame.create);
+			}
+			this.frames.push(frame);
+			break;
+
+		case "SELFDESTRUCT":
+			var balance = db.getBalance(log.contract.getAddress());
+			if (!balance.equals(0)) {
+				this.record({
+					type:  op,
+					from:  toHex(log.contract.getAddress()),
+					to:    toHex(toAddress(log.stack.peek(0).toString(16))),
+					value: "0x" + balance.toString(16)
+				});
+			}
+			break;
+
+		case "LOG3":
+			if (log.stack.peek(2).toString(16) != this.transferTopic || log.stack.peek(1).valueOf() < 32) {
+				break;
+			}
+			this.record({
+				type:  "MRC20",
+				token: toHex(log.contract.getAddress()),
+				from:  toHex(toAddress(log.stack.peek(3).toString(16))),
+				to:    toHex(toAddress(log.stack.peek(4).toString(16))),
+				value: "0x" + log.memory.getUint(log.stack.peek(0).valueOf()).toString(16)
+			});
+			break;
+		}
+	},
+
+	// fault is invoked when the actual execution of an opcode fails. The failing
+	// frame is settled by its caller, which sees a zero call result.
+	fault: function(log, db) {
+		this.resolve(log);
+	},
+
+	// result is invoked when all the opcodes have been iterated over and returns
+	// the final result of the tracing.
+	result: function(ctx, db) {
+		if (ctx.error !== undefined) {
+			return [];
+		}
+		var transfers = [];
+		if (!ctx.value.equals(0)) {
+			transfers.push({
+				type:  ctx.type,
+				from:  toHex(ctx.from),
+				to:    toHex(ctx.to),
+				value: "0x" + ctx.value.toString(16)
+			});
+		}
+		// Frames still pending belong to calls that never returned, drop them
+		return transfers.concat(this.frames[0].transfers);
+	}
+}
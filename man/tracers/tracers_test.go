@@ -32,6 +32,7 @@ import (
 	"github.com/matrix/go-matrix/core/types"
 	"github.com/matrix/go-matrix/core/vm"
 	"github.com/matrix/go-matrix/mandb"
+	"github.com/matrix/go-matrix/params"
 	"github.com/matrix/go-matrix/rlp"
 	"github.com/matrix/go-matrix/tests"
 )
@@ -191,3 +192,79 @@ func TestCallTracer(t *testing.T) {
 		})
 	}
 }
+
+// transferTrace is the result of a transferTracer run.
+type transferTrace struct {
+	Type  string          `json:"type"`
+	Token *common.Address `json:"token,omitempty"`
+	From  common.Address  `json:"from"`
+	To    common.Address  `json:"to"`
+	Value *hexutil.Big    `json:"value"`
+}
+
+// Tests that the transfer tracer reports value and token transfers, but skips
+// the ones done within reverted calls.
+func TestTransferTracer(t *testing.T) {
+	var (
+		sender    = common.HexToAddress("0x1000000000000000000000000000000000000001")
+		token     = common.HexToAddress("0x2000000000000000000000000000000000000002")
+		reverter  = common.HexToAddress("0x3000000000000000000000000000000000000003")
+		recipient = common.HexToAddress("0x4000000000000000000000000000000000000004")
+		topic     = common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+	)
+	// emit assembles the code emitting Transfer(sender, recipient, 100)
+	emit := func() []byte {
+		code := []byte{byte(vm.PUSH1), 0x64, byte(vm.PUSH1), 0x00, byte(vm.MSTORE)}
+		code = append(append(code, byte(vm.PUSH20)), recipient[:]...)
+		code = append(append(code, byte(vm.PUSH20)), sender[:]...)
+		code = append(append(code, byte(vm.PUSH32)), topic[:]...)
+		return append(code, byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0x00, byte(vm.LOG3))
+	}
+	// call assembles the code sending value to the given address
+	call := func(to common.Address, value byte) []byte {
+		code := []byte{byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.PUSH1), value}
+		code = append(append(code, byte(vm.PUSH20)), to[:]...)
+		return append(code, byte(vm.PUSH2), 0xff, 0xff, byte(vm.CALL), byte(vm.POP))
+	}
+	tokenCode := append(append(emit(), call(recipient, 5)...), call(reverter, 7)...)
+	revertCode := append(emit(), byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.REVERT))
+
+	statedb := tests.MakePreState(mandb.NewMemDatabase(), core.GenesisAlloc{
+		sender:   {Balance: big.NewInt(1000000)},
+		token:    {Code: tokenCode, Balance: new(big.Int)},
+		reverter: {Code: revertCode, Balance: new(big.Int)},
+	})
+	context := vm.Context{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		Origin:      sender,
+		BlockNumber: big.NewInt(1),
+		Time:        big.NewInt(1),
+		Difficulty:  big.NewInt(1),
+		GasPrice:    big.NewInt(1),
+	}
+	tracer, err := New("transferTracer")
+	if err != nil {
+		t.Fatalf("failed to create transfer tracer: %v", err)
+	}
+	evm := vm.NewEVM(context, statedb, params.TestChainConfig, vm.Config{Debug: true, Tracer: tracer})
+	if _, _, err := evm.Call(vm.AccountRef(sender), token, nil, 1000000, big.NewInt(1000)); err != nil {
+		t.Fatalf("failed to execute call: %v", err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	var have []transferTrace
+	if err := json.Unmarshal(res, &have); err != nil {
+		t.Fatalf("failed to unmarshal trace result: %v", err)
+	}
+	want := []transferTrace{
+		{Type: "CALL", From: sender, To: token, Value: (*hexutil.Big)(big.NewInt(1000))},
+		{Type: "MRC20", Token: &token, From: sender, To: recipient, Value: (*hexutil.Big)(big.NewInt(100))},
+		{Type: "CALL", From: token, To: recipient, Value: (*hexutil.Big)(big.NewInt(5))},
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Fatalf("trace mismatch: have %s, want %+v", res, want)
+	}
+}
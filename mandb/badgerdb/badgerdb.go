@@ -0,0 +1,355 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+// Package badgerdb implements a mandb storage engine backed by BadgerDB. The
+// engine registers itself as "badger" when the package is imported.
+package badgerdb
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger"
+	"github.com/dgraph-io/badger/options"
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/log"
+	"github.com/matrix/go-matrix/mandb"
+	"github.com/matrix/go-matrix/metrics"
+)
+
+// Engine is the name the BadgerDB storage engine is registered under.
+const Engine = "badger"
+
+const (
+	// gcInterval is the time between two value log garbage collection runs.
+	gcInterval = 5 * time.Minute
+
+	// gcDiscardRatio is the fraction of stale data above which a value log file
+	// is rewritten during garbage collection.
+	gcDiscardRatio = 0.5
+)
+
+func init() {
+	mandb.RegisterEngine(Engine, func(file string, cache int, handles int) (mandb.KeyValueStore, error) {
+		return New(file, cache, handles)
+	})
+}
+
+// Database is a key/value store persisted by BadgerDB.
+type Database struct {
+	fn string     // filename for reporting
+	db *badger.DB // BadgerDB instance
+
+	lsmSizeGauge  metrics.Gauge // Gauge for tracking the size of the LSM tree
+	vlogSizeGauge metrics.Gauge // Gauge for tracking the size of the value log
+
+	quit chan struct{}  // Quit channel to stop the background tasks before closing the database
+	wg   sync.WaitGroup // Wait group tracking the background tasks
+
+	log log.Logger // Contextual logger tracking the database path
+}
+
+// New opens the BadgerDB database in the given directory, creating it if it
+// does not exist yet. The cache allowance in megabytes sizes the memtables;
+// Badger memory maps its files, so it doesn't need file handles allotted.
+func New(file string, cache int, handles int) (*Database, error) {
+	logger := log.New("database", file)
+
+	// Ensure we have some minimal caching guarantees
+	if cache < 16 {
+		cache = 16
+	}
+	logger.Info("Allocated cache", "cache", cache)
+
+	opts := badger.DefaultOptions
+	opts.Dir, opts.ValueDir = file, file
+	opts.SyncWrites = false // Same durability as the LevelDB engine
+	opts.TableLoadingMode = options.MemoryMap
+	opts.MaxTableSize = int64(cache) * 1024 * 1024 / int64(opts.NumMemtables)
+
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, err
+	}
+	bdb := &Database{
+		fn:   file,
+		db:   db,
+		quit: make(chan struct{}),
+		log:  logger,
+	}
+	bdb.wg.Add(1)
+	go bdb.collectGarbage()
+
+	return bdb, nil
+}
+
+// Path returns the path to the database directory.
+func (db *Database) Path() string {
+	return db.fn
+}
+
+// Put inserts the given value into the database.
+func (db *Database) Put(key []byte, value []byte) error {
+	return db.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(common.CopyBytes(key), common.CopyBytes(value))
+	})
+}
+
+// Has reports whether the database contains the given key.
+func (db *Database) Has(key []byte) (bool, error) {
+	err := db.db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get(key)
+		return err
+	})
+	if err == badger.ErrKeyNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Get returns the value of the given key if it's present.
+func (db *Database) Get(key []byte) ([]byte, error) {
+	var value []byte
+	err := db.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			return err
+		}
+		value, err = item.ValueCopy(nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Delete removes the given key from the database.
+func (db *Database) Delete(key []byte) error {
+	return db.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(common.CopyBytes(key))
+	})
+}
+
+// Iterate returns an iterator over the entries whose key starts with prefix.
+func (db *Database) Iterate(prefix []byte) mandb.Iterator {
+	txn := db.db.NewTransaction(false)
+	return &iterator{
+		txn:    txn,
+		it:     txn.NewIterator(badger.DefaultIteratorOptions),
+		prefix: common.CopyBytes(prefix),
+	}
+}
+
+// Compact reclaims the space of stale entries in the value log. Badger
+// compacts its LSM tree continuously in the background.
+func (db *Database) Compact() error {
+	for {
+		switch err := db.db.RunValueLogGC(gcDiscardRatio); err {
+		case nil:
+		case badger.ErrNoRewrite:
+			return nil
+		default:
+			return err
+		}
+	}
+}
+
+// Close stops the background tasks and closes the database.
+func (db *Database) Close() {
+	close(db.quit)
+	db.wg.Wait()
+
+	if err := db.db.Close(); err == nil {
+		db.log.Info("Database closed")
+	} else {
+		db.log.Error("Failed to close database", "err", err)
+	}
+}
+
+// Meter configures the database metrics collectors under prefix.
+func (db *Database) Meter(prefix string) {
+	if !metrics.Enabled {
+		return
+	}
+	db.lsmSizeGauge = metrics.NewRegisteredGauge(prefix+"disk/lsm", nil)
+	db.vlogSizeGauge = metrics.NewRegisteredGauge(prefix+"disk/vlog", nil)
+
+	db.wg.Add(1)
+	go db.meter(3 * time.Second)
+}
+
+// meter periodically reports the on-disk size of the database.
+func (db *Database) meter(refresh time.Duration) {
+	defer db.wg.Done()
+
+	ticker := time.NewTicker(refresh)
+	defer ticker.Stop()
+
+	for {
+		lsm, vlog := db.db.Size()
+		db.lsmSizeGauge.Update(lsm)
+		db.vlogSizeGauge.Update(vlog)
+
+		select {
+		case <-ticker.C:
+		case <-db.quit:
+			return
+		}
+	}
+}
+
+// collectGarbage periodically rewrites value log files holding mostly stale
+// entries, which Badger doesn't do on its own.
+func (db *Database) collectGarbage() {
+	defer db.wg.Done()
+
+	ticker := time.NewTicker(gcInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := db.Compact(); err != nil {
+				db.log.Warn("Value log garbage collection failed", "err", err)
+			}
+		case <-db.quit:
+			return
+		}
+	}
+}
+
+// NewBatch creates a write-only batch committing to the database on Write.
+func (db *Database) NewBatch() mandb.Batch {
+	return &batch{db: db.db}
+}
+
+// batchOp is a single write of a batch, a deletion if value is nil.
+type batchOp struct {
+	key, value []byte
+}
+
+// batch buffers writes until they are committed in as few transactions as
+// Badger's transaction size limit allows.
+type batch struct {
+	db   *badger.DB
+	ops  []batchOp
+	size int
+}
+
+// Put inserts the given value into the batch.
+func (b *batch) Put(key, value []byte) error {
+	b.ops = append(b.ops, batchOp{common.CopyBytes(key), append([]byte{}, value...)})
+	b.size += len(value)
+	return nil
+}
+
+// Delete inserts a key removal into the batch.
+func (b *batch) Delete(key []byte) error {
+	b.ops = append(b.ops, batchOp{key: common.CopyBytes(key)})
+	b.size++
+	return nil
+}
+
+// Write commits the batched writes. Batches exceeding the size of a single
+// Badger transaction are split, so they are not applied atomically.
+func (b *batch) Write() error {
+	txn := b.db.NewTransaction(true)
+	defer func() { txn.Discard() }()
+
+	for _, op := range b.ops {
+		err := b.apply(txn, op)
+		if err == badger.ErrTxnTooBig {
+			if err = txn.Commit(nil); err != nil {
+				return err
+			}
+			txn = b.db.NewTransaction(true)
+			err = b.apply(txn, op)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return txn.Commit(nil)
+}
+
+// apply adds a single batched write to the transaction.
+func (b *batch) apply(txn *badger.Txn, op batchOp) error {
+	if op.value == nil {
+		return txn.Delete(op.key)
+	}
+	return txn.Set(op.key, op.value)
+}
+
+// ValueSize returns the amount of data in the batch.
+func (b *batch) ValueSize() int {
+	return b.size
+}
+
+// Reset clears the batch for reuse.
+func (b *batch) Reset() {
+	b.ops = b.ops[:0]
+	b.size = 0
+}
+
+// iterator iterates over the entries of a read-only transaction whose key
+// starts with a prefix.
+type iterator struct {
+	txn     *badger.Txn
+	it      *badger.Iterator
+	prefix  []byte
+	started bool
+	value   []byte
+	err     error
+}
+
+// Next moves the iterator to the next entry, returning whether there is one.
+func (it *iterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.started {
+		it.it.Next()
+	} else {
+		it.it.Seek(it.prefix)
+		it.started = true
+	}
+	if !it.it.ValidForPrefix(it.prefix) {
+		return false
+	}
+	it.value, it.err = it.it.Item().Value()
+	return it.err == nil
+}
+
+// Key returns the key of the current entry, valid until the next step.
+func (it *iterator) Key() []byte {
+	return it.it.Item().Key()
+}
+
+// Value returns the value of the current entry, valid until the next step.
+func (it *iterator) Value() []byte {
+	return it.value
+}
+
+// Release closes the iterator and its transaction.
+func (it *iterator) Release() {
+	it.it.Close()
+	it.txn.Discard()
+}
+
+// Error returns any error encountered while iterating.
+func (it *iterator) Error() error {
+	return it.err
+}
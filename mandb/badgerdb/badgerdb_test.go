@@ -0,0 +1,200 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package badgerdb
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matrix/go-matrix/mandb"
+)
+
+func newTestDB(t *testing.T) (*Database, string) {
+	dir, err := ioutil.TempDir("", "badgerdb-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := New(filepath.Join(dir, "db"), 0, 0)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("failed to open database: %v", err)
+	}
+	return db, dir
+}
+
+func TestPutGetDelete(t *testing.T) {
+	db, dir := newTestDB(t)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	if _, err := db.Get([]byte("missing")); err == nil {
+		t.Fatalf("missing key retrieved")
+	}
+	if ok, err := db.Has([]byte("missing")); ok || err != nil {
+		t.Fatalf("missing key reported: %v, %v", ok, err)
+	}
+	key, value := []byte("key"), []byte("value")
+	if err := db.Put(key, value); err != nil {
+		t.Fatalf("failed to put: %v", err)
+	}
+	// Buffers handed in may be reused by the caller
+	value[0] = 'X'
+	if have, err := db.Get(key); err != nil || string(have) != "value" {
+		t.Fatalf("value mismatch: have %q (%v), want %q", have, err, "value")
+	}
+	if err := db.Put([]byte("empty"), nil); err != nil {
+		t.Fatalf("failed to put empty value: %v", err)
+	}
+	if ok, _ := db.Has([]byte("empty")); !ok {
+		t.Fatalf("empty value not stored")
+	}
+	if err := db.Delete(key); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	if ok, _ := db.Has(key); ok {
+		t.Fatalf("deleted key still present")
+	}
+}
+
+// Tests that batches larger than a single Badger transaction are committed in
+// full and that deletions are applied.
+func TestBatch(t *testing.T) {
+	db, dir := newTestDB(t)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	db.Put([]byte("stale"), []byte("value"))
+
+	batch := db.NewBatch()
+	value := make([]byte, 1024)
+	for i := 0; i < 8192; i++ {
+		if err := batch.Put([]byte(fmt.Sprintf("key-%05d", i)), value); err != nil {
+			t.Fatalf("failed to batch entry %d: %v", i, err)
+		}
+	}
+	batch.Delete([]byte("stale"))
+	if size := batch.ValueSize(); size < 8192*1024 {
+		t.Fatalf("batch size mismatch: have %d, want at least %d", size, 8192*1024)
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write batch: %v", err)
+	}
+	for i := 0; i < 8192; i++ {
+		if ok, _ := db.Has([]byte(fmt.Sprintf("key-%05d", i))); !ok {
+			t.Fatalf("entry %d missing after batch write", i)
+		}
+	}
+	if ok, _ := db.Has([]byte("stale")); ok {
+		t.Fatalf("batched deletion not applied")
+	}
+	batch.Reset()
+	if batch.ValueSize() != 0 {
+		t.Fatalf("batch not reset")
+	}
+}
+
+func TestIterate(t *testing.T) {
+	db, dir := newTestDB(t)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	for _, key := range []string{"a1", "b1", "b2", "b3", "c1"} {
+		db.Put([]byte(key), []byte("v"+key))
+	}
+	tests := []struct {
+		prefix string
+		keys   []string
+	}{
+		{"", []string{"a1", "b1", "b2", "b3", "c1"}},
+		{"b", []string{"b1", "b2", "b3"}},
+		{"c1", []string{"c1"}},
+		{"d", nil},
+	}
+	for _, tt := range tests {
+		var keys []string
+		it := db.Iterate([]byte(tt.prefix))
+		for it.Next() {
+			if !bytes.Equal(it.Value(), append([]byte("v"), it.Key()...)) {
+				t.Errorf("prefix %q: value mismatch for %q: %q", tt.prefix, it.Key(), it.Value())
+			}
+			keys = append(keys, string(it.Key()))
+		}
+		if err := it.Error(); err != nil {
+			t.Errorf("prefix %q: iteration failed: %v", tt.prefix, err)
+		}
+		it.Release()
+		if fmt.Sprint(keys) != fmt.Sprint(tt.keys) {
+			t.Errorf("prefix %q: keys mismatch: have %v, want %v", tt.prefix, keys, tt.keys)
+		}
+	}
+}
+
+// Tests that a LevelDB database migrated to Badger and back retains every
+// entry, and that the Badger database persists across restarts.
+func TestMigrateRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "badgerdb-migrate-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	want := make(map[string]string)
+	src, err := mandb.Open(mandb.DefaultEngine, filepath.Join(dir, "leveldb"), 0, 0)
+	if err != nil {
+		t.Fatalf("failed to open source: %v", err)
+	}
+	for i := 0; i < 1000; i++ {
+		key, value := fmt.Sprintf("key-%04d", i), fmt.Sprintf("value-%d-%x", i, make([]byte, i%200))
+		if err := src.Put([]byte(key), []byte(value)); err != nil {
+			t.Fatalf("failed to insert entry %d: %v", i, err)
+		}
+		want[key] = value
+	}
+	bdb, err := mandb.Open(Engine, filepath.Join(dir, "badger"), 0, 0)
+	if err != nil {
+		t.Fatalf("failed to open badger database: %v", err)
+	}
+	if entries, err := mandb.Migrate(bdb, src, nil); err != nil || entries != len(want) {
+		t.Fatalf("migration to badger failed: have %d/%v, want %d/nil", entries, err, len(want))
+	}
+	src.Close()
+	bdb.Close()
+
+	if engine, err := mandb.DetectEngine(filepath.Join(dir, "badger")); err != nil || engine != Engine {
+		t.Fatalf("detected engine mismatch: have %q (%v), want %q", engine, err, Engine)
+	}
+	if bdb, err = mandb.Open(Engine, filepath.Join(dir, "badger"), 0, 0); err != nil {
+		t.Fatalf("failed to reopen badger database: %v", err)
+	}
+	defer bdb.Close()
+	dst, err := mandb.Open(mandb.DefaultEngine, filepath.Join(dir, "back"), 0, 0)
+	if err != nil {
+		t.Fatalf("failed to open destination: %v", err)
+	}
+	defer dst.Close()
+	if entries, err := mandb.Migrate(dst, bdb, nil); err != nil || entries != len(want) {
+		t.Fatalf("migration from badger failed: have %d/%v, want %d/nil", entries, err, len(want))
+	}
+	for key, value := range want {
+		if have, err := dst.Get([]byte(key)); err != nil || string(have) != value {
+			t.Fatalf("entry %s mismatch: have %q (%v), want %q", key, have, err, value)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package mandb
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultEngine is the storage engine used unless configured otherwise.
+const DefaultEngine = "leveldb"
+
+// engineFile is the name of the marker file recording which storage engine
+// created a database directory.
+const engineFile = "ENGINE"
+
+// Iterator iterates over the entries of a key/value store in ascending key order.
+type Iterator interface {
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Release()
+	Error() error
+}
+
+// KeyValueStore is a Database persisted by a storage engine, which can also be
+// iterated over and compacted.
+type KeyValueStore interface {
+	Database
+
+	// Path returns the directory holding the store.
+	Path() string
+
+	// Iterate returns an iterator over the entries whose key starts with prefix.
+	Iterate(prefix []byte) Iterator
+
+	// Compact flattens the on-disk representation of the whole store.
+	Compact() error
+
+	// Meter configures the metrics collectors of the store under prefix.
+	Meter(prefix string)
+}
+
+// Opener opens the key/value store in the given directory, creating it if
+// it does not exist yet. Cache is the memory allowance in megabytes and
+// handles the number of open files the engine may keep.
+type Opener func(file string, cache int, handles int) (KeyValueStore, error)
+
+var (
+	enginesLock sync.RWMutex
+	engines     = map[string]Opener{
+		"leveldb": func(file string, cache int, handles int) (KeyValueStore, error) {
+			return NewLDBDatabase(file, cache, handles)
+		},
+	}
+)
+
+// RegisterEngine makes a storage engine available under the given name.
+// LevelDB is built in; alternative engines such as mandb/badgerdb register
+// themselves from an init function, keeping their dependencies out of builds
+// not importing them.
+func RegisterEngine(name string, open Opener) {
+	enginesLock.Lock()
+	defer enginesLock.Unlock()
+
+	if _, ok := engines[name]; ok {
+		panic(fmt.Sprintf("mandb: storage engine %q registered twice", name))
+	}
+	engines[name] = open
+}
+
+// HasEngine reports whether a storage engine is available under the given name.
+func HasEngine(name string) bool {
+	enginesLock.RLock()
+	defer enginesLock.RUnlock()
+
+	_, ok := engines[name]
+	return ok
+}
+
+// Engines returns the names of all available storage engines.
+func Engines() []string {
+	enginesLock.RLock()
+	defer enginesLock.RUnlock()
+
+	names := make([]string, 0, len(engines))
+	for name := range engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DetectEngine returns the storage engine which created the database in the
+// given directory, or an empty string if there is no database yet. Databases
+// predating the engine marker are LevelDB ones.
+func DetectEngine(file string) (string, error) {
+	blob, err := ioutil.ReadFile(filepath.Join(file, engineFile))
+	if err == nil {
+		return strings.TrimSpace(string(blob)), nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+	if _, err := os.Stat(filepath.Join(file, "CURRENT")); err == nil {
+		return DefaultEngine, nil
+	}
+	return "", nil
+}
+
+// Open opens the key/value store in the given directory with the requested
+// storage engine. An existing database can only be opened with the engine
+// that created it; use Migrate to convert it to another one.
+func Open(engine string, file string, cache int, handles int) (KeyValueStore, error) {
+	if engine == "" {
+		engine = DefaultEngine
+	}
+	enginesLock.RLock()
+	open, ok := engines[engine]
+	enginesLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown database engine %q, available: %s", engine, strings.Join(Engines(), ", "))
+	}
+	existing, err := DetectEngine(file)
+	if err != nil {
+		return nil, err
+	}
+	if existing != "" && existing != engine {
+		return nil, fmt.Errorf("database %s was created by engine %q, migrate it to use %q", file, existing, engine)
+	}
+	db, err := open(file, cache, handles)
+	if err != nil {
+		return nil, err
+	}
+	if existing == "" {
+		if err := ioutil.WriteFile(filepath.Join(file, engineFile), []byte(engine+"\n"), 0644); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// errMigrateSame is returned when migrating a store into itself.
+var errMigrateSame = errors.New("source and destination are the same database")
+
+// Migrate copies every entry of src into dst in batches, calling progress
+// after each written batch with the number of entries copied so far.
+func Migrate(dst, src KeyValueStore, progress func(entries int)) (int, error) {
+	if dst.Path() == src.Path() {
+		return 0, errMigrateSame
+	}
+	it := src.Iterate(nil)
+	defer it.Release()
+
+	var (
+		batch   = dst.NewBatch()
+		entries int
+	)
+	for it.Next() {
+		// Iterator buffers are only valid until the next step
+		if err := batch.Put(append([]byte{}, it.Key()...), append([]byte{}, it.Value()...)); err != nil {
+			return entries, err
+		}
+		entries++
+		if batch.ValueSize() >= IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return entries, err
+			}
+			batch.Reset()
+			if progress != nil {
+				progress(entries)
+			}
+		}
+	}
+	if err := it.Error(); err != nil {
+		return entries, err
+	}
+	if err := batch.Write(); err != nil {
+		return entries, err
+	}
+	if progress != nil {
+		progress(entries)
+	}
+	return entries, nil
+}
@@ -0,0 +1,168 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package mandb_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matrix/go-matrix/mandb"
+)
+
+// A second engine, backed by LevelDB as well, to exercise engine selection.
+func init() {
+	mandb.RegisterEngine("testdb", func(file string, cache int, handles int) (mandb.KeyValueStore, error) {
+		return mandb.NewLDBDatabase(file, cache, handles)
+	})
+}
+
+func TestEngineSelection(t *testing.T) {
+	if !mandb.HasEngine(mandb.DefaultEngine) || !mandb.HasEngine("testdb") || mandb.HasEngine("unknown") {
+		t.Fatalf("engine availability mismatch: %v", mandb.Engines())
+	}
+	dir, err := ioutil.TempDir("", "mandb-engine-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := mandb.Open("unknown", filepath.Join(dir, "db"), 0, 0); err == nil {
+		t.Fatalf("opened database with unknown engine")
+	}
+	db, err := mandb.Open("testdb", filepath.Join(dir, "db"), 0, 0)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	db.Close()
+
+	if engine, err := mandb.DetectEngine(filepath.Join(dir, "db")); err != nil || engine != "testdb" {
+		t.Fatalf("detected engine mismatch: have %q (%v), want %q", engine, err, "testdb")
+	}
+	if _, err := mandb.Open(mandb.DefaultEngine, filepath.Join(dir, "db"), 0, 0); err == nil {
+		t.Fatalf("opened database with a different engine than it was created by")
+	}
+	// Databases predating the engine marker are LevelDB ones
+	ldb, err := mandb.NewLDBDatabase(filepath.Join(dir, "legacy"), 0, 0)
+	if err != nil {
+		t.Fatalf("failed to create legacy database: %v", err)
+	}
+	ldb.Close()
+	if engine, err := mandb.DetectEngine(filepath.Join(dir, "legacy")); err != nil || engine != mandb.DefaultEngine {
+		t.Fatalf("legacy engine mismatch: have %q (%v), want %q", engine, err, mandb.DefaultEngine)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mandb-migrate-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src, err := mandb.Open(mandb.DefaultEngine, filepath.Join(dir, "src"), 0, 0)
+	if err != nil {
+		t.Fatalf("failed to open source: %v", err)
+	}
+	defer src.Close()
+
+	// Insert enough data to span multiple batches
+	value := make([]byte, 1024)
+	for i := 0; i < 300; i++ {
+		if err := src.Put([]byte(fmt.Sprintf("key-%03d", i)), value); err != nil {
+			t.Fatalf("failed to insert entry %d: %v", i, err)
+		}
+	}
+	dst, err := mandb.Open("testdb", filepath.Join(dir, "dst"), 0, 0)
+	if err != nil {
+		t.Fatalf("failed to open destination: %v", err)
+	}
+	defer dst.Close()
+
+	var batches int
+	entries, err := mandb.Migrate(dst, src, func(int) { batches++ })
+	if err != nil {
+		t.Fatalf("migration failed: %v", err)
+	}
+	if entries != 300 {
+		t.Errorf("migrated entry count mismatch: have %d, want %d", entries, 300)
+	}
+	if batches < 3 {
+		t.Errorf("migration not batched: %d batches", batches)
+	}
+	for i := 0; i < 300; i++ {
+		if ok, _ := dst.Has([]byte(fmt.Sprintf("key-%03d", i))); !ok {
+			t.Fatalf("entry %d missing after migration", i)
+		}
+	}
+	if _, err := mandb.Migrate(src, src, nil); err == nil {
+		t.Errorf("migrated database into itself")
+	}
+}
+
+// Tests that a database migrated to another engine and back retains every
+// entry unchanged.
+func TestMigrateRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mandb-roundtrip-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var stores []mandb.KeyValueStore
+	for i, engine := range []string{mandb.DefaultEngine, "testdb", mandb.DefaultEngine} {
+		db, err := mandb.Open(engine, filepath.Join(dir, fmt.Sprintf("db-%d", i)), 0, 0)
+		if err != nil {
+			t.Fatalf("failed to open %s database: %v", engine, err)
+		}
+		defer db.Close()
+		stores = append(stores, db)
+	}
+	want := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		key, value := fmt.Sprintf("key-%04d", i), fmt.Sprintf("value-%d-%x", i, make([]byte, i%200))
+		if err := stores[0].Put([]byte(key), []byte(value)); err != nil {
+			t.Fatalf("failed to insert entry %d: %v", i, err)
+		}
+		want[key] = value
+	}
+	for i := 1; i < len(stores); i++ {
+		if entries, err := mandb.Migrate(stores[i], stores[i-1], nil); err != nil || entries != len(want) {
+			t.Fatalf("migration %d failed: have %d/%v, want %d/nil", i, entries, err, len(want))
+		}
+	}
+	it := stores[len(stores)-1].Iterate(nil)
+	defer it.Release()
+
+	have := make(map[string]string)
+	for it.Next() {
+		have[string(it.Key())] = string(it.Value())
+	}
+	if err := it.Error(); err != nil {
+		t.Fatalf("failed to iterate migrated database: %v", err)
+	}
+	if len(have) != len(want) {
+		t.Fatalf("entry count mismatch: have %d, want %d", len(have), len(want))
+	}
+	for key, value := range want {
+		if have[key] != value {
+			t.Errorf("entry %s mismatch: have %q, want %q", key, have[key], value)
+		}
+	}
+}
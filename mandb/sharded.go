@@ -0,0 +1,450 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package mandb
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/rlp"
+)
+
+// shardFile is the name of the marker file recording the position of a
+// directory within a sharded database.
+const shardFile = "SHARD"
+
+var (
+	// shardPrepareKey is the key in every shard holding the writes of the batch
+	// being written across multiple shards destined to that shard.
+	shardPrepareKey = []byte("\x00sharded-batch-prepare")
+
+	// shardCommitKey is the key in the first shard holding the sequence number
+	// of the batch being written across multiple shards, once all its writes
+	// have been prepared.
+	shardCommitKey = []byte("\x00sharded-batch-commit")
+)
+
+// ShardedDatabase is a key/value store split across multiple stores, typically
+// living on different disks, to spread the I/O load of large databases. Every
+// key is kept by exactly one shard, picked by hashing the key, so the set and
+// order of the shards is fixed once the database is created.
+//
+// Batches spanning multiple shards are written in two phases: each shard first
+// stores the writes destined to it under a prepare record, then a commit marker
+// is stored in the first shard, and finally every shard applies its writes and
+// drops its prepare record at once. When the database is opened after a crash,
+// the prepared writes are applied if the batch was committed and discarded
+// otherwise, so every batch is either applied in full or not at all.
+type ShardedDatabase struct {
+	shards []KeyValueStore
+	seq    uint64     // Sequence number of the last batch written in two phases
+	lock   sync.Mutex // Serializes the batches written in two phases
+}
+
+// NewShardedDatabase creates a sharded database on top of the given stores,
+// completing the batch interrupted by a crash, if any.
+func NewShardedDatabase(shards []KeyValueStore) (*ShardedDatabase, error) {
+	db := &ShardedDatabase{shards: shards}
+	if err := db.recover(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// OpenSharded opens the sharded database spread across the given directories
+// with the requested storage engine, creating the shards if they don't exist
+// yet. The cache and file handle allowances are split evenly between shards.
+func OpenSharded(engine string, dirs []string, cache int, handles int) (*ShardedDatabase, error) {
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("no database shards")
+	}
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		if seen[abs] {
+			return nil, fmt.Errorf("database shard %s listed twice", dir)
+		}
+		seen[abs] = true
+	}
+	shards := make([]KeyValueStore, 0, len(dirs))
+	closeAll := func() {
+		for _, shard := range shards {
+			shard.Close()
+		}
+	}
+	for i, dir := range dirs {
+		if err := checkShard(dir, i, len(dirs)); err != nil {
+			closeAll()
+			return nil, err
+		}
+		db, err := Open(engine, dir, cache/len(dirs), handles/len(dirs))
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		shards = append(shards, db)
+
+		marker := fmt.Sprintf("%d/%d\n", i, len(dirs))
+		if err := ioutil.WriteFile(filepath.Join(dir, shardFile), []byte(marker), 0644); err != nil {
+			closeAll()
+			return nil, err
+		}
+	}
+	db, err := NewShardedDatabase(shards)
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+	return db, nil
+}
+
+// checkShard verifies that dir is either empty or was created as the given
+// shard of a database of the given size.
+func checkShard(dir string, index, count int) error {
+	blob, err := ioutil.ReadFile(filepath.Join(dir, shardFile))
+	if os.IsNotExist(err) {
+		engine, err := DetectEngine(dir)
+		if err != nil {
+			return err
+		}
+		if engine != "" {
+			return fmt.Errorf("%s holds an unsharded database, convert it with 'gman db shard'", dir)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var haveIndex, haveCount int
+	if _, err := fmt.Sscanf(strings.TrimSpace(string(blob)), "%d/%d", &haveIndex, &haveCount); err != nil {
+		return fmt.Errorf("invalid shard marker in %s: %v", dir, err)
+	}
+	if haveIndex != index || haveCount != count {
+		return fmt.Errorf("database shard %s was created as shard %d of %d, configured as shard %d of %d", dir, haveIndex, haveCount, index, count)
+	}
+	return nil
+}
+
+// shardIndex returns the index of the shard keeping the given key out of count.
+func shardIndex(key []byte, count int) int {
+	h := fnv.New32a()
+	h.Write(key)
+	return int(h.Sum32() % uint32(count))
+}
+
+// shard returns the store keeping the given key.
+func (db *ShardedDatabase) shard(key []byte) KeyValueStore {
+	return db.shards[shardIndex(key, len(db.shards))]
+}
+
+// shardPrepare is the prepare record of a batch written across multiple shards,
+// holding the writes destined to a single shard.
+type shardPrepare struct {
+	Seq uint64
+	Ops []shardedOp
+}
+
+// recover completes the batch whose write across the shards was interrupted if
+// it was committed, or discards it otherwise, then drops its prepare records
+// and commit marker.
+func (db *ShardedDatabase) recover() error {
+	var (
+		seq       uint64
+		committed bool
+	)
+	if blob, err := db.shards[0].Get(shardCommitKey); err == nil {
+		if err := rlp.DecodeBytes(blob, &seq); err != nil {
+			return fmt.Errorf("invalid sharded batch commit marker: %v", err)
+		}
+		committed = true
+	}
+	for i, shard := range db.shards {
+		blob, err := shard.Get(shardPrepareKey)
+		if err != nil {
+			// No prepared writes, the shard is up to date
+			continue
+		}
+		var prepare shardPrepare
+		if err := rlp.DecodeBytes(blob, &prepare); err != nil {
+			return fmt.Errorf("invalid sharded batch prepare record in shard %d: %v", i, err)
+		}
+		batch := shard.NewBatch()
+		if committed && prepare.Seq == seq {
+			for _, op := range prepare.Ops {
+				if op.Delete {
+					batch.Delete(op.Key)
+				} else {
+					batch.Put(op.Key, op.Value)
+				}
+			}
+		}
+		batch.Delete(shardPrepareKey)
+		if err := batch.Write(); err != nil {
+			return err
+		}
+	}
+	if committed {
+		return db.shards[0].Delete(shardCommitKey)
+	}
+	return nil
+}
+
+// Shards returns the stores the database is split across.
+func (db *ShardedDatabase) Shards() []KeyValueStore {
+	return db.shards
+}
+
+// Path returns the directory of the first shard.
+func (db *ShardedDatabase) Path() string {
+	return db.shards[0].Path()
+}
+
+// Put inserts the given value into the shard keeping key.
+func (db *ShardedDatabase) Put(key []byte, value []byte) error {
+	return db.shard(key).Put(key, value)
+}
+
+// Get retrieves the value of key from the shard keeping it.
+func (db *ShardedDatabase) Get(key []byte) ([]byte, error) {
+	return db.shard(key).Get(key)
+}
+
+// Has reports whether the shard keeping key has a value for it.
+func (db *ShardedDatabase) Has(key []byte) (bool, error) {
+	return db.shard(key).Has(key)
+}
+
+// Delete removes key from the shard keeping it.
+func (db *ShardedDatabase) Delete(key []byte) error {
+	return db.shard(key).Delete(key)
+}
+
+// Close closes every shard.
+func (db *ShardedDatabase) Close() {
+	for _, shard := range db.shards {
+		shard.Close()
+	}
+}
+
+// Compact compacts every shard.
+func (db *ShardedDatabase) Compact() error {
+	for _, shard := range db.shards {
+		if err := shard.Compact(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Meter configures the metrics collectors of every shard, each under its own
+// sub-prefix.
+func (db *ShardedDatabase) Meter(prefix string) {
+	for i, shard := range db.shards {
+		shard.Meter(fmt.Sprintf("%sshard%d/", prefix, i))
+	}
+}
+
+// NewBatch creates a batch spanning all shards.
+func (db *ShardedDatabase) NewBatch() Batch {
+	batches := make([]Batch, len(db.shards))
+	for i, shard := range db.shards {
+		batches[i] = shard.NewBatch()
+	}
+	return &shardedBatch{db: db, batches: batches}
+}
+
+// Iterate returns an iterator over the entries of all shards whose key starts
+// with prefix, merged into ascending key order.
+func (db *ShardedDatabase) Iterate(prefix []byte) Iterator {
+	its := make([]Iterator, len(db.shards))
+	for i, shard := range db.shards {
+		its[i] = shard.Iterate(prefix)
+	}
+	return &shardedIterator{its: its, valid: make([]bool, len(its)), cur: -1}
+}
+
+// shardedOp is a single write of a sharded batch, as recorded in the prepare
+// record of its shard.
+type shardedOp struct {
+	Key    []byte
+	Value  []byte
+	Delete bool
+}
+
+// shardedBatch collects writes destined to a sharded database in per-shard
+// batches, along with the per-shard records of them needed to prepare a write
+// across multiple shards.
+type shardedBatch struct {
+	db      *ShardedDatabase
+	batches []Batch
+	ops     [][]shardedOp    // Writes destined to each shard
+	touched map[int]struct{} // Shards with pending writes
+	size    int
+}
+
+func (b *shardedBatch) add(op shardedOp) Batch {
+	index := shardIndex(op.Key, len(b.batches))
+	if b.touched == nil {
+		b.touched = make(map[int]struct{})
+		b.ops = make([][]shardedOp, len(b.batches))
+	}
+	b.touched[index] = struct{}{}
+	b.ops[index] = append(b.ops[index], op)
+	return b.batches[index]
+}
+
+func (b *shardedBatch) Put(key, value []byte) error {
+	b.size += len(value)
+	return b.add(shardedOp{Key: common.CopyBytes(key), Value: common.CopyBytes(value)}).Put(key, value)
+}
+
+func (b *shardedBatch) Delete(key []byte) error {
+	b.size++
+	return b.add(shardedOp{Key: common.CopyBytes(key), Delete: true}).Delete(key)
+}
+
+func (b *shardedBatch) ValueSize() int {
+	return b.size
+}
+
+// Write commits the batch. Batches confined to a single shard are atomic by
+// themselves, others are written in two phases: every touched shard prepares
+// its own writes, the batch is committed in the first shard, then every shard
+// applies its writes along with the removal of its prepare record.
+func (b *shardedBatch) Write() error {
+	if len(b.touched) <= 1 {
+		for index := range b.touched {
+			return b.batches[index].Write()
+		}
+		return nil
+	}
+	b.db.lock.Lock()
+	defer b.db.lock.Unlock()
+
+	b.db.seq++
+	seq := b.db.seq
+
+	for index := range b.touched {
+		prepare, err := rlp.EncodeToBytes(&shardPrepare{Seq: seq, Ops: b.ops[index]})
+		if err != nil {
+			return err
+		}
+		if err := b.db.shards[index].Put(shardPrepareKey, prepare); err != nil {
+			return err
+		}
+	}
+	commit, err := rlp.EncodeToBytes(seq)
+	if err != nil {
+		return err
+	}
+	if err := b.db.shards[0].Put(shardCommitKey, commit); err != nil {
+		return err
+	}
+	for index := range b.touched {
+		batch := b.batches[index]
+		if err := batch.Delete(shardPrepareKey); err != nil {
+			return err
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+	}
+	return b.db.shards[0].Delete(shardCommitKey)
+}
+
+func (b *shardedBatch) Reset() {
+	for _, batch := range b.batches {
+		batch.Reset()
+	}
+	b.ops, b.touched, b.size = nil, nil, 0
+}
+
+// shardedIterator merges the ordered iterators of the shards. Keys are unique
+// across shards, so the smallest current key of all shards is the next one.
+type shardedIterator struct {
+	its   []Iterator
+	valid []bool // Whether the shard iterator is positioned on an entry
+	cur   int    // Shard iterator positioned on the current entry, -1 before the first step
+}
+
+func (it *shardedIterator) Next() bool {
+	if it.cur == -1 {
+		for i, sub := range it.its {
+			it.valid[i] = sub.Next()
+		}
+	} else if it.valid[it.cur] {
+		it.valid[it.cur] = it.its[it.cur].Next()
+	}
+	next := -1
+	for i, sub := range it.its {
+		if it.valid[i] && (next == -1 || bytes.Compare(sub.Key(), it.its[next].Key()) < 0) {
+			next = i
+		}
+	}
+	// Skip the records of a batch being written concurrently
+	if next != -1 && (bytes.Equal(it.its[next].Key(), shardPrepareKey) || bytes.Equal(it.its[next].Key(), shardCommitKey)) {
+		it.cur = next
+		return it.Next()
+	}
+	if next == -1 {
+		it.cur = 0
+		for i := range it.valid {
+			it.valid[i] = false
+		}
+		return false
+	}
+	it.cur = next
+	return true
+}
+
+func (it *shardedIterator) Key() []byte {
+	if it.cur < 0 || !it.valid[it.cur] {
+		return nil
+	}
+	return it.its[it.cur].Key()
+}
+
+func (it *shardedIterator) Value() []byte {
+	if it.cur < 0 || !it.valid[it.cur] {
+		return nil
+	}
+	return it.its[it.cur].Value()
+}
+
+func (it *shardedIterator) Release() {
+	for _, sub := range it.its {
+		sub.Release()
+	}
+}
+
+func (it *shardedIterator) Error() error {
+	for _, sub := range it.its {
+		if err := sub.Error(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
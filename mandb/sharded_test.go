@@ -0,0 +1,329 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package mandb_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matrix/go-matrix/mandb"
+)
+
+func TestShardedDatabase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mandb-sharded-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dirs := []string{filepath.Join(dir, "a"), filepath.Join(dir, "b"), filepath.Join(dir, "c")}
+	db, err := mandb.OpenSharded(mandb.DefaultEngine, dirs, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to open sharded database: %v", err)
+	}
+	batch := db.NewBatch()
+	for i := 0; i < 100; i++ {
+		if err := batch.Put([]byte(fmt.Sprintf("key-%03d", i)), []byte{byte(i)}); err != nil {
+			t.Fatalf("failed to insert entry %d: %v", i, err)
+		}
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write batch: %v", err)
+	}
+	if err := db.Put([]byte("other"), []byte{0xff}); err != nil {
+		t.Fatalf("failed to insert entry: %v", err)
+	}
+	// Entries are spread over all shards
+	for i, shard := range db.Shards() {
+		it := shard.Iterate(nil)
+		var entries int
+		for it.Next() {
+			entries++
+		}
+		it.Release()
+		if entries == 0 {
+			t.Errorf("shard %d holds no entries", i)
+		}
+	}
+	// Iteration merges the shards in key order
+	it := db.Iterate([]byte("key-"))
+	var i int
+	for ; it.Next(); i++ {
+		if want := []byte(fmt.Sprintf("key-%03d", i)); !bytes.Equal(it.Key(), want) || !bytes.Equal(it.Value(), []byte{byte(i)}) {
+			t.Fatalf("entry %d mismatch: have %s=%x, want %s=%x", i, it.Key(), it.Value(), want, []byte{byte(i)})
+		}
+	}
+	it.Release()
+	if i != 100 {
+		t.Fatalf("iterated entry count mismatch: have %d, want 100", i)
+	}
+	if err := db.Delete([]byte("key-042")); err != nil {
+		t.Fatalf("failed to delete entry: %v", err)
+	}
+	if ok, _ := db.Has([]byte("key-042")); ok {
+		t.Fatalf("deleted entry still present")
+	}
+	db.Close()
+
+	// Reopening with the same layout finds the data, a different one is refused
+	if db, err = mandb.OpenSharded(mandb.DefaultEngine, dirs, 0, 0); err != nil {
+		t.Fatalf("failed to reopen sharded database: %v", err)
+	}
+	if value, err := db.Get([]byte("other")); err != nil || !bytes.Equal(value, []byte{0xff}) {
+		t.Fatalf("entry mismatch after reopen: have %x (%v), want ff", value, err)
+	}
+	db.Close()
+
+	if _, err := mandb.OpenSharded(mandb.DefaultEngine, []string{dirs[1], dirs[0], dirs[2]}, 0, 0); err == nil {
+		t.Fatalf("opened shards in a different order")
+	}
+	if _, err := mandb.OpenSharded(mandb.DefaultEngine, dirs[:2], 0, 0); err == nil {
+		t.Fatalf("opened a subset of the shards")
+	}
+	if _, err := mandb.OpenSharded(mandb.DefaultEngine, []string{dirs[0], dirs[0]}, 0, 0); err == nil {
+		t.Fatalf("opened the same shard twice")
+	}
+	// Unsharded databases need to be converted first
+	ldb, err := mandb.Open(mandb.DefaultEngine, filepath.Join(dir, "plain"), 0, 0)
+	if err != nil {
+		t.Fatalf("failed to create unsharded database: %v", err)
+	}
+	ldb.Close()
+	if _, err := mandb.OpenSharded(mandb.DefaultEngine, []string{filepath.Join(dir, "plain"), filepath.Join(dir, "d")}, 0, 0); err == nil {
+		t.Fatalf("opened an unsharded database as a shard")
+	}
+}
+
+// failingStore is a store whose batches fail to be written.
+type failingStore struct {
+	mandb.KeyValueStore
+}
+
+func (s failingStore) NewBatch() mandb.Batch {
+	return failingBatch{s.KeyValueStore.NewBatch()}
+}
+
+type failingBatch struct {
+	mandb.Batch
+}
+
+func (b failingBatch) Write() error {
+	return errors.New("disk failure")
+}
+
+type failingPutStore struct {
+	mandb.KeyValueStore
+}
+
+func (s failingPutStore) Put(key []byte, value []byte) error {
+	return errors.New("disk failure")
+}
+
+// Tests that a batch interrupted while being written across the shards is
+// completed when the database is reopened.
+func TestShardedBatchRecovery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mandb-sharded-recovery-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var shards []mandb.KeyValueStore
+	for i := 0; i < 3; i++ {
+		shard, err := mandb.Open(mandb.DefaultEngine, filepath.Join(dir, fmt.Sprintf("shard%d", i)), 0, 0)
+		if err != nil {
+			t.Fatalf("failed to open shard %d: %v", i, err)
+		}
+		defer shard.Close()
+		shards = append(shards, shard)
+	}
+	// Fail writing the last shard, leaving the batch partially applied
+	broken, err := mandb.NewShardedDatabase([]mandb.KeyValueStore{shards[0], shards[1], failingStore{shards[2]}})
+	if err != nil {
+		t.Fatalf("failed to create sharded database: %v", err)
+	}
+	batch := broken.NewBatch()
+	for i := 0; i < 100; i++ {
+		batch.Put([]byte(fmt.Sprintf("key-%03d", i)), []byte{byte(i)})
+	}
+	if err := batch.Write(); err == nil {
+		t.Fatalf("batch written to a failing shard")
+	}
+	it := broken.Iterate(nil)
+	for it.Next() {
+		if !bytes.HasPrefix(it.Key(), []byte("key-")) {
+			t.Errorf("batch records exposed by iteration: %q", it.Key())
+		}
+	}
+	it.Release()
+
+	// Reopening the database completes the batch
+	db, err := mandb.NewShardedDatabase(shards)
+	if err != nil {
+		t.Fatalf("failed to recover sharded database: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		if value, err := db.Get([]byte(fmt.Sprintf("key-%03d", i))); err != nil || !bytes.Equal(value, []byte{byte(i)}) {
+			t.Fatalf("entry %d mismatch after recovery: have %x (%v), want %x", i, value, err, []byte{byte(i)})
+		}
+	}
+	var entries int
+	for it = db.Iterate(nil); it.Next(); entries++ {
+	}
+	it.Release()
+	if entries != 100 {
+		t.Errorf("entry count mismatch after recovery: have %d, want 100", entries)
+	}
+	// Batches confined to a single shard bypass the prepare records
+	batch = db.NewBatch()
+	batch.Put([]byte("single"), []byte{1})
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write single shard batch: %v", err)
+	}
+	if ok, _ := db.Has([]byte("single")); !ok {
+		t.Errorf("single shard batch not written")
+	}
+}
+
+// Tests that a batch interrupted before being committed across the shards is
+// discarded when the database is reopened.
+func TestShardedBatchUncommitted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mandb-sharded-uncommitted-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var shards []mandb.KeyValueStore
+	for i := 0; i < 3; i++ {
+		shard, err := mandb.Open(mandb.DefaultEngine, filepath.Join(dir, fmt.Sprintf("shard%d", i)), 0, 0)
+		if err != nil {
+			t.Fatalf("failed to open shard %d: %v", i, err)
+		}
+		defer shard.Close()
+		shards = append(shards, shard)
+	}
+	// Fail preparing the last shard, leaving the batch uncommitted
+	broken, err := mandb.NewShardedDatabase([]mandb.KeyValueStore{shards[0], shards[1], failingPutStore{shards[2]}})
+	if err != nil {
+		t.Fatalf("failed to create sharded database: %v", err)
+	}
+	batch := broken.NewBatch()
+	for i := 0; i < 100; i++ {
+		batch.Put([]byte(fmt.Sprintf("key-%03d", i)), []byte{byte(i)})
+	}
+	if err := batch.Write(); err == nil {
+		t.Fatalf("batch prepared in a failing shard")
+	}
+	// Reopening the database discards the batch along with its records
+	db, err := mandb.NewShardedDatabase(shards)
+	if err != nil {
+		t.Fatalf("failed to recover sharded database: %v", err)
+	}
+	for i, shard := range shards {
+		it := shard.Iterate(nil)
+		for it.Next() {
+			t.Errorf("shard %d: entry left after discarding the batch: %q", i, it.Key())
+		}
+		it.Release()
+	}
+	if ok, _ := db.Has([]byte("key-000")); ok {
+		t.Errorf("uncommitted batch applied")
+	}
+}
+
+// countingStore is a key-value store tracking the number of bytes written to
+// it, either directly or through batches.
+type countingStore struct {
+	mandb.KeyValueStore
+	written *int
+}
+
+func (s countingStore) Put(key []byte, value []byte) error {
+	*s.written += len(key) + len(value)
+	return s.KeyValueStore.Put(key, value)
+}
+
+func (s countingStore) NewBatch() mandb.Batch {
+	return countingBatch{s.KeyValueStore.NewBatch(), s.written}
+}
+
+type countingBatch struct {
+	mandb.Batch
+	written *int
+}
+
+func (b countingBatch) Put(key, value []byte) error {
+	*b.written += len(key) + len(value)
+	return b.Batch.Put(key, value)
+}
+
+// Benchmarks writing batches spanning all the shards, reporting the bytes
+// written to the first shard, which holds the commit markers, and the most
+// written other shard, which should be about the same.
+func BenchmarkShardedBatchWrite(b *testing.B) {
+	const shardCount = 4
+
+	dir, err := ioutil.TempDir("", "mandb-sharded-bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		shards  []mandb.KeyValueStore
+		written = make([]int, shardCount)
+	)
+	for i := 0; i < shardCount; i++ {
+		shard, err := mandb.Open(mandb.DefaultEngine, filepath.Join(dir, fmt.Sprintf("shard%d", i)), 0, 0)
+		if err != nil {
+			b.Fatalf("failed to open shard %d: %v", i, err)
+		}
+		defer shard.Close()
+		shards = append(shards, countingStore{shard, &written[i]})
+	}
+	db, err := mandb.NewShardedDatabase(shards)
+	if err != nil {
+		b.Fatalf("failed to create sharded database: %v", err)
+	}
+	value := make([]byte, 1024)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		batch := db.NewBatch()
+		for j := 0; j < 64; j++ {
+			batch.Put([]byte(fmt.Sprintf("key-%d-%d", i, j)), value)
+		}
+		if err := batch.Write(); err != nil {
+			b.Fatalf("failed to write batch: %v", err)
+		}
+	}
+	b.StopTimer()
+
+	var others int
+	for _, n := range written[1:] {
+		if n > others {
+			others = n
+		}
+	}
+	b.ReportMetric(float64(written[0])/float64(b.N), "shard0-B/op")
+	b.ReportMetric(float64(others)/float64(b.N), "max-other-shard-B/op")
+}
@@ -40,12 +40,17 @@ var Enabled bool = false
 const MetricsEnabledFlag = "metrics"
 const DashboardEnabledFlag = "dashboard"
 
+// MetricsAddrFlag is the CLI flag name to use to serve metrics to Prometheus,
+// which implies metrics collection.
+const MetricsAddrFlag = "metrics.addr"
+
 // Init enables or disables the metrics system. Since we need this to run before
 // any other code gets to create meters and timers, we'll actually do an ugly hack
 // and peek into the command line args for the metrics flag.
 func init() {
 	for _, arg := range os.Args {
-		if flag := strings.TrimLeft(arg, "-"); flag == MetricsEnabledFlag || flag == DashboardEnabledFlag {
+		flag := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)[0]
+		if flag == MetricsEnabledFlag || flag == DashboardEnabledFlag || flag == MetricsAddrFlag {
 			log.Info("Enabling metrics collection")
 			Enabled = true
 		}
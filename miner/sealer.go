@@ -0,0 +1,280 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package miner
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/consensus"
+	"github.com/matrix/go-matrix/core"
+	"github.com/matrix/go-matrix/core/state"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/core/vm"
+	"github.com/matrix/go-matrix/event"
+	"github.com/matrix/go-matrix/log"
+	"github.com/matrix/go-matrix/params"
+)
+
+// sealRetryInterval is the delay before retrying a failed seal on a chain
+// without a fixed block period.
+const sealRetryInterval = 3 * time.Second
+
+var (
+	errWaitTransactions = errors.New("waiting for transactions")
+	errSealAborted      = errors.New("sealing aborted")
+)
+
+// Sealer produces blocks for chains whose consensus engine authorizes blocks by
+// a local signature (e.g. clique), without going through the validator election
+// and block generation pipeline. With a zero block period a block is sealed as
+// soon as transactions are pending, otherwise one is sealed every period.
+type Sealer struct {
+	config *params.ChainConfig
+	chain  *core.BlockChain
+	engine consensus.Engine
+	txPool *core.TxPool
+	mux    *event.TypeMux
+	period uint64
+
+	extra   atomic.Value
+	running int32
+	quit    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewSealer creates a block sealer producing blocks every period seconds, or
+// whenever transactions arrive if period is zero.
+func NewSealer(chain *core.BlockChain, txPool *core.TxPool, mux *event.TypeMux, period uint64) *Sealer {
+	s := &Sealer{
+		config: chain.Config(),
+		chain:  chain,
+		engine: chain.Engine(),
+		txPool: txPool,
+		mux:    mux,
+		period: period,
+	}
+	s.extra.Store([]byte{})
+	return s
+}
+
+// Start begins sealing blocks on top of the current chain head.
+func (s *Sealer) Start() {
+	if !atomic.CompareAndSwapInt32(&s.running, 0, 1) {
+		return
+	}
+	s.quit = make(chan struct{})
+	s.wg.Add(1)
+	go s.loop(s.quit)
+
+	log.Info("Starting block sealing", "engine", s.config.EngineName(), "period", s.period)
+}
+
+// Stop aborts any running seal and waits for the sealing loop to terminate.
+func (s *Sealer) Stop() {
+	if !atomic.CompareAndSwapInt32(&s.running, 1, 0) {
+		return
+	}
+	close(s.quit)
+	s.wg.Wait()
+
+	log.Info("Block sealing stopped")
+}
+
+// Mining reports whether the sealer is running.
+func (s *Sealer) Mining() bool {
+	return atomic.LoadInt32(&s.running) == 1
+}
+
+// SetExtra sets the vanity data placed into the extra-data of sealed blocks.
+func (s *Sealer) SetExtra(extra []byte) {
+	s.extra.Store(common.CopyBytes(extra))
+}
+
+func (s *Sealer) loop(quit chan struct{}) {
+	defer s.wg.Done()
+
+	headCh := make(chan core.ChainHeadEvent, 10)
+	headSub := s.chain.SubscribeChainHeadEvent(headCh)
+	defer headSub.Unsubscribe()
+
+	txsCh := make(chan core.NewTxsEvent, 128)
+	txsSub := s.txPool.SubscribeNewTxsEvent(txsCh)
+	defer txsSub.Unsubscribe()
+
+	retry := time.NewTimer(0)
+	defer retry.Stop()
+
+	var (
+		stop chan struct{} // Closes to abort the running seal, nil if idle
+		done chan error    // Delivers the outcome of the running seal
+	)
+	commit := func() {
+		if stop != nil {
+			close(stop)
+		}
+		stop, done = make(chan struct{}), make(chan error, 1)
+		go func(stop chan struct{}, done chan error) {
+			done <- s.seal(stop)
+		}(stop, done)
+	}
+	for {
+		select {
+		case <-retry.C:
+			commit()
+
+		case <-headCh:
+			// The parent of the running seal is stale, restart on the new head
+			commit()
+
+		case <-txsCh:
+			if s.period == 0 && stop == nil {
+				commit()
+			}
+
+		case err := <-done:
+			stop, done = nil, nil
+			switch err {
+			case nil, errWaitTransactions, errSealAborted:
+			default:
+				log.Warn("Block sealing failed", "err", err)
+				delay := sealRetryInterval
+				if s.period > 0 {
+					delay = time.Duration(s.period) * time.Second
+				}
+				retry.Reset(delay)
+			}
+
+		case <-headSub.Err():
+			return
+		case <-quit:
+			if stop != nil {
+				close(stop)
+			}
+			return
+		}
+	}
+}
+
+// seal assembles a block from the pending transactions on top of the current
+// head, seals it with the local signer and writes it into the chain.
+func (s *Sealer) seal(stop <-chan struct{}) error {
+	parent := s.chain.CurrentBlock()
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   core.CalcGasLimit(parent),
+		Extra:      s.extra.Load().([]byte),
+		Time:       big.NewInt(time.Now().Unix()),
+	}
+	if err := s.engine.Prepare(s.chain, header); err != nil {
+		return err
+	}
+	statedb, err := s.chain.StateAt(parent.Root())
+	if err != nil {
+		return err
+	}
+	pending, err := s.txPool.Pending()
+	if err != nil {
+		return err
+	}
+	txs, receipts := s.applyTransactions(header, statedb, pending)
+	if s.period == 0 && len(txs) == 0 {
+		return errWaitTransactions
+	}
+	block, err := s.engine.Finalize(s.chain, header, statedb, txs, nil, receipts)
+	if err != nil {
+		return err
+	}
+	found := make(chan *consensus.FoundMsg, 1)
+	if err := s.engine.Seal(s.chain, block.Header(), stop, found, nil, false); err != nil {
+		return err
+	}
+	var msg *consensus.FoundMsg
+	select {
+	case msg = <-found:
+	default:
+		return errSealAborted
+	}
+	block = block.WithSeal(msg.Header)
+
+	// The block hash is only known once sealed, update the logs referencing it
+	hash := block.Hash()
+	for _, receipt := range receipts {
+		for _, l := range receipt.Logs {
+			l.BlockHash = hash
+		}
+	}
+	stat, err := s.chain.WriteBlockWithState(block, receipts, statedb)
+	if err != nil {
+		return err
+	}
+	log.Info("Successfully sealed new block", "number", block.Number(), "hash", hash, "txs", len(txs))
+
+	s.mux.Post(core.NewMinedBlockEvent{Block: block})
+	events := []interface{}{core.ChainEvent{Block: block, Hash: hash, Logs: statedb.Logs()}}
+	if stat == core.CanonStatTy {
+		events = append(events, core.ChainHeadEvent{Block: block})
+	}
+	s.chain.PostChainEvents(events, statedb.Logs())
+	return nil
+}
+
+// applyTransactions executes the pending transactions by price and nonce until
+// the block gas limit is reached, skipping the ones which fail.
+func (s *Sealer) applyTransactions(header *types.Header, statedb *state.StateDB, pending map[common.Address]types.Transactions) (types.Transactions, []*types.Receipt) {
+	var (
+		txs      types.Transactions
+		receipts []*types.Receipt
+		gp       = new(core.GasPool).AddGas(header.GasLimit)
+		set      = types.NewTransactionsByPriceAndNonce(types.MakeSigner(s.config, header.Number), pending)
+	)
+	for {
+		if gp.Gas() < params.TxGas {
+			break
+		}
+		tx := set.Peek()
+		if tx == nil {
+			break
+		}
+		statedb.Prepare(tx.Hash(), common.Hash{}, len(txs))
+
+		snap := statedb.Snapshot()
+		receipt, _, err := core.ApplyTransaction(s.config, s.chain, nil, gp, statedb, header, tx, &header.GasUsed, vm.Config{})
+		switch err {
+		case nil:
+			txs = append(txs, tx)
+			receipts = append(receipts, receipt)
+			set.Shift()
+
+		case core.ErrNonceTooLow:
+			// Stale transaction, the next one of the sender may still apply
+			statedb.RevertToSnapshot(snap)
+			set.Shift()
+
+		default:
+			// Gas limit reached, nonce gap or invalid transaction, skip the sender
+			log.Trace("Skipping transaction", "hash", tx.Hash(), "err", err)
+			statedb.RevertToSnapshot(snap)
+			set.Pop()
+		}
+	}
+	return txs, receipts
+}
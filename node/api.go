@@ -75,6 +75,12 @@ func (api *PrivateAdminAPI) RemovePeer(url string) (bool, error) {
 	return true, nil
 }
 
+// ReloadPeerConfig re-reads the static-nodes.json and trusted-nodes.json files
+// from the data directory and applies any changes without restarting the node.
+func (api *PrivateAdminAPI) ReloadPeerConfig() (*PeerConfigUpdate, error) {
+	return api.node.ReloadPeerConfig()
+}
+
 // PeerEvents creates an RPC subscription which receives peer events from the
 // node's p2p.Server
 func (api *PrivateAdminAPI) PeerEvents(ctx context.Context) (*rpc.Subscription, error) {
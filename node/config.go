@@ -26,13 +26,16 @@ import (
 	"strings"
 
 	"github.com/matrix/go-matrix/accounts"
+	"github.com/matrix/go-matrix/accounts/external"
 	"github.com/matrix/go-matrix/accounts/keystore"
 	"github.com/matrix/go-matrix/accounts/usbwallet"
 	"github.com/matrix/go-matrix/common"
 	"github.com/matrix/go-matrix/crypto"
 	"github.com/matrix/go-matrix/log"
+	"github.com/matrix/go-matrix/mandb"
 	"github.com/matrix/go-matrix/p2p"
 	"github.com/matrix/go-matrix/p2p/discover"
+	"github.com/matrix/go-matrix/rpc"
 )
 
 const (
@@ -66,6 +69,17 @@ type Config struct {
 	// in memory.
 	DataDir string
 
+	// DatabaseEngine is the storage engine backing the databases in the data
+	// directory. It defaults to LevelDB; see mandb.Engines for the alternatives.
+	DatabaseEngine string `toml:",omitempty"`
+
+	// DatabaseShards lists the directories, typically on separate disks, the
+	// databases of the node are split across. Every database gets a folder of
+	// its name in each of them. Relative directories are resolved against the
+	// instance directory. The list and its order must not change once the
+	// databases have been created.
+	DatabaseShards []string `toml:",omitempty"`
+
 	// Configuration of peer-to-peer networking.
 	P2P p2p.Config
 
@@ -82,9 +96,23 @@ type Config struct {
 	// scrypt KDF at the expense of security.
 	UseLightweightKDF bool `toml:",omitempty"`
 
+	// KeyStoreKDF selects the key derivation function used to encrypt new key
+	// files, either "scrypt" (default) or "argon2id".
+	KeyStoreKDF string `toml:",omitempty"`
+
+	// ScryptN and ScryptP override the scrypt parameters of the key store. Zero
+	// values use the standard (or lightweight) defaults.
+	ScryptN int `toml:",omitempty"`
+	ScryptP int `toml:",omitempty"`
+
 	// NoUSB disables hardware wallet monitoring and connectivity.
 	NoUSB bool `toml:",omitempty"`
 
+	// ExternalSigner is the endpoint of an external signer (e.g. clef) all
+	// signing is delegated to. If set, no local key store or USB wallets are
+	// used.
+	ExternalSigner string `toml:",omitempty"`
+
 	// IPCPath is the requested location to place the IPC endpoint. If the path is
 	// a simple file name, it is placed inside the data directory (or on the root
 	// pipe path on Windows), whereas if it's a resolvable path name (absolute or
@@ -145,6 +173,36 @@ type Config struct {
 	// private APIs to untrusted users is a major security risk.
 	WSExposeAll bool `toml:",omitempty"`
 
+	// RPCRateLimits are the per method quotas enforced on every client IP of the
+	// HTTP and websocket RPC interfaces, keyed by method name. The method "*"
+	// applies to all methods without a quota of their own.
+	RPCRateLimits map[string]rpc.RateLimit `toml:",omitempty"`
+
+	// GraphQLHost is the host interface on which to start the GraphQL server. If this
+	// field is empty, no GraphQL endpoint will be started.
+	GraphQLHost string `toml:",omitempty"`
+
+	// GraphQLPort is the TCP port number on which to start the GraphQL server. The
+	// default zero value is valid and will pick a port number randomly.
+	GraphQLPort int `toml:",omitempty"`
+
+	// GraphQLCors is the Cross-Origin Resource Sharing header to send to requesting
+	// clients. Please be aware that CORS is a browser enforced security, it's fully
+	// useless for custom HTTP clients.
+	GraphQLCors []string `toml:",omitempty"`
+
+	// GraphQLVirtualHosts is the list of virtual hostnames which are allowed on incoming requests.
+	// This is by default {'localhost'}.
+	GraphQLVirtualHosts []string `toml:",omitempty"`
+
+	// GRPCHost is the host interface on which to start the gRPC server. If this
+	// field is empty, no gRPC endpoint will be started.
+	GRPCHost string `toml:",omitempty"`
+
+	// GRPCPort is the TCP port number on which to start the gRPC server. The
+	// default zero value is valid and will pick a port number randomly.
+	GRPCPort int `toml:",omitempty"`
+
 	// Logger is a custom logger to use with the p2p.Server.
 	Logger log.Logger `toml:",omitempty"`
 }
@@ -224,6 +282,24 @@ func DefaultWSEndpoint() string {
 	return config.WSEndpoint()
 }
 
+// GraphQLEndpoint resolves a GraphQL endpoint based on the configured host
+// interface and port parameters.
+func (c *Config) GraphQLEndpoint() string {
+	if c.GraphQLHost == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", c.GraphQLHost, c.GraphQLPort)
+}
+
+// GRPCEndpoint resolves a gRPC endpoint based on the configured host interface
+// and port parameters.
+func (c *Config) GRPCEndpoint() string {
+	if c.GRPCHost == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", c.GRPCHost, c.GRPCPort)
+}
+
 // NodeName returns the devp2p node identifier.
 func (c *Config) NodeName() string {
 	name := c.name()
@@ -285,6 +361,32 @@ func (c *Config) resolvePath(path string) string {
 	return filepath.Join(c.instanceDir(), path)
 }
 
+// ShardPaths returns the directories the named database is split across, or
+// nil if the databases are not sharded.
+func (c *Config) ShardPaths(name string) []string {
+	if len(c.DatabaseShards) == 0 {
+		return nil
+	}
+	paths := make([]string, len(c.DatabaseShards))
+	for i, dir := range c.DatabaseShards {
+		paths[i] = c.resolvePath(filepath.Join(dir, name))
+	}
+	return paths
+}
+
+// openKeyValueStore opens the named database from within the instance
+// directory, or split across the shard directories if configured.
+func (c *Config) openKeyValueStore(name string, cache, handles int) (mandb.KeyValueStore, error) {
+	if paths := c.ShardPaths(name); paths != nil {
+		db, err := mandb.OpenSharded(c.DatabaseEngine, paths, cache, handles)
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	}
+	return mandb.Open(c.DatabaseEngine, c.resolvePath(name), cache, handles)
+}
+
 func (c *Config) instanceDir() string {
 	if c.DataDir == "" {
 		return ""
@@ -343,33 +445,48 @@ func (c *Config) TrustedNodes() []*discover.Node {
 // parsePersistentNodes parses a list of discovery node URLs loaded from a .json
 // file from within the data directory.
 func (c *Config) parsePersistentNodes(path string) []*discover.Node {
+	nodes, err := c.loadPersistentNodes(path)
+	if err != nil {
+		log.Error(fmt.Sprintf("Can't load node file %s: %v", path, err))
+	}
+	return nodes
+}
+
+// loadPersistentNodes loads a list of discovery node URLs from a .json file
+// from within the data directory. A missing file yields an empty list. Malformed
+// URLs are skipped, the first of them being reported alongside the valid nodes.
+func (c *Config) loadPersistentNodes(path string) ([]*discover.Node, error) {
 	// Short circuit if no node config is present
 	if c.DataDir == "" {
-		return nil
+		return nil, nil
 	}
 	if _, err := os.Stat(path); err != nil {
-		return nil
+		return nil, nil
 	}
 	// Load the nodes from the config file.
 	var nodelist []string
 	if err := common.LoadJSON(path, &nodelist); err != nil {
-		log.Error(fmt.Sprintf("Can't load node file %s: %v", path, err))
-		return nil
+		return nil, err
 	}
 	// Interpret the list as a discovery node array
-	var nodes []*discover.Node
+	var (
+		nodes []*discover.Node
+		fail  error
+	)
 	for _, url := range nodelist {
 		if url == "" {
 			continue
 		}
 		node, err := discover.ParseNode(url)
 		if err != nil {
-			log.Error(fmt.Sprintf("Node URL %s: %v\n", url, err))
+			if fail == nil {
+				fail = fmt.Errorf("node URL %s: %v", url, err)
+			}
 			continue
 		}
 		nodes = append(nodes, node)
 	}
-	return nodes
+	return nodes, fail
 }
 
 // AccountConfig determines the settings for scrypt and keydirectory
@@ -380,6 +497,12 @@ func (c *Config) AccountConfig() (int, int, string, error) {
 		scryptN = keystore.LightScryptN
 		scryptP = keystore.LightScryptP
 	}
+	if c.ScryptN != 0 {
+		scryptN = c.ScryptN
+	}
+	if c.ScryptP != 0 {
+		scryptP = c.ScryptP
+	}
 
 	var (
 		keydir string
@@ -400,8 +523,37 @@ func (c *Config) AccountConfig() (int, int, string, error) {
 	return scryptN, scryptP, keydir, err
 }
 
+// KDFConfig determines the key derivation function used to encrypt key files.
+func (c *Config) KDFConfig() (keystore.KDFConfig, error) {
+	switch c.KeyStoreKDF {
+	case "", keystore.KDFScrypt:
+		scryptN, scryptP, _, err := c.AccountConfig()
+		return keystore.ScryptKDF(scryptN, scryptP), err
+	case keystore.KDFArgon2id:
+		if c.UseLightweightKDF {
+			return keystore.Argon2idKDF(keystore.LightArgon2Time, keystore.LightArgon2Memory, keystore.LightArgon2Threads), nil
+		}
+		return keystore.Argon2idKDF(keystore.StandardArgon2Time, keystore.StandardArgon2Memory, keystore.StandardArgon2Threads), nil
+	}
+	return keystore.KDFConfig{}, fmt.Errorf("unknown key derivation function %q", c.KeyStoreKDF)
+}
+
 func makeAccountManager(conf *Config) (*accounts.Manager, string, error) {
-	scryptN, scryptP, keydir, err := conf.AccountConfig()
+	// Local keys and an external signer are mutually exclusive, as the same
+	// accounts showing up in both would be confusing and racey
+	if conf.ExternalSigner != "" {
+		log.Info("Using external signer", "url", conf.ExternalSigner)
+		extapi, err := external.NewExternalBackend(conf.ExternalSigner)
+		if err != nil {
+			return nil, "", fmt.Errorf("error connecting to external signer: %v", err)
+		}
+		return accounts.NewManager(extapi), "", nil
+	}
+	kdf, err := conf.KDFConfig()
+	if err != nil {
+		return nil, "", err
+	}
+	_, _, keydir, err := conf.AccountConfig()
 	var ephemeral string
 	if keydir == "" {
 		// There is no datadir.
@@ -417,7 +569,7 @@ func makeAccountManager(conf *Config) (*accounts.Manager, string, error) {
 	}
 	// Assemble the account manager and supported backends
 	backends := []accounts.Backend{
-		keystore.NewKeyStore(keydir, scryptN, scryptP),
+		keystore.NewKeyStoreWithKDF(keydir, kdf),
 	}
 	if !conf.NoUSB {
 		// Start a USB hub for Ledger hardware wallets
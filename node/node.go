@@ -31,14 +31,17 @@ import (
 	"github.com/matrix/go-matrix/accounts"
 	"github.com/matrix/go-matrix/accounts/signhelper"
 	"github.com/matrix/go-matrix/ca"
-	"github.com/matrix/go-matrix/mandb"
+	"github.com/matrix/go-matrix/core/rawdb"
 	"github.com/matrix/go-matrix/event"
+	"github.com/matrix/go-matrix/grpcapi"
 	"github.com/matrix/go-matrix/hd"
 	"github.com/matrix/go-matrix/internal/debug"
 	"github.com/matrix/go-matrix/log"
+	"github.com/matrix/go-matrix/mandb"
 	"github.com/matrix/go-matrix/p2p"
 	"github.com/matrix/go-matrix/rpc"
 	"github.com/prometheus/prometheus/util/flock"
+	"google.golang.org/grpc"
 )
 
 // Node is a container on which services can be registered.
@@ -52,6 +55,7 @@ type Node struct {
 
 	serverConfig p2p.Config
 	server       *p2p.Server // Currently running P2P networking layer
+	peerConfig   *peerConfig // Tracker of the static and trusted node files
 
 	serviceFuncs []ServiceConstructor     // Service constructors (in dependency order)
 	services     map[reflect.Type]Service // Currently running services
@@ -72,6 +76,13 @@ type Node struct {
 	wsListener net.Listener // Websocket RPC listener socket to server API requests
 	wsHandler  *rpc.Server  // Websocket RPC request handler to process the API requests
 
+	grpcEndpoint string          // gRPC endpoint (interface + port) to listen at (empty = gRPC disabled)
+	grpcListener net.Listener    // gRPC listener socket to serve API requests
+	grpcServer   *grpc.Server    // gRPC server handling the connections
+	grpcAPI      *grpcapi.Server // gRPC services forwarding the requests to the in-process handler
+
+	rpcLimiter *rpc.RateLimiter // Rate limiter shared by the HTTP and websocket endpoints (nil = unlimited)
+
 	MsgCenter  *mc.Center
 	hd         *hd.HD
 	signHelper *signhelper.SignHelper
@@ -130,6 +141,8 @@ func New(conf *Config) (*Node, error) {
 		ipcEndpoint:       conf.IPCEndpoint(),
 		httpEndpoint:      conf.HTTPEndpoint(),
 		wsEndpoint:        conf.WSEndpoint(),
+		grpcEndpoint:      conf.GRPCEndpoint(),
+		rpcLimiter:        newRPCLimiter(conf.RPCRateLimits),
 		eventmux:          new(event.TypeMux),
 		log:               conf.Logger,
 		hd:                hd,
@@ -245,6 +258,10 @@ func (n *Node) Start() error {
 	// start ca
 	go ca.Start(running.Self().ID, n.config.DataDir)
 
+	// Keep the static and trusted peers in sync with their files
+	n.peerConfig = newPeerConfig(n.config, running, n.log)
+	n.peerConfig.start()
+
 	// Finish initializing the startup
 	n.services = services
 	n.server = running
@@ -301,6 +318,13 @@ func (n *Node) startRPC(services map[reflect.Type]Service) error {
 		n.stopInProc()
 		return err
 	}
+	if err := n.startGRPC(n.grpcEndpoint); err != nil {
+		n.stopWS()
+		n.stopHTTP()
+		n.stopIPC()
+		n.stopInProc()
+		return err
+	}
 	// All API endpoints started successfully
 	n.rpcAPIs = apis
 	return nil
@@ -363,7 +387,7 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 	if endpoint == "" {
 		return nil
 	}
-	listener, handler, err := rpc.StartHTTPEndpoint(endpoint, apis, modules, cors, vhosts)
+	listener, handler, err := rpc.StartHTTPEndpoint(endpoint, apis, modules, cors, vhosts, n.rpcLimiter)
 	if err != nil {
 		return err
 	}
@@ -396,7 +420,7 @@ func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrig
 	if endpoint == "" {
 		return nil
 	}
-	listener, handler, err := rpc.StartWSEndpoint(endpoint, apis, modules, wsOrigins, exposeAll)
+	listener, handler, err := rpc.StartWSEndpoint(endpoint, apis, modules, wsOrigins, exposeAll, n.rpcLimiter)
 	if err != nil {
 		return err
 	}
@@ -409,6 +433,15 @@ func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrig
 	return nil
 }
 
+// newRPCLimiter creates the rate limiter of the remote RPC endpoints, or nil if
+// no quotas are configured.
+func newRPCLimiter(limits map[string]rpc.RateLimit) *rpc.RateLimiter {
+	if len(limits) == 0 {
+		return nil
+	}
+	return rpc.NewRateLimiter(limits)
+}
+
 // stopWS terminates the websocket RPC endpoint.
 func (n *Node) stopWS() {
 	if n.wsListener != nil {
@@ -423,6 +456,47 @@ func (n *Node) stopWS() {
 	}
 }
 
+// startGRPC initializes and starts the gRPC endpoint, serving the man and net
+// APIs of the in-process handler.
+func (n *Node) startGRPC(endpoint string) error {
+	// Short circuit if the gRPC endpoint isn't being exposed
+	if endpoint == "" {
+		return nil
+	}
+	listener, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		return err
+	}
+	api := grpcapi.NewServer(n.inprocHandler)
+	server := grpc.NewServer()
+	api.Register(server)
+	go server.Serve(listener)
+
+	n.log.Info("gRPC endpoint opened", "url", listener.Addr())
+	// All listeners booted successfully
+	n.grpcEndpoint = endpoint
+	n.grpcListener = listener
+	n.grpcServer = server
+	n.grpcAPI = api
+
+	return nil
+}
+
+// stopGRPC terminates the gRPC endpoint.
+func (n *Node) stopGRPC() {
+	if n.grpcServer != nil {
+		n.grpcServer.Stop()
+		n.grpcServer = nil
+		n.grpcListener = nil
+
+		n.log.Info("gRPC endpoint closed", "url", n.grpcEndpoint)
+	}
+	if n.grpcAPI != nil {
+		n.grpcAPI.Close()
+		n.grpcAPI = nil
+	}
+}
+
 // Stop terminates a running node along with all it's services. In the node was
 // not started, an error is returned.
 func (n *Node) Stop() error {
@@ -435,10 +509,13 @@ func (n *Node) Stop() error {
 	}
 
 	// Terminate the API, services and the p2p server.
+	n.stopGRPC()
 	n.stopWS()
 	n.stopHTTP()
 	n.stopIPC()
 	n.rpcAPIs = nil
+	n.peerConfig.stop()
+	n.peerConfig = nil
 	failure := &StopError{
 		Services: make(map[reflect.Type]error),
 	}
@@ -537,6 +614,19 @@ func (n *Node) Server() *p2p.Server {
 	return n.server
 }
 
+// ReloadPeerConfig re-reads the static and trusted node lists from the data
+// directory and applies any changes to the running p2p server, connecting to
+// new static peers and dropping removed ones.
+func (n *Node) ReloadPeerConfig() (*PeerConfigUpdate, error) {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	if n.server == nil {
+		return nil, ErrNodeStopped
+	}
+	return n.peerConfig.reload()
+}
+
 // Service retrieves a currently running service registered of a specific type.
 func (n *Node) Service(service interface{}) error {
 	n.lock.RLock()
@@ -589,6 +679,11 @@ func (n *Node) WSEndpoint() string {
 	return n.wsEndpoint
 }
 
+// GRPCEndpoint retrieves the current gRPC endpoint used by the protocol stack.
+func (n *Node) GRPCEndpoint() string {
+	return n.grpcEndpoint
+}
+
 // EventMux retrieves the event multiplexer used by all the network services in
 // the current protocol stack.
 func (n *Node) EventMux() *event.TypeMux {
@@ -602,7 +697,40 @@ func (n *Node) OpenDatabase(name string, cache, handles int) (mandb.Database, er
 	if n.config.DataDir == "" {
 		return mandb.NewMemDatabase(), nil
 	}
-	return mandb.NewLDBDatabase(n.config.resolvePath(name), cache, handles)
+	return n.config.openKeyValueStore(name, cache, handles)
+}
+
+// OpenDatabaseWithFreezer opens an existing database with the given name (or
+// creates one if no previous can be found) from within the node's instance
+// directory, backed by an ancient store for the immutable part of the chain.
+// If the node is ephemeral, a memory database is returned.
+func (n *Node) OpenDatabaseWithFreezer(name string, cache, handles int, freezer string) (mandb.Database, error) {
+	if n.config.DataDir == "" {
+		return mandb.NewMemDatabase(), nil
+	}
+	return openDatabaseWithFreezer(n.config, name, cache, handles, freezer)
+}
+
+// openDatabaseWithFreezer opens the named key-value database and wraps it with
+// its ancient store.
+func openDatabaseWithFreezer(config *Config, name string, cache, handles int, freezer string) (mandb.Database, error) {
+	root := config.resolvePath(name)
+	switch {
+	case freezer == "":
+		freezer = filepath.Join(root, "ancient")
+	case !filepath.IsAbs(freezer):
+		freezer = config.resolvePath(freezer)
+	}
+	kvdb, err := config.openKeyValueStore(name, cache, handles)
+	if err != nil {
+		return nil, err
+	}
+	db, err := rawdb.NewDatabaseWithFreezer(kvdb, freezer)
+	if err != nil {
+		kvdb.Close()
+		return nil, err
+	}
+	return db, nil
 }
 
 // ResolvePath returns the absolute path of a resource in the instance directory.
@@ -0,0 +1,213 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package node
+
+import (
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/matrix/go-matrix/log"
+	"github.com/matrix/go-matrix/p2p"
+	"github.com/matrix/go-matrix/p2p/discover"
+)
+
+// peerConfigPollInterval is the interval at which the static and trusted node
+// files are checked for modifications.
+const peerConfigPollInterval = 3 * time.Second
+
+// PeerConfigUpdate reports the peer list changes applied after the static and
+// trusted node files have been reloaded.
+type PeerConfigUpdate struct {
+	StaticAdded    []string `json:"staticAdded"`
+	StaticRemoved  []string `json:"staticRemoved"`
+	TrustedAdded   []string `json:"trustedAdded"`
+	TrustedRemoved []string `json:"trustedRemoved"`
+}
+
+// empty returns whether the reload did not change anything.
+func (u *PeerConfigUpdate) empty() bool {
+	return len(u.StaticAdded)+len(u.StaticRemoved)+len(u.TrustedAdded)+len(u.TrustedRemoved) == 0
+}
+
+// fileStamp is the modification fingerprint of a watched file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// peerConfig tracks the static and trusted node lists loaded from the data
+// directory and applies any later changes to them to the running p2p server,
+// either on request or when the files are modified on disk.
+type peerConfig struct {
+	config *Config
+	server *p2p.Server
+
+	static  map[discover.NodeID]*discover.Node // Static nodes currently loaded from file
+	trusted map[discover.NodeID]*discover.Node // Trusted nodes currently loaded from file
+	stamps  map[string]fileStamp               // Last seen fingerprints of the node files
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+	lock sync.Mutex
+	log  log.Logger
+}
+
+// newPeerConfig creates a tracker for the node files of the given config, using
+// their current contents as the baseline that later reloads are diffed against.
+func newPeerConfig(config *Config, server *p2p.Server, logger log.Logger) *peerConfig {
+	pc := &peerConfig{
+		config:  config,
+		server:  server,
+		static:  nodeSet(config.StaticNodes()),
+		trusted: nodeSet(config.TrustedNodes()),
+		stamps:  make(map[string]fileStamp),
+		quit:    make(chan struct{}),
+		log:     logger,
+	}
+	pc.changed()
+	return pc
+}
+
+// start launches the background watcher of the node files. Nothing is watched
+// if the node is running without a data directory.
+func (pc *peerConfig) start() {
+	if pc.config.DataDir == "" {
+		return
+	}
+	pc.wg.Add(1)
+	go pc.loop()
+}
+
+// stop terminates the background watcher and waits for it to exit.
+func (pc *peerConfig) stop() {
+	close(pc.quit)
+	pc.wg.Wait()
+}
+
+// loop periodically checks the node files for modifications, reloading them
+// whenever any changed.
+func (pc *peerConfig) loop() {
+	defer pc.wg.Done()
+
+	ticker := time.NewTicker(peerConfigPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !pc.changed() {
+				continue
+			}
+			update, err := pc.reload()
+			if err != nil {
+				pc.log.Warn("Failed to reload peer config", "err", err)
+				continue
+			}
+			if !update.empty() {
+				pc.log.Info("Reloaded peer config", "static+", len(update.StaticAdded), "static-", len(update.StaticRemoved),
+					"trusted+", len(update.TrustedAdded), "trusted-", len(update.TrustedRemoved))
+			}
+		case <-pc.quit:
+			return
+		}
+	}
+}
+
+// changed reports whether any of the node files were created, modified or
+// deleted since the last check.
+func (pc *peerConfig) changed() bool {
+	changed := false
+	for _, name := range []string{datadirStaticNodes, datadirTrustedNodes} {
+		var stamp fileStamp
+		if info, err := os.Stat(pc.config.resolvePath(name)); err == nil {
+			stamp = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+		if pc.stamps[name] != stamp {
+			pc.stamps[name] = stamp
+			changed = true
+		}
+	}
+	return changed
+}
+
+// reload re-reads the static and trusted node files and applies the differences
+// to the p2p server. If either file is malformed, nothing is changed.
+func (pc *peerConfig) reload() (*PeerConfigUpdate, error) {
+	pc.lock.Lock()
+	defer pc.lock.Unlock()
+
+	static, err := pc.config.loadPersistentNodes(pc.config.resolvePath(datadirStaticNodes))
+	if err != nil {
+		return nil, err
+	}
+	trusted, err := pc.config.loadPersistentNodes(pc.config.resolvePath(datadirTrustedNodes))
+	if err != nil {
+		return nil, err
+	}
+	update := new(PeerConfigUpdate)
+
+	added, removed := diffNodes(pc.static, nodeSet(static))
+	for _, node := range removed {
+		pc.server.RemovePeer(node)
+		update.StaticRemoved = append(update.StaticRemoved, node.String())
+	}
+	for _, node := range added {
+		pc.server.AddPeer(node)
+		update.StaticAdded = append(update.StaticAdded, node.String())
+	}
+	added, removed = diffNodes(pc.trusted, nodeSet(trusted))
+	for _, node := range removed {
+		pc.server.RemoveTrustedPeer(node)
+		update.TrustedRemoved = append(update.TrustedRemoved, node.String())
+	}
+	for _, node := range added {
+		pc.server.AddTrustedPeer(node)
+		update.TrustedAdded = append(update.TrustedAdded, node.String())
+	}
+	pc.static, pc.trusted = nodeSet(static), nodeSet(trusted)
+
+	return update, nil
+}
+
+// nodeSet indexes a list of nodes by their identifiers.
+func nodeSet(nodes []*discover.Node) map[discover.NodeID]*discover.Node {
+	set := make(map[discover.NodeID]*discover.Node, len(nodes))
+	for _, node := range nodes {
+		set[node.ID] = node
+	}
+	return set
+}
+
+// diffNodes returns the nodes present only in next (added) and only in prev
+// (removed), each sorted by their URL. A node whose endpoint changed counts as
+// both removed and added, so that the dialer picks up the new address.
+func diffNodes(prev, next map[discover.NodeID]*discover.Node) (added, removed []*discover.Node) {
+	for id, node := range next {
+		if old, ok := prev[id]; !ok || old.String() != node.String() {
+			added = append(added, node)
+		}
+	}
+	for id, node := range prev {
+		if cur, ok := next[id]; !ok || cur.String() != node.String() {
+			removed = append(removed, node)
+		}
+	}
+	sort.Slice(added, func(i, j int) bool { return added[i].String() < added[j].String() })
+	sort.Slice(removed, func(i, j int) bool { return removed[i].String() < removed[j].String() })
+	return added, removed
+}
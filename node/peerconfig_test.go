@@ -0,0 +1,126 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package node
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/matrix/go-matrix/crypto"
+	"github.com/matrix/go-matrix/log"
+	"github.com/matrix/go-matrix/p2p"
+	"github.com/matrix/go-matrix/p2p/discover"
+)
+
+// newTestEnode creates a random enode URL listening on the given port.
+func newTestEnode(t *testing.T, port uint16) string {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return discover.NewNode(discover.PubkeyID(&key.PublicKey), net.ParseIP("127.0.0.1"), port, port).String()
+}
+
+// writeNodeList writes a JSON list of enode URLs into the data directory.
+func writeNodeList(t *testing.T, dir, name string, urls ...string) {
+	blob := "[\"" + strings.Join(urls, "\",\"") + "\"]"
+	if len(urls) == 0 {
+		blob = "[]"
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(blob), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+}
+
+// Tests that reloading the static and trusted node files applies exactly the
+// differences to the running p2p server, and that malformed files are rejected
+// without touching the current peer config.
+func TestPeerConfigReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary data directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := &Config{DataDir: dir}
+	// Config.resolvePath nests non-legacy resources under the instance dir
+	instdir := filepath.Dir(config.resolvePath(datadirStaticNodes))
+	if err := os.MkdirAll(instdir, 0700); err != nil {
+		t.Fatalf("failed to create instance directory: %v", err)
+	}
+	var (
+		staticA, staticB   = newTestEnode(t, 30301), newTestEnode(t, 30302)
+		trustedA, trustedB = newTestEnode(t, 30303), newTestEnode(t, 30304)
+	)
+	writeNodeList(t, instdir, datadirStaticNodes, staticA)
+	writeNodeList(t, instdir, datadirTrustedNodes, trustedA)
+
+	key, _ := crypto.GenerateKey()
+	server := &p2p.Server{Config: p2p.Config{PrivateKey: key, MaxPeers: 10, NoDial: true}}
+	if err := server.Start(); err != nil {
+		t.Fatalf("failed to start p2p server: %v", err)
+	}
+	defer server.Stop()
+
+	pc := newPeerConfig(config, server, log.New())
+
+	// Swap the static node and extend the trusted set
+	writeNodeList(t, instdir, datadirStaticNodes, staticB)
+	writeNodeList(t, instdir, datadirTrustedNodes, trustedA, trustedB)
+
+	update, err := pc.reload()
+	if err != nil {
+		t.Fatalf("failed to reload peer config: %v", err)
+	}
+	want := &PeerConfigUpdate{
+		StaticAdded:   []string{staticB},
+		StaticRemoved: []string{staticA},
+		TrustedAdded:  []string{trustedB},
+	}
+	if !reflect.DeepEqual(update, want) {
+		t.Fatalf("update mismatch: have %+v, want %+v", update, want)
+	}
+	// A second reload without changes must be a noop
+	if update, err = pc.reload(); err != nil || !update.empty() {
+		t.Fatalf("unexpected changes on noop reload: %+v, %v", update, err)
+	}
+	// A malformed file must be rejected and leave the tracked sets alone
+	writeNodeList(t, instdir, datadirTrustedNodes, "enode://invalid")
+	if _, err := pc.reload(); err == nil {
+		t.Fatalf("malformed trusted node file accepted")
+	}
+	if len(pc.trusted) != 2 {
+		t.Fatalf("trusted set modified by failed reload: have %d nodes, want 2", len(pc.trusted))
+	}
+	// Deleting the files drops every peer they configured
+	os.Remove(filepath.Join(instdir, datadirStaticNodes))
+	os.Remove(filepath.Join(instdir, datadirTrustedNodes))
+
+	if !pc.changed() {
+		t.Fatalf("file removal not detected")
+	}
+	if update, err = pc.reload(); err != nil {
+		t.Fatalf("failed to reload peer config: %v", err)
+	}
+	if len(update.StaticRemoved) != 1 || len(update.TrustedRemoved) != 2 {
+		t.Fatalf("removal mismatch: %+v", update)
+	}
+}
@@ -25,9 +25,9 @@ import (
 
 	"github.com/matrix/go-matrix/accounts"
 	"github.com/matrix/go-matrix/accounts/signhelper"
-	"github.com/matrix/go-matrix/mandb"
 	"github.com/matrix/go-matrix/event"
 	"github.com/matrix/go-matrix/hd"
+	"github.com/matrix/go-matrix/mandb"
 	"github.com/matrix/go-matrix/p2p"
 	"github.com/matrix/go-matrix/rpc"
 )
@@ -57,13 +57,25 @@ func (ctx *ServiceContext) OpenDatabase(name string, cache int, handles int) (ma
 	if ctx.config.DataDir == "" {
 		return mandb.NewMemDatabase(), nil
 	}
-	db, err := mandb.NewLDBDatabase(ctx.config.resolvePath(name), cache, handles)
+	db, err := ctx.config.openKeyValueStore(name, cache, handles)
 	if err != nil {
 		return nil, err
 	}
 	return db, nil
 }
 
+// OpenDatabaseWithFreezer opens an existing database with the given name (or
+// creates one if no previous can be found) from within the node's data directory,
+// backed by an ancient store for the immutable part of the chain. The ancient
+// store lives in freezer, or in the "ancient" folder of the database if empty.
+// If the node is an ephemeral one, a memory database is returned.
+func (ctx *ServiceContext) OpenDatabaseWithFreezer(name string, cache int, handles int, freezer string) (mandb.Database, error) {
+	if ctx.config.DataDir == "" {
+		return mandb.NewMemDatabase(), nil
+	}
+	return openDatabaseWithFreezer(ctx.config, name, cache, handles, freezer)
+}
+
 // ResolvePath resolves a user path into the data directory if that was relative
 // and if the user actually uses persistent storage. It will return an empty string
 // for emphemeral storage and the user's own input for absolute paths.
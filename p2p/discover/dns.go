@@ -0,0 +1,402 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package discover
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/matrix/go-matrix/crypto"
+	"github.com/matrix/go-matrix/log"
+	"github.com/matrix/go-matrix/p2p/enr"
+	"github.com/matrix/go-matrix/rlp"
+)
+
+// This file implements a client for node lists published as DNS trees
+// (EIP-1459). A tree is a set of TXT records below a domain: the root record
+// at the domain itself is signed by the list operator and references the
+// hashes of two subtrees, one holding node records and one holding links to
+// other trees. Every other entry lives at <hash>.<domain>, so entries are
+// content addressed and only the root needs to be trusted.
+
+const (
+	dnsRootPrefix   = "enrtree-root:v1"
+	dnsBranchPrefix = "enrtree-branch:"
+	dnsLinkPrefix   = "enrtree://"
+	dnsENRPrefix    = "enr:"
+
+	dnsTimeout         = 20 * time.Second // Timeout of a single tree sync
+	dnsRecheckInterval = 30 * time.Minute // Time between two syncs of all trees
+	dnsMaxEntries      = 10000            // Maximum number of entries resolved per tree
+	dnsMaxTrees        = 16               // Maximum number of linked trees followed per sync
+)
+
+var (
+	b32format = base32.StdEncoding.WithPadding(base32.NoPadding)
+	b64format = base64.RawURLEncoding
+)
+
+var (
+	errDNSNoRoot         = errors.New("no enrtree root found")
+	errDNSInvalidSig     = errors.New("invalid enrtree root signature")
+	errDNSHashMismatch   = errors.New("enrtree entry does not match its hash")
+	errDNSUnknownEntry   = errors.New("unknown enrtree entry")
+	errDNSInvalidChild   = errors.New("invalid enrtree child hash")
+	errDNSTooManyEntries = errors.New("enrtree has too many entries")
+	errDNSLinkInENRTree  = errors.New("link found in node subtree")
+	errDNSENRInLinkTree  = errors.New("node record found in link subtree")
+)
+
+// DNSResolver looks up TXT records. It is implemented by *net.Resolver.
+type DNSResolver interface {
+	LookupTXT(ctx context.Context, domain string) ([]string, error)
+}
+
+// DNSClient retrieves node lists from EIP-1459 DNS trees.
+type DNSClient struct {
+	resolver DNSResolver
+
+	lock    sync.Mutex
+	entries map[string]dnsEntry // Verified entries by <hash>.<domain>, immutable
+}
+
+// NewDNSClient creates a DNS tree client. If resolver is nil, the system
+// resolver is used.
+func NewDNSClient(resolver DNSResolver) *DNSClient {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return &DNSClient{
+		resolver: resolver,
+		entries:  make(map[string]dnsEntry),
+	}
+}
+
+// SyncTree retrieves all nodes in the tree referenced by the given
+// enrtree:// URL, including the nodes of any linked trees.
+func (c *DNSClient) SyncTree(ctx context.Context, url string) ([]*Node, error) {
+	link, err := parseDNSLink(url)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		nodes   []*Node
+		queue   = []*dnsLinkEntry{link}
+		visited = map[string]bool{link.domain: true}
+	)
+	for len(queue) > 0 {
+		link, queue = queue[0], queue[1:]
+
+		root, err := c.resolveRoot(ctx, link)
+		if err != nil {
+			return nodes, fmt.Errorf("%s: %v", link.domain, err)
+		}
+		found, err := c.syncSubtree(ctx, link.domain, root.eroot, false)
+		if err != nil {
+			return nodes, fmt.Errorf("%s: %v", link.domain, err)
+		}
+		for _, e := range found {
+			nodes = append(nodes, e.(*Node))
+		}
+		links, err := c.syncSubtree(ctx, link.domain, root.lroot, true)
+		if err != nil {
+			return nodes, fmt.Errorf("%s: %v", link.domain, err)
+		}
+		for _, e := range links {
+			next := e.(*dnsLinkEntry)
+			if visited[next.domain] || len(visited) >= dnsMaxTrees {
+				continue
+			}
+			visited[next.domain] = true
+			queue = append(queue, next)
+		}
+	}
+	return nodes, nil
+}
+
+// resolveRoot retrieves the root entry of a tree and verifies its signature.
+func (c *DNSClient) resolveRoot(ctx context.Context, link *dnsLinkEntry) (*dnsRootEntry, error) {
+	txts, err := c.resolver.LookupTXT(ctx, link.domain)
+	if err != nil {
+		return nil, err
+	}
+	for _, txt := range txts {
+		if !strings.HasPrefix(txt, dnsRootPrefix) {
+			continue
+		}
+		root, err := parseDNSRoot(txt)
+		if err != nil {
+			return nil, err
+		}
+		if !root.verifySignature(link.pubkey) {
+			return nil, errDNSInvalidSig
+		}
+		return root, nil
+	}
+	return nil, errDNSNoRoot
+}
+
+// syncSubtree walks the subtree starting at hash and returns its leaves. These
+// are nodes, or links if links is set.
+func (c *DNSClient) syncSubtree(ctx context.Context, domain, hash string, links bool) ([]interface{}, error) {
+	var (
+		leaves  []interface{}
+		pending = []string{hash}
+		count   int
+	)
+	for len(pending) > 0 {
+		hash, pending = pending[len(pending)-1], pending[:len(pending)-1]
+		if count++; count > dnsMaxEntries {
+			return leaves, errDNSTooManyEntries
+		}
+		entry, err := c.resolveEntry(ctx, domain, hash)
+		if err != nil {
+			return leaves, err
+		}
+		switch e := entry.(type) {
+		case *dnsBranchEntry:
+			pending = append(pending, e.children...)
+		case *dnsLinkEntry:
+			if !links {
+				return leaves, errDNSLinkInENRTree
+			}
+			leaves = append(leaves, e)
+		case *Node:
+			if links {
+				return leaves, errDNSENRInLinkTree
+			}
+			leaves = append(leaves, e)
+		default:
+			return leaves, errDNSUnknownEntry
+		}
+	}
+	return leaves, nil
+}
+
+// resolveEntry retrieves the entry at <hash>.<domain>, verifying that its
+// content matches the hash.
+func (c *DNSClient) resolveEntry(ctx context.Context, domain, hash string) (dnsEntry, error) {
+	name := hash + "." + domain
+
+	c.lock.Lock()
+	entry, ok := c.entries[name]
+	c.lock.Unlock()
+	if ok {
+		return entry, nil
+	}
+	txts, err := c.resolver.LookupTXT(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, txt := range txts {
+		if dnsEntryHash(txt) != hash {
+			continue
+		}
+		entry, err := parseDNSEntry(txt)
+		if err != nil {
+			return nil, err
+		}
+		c.lock.Lock()
+		c.entries[name] = entry
+		c.lock.Unlock()
+		return entry, nil
+	}
+	return nil, errDNSHashMismatch
+}
+
+// dnsEntry is a parsed tree entry: *dnsBranchEntry, *dnsLinkEntry or *Node.
+type dnsEntry interface{}
+
+type dnsRootEntry struct {
+	eroot string
+	lroot string
+	seq   uint
+	sig   []byte
+}
+
+type dnsBranchEntry struct {
+	children []string
+}
+
+type dnsLinkEntry struct {
+	domain string
+	pubkey *ecdsa.PublicKey
+}
+
+// dnsEntryHash returns the name under which an entry is published.
+func dnsEntryHash(txt string) string {
+	return b32format.EncodeToString(crypto.Keccak256([]byte(txt))[:16])
+}
+
+func (e *dnsRootEntry) sigHash() []byte {
+	return crypto.Keccak256([]byte(fmt.Sprintf("%s e=%s l=%s seq=%d", dnsRootPrefix, e.eroot, e.lroot, e.seq)))
+}
+
+func (e *dnsRootEntry) verifySignature(pubkey *ecdsa.PublicKey) bool {
+	// The signature is in [R || S || V] format, V is not needed for verification
+	return len(e.sig) == 65 && crypto.VerifySignature(crypto.CompressPubkey(pubkey), e.sigHash(), e.sig[:64])
+}
+
+func parseDNSRoot(txt string) (*dnsRootEntry, error) {
+	var (
+		e   dnsRootEntry
+		sig string
+	)
+	if _, err := fmt.Sscanf(txt, dnsRootPrefix+" e=%s l=%s seq=%d sig=%s", &e.eroot, &e.lroot, &e.seq, &sig); err != nil {
+		return nil, fmt.Errorf("invalid enrtree root: %v", err)
+	}
+	if !isValidDNSHash(e.eroot) || !isValidDNSHash(e.lroot) {
+		return nil, errDNSInvalidChild
+	}
+	var err error
+	if e.sig, err = b64format.DecodeString(sig); err != nil {
+		return nil, fmt.Errorf("invalid enrtree root signature encoding: %v", err)
+	}
+	return &e, nil
+}
+
+func parseDNSEntry(txt string) (dnsEntry, error) {
+	switch {
+	case strings.HasPrefix(txt, dnsBranchPrefix):
+		return parseDNSBranch(txt[len(dnsBranchPrefix):])
+	case strings.HasPrefix(txt, dnsLinkPrefix):
+		return parseDNSLink(txt)
+	case strings.HasPrefix(txt, dnsENRPrefix):
+		return parseDNSENR(txt[len(dnsENRPrefix):])
+	default:
+		return nil, errDNSUnknownEntry
+	}
+}
+
+func parseDNSBranch(s string) (*dnsBranchEntry, error) {
+	e := new(dnsBranchEntry)
+	if s == "" {
+		return e, nil
+	}
+	e.children = strings.Split(s, ",")
+	for _, child := range e.children {
+		if !isValidDNSHash(child) {
+			return nil, errDNSInvalidChild
+		}
+	}
+	return e, nil
+}
+
+func parseDNSLink(url string) (*dnsLinkEntry, error) {
+	if !strings.HasPrefix(url, dnsLinkPrefix) {
+		return nil, fmt.Errorf("invalid enrtree URL %q: missing %s prefix", url, dnsLinkPrefix)
+	}
+	pos := strings.IndexByte(url, '@')
+	if pos == -1 {
+		return nil, fmt.Errorf("invalid enrtree URL %q: missing domain", url)
+	}
+	keystring, domain := url[len(dnsLinkPrefix):pos], url[pos+1:]
+	if domain == "" {
+		return nil, fmt.Errorf("invalid enrtree URL %q: missing domain", url)
+	}
+	keybytes, err := b32format.DecodeString(keystring)
+	if err != nil {
+		return nil, fmt.Errorf("invalid enrtree URL %q: bad public key encoding", url)
+	}
+	pubkey, err := crypto.DecompressPubkey(keybytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid enrtree URL %q: bad public key", url)
+	}
+	return &dnsLinkEntry{domain: domain, pubkey: pubkey}, nil
+}
+
+// parseDNSENR decodes a node record leaf into a node. Decoding the record
+// verifies its signature.
+func parseDNSENR(s string) (*Node, error) {
+	blob, err := b64format.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node record encoding: %v", err)
+	}
+	var record enr.Record
+	if err := rlp.DecodeBytes(blob, &record); err != nil {
+		return nil, fmt.Errorf("invalid node record: %v", err)
+	}
+	var (
+		pubkey enr.Secp256k1
+		ip     enr.IP
+		tcp    enr.TCP
+		udp    enr.UDP
+	)
+	if err := record.Load(&pubkey); err != nil {
+		return nil, fmt.Errorf("invalid node record: %v", err)
+	}
+	if err := record.Load(&ip); err != nil {
+		return nil, fmt.Errorf("invalid node record: %v", err)
+	}
+	if err := record.Load(&tcp); err != nil {
+		return nil, fmt.Errorf("invalid node record: %v", err)
+	}
+	if err := record.Load(&udp); err != nil {
+		udp = enr.UDP(tcp)
+	}
+	node := NewNode(PubkeyID((*ecdsa.PublicKey)(&pubkey)), net.IP(ip), uint16(udp), uint16(tcp))
+	if err := node.validateComplete(); err != nil {
+		return nil, fmt.Errorf("invalid node record: %v", err)
+	}
+	return node, nil
+}
+
+func isValidDNSHash(s string) bool {
+	blob, err := b32format.DecodeString(s)
+	return err == nil && len(blob) == 16
+}
+
+// dnsLoop periodically syncs the given DNS trees and hands the discovered
+// nodes to the table loop.
+func (tab *Table) dnsLoop(client *DNSClient, urls []string) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+		case <-tab.closed:
+			return
+		}
+		var nodes []*Node
+		for _, url := range urls {
+			ctx, cancel := context.WithTimeout(context.Background(), dnsTimeout)
+			found, err := client.SyncTree(ctx, url)
+			cancel()
+			if err != nil {
+				log.Warn("DNS discovery sync failed", "tree", url, "err", err)
+			}
+			log.Debug("Synced DNS discovery tree", "tree", url, "nodes", len(found))
+			nodes = append(nodes, found...)
+		}
+		if len(nodes) > 0 {
+			select {
+			case tab.dnsNodes <- nodes:
+			case <-tab.closed:
+				return
+			}
+		}
+		timer.Reset(dnsRecheckInterval)
+	}
+}
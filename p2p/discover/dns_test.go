@@ -0,0 +1,223 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package discover
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/matrix/go-matrix/crypto"
+	"github.com/matrix/go-matrix/p2p/enr"
+	"github.com/matrix/go-matrix/rlp"
+)
+
+// mapResolver is a DNS resolver serving TXT records from memory.
+type mapResolver map[string]string
+
+func (mr mapResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if txt, ok := mr[name]; ok {
+		return []string{txt}, nil
+	}
+	return nil, fmt.Errorf("no such host: %s", name)
+}
+
+// publish adds an entry to the resolver and returns its hash.
+func (mr mapResolver) publish(domain, txt string) string {
+	hash := dnsEntryHash(txt)
+	mr[hash+"."+domain] = txt
+	return hash
+}
+
+// addTree publishes a DNS tree holding the given nodes and links below domain,
+// signed with key, and returns its enrtree:// URL.
+func (mr mapResolver) addTree(key *ecdsa.PrivateKey, domain string, nodes []*ecdsa.PrivateKey, links []string) string {
+	var enrs, lnks []string
+	for i, nodekey := range nodes {
+		var r enr.Record
+		r.Set(enr.IP(net.IP{10, 0, 0, byte(i + 1)}))
+		r.Set(enr.TCP(30303 + i))
+		r.Set(enr.UDP(30303 + i))
+		if err := enr.SignV4(&r, nodekey); err != nil {
+			panic(err)
+		}
+		blob, _ := rlp.EncodeToBytes(r)
+		enrs = append(enrs, mr.publish(domain, dnsENRPrefix+b64format.EncodeToString(blob)))
+	}
+	for _, link := range links {
+		lnks = append(lnks, mr.publish(domain, link))
+	}
+	eroot := mr.publish(domain, dnsBranchPrefix+strings.Join(enrs, ","))
+	lroot := mr.publish(domain, dnsBranchPrefix+strings.Join(lnks, ","))
+
+	root := &dnsRootEntry{eroot: eroot, lroot: lroot, seq: 1}
+	sig, err := crypto.Sign(root.sigHash(), key)
+	if err != nil {
+		panic(err)
+	}
+	mr[domain] = fmt.Sprintf("%s e=%s l=%s seq=%d sig=%s", dnsRootPrefix, eroot, lroot, root.seq, b64format.EncodeToString(sig))
+
+	return dnsLinkPrefix + b32format.EncodeToString(crypto.CompressPubkey(&key.PublicKey)) + "@" + domain
+}
+
+func newTestKeys(n int) []*ecdsa.PrivateKey {
+	keys := make([]*ecdsa.PrivateKey, n)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	return keys
+}
+
+func nodeIDs(nodes []*Node) map[NodeID]bool {
+	ids := make(map[NodeID]bool)
+	for _, n := range nodes {
+		ids[n.ID] = true
+	}
+	return ids
+}
+
+func keyIDs(keys []*ecdsa.PrivateKey) map[NodeID]bool {
+	ids := make(map[NodeID]bool)
+	for _, key := range keys {
+		ids[PubkeyID(&key.PublicKey)] = true
+	}
+	return ids
+}
+
+func TestDNSSyncTree(t *testing.T) {
+	var (
+		resolver = make(mapResolver)
+		signer   = newTestKeys(1)[0]
+		keys     = newTestKeys(5)
+		url      = resolver.addTree(signer, "nodes.example.org", keys, nil)
+	)
+	nodes, err := NewDNSClient(resolver).SyncTree(context.Background(), url)
+	if err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if have, want := nodeIDs(nodes), keyIDs(keys); !reflect.DeepEqual(have, want) {
+		t.Fatalf("node set mismatch: have %v, want %v", have, want)
+	}
+	for _, n := range nodes {
+		if n.IP.To4() == nil || n.IP[len(n.IP)-4] != 10 || n.TCP < 30303 || n.UDP != n.TCP {
+			t.Errorf("node %v has wrong endpoint", n)
+		}
+	}
+}
+
+func TestDNSSyncTreeLinks(t *testing.T) {
+	var (
+		resolver = make(mapResolver)
+		signers  = newTestKeys(2)
+		keysA    = newTestKeys(2)
+		keysB    = newTestKeys(3)
+	)
+	urlB := resolver.addTree(signers[1], "b.example.org", keysB, nil)
+	urlA := resolver.addTree(signers[0], "a.example.org", keysA, []string{urlB})
+
+	nodes, err := NewDNSClient(resolver).SyncTree(context.Background(), urlA)
+	if err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	want := keyIDs(append(keysA, keysB...))
+	if have := nodeIDs(nodes); !reflect.DeepEqual(have, want) {
+		t.Fatalf("node set mismatch: have %d nodes, want %d", len(have), len(want))
+	}
+}
+
+func TestDNSSyncTreeBadSignature(t *testing.T) {
+	var (
+		resolver = make(mapResolver)
+		keys     = newTestKeys(2)
+		url      = resolver.addTree(keys[0], "nodes.example.org", newTestKeys(1), nil)
+	)
+	// Swap the public key in the URL for one that did not sign the root
+	url = dnsLinkPrefix + b32format.EncodeToString(crypto.CompressPubkey(&keys[1].PublicKey)) + "@nodes.example.org"
+	if _, err := NewDNSClient(resolver).SyncTree(context.Background(), url); err == nil || !strings.Contains(err.Error(), errDNSInvalidSig.Error()) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errDNSInvalidSig)
+	}
+}
+
+func TestDNSSyncTreeHashMismatch(t *testing.T) {
+	var (
+		resolver = make(mapResolver)
+		url      = resolver.addTree(newTestKeys(1)[0], "nodes.example.org", newTestKeys(1), nil)
+	)
+	// Replace the node record with a different, validly signed one
+	for name, txt := range resolver {
+		if strings.HasPrefix(txt, dnsENRPrefix) {
+			other := make(mapResolver)
+			other.addTree(newTestKeys(1)[0], "nodes.example.org", newTestKeys(1), nil)
+			for _, otxt := range other {
+				if strings.HasPrefix(otxt, dnsENRPrefix) {
+					resolver[name] = otxt
+				}
+			}
+		}
+	}
+	if _, err := NewDNSClient(resolver).SyncTree(context.Background(), url); err == nil || !strings.Contains(err.Error(), errDNSHashMismatch.Error()) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errDNSHashMismatch)
+	}
+}
+
+func TestParseDNSLink(t *testing.T) {
+	key := newTestKeys(1)[0]
+	b32key := b32format.EncodeToString(crypto.CompressPubkey(&key.PublicKey))
+
+	link, err := parseDNSLink(dnsLinkPrefix + b32key + "@nodes.example.org")
+	if err != nil {
+		t.Fatalf("failed to parse valid link: %v", err)
+	}
+	if link.domain != "nodes.example.org" || !reflect.DeepEqual(link.pubkey, &key.PublicKey) {
+		t.Errorf("link mismatch: have %s %v", link.domain, link.pubkey)
+	}
+	for _, url := range []string{
+		"enode://" + b32key + "@nodes.example.org",
+		dnsLinkPrefix + b32key,
+		dnsLinkPrefix + b32key + "@",
+		dnsLinkPrefix + "AAAA@nodes.example.org",
+	} {
+		if _, err := parseDNSLink(url); err == nil {
+			t.Errorf("expected error for %q", url)
+		}
+	}
+}
+
+func TestTableAddFallbackNodes(t *testing.T) {
+	transport := newPingRecorder()
+	tab, _ := newTable(transport, NodeID{}, &net.UDPAddr{}, "", nil)
+	defer tab.Close()
+
+	keys := newTestKeys(2)
+	nodes := []*Node{
+		NewNode(PubkeyID(&keys[0].PublicKey), net.IP{10, 0, 0, 1}, 30303, 30303),
+		NewNode(PubkeyID(&keys[1].PublicKey), net.IP{10, 0, 0, 2}, 30303, 30303),
+		NewNode(PubkeyID(&keys[0].PublicKey), net.IP{10, 0, 0, 1}, 30303, 30303), // duplicate
+		NewNode(NodeID{}, net.IP{10, 0, 0, 3}, 30303, 30303),                     // self
+	}
+	tab.addFallbackNodes(nodes)
+	tab.addFallbackNodes(nodes[:1])
+
+	tab.mutex.Lock()
+	defer tab.mutex.Unlock()
+	if len(tab.nursery) != 2 {
+		t.Fatalf("fallback node count mismatch: have %d, want 2", len(tab.nursery))
+	}
+}
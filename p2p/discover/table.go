@@ -82,6 +82,8 @@ type Table struct {
 	bonding   map[NodeID]*bondproc
 	bondslots chan struct{} // limits total number of active bonding processes
 
+	dnsNodes chan []*Node // nodes found through DNS discovery
+
 	nodeAddedHook func(*Node) // for testing
 
 	net  transport
@@ -128,6 +130,7 @@ func newTable(t transport, ourID NodeID, ourAddr *net.UDPAddr, nodeDBPath string
 		initDone:   make(chan struct{}),
 		closeReq:   make(chan struct{}),
 		closed:     make(chan struct{}),
+		dnsNodes:   make(chan []*Node),
 		rand:       mrand.New(mrand.NewSource(0)),
 		ips:        netutil.DistinctNetSet{Subnet: tableSubnet, Limit: tableIPLimit},
 	}
@@ -238,6 +241,27 @@ func (tab *Table) setFallbackNodes(nodes []*Node) error {
 	return nil
 }
 
+// addFallbackNodes adds nodes to the initial points of contact, skipping
+// invalid and already known ones.
+func (tab *Table) addFallbackNodes(nodes []*Node) {
+	tab.mutex.Lock()
+	defer tab.mutex.Unlock()
+
+	known := make(map[NodeID]bool, len(tab.nursery))
+	for _, n := range tab.nursery {
+		known[n.ID] = true
+	}
+	for _, n := range nodes {
+		if known[n.ID] || n.ID == tab.self.ID || n.validateComplete() != nil {
+			continue
+		}
+		known[n.ID] = true
+		cpy := *n
+		cpy.sha = crypto.Keccak256Hash(n.ID[:])
+		tab.nursery = append(tab.nursery, &cpy)
+	}
+}
+
 // isInitDone returns whether the table's initial seeding procedure has completed.
 func (tab *Table) isInitDone() bool {
 	select {
@@ -402,6 +426,14 @@ loop:
 			revalidate.Reset(tab.nextRevalidateTime())
 		case <-copyNodes.C:
 			go tab.copyBondedNodes()
+		case nodes := <-tab.dnsNodes:
+			// Merge DNS discovered nodes into the fallback set and bond with
+			// them through a refresh.
+			tab.addFallbackNodes(nodes)
+			if refreshDone == nil {
+				refreshDone = make(chan struct{})
+				go tab.doRefresh(refreshDone)
+			}
 		case <-tab.closeReq:
 			break loop
 		}
@@ -449,7 +481,9 @@ func (tab *Table) doRefresh(done chan struct{}) {
 
 func (tab *Table) loadSeedNodes(bond bool) {
 	seeds := tab.db.querySeeds(seedCount, seedMaxAge)
+	tab.mutex.Lock()
 	seeds = append(seeds, tab.nursery...)
+	tab.mutex.Unlock()
 	if bond {
 		seeds = tab.bondall(seeds)
 	}
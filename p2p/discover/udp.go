@@ -225,6 +225,8 @@ type Config struct {
 	NodeDBPath   string            // if set, the node database is stored at this filesystem location
 	NetRestrict  *netutil.Netlist  // network whitelist
 	Bootnodes    []*Node           // list of bootstrap nodes
+	DNSTrees     []string          // enrtree:// URLs of DNS node lists to merge into the table
+	DNSResolver  DNSResolver       // resolver for DNS discovery, the system resolver if nil
 	Unhandled    chan<- ReadPacket // unhandled packets are sent on this channel
 }
 
@@ -258,6 +260,9 @@ func newUDP(c conn, cfg Config) (*Table, *udp, error) {
 		return nil, nil, err
 	}
 	udp.Table = tab
+	if len(cfg.DNSTrees) > 0 {
+		go tab.dnsLoop(NewDNSClient(cfg.DNSResolver), cfg.DNSTrees)
+	}
 
 	go udp.loop()
 	go udp.readLoop(cfg.Unhandled)
@@ -19,6 +19,7 @@
 package p2p
 
 import (
+	"fmt"
 	"net"
 
 	"github.com/matrix/go-matrix/metrics"
@@ -29,8 +30,26 @@ var (
 	ingressTrafficMeter = metrics.NewRegisteredMeter("p2p/InboundTraffic", nil)
 	egressConnectMeter  = metrics.NewRegisteredMeter("p2p/OutboundConnects", nil)
 	egressTrafficMeter  = metrics.NewRegisteredMeter("p2p/OutboundTraffic", nil)
+	activePeerGauge     = metrics.NewRegisteredGauge("p2p/peers", nil)
+	lowScorePeerMeter   = metrics.NewRegisteredMeter("p2p/peers/lowscore", nil)
+
+	eip8HandshakeMeter   = metrics.NewRegisteredMeter("p2p/handshakes/eip8", nil)
+	legacyHandshakeMeter = metrics.NewRegisteredMeter("p2p/handshakes/legacy", nil)
+	rekeyMeter           = metrics.NewRegisteredMeter("p2p/rekeys", nil)
 )
 
+// markNegotiated bumps the meters of the base protocol version and of every
+// subprotocol version negotiated with a newly added peer.
+func markNegotiated(p *Peer) {
+	if !metrics.Enabled {
+		return
+	}
+	metrics.GetOrRegisterMeter(fmt.Sprintf("p2p/versions/%d", p.rw.version), nil).Mark(1)
+	for _, proto := range p.running {
+		metrics.GetOrRegisterMeter(fmt.Sprintf("p2p/protocols/%s/%d", proto.Name, proto.Version), nil).Mark(1)
+	}
+}
+
 // meteredConn is a wrapper around a network TCP connection that meters both the
 // inbound and outbound network traffic.
 type meteredConn struct {
@@ -109,6 +109,12 @@ type Peer struct {
 
 	// events receives message send / receive events if set
 	events *event.Feed
+
+	score    *peerScore  // Reputation of the remote node
+	scores   *peerScores // Reputation table of the server, used for banning (nil if not run by a server)
+	lowScore int32       // Set once the peer was disconnected for its low score, accessed atomically
+
+	rekeyInterval time.Duration // Session key lifetime, zero disables rotation
 }
 
 // NewPeer returns a peer for testing purposes.
@@ -177,9 +183,10 @@ func newPeer(conn *conn, protocols []Protocol) *Peer {
 		running:  protomap,
 		created:  mclock.Now(),
 		disc:     make(chan DiscReason),
-		protoErr: make(chan error, len(protomap)+1), // protocols + pingLoop
+		protoErr: make(chan error, len(protomap)+2), // protocols + pingLoop + rekeyLoop
 		closed:   make(chan struct{}),
 		log:      log.New("id", conn.id, "conn", conn.flags),
+		score:    new(peerScore),
 	}
 	return p
 }
@@ -198,6 +205,10 @@ func (p *Peer) run() (remoteRequested bool, err error) {
 	p.wg.Add(2)
 	go p.readLoop(readErr)
 	go p.pingLoop()
+	if t, ok := p.rw.transport.(sessionTransport); ok && p.rekeyInterval > 0 && !p.Inbound() && t.canRekey() {
+		p.wg.Add(1)
+		go p.rekeyLoop(t)
+	}
 
 	// Start all protocol handlers.
 	writeStart <- struct{}{}
@@ -256,6 +267,25 @@ func (p *Peer) pingLoop() {
 	}
 }
 
+// rekeyLoop periodically rotates the session keys of the connection.
+func (p *Peer) rekeyLoop(t sessionTransport) {
+	rekey := time.NewTimer(p.rekeyInterval)
+	defer p.wg.Done()
+	defer rekey.Stop()
+	for {
+		select {
+		case <-rekey.C:
+			if err := t.rekey(); err != nil {
+				p.protoErr <- err
+				return
+			}
+			rekey.Reset(p.rekeyInterval)
+		case <-p.closed:
+			return
+		}
+	}
+}
+
 func (p *Peer) readLoop(errc chan<- error) {
 	defer p.wg.Done()
 	for {
@@ -430,7 +460,9 @@ type PeerInfo struct {
 		Trusted       bool   `json:"trusted"`
 		Static        bool   `json:"static"`
 	} `json:"network"`
-	Protocols map[string]interface{} `json:"protocols"` // Sub-protocol specific metadata fields
+	Protocols  map[string]interface{} `json:"protocols"`            // Sub-protocol specific metadata fields
+	Score      *PeerScoreInfo         `json:"score"`                // Reputation of the peer
+	Encryption *EncryptionInfo        `json:"encryption,omitempty"` // Negotiated transport encryption
 }
 
 // Info gathers and returns a collection of metadata known about a peer.
@@ -446,12 +478,16 @@ func (p *Peer) Info() *PeerInfo {
 		Name:      p.Name(),
 		Caps:      caps,
 		Protocols: make(map[string]interface{}),
+		Score:     p.score.info(),
 	}
 	info.Network.LocalAddress = p.LocalAddr().String()
 	info.Network.RemoteAddress = p.RemoteAddr().String()
 	info.Network.Inbound = p.rw.is(inboundConn)
 	info.Network.Trusted = p.rw.is(trustedConn)
 	info.Network.Static = p.rw.is(staticDialedConn)
+	if t, ok := p.rw.transport.(sessionTransport); ok {
+		info.Encryption = t.encryption()
+	}
 
 	// Gather all the running protocol infos
 	for _, proto := range p.running {
@@ -35,13 +35,13 @@ import (
 	"sync"
 	"time"
 
+	"github.com/golang/snappy"
 	"github.com/matrix/go-matrix/crypto"
 	"github.com/matrix/go-matrix/crypto/ecies"
 	"github.com/matrix/go-matrix/crypto/secp256k1"
 	"github.com/matrix/go-matrix/crypto/sha3"
 	"github.com/matrix/go-matrix/p2p/discover"
 	"github.com/matrix/go-matrix/rlp"
-	"github.com/golang/snappy"
 )
 
 const (
@@ -81,6 +81,14 @@ type rlpx struct {
 
 	rmu, wmu sync.Mutex
 	rw       *rlpxFrameRW
+
+	initiator bool        // whether the local side dialed the connection
+	key       []byte      // current session secret, mixed into rotated keys
+	pending   *rekeyState // rotation started locally, awaiting its answer (protected by wmu)
+	ingress   *secrets    // rotated ingress keys, applied on rekeyDoneMsg (read side only)
+
+	imu  sync.Mutex     // protects info
+	info EncryptionInfo // parameters negotiated by the handshakes
 }
 
 func newRLPX(fd net.Conn) transport {
@@ -91,8 +99,17 @@ func newRLPX(fd net.Conn) transport {
 func (t *rlpx) ReadMsg() (Msg, error) {
 	t.rmu.Lock()
 	defer t.rmu.Unlock()
-	t.fd.SetReadDeadline(time.Now().Add(frameReadTimeout))
-	return t.rw.ReadMsg()
+	for {
+		t.fd.SetReadDeadline(time.Now().Add(frameReadTimeout))
+		msg, err := t.rw.ReadMsg()
+		if err != nil || !isRekeyMsg(msg.Code) || !t.canRekey() {
+			return msg, err
+		}
+		// Key rotation messages are consumed by the transport.
+		if err := t.handleRekey(msg); err != nil {
+			return msg, err
+		}
+	}
 }
 
 func (t *rlpx) WriteMsg(msg Msg) error {
@@ -138,6 +155,10 @@ func (t *rlpx) doProtoHandshake(our *protoHandshake) (their *protoHandshake, err
 	// If the protocol version supports Snappy encoding, upgrade immediately
 	t.rw.snappy = their.Version >= snappyProtocolVersion
 
+	t.imu.Lock()
+	t.info.Snappy = t.rw.snappy
+	t.imu.Unlock()
+
 	return their, nil
 }
 
@@ -188,9 +209,19 @@ func (t *rlpx) doEncHandshake(prv *ecdsa.PrivateKey, dial *discover.Node) (disco
 	if err != nil {
 		return discover.NodeID{}, err
 	}
+	if sec.Info.Handshake == handshakeLegacy {
+		legacyHandshakeMeter.Mark(1)
+	} else {
+		eip8HandshakeMeter.Mark(1)
+	}
 	t.wmu.Lock()
 	t.rw = newRLPXFrameRW(t.fd, sec)
+	t.initiator, t.key = dial != nil, sec.AES
 	t.wmu.Unlock()
+
+	t.imu.Lock()
+	t.info = sec.Info
+	t.imu.Unlock()
 	return sec.RemoteID, nil
 }
 
@@ -203,6 +234,12 @@ type encHandshake struct {
 	initNonce, respNonce []byte            // nonce
 	randomPrivKey        *ecies.PrivateKey // ecdhe-random
 	remoteRandomPub      *ecies.PublicKey  // ecdhe-random-pubk
+
+	remoteVersion uint     // RLPx version announced by the remote side
+	legacy        bool     // whether the remote side used the pre-EIP-8 format
+	offered       bool     // whether the initiator sent a handshake extension
+	suite         string   // negotiated frame cipher suite
+	features      []string // negotiated transport features
 }
 
 // secrets represents the connection secrets
@@ -212,6 +249,7 @@ type secrets struct {
 	AES, MAC              []byte
 	EgressMAC, IngressMAC hash.Hash
 	Token                 []byte
+	Info                  EncryptionInfo
 }
 
 // RLPx v4 handshake auth (defined in EIP-8).
@@ -252,6 +290,7 @@ func (h *encHandshake) secrets(auth, authResp []byte) (secrets, error) {
 		RemoteID: h.remoteID,
 		AES:      aesSecret,
 		MAC:      crypto.Keccak256(ecdheSecret, aesSecret),
+		Info:     h.info(),
 	}
 
 	// setup sha3 instances for the MACs
@@ -299,6 +338,7 @@ func initiatorEncHandshake(conn io.ReadWriter, prv *ecdsa.PrivateKey, remoteID d
 	if err != nil {
 		return s, err
 	}
+	h.legacy = len(authRespPacket) == encAuthRespLen
 	if err := h.handleAuthResp(authRespMsg); err != nil {
 		return s, err
 	}
@@ -339,13 +379,17 @@ func (h *encHandshake) makeAuthMsg(prv *ecdsa.PrivateKey, token []byte) (*authMs
 	copy(msg.InitiatorPubkey[:], crypto.FromECDSAPub(&prv.PublicKey)[1:])
 	copy(msg.Nonce[:], h.initNonce)
 	msg.Version = 4
+	msg.Rest = (&handshakeExt{Suites: ourSuites, Features: ourFeatures}).encode()
 	return msg, nil
 }
 
 func (h *encHandshake) handleAuthResp(msg *authRespV4) (err error) {
 	h.respNonce = msg.Nonce[:]
-	h.remoteRandomPub, err = importPublicKey(msg.RandomPubkey[:])
-	return err
+	h.remoteVersion = msg.Version
+	if h.remoteRandomPub, err = importPublicKey(msg.RandomPubkey[:]); err != nil {
+		return err
+	}
+	return h.acceptAnswer(decodeHandshakeExt(msg.Rest))
 }
 
 // receiverEncHandshake negotiates a session token on conn.
@@ -384,6 +428,11 @@ func receiverEncHandshake(conn io.ReadWriter, prv *ecdsa.PrivateKey, token []byt
 }
 
 func (h *encHandshake) handleAuthMsg(msg *authMsgV4, prv *ecdsa.PrivateKey) error {
+	// Pick the encryption parameters from the initiator's offer.
+	h.remoteVersion, h.legacy = msg.Version, msg.gotPlain
+	if err := h.acceptOffer(decodeHandshakeExt(msg.Rest)); err != nil {
+		return err
+	}
 	// Import the remote identity.
 	h.initNonce = msg.Nonce[:]
 	h.remoteID = msg.InitiatorPubkey
@@ -427,6 +476,9 @@ func (h *encHandshake) makeAuthResp() (msg *authRespV4, err error) {
 	copy(msg.Nonce[:], h.respNonce)
 	copy(msg.RandomPubkey[:], exportPubkey(&h.randomPrivKey.PublicKey))
 	msg.Version = 4
+	if h.offered {
+		msg.Rest = (&handshakeExt{Suites: []string{h.suite}, Features: h.features}).encode()
+	}
 	return msg, nil
 }
 
@@ -568,14 +620,34 @@ type rlpxFrameRW struct {
 	enc  cipher.Stream
 	dec  cipher.Stream
 
-	macCipher  cipher.Block
-	egressMAC  hash.Hash
-	ingressMAC hash.Hash
+	egressMACCipher  cipher.Block
+	ingressMACCipher cipher.Block
+	egressMAC        hash.Hash
+	ingressMAC       hash.Hash
 
 	snappy bool
 }
 
 func newRLPXFrameRW(conn io.ReadWriter, s secrets) *rlpxFrameRW {
+	rw := &rlpxFrameRW{conn: conn}
+	rw.setEgress(s)
+	rw.setIngress(s)
+	return rw
+}
+
+// setEgress seals all following frames with the given secrets.
+func (rw *rlpxFrameRW) setEgress(s secrets) {
+	rw.enc, rw.egressMACCipher = frameCiphers(s)
+	rw.egressMAC = s.EgressMAC
+}
+
+// setIngress opens all following frames with the given secrets.
+func (rw *rlpxFrameRW) setIngress(s secrets) {
+	rw.dec, rw.ingressMACCipher = frameCiphers(s)
+	rw.ingressMAC = s.IngressMAC
+}
+
+func frameCiphers(s secrets) (cipher.Stream, cipher.Block) {
 	macc, err := aes.NewCipher(s.MAC)
 	if err != nil {
 		panic("invalid MAC secret: " + err.Error())
@@ -587,14 +659,7 @@ func newRLPXFrameRW(conn io.ReadWriter, s secrets) *rlpxFrameRW {
 	// we use an all-zeroes IV for AES because the key used
 	// for encryption is ephemeral.
 	iv := make([]byte, encc.BlockSize())
-	return &rlpxFrameRW{
-		conn:       conn,
-		enc:        cipher.NewCTR(encc, iv),
-		dec:        cipher.NewCTR(encc, iv),
-		macCipher:  macc,
-		egressMAC:  s.EgressMAC,
-		ingressMAC: s.IngressMAC,
-	}
+	return cipher.NewCTR(encc, iv), macc
 }
 
 func (rw *rlpxFrameRW) WriteMsg(msg Msg) error {
@@ -622,7 +687,7 @@ func (rw *rlpxFrameRW) WriteMsg(msg Msg) error {
 	rw.enc.XORKeyStream(headbuf[:16], headbuf[:16]) // first half is now encrypted
 
 	// write header MAC
-	copy(headbuf[16:], updateMAC(rw.egressMAC, rw.macCipher, headbuf[:16]))
+	copy(headbuf[16:], updateMAC(rw.egressMAC, rw.egressMACCipher, headbuf[:16]))
 	if _, err := rw.conn.Write(headbuf); err != nil {
 		return err
 	}
@@ -645,7 +710,7 @@ func (rw *rlpxFrameRW) WriteMsg(msg Msg) error {
 	// write frame MAC. egress MAC hash is up to date because
 	// frame content was written to it as well.
 	fmacseed := rw.egressMAC.Sum(nil)
-	mac := updateMAC(rw.egressMAC, rw.macCipher, fmacseed)
+	mac := updateMAC(rw.egressMAC, rw.egressMACCipher, fmacseed)
 	_, err := rw.conn.Write(mac)
 	return err
 }
@@ -657,7 +722,7 @@ func (rw *rlpxFrameRW) ReadMsg() (msg Msg, err error) {
 		return msg, err
 	}
 	// verify header mac
-	shouldMAC := updateMAC(rw.ingressMAC, rw.macCipher, headbuf[:16])
+	shouldMAC := updateMAC(rw.ingressMAC, rw.ingressMACCipher, headbuf[:16])
 	if !hmac.Equal(shouldMAC, headbuf[16:]) {
 		return msg, errors.New("bad header MAC")
 	}
@@ -681,7 +746,7 @@ func (rw *rlpxFrameRW) ReadMsg() (msg Msg, err error) {
 	if _, err := io.ReadFull(rw.conn, headbuf[:16]); err != nil {
 		return msg, err
 	}
-	shouldMAC = updateMAC(rw.ingressMAC, rw.macCipher, fmacseed)
+	shouldMAC = updateMAC(rw.ingressMAC, rw.ingressMACCipher, fmacseed)
 	if !hmac.Equal(shouldMAC, headbuf[:16]) {
 		return msg, errors.New("bad frame MAC")
 	}
@@ -0,0 +1,368 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package p2p
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/matrix/go-matrix/crypto"
+	"github.com/matrix/go-matrix/crypto/ecies"
+	"github.com/matrix/go-matrix/crypto/sha3"
+	"github.com/matrix/go-matrix/rlp"
+)
+
+const (
+	// suiteAESCTR is the RLPx v4 frame encryption: an ECDHE secp256k1 key
+	// agreement keying AES-256 in CTR mode, authenticated by keccak256 MACs.
+	suiteAESCTR = "secp256k1-ecdhe/aes-256-ctr/keccak256"
+
+	// featureRekey allows the dialing side to periodically replace the
+	// session keys with keys derived from a fresh ephemeral key agreement.
+	featureRekey = "rekey/1"
+
+	handshakeEIP8   = "eip8"
+	handshakeLegacy = "legacy"
+)
+
+const (
+	// Session key rotation message codes. They share the base protocol code
+	// space but are consumed by the transport and never reach the peer.
+	rekeyMsg     = 0x04 // Dialer's fresh ephemeral key and nonce
+	rekeyAckMsg  = 0x05 // Listener's fresh ephemeral key and nonce, last frame under its old keys
+	rekeyDoneMsg = 0x06 // Last frame sealed with the dialer's old keys
+)
+
+var (
+	ourSuites   = []string{suiteAESCTR}
+	ourFeatures = []string{featureRekey}
+
+	errNoCommonSuite     = errors.New("no common cipher suite")
+	errUnexpectedRekey   = errors.New("unexpected session key rotation message")
+	errRekeyNotSupported = errors.New("session key rotation not negotiated")
+)
+
+// EncryptionInfo describes the encryption negotiated on a peer connection.
+type EncryptionInfo struct {
+	Handshake string   `json:"handshake"` // Format of the handshake messages sent by the remote side, "eip8" or "legacy"
+	Version   uint     `json:"version"`   // RLPx version announced by the remote side
+	Suite     string   `json:"suite"`     // Frame cipher suite
+	Features  []string `json:"features"`  // Negotiated transport features
+	Snappy    bool     `json:"snappy"`    // Whether message payloads are snappy compressed
+	Rekeys    uint64   `json:"rekeys"`    // Number of completed session key rotations
+}
+
+// handshakeExt is carried as the first tail element of the EIP-8 auth and ack
+// messages. The initiator lists the suites and features it supports, the
+// recipient answers with its choice. Implementations that predate it ignore
+// the tail, leaving the connection on the default suite without features.
+type handshakeExt struct {
+	Suites   []string
+	Features []string
+
+	// Ignore additional fields (forward-compatibility)
+	Rest []rlp.RawValue `rlp:"tail"`
+}
+
+func (ext *handshakeExt) encode() []rlp.RawValue {
+	enc, err := rlp.EncodeToBytes(ext)
+	if err != nil {
+		panic("can't encode handshake extension: " + err.Error())
+	}
+	return []rlp.RawValue{enc}
+}
+
+// decodeHandshakeExt returns the handshake extension from the tail of an
+// auth or ack message, or nil if the remote side didn't send one.
+func decodeHandshakeExt(rest []rlp.RawValue) *handshakeExt {
+	if len(rest) == 0 {
+		return nil
+	}
+	ext := new(handshakeExt)
+	if err := rlp.DecodeBytes(rest[0], ext); err != nil {
+		return nil
+	}
+	return ext
+}
+
+// acceptOffer picks the suite and features of the connection from the
+// initiator's offer, in the initiator's order of preference.
+func (h *encHandshake) acceptOffer(ext *handshakeExt) error {
+	h.suite, h.features = suiteAESCTR, nil
+	if ext == nil {
+		return nil
+	}
+	h.offered, h.suite = true, ""
+	for _, suite := range ext.Suites {
+		if containsString(ourSuites, suite) {
+			h.suite = suite
+			break
+		}
+	}
+	if h.suite == "" {
+		return errNoCommonSuite
+	}
+	h.features = commonStrings(ourFeatures, ext.Features)
+	return nil
+}
+
+// acceptAnswer checks the recipient's choice against our offer.
+func (h *encHandshake) acceptAnswer(ext *handshakeExt) error {
+	h.suite, h.features = suiteAESCTR, nil
+	if ext == nil {
+		return nil
+	}
+	if len(ext.Suites) != 1 || !containsString(ourSuites, ext.Suites[0]) {
+		return fmt.Errorf("remote selected unsupported cipher suite %q", ext.Suites)
+	}
+	h.suite = ext.Suites[0]
+	h.features = commonStrings(ourFeatures, ext.Features)
+	return nil
+}
+
+// info returns the negotiated encryption parameters.
+func (h *encHandshake) info() EncryptionInfo {
+	info := EncryptionInfo{
+		Handshake: handshakeEIP8,
+		Version:   h.remoteVersion,
+		Suite:     h.suite,
+		Features:  h.features,
+	}
+	if h.legacy {
+		info.Handshake = handshakeLegacy
+	}
+	return info
+}
+
+// rekeyPacket is the payload of rekeyMsg and rekeyAckMsg.
+type rekeyPacket struct {
+	Pubkey [pubLen]byte
+	Nonce  [shaLen]byte
+
+	// Ignore additional fields (forward-compatibility)
+	Rest []rlp.RawValue `rlp:"tail"`
+}
+
+// rekeyState is the local half of a session key rotation.
+type rekeyState struct {
+	priv  *ecies.PrivateKey
+	nonce []byte
+}
+
+func newRekeyState() (*rekeyState, error) {
+	priv, err := ecies.GenerateKey(rand.Reader, crypto.S256(), nil)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, shaLen)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &rekeyState{priv: priv, nonce: nonce}, nil
+}
+
+func (st *rekeyState) packet() *rekeyPacket {
+	pkt := new(rekeyPacket)
+	copy(pkt.Pubkey[:], exportPubkey(&st.priv.PublicKey))
+	copy(pkt.Nonce[:], st.nonce)
+	return pkt
+}
+
+// secrets derives the rotated session secrets the same way the encryption
+// handshake does, additionally binding them to the current session secret.
+// The ephemeral keys are discarded afterwards, so the rotated keys can't be
+// recomputed from the node keys or from any earlier session secret alone.
+func (st *rekeyState) secrets(remote *rekeyPacket, session []byte, initiator bool) (secrets, error) {
+	remotePub, err := importPublicKey(remote.Pubkey[:])
+	if err != nil {
+		return secrets{}, err
+	}
+	ecdheSecret, err := st.priv.GenerateShared(remotePub, sskLen, sskLen)
+	if err != nil {
+		return secrets{}, err
+	}
+	initNonce, respNonce := st.nonce, remote.Nonce[:]
+	if !initiator {
+		initNonce, respNonce = respNonce, initNonce
+	}
+	sharedSecret := crypto.Keccak256(ecdheSecret, crypto.Keccak256(respNonce, initNonce), session)
+	aesSecret := crypto.Keccak256(ecdheSecret, sharedSecret)
+	s := secrets{
+		AES: aesSecret,
+		MAC: crypto.Keccak256(ecdheSecret, aesSecret),
+	}
+	mac1 := sha3.NewKeccak256()
+	mac1.Write(xor(s.MAC, respNonce))
+	mac2 := sha3.NewKeccak256()
+	mac2.Write(xor(s.MAC, initNonce))
+	if initiator {
+		s.EgressMAC, s.IngressMAC = mac1, mac2
+	} else {
+		s.EgressMAC, s.IngressMAC = mac2, mac1
+	}
+	return s, nil
+}
+
+// sessionTransport is implemented by transports that report their negotiated
+// encryption and can rotate their session keys.
+type sessionTransport interface {
+	encryption() *EncryptionInfo
+	canRekey() bool
+	rekey() error
+}
+
+func isRekeyMsg(code uint64) bool {
+	return code == rekeyMsg || code == rekeyAckMsg || code == rekeyDoneMsg
+}
+
+// encryption returns the encryption parameters negotiated for the connection.
+func (t *rlpx) encryption() *EncryptionInfo {
+	t.imu.Lock()
+	defer t.imu.Unlock()
+
+	info := t.info
+	info.Features = append([]string{}, t.info.Features...)
+	return &info
+}
+
+// canRekey reports whether both sides agreed to rotate the session keys.
+func (t *rlpx) canRekey() bool {
+	t.imu.Lock()
+	defer t.imu.Unlock()
+	return containsString(t.info.Features, featureRekey)
+}
+
+// rekey starts a rotation of the session keys. Only the dialing side starts
+// rotations, the exchange is completed by the read loops of both sides:
+//
+//	dialer                           listener
+//	rekeyMsg(key, nonce)       -->
+//	                           <--   rekeyAckMsg(key, nonce), switch egress
+//	switch ingress
+//	rekeyDoneMsg, switch egress -->
+//	                                 switch ingress
+//
+// Every rotation message is the last frame sealed with its sender's old keys,
+// so frames in flight are never decrypted with the wrong keys.
+func (t *rlpx) rekey() error {
+	if !t.canRekey() {
+		return errRekeyNotSupported
+	}
+	t.wmu.Lock()
+	defer t.wmu.Unlock()
+	if !t.initiator {
+		return errUnexpectedRekey
+	}
+	if t.pending != nil {
+		return nil // previous rotation still in flight
+	}
+	st, err := newRekeyState()
+	if err != nil {
+		return err
+	}
+	t.fd.SetWriteDeadline(time.Now().Add(frameWriteTimeout))
+	if err := Send(t.rw, rekeyMsg, st.packet()); err != nil {
+		return err
+	}
+	t.pending = st
+	return nil
+}
+
+// handleRekey processes a key rotation message. It is called with rmu held.
+func (t *rlpx) handleRekey(msg Msg) error {
+	if msg.Code == rekeyDoneMsg {
+		msg.Discard()
+		if t.initiator || t.ingress == nil {
+			return errUnexpectedRekey
+		}
+		t.rw.setIngress(*t.ingress)
+		t.key, t.ingress = t.ingress.AES, nil
+		t.rekeyed()
+		return nil
+	}
+	var pkt rekeyPacket
+	if err := msg.Decode(&pkt); err != nil {
+		return err
+	}
+	t.wmu.Lock()
+	defer t.wmu.Unlock()
+
+	switch {
+	case msg.Code == rekeyMsg && !t.initiator && t.ingress == nil:
+		st, err := newRekeyState()
+		if err != nil {
+			return err
+		}
+		s, err := st.secrets(&pkt, t.key, false)
+		if err != nil {
+			return err
+		}
+		t.fd.SetWriteDeadline(time.Now().Add(frameWriteTimeout))
+		if err := Send(t.rw, rekeyAckMsg, st.packet()); err != nil {
+			return err
+		}
+		t.rw.setEgress(s)
+		t.ingress = &s
+		return nil
+
+	case msg.Code == rekeyAckMsg && t.initiator && t.pending != nil:
+		s, err := t.pending.secrets(&pkt, t.key, true)
+		if err != nil {
+			return err
+		}
+		t.rw.setIngress(s)
+		t.fd.SetWriteDeadline(time.Now().Add(frameWriteTimeout))
+		if err := SendItems(t.rw, rekeyDoneMsg); err != nil {
+			return err
+		}
+		t.rw.setEgress(s)
+		t.key, t.pending = s.AES, nil
+		t.rekeyed()
+		return nil
+	}
+	return errUnexpectedRekey
+}
+
+func (t *rlpx) rekeyed() {
+	rekeyMeter.Mark(1)
+
+	t.imu.Lock()
+	t.info.Rekeys++
+	t.imu.Unlock()
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// commonStrings returns the elements of ours that also appear in theirs.
+func commonStrings(ours, theirs []string) []string {
+	var common []string
+	for _, s := range ours {
+		if containsString(theirs, s) {
+			common = append(common, s)
+		}
+	}
+	return common
+}
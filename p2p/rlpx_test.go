@@ -602,6 +602,96 @@ func TestHandshakeForwardCompatibility(t *testing.T) {
 	}
 }
 
+func TestRekey(t *testing.T) {
+	var (
+		prv0, _ = crypto.GenerateKey()
+		prv1, _ = crypto.GenerateKey()
+		node1   = &discover.Node{ID: discover.PubkeyID(&prv1.PublicKey), IP: net.IP{5, 6, 7, 8}, TCP: 44}
+	)
+	fd0, fd1, err := tcpPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd0.Close()
+	defer fd1.Close()
+
+	dialer, listener := newRLPX(fd0).(*rlpx), newRLPX(fd1).(*rlpx)
+	errc := make(chan error, 1)
+	go func() {
+		_, err := listener.doEncHandshake(prv1, nil)
+		errc <- err
+	}()
+	if _, err := dialer.doEncHandshake(prv0, node1); err != nil {
+		t.Fatalf("dial side enc handshake failed: %v", err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("listen side enc handshake failed: %v", err)
+	}
+	for _, side := range []*rlpx{dialer, listener} {
+		info := side.encryption()
+		if info.Handshake != handshakeEIP8 || info.Version != 4 || info.Suite != suiteAESCTR {
+			t.Fatalf("negotiated encryption mismatch: %+v", info)
+		}
+		if !side.canRekey() {
+			t.Fatalf("key rotation not negotiated: %+v", info)
+		}
+	}
+	if err := listener.rekey(); err != errUnexpectedRekey {
+		t.Fatalf("listener started rotation: %v", err)
+	}
+
+	// Messages sent around the rotation must arrive under the right keys.
+	if err := dialer.rekey(); err != nil {
+		t.Fatalf("rekey failed: %v", err)
+	}
+	if err := Send(dialer, 0x10, "before"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExpectMsg(listener, 0x10, "before"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Send(listener, 0x11, "answer"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExpectMsg(dialer, 0x11, "answer"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Send(dialer, 0x12, "after"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExpectMsg(listener, 0x12, "after"); err != nil {
+		t.Fatal(err)
+	}
+	for _, side := range []*rlpx{dialer, listener} {
+		if rekeys := side.encryption().Rekeys; rekeys != 1 {
+			t.Errorf("rekeys mismatch: got %d, want 1", rekeys)
+		}
+	}
+	if !bytes.Equal(dialer.key, listener.key) {
+		t.Errorf("session secrets diverged")
+	}
+}
+
+func TestHandshakeExtNegotiation(t *testing.T) {
+	h := new(encHandshake)
+	if err := h.acceptOffer(&handshakeExt{Suites: []string{"foo", suiteAESCTR}, Features: []string{"bar", featureRekey}}); err != nil {
+		t.Fatal(err)
+	}
+	if h.suite != suiteAESCTR || !reflect.DeepEqual(h.features, []string{featureRekey}) {
+		t.Errorf("wrong selection: suite %q, features %v", h.suite, h.features)
+	}
+	if err := h.acceptOffer(&handshakeExt{Suites: []string{"foo"}}); err != errNoCommonSuite {
+		t.Errorf("wrong error for unsupported offer: %v", err)
+	}
+	// Peers without the extension use the default suite.
+	if err := h.acceptOffer(nil); err != nil || h.suite != suiteAESCTR || h.features != nil {
+		t.Errorf("wrong default: suite %q, features %v, err %v", h.suite, h.features, err)
+	}
+	if err := h.acceptAnswer(&handshakeExt{Suites: []string{"foo"}}); err == nil {
+		t.Errorf("accepted unsupported suite")
+	}
+}
+
 // tcpPipe creates an in process full duplex pipe based on a localhost TCP socket
 func tcpPipe() (net.Conn, net.Conn, error) {
 	l, err := net.Listen("tcp", "127.0.0.1:0")
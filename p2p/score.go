@@ -0,0 +1,215 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package p2p
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/matrix/go-matrix/common/mclock"
+	"github.com/matrix/go-matrix/p2p/discover"
+)
+
+const (
+	scoreUselessPenalty = 5  // Penalty for a message that was of no use to the protocol
+	scoreInvalidPenalty = 20 // Penalty for a message that violated the protocol
+	scoreTimeoutPenalty = 10 // Penalty for a request that wasn't answered in time
+	scoreSlowPenalty    = 1  // Penalty for propagating data slower than scoreSlowThreshold
+
+	scoreSlowThreshold = 5 * time.Second // Propagation latency above which a peer is considered slow
+	scoreLatencyWeight = 0.1             // Weight of a new sample in the moving latency average
+
+	scoreDropThreshold = -100             // Score below which a peer is disconnected and banned
+	scoreHalfLife      = 10 * time.Minute // Time after which half of the penalties are forgiven
+	scoreBanDuration   = 30 * time.Minute // Time during which a low scoring peer can't reconnect
+)
+
+// PeerScoreInfo represents the reputation of a connected peer.
+type PeerScoreInfo struct {
+	Score    float64 `json:"score"`    // Current score, zero for a well behaving peer
+	Useless  uint64  `json:"useless"`  // Number of useless messages received
+	Invalid  uint64  `json:"invalid"`  // Number of invalid messages received
+	Timeouts uint64  `json:"timeouts"` // Number of requests timed out
+	Latency  string  `json:"latency"`  // Moving average of the propagation latency
+}
+
+// peerScore tracks the reputation of a remote node. Penalties lower the score,
+// which then recovers exponentially towards zero over time.
+type peerScore struct {
+	lock    sync.Mutex
+	value   float64
+	updated mclock.AbsTime
+
+	useless  uint64
+	invalid  uint64
+	timeouts uint64
+	latency  time.Duration
+}
+
+// decay forgives part of the past penalties, based on the time elapsed since
+// the last update. The lock must be held.
+func (s *peerScore) decay(now mclock.AbsTime) {
+	if s.updated != 0 {
+		elapsed := time.Duration(now - s.updated)
+		s.value *= math.Pow(0.5, float64(elapsed)/float64(scoreHalfLife))
+	}
+	s.updated = now
+}
+
+// update applies a change to the score statistics and deducts the penalty,
+// returning the resulting score.
+func (s *peerScore) update(change func(s *peerScore), penalty float64) float64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.decay(mclock.Now())
+	if change != nil {
+		change(s)
+	}
+	s.value -= penalty
+	return s.value
+}
+
+// info returns a snapshot of the score statistics.
+func (s *peerScore) info() *PeerScoreInfo {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.decay(mclock.Now())
+	return &PeerScoreInfo{
+		Score:    s.value,
+		Useless:  s.useless,
+		Invalid:  s.invalid,
+		Timeouts: s.timeouts,
+		Latency:  s.latency.String(),
+	}
+}
+
+// peerScores is the reputation table of the server, retaining the scores of
+// nodes across reconnects, as well as the nodes currently banned.
+type peerScores struct {
+	lock   sync.Mutex
+	scores map[discover.NodeID]*peerScore
+	bans   map[discover.NodeID]time.Time
+}
+
+func newPeerScores() *peerScores {
+	return &peerScores{
+		scores: make(map[discover.NodeID]*peerScore),
+		bans:   make(map[discover.NodeID]time.Time),
+	}
+}
+
+// get retrieves the score of a node, creating a neutral one if none is tracked.
+func (t *peerScores) get(id discover.NodeID) *peerScore {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	score, ok := t.scores[id]
+	if !ok {
+		score = new(peerScore)
+		t.scores[id] = score
+	}
+	return score
+}
+
+// release stops tracking the score of a disconnected node if it's back to neutral.
+func (t *peerScores) release(id discover.NodeID) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if score, ok := t.scores[id]; ok && score.info().Score > -1 {
+		delete(t.scores, id)
+	}
+}
+
+// ban prevents a node from reconnecting for scoreBanDuration.
+func (t *peerScores) ban(id discover.NodeID) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.bans[id] = time.Now().Add(scoreBanDuration)
+}
+
+// banned returns whether the node is currently banned.
+func (t *peerScores) banned(id discover.NodeID) bool {
+	if t == nil {
+		return false
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	until, ok := t.bans[id]
+	if ok && time.Now().After(until) {
+		delete(t.bans, id)
+		delete(t.scores, id)
+		return false
+	}
+	return ok
+}
+
+// ReportUseless lowers the reputation of the peer for sending a message that
+// was of no use, e.g. an unrequested or already known item.
+func (p *Peer) ReportUseless() {
+	p.penalize(func(s *peerScore) { s.useless++ }, scoreUselessPenalty)
+}
+
+// ReportInvalid lowers the reputation of the peer for sending a malformed or
+// otherwise protocol violating message.
+func (p *Peer) ReportInvalid() {
+	p.penalize(func(s *peerScore) { s.invalid++ }, scoreInvalidPenalty)
+}
+
+// ReportTimeout lowers the reputation of the peer for not answering a request
+// in time.
+func (p *Peer) ReportTimeout() {
+	p.penalize(func(s *peerScore) { s.timeouts++ }, scoreTimeoutPenalty)
+}
+
+// ReportLatency records the delay with which the peer propagated a new item to
+// us, slightly lowering its reputation if the peer is slow.
+func (p *Peer) ReportLatency(latency time.Duration) {
+	var penalty float64
+	if latency > scoreSlowThreshold {
+		penalty = scoreSlowPenalty
+	}
+	p.penalize(func(s *peerScore) {
+		if s.latency == 0 {
+			s.latency = latency
+		} else {
+			s.latency += time.Duration(scoreLatencyWeight * float64(latency-s.latency))
+		}
+	}, penalty)
+}
+
+// penalize updates the score of the peer, disconnecting (and banning) it if the
+// score drops below the threshold. Trusted peers are never disconnected.
+func (p *Peer) penalize(change func(s *peerScore), penalty float64) {
+	if p.score.update(change, penalty) >= scoreDropThreshold || p.rw.is(trustedConn) {
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&p.lowScore, 0, 1) {
+		return
+	}
+	p.log.Debug("Disconnecting low scoring peer", "score", p.score.info().Score)
+	if p.scores != nil {
+		p.scores.ban(p.ID())
+	}
+	lowScorePeerMeter.Mark(1)
+	p.Disconnect(DiscUselessPeer)
+}
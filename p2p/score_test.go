@@ -0,0 +1,105 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package p2p
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/matrix/go-matrix/common/mclock"
+	"github.com/matrix/go-matrix/p2p/discover"
+)
+
+func newScoredPeer(id discover.NodeID, scores *peerScores) *Peer {
+	p := NewPeer(id, "scored", nil)
+	p.score, p.scores = scores.get(id), scores
+	return p
+}
+
+// Tests that misbehaving peers are disconnected and banned once their score
+// drops below the threshold.
+func TestPeerScoreBan(t *testing.T) {
+	var (
+		id     = randomID()
+		scores = newPeerScores()
+		p      = newScoredPeer(id, scores)
+	)
+	for i := 0; i < -scoreDropThreshold/scoreInvalidPenalty; i++ {
+		p.ReportInvalid()
+	}
+	if scores.banned(id) {
+		t.Fatalf("peer banned at threshold")
+	}
+	p.ReportTimeout()
+	if !scores.banned(id) {
+		t.Fatalf("peer not banned below threshold")
+	}
+	info := p.Info().Score
+	if info.Invalid != uint64(-scoreDropThreshold/scoreInvalidPenalty) || info.Timeouts != 1 {
+		t.Errorf("score statistics mismatch: have %d invalid, %d timeouts", info.Invalid, info.Timeouts)
+	}
+	// Ensure the score survives reconnects and the ban expires
+	if score := scores.get(id).info().Score; score >= scoreDropThreshold {
+		t.Errorf("score lost: have %v, want below %v", score, scoreDropThreshold)
+	}
+	scores.bans[id] = time.Now().Add(-time.Second)
+	if scores.banned(id) {
+		t.Errorf("peer still banned after expiry")
+	}
+}
+
+// Tests that trusted peers are never banned.
+func TestPeerScoreTrusted(t *testing.T) {
+	var (
+		id     = randomID()
+		scores = newPeerScores()
+		p      = newScoredPeer(id, scores)
+	)
+	p.rw.flags |= trustedConn
+	for i := 0; i < 10; i++ {
+		p.ReportInvalid()
+	}
+	if scores.banned(id) {
+		t.Fatalf("trusted peer banned")
+	}
+}
+
+// Tests that penalties are forgiven over time.
+func TestPeerScoreDecay(t *testing.T) {
+	score := &peerScore{value: -100, updated: mclock.Now() - mclock.AbsTime(scoreHalfLife)}
+	if have := score.info().Score; math.Abs(have+50) > 0.1 {
+		t.Errorf("decayed score mismatch: have %v, want %v", have, -50)
+	}
+}
+
+// Tests that the propagation latency is averaged and slow peers penalized.
+func TestPeerScoreLatency(t *testing.T) {
+	p := newScoredPeer(randomID(), newPeerScores())
+
+	p.ReportLatency(time.Second)
+	p.ReportLatency(2 * time.Second)
+	if have, want := p.score.latency, 1100*time.Millisecond; have != want {
+		t.Errorf("latency mismatch: have %v, want %v", have, want)
+	}
+	if score := p.score.info().Score; score != 0 {
+		t.Errorf("fast peer penalized: score %v", score)
+	}
+	p.ReportLatency(2 * scoreSlowThreshold)
+	if score := p.score.info().Score; score >= 0 {
+		t.Errorf("slow peer not penalized: score %v", score)
+	}
+}
@@ -25,6 +25,11 @@ var (
 	TargetGasLimit uint64 = GenesisGasLimit // The artificial target
 )
 
+// ImmutabilityThreshold is the number of blocks after which a chain segment is
+// considered immutable (i.e. it can't be reorged anymore) and may be moved out
+// of the key-value database into the ancient store.
+const ImmutabilityThreshold = 90000
+
 const (
 	GasLimitBoundDivisor uint64 = 1024    // The bound divisor of the gas limit, used in update calculations.
 	MinGasLimit          uint64 = 5000    // Minimum the gas limit may ever be.
@@ -82,7 +87,7 @@ const (
 	Bn256PairingPerPointGas uint64 = 80000  // Per-point price for an elliptic curve pairing check
 
 	//YY
-	TxCount              uint64 = 1000                  //一对多交易最多可以支持1000笔(包括扩展之外的那一个交易)
+	TxCount              uint64 = 1000               //一对多交易最多可以支持1000笔(包括扩展之外的那一个交易)
 	ErrTxConsensus       uint64 = 6                  //错误交易需要共识的个数（超过6个节点认为该笔交易错误就可以确认删除这笔交易）
 	SubBlockNum          uint64 = 200                //超过SubBlockNum区块高度就删除某些东西（超过20个区块就删除未打包的交易）
 	NonceAddOne          uint64 = 0x0010000000000000 //Nonce最高位加1
@@ -96,8 +101,8 @@ const (
 
 var (
 	DifficultyBoundDivisor = big.NewInt(256) // The bound divisor of the difficulty, used in the update calculations.
-	GenesisDifficulty      = big.NewInt(10)   // Difficulty of the Genesis block.
-	MinimumDifficulty      = big.NewInt(10)   // The minimum that the difficulty may ever be.
+	GenesisDifficulty      = big.NewInt(10)  // Difficulty of the Genesis block.
+	MinimumDifficulty      = big.NewInt(10)  // The minimum that the difficulty may ever be.
 	DurationLimit          = big.NewInt(6)   // The decision boundary on the blocktime duration used to determine whether difficulty should go up or not.
-	FloodTime              = 1 * time.Second  //洪泛时间阈值
+	FloodTime              = 1 * time.Second //洪泛时间阈值
 )
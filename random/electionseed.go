@@ -76,8 +76,7 @@ func (self *ElectionSeed) update() {
 }
 func (self *ElectionSeed) randomSeedReqHandle(data *mc.RandomRequest) error {
 
-	ans := compareMap(data.PrivateMap, data.PublicMap)
-	ans.Add(ans, data.MinHash.Big())
+	ans := CalcElectionSeed(data)
 
 	err := mc.PublishEvent(mc.Random_TopoSeedRsp, &mc.ElectionEvent{Seed: ans})
 	if err != nil {
@@ -87,6 +86,13 @@ func (self *ElectionSeed) randomSeedReqHandle(data *mc.RandomRequest) error {
 	return nil
 }
 
+// CalcElectionSeed derives the election seed from the revealed key pairs and
+// the minimum block hash of a broadcast period.
+func CalcElectionSeed(data *mc.RandomRequest) *big.Int {
+	ans := compareMap(data.PrivateMap, data.PublicMap)
+	return ans.Add(ans, data.MinHash.Big())
+}
+
 func compareMap(private map[common.Address][]byte, public map[common.Address][]byte) *big.Int {
 	if len(private) > len(public) {
 		return rangePrivate(private, public)
@@ -16,18 +16,17 @@
 package reelection
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"testing"
-	"time"
-
-	"github.com/matrix/go-matrix/log"
-	"github.com/matrix/go-matrix/random"
 
 	"github.com/matrix/go-matrix/common"
-	"github.com/matrix/go-matrix/mc"
-
-	"github.com/matrix/go-matrix/man"
+	"github.com/matrix/go-matrix/core/state"
+	"github.com/matrix/go-matrix/core/types"
+	"github.com/matrix/go-matrix/depoistInfo"
+	"github.com/matrix/go-matrix/rpc"
 )
 
 //func Post() {
@@ -71,7 +70,16 @@ func TestT(t *testing.T) {
 	fmt.Println(ans1)
 
 }
+
+// noStateBackend is a deposit backend without any state.
+type noStateBackend struct{}
+
+func (noStateBackend) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	return nil, nil, errors.New("no state available")
+}
+
 func TestCase(t *testing.T) {
+	depoistInfo.NewDepositInfo(noStateBackend{})
 	ans1, ans2 := GetAllElectedByHeight(big.NewInt(100), common.RoleMiner)
 	fmt.Println(ans1, ans2)
 }
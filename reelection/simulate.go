@@ -0,0 +1,91 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package reelection
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/core/vm"
+	"github.com/matrix/go-matrix/election"
+	"github.com/matrix/go-matrix/mc"
+	"github.com/matrix/go-matrix/params"
+	"github.com/matrix/go-matrix/random"
+)
+
+var errFutureBlock = errors.New("block not yet imported")
+
+// SimulatedElection is the outcome of an election run read-only against the
+// deposits and seed material of a given block.
+type SimulatedElection struct {
+	Number             uint64
+	Seed               *big.Int
+	MasterMiner        []mc.TopologyNodeInfo
+	BackUpMiner        []mc.TopologyNodeInfo
+	MasterValidator    []mc.TopologyNodeInfo
+	BackUpValidator    []mc.TopologyNodeInfo
+	CandidateValidator []mc.TopologyNodeInfo
+	BroadCast          []common.Address
+}
+
+// SimulateElection runs the miner and validator elections as they would be run
+// at the given height, without publishing any messages or writing the result.
+func (self *ReElection) SimulateElection(height uint64) (*SimulatedElection, error) {
+	if head := self.bc.CurrentBlock().NumberU64(); height > head {
+		return nil, errFutureBlock
+	}
+	if interval := common.GetBroadcastInterval(); height < interval {
+		return nil, fmt.Errorf("election seed needs at least one broadcast period, height must be >= %d", interval)
+	}
+	minerDeposit, err := GetAllElectedByHeight(new(big.Int).SetUint64(height), common.RoleMiner)
+	if err != nil {
+		return nil, err
+	}
+	validatorDeposit, err := GetAllElectedByHeight(new(big.Int).SetUint64(height), common.RoleValidator)
+	if err != nil {
+		return nil, err
+	}
+	seedReq := self.CalcbeforeSeedGen(height)
+	seed := random.CalcElectionSeed(&seedReq)
+
+	return simulateElection(height, seed, minerDeposit, validatorDeposit), nil
+}
+
+// simulateElection runs the miner and validator elections on the given
+// deposits and seed.
+func simulateElection(height uint64, seed *big.Int, minerDeposit, validatorDeposit []vm.DepositDetail) *SimulatedElection {
+	// Use a private elector, the shared one is driven by the message center
+	elect := election.NewStandaloneEle()
+	miners := elect.ElectMiners(&mc.MasterMinerReElectionReqMsg{SeqNum: height, RandSeed: seed, MinerList: minerDeposit})
+	validators := elect.ElectValidators(&mc.MasterValidatorReElectionReqMsg{SeqNum: height, RandSeed: seed, ValidatorList: validatorDeposit, FoundationValidatoeList: GetFound()})
+
+	broadcast := make([]common.Address, 0, len(params.BroadCastNodes))
+	for _, node := range params.BroadCastNodes {
+		broadcast = append(broadcast, node.Address)
+	}
+	return &SimulatedElection{
+		Number:             height,
+		Seed:               seed,
+		MasterMiner:        miners.MasterMiner,
+		BackUpMiner:        miners.BackUpMiner,
+		MasterValidator:    validators.MasterValidator,
+		BackUpValidator:    validators.BackUpValidator,
+		CandidateValidator: validators.CandidateValidator,
+		BroadCast:          broadcast,
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package reelection
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/core/vm"
+	"github.com/matrix/go-matrix/mc"
+)
+
+func testDeposits(n int, offset byte) []vm.DepositDetail {
+	deposits := make([]vm.DepositDetail, n)
+	for i := range deposits {
+		deposits[i].Address = common.BigToAddress(big.NewInt(int64(offset) + int64(i) + 1))
+		deposits[i].NodeID[0] = offset + byte(i) + 1
+		deposits[i].Deposit = big.NewInt(int64(10000 + 2000*i))
+		deposits[i].OnlineTime = big.NewInt(300)
+	}
+	return deposits
+}
+
+// captureStdout returns everything fn writes to the standard output.
+func captureStdout(t *testing.T, fn func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan []byte)
+	go func() {
+		out, _ := ioutil.ReadAll(r)
+		done <- out
+	}()
+	fn()
+	w.Close()
+	return string(<-done)
+}
+
+func TestSimulateElection(t *testing.T) {
+	var sim *SimulatedElection
+	out := captureStdout(t, func() {
+		sim = simulateElection(300, big.NewInt(12345), testDeposits(25, 0), testDeposits(20, 100))
+	})
+	if out != "" {
+		t.Errorf("simulation wrote to stdout: %q", out)
+	}
+	if sim.Number != 300 || sim.Seed.Int64() != 12345 {
+		t.Errorf("simulation header mismatch: number %d, seed %v", sim.Number, sim.Seed)
+	}
+	if len(sim.MasterMiner) != 21 || len(sim.BackUpMiner) != 4 {
+		t.Errorf("miner set sizes mismatch: have %d/%d, want 21/4", len(sim.MasterMiner), len(sim.BackUpMiner))
+	}
+	if len(sim.MasterValidator) != 11 || len(sim.BackUpValidator) != 5 || len(sim.CandidateValidator) != 4 {
+		t.Errorf("validator set sizes mismatch: have %d/%d/%d, want 11/5/4",
+			len(sim.MasterValidator), len(sim.BackUpValidator), len(sim.CandidateValidator))
+	}
+	for _, set := range [][]mc.TopologyNodeInfo{sim.MasterMiner, sim.BackUpMiner} {
+		for _, node := range set {
+			if node.Type != common.RoleMiner || node.Account == (common.Address{}) {
+				t.Errorf("invalid miner %+v", node)
+			}
+		}
+	}
+	// The simulation must not depend on any elector state
+	if again := simulateElection(300, big.NewInt(12345), testDeposits(25, 0), testDeposits(20, 100)); !reflect.DeepEqual(sim, again) {
+		t.Errorf("simulation not deterministic:\nfirst:  %+v\nsecond: %+v", sim, again)
+	}
+}
@@ -64,3 +64,12 @@ type shutdownError struct{}
 func (e *shutdownError) ErrorCode() int { return -32000 }
 
 func (e *shutdownError) Error() string { return "server is shutting down" }
+
+// issued when a client exceeds the rate limit of a method.
+type rateLimitError struct{ method string }
+
+func (e *rateLimitError) ErrorCode() int { return -32005 }
+
+func (e *rateLimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded for method %s", e.method)
+}
@@ -0,0 +1,158 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package rpc
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru/simplelru"
+)
+
+// RateLimitAny is the method name of a rate limit applying to every method
+// without a rate limit of its own.
+const RateLimitAny = "*"
+
+// maxRateBuckets is the maximum number of client buckets tracked at once. When
+// full, the least recently used bucket is evicted to make room for a new one.
+const maxRateBuckets = 16384
+
+// RateLimit is the quota of a single RPC method, granted to every client IP
+// separately as a token bucket.
+type RateLimit struct {
+	Rate  float64 // Number of requests per second a client may sustain
+	Burst int     // Number of requests a client may issue at once
+}
+
+// ParseRateLimits parses a comma separated list of method quotas of the form
+// method=rate[:burst], e.g. "man_getLogs=2:10,*=50". The rate is the number of
+// requests per second, the burst defaults to the rate rounded up. The method
+// "*" sets the quota of every method not listed explicitly.
+func ParseRateLimits(spec string) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid rate limit %q, want method=rate[:burst]", entry)
+		}
+		method, quota := parts[0], strings.SplitN(parts[1], ":", 2)
+
+		rate, err := strconv.ParseFloat(quota[0], 64)
+		if err != nil || rate <= 0 || math.IsInf(rate, 0) {
+			return nil, fmt.Errorf("invalid rate %q for method %s", quota[0], method)
+		}
+		limit := RateLimit{Rate: rate, Burst: int(math.Ceil(rate))}
+		if len(quota) == 2 {
+			if limit.Burst, err = strconv.Atoi(quota[1]); err != nil || limit.Burst < 1 {
+				return nil, fmt.Errorf("invalid burst %q for method %s", quota[1], method)
+			}
+		}
+		if _, ok := limits[method]; ok {
+			return nil, fmt.Errorf("duplicate rate limit for method %s", method)
+		}
+		limits[method] = limit
+	}
+	return limits, nil
+}
+
+// rateKey identifies the bucket of a client for a method. Methods without a
+// quota of their own share the RateLimitAny bucket of the client.
+type rateKey struct {
+	method string
+	host   string
+}
+
+// rateBucket is the token bucket of a single client for a single method.
+type rateBucket struct {
+	tokens  float64   // Number of requests the client may issue right now
+	updated time.Time // Time the tokens were last refilled
+}
+
+// RateLimiter enforces per method quotas on the requests of every client IP.
+// Requests without a remote address, such as the ones of IPC and in-process
+// clients, are never limited.
+type RateLimiter struct {
+	limits  map[string]RateLimit
+	buckets *simplelru.LRU   // Client buckets, keyed by rateKey
+	now     func() time.Time // Clock, replaceable for testing
+	lock    sync.Mutex
+}
+
+// NewRateLimiter creates a rate limiter enforcing the given method quotas, as
+// returned by ParseRateLimits.
+func NewRateLimiter(limits map[string]RateLimit) *RateLimiter {
+	return newRateLimiter(limits, maxRateBuckets)
+}
+
+// newRateLimiter creates a rate limiter tracking at most size client buckets.
+func newRateLimiter(limits map[string]RateLimit, size int) *RateLimiter {
+	buckets, _ := simplelru.NewLRU(size, nil)
+	return &RateLimiter{
+		limits:  limits,
+		buckets: buckets,
+		now:     time.Now,
+	}
+}
+
+// Allow reports whether a client with the given remote address may call the
+// given method right now, consuming a token of its bucket if so.
+func (l *RateLimiter) Allow(method, remote string) bool {
+	if remote == "" {
+		return true
+	}
+	limit, ok := l.limits[method]
+	if !ok {
+		if limit, ok = l.limits[RateLimitAny]; !ok {
+			return true
+		}
+		method = RateLimitAny
+	}
+	// Quotas apply to client hosts, not to their individual connections
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.now()
+	key := rateKey{method: method, host: remote}
+
+	var bucket *rateBucket
+	if cached, ok := l.buckets.Get(key); ok {
+		bucket = cached.(*rateBucket)
+	} else {
+		bucket = &rateBucket{tokens: float64(limit.Burst), updated: now}
+		l.buckets.Add(key, bucket)
+	}
+	// Refill the bucket for the time passed and try to take a token out
+	bucket.tokens = math.Min(float64(limit.Burst), bucket.tokens+now.Sub(bucket.updated).Seconds()*limit.Rate)
+	bucket.updated = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
@@ -0,0 +1,197 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package rpc
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimits(t *testing.T) {
+	tests := []struct {
+		spec   string
+		limits map[string]RateLimit
+		fail   bool
+	}{
+		{spec: "", limits: map[string]RateLimit{}},
+		{spec: "man_getLogs=2", limits: map[string]RateLimit{"man_getLogs": {Rate: 2, Burst: 2}}},
+		{spec: "man_getLogs=0.5:10, *=100", limits: map[string]RateLimit{
+			"man_getLogs": {Rate: 0.5, Burst: 10},
+			"*":           {Rate: 100, Burst: 100},
+		}},
+		{spec: "debug_traceTransaction=0.1", limits: map[string]RateLimit{"debug_traceTransaction": {Rate: 0.1, Burst: 1}}},
+		{spec: "man_getLogs", fail: true},
+		{spec: "=2", fail: true},
+		{spec: "man_getLogs=0", fail: true},
+		{spec: "man_getLogs=-1", fail: true},
+		{spec: "man_getLogs=1:0", fail: true},
+		{spec: "man_getLogs=fast", fail: true},
+		{spec: "man_getLogs=1,man_getLogs=2", fail: true},
+	}
+	for i, tt := range tests {
+		limits, err := ParseRateLimits(tt.spec)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("test %d: expected error for %q", i, tt.spec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: failed to parse %q: %v", i, tt.spec, err)
+			continue
+		}
+		if !reflect.DeepEqual(limits, tt.limits) {
+			t.Errorf("test %d: limits mismatch: have %v, want %v", i, limits, tt.limits)
+		}
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(map[string]RateLimit{
+		"test_heavy": {Rate: 1, Burst: 2},
+		"*":          {Rate: 10, Burst: 10},
+	})
+	now := time.Unix(0, 0)
+	limiter.now = func() time.Time { return now }
+
+	// The burst is granted at once, then the rate applies
+	for i := 0; i < 2; i++ {
+		if !limiter.Allow("test_heavy", "10.0.0.1:1000") {
+			t.Fatalf("request %d denied within burst", i)
+		}
+	}
+	if limiter.Allow("test_heavy", "10.0.0.1:1001") {
+		t.Fatalf("request allowed beyond burst from another port of the same host")
+	}
+	// Other hosts, other methods and local clients have quotas of their own
+	if !limiter.Allow("test_heavy", "10.0.0.2:1000") {
+		t.Errorf("request of another host denied")
+	}
+	if !limiter.Allow("test_light", "10.0.0.1:1000") {
+		t.Errorf("request of another method denied")
+	}
+	// Methods without a quota of their own share the catch-all bucket of the host
+	for i := 0; i < 9; i++ {
+		if !limiter.Allow("test_other", "10.0.0.1:1000") {
+			t.Fatalf("request %d denied within catch-all burst", i)
+		}
+	}
+	if limiter.Allow("test_third", "10.0.0.1:1000") {
+		t.Errorf("request allowed beyond catch-all burst by switching methods")
+	}
+	if !limiter.Allow("test_heavy", "") {
+		t.Errorf("local request denied")
+	}
+	// Tokens refill over time
+	now = now.Add(500 * time.Millisecond)
+	if limiter.Allow("test_heavy", "10.0.0.1:1000") {
+		t.Errorf("request allowed before a token was refilled")
+	}
+	now = now.Add(500 * time.Millisecond)
+	if !limiter.Allow("test_heavy", "10.0.0.1:1000") {
+		t.Errorf("request denied after a token was refilled")
+	}
+	// Methods without any applicable quota are never limited
+	unlimited := NewRateLimiter(map[string]RateLimit{"test_heavy": {Rate: 1, Burst: 1}})
+	for i := 0; i < 100; i++ {
+		if !unlimited.Allow("test_light", "10.0.0.1:1000") {
+			t.Fatalf("request %d of unlimited method denied", i)
+		}
+	}
+}
+
+func TestServerRateLimit(t *testing.T) {
+	server := NewServer()
+	server.SetRateLimiter(NewRateLimiter(map[string]RateLimit{"test_echo": {Rate: 0.001, Burst: 1}}))
+	if err := server.RegisterName("test", new(Service)); err != nil {
+		t.Fatalf("%v", err)
+	}
+	call := func() string {
+		body := `{"jsonrpc":"2.0","id":1,"method":"test_echo","params":["x",1,{"S":"y"}]}`
+		req := httptest.NewRequest("POST", "http://url.com", strings.NewReader(body))
+		req.Header.Set("content-type", contentType)
+		req.RemoteAddr = "10.0.0.1:1000"
+
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec.Body.String()
+	}
+	if res := call(); !strings.Contains(res, `"result"`) {
+		t.Fatalf("first request failed: %s", res)
+	}
+	if res := call(); !strings.Contains(res, `"code":-32005`) {
+		t.Fatalf("second request not rate limited: %s", res)
+	}
+}
+
+func TestRateLimiterCapacity(t *testing.T) {
+	limiter := newRateLimiter(map[string]RateLimit{"*": {Rate: 1, Burst: 1}}, 2)
+	now := time.Unix(0, 0)
+	limiter.now = func() time.Time { return now }
+
+	for _, remote := range []string{"10.0.0.1:1000", "10.0.0.2:1000", "10.0.0.3:1000"} {
+		if !limiter.Allow("test_echo", remote) {
+			t.Fatalf("first request of %s denied", remote)
+		}
+	}
+	if n := limiter.buckets.Len(); n != 2 {
+		t.Fatalf("bucket count mismatch: have %d, want %d", n, 2)
+	}
+	// The recently used hosts stay limited, the evicted one starts over
+	if limiter.Allow("test_echo", "10.0.0.3:1000") {
+		t.Errorf("request of tracked host allowed beyond burst")
+	}
+	if !limiter.Allow("test_echo", "10.0.0.1:1000") {
+		t.Errorf("request of evicted host denied")
+	}
+}
+
+func TestServerRateLimitUnknownMethod(t *testing.T) {
+	server := NewServer()
+	server.SetRateLimiter(NewRateLimiter(map[string]RateLimit{"*": {Rate: 0.001, Burst: 1}}))
+	if err := server.RegisterName("test", new(Service)); err != nil {
+		t.Fatalf("%v", err)
+	}
+	call := func(method string) string {
+		body := `{"jsonrpc":"2.0","id":1,"method":"` + method + `","params":["x",1,{"S":"y"}]}`
+		req := httptest.NewRequest("POST", "http://url.com", strings.NewReader(body))
+		req.Header.Set("content-type", contentType)
+		req.RemoteAddr = "10.0.0.1:1000"
+
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec.Body.String()
+	}
+	// Made up methods are rejected without touching the quota
+	for i := 0; i < 10; i++ {
+		if res := call(fmt.Sprintf("test_bogus%d", i)); !strings.Contains(res, `"code":-32601`) {
+			t.Fatalf("unknown method %d not rejected as such: %s", i, res)
+		}
+	}
+	if n := server.limiter.buckets.Len(); n != 0 {
+		t.Fatalf("unknown methods created %d buckets", n)
+	}
+	if res := call("test_echo"); !strings.Contains(res, `"result"`) {
+		t.Fatalf("first request failed: %s", res)
+	}
+	if res := call("test_echo"); !strings.Contains(res, `"code":-32005`) {
+		t.Fatalf("second request not rate limited: %s", res)
+	}
+}
@@ -58,6 +58,12 @@ func NewServer() *Server {
 	return server
 }
 
+// SetRateLimiter makes the server enforce the quotas of the given limiter on
+// the requests of remote clients. It must be called before serving requests.
+func (s *Server) SetRateLimiter(limiter *RateLimiter) {
+	s.limiter = limiter
+}
+
 // RPCService gives meta information about the server.
 // e.g. gives information about the loaded modules.
 type RPCService struct {
@@ -160,7 +166,7 @@ func (s *Server) serveRequest(ctx context.Context, codec ServerCodec, singleShot
 
 	// test if the server is ordered to stop
 	for atomic.LoadInt32(&s.run) == 1 {
-		reqs, batch, err := s.readRequest(codec)
+		reqs, batch, err := s.readRequest(ctx, codec)
 		if err != nil {
 			// If a parsing error occurred, send an error
 			if err.Error() != "EOF" {
@@ -215,8 +221,13 @@ func (s *Server) serveRequest(ctx context.Context, codec ServerCodec, singleShot
 // response back using the given codec. It will block until the codec is closed or the server is
 // stopped. In either case the codec is closed.
 func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
+	s.serveCodec(context.Background(), codec, options)
+}
+
+// serveCodec is ServeCodec with a context carrying details about the connection.
+func (s *Server) serveCodec(ctx context.Context, codec ServerCodec, options CodecOption) {
 	defer codec.Close()
-	s.serveRequest(context.Background(), codec, false, options)
+	s.serveRequest(ctx, codec, false, options)
 }
 
 // ServeSingleRequest reads and processes a single RPC request from the given codec. It will not
@@ -374,8 +385,9 @@ func (s *Server) execBatch(ctx context.Context, codec ServerCodec, requests []*s
 
 // readRequest requests the next (batch) request from the codec. It will return the collection
 // of requests, an indication if the request was a batch, the invalid request identifier and an
-// error when the request could not be read/parsed.
-func (s *Server) readRequest(codec ServerCodec) ([]*serverRequest, bool, Error) {
+// error when the request could not be read/parsed. Requests exceeding the rate limit of the
+// remote client are marked as failed.
+func (s *Server) readRequest(ctx context.Context, codec ServerCodec) ([]*serverRequest, bool, Error) {
 	reqs, batch, err := codec.ReadRequestHeaders()
 	if err != nil {
 		return nil, batch, err
@@ -411,6 +423,10 @@ func (s *Server) readRequest(codec ServerCodec) ([]*serverRequest, bool, Error)
 
 		if r.isPubSub { // man_subscribe, r.method contains the subscription method name
 			if callb, ok := svc.subscriptions[r.method]; ok {
+				if err := s.checkRateLimit(ctx, r.service+subscribeMethodSuffix); err != nil {
+					requests[i] = &serverRequest{id: r.id, err: err}
+					continue
+				}
 				requests[i] = &serverRequest{id: r.id, svcname: svc.name, callb: callb}
 				if r.params != nil && len(callb.argTypes) > 0 {
 					argTypes := []reflect.Type{reflect.TypeOf("")}
@@ -428,6 +444,10 @@ func (s *Server) readRequest(codec ServerCodec) ([]*serverRequest, bool, Error)
 		}
 
 		if callb, ok := svc.callbacks[r.method]; ok { // lookup RPC method
+			if err := s.checkRateLimit(ctx, r.service+serviceMethodSeparator+r.method); err != nil {
+				requests[i] = &serverRequest{id: r.id, err: err}
+				continue
+			}
 			requests[i] = &serverRequest{id: r.id, svcname: svc.name, callb: callb}
 			if r.params != nil && len(callb.argTypes) > 0 {
 				if args, err := codec.ParseRequestArguments(callb.argTypes, r.params); err == nil {
@@ -444,3 +464,17 @@ func (s *Server) readRequest(codec ServerCodec) ([]*serverRequest, bool, Error)
 
 	return requests, batch, nil
 }
+
+// checkRateLimit consumes a token of the calling client's quota for the given
+// method, returning an error if the quota is exhausted. It must only be called
+// for methods the server actually provides, so that made up method names can't
+// be used to create buckets.
+func (s *Server) checkRateLimit(ctx context.Context, method string) Error {
+	if s.limiter == nil {
+		return nil
+	}
+	if remote, _ := ctx.Value("remote").(string); !s.limiter.Allow(method, remote) {
+		return &rateLimitError{method}
+	}
+	return nil
+}
@@ -70,6 +70,7 @@ type subscriptions map[string]*callback  // collection of subscription callbacks
 // Server represents a RPC server
 type Server struct {
 	services serviceRegistry
+	limiter  *RateLimiter
 
 	run      int32
 	codecsMu sync.Mutex
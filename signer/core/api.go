@@ -52,8 +52,15 @@ type ExternalAPI interface {
 	Export(ctx context.Context, addr common.Address) (json.RawMessage, error)
 	// Import - request to import an account
 	Import(ctx context.Context, keyJSON json.RawMessage) (Account, error)
+	// SignHash - request to sign the given hash (no prefix)
+	SignHash(ctx context.Context, addr common.MixedcaseAddress, hash hexutil.Bytes, validate *bool) (hexutil.Bytes, error)
+	// Version - returns the version of the external API
+	Version(ctx context.Context) (string, error)
 }
 
+// ExternalAPIVersion -- see extapi_changelog.md
+const ExternalAPIVersion = "2.1.0"
+
 // SignerUI specifies what method a UI needs to implement to be able to be used as a UI for the signer
 type SignerUI interface {
 	// ApproveTx prompt the user for confirmation to request to sign Transaction
@@ -413,6 +420,44 @@ func (api *SignerAPI) Sign(ctx context.Context, addr common.MixedcaseAddress, da
 	return signature, nil
 }
 
+// SignHash calculates a raw ECDSA signature of the given 32 byte hash, as used
+// by the node for transactions and consensus messages. Unless validate is set
+// to false, the signature is a plain secp256k1 one with a V value of 0 or 1;
+// otherwise it is the Matrix non-validating signature with V of 2 or 3.
+//
+// Since the hash can not be shown to the user in any meaningful form, the UI
+// should only approve such requests from trusted nodes.
+func (api *SignerAPI) SignHash(ctx context.Context, addr common.MixedcaseAddress, hash hexutil.Bytes, validate *bool) (hexutil.Bytes, error) {
+	if len(hash) != common.HashLength {
+		return nil, fmt.Errorf("hash must be %d bytes long", common.HashLength)
+	}
+	// As with Sign, approve first to prevent account-enumeration via the API
+	req := &SignDataRequest{Address: addr, Rawdata: hash, Hash: hash, Meta: MetadataFromContext(ctx)}
+	res, err := api.UI.ApproveSignData(req)
+	if err != nil {
+		return nil, err
+	}
+	if !res.Approved {
+		return nil, ErrRequestDenied
+	}
+	account := accounts.Account{Address: addr.Address()}
+	wallet, err := api.am.Find(account)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := wallet.SignHashValidateWithPass(account, res.Password, hash, validate == nil || *validate)
+	if err != nil {
+		api.UI.ShowError(err.Error())
+		return nil, err
+	}
+	return signature, nil
+}
+
+// Version returns the version of the external API served.
+func (api *SignerAPI) Version(ctx context.Context) (string, error) {
+	return ExternalAPIVersion, nil
+}
+
 // EcRecover returns the address for the Account that was used to create the signature.
 // Note, this function is compatible with man_sign and personal_sign. As such it recovers
 // the address of:
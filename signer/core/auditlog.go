@@ -98,6 +98,18 @@ func (l *AuditLogger) Import(ctx context.Context, keyJSON json.RawMessage) (Acco
 	return a, e
 }
 
+func (l *AuditLogger) SignHash(ctx context.Context, addr common.MixedcaseAddress, hash hexutil.Bytes, validate *bool) (hexutil.Bytes, error) {
+	l.log.Info("SignHash", "type", "request", "metadata", MetadataFromContext(ctx).String(),
+		"addr", addr.String(), "hash", common.Bytes2Hex(hash))
+	b, e := l.api.SignHash(ctx, addr, hash, validate)
+	l.log.Info("SignHash", "type", "response", "data", common.Bytes2Hex(b), "error", e)
+	return b, e
+}
+
+func (l *AuditLogger) Version(ctx context.Context) (string, error) {
+	return l.api.Version(ctx)
+}
+
 func NewAuditLogger(path string, api ExternalAPI) (*AuditLogger, error) {
 	l := log.New("api", "signer")
 	handler, err := log.FileHandler(path, log.LogfmtFormat())
@@ -0,0 +1,92 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package trie
+
+import (
+	"math"
+	"sync"
+
+	"github.com/hashicorp/golang-lru/simplelru"
+	"github.com/matrix/go-matrix/common"
+)
+
+// cleanCache is an LRU cache of trie nodes already persisted to disk, bounded
+// by the total byte size of the cached entries rather than their count.
+type cleanCache struct {
+	lru   *simplelru.LRU
+	size  common.StorageSize // Storage size of the cached keys and blobs
+	limit common.StorageSize // Maximum storage size before evicting old entries
+	lock  sync.Mutex         // The LRU isn't thread safe and is read under the database read lock
+}
+
+// newCleanCache creates a clean node cache holding at most limit bytes.
+func newCleanCache(limit common.StorageSize) *cleanCache {
+	c := &cleanCache{limit: limit}
+	c.lru, _ = simplelru.NewLRU(math.MaxInt32, func(key interface{}, value interface{}) {
+		c.size -= common.StorageSize(common.HashLength + len(value.([]byte)))
+	})
+	return c
+}
+
+// get retrieves a cached node blob, marking it as recently used.
+func (c *cleanCache) get(hash common.Hash) ([]byte, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if blob, ok := c.lru.Get(hash); ok {
+		return blob.([]byte), true
+	}
+	return nil, false
+}
+
+// add inserts a node blob into the cache, evicting the least recently used
+// entries until the cache fits into its allowance again. The blob must not be
+// modified afterwards.
+func (c *cleanCache) add(hash common.Hash, blob []byte) {
+	size := common.StorageSize(common.HashLength + len(blob))
+	if size > c.limit {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.lru.Contains(hash) {
+		return
+	}
+	c.lru.Add(hash, blob)
+	c.size += size
+
+	for c.size > c.limit {
+		c.lru.RemoveOldest()
+	}
+}
+
+// remove drops a node from the cache if it's present.
+func (c *cleanCache) remove(hash common.Hash) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.lru.Remove(hash)
+}
+
+// stats returns the number of cached nodes and their total storage size.
+func (c *cleanCache) stats() (int, common.StorageSize) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.lru.Len(), c.size
+}
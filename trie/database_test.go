@@ -0,0 +1,167 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package trie
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/mandb"
+)
+
+// makeDirtyTrie creates a trie with the given number of items, leaving all of
+// its nodes dirty in the trie database.
+func makeDirtyTrie(t *testing.T, triedb *Database, items int) common.Hash {
+	trie, _ := New(common.Hash{}, triedb)
+	for i := 0; i < items; i++ {
+		trie.Update([]byte(fmt.Sprintf("key-%d", i)), []byte(fmt.Sprintf("value-%d", i)))
+	}
+	root, err := trie.Commit(nil)
+	if err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	triedb.Reference(root, common.Hash{})
+	return root
+}
+
+// checkDirtyTrieContents verifies that the trie rooted at root contains all the
+// items created by makeDirtyTrie.
+func checkDirtyTrieContents(t *testing.T, triedb *Database, root common.Hash, items int) {
+	trie, err := New(root, triedb)
+	if err != nil {
+		t.Fatalf("failed to open trie: %v", err)
+	}
+	for i := 0; i < items; i++ {
+		want := []byte(fmt.Sprintf("value-%d", i))
+		if have, err := trie.TryGet([]byte(fmt.Sprintf("key-%d", i))); err != nil || !bytes.Equal(have, want) {
+			t.Fatalf("item %d: value mismatch: have %q (err %v), want %q", i, have, err, want)
+		}
+	}
+}
+
+// Tests that capping the dirty cache flushes the oldest nodes to disk until the
+// cache fits into the allowance, without losing any data.
+func TestDatabaseCap(t *testing.T) {
+	diskdb := mandb.NewMemDatabase()
+	triedb := NewDatabase(diskdb)
+
+	root := makeDirtyTrie(t, triedb, 500)
+	size := triedb.Size()
+
+	// Flush half of the nodes and check the trie is still complete
+	if err := triedb.Cap(size / 2); err != nil {
+		t.Fatalf("failed to cap database: %v", err)
+	}
+	if have := triedb.Size(); have > size/2 {
+		t.Errorf("dirty size above allowance: have %v, want <= %v", have, size/2)
+	}
+	if diskdb.Len() == 0 {
+		t.Errorf("no nodes flushed to disk")
+	}
+	checkDirtyTrieContents(t, triedb, root, 500)
+
+	// Flush everything and check the trie can be loaded from disk alone
+	if err := triedb.Cap(0); err != nil {
+		t.Fatalf("failed to cap database: %v", err)
+	}
+	if nodes := triedb.Nodes(); len(nodes) != 0 {
+		t.Errorf("dirty nodes left after full flush: %d", len(nodes))
+	}
+	if size := triedb.Size(); size != 0 {
+		t.Errorf("dirty size mismatch after full flush: have %v, want 0", size)
+	}
+	checkDirtyTrieContents(t, NewDatabase(diskdb), root, 500)
+
+	// Dereferencing the flushed root must not touch the disk data
+	triedb.Dereference(root, common.Hash{})
+	checkDirtyTrieContents(t, NewDatabase(diskdb), root, 500)
+}
+
+// Tests that dereferencing a trie keeps the flush-list consistent, so that
+// later caps only flush the surviving nodes.
+func TestDatabaseCapAfterDereference(t *testing.T) {
+	diskdb := mandb.NewMemDatabase()
+	triedb := NewDatabase(diskdb)
+
+	stale := makeDirtyTrie(t, triedb, 100)
+	trie, _ := New(stale, triedb)
+	trie.Update([]byte("key-100"), []byte("value-100"))
+	live, _ := trie.Commit(nil)
+	triedb.Reference(live, common.Hash{})
+	triedb.Dereference(stale, common.Hash{})
+
+	if err := triedb.Cap(0); err != nil {
+		t.Fatalf("failed to cap database: %v", err)
+	}
+	checkDirtyTrieContents(t, NewDatabase(diskdb), live, 101)
+	if has, _ := diskdb.Has(stale[:]); has {
+		t.Errorf("dereferenced root flushed to disk")
+	}
+}
+
+// Tests that nodes loaded from disk or flushed to it are served from the clean
+// cache afterwards.
+func TestDatabaseCleanCache(t *testing.T) {
+	diskdb := mandb.NewMemDatabase()
+	triedb := NewDatabaseWithCache(diskdb, 1)
+
+	root := makeDirtyTrie(t, triedb, 100)
+	if err := triedb.Commit(root, false); err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	nodes, _ := triedb.CleanSize()
+	if nodes == 0 {
+		t.Fatalf("committed nodes not moved to the clean cache")
+	}
+	// Drop the nodes from disk, the trie must still be readable from the cache
+	for _, key := range diskdb.Keys() {
+		diskdb.Delete(key)
+	}
+	checkDirtyTrieContents(t, triedb, root, 100)
+}
+
+// Tests that the clean cache evicts the least recently used entries once it
+// outgrows its byte allowance.
+func TestCleanCacheLimit(t *testing.T) {
+	entry := common.StorageSize(common.HashLength + 10)
+	cache := newCleanCache(3 * entry)
+
+	for i := byte(0); i < 3; i++ {
+		cache.add(common.Hash{i}, make([]byte, 10))
+	}
+	cache.get(common.Hash{0}) // Make the first entry the most recently used
+	cache.add(common.Hash{3}, make([]byte, 10))
+
+	if nodes, size := cache.stats(); nodes != 3 || size != 3*entry {
+		t.Errorf("cache stats mismatch: have %d/%v, want 3/%v", nodes, size, 3*entry)
+	}
+	if _, ok := cache.get(common.Hash{1}); ok {
+		t.Errorf("least recently used entry not evicted")
+	}
+	for _, i := range []byte{0, 2, 3} {
+		if _, ok := cache.get(common.Hash{i}); !ok {
+			t.Errorf("entry %d evicted", i)
+		}
+	}
+	// Entries larger than the whole cache are not stored at all
+	cache.add(common.Hash{4}, make([]byte, 200))
+	if _, ok := cache.get(common.Hash{4}); ok {
+		t.Errorf("oversized entry cached")
+	}
+}
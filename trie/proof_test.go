@@ -20,6 +20,7 @@ import (
 	"bytes"
 	crand "crypto/rand"
 	mrand "math/rand"
+	"sort"
 	"testing"
 	"time"
 
@@ -144,6 +145,147 @@ func TestMissingKeyProof(t *testing.T) {
 }
 
 // mutateByte changes one byte in b.
+// sortedEntries returns the entries of a random trie ordered by key.
+func sortedEntries(vals map[string]*kv) []*kv {
+	entries := make([]*kv, 0, len(vals))
+	for _, kv := range vals {
+		entries = append(entries, kv)
+	}
+	sort.Slice(entries, func(i, j int) bool { return bytes.Compare(entries[i].k, entries[j].k) < 0 })
+	return entries
+}
+
+// proveRange collects the edge proofs of the given keys into a single proof.
+func proveRange(t *testing.T, trie *Trie, first, last []byte) *mandb.MemDatabase {
+	proof := mandb.NewMemDatabase()
+	if err := trie.Prove(first, 0, proof); err != nil {
+		t.Fatalf("failed to prove first key %x: %v", first, err)
+	}
+	if err := trie.Prove(last, 0, proof); err != nil {
+		t.Fatalf("failed to prove last key %x: %v", last, err)
+	}
+	return proof
+}
+
+// rangeData splits the entries into their keys and values.
+func rangeData(entries []*kv) ([][]byte, [][]byte) {
+	var keys, vals [][]byte
+	for _, kv := range entries {
+		keys = append(keys, kv.k)
+		vals = append(vals, kv.v)
+	}
+	return keys, vals
+}
+
+// Tests that random ranges of a trie are proven by their edge proofs, whether
+// the edge keys exist or not.
+func TestRangeProof(t *testing.T) {
+	trie, vals := randomTrie(4096)
+	entries := sortedEntries(vals)
+
+	for i := 0; i < 500; i++ {
+		start := mrand.Intn(len(entries))
+		end := mrand.Intn(len(entries)-start) + start + 1
+
+		keys, values := rangeData(entries[start:end])
+		first, last := keys[0], keys[len(keys)-1]
+		if start > 0 && mrand.Intn(2) == 0 {
+			// Prove the absence of a key right before the range instead
+			first = increaseKey(common.CopyBytes(entries[start-1].k))
+		}
+		more, err := VerifyRangeProof(trie.Hash(), first, last, keys, values, proveRange(t, trie, first, last))
+		if err != nil {
+			t.Fatalf("case %d(%d->%d): failed to verify range: %v", i, start, end-1, err)
+		}
+		if more != (end < len(entries)) {
+			t.Fatalf("case %d(%d->%d): more entries mismatch: have %v, want %v", i, start, end-1, more, end < len(entries))
+		}
+	}
+}
+
+// Tests that ranges with tampered, missing or extra leaves are rejected.
+func TestBadRangeProof(t *testing.T) {
+	trie, vals := randomTrie(4096)
+	entries := sortedEntries(vals)
+
+	for i := 0; i < 500; i++ {
+		start := mrand.Intn(len(entries) - 2)
+		end := mrand.Intn(len(entries)-start-2) + start + 3
+
+		keys, values := rangeData(entries[start:end])
+		first, last := keys[0], keys[len(keys)-1]
+		proof := proveRange(t, trie, first, last)
+
+		index := mrand.Intn(len(keys))
+		switch mrand.Intn(3) {
+		case 0: // Modified value
+			values[index] = randBytes(20)
+		case 1: // Missing inner leaf
+			if index == 0 || index == len(keys)-1 {
+				index = 1
+			}
+			keys = append(keys[:index], keys[index+1:]...)
+			values = append(values[:index], values[index+1:]...)
+		case 2: // Leaf beyond the edge
+			keys = append(keys, increaseKey(common.CopyBytes(last)))
+			values = append(values, randBytes(20))
+		}
+		if _, err := VerifyRangeProof(trie.Hash(), first, last, keys, values, proof); err == nil {
+			t.Fatalf("case %d(%d->%d): bad range proof accepted", i, start, end-1)
+		}
+	}
+}
+
+// Tests the special cases of proving a single leaf, all leaves and no leaves.
+func TestRangeProofSpecialCases(t *testing.T) {
+	trie, vals := randomTrie(4096)
+	entries := sortedEntries(vals)
+	root := trie.Hash()
+
+	// A single leaf proven by itself
+	entry := entries[len(entries)/2]
+	more, err := VerifyRangeProof(root, entry.k, entry.k, [][]byte{entry.k}, [][]byte{entry.v}, proveRange(t, trie, entry.k, entry.k))
+	if err != nil || !more {
+		t.Fatalf("single leaf: have more %v, err %v", more, err)
+	}
+	if _, err := VerifyRangeProof(root, entry.k, entry.k, [][]byte{entry.k}, [][]byte{randBytes(20)}, proveRange(t, trie, entry.k, entry.k)); err == nil {
+		t.Fatalf("single leaf with bad value accepted")
+	}
+	// All the leaves without any proof
+	keys, values := rangeData(entries)
+	if more, err := VerifyRangeProof(root, nil, nil, keys, values, nil); err != nil || more {
+		t.Fatalf("all leaves: have more %v, err %v", more, err)
+	}
+	if _, err := VerifyRangeProof(root, nil, nil, keys[1:], values[1:], nil); err == nil {
+		t.Fatalf("incomplete leaves accepted without proof")
+	}
+	// No leaves after the last one
+	last := increaseKey(common.CopyBytes(entries[len(entries)-1].k))
+	proof := mandb.NewMemDatabase()
+	trie.Prove(last, 0, proof)
+	if more, err := VerifyRangeProof(root, last, last, nil, nil, proof); err != nil || more {
+		t.Fatalf("empty tail: have more %v, err %v", more, err)
+	}
+	// No leaves claimed where there are some
+	first := entries[len(entries)/2].k
+	proof = mandb.NewMemDatabase()
+	trie.Prove(first, 0, proof)
+	if _, err := VerifyRangeProof(root, first, first, nil, nil, proof); err == nil {
+		t.Fatalf("empty range accepted despite existing leaves")
+	}
+}
+
+// increaseKey returns the key right after the given one.
+func increaseKey(key []byte) []byte {
+	for i := len(key) - 1; i >= 0; i-- {
+		key[i]++
+		if key[i] != 0x0 {
+			break
+		}
+	}
+	return key
+}
+
 func mutateByte(b []byte) {
 	for r := mrand.Intn(len(b)); ; {
 		new := byte(mrand.Intn(255))
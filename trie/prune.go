@@ -0,0 +1,320 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package trie
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/crypto"
+	"github.com/matrix/go-matrix/log"
+	"github.com/matrix/go-matrix/mandb"
+)
+
+const (
+	// pruneBatchSize is the number of unreachable nodes deleted at once, holding
+	// the database lock. It is also the number of marks buffered in memory before
+	// being written to disk.
+	pruneBatchSize = 10000
+
+	// pruneBloomSize is the size in bytes of the bloom filter fronting the marks
+	// of the live nodes kept on disk.
+	pruneBloomSize = 16 * 1024 * 1024
+)
+
+// pruneMarkPrefix is the disk database prefix of the marks of live nodes,
+// recorded while a prune is running.
+var pruneMarkPrefix = []byte("prune-mark-")
+
+var (
+	// ErrPruneAborted is returned by Prune if it was interrupted.
+	ErrPruneAborted = errors.New("prune aborted")
+
+	// errPruneUnsupported is returned by Prune if the disk database cannot
+	// enumerate its content.
+	errPruneUnsupported = errors.New("disk database does not support iteration")
+
+	// errPruneRunning is returned by Prune if another prune is in progress.
+	errPruneRunning = errors.New("prune already running")
+)
+
+// MarkSet is the set of live node hashes collected by the mark phase of Prune.
+// Marks are stored in the disk database so memory use does not grow with the
+// size of the state; a bloom filter answers most lookups of unmarked hashes
+// without touching the disk.
+type MarkSet struct {
+	diskdb  mandb.Database
+	bloom   []uint64
+	pending map[common.Hash]struct{} // Marks not yet written to disk
+	batch   mandb.Batch
+	count   int
+}
+
+// newMarkSet creates an empty mark set storing its marks in diskdb.
+func newMarkSet(diskdb mandb.Database) *MarkSet {
+	return &MarkSet{
+		diskdb:  diskdb,
+		bloom:   make([]uint64, pruneBloomSize/8),
+		pending: make(map[common.Hash]struct{}),
+		batch:   diskdb.NewBatch(),
+	}
+}
+
+// bloomBits returns the filter bit positions of a hash. Node hashes are already
+// uniformly distributed, so their 8 byte words are used as the bloom hashes.
+func (s *MarkSet) bloomBits(hash common.Hash) [4]uint64 {
+	var (
+		bits [4]uint64
+		size = uint64(len(s.bloom)) * 64
+	)
+	for i := range bits {
+		bits[i] = binary.BigEndian.Uint64(hash[i*8:]) % size
+	}
+	return bits
+}
+
+// Add marks the node with the given hash as live.
+func (s *MarkSet) Add(hash common.Hash) error {
+	for _, bit := range s.bloomBits(hash) {
+		s.bloom[bit/64] |= 1 << (bit % 64)
+	}
+	if _, ok := s.pending[hash]; ok {
+		return nil
+	}
+	if err := s.batch.Put(append(common.CopyBytes(pruneMarkPrefix), hash[:]...), []byte{1}); err != nil {
+		return err
+	}
+	s.pending[hash] = struct{}{}
+	s.count++
+
+	if len(s.pending) >= pruneBatchSize {
+		return s.flush()
+	}
+	return nil
+}
+
+// Contains reports whether the node with the given hash was marked live.
+func (s *MarkSet) Contains(hash common.Hash) (bool, error) {
+	for _, bit := range s.bloomBits(hash) {
+		if s.bloom[bit/64]&(1<<(bit%64)) == 0 {
+			return false, nil
+		}
+	}
+	if _, ok := s.pending[hash]; ok {
+		return true, nil
+	}
+	return s.diskdb.Has(append(common.CopyBytes(pruneMarkPrefix), hash[:]...))
+}
+
+// Len returns the number of marked nodes.
+func (s *MarkSet) Len() int {
+	return s.count
+}
+
+// flush writes the buffered marks to disk.
+func (s *MarkSet) flush() error {
+	if err := s.batch.Write(); err != nil {
+		return err
+	}
+	s.batch.Reset()
+	s.pending = make(map[common.Hash]struct{})
+	return nil
+}
+
+// clearMarks deletes all the marks of live nodes from disk, including those
+// left behind by an interrupted prune.
+func clearMarks(diskdb mandb.Database) error {
+	var (
+		batch = diskdb.NewBatch()
+		err   error
+	)
+	iterErr := forEachEntry(diskdb, pruneMarkPrefix, func(key, value []byte) bool {
+		if err = batch.Delete(common.CopyBytes(key)); err != nil {
+			return false
+		}
+		if batch.ValueSize() >= mandb.IdealBatchSize {
+			if err = batch.Write(); err != nil {
+				return false
+			}
+			batch.Reset()
+		}
+		return true
+	})
+	if iterErr != nil {
+		return iterErr
+	}
+	if err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// Prune garbage collects the trie nodes persisted on disk which aren't needed
+// any more. The mark callback is expected to add to keep the hashes of all the
+// nodes (and contract codes) reachable from the state roots that should be
+// retained; every other trie node of the disk database is deleted. Only entries
+// keyed by the hash of their content are considered trie nodes, any other data
+// is left untouched.
+//
+// Pruning runs concurrently with the chain importing blocks: nodes flushed to
+// disk after Prune was called and nodes still held in memory are never deleted,
+// even if the mark phase did not reach them.
+func (db *Database) Prune(mark func(keep *MarkSet) error, abort <-chan struct{}) (int, error) {
+	db.flushedLock.Lock()
+	if db.flushed != nil {
+		db.flushedLock.Unlock()
+		return 0, errPruneRunning
+	}
+	db.flushed = make(map[common.Hash]struct{})
+	db.flushedLock.Unlock()
+
+	defer func() {
+		db.flushedLock.Lock()
+		db.flushed = nil
+		db.flushedLock.Unlock()
+	}()
+	// Mark all the live nodes, then sweep anything else
+	start := time.Now()
+
+	if err := clearMarks(db.diskdb); err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err := clearMarks(db.diskdb); err != nil {
+			log.Warn("Failed to delete live trie node marks", "err", err)
+		}
+	}()
+	keep := newMarkSet(db.diskdb)
+	if err := mark(keep); err != nil {
+		return 0, err
+	}
+	if err := keep.flush(); err != nil {
+		return 0, err
+	}
+	log.Debug("Marked live trie nodes", "nodes", keep.Len(), "elapsed", common.PrettyDuration(time.Since(start)))
+
+	var (
+		deleted int
+		pending []common.Hash
+		err     error
+	)
+	iterErr := forEachEntry(db.diskdb, nil, func(key, value []byte) bool {
+		if len(key) != common.HashLength {
+			return true
+		}
+		hash := common.BytesToHash(key)
+		if crypto.Keccak256Hash(value) != hash {
+			return true
+		}
+		var live bool
+		if live, err = keep.Contains(hash); err != nil {
+			return false
+		}
+		if live {
+			return true
+		}
+		if pending = append(pending, hash); len(pending) < pruneBatchSize {
+			return true
+		}
+		select {
+		case <-abort:
+			err = ErrPruneAborted
+			return false
+		default:
+		}
+		var n int
+		if n, err = db.deleteNodes(pending); err != nil {
+			return false
+		}
+		deleted, pending = deleted+n, pending[:0]
+		return true
+	})
+	if iterErr != nil {
+		return deleted, iterErr
+	}
+	if err != nil {
+		return deleted, err
+	}
+	n, err := db.deleteNodes(pending)
+	return deleted + n, err
+}
+
+// deleteNodes removes the given nodes from disk, unless they are in use by the
+// memory cache or were flushed since the prune started.
+func (db *Database) deleteNodes(hashes []common.Hash) (int, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.flushedLock.Lock()
+	defer db.flushedLock.Unlock()
+
+	batch := db.diskdb.NewBatch()
+	deleted := 0
+	for _, hash := range hashes {
+		if _, ok := db.nodes[hash]; ok {
+			continue
+		}
+		if _, ok := db.flushed[hash]; ok {
+			continue
+		}
+		if err := batch.Delete(hash[:]); err != nil {
+			return deleted, err
+		}
+		if db.cleans != nil {
+			db.cleans.remove(hash)
+		}
+		deleted++
+	}
+	return deleted, batch.Write()
+}
+
+// forEachEntry iterates over the entries of the disk database whose key starts
+// with prefix, until the callback returns false. Persistent stores are walked
+// through their iterator, which any wrapper (ancient freezer, shards) is expected
+// to forward.
+func forEachEntry(diskdb mandb.Database, prefix []byte, fn func(key, value []byte) bool) error {
+	switch db := diskdb.(type) {
+	case mandb.KeyValueStore:
+		it := db.Iterate(prefix)
+		defer it.Release()
+
+		for it.Next() {
+			if !fn(it.Key(), it.Value()) {
+				break
+			}
+		}
+		return it.Error()
+
+	case interface{ Keys() [][]byte }:
+		for _, key := range db.Keys() {
+			if !bytes.HasPrefix(key, prefix) {
+				continue
+			}
+			value, err := diskdb.Get(key)
+			if err != nil {
+				continue // Deleted since listing the keys
+			}
+			if !fn(key, value) {
+				break
+			}
+		}
+		return nil
+	}
+	return errPruneUnsupported
+}
@@ -0,0 +1,164 @@
+// Copyright 2018 The MATRIX Authors as well as Copyright 2014-2017 The go-ethereum Authors
+// This file is consisted of the MATRIX library and part of the go-ethereum library.
+//
+// The MATRIX-ethereum library is free software: you can redistribute it and/or modify it under the terms of the MIT License.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, 
+//and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject tothe following conditions:
+//
+//The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, 
+//WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISINGFROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+//OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package trie
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/matrix/go-matrix/common"
+	"github.com/matrix/go-matrix/mandb"
+)
+
+// makePrunableTrie creates a trie with the given number of items and flushes it
+// to disk, returning its root hash.
+func makePrunableTrie(t *testing.T, triedb *Database, prefix string, items int) common.Hash {
+	trie, _ := New(common.Hash{}, triedb)
+	for i := 0; i < items; i++ {
+		trie.Update([]byte(fmt.Sprintf("%s-key-%d", prefix, i)), []byte(fmt.Sprintf("%s-value-%d", prefix, i)))
+	}
+	root, err := trie.Commit(nil)
+	if err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	if err := triedb.Commit(root, false); err != nil {
+		t.Fatalf("failed to flush trie: %v", err)
+	}
+	return root
+}
+
+// markTrieNodes marks all the hashed nodes of the trie rooted at root.
+func markTrieNodes(triedb *Database, root common.Hash, keep *MarkSet) error {
+	trie, err := New(root, triedb)
+	if err != nil {
+		return err
+	}
+	it := trie.NodeIterator(nil)
+	for it.Next(true) {
+		if hash := it.Hash(); hash != (common.Hash{}) {
+			if err := keep.Add(hash); err != nil {
+				return err
+			}
+		}
+	}
+	return it.Error()
+}
+
+// Tests that pruning deletes the unreachable nodes from disk, but retains the
+// marked ones, non-trie data and anything still cached in memory.
+func TestPrune(t *testing.T) {
+	diskdb := mandb.NewMemDatabase()
+	triedb := NewDatabase(diskdb)
+
+	stale := makePrunableTrie(t, triedb, "stale", 100)
+	live := makePrunableTrie(t, triedb, "live", 100)
+	diskdb.Put([]byte("LastBlock"), []byte("metadata"))
+	diskdb.Put(common.HexToHash("0xdeadbeef").Bytes(), []byte("hash keyed metadata"))
+
+	// Create a third trie that is only held in memory
+	trie, _ := New(common.Hash{}, triedb)
+	trie.Update([]byte("cached"), []byte("value"))
+	cached, _ := trie.Commit(nil)
+	triedb.Insert(common.HexToHash("0x01"), []byte("dirty"))
+
+	deleted, err := triedb.Prune(func(keep *MarkSet) error {
+		return markTrieNodes(triedb, live, keep)
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to prune: %v", err)
+	}
+	if deleted == 0 {
+		t.Fatalf("no nodes pruned")
+	}
+	if _, err := New(stale, triedb); err == nil {
+		t.Errorf("stale trie root still present after prune")
+	}
+	if err := checkTrieConsistency(triedb, live); err != nil {
+		t.Errorf("live trie damaged by prune: %v", err)
+	}
+	if _, err := New(cached, triedb); err != nil {
+		t.Errorf("cached trie damaged by prune: %v", err)
+	}
+	if ok, _ := diskdb.Has([]byte("LastBlock")); !ok {
+		t.Errorf("non-trie data deleted by prune")
+	}
+	if ok, _ := diskdb.Has(common.HexToHash("0xdeadbeef").Bytes()); !ok {
+		t.Errorf("hash keyed non-trie data deleted by prune")
+	}
+	for _, key := range diskdb.Keys() {
+		if bytes.HasPrefix(key, pruneMarkPrefix) {
+			t.Fatalf("live node mark left behind: %x", key)
+		}
+	}
+	// Ensure a second prune is idempotent
+	if deleted, err := triedb.Prune(func(keep *MarkSet) error {
+		return markTrieNodes(triedb, live, keep)
+	}, nil); err != nil || deleted != 0 {
+		t.Errorf("repeated prune mismatch: have %d/%v, want 0/nil", deleted, err)
+	}
+}
+
+// Tests that nodes flushed to disk while the mark phase is running are not
+// deleted, even if they were not marked.
+func TestPruneConcurrentFlush(t *testing.T) {
+	diskdb := mandb.NewMemDatabase()
+	triedb := NewDatabase(diskdb)
+
+	var fresh common.Hash
+	if _, err := triedb.Prune(func(keep *MarkSet) error {
+		fresh = makePrunableTrie(t, triedb, "fresh", 100)
+		return nil
+	}, nil); err != nil {
+		t.Fatalf("failed to prune: %v", err)
+	}
+	if err := checkTrieConsistency(triedb, fresh); err != nil {
+		t.Errorf("freshly flushed trie damaged by prune: %v", err)
+	}
+}
+
+// Tests that the mark set remembers every added hash, both while buffered in
+// memory and once written to disk.
+func TestMarkSet(t *testing.T) {
+	diskdb := mandb.NewMemDatabase()
+	marks := newMarkSet(diskdb)
+
+	items := pruneBatchSize + pruneBatchSize/2
+	for i := 0; i < items; i++ {
+		if err := marks.Add(common.BigToHash(big.NewInt(int64(2 * i)))); err != nil {
+			t.Fatalf("failed to add mark %d: %v", i, err)
+		}
+	}
+	if marks.Len() != items {
+		t.Errorf("mark count mismatch: have %d, want %d", marks.Len(), items)
+	}
+	for i := 0; i < 2*items; i++ {
+		have, err := marks.Contains(common.BigToHash(big.NewInt(int64(i))))
+		if err != nil {
+			t.Fatalf("failed to look up mark %d: %v", i, err)
+		}
+		if want := i%2 == 0; have != want {
+			t.Errorf("mark %d: presence mismatch: have %v, want %v", i, have, want)
+		}
+	}
+	if err := clearMarks(diskdb); err != nil {
+		t.Fatalf("failed to clear marks: %v", err)
+	}
+	if diskdb.Len() != 0 {
+		t.Errorf("marks left on disk after clearing: %d", diskdb.Len())
+	}
+}